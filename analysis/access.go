@@ -0,0 +1,280 @@
+// access.go classifies the tables a statement reads and the relations it writes.
+package analysis
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+)
+
+// WriteKind is the way a statement modifies a relation.
+type WriteKind string
+
+const (
+	WriteInsert   WriteKind = "INSERT"
+	WriteUpdate   WriteKind = "UPDATE"
+	WriteDelete   WriteKind = "DELETE"
+	WriteTruncate WriteKind = "TRUNCATE"
+	// WriteSequence means a sequence is advanced or set by nextval or setval.
+	WriteSequence WriteKind = "SEQUENCE"
+)
+
+// TableWrite is a relation a statement modifies.
+type TableWrite struct {
+	Table   postgresparser.TableRef // Relation written; for a sequence named by an expression rather than a literal, Name is "" and Raw holds the expression
+	Kind    WriteKind
+	Columns []string // Columns assigned by INSERT or UPDATE; nil for an INSERT without a column list and for other kinds
+}
+
+// TableAccess lists the tables a statement reads and the relations it writes.
+type TableAccess struct {
+	Command postgresparser.QueryCommand
+	Reads   []postgresparser.TableRef      // Tables and views read, once each and without alias
+	Writes  []TableWrite                   // Relations written, once per relation and kind
+	Locking []postgresparser.LockingClause // FOR UPDATE and FOR SHARE clauses, which lock the rows they read
+}
+
+// ReadOnly reports whether the statement is a query that writes nothing and takes no
+// row locks. DDL and statements the parser does not model are never read-only.
+func (a TableAccess) ReadOnly() bool {
+	return a.Command == postgresparser.QueryCommandSelect && len(a.Writes) == 0 && len(a.Locking) == 0
+}
+
+// Access parses a statement and returns the tables it reads and writes. See ExtractAccess.
+func Access(query string) (TableAccess, error) {
+	pq, err := postgresparser.ParseSQL(query)
+	if err != nil {
+		return TableAccess{}, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return ExtractAccess(pq), nil
+}
+
+// ExtractAccess returns the tables a statement reads and the relations it writes,
+// looking past its Command: data-modifying CTEs write their targets, SELECT INTO and
+// CREATE TABLE AS insert into a new table, ON CONFLICT DO UPDATE and MERGE actions
+// update, TRUNCATE empties its tables, and nextval and setval advance sequences.
+//
+// Reads come from the relations of every query level, including subqueries and CTE
+// bodies. The target of an INSERT is read only by an ON CONFLICT clause; the targets
+// of UPDATE, DELETE, and MERGE are always read. References to CTEs and set-returning
+// functions are not tables. Names are as written; see QualifyQuery to add schemas.
+func ExtractAccess(pq *postgresparser.ParsedQuery) TableAccess {
+	if pq == nil {
+		return TableAccess{Command: postgresparser.QueryCommandUnknown}
+	}
+	var b accessBuilder
+	b.statement(pq)
+	return TableAccess{Command: pq.Command, Reads: b.reads, Writes: b.writes, Locking: pq.Locking}
+}
+
+// accessBuilder collects the reads and writes of a statement and its data-modifying CTEs.
+type accessBuilder struct {
+	reads  []postgresparser.TableRef
+	writes []TableWrite
+}
+
+// statement adds the reads and writes of pq.
+func (b *accessBuilder) statement(pq *postgresparser.ParsedQuery) {
+	modifying := modifyingCTEs(pq)
+	for i, sc := range pq.Scopes {
+		if withinScopes(pq.Scopes, i, modifying) {
+			continue // Added with the CTE's own statement
+		}
+		for ri, rel := range sc.Relations {
+			if rel.Scope >= 0 || rel.Table.Type != postgresparser.TableTypeBase {
+				continue
+			}
+			if i == 0 && ri == 0 && pq.Command == postgresparser.QueryCommandInsert && pq.Upsert == nil {
+				continue // The INSERT target
+			}
+			b.read(rel.Table)
+		}
+	}
+	for _, i := range slices.Sorted(maps.Keys(modifying)) {
+		b.statement(modifying[i])
+	}
+
+	switch pq.Command {
+	case postgresparser.QueryCommandInsert:
+		if pq.Target != nil {
+			b.write(*pq.Target, WriteInsert, unquoteAll(pq.InsertColumns))
+			if pq.Upsert != nil && len(pq.Upsert.SetClauses) > 0 {
+				b.write(*pq.Target, WriteUpdate, assignedColumns(pq.Upsert.SetClauses))
+			}
+		}
+	case postgresparser.QueryCommandUpdate, postgresparser.QueryCommandDelete:
+		if len(pq.Scopes) > 0 && len(pq.Scopes[0].Relations) > 0 {
+			if pq.Command == postgresparser.QueryCommandUpdate {
+				b.write(pq.Scopes[0].Relations[0].Table, WriteUpdate, assignedColumns(pq.SetClauses))
+			} else {
+				b.write(pq.Scopes[0].Relations[0].Table, WriteDelete, nil)
+			}
+		}
+	case postgresparser.QueryCommandMerge:
+		if pq.Merge != nil {
+			for _, act := range pq.Merge.Actions {
+				switch act.Type {
+				case "UPDATE":
+					b.write(pq.Merge.Target, WriteUpdate, assignedColumns(act.SetClauses))
+				case "INSERT":
+					b.write(pq.Merge.Target, WriteInsert, unquoteAll(act.InsertColumns))
+				case "DELETE":
+					b.write(pq.Merge.Target, WriteDelete, nil)
+				}
+			}
+		}
+	case postgresparser.QueryCommandSelect, postgresparser.QueryCommandDDL:
+		// SELECT INTO, CREATE TABLE AS, and CREATE MATERIALIZED VIEW fill their Target.
+		if pq.Target != nil {
+			b.write(*pq.Target, WriteInsert, nil)
+		}
+		for _, a := range pq.DDLActions {
+			if a.Type == postgresparser.DDLTruncate {
+				b.write(postgresparser.TableRef{Schema: a.Schema, Name: a.ObjectName, Type: postgresparser.TableTypeBase}, WriteTruncate, nil)
+			}
+		}
+	}
+
+	source := -1
+	if pq.Command == postgresparser.QueryCommandDDL {
+		// Calls in a DDL statement run only in the query of CREATE TABLE AS; a column
+		// DEFAULT nextval(...) advances nothing yet.
+		if source = sourceScope(pq.Scopes); source < 0 {
+			return
+		}
+	}
+	for _, f := range pq.Functions {
+		name := strings.ToLower(trimQuotes(f.Name))
+		if name != "nextval" && name != "setval" || len(f.Args) == 0 {
+			continue
+		}
+		if source >= 0 && (f.Position < pq.Scopes[source].Start || f.Position >= pq.Scopes[source].End) {
+			continue
+		}
+		b.write(sequenceRef(f.Args[0]), WriteSequence, nil)
+	}
+}
+
+// read adds a table read, once.
+func (b *accessBuilder) read(t postgresparser.TableRef) {
+	if slices.ContainsFunc(b.reads, func(r postgresparser.TableRef) bool { return sameTable(r, t) }) {
+		return
+	}
+	t.Alias = ""
+	b.reads = append(b.reads, t)
+}
+
+// write adds a write, merging the columns of writes of the same kind to one relation.
+// Nil columns mean all of them.
+func (b *accessBuilder) write(t postgresparser.TableRef, kind WriteKind, cols []string) {
+	t.Alias = ""
+	for i, w := range b.writes {
+		if w.Kind != kind || !sameTable(w.Table, t) {
+			continue
+		}
+		if w.Columns == nil || cols == nil {
+			b.writes[i].Columns = nil
+			return
+		}
+		for _, c := range cols {
+			if indexFold(w.Columns, c) < 0 {
+				b.writes[i].Columns = append(b.writes[i].Columns, c)
+			}
+		}
+		return
+	}
+	if kind != WriteInsert && kind != WriteUpdate {
+		cols = nil
+	}
+	b.writes = append(b.writes, TableWrite{Table: t, Kind: kind, Columns: cols})
+}
+
+// sameTable reports whether two references name the same relation as written.
+func sameTable(a, b postgresparser.TableRef) bool {
+	if a.Name == "" && b.Name == "" {
+		return a.Raw == b.Raw
+	}
+	return strings.EqualFold(trimQuotes(a.Schema), trimQuotes(b.Schema)) && strings.EqualFold(trimQuotes(a.Name), trimQuotes(b.Name))
+}
+
+// modifyingCTEs parses the CTE bodies of pq that are INSERT, UPDATE, DELETE, or MERGE
+// statements, keyed by scope.
+func modifyingCTEs(pq *postgresparser.ParsedQuery) map[int]*postgresparser.ParsedQuery {
+	out := make(map[int]*postgresparser.ParsedQuery)
+	for i, sc := range pq.Scopes {
+		if sc.Kind != postgresparser.ScopeCTE || sc.End > len(pq.RawSQL) {
+			continue
+		}
+		text := strings.TrimSpace(pq.RawSQL[sc.Start:sc.End])
+		if hasPrefixFold(text, "SELECT") || hasPrefixFold(text, "VALUES") || strings.HasPrefix(text, "(") {
+			continue
+		}
+		sub, err := postgresparser.ParseSQL(text)
+		if err != nil {
+			continue
+		}
+		switch sub.Command {
+		case postgresparser.QueryCommandInsert, postgresparser.QueryCommandUpdate,
+			postgresparser.QueryCommandDelete, postgresparser.QueryCommandMerge:
+			out[i] = sub
+		}
+	}
+	return out
+}
+
+// withinScopes reports whether scope i is one of the scopes in set or nested in one.
+func withinScopes(scopes []postgresparser.QueryScope, i int, set map[int]*postgresparser.ParsedQuery) bool {
+	for ; i >= 0; i = scopes[i].Parent {
+		if _, ok := set[i]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// assignedColumns returns the columns assigned by SET clauses.
+func assignedColumns(clauses []string) []string {
+	cols := []string{}
+	for _, c := range clauses {
+		cols = append(cols, setTargets(c)...)
+	}
+	return cols
+}
+
+// unquoteAll strips identifier quotes from names, keeping nil as nil.
+func unquoteAll(names []string) []string {
+	if names == nil {
+		return nil
+	}
+	out := make([]string, len(names))
+	for i, n := range names {
+		out[i] = trimQuotes(n)
+	}
+	return out
+}
+
+// sequenceRef returns the sequence named by the first argument of nextval or setval,
+// a literal such as 'app.seq' or 'seq'::regclass folded as PostgreSQL folds it.
+func sequenceRef(arg string) postgresparser.TableRef {
+	lit := strings.TrimSpace(arg)
+	if i := strings.Index(lit, "::"); i >= 0 {
+		lit = strings.TrimSpace(lit[:i])
+	}
+	if len(lit) < 2 || lit[0] != '\'' || lit[len(lit)-1] != '\'' {
+		return postgresparser.TableRef{Raw: arg}
+	}
+	name := strings.ReplaceAll(lit[1:len(lit)-1], "''", "'")
+	parts := identPartRe.FindAllString(name, -1)
+	if len(parts) == 0 {
+		return postgresparser.TableRef{Raw: arg}
+	}
+	ref := postgresparser.TableRef{Name: sqlident.Name(parts[len(parts)-1]), Raw: name}
+	if len(parts) > 1 {
+		ref.Schema = sqlident.Name(parts[len(parts)-2])
+	}
+	return ref
+}
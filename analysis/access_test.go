@@ -0,0 +1,153 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// accessSummary classifies query and summarizes its reads as table names and its
+// writes as "KIND table(columns)".
+func accessSummary(t *testing.T, query string) (reads, writes []string, readOnly bool) {
+	t.Helper()
+	access, err := Access(query)
+	require.NoError(t, err, "access failed")
+	for _, r := range access.Reads {
+		reads = append(reads, qualifiedName(r.Schema, r.Name))
+	}
+	for _, w := range access.Writes {
+		s := string(w.Kind) + " " + qualifiedName(w.Table.Schema, w.Table.Name)
+		if w.Columns != nil {
+			s += "(" + strings.Join(w.Columns, ", ") + ")"
+		}
+		writes = append(writes, s)
+	}
+	return reads, writes, access.ReadOnly()
+}
+
+// qualifiedName joins a schema and a name.
+func qualifiedName(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
+func TestAccess(t *testing.T) {
+	tests := []struct {
+		name     string
+		query    string
+		reads    []string
+		writes   []string
+		readOnly bool
+	}{
+		{
+			name:     "query with subqueries",
+			query:    "SELECT (SELECT max(total) FROM orders) FROM users u JOIN orgs o ON o.id = u.org_id WHERE EXISTS (SELECT 1 FROM app.payments)",
+			reads:    []string{"users", "orgs", "orders", "app.payments"},
+			readOnly: true,
+		},
+		{
+			name:  "row locks",
+			query: "SELECT * FROM orders o JOIN users u ON u.id = o.user_id FOR UPDATE OF o",
+			reads: []string{"orders", "users"},
+		},
+		{
+			name:   "data-modifying CTE",
+			query:  "WITH d AS (DELETE FROM orders WHERE created_at < now() RETURNING *) INSERT INTO archive (id, total) SELECT id, total FROM d",
+			reads:  []string{"orders"},
+			writes: []string{"DELETE orders", "INSERT archive(id, total)"},
+		},
+		{
+			name:   "SELECT with a data-modifying CTE",
+			query:  "WITH u AS (UPDATE users SET name = 'x' WHERE id = 1 RETURNING id) SELECT * FROM u JOIN orgs ON orgs.id = u.id",
+			reads:  []string{"orgs", "users"},
+			writes: []string{"UPDATE users(name)"},
+		},
+		{
+			name:   "SELECT INTO",
+			query:  "SELECT * INTO TEMP recent FROM orders",
+			reads:  []string{"orders"},
+			writes: []string{"INSERT recent"},
+		},
+		{
+			name:   "sequence functions",
+			query:  "SELECT nextval('app.order_seq'), setval('Seq'::regclass, 1), currval('other')",
+			writes: []string{"SEQUENCE app.order_seq", "SEQUENCE seq"},
+		},
+		{
+			name:   "INSERT reads its source, not its target",
+			query:  "INSERT INTO orders SELECT * FROM orders_staging",
+			reads:  []string{"orders_staging"},
+			writes: []string{"INSERT orders"},
+		},
+		{
+			name:   "upsert",
+			query:  "INSERT INTO users (id, email) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET email = excluded.email",
+			reads:  []string{"users"},
+			writes: []string{"INSERT users(id, email)", "UPDATE users(email)"},
+		},
+		{
+			name:   "UPDATE with a row assignment",
+			query:  "UPDATE users u SET (name, org_id) = (o.name, o.id) FROM orgs o WHERE o.id = u.org_id",
+			reads:  []string{"users", "orgs"},
+			writes: []string{"UPDATE users(name, org_id)"},
+		},
+		{
+			name:   "MERGE actions",
+			query:  "MERGE INTO users t USING staging s ON s.id = t.id WHEN MATCHED THEN UPDATE SET name = s.name WHEN NOT MATCHED THEN INSERT (id, name) VALUES (s.id, s.name) WHEN MATCHED THEN DELETE",
+			reads:  []string{"users", "staging"},
+			writes: []string{"UPDATE users(name)", "INSERT users(id, name)", "DELETE users"},
+		},
+		{
+			name:   "TRUNCATE",
+			query:  "TRUNCATE orders, app.payments",
+			writes: []string{"TRUNCATE orders", "TRUNCATE app.payments"},
+		},
+		{
+			name:   "CREATE TABLE AS",
+			query:  "CREATE TABLE report AS SELECT user_id, nextval('report_seq') FROM orders",
+			reads:  []string{"orders"},
+			writes: []string{"INSERT report", "SEQUENCE report_seq"},
+		},
+		{
+			name:  "column default is not a call",
+			query: "CREATE TABLE t (id bigint DEFAULT nextval('t_seq'))",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			reads, writes, readOnly := accessSummary(t, tt.query)
+			assert.Equal(t, tt.reads, reads, "reads")
+			assert.Equal(t, tt.writes, writes, "writes")
+			assert.Equal(t, tt.readOnly, readOnly, "read-only")
+		})
+	}
+}
+
+func TestExtractAccess_Structure(t *testing.T) {
+	access, err := Access("SELECT id FROM app.orders FOR SHARE SKIP LOCKED")
+	require.NoError(t, err, "access failed")
+	assert.Equal(t, TableAccess{
+		Command: postgresparser.QueryCommandSelect,
+		Reads:   []postgresparser.TableRef{{Schema: "app", Name: "orders", Type: postgresparser.TableTypeBase, Raw: "app.orders"}},
+		Locking: []postgresparser.LockingClause{{Strength: "SHARE", Wait: "SKIP LOCKED", Position: 26}},
+	}, access)
+	assert.False(t, access.ReadOnly(), "row locks are not read-only")
+
+	access, err = Access("INSERT INTO users (id, email) VALUES ($1, $2) ON CONFLICT (id) DO UPDATE SET email = excluded.email")
+	require.NoError(t, err, "access failed")
+	users := postgresparser.TableRef{Name: "users", Type: postgresparser.TableTypeBase, Raw: "users"}
+	assert.Equal(t, TableAccess{
+		Command: postgresparser.QueryCommandInsert,
+		Reads:   []postgresparser.TableRef{users},
+		Writes: []TableWrite{
+			{Table: users, Kind: WriteInsert, Columns: []string{"id", "email"}},
+			{Table: users, Kind: WriteUpdate, Columns: []string{"email"}},
+		},
+	}, access)
+}
@@ -0,0 +1,930 @@
+// advisor.go recommends indexes for a query workload and reports existing indexes the
+// workload cannot use.
+package analysis
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// WorkloadQuery is a statement of a workload.
+type WorkloadQuery struct {
+	SQL   string
+	Calls int64 // Executions, such as pg_stat_statements.calls; 0 counts as 1
+}
+
+// IndexRecommendation is an index that would serve queries of a workload.
+type IndexRecommendation struct {
+	Schema    string
+	Table     string
+	Columns   []string // Key columns and expressions, in index order
+	Include   []string // Non-key columns that let the index answer its queries alone
+	Predicate string   // WHERE clause of a partial index, or ""
+	Calls     int64    // Executions of the queries the index serves
+	Queries   []int    // Indexes in the workload of the queries the index serves
+}
+
+// DDL returns a CREATE INDEX CONCURRENTLY statement for the recommendation, named the
+// way PostgreSQL names indexes created without a name.
+func (r IndexRecommendation) DDL() string {
+	var b strings.Builder
+	b.WriteString("CREATE INDEX CONCURRENTLY ")
+	b.WriteString(indexName(r.Table, r.Columns))
+	b.WriteString(" ON ")
+	b.WriteString(schemaQualified(r.Schema, r.Table))
+	b.WriteString(" (" + strings.Join(r.Columns, ", ") + ")")
+	if len(r.Include) > 0 {
+		b.WriteString(" INCLUDE (" + strings.Join(r.Include, ", ") + ")")
+	}
+	if r.Predicate != "" {
+		b.WriteString(" WHERE " + r.Predicate)
+	}
+	return b.String()
+}
+
+// ExistingIndex is an index defined by the DDL given to the advisor.
+type ExistingIndex struct {
+	Schema     string
+	Name       string
+	Table      string
+	Columns    []string // Key columns and expressions as written, e.g. "created_at DESC"
+	Include    []string
+	Predicate  string
+	Method     string // Access method as written, e.g. "gin"; "" for the default btree
+	Unique     bool
+	Constraint bool // The index backs a PRIMARY KEY or UNIQUE constraint
+}
+
+// RedundantIndex is an index whose work another index already does.
+type RedundantIndex struct {
+	Index     ExistingIndex
+	CoveredBy ExistingIndex // Index with the same leading key columns
+}
+
+// SkippedQuery is a workload query the advisor could not parse.
+type SkippedQuery struct {
+	Query int // Index in the workload
+	Err   error
+}
+
+// IndexAdvice is the result of IndexAdvisor.Advise.
+type IndexAdvice struct {
+	Recommendations []IndexRecommendation // Ordered by Calls, most first, then by table
+	Unused          []ExistingIndex       // Indexes on tables of the workload that no query can use
+	Redundant       []RedundantIndex
+	Skipped         []SkippedQuery
+}
+
+// IndexAdvisor recommends indexes for a workload.
+type IndexAdvisor struct {
+	// DDL defines the existing tables and indexes: CREATE TABLE, CREATE INDEX,
+	// ALTER TABLE ... ADD PRIMARY KEY | UNIQUE, and DROP INDEX statements.
+	DDL string
+	// SchemaMap lists the columns of tables, keyed as for ResolveColumnUsage, to resolve
+	// unqualified columns of joins. Tables created by DDL are added to it.
+	SchemaMap map[string][]ColumnSchema
+	// MaxInclude is the largest number of non-key columns a covering index includes. 0
+	// means 3; a negative value turns covering indexes off.
+	MaxInclude int
+}
+
+// defaultMaxInclude is the MaxInclude used when it is 0.
+const defaultMaxInclude = 3
+
+// AdviseIndexes recommends indexes for a workload, given the DDL of the existing
+// tables and indexes. See IndexAdvisor.Advise.
+func AdviseIndexes(workload []WorkloadQuery, ddl string) (*IndexAdvice, error) {
+	return IndexAdvisor{DDL: ddl}.Advise(workload)
+}
+
+// Advise analyzes how each query of the workload filters, joins, and orders the rows
+// of its base tables, and recommends the btree indexes that would serve them:
+//
+//   - Columns compared for equality with a constant, including IN lists, and join keys
+//     come first, followed by one range-filtered column or, for a query with LIMIT,
+//     its ORDER BY columns.
+//   - A filter on a function of a column, such as lower(email) = $1, makes an
+//     expression index.
+//   - IS [NOT] NULL and boolean conditions become the predicate of a partial index.
+//   - A SELECT reading at most MaxInclude other columns of the table gets them as
+//     INCLUDE columns.
+//
+// Conditions combined with OR are ignored. Recommendations served by an existing
+// index, or by a longer recommendation whose leading columns they share, are dropped.
+// Statements that fail to parse are reported in Skipped; DDL that fails to parse is
+// an error.
+func (a IndexAdvisor) Advise(workload []WorkloadQuery) (*IndexAdvice, error) {
+	adv := &advisor{maxInclude: a.MaxInclude, schemaMap: make(map[string][]ColumnSchema)}
+	if adv.maxInclude == 0 {
+		adv.maxInclude = defaultMaxInclude
+	}
+	for k, v := range a.SchemaMap {
+		adv.schemaMap[k] = v
+	}
+	if strings.TrimSpace(a.DDL) != "" {
+		stmts, err := postgresparser.ParseSQLAll(a.DDL)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse index DDL: %w", err)
+		}
+		for _, pq := range stmts {
+			for _, act := range pq.DDLActions {
+				adv.define(act)
+			}
+		}
+	}
+
+	advice := &IndexAdvice{}
+	for i, q := range workload {
+		pq, err := postgresparser.ParseSQL(q.SQL)
+		if err != nil {
+			advice.Skipped = append(advice.Skipped, SkippedQuery{Query: i, Err: err})
+			continue
+		}
+		calls := max(q.Calls, 1)
+		for _, acc := range adv.accesses(pq) {
+			acc.calls, acc.queries = calls, []int{i}
+			adv.add(acc)
+		}
+		for _, t := range pq.Tables {
+			if t.Type == postgresparser.TableTypeBase {
+				adv.touched = append(adv.touched, t)
+			}
+		}
+	}
+	advice.Recommendations = adv.recommend()
+	advice.Unused = adv.unused()
+	advice.Redundant = adv.redundant()
+	return advice, nil
+}
+
+// ParseStatStatements reads a CSV export of pg_stat_statements, such as the output of
+// \copy (SELECT query, calls FROM pg_stat_statements) TO 'file' CSV HEADER. The header
+// must name a query column; calls is optional.
+func ParseStatStatements(r io.Reader) ([]WorkloadQuery, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read pg_stat_statements header: %w", err)
+	}
+	queryCol, callsCol := -1, -1
+	for i, h := range header {
+		switch strings.ToLower(strings.TrimSpace(h)) {
+		case "query":
+			queryCol = i
+		case "calls":
+			callsCol = i
+		}
+	}
+	if queryCol < 0 {
+		return nil, errors.New("pg_stat_statements export has no query column")
+	}
+	var out []WorkloadQuery
+	for {
+		rec, err := cr.Read()
+		if err == io.EOF {
+			return out, nil
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to read pg_stat_statements: %w", err)
+		}
+		if queryCol >= len(rec) {
+			continue
+		}
+		q := WorkloadQuery{SQL: rec[queryCol]}
+		if callsCol >= 0 && callsCol < len(rec) {
+			if q.Calls, err = strconv.ParseInt(strings.TrimSpace(rec[callsCol]), 10, 64); err != nil {
+				return nil, fmt.Errorf("invalid calls %q for query %q: %w", rec[callsCol], rec[queryCol], err)
+			}
+		}
+		out = append(out, q)
+	}
+}
+
+// advisor accumulates the index definitions and table accesses of a workload.
+type advisor struct {
+	maxInclude int
+	schemaMap  map[string][]ColumnSchema
+	indexes    []ExistingIndex
+	accessed   []indexAccess // Every access of the workload, before merging
+	candidates []indexAccess // Merged accesses
+	touched    []postgresparser.TableRef
+}
+
+// indexAccess is how a query reads one relation, and the index that would serve it.
+type indexAccess struct {
+	schema, table string
+	eq            []string // Equality and join keys, in any order
+	tail          []string // Range column or ORDER BY columns, after eq
+	joins         []string // Join keys, which lead eq when the relation has no equality filters
+	include       []string
+	predicate     string
+	covering      bool // include lists every other column the query reads
+	calls         int64
+	queries       []int
+}
+
+// columns returns the key columns of the index serving a.
+func (a indexAccess) columns() []string {
+	return slices.Concat(a.eq, a.tail)
+}
+
+// define records an index created by a DDL action, or removes a dropped one.
+func (adv *advisor) define(a postgresparser.DDLAction) {
+	switch a.Type {
+	case postgresparser.DDLCreateTable:
+		var cols []ColumnSchema
+		for _, c := range a.ColumnDetails {
+			cols = append(cols, ColumnSchema{Name: c.Name, PGType: c.Type, IsNullable: c.Nullable})
+		}
+		key := strings.ToLower(trimQuotes(a.ObjectName))
+		if _, ok := adv.schemaMap[key]; !ok {
+			adv.schemaMap[key] = cols
+		}
+		if a.Schema != "" {
+			adv.schemaMap[strings.ToLower(trimQuotes(a.Schema))+"."+key] = cols
+		}
+		adv.defineConstraints(a)
+	case postgresparser.DDLAlterTable:
+		if hasFlagFold(a.Flags, "ADD_CONSTRAINT") || hasFlagFold(a.Flags, "ADD_COLUMN") {
+			adv.defineConstraints(a)
+		}
+	case postgresparser.DDLCreateIndex:
+		adv.indexes = append(adv.indexes, ExistingIndex{
+			Schema:    a.Schema,
+			Name:      a.ObjectName,
+			Table:     a.Table,
+			Columns:   a.Columns,
+			Include:   a.IncludeColumns,
+			Predicate: a.Predicate,
+			Method:    a.IndexType,
+			Unique:    hasFlagFold(a.Flags, "UNIQUE"),
+		})
+	case postgresparser.DDLDropIndex:
+		adv.indexes = slices.DeleteFunc(adv.indexes, func(idx ExistingIndex) bool {
+			return strings.EqualFold(trimQuotes(idx.Name), trimQuotes(a.ObjectName)) && sameSchema(idx.Schema, a.Schema)
+		})
+	}
+}
+
+// defineConstraints records the indexes of the PRIMARY KEY and UNIQUE constraints of a
+// CREATE TABLE or ALTER TABLE action.
+func (adv *advisor) defineConstraints(a postgresparser.DDLAction) {
+	for _, c := range a.Constraints {
+		if c.Type != postgresparser.DDLConstraintPrimaryKey && c.Type != postgresparser.DDLConstraintUnique {
+			continue
+		}
+		if c.Index != "" {
+			// USING INDEX turns an existing unique index into the constraint's index.
+			for i := range adv.indexes {
+				if strings.EqualFold(trimQuotes(adv.indexes[i].Name), trimQuotes(c.Index)) {
+					adv.indexes[i].Constraint = true
+				}
+			}
+			continue
+		}
+		name := c.Name
+		if name == "" {
+			name = a.ObjectName + "_pkey"
+			if c.Type == postgresparser.DDLConstraintUnique {
+				name = a.ObjectName + "_" + strings.Join(c.Columns, "_") + "_key"
+			}
+		}
+		adv.indexes = append(adv.indexes, ExistingIndex{
+			Schema:     a.Schema,
+			Name:       name,
+			Table:      a.ObjectName,
+			Columns:    c.Columns,
+			Unique:     true,
+			Constraint: true,
+		})
+	}
+}
+
+// relationUsage collects the column usages of one relation of a query.
+type relationUsage struct {
+	ref      postgresparser.TableRef
+	relation string
+	eq, rng  []string
+	joins    []string
+	preds    []string
+	columns  []string // Columns read other than through an expression key or a predicate
+}
+
+// accesses returns how pq reads each of its base-table relations.
+func (adv *advisor) accesses(pq *postgresparser.ParsedQuery) []indexAccess {
+	toks := sqllex.Lex(pq.RawSQL)
+	resolved := ResolveColumnUsage(pq, adv.schemaMap)
+	orderCols, orderRel := orderColumns(pq, resolved)
+	star := slices.ContainsFunc(pq.Columns, func(c postgresparser.SelectColumn) bool {
+		return c.Expression == "*" || strings.HasSuffix(c.Expression, ".*")
+	})
+
+	var rels []*relationUsage
+	find := func(ref postgresparser.TableRef, relation string) *relationUsage {
+		for _, r := range rels {
+			if r.relation == relation && sameTable(r.ref, ref) {
+				return r
+			}
+		}
+		r := &relationUsage{ref: ref, relation: relation}
+		rels = append(rels, r)
+		return r
+	}
+	for _, rc := range resolved {
+		ref, relation, ok := usageTable(pq, rc)
+		if !ok {
+			continue
+		}
+		r := find(ref, relation)
+		col := sqlident.Name(rc.Column)
+		u := rc.Usage
+		if col == "*" {
+			star = true
+			continue
+		}
+		switch u.UsageType {
+		case postgresparser.ColumnUsageTypeFilter:
+			if underOr(toks, u.Position) {
+				r.columns = appendUnique(r.columns, col)
+				continue
+			}
+			kind, key := classifyCondition(u)
+			if kind != conditionPredicate && !strings.Contains(key, "(") {
+				key = col // The base column, which a CTE or subquery may rename
+			}
+			switch kind {
+			case conditionEqual:
+				r.eq = appendUnique(r.eq, key)
+			case conditionJoin:
+				r.joins = appendUnique(r.joins, key)
+			case conditionRange:
+				r.rng = appendUnique(r.rng, key)
+			case conditionPredicate:
+				r.preds = appendUnique(r.preds, key)
+				continue
+			}
+			if key != col {
+				continue // The expression key supplies the condition.
+			}
+		case postgresparser.ColumnUsageTypeJoin:
+			if !underOr(toks, u.Position) {
+				r.joins = appendUnique(r.joins, col)
+			}
+		}
+		r.columns = appendUnique(r.columns, col)
+	}
+
+	var out []indexAccess
+	for _, r := range rels {
+		// Join keys matter when the relation is the inner side of a nested loop, which
+		// a relation with its own equality filters seldom is.
+		acc := indexAccess{schema: r.ref.Schema, table: r.ref.Name, eq: r.eq, joins: r.joins}
+		if len(acc.eq) == 0 {
+			acc.eq = r.joins
+		}
+		switch {
+		case len(orderCols) > 0 && orderRel == r.relation && pq.Limit != nil:
+			for _, c := range orderCols {
+				if !slices.Contains(acc.eq, c) {
+					acc.tail = append(acc.tail, c)
+				}
+			}
+		case len(r.rng) > 0:
+			acc.tail = r.rng[:1]
+		}
+		if len(acc.eq)+len(acc.tail) == 0 {
+			continue
+		}
+		slices.Sort(r.preds)
+		acc.predicate = strings.Join(r.preds, " AND ")
+		if pq.Command == postgresparser.QueryCommandSelect && !star && adv.maxInclude > 0 {
+			keys := acc.columns()
+			var include []string
+			for _, c := range r.columns {
+				if !slices.Contains(keys, c) {
+					include = append(include, c)
+				}
+			}
+			if len(include) <= adv.maxInclude {
+				acc.include, acc.covering = include, true
+			}
+		}
+		out = append(out, acc)
+	}
+	return out
+}
+
+// usageTable returns the base table of a resolved column, and the relation of its
+// scope that supplies it. A column of unknown owner counts when only one relation can
+// supply it.
+func usageTable(pq *postgresparser.ParsedQuery, rc ResolvedColumn) (postgresparser.TableRef, string, bool) {
+	switch {
+	case rc.Status == ColumnResolved:
+		return postgresparser.TableRef{Schema: rc.Schema, Name: rc.Table}, rc.Relation, true
+	case rc.Status == ColumnUnknown && len(rc.Candidates) == 1:
+		for _, t := range pq.Tables {
+			if t.Type != postgresparser.TableTypeBase {
+				continue
+			}
+			if strings.EqualFold(t.Alias, rc.Candidates[0]) || t.Alias == "" && strings.EqualFold(t.Name, rc.Candidates[0]) {
+				return postgresparser.TableRef{Schema: t.Schema, Name: t.Name}, rc.Candidates[0], true
+			}
+		}
+	}
+	return postgresparser.TableRef{}, "", false
+}
+
+// orderColumns returns the ORDER BY columns of pq and the relation supplying them,
+// when they are plain columns of one relation sorted in one direction.
+func orderColumns(pq *postgresparser.ParsedQuery, resolved []ResolvedColumn) ([]string, string) {
+	if len(pq.OrderBy) == 0 {
+		return nil, ""
+	}
+	var cols []string
+	relation := ""
+	for i, o := range pq.OrderBy {
+		if _, _, ok := parseColumnRef(o.Expression); !ok || o.Direction != pq.OrderBy[0].Direction || o.Nulls != "" {
+			return nil, ""
+		}
+		idx := slices.IndexFunc(resolved, func(rc ResolvedColumn) bool {
+			return rc.Usage.UsageType == postgresparser.ColumnUsageTypeOrderBy && rc.Usage.Expression == o.Expression
+		})
+		if idx < 0 {
+			return nil, ""
+		}
+		_, rel, ok := usageTable(pq, resolved[idx])
+		if !ok || i > 0 && rel != relation {
+			return nil, ""
+		}
+		relation = rel
+		cols = append(cols, sqlident.Name(resolved[idx].Column))
+	}
+	return cols, relation
+}
+
+// conditionKind classifies a filter condition for indexing.
+type conditionKind int
+
+const (
+	conditionNone      conditionKind = iota // Not indexable by a btree
+	conditionEqual                          // Equality with a constant, or IN
+	conditionJoin                           // Equality with a column of another relation
+	conditionRange                          // <, <=, >, >=, or BETWEEN with constants
+	conditionPredicate                      // IS [NOT] NULL or a boolean test, for a partial index
+)
+
+// classifyCondition returns how a filter usage can use an index, and the index key or
+// predicate it contributes.
+func classifyCondition(u postgresparser.ColumnUsage) (conditionKind, string) {
+	toks := sqllex.Lex(u.Context)
+	op := -1
+	depth := 0
+	for i, t := range toks {
+		switch {
+		case t.Is("("):
+			depth++
+		case t.Is(")"):
+			depth--
+		case depth == 0 && op < 0 && (t.Kind == sqllex.Op && comparisonOps[t.Text] || t.Is("IN") || t.Is("BETWEEN") || t.Is("IS") || t.Is("NOT") || t.Is("LIKE") || t.Is("ILIKE")):
+			op = i
+		}
+	}
+	if op <= 0 || op == len(toks)-1 {
+		return conditionNone, ""
+	}
+	left, right := toks[:op], toks[op:]
+	key, ok := indexKey(left, u.Expression)
+	other := toks[op+1:]
+	if !ok {
+		// The column may be on the right, as in $1 < created_at.
+		if !toks[op].Is("=") && !(toks[op].Kind == sqllex.Op && comparisonOps[toks[op].Text]) {
+			return conditionNone, ""
+		}
+		if key, ok = indexKey(toks[op+1:], u.Expression); !ok {
+			return conditionNone, ""
+		}
+		other = left
+	}
+	opText := strings.ToUpper(toks[op].Text)
+	switch {
+	case opText == "IS":
+		return conditionPredicate, key + " " + renderTokens(right)
+	case opText == "=" && len(other) == 1 && (other[0].Is("TRUE") || other[0].Is("FALSE")):
+		return conditionPredicate, key + " = " + strings.ToLower(other[0].Text)
+	case opText == "IN":
+		return conditionEqual, key
+	case !isConstant(other):
+		if opText == "=" {
+			return conditionJoin, key
+		}
+	case opText == "=":
+		return conditionEqual, key
+	case opText == "BETWEEN" || opText != "<>" && opText != "!=" && comparisonOps[opText]:
+		return conditionRange, key
+	}
+	return conditionNone, ""
+}
+
+// comparisonOps are the btree comparison operators.
+var comparisonOps = map[string]bool{"=": true, "<": true, ">": true, "<=": true, ">=": true, "<>": true, "!=": true}
+
+// indexKey returns the index key of a comparison side that is the column expr, or a
+// function of it alone, as in lower(u.email).
+func indexKey(side []sqllex.Token, expr string) (string, bool) {
+	if len(side) > 0 && side[len(side)-1].Is(")") && side[0].Kind == sqllex.Ident && len(side) > 3 && side[1].Is("(") {
+		if _, ok := indexKey(side[2:len(side)-1], expr); ok {
+			return strings.ToLower(side[0].Text) + "(" + unqualified(side[2:len(side)-1]) + ")", true
+		}
+		return "", false
+	}
+	var text strings.Builder
+	for _, t := range side {
+		text.WriteString(t.Text)
+	}
+	if !strings.EqualFold(text.String(), strings.ReplaceAll(expr, " ", "")) {
+		return "", false
+	}
+	return unqualified(side), true
+}
+
+// unqualified renders a column reference without its qualifiers.
+func unqualified(side []sqllex.Token) string {
+	if len(side) == 0 {
+		return ""
+	}
+	return sqlident.Name(side[len(side)-1].Text)
+}
+
+// constantWords are keywords that denote constants in a comparison.
+var constantWords = map[string]bool{
+	"true": true, "false": true, "null": true, "and": true, "interval": true,
+	"current_date": true, "current_time": true, "current_timestamp": true, "localtime": true, "localtimestamp": true,
+}
+
+// isConstant reports whether a comparison side refers to no column: constants,
+// parameters, casts, and function calls of them.
+func isConstant(side []sqllex.Token) bool {
+	for i, t := range side {
+		switch t.Kind {
+		case sqllex.Ident:
+			switch {
+			case constantWords[strings.ToLower(t.Text)]:
+			case i+1 < len(side) && side[i+1].Is("("):
+			case i > 0 && side[i-1].Is("::"):
+			default:
+				return false
+			}
+		case sqllex.Quoted:
+			return false
+		case sqllex.Punct:
+			if t.Is(".") {
+				return false
+			}
+		}
+	}
+	return true
+}
+
+// renderTokens joins tokens with single spaces, upper-casing keywords.
+func renderTokens(toks []sqllex.Token) string {
+	parts := make([]string, len(toks))
+	for i, t := range toks {
+		parts[i] = t.Text
+		if t.Kind == sqllex.Ident {
+			parts[i] = strings.ToUpper(t.Text)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// orBoundaries are keywords that end the boolean expression around a condition.
+var orBoundaries = map[string]bool{
+	"where": true, "on": true, "having": true, "select": true, "from": true, "join": true,
+	"group": true, "order": true, "limit": true, "offset": true, "returning": true, "set": true,
+	"when": true, "then": true, "else": true, "union": true, "intersect": true, "except": true,
+	"window": true, "for": true, "using": true,
+}
+
+// underOr reports whether the condition at character offset pos is combined with
+// another by OR, directly or through enclosing parentheses.
+func underOr(toks []sqllex.Token, pos int) bool {
+	k := slices.IndexFunc(toks, func(t sqllex.Token) bool { return t.Pos >= pos })
+	if k < 0 {
+		return false
+	}
+	boundary := func(t sqllex.Token) bool {
+		return t.Is(",") || t.Is(";") || t.Kind == sqllex.Ident && orBoundaries[strings.ToLower(t.Text)]
+	}
+	lo, hi := k, k
+	for {
+		l, depth := lo-1, 0
+		for ; l >= 0; l-- {
+			if t := toks[l]; t.Is(")") {
+				depth++
+			} else if t.Is("(") {
+				if depth == 0 {
+					break
+				}
+				depth--
+			} else if depth == 0 && boundary(t) {
+				break
+			}
+		}
+		r := hi + 1
+		for depth = 0; r < len(toks); r++ {
+			if t := toks[r]; t.Is("(") {
+				depth++
+			} else if t.Is(")") {
+				if depth == 0 {
+					break
+				}
+				depth--
+			} else if depth == 0 && boundary(t) {
+				break
+			}
+		}
+		depth = 0
+		for i := l + 1; i < r; i++ {
+			switch t := toks[i]; {
+			case t.Is("("):
+				depth++
+			case t.Is(")"):
+				depth--
+			case depth == 0 && t.Is("OR"):
+				return true
+			}
+		}
+		if l < 0 || r >= len(toks) || !toks[l].Is("(") || !toks[r].Is(")") {
+			return false
+		}
+		lo, hi = l, r
+	}
+}
+
+// add records an access of the workload, merging it with an identical one.
+func (adv *advisor) add(acc indexAccess) {
+	adv.accessed = append(adv.accessed, acc)
+	for i := range adv.candidates {
+		c := &adv.candidates[i]
+		if sameRelation(c.schema, c.table, acc.schema, acc.table) && sameSet(c.eq, acc.eq) && slices.Equal(c.tail, acc.tail) && c.predicate == acc.predicate {
+			c.merge(acc, adv.maxInclude)
+			return
+		}
+	}
+	adv.candidates = append(adv.candidates, acc)
+}
+
+// merge folds the queries of other into a, keeping a covering only while its INCLUDE
+// list stays within maxInclude.
+func (a *indexAccess) merge(other indexAccess, maxInclude int) {
+	a.calls += other.calls
+	for _, q := range other.queries {
+		if !slices.Contains(a.queries, q) {
+			a.queries = append(a.queries, q)
+		}
+	}
+	slices.Sort(a.queries)
+	if !a.covering || !other.covering {
+		a.include, a.covering = nil, false
+		return
+	}
+	keys := a.columns()
+	for _, c := range other.include {
+		if !slices.Contains(keys, c) {
+			a.include = appendUnique(a.include, c)
+		}
+	}
+	if len(a.include) > maxInclude {
+		a.include, a.covering = nil, false
+	}
+}
+
+// recommend returns the merged accesses no existing index serves, folding each into a
+// longer recommendation that serves it.
+func (adv *advisor) recommend() []IndexRecommendation {
+	var open []indexAccess
+	for _, c := range adv.candidates {
+		if !slices.ContainsFunc(adv.indexes, func(idx ExistingIndex) bool { return idx.serves(c) }) {
+			open = append(open, c)
+		}
+	}
+	// Longer indexes first, so shorter ones fold into them.
+	sort.SliceStable(open, func(i, j int) bool { return len(open[i].columns()) > len(open[j].columns()) })
+	var kept []indexAccess
+	for _, c := range open {
+		best := -1
+		for i, k := range kept {
+			idx := ExistingIndex{Schema: k.schema, Table: k.table, Columns: k.columns(), Predicate: k.predicate}
+			if idx.serves(c) && (best < 0 || k.calls > kept[best].calls) {
+				best = i
+			}
+		}
+		if best >= 0 {
+			kept[best].merge(c, adv.maxInclude)
+			continue
+		}
+		kept = append(kept, c)
+	}
+
+	out := make([]IndexRecommendation, 0, len(kept))
+	for _, k := range kept {
+		out = append(out, IndexRecommendation{
+			Schema:    k.schema,
+			Table:     k.table,
+			Columns:   k.columns(),
+			Include:   k.include,
+			Predicate: k.predicate,
+			Calls:     k.calls,
+			Queries:   k.queries,
+		})
+	}
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Calls != out[j].Calls {
+			return out[i].Calls > out[j].Calls
+		}
+		return schemaQualified(out[i].Schema, out[i].Table) < schemaQualified(out[j].Schema, out[j].Table)
+	})
+	if len(out) == 0 {
+		return nil
+	}
+	return out
+}
+
+// unused returns the indexes on tables of the workload whose leading column no access
+// of the workload constrains. Unique indexes enforce a constraint and are never unused.
+func (adv *advisor) unused() []ExistingIndex {
+	var out []ExistingIndex
+	for _, idx := range adv.indexes {
+		if idx.Unique || idx.Constraint || len(idx.Columns) == 0 {
+			continue
+		}
+		if !slices.ContainsFunc(adv.touched, func(t postgresparser.TableRef) bool {
+			return sameRelation(t.Schema, t.Name, idx.Schema, idx.Table)
+		}) {
+			continue
+		}
+		lead := indexColumnKey(idx.Columns[0])
+		used := slices.ContainsFunc(adv.accessed, func(acc indexAccess) bool {
+			return sameRelation(acc.schema, acc.table, idx.Schema, idx.Table) &&
+				(idx.Predicate == "" || strings.EqualFold(idx.Predicate, acc.predicate)) &&
+				(slices.Contains(acc.eq, lead) || slices.Contains(acc.joins, lead) || len(acc.tail) > 0 && acc.tail[0] == lead)
+		})
+		if !used {
+			out = append(out, idx)
+		}
+	}
+	return out
+}
+
+// redundant returns the non-unique indexes whose key columns lead another index of the
+// same table, method, and predicate. Of two identical indexes the later one is reported.
+func (adv *advisor) redundant() []RedundantIndex {
+	var out []RedundantIndex
+	for i, idx := range adv.indexes {
+		if idx.Unique || idx.Constraint {
+			continue
+		}
+		keys := indexKeys(idx.Columns)
+		for j, other := range adv.indexes {
+			if i == j || !sameRelation(idx.Schema, idx.Table, other.Schema, other.Table) ||
+				!strings.EqualFold(indexMethod(idx.Method), indexMethod(other.Method)) || !strings.EqualFold(idx.Predicate, other.Predicate) {
+				continue
+			}
+			otherKeys := indexKeys(other.Columns)
+			if len(keys) > len(otherKeys) || !slices.Equal(keys, otherKeys[:len(keys)]) {
+				continue
+			}
+			if len(keys) == len(otherKeys) && !other.Unique && !other.Constraint && j > i {
+				continue // The later of two identical indexes is the redundant one.
+			}
+			if !slices.ContainsFunc(idx.Include, func(c string) bool {
+				c = sqlident.Name(c)
+				return !slices.Contains(otherKeys, c) && !slices.ContainsFunc(other.Include, func(o string) bool { return sqlident.Name(o) == c })
+			}) {
+				out = append(out, RedundantIndex{Index: idx, CoveredBy: other})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// serves reports whether the btree index idx can do the work of the index for acc: its
+// leading columns are acc's equality keys in any order, followed by acc's tail, and its
+// predicate, if any, is acc's. INCLUDE columns are not compared.
+func (idx ExistingIndex) serves(acc indexAccess) bool {
+	if !sameRelation(idx.Schema, idx.Table, acc.schema, acc.table) || indexMethod(idx.Method) != "btree" {
+		return false
+	}
+	if idx.Predicate != "" && !strings.EqualFold(idx.Predicate, acc.predicate) {
+		return false
+	}
+	keys := indexKeys(idx.Columns)
+	n := len(acc.eq)
+	if len(keys) < n+len(acc.tail) || !sameSet(keys[:n], acc.eq) {
+		return false
+	}
+	return slices.Equal(keys[n:n+len(acc.tail)], acc.tail)
+}
+
+// indexKeys returns the comparable keys of index columns.
+func indexKeys(cols []string) []string {
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		out[i] = indexColumnKey(c)
+	}
+	return out
+}
+
+// indexColumnKey returns the column or expression of an index element, without its
+// collation, operator class, ordering, and qualifiers, e.g. "lower(email)" for
+// "LOWER(u.email) DESC".
+func indexColumnKey(elem string) string {
+	toks := sqllex.Lex(elem)
+	if len(toks) > 3 && toks[0].Kind == sqllex.Ident && toks[1].Is("(") {
+		depth := 0
+		for i, t := range toks {
+			if t.Is("(") {
+				depth++
+			} else if t.Is(")") {
+				if depth--; depth == 0 {
+					return strings.ToLower(toks[0].Text) + "(" + unqualified(toks[2:i]) + ")"
+				}
+			}
+		}
+	}
+	if len(toks) > 1 && toks[0].Is("(") {
+		return indexColumnKey(elem[strings.Index(elem, "(")+1 : strings.LastIndex(elem, ")")])
+	}
+	end := 0
+	for end < len(toks) && (toks[end].Kind == sqllex.Ident || toks[end].Kind == sqllex.Quoted || toks[end].Is(".")) {
+		if end > 0 && toks[end].Kind != sqllex.Punct && !toks[end-1].Is(".") {
+			break
+		}
+		end++
+	}
+	return unqualified(toks[:end])
+}
+
+// indexMethod returns the access method of an index, defaulting to btree.
+func indexMethod(method string) string {
+	if method == "" {
+		return "btree"
+	}
+	return strings.ToLower(method)
+}
+
+// indexName returns the name PostgreSQL gives an index of table on columns.
+func indexName(table string, columns []string) string {
+	parts := []string{trimQuotes(table)}
+	for _, c := range columns {
+		if i := strings.IndexByte(c, '('); i > 0 {
+			c = c[:i]
+		}
+		parts = append(parts, trimQuotes(c))
+	}
+	return strings.Join(parts, "_") + "_idx"
+}
+
+// sameRelation reports whether two table names refer to the same table. An empty
+// schema matches any schema.
+func sameRelation(schemaA, nameA, schemaB, nameB string) bool {
+	return strings.EqualFold(trimQuotes(nameA), trimQuotes(nameB)) && sameSchema(schemaA, schemaB)
+}
+
+// sameSchema reports whether two schemas match, treating an empty one as any schema.
+func sameSchema(a, b string) bool {
+	return a == "" || b == "" || strings.EqualFold(trimQuotes(a), trimQuotes(b))
+}
+
+// sameSet reports whether a and b hold the same strings.
+func sameSet(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, s := range a {
+		if !slices.Contains(b, s) {
+			return false
+		}
+	}
+	return true
+}
+
+// appendUnique appends s to list unless it is already there.
+func appendUnique(list []string, s string) []string {
+	if slices.Contains(list, s) {
+		return list
+	}
+	return append(list, s)
+}
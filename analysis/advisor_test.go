@@ -0,0 +1,209 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// advisorDDL is the schema of the advisor tests.
+const advisorDDL = `CREATE TABLE users (id bigint PRIMARY KEY, email text NOT NULL, name text, deleted_at timestamptz);
+CREATE TABLE orders (id bigint PRIMARY KEY, user_id bigint NOT NULL, status text, total numeric, created_at timestamptz);`
+
+// recommendationDDL renders recommendations as CREATE INDEX statements.
+func recommendationDDL(recs []IndexRecommendation) []string {
+	var out []string
+	for _, r := range recs {
+		out = append(out, r.DDL())
+	}
+	return out
+}
+
+func TestAdviseIndexes_Recommendations(t *testing.T) {
+	tests := []struct {
+		name    string
+		queries []string
+		ddl     string // Extra DDL after advisorDDL
+		want    []string
+	}{
+		{
+			name:    "equality then range",
+			queries: []string{"SELECT * FROM orders WHERE created_at >= $1 AND user_id = $2"},
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_user_id_created_at_idx ON orders (user_id, created_at)"},
+		},
+		{
+			name:    "in list and between",
+			queries: []string{"SELECT * FROM orders WHERE status IN ('new', 'paid') AND total BETWEEN 10 AND 20"},
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_status_total_idx ON orders (status, total)"},
+		},
+		{
+			name:    "order by with limit",
+			queries: []string{"SELECT * FROM orders WHERE user_id = $1 ORDER BY created_at DESC LIMIT 20"},
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_user_id_created_at_idx ON orders (user_id, created_at)"},
+		},
+		{
+			name:    "order by without limit",
+			queries: []string{"SELECT * FROM orders WHERE user_id = $1 ORDER BY created_at"},
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_user_id_idx ON orders (user_id)"},
+		},
+		{
+			name:    "expression and partial index",
+			queries: []string{"SELECT u.id FROM users u WHERE lower(u.email) = $1 AND u.deleted_at IS NULL"},
+			want:    []string{"CREATE INDEX CONCURRENTLY users_lower_idx ON users (lower(email)) INCLUDE (id) WHERE deleted_at IS NULL"},
+		},
+		{
+			name:    "join key of the inner relation",
+			queries: []string{"SELECT o.total FROM users u JOIN orders o ON o.user_id = u.id WHERE u.email = $1"},
+			want: []string{
+				"CREATE INDEX CONCURRENTLY orders_user_id_idx ON orders (user_id) INCLUDE (total)",
+				"CREATE INDEX CONCURRENTLY users_email_idx ON users (email) INCLUDE (id)",
+			},
+		},
+		{
+			name:    "covering index",
+			queries: []string{"SELECT id, total FROM orders WHERE status = $1"},
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_status_idx ON orders (status) INCLUDE (id, total)"},
+		},
+		{
+			name:    "conditions under OR are ignored",
+			queries: []string{"SELECT * FROM orders WHERE user_id = $1 AND (status = 'new' OR total > 100)"},
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_user_id_idx ON orders (user_id)"},
+		},
+		{
+			name:    "unindexable operators",
+			queries: []string{"SELECT * FROM orders WHERE status <> 'new' AND status LIKE '%x' OR total > 1"},
+		},
+		{
+			name:    "served by an existing index",
+			queries: []string{"SELECT * FROM orders WHERE created_at > $1 AND status = $2", "SELECT * FROM users WHERE id = $1"},
+			ddl:     "CREATE INDEX orders_status_created_idx ON orders (status, created_at DESC)",
+		},
+		{
+			name:    "partial existing index serves only its predicate",
+			queries: []string{"SELECT * FROM orders WHERE status = $1"},
+			ddl:     "CREATE INDEX orders_status_open_idx ON orders (status) WHERE total IS NULL",
+			want:    []string{"CREATE INDEX CONCURRENTLY orders_status_idx ON orders (status)"},
+		},
+		{
+			name: "shorter recommendation folds into a longer one",
+			queries: []string{
+				"SELECT * FROM orders WHERE user_id = $1",
+				"DELETE FROM orders WHERE user_id = $1 AND created_at < now() - interval '1 year'",
+			},
+			want: []string{"CREATE INDEX CONCURRENTLY orders_user_id_created_at_idx ON orders (user_id, created_at)"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var workload []WorkloadQuery
+			for _, q := range tt.queries {
+				workload = append(workload, WorkloadQuery{SQL: q})
+			}
+			advice, err := AdviseIndexes(workload, advisorDDL+"\n"+tt.ddl)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, recommendationDDL(advice.Recommendations))
+		})
+	}
+}
+
+func TestAdviseIndexes_Workload(t *testing.T) {
+	advice, err := AdviseIndexes([]WorkloadQuery{
+		{SQL: "SELECT * FROM orders WHERE user_id = $1", Calls: 40},
+		{SQL: "SELECT * FROM users WHERE email = $1", Calls: 900},
+		{SQL: "SELECT * FROM orders WHERE user_id = $1 AND created_at > $2", Calls: 60},
+		{SQL: "SELEC 1"},
+	}, advisorDDL)
+	require.NoError(t, err)
+
+	require.Len(t, advice.Recommendations, 2)
+	assert.Equal(t, IndexRecommendation{Table: "users", Columns: []string{"email"}, Calls: 900, Queries: []int{1}}, advice.Recommendations[0])
+	assert.Equal(t, IndexRecommendation{Table: "orders", Columns: []string{"user_id", "created_at"}, Calls: 100, Queries: []int{0, 2}}, advice.Recommendations[1])
+	require.Len(t, advice.Skipped, 1)
+	assert.Equal(t, 3, advice.Skipped[0].Query)
+
+	_, err = AdviseIndexes(nil, "CREATE TABLE (")
+	assert.Error(t, err)
+}
+
+func TestAdviseIndexes_ExistingIndexes(t *testing.T) {
+	ddl := advisorDDL + `
+CREATE INDEX orders_status_idx ON orders (status);
+CREATE INDEX orders_status_created_idx ON orders (status, created_at);
+CREATE INDEX orders_user_idx ON orders (user_id);
+CREATE INDEX orders_user_idx2 ON orders (user_id);
+CREATE INDEX orders_total_idx ON orders (total);
+CREATE UNIQUE INDEX users_email_key ON users (email);
+CREATE INDEX users_email_idx ON users (lower(email));
+CREATE INDEX users_id_idx ON users (id);
+CREATE INDEX users_name_trgm ON users USING gin (name);
+CREATE INDEX products_sku_idx ON products (sku);
+DROP INDEX users_name_trgm;`
+	advice, err := AdviseIndexes([]WorkloadQuery{
+		{SQL: "SELECT * FROM orders o JOIN users u ON u.id = o.user_id WHERE o.status = $1"},
+		{SQL: "SELECT * FROM users WHERE lower(email) = $1"},
+	}, ddl)
+	require.NoError(t, err)
+
+	var unused []string
+	for _, idx := range advice.Unused {
+		unused = append(unused, idx.Name)
+	}
+	assert.Equal(t, []string{"orders_total_idx"}, unused, "unused")
+
+	var redundant []string
+	for _, r := range advice.Redundant {
+		redundant = append(redundant, r.Index.Name+" < "+r.CoveredBy.Name)
+	}
+	assert.Equal(t, []string{
+		"orders_status_idx < orders_status_created_idx",
+		"orders_user_idx2 < orders_user_idx",
+		"users_id_idx < users_pkey",
+	}, redundant, "redundant")
+}
+
+func TestAdviseIndexes_Structure(t *testing.T) {
+	advice, err := AdviseIndexes([]WorkloadQuery{
+		{SQL: "SELECT u.id FROM users u WHERE lower(u.email) = $1 AND u.deleted_at IS NULL"},
+	}, advisorDDL)
+	require.NoError(t, err)
+	assert.Equal(t, &IndexAdvice{
+		Recommendations: []IndexRecommendation{{
+			Table:     "users",
+			Columns:   []string{"lower(email)"},
+			Include:   []string{"id"},
+			Predicate: "deleted_at IS NULL",
+			Calls:     1,
+			Queries:   []int{0},
+		}},
+	}, advice)
+
+	advice, err = AdviseIndexes([]WorkloadQuery{{SQL: "SELECT * FROM orders WHERE status = $1"}}, advisorDDL+`
+CREATE INDEX orders_status_idx ON orders (status);
+CREATE INDEX orders_status_created_idx ON orders (status, created_at DESC);
+CREATE INDEX orders_total_idx ON orders USING brin (total) WHERE total > 0;`)
+	require.NoError(t, err)
+	assert.Equal(t, &IndexAdvice{
+		Unused: []ExistingIndex{{Name: "orders_total_idx", Table: "orders", Columns: []string{"total"}, Predicate: "total > 0", Method: "brin"}},
+		Redundant: []RedundantIndex{{
+			Index:     ExistingIndex{Name: "orders_status_idx", Table: "orders", Columns: []string{"status"}},
+			CoveredBy: ExistingIndex{Name: "orders_status_created_idx", Table: "orders", Columns: []string{"status", "created_at DESC"}},
+		}},
+	}, advice)
+}
+
+func TestParseStatStatements(t *testing.T) {
+	export := "userid,calls,query\n10,42,\"SELECT * FROM users WHERE email = $1\"\n10,7,\"UPDATE users\nSET name = $1 WHERE id = $2\"\n"
+	queries, err := ParseStatStatements(strings.NewReader(export))
+	require.NoError(t, err)
+	assert.Equal(t, []WorkloadQuery{
+		{SQL: "SELECT * FROM users WHERE email = $1", Calls: 42},
+		{SQL: "UPDATE users\nSET name = $1 WHERE id = $2", Calls: 7},
+	}, queries)
+
+	_, err = ParseStatStatements(strings.NewReader("calls\n1\n"))
+	assert.Error(t, err, "missing query column")
+	_, err = ParseStatStatements(strings.NewReader("query,calls\nSELECT 1,many\n"))
+	assert.Error(t, err, "invalid calls")
+}
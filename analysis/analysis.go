@@ -46,6 +46,11 @@ func convertParsedQuery(pq *postgresparser.ParsedQuery) *SQLAnalysis {
 	res.Returning = normalizeReturning(pq.Returning)
 	res.Merge = convertMerge(pq.Merge)
 	res.DDLActions = convertDDLActions(pq.DDLActions)
+	if pq.Target != nil {
+		target := convertTables([]postgresparser.TableRef{*pq.Target})[0]
+		res.Target = &target
+	}
+	res.Source = convertParsedQuery(pq.Source)
 	res.ColumnUsage = convertColumnUsage(pq.ColumnUsage)
 	res.Correlations = convertCorrelations(pq.Correlations)
 	if pq.DerivedColumns != nil {
@@ -74,6 +79,7 @@ func convertColumnUsage(usage []postgresparser.ColumnUsage) []SQLColumnUsage {
 			Operator:   u.Operator,
 			Side:       u.Side,
 			Functions:  append([]string(nil), u.Functions...),
+			Position:   u.Position,
 		})
 	}
 	return out
@@ -152,9 +158,15 @@ func convertCTEs(ctes []postgresparser.CTE) []SQLCTE {
 	out := make([]SQLCTE, 0, len(ctes))
 	for _, cte := range ctes {
 		out = append(out, SQLCTE{
-			Name:         cte.Name,
-			Query:        cte.Query,
-			Materialized: cte.Materialized,
+			Name:          cte.Name,
+			Query:         cte.Query,
+			Materialized:  cte.Materialized,
+			Columns:       append([]string(nil), cte.Columns...),
+			Recursive:     cte.Recursive,
+			Modifying:     cte.Modifying,
+			Analysis:      convertParsedQuery(cte.Body),
+			Anchor:        convertParsedQuery(cte.Anchor),
+			RecursiveTerm: convertParsedQuery(cte.RecursiveTerm),
 		})
 	}
 	return out
@@ -334,13 +346,85 @@ func convertDDLActions(actions []postgresparser.DDLAction) []SQLDDLAction {
 	out := make([]SQLDDLAction, 0, len(actions))
 	for _, a := range actions {
 		out = append(out, SQLDDLAction{
-			Type:          string(a.Type),
-			ObjectName:    a.ObjectName,
-			Schema:        a.Schema,
-			Columns:       append([]string(nil), a.Columns...),
-			ColumnDetails: convertDDLColumns(a.ColumnDetails),
-			Flags:         append([]string(nil), a.Flags...),
-			IndexType:     a.IndexType,
+			Type:            string(a.Type),
+			ObjectName:      a.ObjectName,
+			Schema:          a.Schema,
+			Columns:         append([]string(nil), a.Columns...),
+			ColumnDetails:   convertDDLColumns(a.ColumnDetails),
+			Flags:           append([]string(nil), a.Flags...),
+			IndexType:       a.IndexType,
+			ObjectKind:      string(a.ObjectKind),
+			Table:           a.Table,
+			Owner:           a.Owner,
+			Comment:         a.Comment,
+			Server:          a.Server,
+			Wrapper:         a.Wrapper,
+			RemoteSchema:    a.RemoteSchema,
+			Connection:      a.Connection,
+			Objects:         append([]string(nil), a.Objects...),
+			Options:         convertDDLOptions(a.Options),
+			PublishedTables: convertPublicationTables(a.PublishedTables),
+			NewSchema:       a.NewSchema,
+			NewName:         a.NewName,
+			Constraints:     convertDDLConstraints(a.Constraints),
+			EnumValues:      append([]string(nil), a.EnumValues...),
+			DataType:        a.DataType,
+			Tablespace:      a.Tablespace,
+			Signature:       a.Signature,
+			IncludeColumns:  append([]string(nil), a.IncludeColumns...),
+			Predicate:       a.Predicate,
+		})
+	}
+	return out
+}
+
+// convertDDLConstraints maps parser DDL constraints into analysis DTOs.
+func convertDDLConstraints(cons []postgresparser.DDLConstraint) []SQLDDLConstraint {
+	if len(cons) == 0 {
+		return nil
+	}
+	out := make([]SQLDDLConstraint, 0, len(cons))
+	for _, c := range cons {
+		out = append(out, SQLDDLConstraint{
+			Name:       c.Name,
+			Type:       string(c.Type),
+			Columns:    append([]string(nil), c.Columns...),
+			RefSchema:  c.RefSchema,
+			RefTable:   c.RefTable,
+			RefColumns: append([]string(nil), c.RefColumns...),
+			OnDelete:   c.OnDelete,
+			OnUpdate:   c.OnUpdate,
+			Expression: c.Expression,
+			NotValid:   c.NotValid,
+		})
+	}
+	return out
+}
+
+// convertDDLOptions maps parser DDL options into analysis DTOs.
+func convertDDLOptions(opts []postgresparser.DDLOption) []SQLDDLOption {
+	if len(opts) == 0 {
+		return nil
+	}
+	out := make([]SQLDDLOption, 0, len(opts))
+	for _, o := range opts {
+		out = append(out, SQLDDLOption{Name: o.Name, Value: o.Value})
+	}
+	return out
+}
+
+// convertPublicationTables maps parser publication table entries into analysis DTOs.
+func convertPublicationTables(tables []postgresparser.PublicationTable) []SQLPublicationTable {
+	if len(tables) == 0 {
+		return nil
+	}
+	out := make([]SQLPublicationTable, 0, len(tables))
+	for _, t := range tables {
+		out = append(out, SQLPublicationTable{
+			Schema:    t.Schema,
+			Name:      t.Name,
+			Columns:   append([]string(nil), t.Columns...),
+			RowFilter: t.RowFilter,
 		})
 	}
 	return out
@@ -376,6 +376,26 @@ func TestAnalyze_CTEs_NotMaterialized(t *testing.T) {
 		"AS NOT MATERIALIZED should set Materialized to 'NOT MATERIALIZED'")
 }
 
+// TestAnalyze_CTEs_RecursiveBody verifies that the parsed body, column list, and
+// recursive branches of a CTE are carried into the analysis.
+func TestAnalyze_CTEs_RecursiveBody(t *testing.T) {
+	sql := `WITH RECURSIVE chain (id) AS (SELECT id FROM nodes WHERE id = $1 UNION ALL SELECT n.parent_id FROM nodes n JOIN chain c ON n.id = c.id) SELECT * FROM chain`
+
+	result, err := AnalyzeSQL(sql)
+	require.NoError(t, err)
+	require.Len(t, result.CTEs, 1)
+
+	cte := result.CTEs[0]
+	assert.Equal(t, []string{"id"}, cte.Columns, "CTE column list")
+	assert.True(t, cte.Recursive, "WITH RECURSIVE should set Recursive")
+	assert.False(t, cte.Modifying, "SELECT body is not data-modifying")
+	require.NotNil(t, cte.Analysis, "CTE body should be analyzed")
+	assert.Equal(t, SQLCommandSelect, cte.Analysis.Command)
+	require.NotNil(t, cte.Anchor, "recursive CTE should have an anchor")
+	require.NotNil(t, cte.RecursiveTerm, "recursive CTE should have a recursive term")
+	assert.Equal(t, "SELECT id FROM nodes WHERE id = $1", cte.Anchor.RawSQL)
+}
+
 // =============================================================================
 // 7. Columns -- verify column expression and alias extraction
 // =============================================================================
@@ -603,6 +603,43 @@ func TestAnalyzeSQL_DDL_AlterTableMultiAction(t *testing.T) {
 	}
 }
 
+// TestAnalyzeSQL_DDL_AlterTableOwnerAndRLS verifies OWNER TO and row level security sub-commands in the DTO.
+func TestAnalyzeSQL_DDL_AlterTableOwnerAndRLS(t *testing.T) {
+	res, err := AnalyzeSQL("ALTER TABLE public.accounts OWNER TO app_owner, ENABLE ROW LEVEL SECURITY, SET TABLESPACE fastdisk")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 3 {
+		t.Fatalf("expected 3 DDL actions, got %d: %+v", len(res.DDLActions), res.DDLActions)
+	}
+	owner := res.DDLActions[0]
+	assertAnalysisFlag(t, owner.Flags, "OWNER_TO")
+	if owner.Owner != "app_owner" || owner.ObjectKind != "TABLE" {
+		t.Fatalf("unexpected OWNER TO action: %+v", owner)
+	}
+	assertAnalysisFlag(t, res.DDLActions[1].Flags, "ENABLE_ROW_LEVEL_SECURITY")
+	assertAnalysisFlag(t, res.DDLActions[2].Flags, "SET_TABLESPACE")
+	if res.DDLActions[2].Tablespace != "fastdisk" {
+		t.Fatalf("expected tablespace fastdisk, got %q", res.DDLActions[2].Tablespace)
+	}
+}
+
+// TestAnalyzeSQL_DDL_AlterSetSchema verifies the target schema of ALTER ... SET SCHEMA in the DTO.
+func TestAnalyzeSQL_DDL_AlterSetSchema(t *testing.T) {
+	res, err := AnalyzeSQL("ALTER VIEW reporting.daily SET SCHEMA archive")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	act := res.DDLActions[0]
+	if act.Type != "ALTER" || act.ObjectKind != "VIEW" || act.NewSchema != "archive" {
+		t.Fatalf("unexpected action: %+v", act)
+	}
+	assertAnalysisFlag(t, act.Flags, "SET_SCHEMA")
+}
+
 // TestAnalyzeSQL_DDL_Truncate validates TRUNCATE with CASCADE, RESTRICT, and multi-table support.
 func TestAnalyzeSQL_DDL_Truncate(t *testing.T) {
 	tests := []struct {
@@ -700,6 +737,123 @@ func TestAnalyzeSQL_DDL_Truncate(t *testing.T) {
 	}
 }
 
+// TestAnalyzeSQL_DDL_Comment verifies COMMENT ON metadata is carried into the analysis DTO.
+func TestAnalyzeSQL_DDL_Comment(t *testing.T) {
+	res, err := AnalyzeSQL("COMMENT ON COLUMN public.users.email IS 'Primary email'")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if res.Command != SQLCommandDDL {
+		t.Fatalf("expected DDL command, got %s", res.Command)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	act := res.DDLActions[0]
+	if act.Type != "COMMENT" || act.ObjectKind != "COLUMN" {
+		t.Fatalf("expected COMMENT on COLUMN, got %s on %s", act.Type, act.ObjectKind)
+	}
+	if act.Schema != "public" || act.ObjectName != "users" {
+		t.Fatalf("expected public.users, got %s.%s", act.Schema, act.ObjectName)
+	}
+	if len(act.Columns) != 1 || act.Columns[0] != "email" {
+		t.Fatalf("expected columns [email], got %v", act.Columns)
+	}
+	if act.Comment != "Primary email" {
+		t.Fatalf("expected comment %q, got %q", "Primary email", act.Comment)
+	}
+}
+
+// TestAnalyzeSQL_DDL_CreateSchema verifies CREATE SCHEMA owner metadata in the analysis DTO.
+func TestAnalyzeSQL_DDL_CreateSchema(t *testing.T) {
+	res, err := AnalyzeSQL("CREATE SCHEMA IF NOT EXISTS app AUTHORIZATION bob")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	act := res.DDLActions[0]
+	if act.Type != "CREATE_SCHEMA" || act.ObjectName != "app" || act.Owner != "bob" {
+		t.Fatalf("unexpected action: %+v", act)
+	}
+	assertAnalysisFlag(t, act.Flags, "IF_NOT_EXISTS")
+}
+
+// TestAnalyzeSQL_DDL_Publication verifies published tables carry column lists and row filters.
+func TestAnalyzeSQL_DDL_Publication(t *testing.T) {
+	res, err := AnalyzeSQL("CREATE PUBLICATION orders_pub FOR TABLE sales.orders (id, total) WHERE (total > 0)")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 || res.DDLActions[0].Type != "CREATE_PUBLICATION" {
+		t.Fatalf("expected CREATE_PUBLICATION action, got %+v", res.DDLActions)
+	}
+	pub := res.DDLActions[0].PublishedTables
+	if len(pub) != 1 || pub[0].Schema != "sales" || pub[0].Name != "orders" {
+		t.Fatalf("expected published table sales.orders, got %+v", pub)
+	}
+	if len(pub[0].Columns) != 2 || pub[0].RowFilter != "total > 0" {
+		t.Fatalf("unexpected column list/row filter: %+v", pub[0])
+	}
+}
+
+// TestAnalyzeSQL_DDL_UserMappingRedaction verifies password options stay redacted in the DTO.
+func TestAnalyzeSQL_DDL_UserMappingRedaction(t *testing.T) {
+	res, err := AnalyzeSQL("CREATE USER MAPPING FOR app SERVER billing OPTIONS (user 'r', password 'hunter2')")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	for _, opt := range res.DDLActions[0].Options {
+		if opt.Value == "hunter2" {
+			t.Fatalf("password leaked in options: %+v", res.DDLActions[0].Options)
+		}
+	}
+}
+
+// TestAnalyzeSQL_DDL_DropFunction verifies DROP object kind and signature in the analysis DTO.
+func TestAnalyzeSQL_DDL_DropFunction(t *testing.T) {
+	res, err := AnalyzeSQL("DROP FUNCTION IF EXISTS util.add(integer, integer) CASCADE")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	act := res.DDLActions[0]
+	if act.Type != "DROP" || act.ObjectKind != "FUNCTION" {
+		t.Fatalf("expected DROP of FUNCTION, got %s of %s", act.Type, act.ObjectKind)
+	}
+	if act.Schema != "util" || act.ObjectName != "add" || act.Signature != "(integer, integer)" {
+		t.Fatalf("unexpected function identity: %+v", act)
+	}
+	assertAnalysisFlag(t, act.Flags, "IF_EXISTS")
+	assertAnalysisFlag(t, act.Flags, "CASCADE")
+}
+
+// TestAnalyzeSQL_DDL_AddForeignKey verifies constraint details in the analysis DTO.
+func TestAnalyzeSQL_DDL_AddForeignKey(t *testing.T) {
+	res, err := AnalyzeSQL("ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if len(res.DDLActions) != 1 {
+		t.Fatalf("expected 1 DDL action, got %d", len(res.DDLActions))
+	}
+	act := res.DDLActions[0]
+	assertAnalysisFlag(t, act.Flags, "ADD_CONSTRAINT")
+	if len(act.Constraints) != 1 {
+		t.Fatalf("expected 1 constraint, got %+v", act.Constraints)
+	}
+	con := act.Constraints[0]
+	if con.Name != "orders_user_fk" || con.Type != "FOREIGN KEY" || con.RefTable != "users" || con.OnDelete != "SET NULL" {
+		t.Fatalf("unexpected constraint: %+v", con)
+	}
+}
+
 func assertAnalysisFlag(t *testing.T, flags []string, flag string) {
 	t.Helper()
 	for _, f := range flags {
@@ -131,6 +131,26 @@ RETURNING id`
 	}
 }
 
+// TestAnalyzeSQLInsertSelectLineage verifies the INSERT target and nested source are carried into the DTO.
+func TestAnalyzeSQLInsertSelectLineage(t *testing.T) {
+	res, err := AnalyzeSQL("INSERT INTO audit.events (id) SELECT id FROM events WHERE kind = 'login'")
+	if err != nil {
+		t.Fatalf("AnalyzeSQL failed: %v", err)
+	}
+	if res.Target == nil || res.Target.Schema != "audit" || res.Target.Name != "events" {
+		t.Fatalf("expected target audit.events, got %+v", res.Target)
+	}
+	if res.Source == nil {
+		t.Fatalf("expected source analysis")
+	}
+	if res.Source.Command != SQLCommandSelect {
+		t.Fatalf("expected SELECT source, got %s", res.Source.Command)
+	}
+	if len(res.Source.Tables) != 1 || res.Source.Tables[0].Name != "events" || res.Source.Tables[0].Schema != "" {
+		t.Fatalf("expected source table events, got %+v", res.Source.Tables)
+	}
+}
+
 // TestAnalyzeSQLMultiJoinUsage verifies ColumnUsage distinguishes aliases in joins.
 func TestAnalyzeSQLMultiJoinUsage(t *testing.T) {
 	sql := `SELECT o.id, c.name, u.name
@@ -16,6 +16,10 @@ type QueryAnalysisResult struct {
 	// WhereConditions extracted from the WHERE clause
 	WhereConditions []WhereCondition
 
+	// WhereConditionTree is the AND/OR/NOT structure of the statement's WHERE
+	// clause, or nil if it has none
+	WhereConditionTree *ConditionNode
+
 	// JoinRelationships inferred from JOIN ON conditions
 	JoinRelationships []JoinRelationship
 
@@ -47,7 +51,8 @@ func ExtractQueryAnalysis(query string) (*QueryAnalysisResult, error) {
 	}
 
 	// Extract WHERE conditions from the parsed query
-	result.WhereConditions = extractWhereConditionsFromParsed(pq)
+	result.WhereConditions = extractWhereConditionsFromParsed(pq, nil)
+	result.WhereConditionTree = extractWhereConditionTree(pq, nil)
 
 	// JoinRelationships is nil: FK detection requires schema metadata.
 	// Use ExtractQueryAnalysisWithSchema for FK relationship extraction.
@@ -57,73 +62,89 @@ func ExtractQueryAnalysis(query string) (*QueryAnalysisResult, error) {
 
 // extractWhereConditionsFromParsed extracts WHERE conditions from an already-parsed query.
 // This is the internal implementation shared by both ExtractWhereConditions and ExtractQueryAnalysis.
-func extractWhereConditionsFromParsed(pq *postgresparser.ParsedQuery) []WhereCondition {
+// When resolved is non-nil it holds the schema-based resolution of each ColumnUsage and
+// supplies the table of unqualified columns in multi-table queries.
+func extractWhereConditionsFromParsed(pq *postgresparser.ParsedQuery, resolved []ResolvedColumn) []WhereCondition {
 	var conditions []WhereCondition
 
 	// Extract conditions from ColumnUsage with filter type
-	for _, usage := range pq.ColumnUsage {
-		if usage.UsageType != postgresparser.ColumnUsageTypeFilter {
-			continue
+	for i := range pq.ColumnUsage {
+		if condition, ok := whereConditionFromUsage(pq, i, resolved); ok {
+			conditions = append(conditions, condition)
 		}
+	}
 
-		// Skip if no operator (shouldn't happen in WHERE clauses, but safety check)
-		if usage.Operator == "" {
-			continue
-		}
+	return conditions
+}
 
-		// Skip JSONB extraction operators (->>, ->, #>>, #>) if they are the main operator
-		// These are usually part of larger comparison expressions and are handled via Context analysis
-		if jsonbOperators[usage.Operator] {
-			continue
-		}
+// whereConditionFromUsage builds the WHERE condition of ColumnUsage i, or reports false
+// if the usage is not a filter comparison.
+func whereConditionFromUsage(pq *postgresparser.ParsedQuery, i int, resolved []ResolvedColumn) (WhereCondition, bool) {
+	usage := pq.ColumnUsage[i]
+	if usage.UsageType != postgresparser.ColumnUsageTypeFilter {
+		return WhereCondition{}, false
+	}
 
-		// Resolve table name from alias, or use first table only for single-table queries
-		tableName := resolveTableName(usage.TableAlias, pq.Tables)
-		if tableName == "" && len(pq.Tables) == 1 {
-			// No alias and no resolution - default to first table only for single-table queries
-			tableName = pq.Tables[0].Name
-		}
+	// Skip if no operator (shouldn't happen in WHERE clauses, but safety check)
+	if usage.Operator == "" {
+		return WhereCondition{}, false
+	}
 
-		condition := WhereCondition{
-			Table:    tableName,
-			Column:   usage.Column,
-			Operator: normalizeOperator(usage.Operator),
-		}
+	// Skip JSONB extraction operators (->>, ->, #>>, #>) if they are the main operator
+	// These are usually part of larger comparison expressions and are handled via Context analysis
+	if jsonbOperators[usage.Operator] {
+		return WhereCondition{}, false
+	}
 
-		// Check for JSONB-specific operators (@>, <@, ?, ?|, ?&)
-		// These operate directly on JSONB columns without extraction
-		switch condition.Operator {
-		case "@>", "<@", "?", "?|", "?&":
-			condition.IsJSONB = true
-		}
+	// Resolve table name from alias, or use first table only for single-table queries
+	tableName := resolveTableName(usage.TableAlias, pq.Tables)
+	if tableName == "" && len(pq.Tables) == 1 {
+		// No alias and no resolution - default to first table only for single-table queries
+		tableName = pq.Tables[0].Name
+	}
+	if tableName == "" && resolved != nil && resolved[i].Status == ColumnResolved {
+		tableName = resolved[i].Table
+	}
 
-		// Check if this is a JSONB comparison (context contains JSONB pattern)
-		if jsonbInfo := extractJSONBInfo(usage.Context); jsonbInfo != nil {
-			condition.IsJSONB = true
-			condition.Column = jsonbInfo.column // The JSONB column name
-			condition.JSONBKey = jsonbInfo.key  // The key being extracted
-			if jsonbInfo.castType != "" {
-				condition.JSONBCast = jsonbInfo.castType
-			}
-		}
+	condition := WhereCondition{
+		Table:    tableName,
+		Column:   usage.Column,
+		Operator: normalizeOperator(usage.Operator),
+	}
 
-		// Extract value from context (full comparison expression)
-		// Context contains the full comparison like "status = 'pending'"
-		value, isParam := extractValueFromContext(usage.Context, usage.Column, usage.Operator)
-		condition.Value = value
-		condition.IsParameter = isParam
+	// Check for JSONB-specific operators (@>, <@, ?, ?|, ?&)
+	// These operate directly on JSONB columns without extraction
+	switch condition.Operator {
+	case "@>", "<@", "?", "?|", "?&":
+		condition.IsJSONB = true
+	}
 
-		conditions = append(conditions, condition)
+	// Check if this is a JSONB comparison (context contains JSONB pattern)
+	if jsonbInfo := extractJSONBInfo(usage.Context); jsonbInfo != nil {
+		condition.IsJSONB = true
+		condition.Column = jsonbInfo.column // The JSONB column name
+		condition.JSONBKey = jsonbInfo.key  // The key being extracted
+		if jsonbInfo.castType != "" {
+			condition.JSONBCast = jsonbInfo.castType
+		}
 	}
 
-	return conditions
+	// Extract value from context (full comparison expression)
+	// Context contains the full comparison like "status = 'pending'"
+	value, isParam := extractValueFromContext(usage.Context, usage.Column, usage.Operator)
+	condition.Value = value
+	condition.IsParameter = isParam
+
+	return condition, true
 }
 
 // ExtractQueryAnalysisWithSchema parses a query and extracts analysis results,
 // using schema metadata to improve FK relationship inference accuracy.
 //
 // Schema-aware extraction uses the IsPrimaryKey field from
-// schema metadata instead of heuristic name-based detection.
+// schema metadata instead of heuristic name-based detection, and fills the
+// Table of WHERE conditions on unqualified columns by resolving them against
+// the schema (see ResolveColumnUsage).
 //
 // The schemaMap should be keyed by lowercase table name, with each value
 // containing the column schemas for that table.
@@ -138,8 +159,10 @@ func ExtractQueryAnalysisWithSchema(query string, schemaMap map[string][]ColumnS
 		ParsedQuery: pq,
 	}
 
-	// Extract WHERE conditions (schema-independent)
-	result.WhereConditions = extractWhereConditionsFromParsed(pq)
+	// Extract WHERE conditions, resolving unqualified columns against the schema
+	resolved := ResolveColumnUsage(pq, schemaMap)
+	result.WhereConditions = extractWhereConditionsFromParsed(pq, resolved)
+	result.WhereConditionTree = extractWhereConditionTree(pq, resolved)
 
 	// Extract JOIN relationships with schema awareness
 	result.JoinRelationships = extractJoinRelationshipsWithSchema(pq, schemaMap)
@@ -0,0 +1,248 @@
+// expand.go lists the result columns of a query, expanding * and alias.* against
+// schema metadata.
+package analysis
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+)
+
+// ErrUnknownColumns is returned when a * cannot be expanded because the columns of a
+// relation it covers are not known.
+var ErrUnknownColumns = errors.New("relation columns unknown")
+
+// ResultColumn is an output column of a query.
+type ResultColumn struct {
+	Name       string                 // Column name as PostgreSQL reports it
+	Expression string                 // Select-list expression, or alias.column for a column expanded from a star
+	Star       bool                   // The column was expanded from * or alias.*
+	Status     ColumnResolutionStatus // Resolution of the column's value, as for ResolvedColumn
+	Schema     string                 // Owning table's schema as written in the query, when resolved
+	Table      string                 // Owning base table, when resolved
+	Column     string                 // Column in the owning table, when resolved
+}
+
+// ResultColumns parses a query and returns its result columns. See ExpandResultColumns.
+func ResultColumns(query string, schemaMap map[string][]ColumnSchema) ([]ResultColumn, error) {
+	pq, err := postgresparser.ParseSQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return ExpandResultColumns(pq, schemaMap)
+}
+
+// ExpandResultColumns returns the columns a query returns, in order, with * and
+// alias.* expanded to the columns of the relations they cover. Stars are expanded
+// through CTEs and subqueries, a column list in an alias renames the leading columns,
+// and the columns of USING and NATURAL joins come first and appear once, as in
+// PostgreSQL. The columns of INSERT, UPDATE, DELETE, and MERGE are those of RETURNING;
+// statements without result columns return nil.
+//
+// Each column is resolved like ResolveColumnUsage resolves a reference. The schemaMap
+// has the same shape; a star covering a relation missing from it fails with
+// ErrUnknownColumns.
+func ExpandResultColumns(pq *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) ([]ResultColumn, error) {
+	if pq == nil || len(pq.Scopes) == 0 {
+		return nil, nil
+	}
+	r := newColumnResolver(pq, schemaMap)
+	scope := 0
+	if pq.Command == postgresparser.QueryCommandDDL {
+		// CREATE TABLE AS and CREATE VIEW return nothing themselves; use their query.
+		if scope = sourceScope(r.scopes); scope < 0 {
+			return nil, nil
+		}
+	}
+
+	var out []ResultColumn
+	for _, c := range r.scopes[scope].Columns {
+		qual, isStar := starQualifier(c.Expression)
+		if !isStar {
+			name := sqlident.OutputName(c)
+			res := r.outputValue(scope, scopeOutput{name: name, expr: c.Expression, rel: -1}, 0)
+			out = append(out, newResultColumn(name, c.Expression, false, res))
+			continue
+		}
+		expanded, ok := r.expandStar(scope, qual, 0)
+		if !ok {
+			return nil, fmt.Errorf("%w: cannot expand %s", ErrUnknownColumns, c.Expression)
+		}
+		for _, o := range expanded {
+			expr := o.name
+			if o.rel >= 0 {
+				expr = relationName(r.scopes[scope].Relations[o.rel].Table) + "." + o.column
+			}
+			out = append(out, newResultColumn(o.name, expr, true, r.outputValue(scope, o, 0)))
+		}
+	}
+	return out, nil
+}
+
+// newResultColumn builds a ResultColumn from a resolution.
+func newResultColumn(name, expr string, star bool, res ResolvedColumn) ResultColumn {
+	return ResultColumn{
+		Name:       name,
+		Expression: expr,
+		Star:       star,
+		Status:     res.Status,
+		Schema:     res.Schema,
+		Table:      res.Table,
+		Column:     res.Column,
+	}
+}
+
+// expandStar returns the columns of qual.* or * in scope, or false when a relation's
+// columns are not known. For a bare *, each FROM item contributes its columns in
+// order, and a join lists its USING or NATURAL columns first, then the remaining
+// columns of its left and right sides.
+func (r *columnResolver) expandStar(scope int, qual string, depth int) ([]scopeOutput, bool) {
+	sc := r.scopes[scope]
+	if qual != "" {
+		for ri, rel := range sc.Relations {
+			if relationMatches(rel.Table, qual) {
+				names, ok := r.relationColumnNames(scope, ri, depth)
+				return relationOutputs(ri, names), ok
+			}
+		}
+		return nil, false
+	}
+
+	// Every relation starts as its own group, keyed by its index; a join merges the
+	// group of its right side into the group of its left side.
+	groups := make([][]scopeOutput, len(sc.Relations))
+	owner := make([]int, len(sc.Relations))
+	for ri := range sc.Relations {
+		names, ok := r.relationColumnNames(scope, ri, depth)
+		if !ok {
+			return nil, false
+		}
+		groups[ri] = relationOutputs(ri, names)
+		owner[ri] = ri
+	}
+	for _, j := range sc.Joins {
+		if len(j.Left) == 0 || len(j.Right) == 0 {
+			continue
+		}
+		left, right := owner[j.Left[0]], owner[j.Right[0]]
+		if left == right {
+			continue
+		}
+		groups[left] = joinOutputs(groups[left], groups[right], j)
+		for ri := range owner {
+			if owner[ri] == right {
+				owner[ri] = left
+			}
+		}
+	}
+	var out []scopeOutput
+	for ri := range sc.Relations {
+		if owner[ri] == ri {
+			out = append(out, groups[ri]...)
+		}
+	}
+	return out, true
+}
+
+// relationOutputs returns the star outputs of relation ri with the given column names.
+func relationOutputs(ri int, names []string) []scopeOutput {
+	out := make([]scopeOutput, len(names))
+	for i, name := range names {
+		out[i] = scopeOutput{name: name, rel: ri, column: name}
+	}
+	return out
+}
+
+// joinOutputs returns the columns of a join of two column lists. A merged USING or
+// NATURAL column takes its value from the left side, from the right side of a RIGHT
+// join, and from either side of a FULL join.
+func joinOutputs(left, right []scopeOutput, j postgresparser.ScopeJoin) []scopeOutput {
+	find := func(outs []scopeOutput, name string) int {
+		for i, o := range outs {
+			if strings.EqualFold(o.name, name) {
+				return i
+			}
+		}
+		return -1
+	}
+	common := j.Using
+	if j.Natural {
+		common = nil
+		for _, o := range left {
+			if find(right, o.name) >= 0 {
+				common = append(common, o.name)
+			}
+		}
+	}
+
+	var out []scopeOutput
+	var merged []string
+	for _, name := range common {
+		l, r := find(left, name), find(right, name)
+		if l < 0 || r < 0 {
+			continue
+		}
+		col := left[l]
+		switch j.Type {
+		case "RIGHT":
+			col = right[r]
+		case "FULL":
+			col = scopeOutput{name: col.name, rel: -1}
+		}
+		out = append(out, col)
+		merged = append(merged, col.name)
+	}
+	for _, side := range [][]scopeOutput{left, right} {
+		for _, o := range side {
+			if indexFold(merged, o.name) < 0 {
+				out = append(out, o)
+			}
+		}
+	}
+	return out
+}
+
+// relationColumnNames returns the column names of relation ri of scope, or false when
+// they are not known.
+func (r *columnResolver) relationColumnNames(scope, ri, depth int) ([]string, bool) {
+	rel := r.scopes[scope].Relations[ri]
+	if depth > maxResolveDepth {
+		return nil, false
+	}
+	var names []string
+	aliases := rel.ColumnAliases
+	switch {
+	case rel.Scope >= 0:
+		for _, o := range r.scopeOutputs(rel.Scope, depth+1) {
+			if o.star {
+				return nil, false
+			}
+			names = append(names, o.name)
+		}
+		if len(aliases) == 0 {
+			aliases = r.scopes[rel.Scope].ColumnAliases
+		}
+	case rel.Table.Type == postgresparser.TableTypeFunction:
+		if len(aliases) == 0 {
+			return nil, false
+		}
+		return aliases, true
+	default:
+		cols, ok := r.tableColumns(rel.Table)
+		if !ok {
+			return nil, false
+		}
+		for _, c := range cols {
+			names = append(names, c.Name)
+		}
+	}
+	for i, alias := range aliases {
+		if i < len(names) {
+			names[i] = alias
+		}
+	}
+	return names, true
+}
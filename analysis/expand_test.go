@@ -0,0 +1,141 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// resultSummary expands query against resolveSchema and summarizes each result column
+// as "name=table.column", or "name=derived" when it has no single source column.
+func resultSummary(t *testing.T, query string) []string {
+	t.Helper()
+	cols, err := ResultColumns(query, resolveSchema)
+	require.NoError(t, err, "expand failed")
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		switch c.Status {
+		case ColumnResolved:
+			out[i] = c.Name + "=" + c.Table + "." + c.Column
+		default:
+			out[i] = c.Name + "=" + string(c.Status)
+		}
+	}
+	return out
+}
+
+func TestResultColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "star over a table",
+			query: "SELECT * FROM orgs",
+			want:  []string{"id=orgs.id", "name=orgs.name"},
+		},
+		{
+			name:  "USING column first and once",
+			query: "SELECT * FROM orders JOIN payments USING (user_id)",
+			want: []string{
+				"user_id=orders.user_id",
+				"id=orders.id", "total=orders.total", "created_at=orders.created_at",
+				"id=payments.id", "amount=payments.amount",
+			},
+		},
+		{
+			name:  "RIGHT join USING column comes from the right",
+			query: "SELECT * FROM orders RIGHT JOIN payments USING (user_id)",
+			want: []string{
+				"user_id=payments.user_id",
+				"id=orders.id", "total=orders.total", "created_at=orders.created_at",
+				"id=payments.id", "amount=payments.amount",
+			},
+		},
+		{
+			name:  "FULL join USING column is derived",
+			query: "SELECT user_id FROM (SELECT * FROM orders FULL JOIN payments USING (user_id)) j",
+			want:  []string{"user_id=derived"},
+		},
+		{
+			name:  "NATURAL join",
+			query: "SELECT * FROM orgs NATURAL JOIN users",
+			want:  []string{"id=orgs.id", "name=orgs.name", "email=users.email", "org_id=users.org_id"},
+		},
+		{
+			name:  "alias star and explicit columns",
+			query: "SELECT o.*, u.email AS who, 1 AS one FROM users u JOIN orgs o ON o.id = u.org_id",
+			want:  []string{"id=orgs.id", "name=orgs.name", "who=users.email", "one=derived"},
+		},
+		{
+			name:  "CTE with a column list",
+			query: "WITH c (uid, spent) AS (SELECT user_id, sum(total) FROM orders GROUP BY user_id) SELECT * FROM c",
+			want:  []string{"uid=orders.user_id", "spent=derived"},
+		},
+		{
+			name:  "derived table with column aliases",
+			query: "SELECT * FROM (SELECT email, name FROM users) s (e)",
+			want:  []string{"e=users.email", "name=users.name"},
+		},
+		{
+			name:  "VALUES",
+			query: "SELECT * FROM (VALUES (1, 'a'), (2, 'b')) v (n)",
+			want:  []string{"n=derived", "column2=derived"},
+		},
+		{
+			name:  "join of a join",
+			query: "SELECT * FROM orders o JOIN users u ON u.id = o.user_id JOIN payments p USING (user_id)",
+			want: []string{
+				"user_id=orders.user_id",
+				"id=orders.id", "total=orders.total", "created_at=orders.created_at",
+				"id=users.id", "email=users.email", "name=users.name", "org_id=users.org_id",
+				"id=payments.id", "amount=payments.amount",
+			},
+		},
+		{
+			name:  "view query",
+			query: "CREATE VIEW v AS SELECT * FROM orgs",
+			want:  []string{"id=orgs.id", "name=orgs.name"},
+		},
+		{
+			name:  "RETURNING star",
+			query: "DELETE FROM orgs WHERE id = 1 RETURNING *",
+			want:  []string{"id=orgs.id", "name=orgs.name"},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, resultSummary(t, tc.query))
+		})
+	}
+}
+
+func TestResultColumns_Expression(t *testing.T) {
+	cols, err := ResultColumns("SELECT u.*, now() FROM billing.invoices u", resolveSchema)
+	require.NoError(t, err, "expand failed")
+	require.Len(t, cols, 3, "result columns")
+	assert.Equal(t, ResultColumn{Name: "id", Expression: "u.id", Star: true, Status: ColumnResolved, Schema: "billing", Table: "invoices", Column: "id"}, cols[0])
+	assert.Equal(t, ResultColumn{Name: "now", Expression: "now()", Status: ColumnDerived}, cols[2])
+}
+
+func TestResultColumns_UnknownRelation(t *testing.T) {
+	_, err := ResultColumns("SELECT * FROM users JOIN audit_log a ON a.user_id = users.id", resolveSchema)
+	require.ErrorIs(t, err, ErrUnknownColumns, "expected unknown columns error")
+	assert.Contains(t, err.Error(), "*", "error names the star")
+
+	cols, err := ResultColumns("UPDATE orgs SET name = 'x'", resolveSchema)
+	require.NoError(t, err, "expand failed")
+	assert.Nil(t, cols, "no RETURNING")
+}
+
+func TestResultColumns_Names(t *testing.T) {
+	cols, err := ResultColumns(`SELECT true, NULL, 1, 1::bigint, 'a'::character varying(3)[], id::text, lower(name), CASE WHEN id > 1 THEN 1 END, "Name" FROM orgs`, resolveSchema)
+	require.NoError(t, err, "expand failed")
+	var names []string
+	for _, c := range cols {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"bool", "?column?", "?column?", "int8", "varchar", "id", "lower", "case", "Name"}, names)
+}
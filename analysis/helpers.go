@@ -165,6 +165,14 @@ func trimQuotes(s string) string {
 	return strings.Trim(s, `"`)
 }
 
+// schemaQualified joins a schema and a name.
+func schemaQualified(schema, name string) string {
+	if schema == "" {
+		return name
+	}
+	return schema + "." + name
+}
+
 // EntityNameFromTables creates an entity name string from base tables.
 // Returns all real tables (not CTEs/subqueries) formatted as "schema.table".
 // Multiple tables are comma-separated, e.g. "public.users,public.orders".
@@ -0,0 +1,668 @@
+// joingraph.go builds the join graph of each query level and flags cartesian products,
+// join conditions written in WHERE, and outer joins that WHERE filters undo.
+package analysis
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// JoinGraph is the join graph of one query level.
+type JoinGraph struct {
+	Scope      int        // Index of the query level in ParsedQuery.Scopes
+	Nodes      []JoinNode // The level's FROM relations, index-aligned with QueryScope.Relations
+	Edges      []JoinEdge
+	Components [][]int // Nodes connected by edges; more than one is a cartesian product
+	Issues     []JoinIssue
+}
+
+// JoinNode is a relation of a join graph: a table, CTE, subquery, or function.
+type JoinNode struct {
+	Table   postgresparser.TableRef
+	Scope   int  // Scope of the CTE body or subquery that produces the relation, or -1
+	Lateral bool // LATERAL subquery or function, which may refer to the relations before it
+}
+
+// JoinEdge joins two nodes.
+type JoinEdge struct {
+	Left  int // Node on the left of the join
+	Right int // Node joined to Left; the nullable side of a LEFT join
+	// Type is a plain string by design, matching ScopeJoin.Type. Values: "INNER",
+	// "LEFT", "RIGHT", "FULL", "CROSS", or "LATERAL" for the reference of a LATERAL
+	// relation to an earlier one. Relations joined in WHERE are INNER.
+	Type       string
+	Natural    bool
+	Predicates []JoinPredicate
+	Position   int // Character offset in RawSQL of the ON or USING qualifier, or of the first WHERE predicate; 0 otherwise
+}
+
+// JoinPredicate is a condition of a join.
+type JoinPredicate struct {
+	Expression string       // Condition as written, or the column of a USING join
+	Equality   bool         // The condition is an = comparison, as USING and NATURAL joins are
+	Columns    []JoinColumn // Columns of the graph's nodes the condition reads
+	InWhere    bool         // Written in WHERE rather than ON
+	Position   int          // Character offset in RawSQL
+}
+
+// JoinColumn is a column of a join graph node.
+type JoinColumn struct {
+	Node   int
+	Column string
+}
+
+// JoinIssueKind classifies a JoinIssue.
+type JoinIssueKind string
+
+const (
+	// JoinIssueCartesianProduct means a relation is joined to the others by no
+	// condition, so every row pairs with every row of the others.
+	JoinIssueCartesianProduct JoinIssueKind = "CARTESIAN_PRODUCT"
+	// JoinIssueConditionInWhere means a WHERE condition joins relations that no JOIN
+	// clause joins.
+	JoinIssueConditionInWhere JoinIssueKind = "CONDITION_IN_WHERE"
+	// JoinIssueNullifiedOuterJoin means a WHERE condition rejects the NULL rows of the
+	// nullable side of an outer join, turning it into an inner join.
+	JoinIssueNullifiedOuterJoin JoinIssueKind = "NULLIFIED_OUTER_JOIN"
+	// JoinIssueCycle means the join conditions connect relations in a cycle, such as
+	// a-b, b-c, and c-a: one of them is redundant or compares the wrong columns.
+	JoinIssueCycle JoinIssueKind = "CYCLE"
+)
+
+// JoinIssue is a suspicious join.
+type JoinIssue struct {
+	Kind     JoinIssueKind
+	Nodes    []int // Nodes involved
+	Message  string
+	Position int // Character offset in RawSQL
+}
+
+// JoinGraphs parses a query and returns its join graphs. See ExtractJoinGraphs.
+func JoinGraphs(query string, schemaMap map[string][]ColumnSchema) ([]JoinGraph, error) {
+	pq, err := postgresparser.ParseSQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return ExtractJoinGraphs(pq, schemaMap), nil
+}
+
+// ExtractJoinGraphs returns a join graph for each query level of pq with FROM
+// relations, in scope order.
+//
+// ON conditions make edges between the relations whose columns they compare; a
+// condition reading one side only is kept on the join's edges. USING, NATURAL, and
+// CROSS joins connect the first relation of their right-hand side to the last relation
+// before it, or for USING, to the earlier relation that has the column in schemaMap.
+// A LATERAL subquery or function gets a LATERAL edge to each earlier relation it
+// refers to. WHERE conditions comparing columns of two relations are join predicates.
+// An edge between relations that the edges before it already connect closes a cycle,
+// reported as a JoinIssueCycle.
+//
+// The schemaMap, keyed as for ResolveColumnUsage, resolves unqualified columns; it
+// may be nil, in which case an unqualified column joins nothing unless only one
+// relation is in scope.
+func ExtractJoinGraphs(pq *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) []JoinGraph {
+	if pq == nil {
+		return nil
+	}
+	b := joinGraphBuilder{pq: pq, schemaMap: schemaMap, toks: sqllex.Lex(pq.RawSQL), runes: []rune(pq.RawSQL)}
+	resolved := ResolveColumnUsage(pq, schemaMap)
+	var out []JoinGraph
+	for i, sc := range pq.Scopes {
+		if len(sc.Relations) == 0 {
+			continue
+		}
+		var cols []ResolvedColumn
+		for _, rc := range resolved {
+			if innermostScope(pq.Scopes, rc.Usage.Position) == i {
+				cols = append(cols, rc)
+			}
+		}
+		out = append(out, b.graph(i, cols))
+	}
+	return out
+}
+
+// joinGraphBuilder builds the join graphs of a statement.
+type joinGraphBuilder struct {
+	pq        *postgresparser.ParsedQuery
+	schemaMap map[string][]ColumnSchema
+	toks      []sqllex.Token
+	runes     []rune
+}
+
+// levelToken is a token of a query level with its nesting depth in parentheses and CASE.
+type levelToken struct {
+	sqllex.Token
+	depth int
+}
+
+// graph builds the join graph of scope i, whose column references are cols.
+func (b *joinGraphBuilder) graph(i int, cols []ResolvedColumn) JoinGraph {
+	sc := b.pq.Scopes[i]
+	g := JoinGraph{Scope: i}
+	for _, rel := range sc.Relations {
+		g.Nodes = append(g.Nodes, JoinNode{
+			Table:   rel.Table,
+			Scope:   rel.Scope,
+			Lateral: rel.Table.Type == postgresparser.TableTypeFunction || rel.Scope >= 0 && b.pq.Scopes[rel.Scope].Kind == postgresparser.ScopeLateral,
+		})
+	}
+	level := levelTokens(b.pq.Scopes, b.toks, i)
+	edge := func(join, l, r int, typ string) *JoinEdge {
+		for k := range g.Edges {
+			e := &g.Edges[k]
+			if e.Left == l && e.Right == r && e.Type == typ {
+				return e
+			}
+		}
+		g.Edges = append(g.Edges, JoinEdge{Left: l, Right: r, Type: typ})
+		e := &g.Edges[len(g.Edges)-1]
+		if join >= 0 {
+			e.Natural = sc.Joins[join].Natural
+			e.Position = sc.Joins[join].Position
+		}
+		return e
+	}
+
+	// Explicit joins.
+	nullable := make(map[int]string) // Nodes on the nullable side of an outer join, with its type
+	for j, join := range sc.Joins {
+		if len(join.Left) == 0 || len(join.Right) == 0 {
+			continue
+		}
+		var nulls []int
+		if join.Type == "LEFT" || join.Type == "FULL" {
+			nulls = append(nulls, join.Right...)
+		}
+		if join.Type == "RIGHT" || join.Type == "FULL" {
+			nulls = append(nulls, join.Left...)
+		}
+		for _, n := range nulls {
+			if _, ok := nullable[n]; !ok {
+				nullable[n] = join.Type
+			}
+		}
+		last, first := join.Left[len(join.Left)-1], join.Right[0]
+		switch {
+		case len(join.Using) > 0:
+			for _, col := range join.Using {
+				l := last
+				for _, n := range slices.Backward(join.Left) {
+					if b.hasColumn(sc.Relations[n].Table, col) {
+						l = n
+						break
+					}
+				}
+				e := edge(j, l, first, join.Type)
+				e.Predicates = append(e.Predicates, JoinPredicate{
+					Expression: col,
+					Equality:   true,
+					Columns:    []JoinColumn{{Node: l, Column: sqlident.Name(col)}, {Node: first, Column: sqlident.Name(col)}},
+					Position:   join.Position,
+				})
+			}
+		case join.Natural || join.Type == "CROSS":
+			edge(j, last, first, join.Type)
+		case join.Position > 0:
+			var added []*JoinEdge
+			var local []JoinPredicate
+			for _, cond := range splitConjuncts(onCondition(level, join.Position)) {
+				pred := b.predicate(cond, cols, sc.Relations)
+				var pairs [][2]int
+				for _, lc := range pred.Columns {
+					for _, rc := range pred.Columns {
+						if slices.Contains(join.Left, lc.Node) && slices.Contains(join.Right, rc.Node) && !slices.Contains(pairs, [2]int{lc.Node, rc.Node}) {
+							pairs = append(pairs, [2]int{lc.Node, rc.Node})
+						}
+					}
+				}
+				if len(pairs) == 0 {
+					local = append(local, pred)
+					continue
+				}
+				for _, p := range pairs {
+					e := edge(j, p[0], p[1], join.Type)
+					e.Predicates = append(e.Predicates, pred)
+					added = append(added, e)
+				}
+			}
+			for _, e := range added {
+				e.Predicates = append(e.Predicates, local...)
+			}
+		}
+	}
+
+	// LATERAL references.
+	for n, node := range g.Nodes {
+		if !node.Lateral {
+			continue
+		}
+		var refs []int
+		if node.Scope >= 0 {
+			s := b.pq.Scopes[node.Scope]
+			for _, t := range b.toks {
+				if t.Pos >= s.Start && t.Pos < s.End {
+					refs = append(refs, qualifiedRelations([]sqllex.Token{t}, b.toks, sc.Relations[:n])...)
+				}
+			}
+		} else {
+			refs = qualifiedRelations(sqllex.Lex(node.Table.Raw), nil, sc.Relations[:n])
+		}
+		for _, r := range refs {
+			edge(-1, r, n, "LATERAL")
+		}
+	}
+
+	// WHERE conditions.
+	connected := components(len(g.Nodes), g.Edges)
+	for _, cond := range splitConjuncts(whereCondition(level)) {
+		pred := b.predicate(cond, cols, sc.Relations)
+		pred.InWhere = true
+		var nodes []int
+		for _, c := range pred.Columns {
+			if !slices.Contains(nodes, c.Node) {
+				nodes = append(nodes, c.Node)
+			}
+		}
+		slices.Sort(nodes)
+		for k, l := range nodes {
+			for _, r := range nodes[k+1:] {
+				if e := g.edgeBetween(l, r); e != nil {
+					e.Predicates = append(e.Predicates, pred)
+					continue
+				}
+				e := edge(-1, l, r, "INNER")
+				e.Position = pred.Position
+				e.Predicates = append(e.Predicates, pred)
+				if connected.Find(l) != connected.Find(r) {
+					g.Issues = append(g.Issues, JoinIssue{
+						Kind:     JoinIssueConditionInWhere,
+						Nodes:    []int{l, r},
+						Message:  fmt.Sprintf("WHERE condition %s joins %s and %s; write it as JOIN ... ON", pred.Expression, joinNodeName(g.Nodes[l]), joinNodeName(g.Nodes[r])),
+						Position: pred.Position,
+					})
+				}
+			}
+		}
+		for _, n := range nodes {
+			if typ, ok := nullable[n]; ok && rejectsNulls(cond) {
+				g.Issues = append(g.Issues, JoinIssue{
+					Kind:     JoinIssueNullifiedOuterJoin,
+					Nodes:    []int{n},
+					Message:  fmt.Sprintf("WHERE condition %s discards the rows the %s JOIN adds with NULLs for %s, making it an inner join; move the condition to ON", pred.Expression, typ, joinNodeName(g.Nodes[n])),
+					Position: pred.Position,
+				})
+			}
+		}
+	}
+
+	// Cycles: an edge between nodes the earlier edges already connect closes one.
+	tree := sqllex.NewUnionFind(len(g.Nodes))
+	var treeEdges []JoinEdge
+	for k, e := range g.Edges {
+		if slices.ContainsFunc(g.Edges[:k], func(o JoinEdge) bool { return sameEnds(o, e) }) {
+			continue
+		}
+		if tree.Find(e.Left) != tree.Find(e.Right) {
+			tree.Union(e.Left, e.Right)
+			treeEdges = append(treeEdges, e)
+			continue
+		}
+		cycle := joinPath(treeEdges, e.Left, e.Right)
+		g.Issues = append(g.Issues, JoinIssue{
+			Kind:     JoinIssueCycle,
+			Nodes:    cycle,
+			Message:  fmt.Sprintf("join conditions connect %s in a cycle: the one joining %s and %s is redundant or compares the wrong columns", joinNodeNames(g.Nodes, cycle), joinNodeName(g.Nodes[e.Left]), joinNodeName(g.Nodes[e.Right])),
+			Position: e.Position,
+		})
+	}
+
+	// Connected components.
+	groups := components(len(g.Nodes), g.Edges)
+	var roots []int
+	for n := range g.Nodes {
+		root := groups.Find(n)
+		k := slices.Index(roots, root)
+		if k < 0 {
+			roots = append(roots, root)
+			g.Components = append(g.Components, nil)
+			k = len(roots) - 1
+		}
+		g.Components[k] = append(g.Components[k], n)
+	}
+	for _, comp := range g.Components[1:] {
+		g.Issues = append(g.Issues, JoinIssue{
+			Kind:     JoinIssueCartesianProduct,
+			Nodes:    comp,
+			Message:  fmt.Sprintf("%s is not joined to %s: every row pairs with every row of the other", joinNodeNames(g.Nodes, comp), joinNodeNames(g.Nodes, g.Components[0])),
+			Position: b.itemPosition(level, sc, comp[0]),
+		})
+	}
+	return g
+}
+
+// edgeBetween returns an edge joining nodes a and b, or nil.
+func (g *JoinGraph) edgeBetween(a, b int) *JoinEdge {
+	for k := range g.Edges {
+		e := &g.Edges[k]
+		if e.Left == a && e.Right == b || e.Left == b && e.Right == a {
+			return e
+		}
+	}
+	return nil
+}
+
+// sameEnds reports whether edges a and b join the same two nodes.
+func sameEnds(a, b JoinEdge) bool {
+	return a.Left == b.Left && a.Right == b.Right || a.Left == b.Right && a.Right == b.Left
+}
+
+// joinPath returns the nodes on the path from node a to node b over edges, which form
+// a forest, or nil when there is none.
+func joinPath(edges []JoinEdge, a, b int) []int {
+	prev := map[int]int{a: -1}
+	queue := []int{a}
+	for len(queue) > 0 {
+		n := queue[0]
+		queue = queue[1:]
+		if n == b {
+			var path []int
+			for ; n >= 0; n = prev[n] {
+				path = append(path, n)
+			}
+			slices.Reverse(path)
+			return path
+		}
+		for _, e := range edges {
+			next := -1
+			switch n {
+			case e.Left:
+				next = e.Right
+			case e.Right:
+				next = e.Left
+			}
+			if _, ok := prev[next]; next >= 0 && !ok {
+				prev[next] = n
+				queue = append(queue, next)
+			}
+		}
+	}
+	return nil
+}
+
+// components groups n nodes by the edges between them.
+func components(n int, edges []JoinEdge) sqllex.UnionFind {
+	u := sqllex.NewUnionFind(n)
+	for _, e := range edges {
+		u.Union(e.Left, e.Right)
+	}
+	return u
+}
+
+// predicate builds the join predicate of a condition, reading the columns it
+// references from cols.
+func (b *joinGraphBuilder) predicate(cond []levelToken, cols []ResolvedColumn, rels []postgresparser.ScopeRelation) JoinPredicate {
+	if len(cond) == 0 {
+		return JoinPredicate{}
+	}
+	first, last := cond[0], cond[len(cond)-1]
+	end := last.Pos + len([]rune(last.Text))
+	pred := JoinPredicate{
+		Expression: string(b.runes[first.Pos:min(end, len(b.runes))]),
+		Position:   first.Pos,
+	}
+	for _, t := range cond {
+		if t.depth == cond[0].depth && t.Is("=") {
+			pred.Equality = true
+		}
+		if t.depth == cond[0].depth && (t.Is("OR") || t.Kind == sqllex.Op && t.Text != "=" && comparisonOps[t.Text]) {
+			pred.Equality = false
+			break
+		}
+	}
+	for _, rc := range cols {
+		if rc.Usage.Position < first.Pos || rc.Usage.Position >= end {
+			continue
+		}
+		relation := rc.Relation
+		if rc.Status == ColumnUnknown && len(rc.Candidates) == 1 {
+			relation = rc.Candidates[0]
+		}
+		n := slices.IndexFunc(rels, func(r postgresparser.ScopeRelation) bool {
+			return relation != "" && strings.EqualFold(sqllex.RelationQualifier(r.Table), trimQuotes(relation))
+		})
+		col := JoinColumn{Node: n, Column: sqlident.Name(rc.Usage.Column)}
+		if n >= 0 && !slices.Contains(pred.Columns, col) {
+			pred.Columns = append(pred.Columns, col)
+		}
+	}
+	return pred
+}
+
+// hasColumn reports whether schemaMap lists col for table.
+func (b *joinGraphBuilder) hasColumn(t postgresparser.TableRef, col string) bool {
+	key := strings.ToLower(trimQuotes(t.Name))
+	if t.Schema != "" {
+		key = strings.ToLower(trimQuotes(t.Schema)) + "." + key
+	}
+	return slices.ContainsFunc(b.schemaMap[key], func(c ColumnSchema) bool { return strings.EqualFold(c.Name, sqlident.Name(col)) })
+}
+
+// levelTokens returns the tokens among toks of scope i without those of its nested
+// scopes and the parentheses around it. The tokens between CASE and END are nested
+// like those in parentheses, so the ANDs and ORs of a CASE are not top-level.
+func levelTokens(scopes []postgresparser.QueryScope, toks []sqllex.Token, i int) []levelToken {
+	sc := scopes[i]
+	var in []sqllex.Token
+	for _, t := range toks {
+		if t.Pos >= sc.Start && t.Pos < sc.End && innermostScope(scopes, t.Pos) == i {
+			in = append(in, t)
+		}
+	}
+	for len(in) >= 2 && in[0].Is("(") && in[len(in)-1].Is(")") {
+		in = in[1 : len(in)-1]
+	}
+	out := make([]levelToken, 0, len(in))
+	depth := 0
+	for _, t := range in {
+		if t.Is(")") || t.IsKeyword("END") {
+			depth--
+		}
+		out = append(out, levelToken{Token: t, depth: depth})
+		if t.Is("(") || t.IsKeyword("CASE") {
+			depth++
+		}
+	}
+	return out
+}
+
+// itemPosition returns the offset of the comma or join qualifier before FROM item n of
+// scope sc, or 0.
+func (b *joinGraphBuilder) itemPosition(level []levelToken, sc postgresparser.QueryScope, n int) int {
+	for _, j := range sc.Joins {
+		if slices.Contains(j.Right, n) && j.Position > 0 {
+			return j.Position
+		}
+	}
+	// FROM items are the join trees of the relations, separated by commas.
+	items := sqllex.NewUnionFind(len(sc.Relations))
+	for _, j := range sc.Joins {
+		for _, l := range j.Left {
+			for _, r := range j.Right {
+				items.Union(l, r)
+			}
+		}
+	}
+	var roots []int
+	for r := range sc.Relations {
+		if root := items.Find(r); !slices.Contains(roots, root) {
+			roots = append(roots, root)
+		}
+	}
+	item := slices.Index(roots, items.Find(n))
+	// Items after FROM are separated by commas, and in DELETE by USING; the target of
+	// UPDATE and DELETE comes before them. Count from the last item.
+	var seps []int
+	from := slices.IndexFunc(level, func(t levelToken) bool { return t.depth == 0 && t.Is("FROM") })
+	for k := from + 1; from >= 0 && k < len(level); k++ {
+		t := level[k]
+		if t.depth != 0 {
+			continue
+		}
+		if t.Is(",") || t.Is("USING") && k+1 < len(level) && !level[k+1].Is("(") {
+			seps = append(seps, t.Pos)
+		} else if t.IsKeyword(fromClauseEnd...) {
+			break
+		}
+	}
+	if k := len(seps) - (len(roots) - item); k >= 0 && item > 0 {
+		return seps[k]
+	}
+	return 0
+}
+
+// fromClauseEnd are keywords that end a FROM clause.
+var fromClauseEnd = []string{"WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "RETURNING"}
+
+// conditionEnd are keywords that end an ON or WHERE condition.
+var conditionEnd = []string{
+	"JOIN", "INNER", "LEFT", "RIGHT", "FULL", "CROSS", "NATURAL", "WHERE", "GROUP", "HAVING", "WINDOW",
+	"ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "RETURNING",
+}
+
+// onCondition returns the tokens of the ON condition whose qualifier is at pos.
+func onCondition(level []levelToken, pos int) []levelToken {
+	k := slices.IndexFunc(level, func(t levelToken) bool { return t.Pos == pos })
+	if k < 0 || !level[k].Is("ON") {
+		return nil
+	}
+	return conditionTokens(level, k+1)
+}
+
+// whereCondition returns the tokens of the WHERE condition of a query level.
+func whereCondition(level []levelToken) []levelToken {
+	k := slices.IndexFunc(level, func(t levelToken) bool { return t.depth == 0 && t.Is("WHERE") })
+	if k < 0 {
+		return nil
+	}
+	return conditionTokens(level, k+1)
+}
+
+// conditionTokens returns the tokens of the condition starting at k, up to the next
+// clause at its depth.
+func conditionTokens(level []levelToken, k int) []levelToken {
+	if k >= len(level) {
+		return nil
+	}
+	base := level[k].depth
+	end := k
+	for ; end < len(level); end++ {
+		t := level[end]
+		call := end+1 < len(level) && level[end+1].Is("(") // left(...) and right(...)
+		if t.depth < base || t.depth == base && (t.Is(",") || t.Is(";") || !call && t.IsKeyword(conditionEnd...)) {
+			break
+		}
+	}
+	return level[k:end]
+}
+
+// splitConjuncts splits a condition at its top-level ANDs, leaving the AND of BETWEEN.
+func splitConjuncts(toks []levelToken) [][]levelToken {
+	if len(toks) == 0 {
+		return nil
+	}
+	base := toks[0].depth
+	var out [][]levelToken
+	var cur []levelToken
+	between := false
+	for _, t := range toks {
+		switch {
+		case t.depth == base && t.Is("BETWEEN"):
+			between = true
+		case t.depth == base && t.Is("AND") && between:
+			between = false
+		case t.depth == base && t.Is("AND"):
+			out = append(out, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	return append(out, cur)
+}
+
+// rejectsNulls reports whether a WHERE condition is false or NULL when the columns of
+// a relation are NULL. Conditions with OR, IS [NOT] NULL tests, IS DISTINCT FROM,
+// COALESCE, or CASE may accept NULLs and are assumed to.
+func rejectsNulls(cond []levelToken) bool {
+	for k, t := range cond {
+		switch {
+		case t.Is("OR"), t.Is("COALESCE"), t.Is("CASE"), t.Is("NULLIF"), t.Is("DISTINCT"):
+			return false
+		case t.Is("IS") && k+1 < len(cond) && cond[k+1].Is("NULL"):
+			return false
+		}
+	}
+	return true
+}
+
+// qualifiedRelations returns the relations of rels whose names qualify a column in
+// toks, as in rel.col. ctx, when set, holds toks so the token after each is found.
+func qualifiedRelations(toks, ctx []sqllex.Token, rels []postgresparser.ScopeRelation) []int {
+	if ctx == nil {
+		ctx = toks
+	}
+	var out []int
+	for _, t := range toks {
+		if t.Kind != sqllex.Ident && t.Kind != sqllex.Quoted {
+			continue
+		}
+		k := slices.IndexFunc(ctx, func(c sqllex.Token) bool { return c.Pos == t.Pos })
+		if k < 0 || k+1 >= len(ctx) || !ctx[k+1].Is(".") || k > 0 && ctx[k-1].Is(".") {
+			continue
+		}
+		for r, rel := range rels {
+			if strings.EqualFold(sqllex.RelationQualifier(rel.Table), trimQuotes(t.Text)) && !slices.Contains(out, r) {
+				out = append(out, r)
+			}
+		}
+	}
+	return out
+}
+
+// innermostScope returns the innermost scope containing character offset pos, or -1.
+func innermostScope(scopes []postgresparser.QueryScope, pos int) int {
+	best := -1
+	for i, sc := range scopes {
+		if pos >= sc.Start && pos < sc.End && (best < 0 || sc.End-sc.Start <= scopes[best].End-scopes[best].Start) {
+			best = i
+		}
+	}
+	return best
+}
+
+// joinNodeName returns the alias or name of a node.
+func joinNodeName(n JoinNode) string {
+	if n.Table.Alias != "" {
+		return n.Table.Alias
+	}
+	if n.Table.Name != "" {
+		return schemaQualified(n.Table.Schema, n.Table.Name)
+	}
+	return n.Table.Raw
+}
+
+// joinNodeNames joins the names of nodes.
+func joinNodeNames(nodes []JoinNode, idx []int) string {
+	names := make([]string, len(idx))
+	for i, n := range idx {
+		names[i] = joinNodeName(nodes[n])
+	}
+	return strings.Join(names, ", ")
+}
@@ -0,0 +1,220 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// edgeSummary renders the edges of a graph as "left-TYPE-right[predicate; ...]", with
+// WHERE predicates marked by "where:".
+func edgeSummary(g JoinGraph) []string {
+	var out []string
+	for _, e := range g.Edges {
+		var preds []string
+		for _, p := range e.Predicates {
+			if p.InWhere {
+				preds = append(preds, "where:"+p.Expression)
+			} else {
+				preds = append(preds, p.Expression)
+			}
+		}
+		out = append(out, fmt.Sprintf("%s-%s-%s[%s]", joinNodeName(g.Nodes[e.Left]), e.Type, joinNodeName(g.Nodes[e.Right]), strings.Join(preds, "; ")))
+	}
+	return out
+}
+
+// issueKinds returns the kinds of the issues of a graph.
+func issueKinds(g JoinGraph) []JoinIssueKind {
+	var out []JoinIssueKind
+	for _, i := range g.Issues {
+		out = append(out, i.Kind)
+	}
+	return out
+}
+
+func TestJoinGraphs(t *testing.T) {
+	tests := []struct {
+		name   string
+		sql    string
+		edges  []string
+		issues []JoinIssueKind
+	}{
+		{
+			name:  "inner and left joins",
+			sql:   "SELECT * FROM users u JOIN orders o ON o.user_id = u.id LEFT JOIN items i ON i.order_id = o.id AND i.qty > 0",
+			edges: []string{"u-INNER-o[o.user_id = u.id]", "o-LEFT-i[i.order_id = o.id; i.qty > 0]"},
+		},
+		{
+			name:   "comma join without a condition",
+			sql:    "SELECT * FROM users u, orders o WHERE u.status = 'active'",
+			issues: []JoinIssueKind{JoinIssueCartesianProduct},
+		},
+		{
+			name:   "join condition in WHERE",
+			sql:    "SELECT * FROM users u, orders o WHERE o.user_id = u.id",
+			edges:  []string{"u-INNER-o[where:o.user_id = u.id]"},
+			issues: []JoinIssueKind{JoinIssueConditionInWhere},
+		},
+		{
+			name:  "WHERE condition on joined relations",
+			sql:   "SELECT * FROM users u JOIN orders o ON o.user_id = u.id WHERE o.created_at > u.created_at",
+			edges: []string{"u-INNER-o[o.user_id = u.id; where:o.created_at > u.created_at]"},
+		},
+		{
+			name:   "ON condition that joins nothing",
+			sql:    "SELECT * FROM users u JOIN orders o ON true",
+			issues: []JoinIssueKind{JoinIssueCartesianProduct},
+		},
+		{
+			name:   "WHERE filter on the nullable side",
+			sql:    "SELECT * FROM users u LEFT JOIN orders o ON o.user_id = u.id WHERE o.status = 'paid'",
+			edges:  []string{"u-LEFT-o[o.user_id = u.id]"},
+			issues: []JoinIssueKind{JoinIssueNullifiedOuterJoin},
+		},
+		{
+			name:  "anti-join and null-tolerant filters",
+			sql:   "SELECT * FROM users u LEFT JOIN orders o ON o.user_id = u.id WHERE o.id IS NULL OR coalesce(o.status, 'none') = 'none'",
+			edges: []string{"u-LEFT-o[o.user_id = u.id]"},
+		},
+		{
+			name:   "right and full joins",
+			sql:    "SELECT * FROM users u RIGHT JOIN orders o ON o.user_id = u.id FULL JOIN items i ON i.order_id = o.id WHERE u.name = 'x' AND i.qty IS NOT NULL",
+			edges:  []string{"u-RIGHT-o[o.user_id = u.id]", "o-FULL-i[i.order_id = o.id]"},
+			issues: []JoinIssueKind{JoinIssueNullifiedOuterJoin, JoinIssueNullifiedOuterJoin},
+		},
+		{
+			name:  "using, natural, and cross joins",
+			sql:   "SELECT * FROM a JOIN b USING (id, ver) NATURAL JOIN c CROSS JOIN d",
+			edges: []string{"a-INNER-b[id; ver]", "c-CROSS-d[]", "b-INNER-c[]"},
+		},
+		{
+			name:  "lateral subquery and function",
+			sql:   "SELECT * FROM users u, LATERAL (SELECT * FROM orders o WHERE o.user_id = u.id LIMIT 1) lo, unnest(u.tags) tag",
+			edges: []string{"u-LATERAL-lo[]", "u-LATERAL-tag[]"},
+		},
+		{
+			name:   "join conditions in a cycle",
+			sql:    "SELECT * FROM a, b, c WHERE a.id = b.a_id AND b.id = c.b_id AND c.a_id = a.id",
+			edges:  []string{"a-INNER-b[where:a.id = b.a_id]", "b-INNER-c[where:b.id = c.b_id]", "a-INNER-c[where:c.a_id = a.id]"},
+			issues: []JoinIssueKind{JoinIssueConditionInWhere, JoinIssueConditionInWhere, JoinIssueConditionInWhere, JoinIssueCycle},
+		},
+		{
+			name:  "repeated condition is no cycle",
+			sql:   "SELECT * FROM users u JOIN orders o ON o.user_id = u.id WHERE o.user_id = u.id",
+			edges: []string{"u-INNER-o[o.user_id = u.id; where:o.user_id = u.id]"},
+		},
+		{
+			name:  "left function in a condition",
+			sql:   "SELECT * FROM a JOIN b ON left(b.code, 2) = a.prefix",
+			edges: []string{"a-INNER-b[left(b.code, 2) = a.prefix]"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			graphs, err := JoinGraphs(tt.sql, nil)
+			require.NoError(t, err)
+			require.NotEmpty(t, graphs)
+			assert.Equal(t, tt.edges, edgeSummary(graphs[0]), "edges")
+			assert.Equal(t, tt.issues, issueKinds(graphs[0]), "issues")
+		})
+	}
+}
+
+func TestJoinGraphs_Details(t *testing.T) {
+	sql := "SELECT * FROM users u JOIN orders o ON o.user_id = u.id, products p, tags t WHERE p.id = o.product_id"
+	graphs, err := JoinGraphs(sql, nil)
+	require.NoError(t, err)
+	require.Len(t, graphs, 1)
+	g := graphs[0]
+
+	assert.Equal(t, [][]int{{0, 1, 2}, {3}}, g.Components)
+	require.Len(t, g.Edges, 2)
+	assert.Equal(t, JoinPredicate{
+		Expression: "o.user_id = u.id",
+		Equality:   true,
+		Columns:    []JoinColumn{{Node: 1, Column: "user_id"}, {Node: 0, Column: "id"}},
+		Position:   strings.Index(sql, "o.user_id"),
+	}, g.Edges[0].Predicates[0])
+	assert.Equal(t, strings.Index(sql, "ON"), g.Edges[0].Position)
+
+	require.Len(t, g.Issues, 2)
+	assert.Equal(t, JoinIssueConditionInWhere, g.Issues[0].Kind)
+	assert.Equal(t, []int{1, 2}, g.Issues[0].Nodes)
+	assert.Equal(t, JoinIssueCartesianProduct, g.Issues[1].Kind)
+	assert.Equal(t, []int{3}, g.Issues[1].Nodes)
+	assert.Equal(t, strings.LastIndex(sql, ","), g.Issues[1].Position)
+	assert.Contains(t, g.Issues[1].Message, "t is not joined to u, o, p")
+}
+
+func TestJoinGraphs_Cycle(t *testing.T) {
+	sql := "SELECT * FROM a JOIN b ON b.a_id = a.id JOIN c ON c.b_id = b.id AND c.a_id = a.id"
+	graphs, err := JoinGraphs(sql, nil)
+	require.NoError(t, err)
+	require.Len(t, graphs, 1)
+	g := graphs[0]
+
+	on := strings.LastIndex(sql, "ON")
+	assert.Equal(t, []JoinEdge{
+		{Left: 0, Right: 1, Type: "INNER", Position: strings.Index(sql, "ON"), Predicates: []JoinPredicate{{
+			Expression: "b.a_id = a.id",
+			Equality:   true,
+			Columns:    []JoinColumn{{Node: 1, Column: "a_id"}, {Node: 0, Column: "id"}},
+			Position:   strings.Index(sql, "b.a_id"),
+		}}},
+		{Left: 1, Right: 2, Type: "INNER", Position: on, Predicates: []JoinPredicate{{
+			Expression: "c.b_id = b.id",
+			Equality:   true,
+			Columns:    []JoinColumn{{Node: 2, Column: "b_id"}, {Node: 1, Column: "id"}},
+			Position:   strings.Index(sql, "c.b_id"),
+		}}},
+		{Left: 0, Right: 2, Type: "INNER", Position: on, Predicates: []JoinPredicate{{
+			Expression: "c.a_id = a.id",
+			Equality:   true,
+			Columns:    []JoinColumn{{Node: 2, Column: "a_id"}, {Node: 0, Column: "id"}},
+			Position:   strings.Index(sql, "c.a_id"),
+		}}},
+	}, g.Edges)
+	assert.Equal(t, [][]int{{0, 1, 2}}, g.Components)
+	assert.Equal(t, []JoinIssue{{
+		Kind:     JoinIssueCycle,
+		Nodes:    []int{0, 1, 2},
+		Message:  "join conditions connect a, b, c in a cycle: the one joining a and c is redundant or compares the wrong columns",
+		Position: on,
+	}}, g.Issues)
+}
+
+func TestJoinGraphs_Scopes(t *testing.T) {
+	graphs, err := JoinGraphs("WITH recent AS (SELECT * FROM orders, items) SELECT * FROM users u WHERE EXISTS (SELECT 1 FROM recent r WHERE r.user_id = u.id)", nil)
+	require.NoError(t, err)
+
+	var scopes []int
+	var issues [][]JoinIssueKind
+	for _, g := range graphs {
+		scopes = append(scopes, g.Scope)
+		issues = append(issues, issueKinds(g))
+	}
+	assert.Len(t, scopes, 3)
+	assert.ElementsMatch(t, [][]JoinIssueKind{{JoinIssueCartesianProduct}, nil, nil}, issues, "only the CTE body joins nothing")
+}
+
+func TestJoinGraphs_Schema(t *testing.T) {
+	schema := map[string][]ColumnSchema{
+		"users":  {{Name: "id"}, {Name: "email"}},
+		"orders": {{Name: "order_id"}, {Name: "user_id"}},
+	}
+	graphs, err := JoinGraphs("SELECT * FROM users, orders WHERE user_id = id", schema)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"users-INNER-orders[where:user_id = id]"}, edgeSummary(graphs[0]))
+
+	graphs, err = JoinGraphs("SELECT * FROM users JOIN orders ON true JOIN payments USING (user_id)", schema)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"orders-INNER-payments[user_id]"}, edgeSummary(graphs[0]))
+	assert.Equal(t, []JoinIssueKind{JoinIssueCartesianProduct}, issueKinds(graphs[0]))
+
+	_, err = JoinGraphs("SELECT FROM WHERE", nil)
+	assert.Error(t, err)
+}
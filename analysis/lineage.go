@@ -0,0 +1,1092 @@
+// lineage.go traces the columns a statement returns or writes back to the base-table
+// columns their values are derived from.
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// LineageKind describes how a column's value is derived from a source column.
+type LineageKind string
+
+const (
+	// LineageDirect means the source column's value is copied unchanged.
+	LineageDirect LineageKind = "direct"
+	// LineageExpression means the value is computed from the source column by an
+	// operator, function, or CASE expression.
+	LineageExpression LineageKind = "expression"
+	// LineageAggregated means the value is computed by an aggregate over the source column.
+	LineageAggregated LineageKind = "aggregated"
+	// LineageFiltered means the source column only decides which rows are produced: it
+	// is read by a WHERE, JOIN, GROUP BY, or HAVING clause, a FILTER or OVER clause, or a
+	// subquery that such a clause tests.
+	LineageFiltered LineageKind = "filtered"
+)
+
+// lineageOrder ranks kinds from the most to the least direct use of a source.
+var lineageOrder = []LineageKind{LineageDirect, LineageExpression, LineageAggregated, LineageFiltered}
+
+// LineageSource is a base-table column a column is derived from.
+type LineageSource struct {
+	Schema string // Schema of the table as written in the query, or ""
+	Table  string
+	Column string
+	Kind   LineageKind // How the column uses the source
+}
+
+// ColumnLineage is the lineage of a column a statement returns or writes.
+type ColumnLineage struct {
+	Name       string          // Result column name, or the target column written
+	Schema     string          // Schema of the target table as written, or ""
+	Table      string          // Target table or view, "" for the result of a query
+	Kind       LineageKind     // How the value is derived as a whole; never filtered
+	Sources    []LineageSource // Source columns in order of first use, each once
+	Unresolved []string        // References that could not be traced to a table, as written
+}
+
+// Lineage parses a statement and returns the lineage of its columns. See ExtractLineage.
+func Lineage(query string, schemaMap map[string][]ColumnSchema) ([]ColumnLineage, error) {
+	pq, err := postgresparser.ParseSQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return ExtractLineage(pq, schemaMap)
+}
+
+// ExtractLineage returns, for each column a statement produces, the base-table columns
+// its value is derived from. A SELECT reports its result columns. INSERT ... SELECT,
+// UPDATE, MERGE, CREATE TABLE AS, SELECT INTO, and CREATE VIEW report the columns of
+// the table or view they write, once per column. Other statements return nil.
+//
+// Values are followed through CTEs, derived tables, subqueries, and every branch of a
+// set operation, resolving references the way ResolveColumnUsage does. Each source is
+// tagged with how the column uses it: copied directly, computed by an expression,
+// aggregated, or only filtering the rows. Filtering clauses count at every query level
+// the value passes through. A source reached several ways keeps its most direct kind.
+//
+// The schemaMap has the same shape as for ResolveColumnUsage. A star, or an INSERT
+// without a column list, over a relation missing from it fails with ErrUnknownColumns.
+func ExtractLineage(pq *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) ([]ColumnLineage, error) {
+	if pq == nil || len(pq.Scopes) == 0 {
+		return nil, nil
+	}
+	t := &lineageTracer{
+		columnResolver: newColumnResolver(pq, schemaMap),
+		pq:             pq,
+		clauses:        make(map[int][]lineageClause),
+		active:         make(map[[2]int]bool),
+	}
+	switch pq.Command {
+	case postgresparser.QueryCommandSelect:
+		var target postgresparser.TableRef
+		if pq.Target != nil {
+			target = *pq.Target // SELECT INTO
+		}
+		return t.query(0, target, nil)
+	case postgresparser.QueryCommandDDL:
+		source := sourceScope(pq.Scopes)
+		if source < 0 {
+			return nil, nil
+		}
+		for _, a := range pq.DDLActions {
+			if a.Type == postgresparser.DDLCreateTable || a.Type == postgresparser.DDLCreateView {
+				return t.query(source, postgresparser.TableRef{Schema: a.Schema, Name: a.ObjectName}, a.Columns)
+			}
+		}
+	case postgresparser.QueryCommandInsert:
+		return t.insertColumns()
+	case postgresparser.QueryCommandUpdate:
+		return t.updateColumns(), nil
+	case postgresparser.QueryCommandMerge:
+		return t.mergeColumns()
+	}
+	return nil, nil
+}
+
+// sourceScope returns the scope of the query of an INSERT, CREATE TABLE AS, or
+// CREATE VIEW, or -1.
+func sourceScope(scopes []postgresparser.QueryScope) int {
+	return slices.IndexFunc(scopes, func(s postgresparser.QueryScope) bool {
+		return s.Kind == postgresparser.ScopeSource && s.Parent == 0
+	})
+}
+
+// lineageTracer traces column values through the scopes of one statement.
+type lineageTracer struct {
+	*columnResolver
+	pq      *postgresparser.ParsedQuery
+	clauses map[int][]lineageClause // Filtering clauses by scope
+	active  map[[2]int]bool         // Outputs being traced as {scope, index}, and filters as {scope, -1}
+}
+
+// lineageClause is an expression and its offset in RawSQL, or -1 when unknown.
+type lineageClause struct {
+	expr string
+	pos  int
+}
+
+// lineageSet accumulates the lineage of one value.
+type lineageSet struct {
+	kind       LineageKind // How the value is derived; "" until part of it is traced
+	sources    []LineageSource
+	unresolved []string
+}
+
+// unresolvedLineage returns the lineage of a reference that cannot be traced.
+func unresolvedLineage(ref string) lineageSet {
+	return lineageSet{kind: LineageDirect, unresolved: []string{ref}}
+}
+
+// add adds a source, keeping the more direct kind of a column added twice.
+func (l *lineageSet) add(src LineageSource) {
+	for i, s := range l.sources {
+		if strings.EqualFold(s.Schema, src.Schema) && strings.EqualFold(s.Table, src.Table) && strings.EqualFold(s.Column, src.Column) {
+			if lineageRank(src.Kind) < lineageRank(s.Kind) {
+				l.sources[i].Kind = src.Kind
+			}
+			return
+		}
+	}
+	l.sources = append(l.sources, src)
+}
+
+// setKind makes the value at least as derived as kind.
+func (l *lineageSet) setKind(kind LineageKind) {
+	if lineageRank(kind) > lineageRank(l.kind) {
+		l.kind = kind
+	}
+}
+
+// merge adds the lineage of other, a part of the value used as kind.
+func (l *lineageSet) merge(other lineageSet, kind LineageKind) {
+	for _, s := range other.sources {
+		s.Kind = composeLineage(kind, s.Kind)
+		l.add(s)
+	}
+	for _, u := range other.unresolved {
+		if !slices.Contains(l.unresolved, u) {
+			l.unresolved = append(l.unresolved, u)
+		}
+	}
+	if kind != LineageFiltered && other.kind != "" {
+		l.setKind(composeLineage(kind, other.kind))
+	}
+}
+
+// lineageRank returns the position of kind in lineageOrder, or -1 for "".
+func lineageRank(kind LineageKind) int {
+	return slices.Index(lineageOrder, kind)
+}
+
+// composeLineage returns the kind of a source used as inner by a part used as outer:
+// the less direct of the two.
+func composeLineage(outer, inner LineageKind) LineageKind {
+	if lineageRank(inner) > lineageRank(outer) {
+		return inner
+	}
+	return outer
+}
+
+// column builds the lineage of column name of target.
+func (t *lineageTracer) column(name string, target postgresparser.TableRef, set lineageSet) ColumnLineage {
+	kind := set.kind
+	if kind == "" {
+		kind = LineageExpression
+	}
+	return ColumnLineage{
+		Name:       name,
+		Schema:     trimQuotes(target.Schema),
+		Table:      trimQuotes(target.Name),
+		Kind:       kind,
+		Sources:    set.sources,
+		Unresolved: set.unresolved,
+	}
+}
+
+// query returns the lineage of the output columns of scope, written to target and
+// renamed by names when given.
+func (t *lineageTracer) query(scope int, target postgresparser.TableRef, names []string) ([]ColumnLineage, error) {
+	var out []ColumnLineage
+	for i, o := range t.scopeOutputs(scope, 0) {
+		if o.star {
+			return nil, fmt.Errorf("%w: cannot expand %s", ErrUnknownColumns, starText(o.qual))
+		}
+		name := o.name
+		if i < len(names) {
+			name = trimQuotes(names[i])
+		}
+		out = append(out, t.column(name, target, t.output(scope, i, 0)))
+	}
+	return out, nil
+}
+
+// insertColumns returns the lineage of the columns written by INSERT, matched by position to
+// the output columns of its query.
+func (t *lineageTracer) insertColumns() ([]ColumnLineage, error) {
+	source := sourceScope(t.scopes)
+	if t.pq.Target == nil || source < 0 {
+		return nil, nil
+	}
+	target := *t.pq.Target
+	names, err := t.targetColumns(target, t.pq.InsertColumns)
+	if err != nil {
+		return nil, err
+	}
+	var out []ColumnLineage
+	for i, o := range t.scopeOutputs(source, 0) {
+		if i >= len(names) {
+			break
+		}
+		if o.star {
+			return nil, fmt.Errorf("%w: cannot expand %s", ErrUnknownColumns, starText(o.qual))
+		}
+		out = append(out, t.column(names[i], target, t.output(source, i, 0)))
+	}
+	return out, nil
+}
+
+// targetColumns returns the columns an INSERT writes: its column list, or else all
+// columns of the target table.
+func (t *lineageTracer) targetColumns(target postgresparser.TableRef, list []string) ([]string, error) {
+	if len(list) > 0 {
+		names := make([]string, len(list))
+		for i, c := range list {
+			names[i] = trimQuotes(c)
+		}
+		return names, nil
+	}
+	cols, ok := t.tableColumns(target)
+	if !ok {
+		return nil, fmt.Errorf("%w: cannot list the columns of %s", ErrUnknownColumns, target.Name)
+	}
+	names := make([]string, len(cols))
+	for i, c := range cols {
+		names[i] = c.Name
+	}
+	return names, nil
+}
+
+// assignments collects the lineage of target columns in order of first assignment.
+type assignments struct {
+	names []string
+	sets  map[string]*lineageSet
+}
+
+// add adds value and the filters of its assignment to the lineage of column name.
+func (a *assignments) add(name string, value, filters lineageSet) {
+	if a.sets == nil {
+		a.sets = make(map[string]*lineageSet)
+	}
+	set, ok := a.sets[name]
+	if !ok {
+		set = &lineageSet{}
+		a.sets[name] = set
+		a.names = append(a.names, name)
+	}
+	set.merge(value, LineageDirect)
+	set.merge(filters, LineageFiltered)
+}
+
+// lineage returns the collected columns of target.
+func (t *lineageTracer) lineage(a assignments, target postgresparser.TableRef) []ColumnLineage {
+	out := make([]ColumnLineage, 0, len(a.names))
+	for _, name := range a.names {
+		out = append(out, t.column(name, target, *a.sets[name]))
+	}
+	return out
+}
+
+// updateColumns returns the lineage of the columns assigned by the SET clauses of UPDATE,
+// whose target table is relation 0 of the statement.
+func (t *lineageTracer) updateColumns() []ColumnLineage {
+	if len(t.scopes[0].Relations) == 0 {
+		return nil
+	}
+	filters := t.filters(0, 0)
+	var a assignments
+	for _, clause := range t.pq.SetClauses {
+		names, values := t.setClause(clause)
+		for i, name := range names {
+			a.add(name, values[i], filters)
+		}
+	}
+	return t.lineage(a, t.scopes[0].Relations[0].Table)
+}
+
+// mergeColumns returns the lineage of the columns written by the UPDATE and INSERT actions
+// of MERGE. The join condition filters every action, a WHEN condition its own.
+func (t *lineageTracer) mergeColumns() ([]ColumnLineage, error) {
+	m := t.pq.Merge
+	if m == nil {
+		return nil, nil
+	}
+	var a assignments
+	for _, act := range m.Actions {
+		var filters lineageSet
+		for _, cond := range []string{m.Condition, act.Condition} {
+			if cond != "" {
+				filters.merge(t.expression(0, cond, t.locate(0, cond), 0), LineageFiltered)
+			}
+		}
+		switch act.Type {
+		case "UPDATE":
+			for _, clause := range act.SetClauses {
+				names, values := t.setClause(clause)
+				for i, name := range names {
+					a.add(name, values[i], filters)
+				}
+			}
+		case "INSERT":
+			open, end := strings.Index(act.InsertValues, "("), strings.LastIndex(act.InsertValues, ")")
+			if open < 0 || end < open {
+				continue // DEFAULT VALUES
+			}
+			names, err := t.targetColumns(m.Target, act.InsertColumns)
+			if err != nil {
+				return nil, err
+			}
+			pos := t.locate(0, act.InsertValues)
+			if pos >= 0 {
+				pos += open + 1
+			}
+			for i, item := range splitList(act.InsertValues[open+1:end], pos) {
+				if i < len(names) {
+					a.add(names[i], t.expression(0, item.expr, item.pos, 0), filters)
+				}
+			}
+		}
+	}
+	return t.lineage(a, m.Target), nil
+}
+
+// setClause traces a SET clause of scope 0, "col = expr" or "(a, b) = (x, y)", returning
+// the columns assigned and the lineage of their values.
+func (t *lineageTracer) setClause(clause string) ([]string, []lineageSet) {
+	eq := strings.Index(clause, "=")
+	if eq < 0 {
+		return nil, nil
+	}
+	names := setTargets(clause)
+	rhs := strings.TrimLeft(clause[eq+1:], " \t\r\n")
+	pos := t.locate(0, clause)
+	if pos >= 0 {
+		pos += len(clause) - len(rhs)
+	}
+	rhs = strings.TrimRight(rhs, " \t\r\n")
+	if !strings.HasPrefix(strings.TrimSpace(clause), "(") {
+		return names, []lineageSet{t.expression(0, rhs, pos, 0)}
+	}
+
+	values := make([]lineageSet, len(names))
+	// (a, b) = (SELECT x, y ...) assigns the subquery's outputs in order.
+	if q := t.scopeStartingAt(pos, 0); pos >= 0 && q >= 0 {
+		for i := range values {
+			values[i] = t.output(q, i, 1)
+		}
+		return names, values
+	}
+	row := rhs
+	if hasPrefixFold(row, "ROW") {
+		row = strings.TrimLeft(row[3:], " \t\r\n")
+	}
+	if strings.HasPrefix(row, "(") && strings.HasSuffix(row, ")") {
+		rowPos := pos
+		if pos >= 0 {
+			rowPos += len(rhs) - len(row) + 1
+		}
+		if items := splitList(row[1:len(row)-1], rowPos); len(items) == len(names) {
+			for i, item := range items {
+				values[i] = t.expression(0, item.expr, item.pos, 0)
+			}
+			return names, values
+		}
+	}
+	for i := range values {
+		values[i] = t.expression(0, rhs, pos, 0)
+	}
+	return names, values
+}
+
+// setTargets returns the columns a SET clause assigns, "col = expr" or "(a, b) = ...".
+func setTargets(clause string) []string {
+	lhs, _, ok := strings.Cut(clause, "=")
+	if !ok {
+		return nil
+	}
+	lhs = strings.TrimSpace(lhs)
+	if !strings.HasPrefix(lhs, "(") {
+		return []string{targetName(lhs)}
+	}
+	var names []string
+	for _, item := range splitList(strings.TrimSuffix(lhs[1:], ")"), -1) {
+		names = append(names, targetName(item.expr))
+	}
+	return names
+}
+
+// targetName returns the column assigned by the target of a SET clause, which may
+// be followed by a subscript or field.
+func targetName(target string) string {
+	return sqlident.Name(identPartRe.FindString(strings.TrimSpace(target)))
+}
+
+// output traces output column i of scope, with the same column of its set-operation
+// branches and the clauses filtering its rows.
+func (t *lineageTracer) output(scope, i, depth int) lineageSet {
+	key := [2]int{scope, i}
+	if t.active[key] || depth > maxResolveDepth {
+		return lineageSet{} // A recursive CTE reading itself adds nothing new
+	}
+	t.active[key] = true
+	defer delete(t.active, key)
+
+	var out lineageSet
+	if outs := t.scopeOutputs(scope, depth); i < len(outs) {
+		switch o := outs[i]; {
+		case o.rel >= 0:
+			out.merge(t.relation(scope, o.rel, o.column, depth), LineageDirect)
+		case !o.star:
+			out.merge(t.expression(scope, o.expr, t.locate(scope, o.expr), depth), LineageDirect)
+		}
+	}
+	for c, sc := range t.scopes {
+		if sc.Parent == scope && sc.Kind == postgresparser.ScopeSetOperation {
+			out.merge(t.output(c, i, depth+1), LineageDirect)
+		}
+	}
+	out.merge(t.filters(scope, depth), LineageFiltered)
+	return out
+}
+
+// reference traces column col, qualified by qual unless "", from scope s.
+func (t *lineageTracer) reference(s int, qual, col string, depth int) lineageSet {
+	if qual != "" {
+		for _, lv := range t.levels(s) {
+			for _, ri := range lv.rels {
+				if relationMatches(t.scopes[lv.scope].Relations[ri].Table, qual) {
+					return t.relation(lv.scope, ri, col, depth)
+				}
+			}
+			if lv.scope == 0 && t.insert && strings.EqualFold(qual, "excluded") && len(t.scopes[0].Relations) > 0 {
+				return t.relation(0, 0, col, depth)
+			}
+		}
+		return unresolvedLineage(qual + "." + col)
+	}
+	for _, lv := range t.levels(s) {
+		if set, ok := t.among(lv.scope, lv.rels, col, depth); ok {
+			return set
+		}
+	}
+	// GROUP BY and ORDER BY may name an output column.
+	for i, o := range t.scopeOutputs(s, depth) {
+		if !o.star && strings.EqualFold(o.name, col) {
+			return t.output(s, i, depth+1)
+		}
+	}
+	return unresolvedLineage(col)
+}
+
+// among traces col among the relations rels of scope, reporting false when none of
+// them has it. A single relation of unknown columns is taken to have it.
+func (t *lineageTracer) among(scope int, rels []int, col string, depth int) (lineageSet, bool) {
+	var found, unsure []int
+	for _, ri := range rels {
+		switch _, p := t.relationColumn(scope, ri, col, depth); p {
+		case columnPresent:
+			found = append(found, ri)
+		case columnUnsure:
+			unsure = append(unsure, ri)
+		}
+	}
+	found = t.mergeJoinColumns(scope, found, col)
+	switch {
+	case len(found) == 1:
+		return t.relation(scope, found[0], col, depth), true
+	case len(found) == 0 && len(unsure) == 1:
+		return t.relation(scope, unsure[0], col, depth), true
+	case len(found)+len(unsure) > 0:
+		return unresolvedLineage(col), true
+	}
+	return lineageSet{}, false
+}
+
+// relation traces column col of relation ri of scope.
+func (t *lineageTracer) relation(scope, ri int, col string, depth int) lineageSet {
+	rel := t.scopes[scope].Relations[ri]
+	switch {
+	case depth > maxResolveDepth:
+		return unresolvedLineage(relationName(rel.Table) + "." + col)
+	case rel.Scope >= 0:
+		return t.relationOutput(rel, col, depth+1)
+	case rel.Table.Type == postgresparser.TableTypeFunction:
+		return lineageSet{kind: LineageExpression}
+	}
+	res, p := t.tableColumn(rel, col)
+	if p == columnAbsent || res.Status != ColumnResolved {
+		return unresolvedLineage(relationName(rel.Table) + "." + col)
+	}
+	return lineageSet{
+		kind:    LineageDirect,
+		sources: []LineageSource{{Schema: res.Schema, Table: res.Table, Column: res.Column, Kind: LineageDirect}},
+	}
+}
+
+// relationOutput traces column col of a CTE or subquery relation, finding the output
+// column the way outputColumn does.
+func (t *lineageTracer) relationOutput(rel postgresparser.ScopeRelation, col string, depth int) lineageSet {
+	scope := rel.Scope
+	aliases := rel.ColumnAliases
+	if len(aliases) == 0 {
+		aliases = t.scopes[scope].ColumnAliases
+	}
+	outs := t.scopeOutputs(scope, depth)
+	positional := slices.IndexFunc(outs, func(o scopeOutput) bool { return o.star })
+	if positional < 0 {
+		positional = len(outs)
+	}
+	if i := indexFold(aliases, col); i >= 0 {
+		if i < positional {
+			return t.output(scope, i, depth)
+		}
+		return unresolvedLineage(relationName(rel.Table) + "." + col)
+	}
+	var matches []int
+	for i := min(len(aliases), len(outs)); i < len(outs); i++ {
+		if !outs[i].star && strings.EqualFold(outs[i].name, col) {
+			matches = append(matches, i)
+		}
+	}
+	switch len(matches) {
+	case 0:
+	case 1:
+		return t.output(scope, matches[0], depth)
+	default:
+		return unresolvedLineage(relationName(rel.Table) + "." + col)
+	}
+	// An unexpanded star may still supply the column.
+	for _, o := range outs {
+		if !o.star {
+			continue
+		}
+		var rels []int
+		for ri, r := range t.scopes[scope].Relations {
+			if o.qual == "" || relationMatches(r.Table, o.qual) {
+				rels = append(rels, ri)
+			}
+		}
+		if set, ok := t.among(scope, rels, col, depth); ok {
+			set.merge(t.filters(scope, depth), LineageFiltered)
+			return set
+		}
+	}
+	return unresolvedLineage(relationName(rel.Table) + "." + col)
+}
+
+// filters traces the clauses filtering the rows of scope: JOIN conditions
+// including USING columns, WHERE, GROUP BY, and HAVING.
+func (t *lineageTracer) filters(scope, depth int) lineageSet {
+	key := [2]int{scope, -1}
+	if t.active[key] || depth > maxResolveDepth {
+		return lineageSet{}
+	}
+	t.active[key] = true
+	defer delete(t.active, key)
+
+	var out lineageSet
+	for _, c := range t.filterClauses(scope) {
+		out.merge(t.expression(scope, c.expr, c.pos, depth), LineageFiltered)
+	}
+	for _, j := range t.scopes[scope].Joins {
+		for _, col := range j.Using {
+			for _, side := range [][]int{j.Left, j.Right} {
+				if set, ok := t.among(scope, side, col, depth); ok {
+					out.merge(set, LineageFiltered)
+				}
+			}
+		}
+	}
+	return out
+}
+
+// filterClauses returns the filtering clauses of scope. The parser records them for
+// the statement's own query level only, so other scopes are parsed on their own.
+func (t *lineageTracer) filterClauses(scope int) []lineageClause {
+	if clauses, ok := t.clauses[scope]; ok {
+		return clauses
+	}
+	var src *postgresparser.ParsedQuery
+	if scope == 0 {
+		switch t.pq.Command {
+		case postgresparser.QueryCommandSelect, postgresparser.QueryCommandUpdate, postgresparser.QueryCommandDelete:
+			src = t.pq
+		}
+	} else if sc := t.scopes[scope]; sc.End <= len(t.pq.RawSQL) {
+		text := strings.TrimSpace(t.pq.RawSQL[sc.Start:sc.End])
+		if strings.HasPrefix(text, "(") && strings.HasSuffix(text, ")") {
+			text = text[1 : len(text)-1]
+		}
+		src, _ = postgresparser.ParseSQL(text)
+	}
+
+	var out []lineageClause
+	if src != nil {
+		for _, text := range slices.Concat(src.JoinConditions, src.Where, src.GroupBy, src.Having) {
+			expr, skip := trimClauseKeyword(text)
+			if expr == "" {
+				continue
+			}
+			pos := t.locate(scope, text)
+			if pos >= 0 {
+				pos += skip
+			}
+			out = append(out, lineageClause{expr: expr, pos: pos})
+		}
+	}
+	t.clauses[scope] = out
+	return out
+}
+
+// clauseKeywordRe matches the keyword some clauses are recorded with.
+var clauseKeywordRe = regexp.MustCompile(`(?i)^\s*(?:WHERE|ON|HAVING)\s+`)
+
+// trimClauseKeyword strips a leading WHERE, ON, or HAVING from a clause, returning the
+// expression and its offset in the clause. USING and NATURAL conditions return "",
+// since the scope's joins record their columns.
+func trimClauseKeyword(clause string) (string, int) {
+	if hasPrefixFold(strings.TrimSpace(clause), "USING") || hasPrefixFold(strings.TrimSpace(clause), "NATURAL") {
+		return "", 0
+	}
+	skip := 0
+	if m := clauseKeywordRe.FindString(clause); m != "" {
+		skip = len(m)
+	}
+	return strings.TrimSpace(clause[skip:]), skip
+}
+
+// locate returns the offset in RawSQL of text written in scope, or -1.
+func (t *lineageTracer) locate(scope int, text string) int {
+	sc := t.scopes[scope]
+	sql := t.pq.RawSQL
+	if text == "" || sc.End > len(sql) {
+		return -1
+	}
+	for from := sc.Start; from < sc.End; {
+		i := strings.Index(sql[from:sc.End], text)
+		if i < 0 {
+			break
+		}
+		// Text starting with a subquery starts that subquery's scope.
+		if s := sqllex.ScopeAt(t.scopes, from+i); s == scope || t.scopes[s].Parent == scope && t.scopes[s].Start == from+i {
+			return from + i
+		}
+		from += i + 1
+	}
+	return -1
+}
+
+// scopeStartingAt returns the child scope of parent starting at offset pos, or -1.
+func (t *lineageTracer) scopeStartingAt(pos, parent int) int {
+	if pos < 0 {
+		return -1
+	}
+	return slices.IndexFunc(t.scopes, func(s postgresparser.QueryScope) bool {
+		return s.Parent == parent && s.Start == pos
+	})
+}
+
+// expression traces the value of expr, written in scope at offset pos of RawSQL.
+// Subqueries are followed only when pos is known.
+func (t *lineageTracer) expression(scope int, expr string, pos, depth int) lineageSet {
+	if qual, col, ok := parseColumnRef(expr); ok {
+		if qual == "" && lineageKeywords[col] {
+			return lineageSet{kind: LineageExpression} // TRUE, NULL, CURRENT_DATE
+		}
+		return t.reference(scope, qual, col, depth)
+	}
+	parts, aggregate := scanExpression(expr)
+	var out lineageSet
+	out.setKind(LineageExpression)
+	if aggregate {
+		out.setKind(LineageAggregated)
+	}
+	for _, part := range parts {
+		if part.col != "" {
+			out.merge(t.reference(scope, part.qual, part.col, depth), part.kind)
+			continue
+		}
+		q := t.scopeStartingAt(pos+part.offset, scope)
+		if pos < 0 || q < 0 {
+			continue
+		}
+		kind := part.kind
+		if len(parts) == 1 && part.offset == 0 && part.end == len(expr) {
+			out = lineageSet{} // The expression is a scalar subquery
+			kind = LineageDirect
+		}
+		out.merge(t.subquery(q, part.exists, depth+1), kind)
+	}
+	return out
+}
+
+// subquery traces the output columns and filters of a subquery in an expression.
+// Only the filters of an EXISTS subquery matter.
+func (t *lineageTracer) subquery(q int, exists bool, depth int) lineageSet {
+	var out lineageSet
+	if !exists {
+		for i := range t.scopeOutputs(q, depth) {
+			out.merge(t.output(q, i, depth), LineageDirect)
+		}
+	}
+	out.merge(t.filters(q, depth), LineageFiltered)
+	return out
+}
+
+// exprPart is a column reference or subquery found in an expression.
+type exprPart struct {
+	offset int // Offset in the expression
+	end    int // End offset of a subquery
+	qual   string
+	col    string // Column referenced, "" for a subquery
+	kind   LineageKind
+	exists bool // The subquery is tested by EXISTS
+}
+
+// exprFrame is a level of parentheses in an expression.
+type exprFrame struct {
+	kind LineageKind // How references in it are used
+	fn   string      // Function called, lower case, or ""
+	args int         // Identifiers seen directly inside
+}
+
+// lineageAggregates are aggregate functions, whose arguments are aggregated.
+var lineageAggregates = map[string]bool{
+	"count": true, "sum": true, "avg": true, "min": true, "max": true, "array_agg": true,
+	"string_agg": true, "json_agg": true, "jsonb_agg": true, "json_object_agg": true,
+	"jsonb_object_agg": true, "xmlagg": true, "bool_and": true, "bool_or": true, "every": true,
+	"bit_and": true, "bit_or": true, "bit_xor": true, "stddev": true, "stddev_pop": true,
+	"stddev_samp": true, "variance": true, "var_pop": true, "var_samp": true, "corr": true,
+	"covar_pop": true, "covar_samp": true, "mode": true, "percentile_cont": true,
+	"percentile_disc": true, "any_value": true, "range_agg": true, "range_intersect_agg": true,
+}
+
+// lineageKeywords are words of expressions that are not column references.
+var lineageKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "is": true, "null": true, "true": true, "false": true,
+	"unknown": true, "like": true, "ilike": true, "similar": true, "to": true, "escape": true,
+	"between": true, "symmetric": true, "asymmetric": true, "in": true, "any": true, "all": true,
+	"some": true, "exists": true, "case": true, "when": true, "then": true, "else": true,
+	"end": true, "distinct": true, "from": true, "as": true, "asc": true, "desc": true,
+	"nulls": true, "array": true, "row": true, "interval": true, "collate": true, "at": true,
+	"time": true, "zone": true, "isnull": true, "notnull": true, "overlaps": true,
+	"within": true, "filter": true, "over": true, "order": true, "by": true, "partition": true, "where": true, "using": true,
+	"on": true, "both": true, "leading": true, "trailing": true, "placing": true, "for": true,
+	"default": true, "current_date": true, "current_time": true, "current_timestamp": true,
+	"localtime": true, "localtimestamp": true, "current_user": true, "session_user": true,
+	"user": true, "current_role": true, "current_catalog": true, "current_schema": true,
+}
+
+// windowKeywords are the words of a window frame, keywords only inside OVER.
+var windowKeywords = map[string]bool{
+	"rows": true, "range": true, "groups": true, "unbounded": true, "preceding": true,
+	"following": true, "current": true, "exclude": true, "ties": true, "others": true, "no": true,
+}
+
+// typeWords continue a multi-word type name such as double precision.
+var typeWords = map[string]bool{
+	"precision": true, "varying": true, "with": true, "without": true, "time": true, "zone": true,
+}
+
+// subqueryStartRe matches the start of a parenthesized subquery.
+var subqueryStartRe = regexp.MustCompile(`(?i)^\s*(?:SELECT|WITH|VALUES)\b`)
+
+// scanExpression finds the column references and subqueries of an expression,
+// skipping literals, parameters, keywords, and function and type names. It reports
+// whether the expression calls an aggregate outside its subqueries.
+func scanExpression(expr string) (parts []exprPart, aggregate bool) {
+	stack := []exprFrame{{kind: LineageExpression}}
+	var call *exprFrame // Call whose parenthesis follows
+	prev := ""          // Previous word, lower case
+	for i := 0; i < len(expr); {
+		top := &stack[len(stack)-1]
+		c := expr[i]
+		switch {
+		case c == '\'':
+			i = skipQuoted(expr, i, false)
+		case c == '$':
+			i = skipDollar(expr, i)
+		case c >= '0' && c <= '9':
+			for i < len(expr) && (isIdentByte(expr[i]) || expr[i] == '.') {
+				i++
+			}
+		case c == ':' && i+1 < len(expr) && expr[i+1] == ':':
+			i = skipTypeName(expr, i+2)
+		case c == '(':
+			if subqueryStartRe.MatchString(expr[i+1:]) {
+				end := matchParen(expr, i)
+				parts = append(parts, exprPart{offset: i, end: end, kind: top.kind, exists: prev == "exists"})
+				i, call = end, nil
+				continue
+			}
+			f := exprFrame{kind: top.kind}
+			if call != nil {
+				f, call = *call, nil
+			}
+			stack = append(stack, f)
+			i++
+		case c == ')':
+			if len(stack) > 1 {
+				stack = stack[:len(stack)-1]
+			}
+			i++
+		case c == '"' || c == '_' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= 0x80:
+			words, end := readIdentChain(expr, i)
+			last := words[len(words)-1]
+			word := strings.ToLower(last)
+			next := end
+			for next < len(expr) && isSpace(expr[next]) {
+				next++
+			}
+			followedBy := func(s string) bool { return strings.HasPrefix(expr[next:], s) }
+			switch {
+			case words[0] == "":
+				// A field of a composite value, (x).field.
+			case strings.HasPrefix(expr[end:], ".*"):
+				end += 2 // A whole row, t.*
+			case followedBy("("):
+				name := sqlident.Name(last)
+				kind := composeLineage(top.kind, LineageExpression)
+				switch {
+				case lineageAggregates[name] || name == "group": // WITHIN GROUP
+					kind = composeLineage(top.kind, LineageAggregated)
+					aggregate = true
+				case name == "filter" || name == "over":
+					kind = LineageFiltered
+				}
+				call = &exprFrame{kind: kind, fn: name}
+			case len(words) == 1 && word == "e" && end < len(expr) && expr[end] == '\'':
+				end = skipQuoted(expr, end, true)
+			case len(words) == 1 && word == "as" && top.fn == "cast":
+				end = skipTypeName(expr, end)
+			case len(words) == 1 && !strings.HasPrefix(last, `"`) && (lineageKeywords[word] || top.fn == "over" && windowKeywords[word]):
+			case prev == "collate" || prev == "nulls" || prev == "over" || top.fn == "extract" && top.args == 0:
+				// A collation, NULLS FIRST, a named window, or the field EXTRACT takes.
+			case followedBy("'") || followedBy("=>"):
+				// A typed literal such as DATE '2024-01-01', or a named argument.
+			case len(words) <= 3:
+				part := exprPart{offset: i, col: sqlident.Name(last), kind: top.kind}
+				if len(words) > 1 {
+					part.qual = trimQuotes(words[len(words)-2])
+				}
+				parts = append(parts, part)
+			}
+			top.args++
+			prev = word
+			i = end
+			continue
+		default:
+			i++
+		}
+		if !isSpace(c) {
+			prev = ""
+		}
+	}
+	return parts, aggregate
+}
+
+// readIdentChain reads a dotted chain of identifiers starting at i, returning its
+// parts as written and its end. A chain ending in .* is returned without the star.
+// The first part is "" when the chain follows a dot, as a field selection does.
+func readIdentChain(s string, i int) ([]string, int) {
+	var words []string
+	if i > 0 && s[i-1] == '.' {
+		words = append(words, "")
+	}
+	for {
+		start := i
+		if s[i] == '"' {
+			i++
+			for i < len(s) && s[i] != '"' {
+				i++
+			}
+			i = min(i+1, len(s))
+		} else {
+			for i < len(s) && (isIdentByte(s[i]) || s[i] == '$') {
+				i++
+			}
+		}
+		words = append(words, s[start:i])
+		if i+1 >= len(s) || s[i] != '.' || !(s[i+1] == '"' || isIdentByte(s[i+1])) {
+			return words, i
+		}
+		i++
+	}
+}
+
+// skipQuoted returns the offset after the string literal starting at i. With
+// backslash set, as for E'...', a backslash escapes the next character.
+func skipQuoted(s string, i int, backslash bool) int {
+	for j := i + 1; j < len(s); j++ {
+		switch {
+		case backslash && s[j] == '\\':
+			j++
+		case s[j] == '\'':
+			if j+1 < len(s) && s[j+1] == '\'' {
+				j++
+				continue
+			}
+			return j + 1
+		}
+	}
+	return len(s)
+}
+
+// skipDollar returns the offset after a $n parameter or $tag$...$tag$ string at i.
+func skipDollar(s string, i int) int {
+	j := i + 1
+	if j < len(s) && s[j] >= '0' && s[j] <= '9' {
+		for j < len(s) && s[j] >= '0' && s[j] <= '9' {
+			j++
+		}
+		return j
+	}
+	for j < len(s) && isIdentByte(s[j]) {
+		j++
+	}
+	if j >= len(s) || s[j] != '$' {
+		return i + 1
+	}
+	tag := s[i : j+1]
+	if end := strings.Index(s[j+1:], tag); end >= 0 {
+		return j + 1 + end + len(tag)
+	}
+	return len(s)
+}
+
+// skipTypeName returns the offset after the type name starting at i, as in x::type
+// or CAST(x AS type), including multi-word names, modifiers, and array brackets.
+func skipTypeName(s string, i int) int {
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	if i >= len(s) || !(s[i] == '"' || isIdentByte(s[i])) {
+		return i
+	}
+	_, i = readIdentChain(s, i)
+	for {
+		j := i
+		for j < len(s) && isSpace(s[j]) {
+			j++
+		}
+		k := j
+		for k < len(s) && isIdentByte(s[k]) {
+			k++
+		}
+		if k == j || !typeWords[strings.ToLower(s[j:k])] {
+			break
+		}
+		i = k
+	}
+	for i < len(s) && isSpace(s[i]) {
+		i++
+	}
+	if i < len(s) && s[i] == '(' {
+		i = matchParen(s, i)
+	}
+	for i+1 < len(s) && s[i] == '[' {
+		end := strings.IndexByte(s[i:], ']')
+		if end < 0 {
+			return len(s)
+		}
+		i += end + 1
+	}
+	return i
+}
+
+// matchParen returns the offset after the parenthesis matching the one at i.
+func matchParen(s string, i int) int {
+	depth := 0
+	for i < len(s) {
+		switch s[i] {
+		case '\'':
+			i = skipQuoted(s, i, false)
+			continue
+		case '$':
+			i = skipDollar(s, i)
+			continue
+		case '(':
+			depth++
+		case ')':
+			depth--
+			if depth == 0 {
+				return i + 1
+			}
+		}
+		i++
+	}
+	return len(s)
+}
+
+// splitList splits a comma-separated list at its top level, returning each item
+// with its offset in RawSQL, given the list's offset pos, or -1.
+func splitList(list string, pos int) []lineageClause {
+	var out []lineageClause
+	add := func(start, end int) {
+		item := strings.TrimSpace(list[start:end])
+		p := -1
+		if pos >= 0 {
+			p = pos + start + strings.Index(list[start:end], item)
+		}
+		out = append(out, lineageClause{expr: item, pos: p})
+	}
+	start, depth := 0, 0
+	for i := 0; i < len(list); {
+		switch list[i] {
+		case '\'':
+			i = skipQuoted(list, i, false)
+			continue
+		case '$':
+			i = skipDollar(list, i)
+			continue
+		case '(', '[':
+			depth++
+		case ')', ']':
+			depth--
+		case ',':
+			if depth == 0 {
+				add(start, i)
+				start = i + 1
+			}
+		}
+		i++
+	}
+	if strings.TrimSpace(list[start:]) != "" || len(out) > 0 {
+		add(start, len(list))
+	}
+	return out
+}
+
+// starText returns qual.* or *.
+func starText(qual string) string {
+	if qual == "" {
+		return "*"
+	}
+	return qual + ".*"
+}
+
+// isIdentByte reports whether c may continue an unquoted identifier.
+func isIdentByte(c byte) bool {
+	return c == '_' || c >= '0' && c <= '9' || c >= 'A' && c <= 'Z' || c >= 'a' && c <= 'z' || c >= 0x80
+}
+
+// isSpace reports whether c is SQL whitespace.
+func isSpace(c byte) bool {
+	return c == ' ' || c == '\t' || c == '\n' || c == '\r' || c == '\f'
+}
@@ -0,0 +1,203 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// lineageSummary traces query against resolveSchema and summarizes each column as
+// "table.name kind: source/kind ...", with unresolved references as "?ref".
+func lineageSummary(t *testing.T, query string) []string {
+	t.Helper()
+	cols, err := Lineage(query, resolveSchema)
+	require.NoError(t, err, "lineage failed")
+	out := make([]string, len(cols))
+	for i, c := range cols {
+		parts := []string{c.Table + "." + c.Name, string(c.Kind) + ":"}
+		for _, s := range c.Sources {
+			parts = append(parts, s.Table+"."+s.Column+"/"+string(s.Kind))
+		}
+		for _, u := range c.Unresolved {
+			parts = append(parts, "?"+u)
+		}
+		out[i] = strings.Join(parts, " ")
+	}
+	return out
+}
+
+func TestLineage(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{
+			name:  "direct, expression, and aggregated columns with their filters",
+			query: "SELECT u.email, upper(u.name) AS n, count(o.id) AS c FROM users u JOIN orders o ON o.user_id = u.id WHERE u.org_id = 1 GROUP BY u.email, u.name",
+			want: []string{
+				".email direct: users.email/direct orders.user_id/filtered users.id/filtered users.org_id/filtered users.name/filtered",
+				".n expression: users.name/expression orders.user_id/filtered users.id/filtered users.org_id/filtered users.email/filtered",
+				".c aggregated: orders.id/aggregated orders.user_id/filtered users.id/filtered users.org_id/filtered users.email/filtered users.name/filtered",
+			},
+		},
+		{
+			name:  "through a CTE with its own filter",
+			query: "WITH t AS (SELECT user_id, sum(total) AS s FROM orders WHERE created_at > now() GROUP BY user_id) SELECT u.email, t.s FROM users u JOIN t ON t.user_id = u.id",
+			want: []string{
+				".email direct: users.email/direct orders.user_id/filtered orders.created_at/filtered users.id/filtered",
+				".s aggregated: orders.total/aggregated orders.created_at/filtered orders.user_id/filtered users.id/filtered",
+			},
+		},
+		{
+			name:  "every UNION branch",
+			query: "SELECT email FROM users UNION SELECT name FROM orgs WHERE id > 3",
+			want:  []string{".email direct: users.email/direct orgs.name/direct orgs.id/filtered"},
+		},
+		{
+			name:  "scalar and EXISTS subqueries",
+			query: "SELECT (SELECT max(total) FROM orders o WHERE o.user_id = u.id) AS m, u.id FROM users u WHERE EXISTS (SELECT * FROM payments p WHERE p.user_id = u.id)",
+			want: []string{
+				".m aggregated: orders.total/aggregated orders.user_id/filtered users.id/filtered payments.user_id/filtered",
+				".id direct: users.id/direct payments.user_id/filtered",
+			},
+		},
+		{
+			name:  "casts, EXTRACT, and FILTER",
+			query: "SELECT CAST(total AS double precision) AS t, extract(year FROM created_at) y, count(*) FILTER (WHERE total > 5) AS n, created_at::timestamp with time zone AS c FROM orders",
+			want: []string{
+				".t expression: orders.total/expression",
+				".y expression: orders.created_at/expression",
+				".n aggregated: orders.total/filtered",
+				".c expression: orders.created_at/expression",
+			},
+		},
+		{
+			name:  "window partitioning filters",
+			query: "SELECT row_number() OVER (PARTITION BY user_id ORDER BY created_at DESC) AS rn FROM orders",
+			want:  []string{".rn expression: orders.user_id/filtered orders.created_at/filtered"},
+		},
+		{
+			name:  "recursive CTE",
+			query: "WITH RECURSIVE r AS (SELECT id, org_id FROM users UNION ALL SELECT u.id, r.org_id FROM users u JOIN r ON r.id = u.org_id) SELECT org_id FROM r",
+			want:  []string{".org_id direct: users.org_id/direct users.id/filtered"},
+		},
+		{
+			name:  "unknown relations",
+			query: "SELECT a.x, b FROM unknown_t a, other",
+			want:  []string{".x direct: unknown_t.x/direct", ".b direct: ?b"},
+		},
+		{
+			name:  "INSERT ... SELECT",
+			query: "INSERT INTO orgs (id, name) SELECT id, lower(email) FROM users WHERE org_id IS NULL",
+			want: []string{
+				"orgs.id direct: users.id/direct users.org_id/filtered",
+				"orgs.name expression: users.email/expression users.org_id/filtered",
+			},
+		},
+		{
+			name:  "INSERT without a column list",
+			query: "INSERT INTO orgs SELECT * FROM (SELECT id, name FROM users) x",
+			want:  []string{"orgs.id direct: users.id/direct", "orgs.name direct: users.name/direct"},
+		},
+		{
+			name:  "UPDATE with a row assignment",
+			query: "UPDATE users u SET name = o.name, (email, org_id) = (lower(o.name), o.id) FROM orgs o WHERE o.id = u.org_id",
+			want: []string{
+				"users.name direct: orgs.name/direct orgs.id/filtered users.org_id/filtered",
+				"users.email expression: orgs.name/expression orgs.id/filtered users.org_id/filtered",
+				"users.org_id direct: orgs.id/direct users.org_id/filtered",
+			},
+		},
+		{
+			name:  "UPDATE from a subquery",
+			query: "UPDATE orders SET (total, created_at) = (SELECT sum(amount), now() FROM payments p WHERE p.user_id = orders.user_id)",
+			want: []string{
+				"orders.total aggregated: payments.amount/aggregated payments.user_id/filtered orders.user_id/filtered",
+				"orders.created_at expression: payments.user_id/filtered orders.user_id/filtered",
+			},
+		},
+		{
+			name:  "MERGE actions",
+			query: "MERGE INTO users t USING orgs s ON s.id = t.org_id WHEN MATCHED AND s.name <> '' THEN UPDATE SET name = s.name WHEN NOT MATCHED THEN INSERT (id, email) VALUES (s.id, s.name || '@x')",
+			want: []string{
+				"users.name direct: orgs.name/direct orgs.id/filtered users.org_id/filtered",
+				"users.id direct: orgs.id/direct users.org_id/filtered",
+				"users.email expression: orgs.name/expression orgs.id/filtered users.org_id/filtered",
+			},
+		},
+		{
+			name:  "CREATE TABLE AS with USING",
+			query: "CREATE TABLE report (who, amt) AS SELECT u.email, p.amount::numeric(10,2) FROM users u JOIN payments p USING (id)",
+			want: []string{
+				"report.who direct: users.email/direct users.id/filtered payments.id/filtered",
+				"report.amt expression: payments.amount/expression users.id/filtered payments.id/filtered",
+			},
+		},
+		{
+			name:  "SELECT INTO",
+			query: "SELECT email AS contact INTO contacts FROM users",
+			want:  []string{"contacts.contact direct: users.email/direct"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, lineageSummary(t, tt.query))
+		})
+	}
+}
+
+func TestLineage_Structure(t *testing.T) {
+	cols, err := Lineage("WITH t AS (SELECT user_id, sum(total) AS s FROM orders WHERE created_at > now() GROUP BY user_id) SELECT u.email, t.s FROM users u JOIN t ON t.user_id = u.id", resolveSchema)
+	require.NoError(t, err, "lineage failed")
+	assert.Equal(t, []ColumnLineage{
+		{Name: "email", Kind: LineageDirect, Sources: []LineageSource{
+			{Table: "users", Column: "email", Kind: LineageDirect},
+			{Table: "orders", Column: "user_id", Kind: LineageFiltered},
+			{Table: "orders", Column: "created_at", Kind: LineageFiltered},
+			{Table: "users", Column: "id", Kind: LineageFiltered},
+		}},
+		{Name: "s", Kind: LineageAggregated, Sources: []LineageSource{
+			{Table: "orders", Column: "total", Kind: LineageAggregated},
+			{Table: "orders", Column: "created_at", Kind: LineageFiltered},
+			{Table: "orders", Column: "user_id", Kind: LineageFiltered},
+			{Table: "users", Column: "id", Kind: LineageFiltered},
+		}},
+	}, cols, "query through a CTE")
+
+	cols, err = Lineage("INSERT INTO orders (user_id, total) SELECT u.id, x.amount FROM users u WHERE u.org_id = 1", resolveSchema)
+	require.NoError(t, err, "lineage failed")
+	assert.Equal(t, []ColumnLineage{
+		{Name: "user_id", Table: "orders", Kind: LineageDirect, Sources: []LineageSource{
+			{Table: "users", Column: "id", Kind: LineageDirect},
+			{Table: "users", Column: "org_id", Kind: LineageFiltered},
+		}},
+		{Name: "total", Table: "orders", Kind: LineageDirect, Sources: []LineageSource{
+			{Table: "users", Column: "org_id", Kind: LineageFiltered},
+		}, Unresolved: []string{"x.amount"}},
+	}, cols, "INSERT with an unresolved reference")
+}
+
+func TestLineage_View(t *testing.T) {
+	cols, err := Lineage("CREATE VIEW billing.totals (owner, amount) AS SELECT i.id, i.amount * 2 FROM billing.invoices i", resolveSchema)
+	require.NoError(t, err, "lineage failed")
+	require.Len(t, cols, 2, "view columns")
+	assert.Equal(t, ColumnLineage{
+		Name: "amount", Schema: "billing", Table: "totals", Kind: LineageExpression,
+		Sources: []LineageSource{{Schema: "billing", Table: "invoices", Column: "amount", Kind: LineageExpression}},
+	}, cols[1], "second view column")
+}
+
+func TestLineage_UnknownColumns(t *testing.T) {
+	_, err := Lineage("SELECT * FROM unknown_t", resolveSchema)
+	require.ErrorIs(t, err, ErrUnknownColumns, "star over an unknown table")
+
+	_, err = Lineage("INSERT INTO unknown_t SELECT id FROM users", resolveSchema)
+	require.ErrorIs(t, err, ErrUnknownColumns, "INSERT without a column list into an unknown table")
+
+	cols, err := Lineage("DELETE FROM users WHERE id = 1", resolveSchema)
+	require.NoError(t, err, "DELETE")
+	assert.Nil(t, cols, "DELETE writes no columns")
+}
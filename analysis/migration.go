@@ -0,0 +1,386 @@
+// migration.go infers the locks, rewrites, and scans of DDL and flags patterns that
+// block production traffic.
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+)
+
+// LockLevel is a PostgreSQL table-level lock mode, from weakest to strongest.
+type LockLevel string
+
+const (
+	LockAccessShare          LockLevel = "ACCESS SHARE"
+	LockRowShare             LockLevel = "ROW SHARE"
+	LockRowExclusive         LockLevel = "ROW EXCLUSIVE"
+	LockShareUpdateExclusive LockLevel = "SHARE UPDATE EXCLUSIVE"
+	LockShare                LockLevel = "SHARE"
+	LockShareRowExclusive    LockLevel = "SHARE ROW EXCLUSIVE"
+	LockExclusive            LockLevel = "EXCLUSIVE"
+	LockAccessExclusive      LockLevel = "ACCESS EXCLUSIVE"
+)
+
+// lockStrength orders lock levels.
+var lockStrength = map[LockLevel]int{
+	LockAccessShare: 1, LockRowShare: 2, LockRowExclusive: 3, LockShareUpdateExclusive: 4,
+	LockShare: 5, LockShareRowExclusive: 6, LockExclusive: 7, LockAccessExclusive: 8,
+}
+
+// Blocks reports whether l conflicts with other, so one waits for the other.
+// SHARE UPDATE EXCLUSIVE and stronger modes block writes; ACCESS EXCLUSIVE also blocks reads.
+func (l LockLevel) Blocks(other LockLevel) bool {
+	a, b := lockStrength[l], lockStrength[other]
+	if a == 0 || b == 0 {
+		return false
+	}
+	if a < b {
+		a, b = b, a
+	}
+	// Conflict table of PostgreSQL's explicit locking documentation, by strength.
+	switch a {
+	case 8:
+		return true
+	case 7:
+		return b >= 2
+	case 6:
+		return b >= 3
+	case 5:
+		return b == 3 || b == 4 || b == 6
+	case 4:
+		return b >= 4
+	case 3:
+		return b >= 5
+	}
+	return false
+}
+
+// MigrationRisk classifies a MigrationWarning.
+type MigrationRisk string
+
+const (
+	RiskIndexWithoutConcurrently MigrationRisk = "INDEX_WITHOUT_CONCURRENTLY" // CREATE or DROP INDEX blocking writes or all access
+	RiskVolatileDefault          MigrationRisk = "VOLATILE_DEFAULT"           // ADD COLUMN whose volatile, serial, or identity default rewrites the table
+	RiskGeneratedColumn          MigrationRisk = "GENERATED_COLUMN"           // ADD COLUMN of a stored generated column rewriting the table
+	RiskNotNullWithoutDefault    MigrationRisk = "NOT_NULL_WITHOUT_DEFAULT"   // ADD COLUMN NOT NULL without a default fails on a non-empty table
+	RiskSetNotNull               MigrationRisk = "SET_NOT_NULL"               // SET NOT NULL scanning the table without a validated CHECK
+	RiskConstraintValidation     MigrationRisk = "CONSTRAINT_VALIDATION"      // FOREIGN KEY or CHECK validated while holding the lock
+	RiskIndexBuild               MigrationRisk = "INDEX_BUILD"                // PRIMARY KEY or UNIQUE building its index under ACCESS EXCLUSIVE
+	RiskColumnType               MigrationRisk = "COLUMN_TYPE"                // ALTER COLUMN TYPE rewriting the table and its indexes
+	RiskRename                   MigrationRisk = "RENAME"                     // Renamed table or column breaking running clients
+)
+
+// MigrationWarning is a dangerous pattern found in a DDL action.
+type MigrationWarning struct {
+	Risk    MigrationRisk
+	Message string
+}
+
+// RelationLock is a lock a DDL action takes on an existing relation.
+type RelationLock struct {
+	Schema string
+	Name   string // Table, or the index of DROP INDEX
+	Level  LockLevel
+}
+
+// MigrationStep describes the effect of one DDL action on the relations it touches.
+type MigrationStep struct {
+	Statement int // Index of the statement in the script
+	Action    postgresparser.DDLAction
+	Locks     []RelationLock // Locks on existing relations, held until the transaction ends
+	Rewrite   bool           // The table and its indexes are rewritten
+	Scan      bool           // The whole table is read while the lock is held
+	Warnings  []MigrationWarning
+}
+
+// Lock returns the strongest lock of the step, or "" when it locks no existing relation.
+func (s MigrationStep) Lock() LockLevel {
+	var out LockLevel
+	for _, l := range s.Locks {
+		if lockStrength[l.Level] > lockStrength[out] {
+			out = l.Level
+		}
+	}
+	return out
+}
+
+// AnalyzeMigration parses a migration script and returns a step per DDL action. See
+// ExtractMigrationSteps.
+func AnalyzeMigration(script string) ([]MigrationStep, error) {
+	stmts, err := postgresparser.ParseSQLAll(script)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse migration: %w", err)
+	}
+	return ExtractMigrationSteps(stmts), nil
+}
+
+// ExtractMigrationSteps returns the lock level, rewrite, and scan of every DDL action of
+// a migration, with warnings for patterns that block traffic on a populated table:
+// CREATE INDEX without CONCURRENTLY, ADD COLUMN with a volatile default, SET NOT NULL
+// without a validated CHECK (col IS NOT NULL), FOREIGN KEY and CHECK constraints added
+// without NOT VALID, PRIMARY KEY and UNIQUE without USING INDEX, ALTER COLUMN TYPE, and
+// RENAME of tables and columns.
+//
+// Tables created earlier in the migration are empty, so their actions get no warnings.
+// Lock levels follow PostgreSQL 12 and later; ALTER COLUMN TYPE is assumed to rewrite,
+// although PostgreSQL skips the rewrite for binary-coercible changes such as
+// varchar(n) to text.
+func ExtractMigrationSteps(stmts []*postgresparser.ParsedQuery) []MigrationStep {
+	m := migration{created: make(map[string]bool), checks: make(map[string]notNullCheck)}
+	var out []MigrationStep
+	for i, pq := range stmts {
+		if pq == nil {
+			continue
+		}
+		for _, a := range pq.DDLActions {
+			step := m.step(a)
+			step.Statement = i
+			out = append(out, step)
+		}
+	}
+	return out
+}
+
+// notNullCheck is a CHECK (col IS NOT NULL) constraint added by the migration.
+type notNullCheck struct {
+	table, column string
+	validated     bool
+}
+
+// migration tracks the tables and constraints created so far.
+type migration struct {
+	created map[string]bool         // Tables created by the migration, by relationKey
+	checks  map[string]notNullCheck // CHECK (col IS NOT NULL) constraints, by table key and name
+}
+
+// notNullCheckRe matches a CHECK expression asserting a column is not null.
+var notNullCheckRe = regexp.MustCompile(`(?i)^\(*\s*("(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)\s+IS\s+NOT\s+NULL\s*\)*$`)
+
+// volatileDefaultRe matches calls to common volatile functions, whose defaults are
+// computed for every existing row.
+var volatileDefaultRe = regexp.MustCompile(`(?i)\b(random|gen_random_uuid|uuid_generate_v1|uuid_generate_v1mc|uuid_generate_v4|clock_timestamp|timeofday|nextval|txid_current|pg_current_xact_id)\s*\(`)
+
+// step analyzes one DDL action and records the tables and constraints it creates.
+func (m *migration) step(a postgresparser.DDLAction) MigrationStep {
+	step := MigrationStep{Action: a}
+	table := relationKey(a.Schema, a.ObjectName)
+	if a.Table != "" {
+		table = relationKey(a.Schema, a.Table)
+	}
+	isNew := m.created[table]
+	lock := func(schema, name string, level LockLevel) {
+		if !m.created[relationKey(schema, name)] {
+			step.Locks = append(step.Locks, RelationLock{Schema: schema, Name: name, Level: level})
+		}
+	}
+	warn := func(risk MigrationRisk, format string, args ...any) {
+		if !isNew {
+			step.Warnings = append(step.Warnings, MigrationWarning{Risk: risk, Message: fmt.Sprintf(format, args...)})
+		}
+	}
+	name := schemaQualified(a.Schema, a.ObjectName)
+
+	switch a.Type {
+	case postgresparser.DDLCreateTable:
+		m.created[table] = true
+		for _, c := range a.Constraints {
+			if c.Type == postgresparser.DDLConstraintForeignKey {
+				lock(c.RefSchema, c.RefTable, LockShareRowExclusive)
+			}
+		}
+	case postgresparser.DDLCreateIndex:
+		step.Scan = true
+		if hasFlagFold(a.Flags, "CONCURRENTLY") {
+			lock(a.Schema, a.Table, LockShareUpdateExclusive)
+		} else {
+			lock(a.Schema, a.Table, LockShare)
+			warn(RiskIndexWithoutConcurrently, "CREATE INDEX %s blocks writes to %s while it builds; use CREATE INDEX CONCURRENTLY", a.ObjectName, schemaQualified(a.Schema, a.Table))
+		}
+	case postgresparser.DDLDropIndex:
+		if hasFlagFold(a.Flags, "CONCURRENTLY") {
+			lock(a.Schema, a.ObjectName, LockShareUpdateExclusive)
+		} else {
+			lock(a.Schema, a.ObjectName, LockAccessExclusive)
+			warn(RiskIndexWithoutConcurrently, "DROP INDEX %s blocks all access to its table; use DROP INDEX CONCURRENTLY", name)
+		}
+	case postgresparser.DDLDropTable, postgresparser.DDLTruncate, postgresparser.DDLDropColumn:
+		lock(a.Schema, a.ObjectName, LockAccessExclusive)
+		if a.Type == postgresparser.DDLDropTable {
+			delete(m.created, table)
+		}
+	case postgresparser.DDLCreateTrigger:
+		lock(a.Schema, a.Table, LockShareRowExclusive)
+	case postgresparser.DDLDrop, postgresparser.DDLAlter:
+		switch a.ObjectKind {
+		case postgresparser.DDLObjectView, postgresparser.DDLObjectMaterializedView, postgresparser.DDLObjectForeignTable, postgresparser.DDLObjectSequence:
+			lock(a.Schema, a.ObjectName, LockAccessExclusive)
+		case postgresparser.DDLObjectIndex:
+			lock(a.Schema, a.ObjectName, LockShareUpdateExclusive)
+		case postgresparser.DDLObjectTrigger, postgresparser.DDLObjectPolicy, postgresparser.DDLObjectRule:
+			lock(a.Schema, a.Table, LockAccessExclusive)
+		}
+	case postgresparser.DDLAlterTable:
+		m.alterTable(&step, a, lock, warn)
+	}
+	return step
+}
+
+// alterTable fills in a step for an ALTER TABLE sub-command.
+func (m *migration) alterTable(step *MigrationStep, a postgresparser.DDLAction, lock func(schema, name string, level LockLevel), warn func(risk MigrationRisk, format string, args ...any)) {
+	table := relationKey(a.Schema, a.ObjectName)
+	name := schemaQualified(a.Schema, a.ObjectName)
+	has := func(flag string) bool { return hasFlagFold(a.Flags, flag) }
+	level := LockAccessExclusive
+	switch {
+	case has("ADD_COLUMN"):
+		for _, col := range a.ColumnDetails {
+			switch {
+			case col.Generated != "":
+				step.Rewrite = true
+				warn(RiskGeneratedColumn, "adding stored generated column %s rewrites %s", col.Name, name)
+			case col.Identity != "":
+				step.Rewrite = true
+				warn(RiskVolatileDefault, "adding identity column %s rewrites %s", col.Name, name)
+			case isSerialType(col.Type) || volatileDefaultRe.MatchString(col.Default):
+				step.Rewrite = true
+				warn(RiskVolatileDefault, "the volatile default of column %s is computed for every row, rewriting %s; add the column without a default and backfill it in batches", col.Name, name)
+			case !col.Nullable && col.Default == "":
+				warn(RiskNotNullWithoutDefault, "adding NOT NULL column %s without a default fails unless %s is empty", col.Name, name)
+			}
+		}
+		level = "" // constraints takes the table lock
+		m.constraints(step, a, lock, warn)
+	case has("SET_TYPE"):
+		step.Rewrite = true
+		for _, col := range a.ColumnDetails {
+			warn(RiskColumnType, "changing the type of %s.%s to %s rewrites %s and its indexes under an ACCESS EXCLUSIVE lock", name, col.Name, col.Type, name)
+		}
+	case has("SET_NOT_NULL"):
+		for _, col := range a.Columns {
+			if m.hasValidNotNullCheck(table, col) {
+				continue
+			}
+			step.Scan = true
+			warn(RiskSetNotNull, "SET NOT NULL on %s.%s scans %s under an ACCESS EXCLUSIVE lock; add CHECK (%s IS NOT NULL) NOT VALID and VALIDATE it first", name, col, name, col)
+		}
+	case has("SET_STATISTICS"), has("ALTER_COLUMN") && (has("SET_OPTIONS") || has("RESET_OPTIONS")):
+		level = LockShareUpdateExclusive
+	case has("ADD_CONSTRAINT"):
+		level = ""
+		m.constraints(step, a, lock, warn)
+	case has("VALIDATE_CONSTRAINT"):
+		level = LockShareUpdateExclusive
+		step.Scan = true
+		for _, c := range a.Constraints {
+			key := table + "." + strings.ToLower(trimQuotes(c.Name))
+			if chk, ok := m.checks[key]; ok {
+				chk.validated = true
+				m.checks[key] = chk
+			}
+		}
+	case has("RENAME"):
+		warn(RiskRename, "renaming %s to %s breaks clients still using the old name", name, a.NewName)
+		if m.created[table] {
+			defer func() {
+				delete(m.created, table)
+				m.created[relationKey(a.Schema, a.NewName)] = true
+			}()
+		}
+	case has("RENAME_COLUMN"):
+		for _, col := range a.Columns {
+			warn(RiskRename, "renaming %s.%s to %s breaks clients still using the old name", name, col, a.NewName)
+		}
+	case has("SET_TABLESPACE"), has("SET_LOGGED"), has("SET_UNLOGGED"):
+		step.Rewrite = true
+	case has("ENABLE_TRIGGER"), has("ENABLE_ALWAYS_TRIGGER"), has("ENABLE_REPLICA_TRIGGER"), has("DISABLE_TRIGGER"):
+		level = LockShareRowExclusive
+	case has("CLUSTER_ON"), has("SET_WITHOUT_CLUSTER"), has("SET_OPTIONS"), has("RESET_OPTIONS"), has("ATTACH_PARTITION"):
+		level = LockShareUpdateExclusive
+	case has("DETACH_PARTITION") && has("CONCURRENTLY"):
+		level = LockShareUpdateExclusive
+	}
+	if level != "" {
+		lock(a.Schema, a.ObjectName, level)
+	}
+}
+
+// constraints adds the locks, scans, and warnings of the constraints an ALTER TABLE
+// action adds.
+func (m *migration) constraints(step *MigrationStep, a postgresparser.DDLAction, lock func(schema, name string, level LockLevel), warn func(risk MigrationRisk, format string, args ...any)) {
+	table := relationKey(a.Schema, a.ObjectName)
+	name := schemaQualified(a.Schema, a.ObjectName)
+	level := LockLevel("")
+	if !hasFlagFold(a.Flags, "ADD_CONSTRAINT") {
+		level = LockAccessExclusive // ADD COLUMN
+	}
+	for _, c := range a.Constraints {
+		label := c.Name
+		if label == "" {
+			label = string(c.Type)
+		}
+		switch c.Type {
+		case postgresparser.DDLConstraintForeignKey:
+			if lockStrength[level] < lockStrength[LockShareRowExclusive] {
+				level = LockShareRowExclusive
+			}
+			lock(c.RefSchema, c.RefTable, LockShareRowExclusive)
+			if !c.NotValid {
+				step.Scan = true
+				warn(RiskConstraintValidation, "foreign key %s is validated against every row of %s while writes to both tables are blocked; add it NOT VALID and VALIDATE CONSTRAINT separately", label, name)
+			}
+		case postgresparser.DDLConstraintCheck:
+			level = LockAccessExclusive
+			if col := notNullCheckRe.FindStringSubmatch(c.Expression); col != nil && c.Name != "" {
+				m.checks[table+"."+strings.ToLower(trimQuotes(c.Name))] = notNullCheck{table: table, column: sqlident.Name(col[1]), validated: !c.NotValid}
+			}
+			if !c.NotValid {
+				step.Scan = true
+				warn(RiskConstraintValidation, "check constraint %s is validated against every row of %s under an ACCESS EXCLUSIVE lock; add it NOT VALID and VALIDATE CONSTRAINT separately", label, name)
+			}
+		case postgresparser.DDLConstraintPrimaryKey, postgresparser.DDLConstraintUnique, postgresparser.DDLConstraintExclude:
+			level = LockAccessExclusive
+			if c.Index == "" {
+				step.Scan = true
+				warn(RiskIndexBuild, "%s builds its index on %s under an ACCESS EXCLUSIVE lock; create a unique index CONCURRENTLY and add the constraint USING INDEX", label, name)
+			}
+		}
+	}
+	if level != "" {
+		lock(a.Schema, a.ObjectName, level)
+	}
+}
+
+// hasValidNotNullCheck reports whether the migration added and validated a
+// CHECK (column IS NOT NULL) on table, which lets SET NOT NULL skip its scan.
+func (m *migration) hasValidNotNullCheck(table, column string) bool {
+	for _, chk := range m.checks {
+		if chk.validated && chk.table == table && strings.EqualFold(chk.column, sqlident.Name(column)) {
+			return true
+		}
+	}
+	return false
+}
+
+// relationKey returns a case-folded key for a relation.
+func relationKey(schema, name string) string {
+	return strings.ToLower(trimQuotes(schema)) + "." + strings.ToLower(trimQuotes(name))
+}
+
+// hasFlagFold reports whether flags contains flag, ignoring case.
+func hasFlagFold(flags []string, flag string) bool {
+	return indexFold(flags, flag) >= 0
+}
+
+// isSerialType reports whether a column type is a serial pseudo-type, whose default
+// draws from a new sequence.
+func isSerialType(typ string) bool {
+	switch strings.ToLower(typ) {
+	case "serial", "serial4", "bigserial", "serial8", "smallserial", "serial2":
+		return true
+	}
+	return false
+}
@@ -0,0 +1,246 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// stepSummary renders a step as "TABLE:LEVEL ... [rewrite] [scan]" and its warning risks.
+func stepSummary(s MigrationStep) (string, []MigrationRisk) {
+	var parts []string
+	for _, l := range s.Locks {
+		parts = append(parts, qualifiedName(l.Schema, l.Name)+":"+string(l.Level))
+	}
+	if s.Rewrite {
+		parts = append(parts, "rewrite")
+	}
+	if s.Scan {
+		parts = append(parts, "scan")
+	}
+	var risks []MigrationRisk
+	for _, w := range s.Warnings {
+		risks = append(risks, w.Risk)
+	}
+	return strings.Join(parts, " "), risks
+}
+
+func TestAnalyzeMigration(t *testing.T) {
+	tests := []struct {
+		name  string
+		sql   string
+		steps []string          // stepSummary of each step
+		risks [][]MigrationRisk // Warning risks of each step
+	}{
+		{
+			name:  "create index",
+			sql:   "CREATE INDEX orders_user_idx ON app.orders (user_id)",
+			steps: []string{"app.orders:SHARE scan"},
+			risks: [][]MigrationRisk{{RiskIndexWithoutConcurrently}},
+		},
+		{
+			name:  "create index concurrently",
+			sql:   "CREATE INDEX CONCURRENTLY orders_user_idx ON orders (user_id)",
+			steps: []string{"orders:SHARE UPDATE EXCLUSIVE scan"},
+			risks: [][]MigrationRisk{nil},
+		},
+		{
+			name:  "drop index",
+			sql:   "DROP INDEX orders_user_idx; DROP INDEX CONCURRENTLY orders_email_idx",
+			steps: []string{"orders_user_idx:ACCESS EXCLUSIVE", "orders_email_idx:SHARE UPDATE EXCLUSIVE"},
+			risks: [][]MigrationRisk{{RiskIndexWithoutConcurrently}, nil},
+		},
+		{
+			name:  "add nullable column",
+			sql:   "ALTER TABLE users ADD COLUMN nickname text",
+			steps: []string{"users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{nil},
+		},
+		{
+			name:  "add column with constant default",
+			sql:   "ALTER TABLE users ADD COLUMN active boolean NOT NULL DEFAULT true",
+			steps: []string{"users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{nil},
+		},
+		{
+			name:  "add column with volatile default",
+			sql:   "ALTER TABLE users ADD COLUMN token uuid DEFAULT gen_random_uuid()",
+			steps: []string{"users:ACCESS EXCLUSIVE rewrite"},
+			risks: [][]MigrationRisk{{RiskVolatileDefault}},
+		},
+		{
+			name:  "add serial and identity columns",
+			sql:   "ALTER TABLE users ADD COLUMN seq bigserial; ALTER TABLE users ADD COLUMN n int GENERATED ALWAYS AS IDENTITY",
+			steps: []string{"users:ACCESS EXCLUSIVE rewrite", "users:ACCESS EXCLUSIVE rewrite"},
+			risks: [][]MigrationRisk{{RiskVolatileDefault}, {RiskVolatileDefault}},
+		},
+		{
+			name:  "add stored generated column",
+			sql:   "ALTER TABLE users ADD COLUMN email_lower text GENERATED ALWAYS AS (lower(email)) STORED",
+			steps: []string{"users:ACCESS EXCLUSIVE rewrite"},
+			risks: [][]MigrationRisk{{RiskGeneratedColumn}},
+		},
+		{
+			name:  "add NOT NULL column without default",
+			sql:   "ALTER TABLE users ADD COLUMN email text NOT NULL",
+			steps: []string{"users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{{RiskNotNullWithoutDefault}},
+		},
+		{
+			name:  "set not null",
+			sql:   "ALTER TABLE users ALTER COLUMN email SET NOT NULL",
+			steps: []string{"users:ACCESS EXCLUSIVE scan"},
+			risks: [][]MigrationRisk{{RiskSetNotNull}},
+		},
+		{
+			name: "set not null after a validated check",
+			sql: `ALTER TABLE users ADD CONSTRAINT email_nn CHECK (email IS NOT NULL) NOT VALID;
+ALTER TABLE users VALIDATE CONSTRAINT email_nn;
+ALTER TABLE users ALTER COLUMN email SET NOT NULL`,
+			steps: []string{"users:ACCESS EXCLUSIVE", "users:SHARE UPDATE EXCLUSIVE scan", "users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{nil, nil, nil},
+		},
+		{
+			name:  "set not null with an unvalidated check",
+			sql:   "ALTER TABLE users ADD CONSTRAINT email_nn CHECK (email IS NOT NULL) NOT VALID; ALTER TABLE users ALTER COLUMN email SET NOT NULL",
+			steps: []string{"users:ACCESS EXCLUSIVE", "users:ACCESS EXCLUSIVE scan"},
+			risks: [][]MigrationRisk{nil, {RiskSetNotNull}},
+		},
+		{
+			name:  "add foreign key",
+			sql:   "ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES app.users (id)",
+			steps: []string{"app.users:SHARE ROW EXCLUSIVE orders:SHARE ROW EXCLUSIVE scan"},
+			risks: [][]MigrationRisk{{RiskConstraintValidation}},
+		},
+		{
+			name:  "add foreign key not valid",
+			sql:   "ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id) NOT VALID",
+			steps: []string{"users:SHARE ROW EXCLUSIVE orders:SHARE ROW EXCLUSIVE"},
+			risks: [][]MigrationRisk{nil},
+		},
+		{
+			name:  "add check",
+			sql:   "ALTER TABLE orders ADD CONSTRAINT total_positive CHECK (total > 0)",
+			steps: []string{"orders:ACCESS EXCLUSIVE scan"},
+			risks: [][]MigrationRisk{{RiskConstraintValidation}},
+		},
+		{
+			name:  "add unique constraint",
+			sql:   "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE (email)",
+			steps: []string{"users:ACCESS EXCLUSIVE scan"},
+			risks: [][]MigrationRisk{{RiskIndexBuild}},
+		},
+		{
+			name:  "add primary key using index",
+			sql:   "ALTER TABLE users ADD CONSTRAINT users_pkey PRIMARY KEY USING INDEX users_id_idx",
+			steps: []string{"users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{nil},
+		},
+		{
+			name:  "alter column type",
+			sql:   "ALTER TABLE orders ALTER COLUMN total TYPE numeric(12,2)",
+			steps: []string{"orders:ACCESS EXCLUSIVE rewrite"},
+			risks: [][]MigrationRisk{{RiskColumnType}},
+		},
+		{
+			name:  "renames",
+			sql:   "ALTER TABLE users RENAME COLUMN name TO full_name; ALTER TABLE users RENAME TO accounts",
+			steps: []string{"users:ACCESS EXCLUSIVE", "users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{{RiskRename}, {RiskRename}},
+		},
+		{
+			name:  "statistics and tablespace",
+			sql:   "ALTER TABLE users ALTER COLUMN email SET STATISTICS 500; ALTER TABLE users SET TABLESPACE fast",
+			steps: []string{"users:SHARE UPDATE EXCLUSIVE", "users:ACCESS EXCLUSIVE rewrite"},
+			risks: [][]MigrationRisk{nil, nil},
+		},
+		{
+			name: "table created by the migration",
+			sql: `CREATE TABLE audit (id bigint, user_id bigint REFERENCES users (id));
+CREATE INDEX audit_user_idx ON audit (user_id);
+ALTER TABLE audit ADD COLUMN token uuid DEFAULT gen_random_uuid();
+ALTER TABLE audit RENAME TO audit_log;
+ALTER TABLE audit_log ALTER COLUMN id SET NOT NULL`,
+			steps: []string{"users:SHARE ROW EXCLUSIVE", "scan", "rewrite", "", "scan"},
+			risks: [][]MigrationRisk{nil, nil, nil, nil, nil},
+		},
+		{
+			name:  "drop and truncate",
+			sql:   "DROP TABLE old_orders; TRUNCATE sessions; ALTER TABLE users DROP COLUMN legacy",
+			steps: []string{"old_orders:ACCESS EXCLUSIVE", "sessions:ACCESS EXCLUSIVE", "users:ACCESS EXCLUSIVE"},
+			risks: [][]MigrationRisk{nil, nil, nil},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			steps, err := AnalyzeMigration(tt.sql)
+			require.NoError(t, err)
+			var gotSteps []string
+			var gotRisks [][]MigrationRisk
+			for _, s := range steps {
+				summary, risks := stepSummary(s)
+				gotSteps = append(gotSteps, summary)
+				gotRisks = append(gotRisks, risks)
+			}
+			assert.Equal(t, tt.steps, gotSteps, "steps")
+			assert.Equal(t, tt.risks, gotRisks, "warnings")
+		})
+	}
+}
+
+func TestAnalyzeMigration_StepFields(t *testing.T) {
+	steps, err := AnalyzeMigration("SELECT 1; ALTER TABLE users ADD COLUMN a int, ALTER COLUMN b TYPE bigint")
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	assert.Equal(t, 1, steps[0].Statement)
+	assert.Equal(t, 1, steps[1].Statement)
+	assert.Equal(t, []string{"ALTER_COLUMN", "SET_TYPE"}, steps[1].Action.Flags)
+	assert.Equal(t, LockAccessExclusive, steps[1].Lock())
+	assert.Contains(t, steps[1].Warnings[0].Message, "users.b to bigint")
+
+	_, err = AnalyzeMigration("ALTER TABLE")
+	assert.Error(t, err)
+}
+
+func TestAnalyzeMigration_Structure(t *testing.T) {
+	steps, err := AnalyzeMigration("CREATE INDEX orders_user_idx ON app.orders (user_id); ALTER TABLE users ALTER COLUMN b TYPE bigint")
+	require.NoError(t, err)
+	require.Len(t, steps, 2)
+	for i := range steps {
+		steps[i].Action = postgresparser.DDLAction{}
+	}
+	assert.Equal(t, []MigrationStep{
+		{
+			Statement: 0,
+			Locks:     []RelationLock{{Schema: "app", Name: "orders", Level: LockShare}},
+			Scan:      true,
+			Warnings: []MigrationWarning{{
+				Risk:    RiskIndexWithoutConcurrently,
+				Message: "CREATE INDEX orders_user_idx blocks writes to app.orders while it builds; use CREATE INDEX CONCURRENTLY",
+			}},
+		},
+		{
+			Statement: 1,
+			Locks:     []RelationLock{{Name: "users", Level: LockAccessExclusive}},
+			Rewrite:   true,
+			Warnings: []MigrationWarning{{
+				Risk:    RiskColumnType,
+				Message: "changing the type of users.b to bigint rewrites users and its indexes under an ACCESS EXCLUSIVE lock",
+			}},
+		},
+	}, steps)
+}
+
+func TestLockLevel_Blocks(t *testing.T) {
+	assert.True(t, LockAccessExclusive.Blocks(LockAccessShare))
+	assert.True(t, LockShare.Blocks(LockRowExclusive))
+	assert.True(t, LockShareUpdateExclusive.Blocks(LockShareUpdateExclusive))
+	assert.False(t, LockShareUpdateExclusive.Blocks(LockRowExclusive))
+	assert.False(t, LockShare.Blocks(LockShare))
+	assert.False(t, LockRowExclusive.Blocks(LockAccessShare))
+	assert.True(t, LockAccessShare.Blocks(LockAccessExclusive))
+}
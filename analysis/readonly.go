@@ -0,0 +1,148 @@
+// readonly.go decides whether SQL is safe to run on a read-only connection.
+package analysis
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// ReasonKind is why a statement is not read-only.
+type ReasonKind string
+
+const (
+	// ReasonParseError means the SQL could not be parsed, so nothing can be vouched for.
+	ReasonParseError ReasonKind = "PARSE_ERROR"
+	// ReasonStatement means the statement is not a query: DML, DDL, SELECT INTO, or a
+	// utility command such as SET, VACUUM, or CALL.
+	ReasonStatement ReasonKind = "STATEMENT"
+	// ReasonModifyingCTE means a WITH query is an INSERT, UPDATE, DELETE, or MERGE.
+	ReasonModifyingCTE ReasonKind = "MODIFYING_CTE"
+	// ReasonLocking means a FOR UPDATE/SHARE clause takes row locks.
+	ReasonLocking ReasonKind = "LOCKING"
+	// ReasonFunction means a function on the deny list is called.
+	ReasonFunction ReasonKind = "FUNCTION"
+)
+
+// Reason is one finding that makes SQL unsafe to run read-only.
+type Reason struct {
+	Kind      ReasonKind
+	Statement int    // Index of the statement in the script
+	Detail    string // Command or DDL action type, "name: COMMAND" of a CTE, locking strength such as "FOR UPDATE", function name as written, or the parse error
+	Position  int    // Character offset in the statement's RawSQL; 0 for the statement itself
+}
+
+// DefaultDenyFunctions are functions that write, take locks, signal other sessions,
+// touch the server filesystem, or change settings, even when called from a SELECT.
+var DefaultDenyFunctions = []string{
+	"nextval", "setval",
+	"pg_advisory_lock", "pg_advisory_lock_shared", "pg_advisory_xact_lock", "pg_advisory_xact_lock_shared",
+	"pg_try_advisory_lock", "pg_try_advisory_lock_shared", "pg_try_advisory_xact_lock", "pg_try_advisory_xact_lock_shared",
+	"pg_terminate_backend", "pg_cancel_backend", "pg_reload_conf", "pg_rotate_logfile",
+	"dblink_exec", "dblink_connect", "dblink_send_query",
+	"lo_import", "lo_export", "lo_unlink", "lo_create", "lo_creat", "lo_from_bytea", "lo_put", "lo_truncate",
+	"set_config", "txid_current", "pg_current_xact_id",
+}
+
+// ReadOnlyPolicy configures IsReadOnly.
+type ReadOnlyPolicy struct {
+	// DenyFunctions lists functions whose calls make SQL unsafe, by name or as
+	// schema.name, matched case-insensitively. A bare name matches any schema.
+	DenyFunctions []string
+}
+
+// IsReadOnly reports whether every statement of sql is a plain query, using
+// DefaultDenyFunctions. See ReadOnlyPolicy.IsReadOnly.
+func IsReadOnly(sql string) (bool, []Reason) {
+	return ReadOnlyPolicy{DenyFunctions: DefaultDenyFunctions}.IsReadOnly(sql)
+}
+
+// IsReadOnly parses every statement of sql and reports whether all of them are
+// queries that modify nothing, with the reasons when they are not. SQL that fails to
+// parse is never read-only.
+func (p ReadOnlyPolicy) IsReadOnly(sql string) (bool, []Reason) {
+	stmts, err := postgresparser.ParseSQLAll(sql)
+	if err != nil {
+		return false, []Reason{{Kind: ReasonParseError, Detail: err.Error()}}
+	}
+	var reasons []Reason
+	for i, pq := range stmts {
+		for _, r := range p.Reasons(pq) {
+			r.Statement = i
+			reasons = append(reasons, r)
+		}
+	}
+	return len(reasons) == 0, reasons
+}
+
+// Reasons returns why a parsed statement is not read-only, in the order found: the
+// statement kind, data-modifying CTEs, row-locking clauses, then denied function
+// calls. It returns nil for a read-only query.
+func (p ReadOnlyPolicy) Reasons(pq *postgresparser.ParsedQuery) []Reason {
+	if pq == nil {
+		return nil
+	}
+	var reasons []Reason
+	switch pq.Command {
+	case postgresparser.QueryCommandSelect:
+		if pq.Target != nil {
+			reasons = append(reasons, Reason{Kind: ReasonStatement, Detail: "SELECT INTO"})
+		}
+	case postgresparser.QueryCommandDDL:
+		for _, a := range pq.DDLActions {
+			reasons = append(reasons, Reason{Kind: ReasonStatement, Detail: string(a.Type)})
+		}
+		if len(pq.DDLActions) == 0 {
+			reasons = append(reasons, Reason{Kind: ReasonStatement, Detail: string(pq.Command)})
+		}
+	case postgresparser.QueryCommandUnknown:
+		reasons = append(reasons, Reason{Kind: ReasonStatement, Detail: leadingKeyword(pq.RawSQL)})
+	default:
+		reasons = append(reasons, Reason{Kind: ReasonStatement, Detail: string(pq.Command)})
+	}
+
+	modifying := modifyingCTEs(pq)
+	for _, i := range slices.Sorted(maps.Keys(modifying)) {
+		sc := pq.Scopes[i]
+		reasons = append(reasons, Reason{Kind: ReasonModifyingCTE, Detail: sc.Name + ": " + string(modifying[i].Command), Position: sc.Start})
+	}
+	for _, l := range pq.Locking {
+		reasons = append(reasons, Reason{Kind: ReasonLocking, Detail: "FOR " + l.Strength, Position: l.Position})
+	}
+	for _, f := range pq.Functions {
+		if p.denied(f) {
+			name := f.Name
+			if f.Schema != "" {
+				name = f.Schema + "." + name
+			}
+			reasons = append(reasons, Reason{Kind: ReasonFunction, Detail: name, Position: f.Position})
+		}
+	}
+	return reasons
+}
+
+// denied reports whether f is on the deny list.
+func (p ReadOnlyPolicy) denied(f postgresparser.FunctionCall) bool {
+	schema, name := trimQuotes(f.Schema), trimQuotes(f.Name)
+	for _, d := range p.DenyFunctions {
+		if ds, dn, ok := strings.Cut(d, "."); ok {
+			if strings.EqualFold(ds, schema) && strings.EqualFold(dn, name) {
+				return true
+			}
+		} else if strings.EqualFold(d, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// leadingKeyword returns the first word of a statement, upper-cased.
+func leadingKeyword(sql string) string {
+	fields := strings.Fields(sql)
+	if len(fields) == 0 {
+		return string(postgresparser.QueryCommandUnknown)
+	}
+	return strings.ToUpper(strings.TrimRight(fields[0], ";("))
+}
@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// reasonSummary renders reasons as "statement KIND detail".
+func reasonSummary(reasons []Reason) []string {
+	var out []string
+	for _, r := range reasons {
+		out = append(out, strconv.Itoa(r.Statement)+" "+string(r.Kind)+" "+r.Detail)
+	}
+	return out
+}
+
+func TestIsReadOnly(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		reasons []string
+	}{
+		{name: "query", sql: "SELECT o.id, lower(u.email) FROM orders o JOIN users u ON u.id = o.user_id WHERE o.id IN (SELECT order_id FROM refunds)"},
+		{name: "query with a read-only CTE", sql: "WITH t AS (SELECT * FROM orders) SELECT count(*) FROM t"},
+		{name: "DML", sql: "UPDATE users SET name = 'x'", reasons: []string{"0 STATEMENT UPDATE"}},
+		{name: "DDL", sql: "DROP TABLE users", reasons: []string{"0 STATEMENT DROP_TABLE"}},
+		{name: "utility command", sql: "VACUUM users", reasons: []string{"0 STATEMENT VACUUM"}},
+		{name: "SELECT INTO", sql: "SELECT * INTO copy FROM users", reasons: []string{"0 STATEMENT SELECT INTO"}},
+		{name: "later statement of a script", sql: "SELECT 1; TRUNCATE users", reasons: []string{"1 STATEMENT TRUNCATE"}},
+		{
+			name:    "data-modifying CTE",
+			sql:     "WITH gone AS (DELETE FROM users WHERE id = 1 RETURNING *) SELECT * FROM gone",
+			reasons: []string{"0 MODIFYING_CTE gone: DELETE"},
+		},
+		{
+			name:    "locking clauses",
+			sql:     "SELECT * FROM jobs WHERE id = (SELECT id FROM queue FOR SHARE) FOR UPDATE SKIP LOCKED",
+			reasons: []string{"0 LOCKING FOR SHARE", "0 LOCKING FOR UPDATE"},
+		},
+		{
+			name:    "side-effecting functions",
+			sql:     "SELECT nextval('s'), pg_catalog.set_config('work_mem', '1GB', false), PG_ADVISORY_LOCK(1), now() FROM dblink_exec('x', 'DELETE FROM t')",
+			reasons: []string{"0 FUNCTION nextval", "0 FUNCTION pg_catalog.set_config", "0 FUNCTION PG_ADVISORY_LOCK", "0 FUNCTION dblink_exec"},
+		},
+		{name: "unparsable", sql: "SELEC 1", reasons: []string{"0 PARSE_ERROR"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reasons := IsReadOnly(tt.sql)
+			assert.Equal(t, len(tt.reasons) == 0, ok, "read-only")
+			got := reasonSummary(reasons)
+			if len(reasons) == 1 && reasons[0].Kind == ReasonParseError {
+				got = []string{"0 PARSE_ERROR"}
+			}
+			assert.Equal(t, tt.reasons, got, "reasons")
+		})
+	}
+}
+
+func TestIsReadOnly_Reasons(t *testing.T) {
+	tests := []struct {
+		name    string
+		sql     string
+		reasons []Reason
+	}{
+		{
+			name:    "later statement of a script",
+			sql:     "SELECT 1; TRUNCATE users",
+			reasons: []Reason{{Kind: ReasonStatement, Statement: 1, Detail: "TRUNCATE"}},
+		},
+		{
+			name:    "data-modifying CTE",
+			sql:     "WITH gone AS (DELETE FROM users WHERE id = 1 RETURNING *) SELECT * FROM gone",
+			reasons: []Reason{{Kind: ReasonModifyingCTE, Detail: "gone: DELETE", Position: 14}},
+		},
+		{
+			name: "locking clauses",
+			sql:  "SELECT * FROM jobs WHERE id = (SELECT id FROM queue FOR SHARE) FOR UPDATE SKIP LOCKED",
+			reasons: []Reason{
+				{Kind: ReasonLocking, Detail: "FOR SHARE", Position: 52},
+				{Kind: ReasonLocking, Detail: "FOR UPDATE", Position: 63},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, reasons := IsReadOnly(tt.sql)
+			assert.False(t, ok, "read-only")
+			assert.Equal(t, tt.reasons, reasons, "reasons")
+		})
+	}
+}
+
+func TestReadOnlyPolicy_DenyFunctions(t *testing.T) {
+	policy := ReadOnlyPolicy{DenyFunctions: []string{"audit.log_access", "refresh_cache"}}
+
+	ok, reasons := policy.IsReadOnly("SELECT audit.log_access(id), refresh_cache(), public.log_access(id), nextval('s') FROM users")
+	assert.False(t, ok, "denied calls")
+	assert.Equal(t, []string{"0 FUNCTION audit.log_access", "0 FUNCTION refresh_cache"}, reasonSummary(reasons), "only listed functions, and a schema must match")
+	assert.Equal(t, 7, reasons[0].Position, "position of the call")
+
+	ok, reasons = policy.IsReadOnly("SELECT nextval('s')")
+	assert.True(t, ok, "nextval is allowed by this policy")
+	assert.Empty(t, reasons, "no reasons")
+}
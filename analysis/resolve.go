@@ -0,0 +1,580 @@
+// resolve.go resolves column references to the base tables that own them, using the
+// query scopes recorded by the parser and schema metadata.
+package analysis
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// ColumnResolutionStatus describes the outcome of resolving a column reference.
+type ColumnResolutionStatus string
+
+const (
+	// ColumnResolved means the column is read from a base table named by Table and Column.
+	ColumnResolved ColumnResolutionStatus = "resolved"
+	// ColumnDerived means the column is computed by an expression of a CTE or subquery,
+	// combined by a set operation, or produced by a set-returning function.
+	ColumnDerived ColumnResolutionStatus = "derived"
+	// ColumnAmbiguous means more than one relation in scope has the column.
+	ColumnAmbiguous ColumnResolutionStatus = "ambiguous"
+	// ColumnUnknown means no relation in scope is known to have the column. Candidates
+	// lists the relations whose columns are missing from the schema map.
+	ColumnUnknown ColumnResolutionStatus = "unknown"
+)
+
+// ResolvedColumn is a ColumnUsage resolved against a schema map.
+type ResolvedColumn struct {
+	Usage      postgresparser.ColumnUsage
+	Status     ColumnResolutionStatus
+	Schema     string   // Schema of the owning table as written in the query, or ""
+	Table      string   // Owning base table, set when Status is resolved
+	Column     string   // Column in the owning table; differs from Usage.Column when a CTE or subquery renames it
+	Relation   string   // Alias or name of the relation supplying the column in the reference's own scope
+	Candidates []string // Relations that may supply the column, for ambiguous and unknown references
+}
+
+// ResolveColumns parses a query and resolves every entry of its ColumnUsage.
+// See ResolveColumnUsage.
+func ResolveColumns(query string, schemaMap map[string][]ColumnSchema) ([]ResolvedColumn, error) {
+	pq, err := postgresparser.ParseSQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return ResolveColumnUsage(pq, schemaMap), nil
+}
+
+// ResolveColumnUsage resolves each entry of pq.ColumnUsage to the base table that owns
+// it. The result is index-aligned with pq.ColumnUsage.
+//
+// References are resolved the way PostgreSQL does: against the relations of their own
+// query level first, then outward. LATERAL subqueries see the FROM items before them,
+// CTE outputs and subquery aliases are followed to the columns they select, and USING
+// and NATURAL joins merge their join columns. An unqualified reference in ORDER BY may
+// name an output column alias. Column names compare as in PostgreSQL: unquoted
+// references fold to lower case and quoted ones match exactly.
+//
+// The schemaMap is keyed by lower-case table name or "schema.table", as produced by
+// catalog.ColumnSchemas. Tables missing from it still resolve qualified references;
+// unqualified references that one of them might supply are reported as unknown.
+func ResolveColumnUsage(pq *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) []ResolvedColumn {
+	if pq == nil || len(pq.ColumnUsage) == 0 {
+		return nil
+	}
+	r := newColumnResolver(pq, schemaMap)
+	out := make([]ResolvedColumn, len(pq.ColumnUsage))
+	for i, u := range pq.ColumnUsage {
+		out[i] = r.resolveUsage(u)
+		out[i].Usage = u
+	}
+	return out
+}
+
+// ResolveReference resolves a column reference such as "o.total" or "email" written at
+// character offset pos of pq.RawSQL, the way ResolveColumnUsage resolves a ColumnUsage
+// at that offset. It serves callers that find references in the SQL text themselves
+// rather than through pq.ColumnUsage.
+func ResolveReference(pq *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema, expr string, pos int) ResolvedColumn {
+	qual, col, ok := parseColumnRef(expr)
+	if pq == nil || !ok {
+		return ResolvedColumn{Status: ColumnUnknown}
+	}
+	u := postgresparser.ColumnUsage{TableAlias: qual, Column: col, Expression: expr, Position: pos}
+	res := newColumnResolver(pq, schemaMap).resolveUsage(u)
+	res.Usage = u
+	return res
+}
+
+// presence tells whether a relation has a column.
+type presence int
+
+const (
+	columnAbsent presence = iota
+	columnUnsure          // The relation's columns are not known
+	columnPresent
+)
+
+// maxResolveDepth bounds how many CTEs and subqueries a reference is followed through.
+const maxResolveDepth = 32
+
+// columnResolver resolves references against the scopes of one statement.
+type columnResolver struct {
+	scopes    []postgresparser.QueryScope
+	schemaMap map[string][]ColumnSchema
+	insert    bool // The statement is an INSERT, whose ON CONFLICT clause may use EXCLUDED
+}
+
+// newColumnResolver returns a resolver for the scopes of pq.
+func newColumnResolver(pq *postgresparser.ParsedQuery, schemaMap map[string][]ColumnSchema) *columnResolver {
+	return &columnResolver{scopes: pq.Scopes, schemaMap: schemaMap, insert: pq.Command == postgresparser.QueryCommandInsert}
+}
+
+// resolveUsage resolves one reference.
+func (r *columnResolver) resolveUsage(u postgresparser.ColumnUsage) ResolvedColumn {
+	if len(r.scopes) == 0 || u.Column == "" || u.Column == "*" {
+		return ResolvedColumn{Status: ColumnUnknown}
+	}
+	scope := sqllex.ScopeAt(r.scopes, u.Position)
+	// The parser strips the quotes of Column; the reference as written tells whether
+	// its case folds.
+	if _, name, ok := parseColumnRef(u.Expression); ok {
+		u.Column = name
+	}
+	// The parser guesses the tables of USING columns; the scope knows the join sides.
+	if u.UsageType == postgresparser.ColumnUsageTypeJoin && u.Side != "" && hasPrefixFold(u.Context, "USING") {
+		if res, ok := r.usingColumn(scope, u); ok {
+			return res
+		}
+	}
+	if u.TableAlias != "" {
+		res, _ := r.qualified(scope, u.TableAlias, u.Column, 0)
+		return res
+	}
+
+	switch u.UsageType {
+	case postgresparser.ColumnUsageTypeDMLSet, postgresparser.ColumnUsageTypeUpsertSet, postgresparser.ColumnUsageTypeMergeSet:
+		// SET targets belong to the target table, which is relation 0 of the statement.
+		if scope == 0 && len(r.scopes[0].Relations) > 0 {
+			if res, p := r.relationColumn(0, 0, u.Column, 0); p != columnAbsent {
+				return res
+			}
+		}
+	case postgresparser.ColumnUsageTypeOrderBy:
+		if res, ok := r.outputAlias(scope, u.Column); ok {
+			return res
+		}
+	}
+	res, p := r.unqualified(scope, u.Column, 0)
+	if p == columnAbsent && u.UsageType == postgresparser.ColumnUsageTypeGroupBy {
+		if alias, ok := r.outputAlias(scope, u.Column); ok {
+			return alias
+		}
+	}
+	return res
+}
+
+// scopeLevel is a scope and the indexes of its relations visible from an inner scope.
+type scopeLevel struct {
+	scope int
+	rels  []int
+}
+
+// levels returns the relations visible from scope s, innermost level first. A
+// subquery in an expression sees all relations of its parent, a LATERAL subquery the
+// relations before it; CTEs, derived tables, set-operation branches, and INSERT sources
+// see none of their parent's relations but do see the levels above it.
+func (r *columnResolver) levels(s int) []scopeLevel {
+	var out []scopeLevel
+	limit := -1
+	for cur := s; cur >= 0; cur = r.scopes[cur].Parent {
+		n := len(r.scopes[cur].Relations)
+		if limit >= 0 && limit < n {
+			n = limit
+		}
+		rels := make([]int, n)
+		for i := range rels {
+			rels[i] = i
+		}
+		out = append(out, scopeLevel{scope: cur, rels: rels})
+
+		sc := r.scopes[cur]
+		switch {
+		case sc.Parent < 0:
+		case sc.Kind == postgresparser.ScopeSubquery:
+			limit = -1
+		case sc.Kind == postgresparser.ScopeLateral:
+			limit = slices.IndexFunc(r.scopes[sc.Parent].Relations, func(rel postgresparser.ScopeRelation) bool {
+				return rel.Scope == cur
+			})
+		default:
+			limit = 0
+		}
+	}
+	return out
+}
+
+// qualified resolves qual.col from scope s.
+func (r *columnResolver) qualified(s int, qual, col string, depth int) (ResolvedColumn, presence) {
+	qual = trimQuotes(qual)
+	for _, lv := range r.levels(s) {
+		for _, ri := range lv.rels {
+			if relationMatches(r.scopes[lv.scope].Relations[ri].Table, qual) {
+				return r.relationColumn(lv.scope, ri, col, depth)
+			}
+		}
+		// EXCLUDED in ON CONFLICT ... DO UPDATE is the row proposed for the target.
+		if lv.scope == 0 && r.insert && strings.EqualFold(qual, "excluded") && len(r.scopes[0].Relations) > 0 {
+			return r.relationColumn(0, 0, col, depth)
+		}
+	}
+	return ResolvedColumn{Status: ColumnUnknown, Relation: qual}, columnAbsent
+}
+
+// unqualified resolves col from scope s, searching the levels outward.
+func (r *columnResolver) unqualified(s int, col string, depth int) (ResolvedColumn, presence) {
+	var unsure []string
+	for _, lv := range r.levels(s) {
+		res, p := r.unqualifiedIn(lv.scope, lv.rels, col, depth, false)
+		switch p {
+		case columnPresent:
+			if len(unsure) > 0 {
+				// A closer relation with unknown columns may shadow this one.
+				if res.Status != ColumnAmbiguous {
+					res.Candidates = []string{res.Relation}
+				}
+				return ResolvedColumn{Status: ColumnUnknown, Candidates: append(unsure, res.Candidates...)}, columnUnsure
+			}
+			return res, p
+		case columnUnsure:
+			unsure = append(unsure, res.Candidates...)
+		}
+	}
+	if len(unsure) > 0 {
+		return ResolvedColumn{Status: ColumnUnknown, Candidates: unsure}, columnUnsure
+	}
+	return ResolvedColumn{Status: ColumnUnknown}, columnAbsent
+}
+
+// unqualifiedIn resolves col among the relations rels of scope. With assume set, a
+// single relation of unknown columns is taken to have col, as the side of a USING
+// join must.
+func (r *columnResolver) unqualifiedIn(scope int, rels []int, col string, depth int, assume bool) (ResolvedColumn, presence) {
+	results := make(map[int]ResolvedColumn)
+	var found, unsure []int
+	for _, ri := range rels {
+		res, p := r.relationColumn(scope, ri, col, depth)
+		results[ri] = res
+		switch p {
+		case columnPresent:
+			found = append(found, ri)
+		case columnUnsure:
+			unsure = append(unsure, ri)
+		}
+	}
+	found = r.mergeJoinColumns(scope, found, col)
+	if assume && len(found) == 0 && len(unsure) == 1 {
+		found, unsure = unsure, nil
+	}
+	switch {
+	case len(found) == 1:
+		return results[found[0]], columnPresent
+	case len(found) > 1:
+		return ResolvedColumn{Status: ColumnAmbiguous, Candidates: r.relationNames(scope, found)}, columnPresent
+	case len(unsure) > 0:
+		return ResolvedColumn{Status: ColumnUnknown, Candidates: r.relationNames(scope, unsure)}, columnUnsure
+	}
+	return ResolvedColumn{Status: ColumnUnknown}, columnAbsent
+}
+
+// mergeJoinColumns drops relations whose copy of col is merged into another by a
+// USING or NATURAL join, keeping the left side except for RIGHT joins.
+func (r *columnResolver) mergeJoinColumns(scope int, found []int, col string) []int {
+	for _, j := range r.scopes[scope].Joins {
+		if !j.Natural && !slices.ContainsFunc(j.Using, func(u string) bool { return strings.EqualFold(u, col) }) {
+			continue
+		}
+		left := slices.DeleteFunc(slices.Clone(found), func(ri int) bool { return !slices.Contains(j.Left, ri) })
+		right := slices.DeleteFunc(slices.Clone(found), func(ri int) bool { return !slices.Contains(j.Right, ri) })
+		if len(left) == 0 || len(right) == 0 {
+			continue
+		}
+		drop := right
+		if j.Type == "RIGHT" {
+			drop = left
+		}
+		found = slices.DeleteFunc(found, func(ri int) bool { return slices.Contains(drop, ri) })
+	}
+	return found
+}
+
+// usingColumn resolves a column of a USING clause to the relation on its side of the join.
+func (r *columnResolver) usingColumn(scope int, u postgresparser.ColumnUsage) (ResolvedColumn, bool) {
+	for _, j := range r.scopes[scope].Joins {
+		if j.Position != u.Position {
+			continue
+		}
+		rels := j.Left
+		if u.Side == "right" {
+			rels = j.Right
+		}
+		res, p := r.unqualifiedIn(scope, rels, u.Column, 0, true)
+		return res, p != columnAbsent
+	}
+	return ResolvedColumn{}, false
+}
+
+// outputAlias resolves col as an output column alias of scope, as ORDER BY allows.
+func (r *columnResolver) outputAlias(scope int, col string) (ResolvedColumn, bool) {
+	for _, c := range r.scopes[scope].Columns {
+		if c.Alias != "" && sqlident.Name(c.Alias) == col {
+			return r.outputValue(scope, scopeOutput{name: c.Alias, expr: c.Expression, rel: -1}, 0), true
+		}
+	}
+	return ResolvedColumn{}, false
+}
+
+// relationColumn resolves column col of relation ri of scope.
+func (r *columnResolver) relationColumn(scope, ri int, col string, depth int) (ResolvedColumn, presence) {
+	rel := r.scopes[scope].Relations[ri]
+	name := relationName(rel.Table)
+	if depth > maxResolveDepth {
+		return ResolvedColumn{Status: ColumnUnknown, Relation: name}, columnUnsure
+	}
+	var res ResolvedColumn
+	var p presence
+	switch {
+	case rel.Scope >= 0:
+		res, p = r.outputColumn(rel.Scope, rel.ColumnAliases, col, depth+1)
+	case rel.Table.Type == postgresparser.TableTypeFunction:
+		res, p = functionColumn(rel, col)
+	default:
+		res, p = r.tableColumn(rel, col)
+	}
+	res.Relation = name
+	return res, p
+}
+
+// tableColumn resolves col of a base table relation from the schema map.
+func (r *columnResolver) tableColumn(rel postgresparser.ScopeRelation, col string) (ResolvedColumn, presence) {
+	cols, known := r.tableColumns(rel.Table)
+	res := ResolvedColumn{Status: ColumnResolved, Schema: trimQuotes(rel.Table.Schema), Table: trimQuotes(rel.Table.Name), Column: col}
+	// AS t (a, b) renames the first columns of the table.
+	if i := indexFold(rel.ColumnAliases, col); i >= 0 {
+		if known && i < len(cols) {
+			res.Column = cols[i].Name
+			return res, columnPresent
+		}
+		return ResolvedColumn{Status: ColumnUnknown, Schema: res.Schema, Table: res.Table}, columnPresent
+	}
+	if !known {
+		return res, columnUnsure
+	}
+	for _, c := range cols[min(len(rel.ColumnAliases), len(cols)):] {
+		if c.Name == col {
+			res.Column = c.Name
+			return res, columnPresent
+		}
+	}
+	return ResolvedColumn{Status: ColumnUnknown}, columnAbsent
+}
+
+// tableColumns looks a table up in the schema map, by "schema.table" first.
+func (r *columnResolver) tableColumns(t postgresparser.TableRef) ([]ColumnSchema, bool) {
+	name := strings.ToLower(trimQuotes(t.Name))
+	if t.Schema != "" {
+		if cols, ok := r.schemaMap[strings.ToLower(trimQuotes(t.Schema))+"."+name]; ok {
+			return cols, true
+		}
+	}
+	cols, ok := r.schemaMap[name]
+	return cols, ok
+}
+
+// functionColumn resolves col of a function in FROM. Its columns are known only when
+// listed in the alias; without a list, the alias names its single column if scalar.
+func functionColumn(rel postgresparser.ScopeRelation, col string) (ResolvedColumn, presence) {
+	if len(rel.ColumnAliases) > 0 {
+		if indexFold(rel.ColumnAliases, col) >= 0 {
+			return ResolvedColumn{Status: ColumnDerived}, columnPresent
+		}
+		return ResolvedColumn{Status: ColumnUnknown}, columnAbsent
+	}
+	if rel.Table.Alias != "" && strings.EqualFold(trimQuotes(rel.Table.Alias), col) {
+		return ResolvedColumn{Status: ColumnDerived}, columnPresent
+	}
+	return ResolvedColumn{Status: ColumnDerived}, columnUnsure
+}
+
+// scopeOutput is an output column of a scope: a select-list expression, a column of
+// a relation expanded from a star, or a star that could not be expanded.
+type scopeOutput struct {
+	name   string
+	expr   string
+	rel    int    // Relation supplying an expanded star column, or -1
+	column string // Column of rel
+	star   bool   // Unexpanded star
+	qual   string // Qualifier of an unexpanded star, "" for a bare *
+}
+
+// outputColumn resolves output column col of scope, renamed by aliases if given.
+func (r *columnResolver) outputColumn(scope int, aliases []string, col string, depth int) (ResolvedColumn, presence) {
+	if len(aliases) == 0 {
+		aliases = r.scopes[scope].ColumnAliases
+	}
+	outs := r.scopeOutputs(scope, depth)
+	// Column aliases rename the leading outputs, which must not hide an unexpanded star.
+	positional := slices.IndexFunc(outs, func(o scopeOutput) bool { return o.star })
+	if positional < 0 {
+		positional = len(outs)
+	}
+	if i := indexFold(aliases, col); i >= 0 {
+		if i < positional {
+			return r.outputValue(scope, outs[i], depth), columnPresent
+		}
+		return ResolvedColumn{Status: ColumnUnknown}, columnPresent
+	}
+	if len(aliases) > positional {
+		return ResolvedColumn{Status: ColumnUnknown}, columnUnsure
+	}
+
+	var matches []scopeOutput
+	for _, o := range outs[len(aliases):] {
+		if !o.star && o.name == col {
+			matches = append(matches, o)
+		}
+	}
+	switch len(matches) {
+	case 0:
+	case 1:
+		return r.outputValue(scope, matches[0], depth), columnPresent
+	default:
+		return ResolvedColumn{Status: ColumnAmbiguous}, columnPresent
+	}
+	// An unexpanded star may still supply the column.
+	for _, o := range outs[len(aliases):] {
+		if !o.star {
+			continue
+		}
+		if res, p := r.starColumn(scope, o.qual, col, depth); p != columnAbsent {
+			if r.hasSetOperations(scope) {
+				res = ResolvedColumn{Status: ColumnDerived}
+			}
+			return res, p
+		}
+	}
+	return ResolvedColumn{Status: ColumnUnknown}, columnAbsent
+}
+
+// starColumn resolves col through an unexpanded qual.* or * of scope.
+func (r *columnResolver) starColumn(scope int, qual, col string, depth int) (ResolvedColumn, presence) {
+	rels := r.scopes[scope].Relations
+	var idx []int
+	for ri, rel := range rels {
+		if qual == "" || relationMatches(rel.Table, qual) {
+			idx = append(idx, ri)
+		}
+	}
+	return r.unqualifiedIn(scope, idx, col, depth, false)
+}
+
+// outputValue resolves the value of an output column of scope.
+func (r *columnResolver) outputValue(scope int, o scopeOutput, depth int) ResolvedColumn {
+	if r.hasSetOperations(scope) {
+		return ResolvedColumn{Status: ColumnDerived}
+	}
+	if o.rel >= 0 {
+		res, _ := r.relationColumn(scope, o.rel, o.column, depth)
+		return res
+	}
+	qual, name, ok := parseColumnRef(o.expr)
+	if !ok {
+		return ResolvedColumn{Status: ColumnDerived}
+	}
+	var res ResolvedColumn
+	if qual != "" {
+		res, _ = r.qualified(scope, qual, name, depth)
+	} else {
+		res, _ = r.unqualified(scope, name, depth)
+	}
+	return res
+}
+
+// hasSetOperations reports whether scope combines several branches with UNION,
+// INTERSECT, or EXCEPT.
+func (r *columnResolver) hasSetOperations(scope int) bool {
+	return slices.ContainsFunc(r.scopes, func(s postgresparser.QueryScope) bool {
+		return s.Parent == scope && s.Kind == postgresparser.ScopeSetOperation
+	})
+}
+
+// scopeOutputs returns the output columns of scope, expanding stars whose relations
+// have known columns.
+func (r *columnResolver) scopeOutputs(scope, depth int) []scopeOutput {
+	var out []scopeOutput
+	for _, c := range r.scopes[scope].Columns {
+		qual, isStar := starQualifier(c.Expression)
+		if !isStar {
+			out = append(out, scopeOutput{name: sqlident.OutputName(c), expr: c.Expression, rel: -1})
+			continue
+		}
+		expanded, ok := r.expandStar(scope, qual, depth)
+		if !ok {
+			out = append(out, scopeOutput{rel: -1, star: true, qual: qual})
+			continue
+		}
+		out = append(out, expanded...)
+	}
+	return out
+}
+
+// relationNames returns the aliases or names of relations rels of scope.
+func (r *columnResolver) relationNames(scope int, rels []int) []string {
+	out := make([]string, len(rels))
+	for i, ri := range rels {
+		out[i] = relationName(r.scopes[scope].Relations[ri].Table)
+	}
+	return out
+}
+
+// relationName returns the alias of a relation, or its name without one.
+func relationName(t postgresparser.TableRef) string {
+	if t.Alias != "" {
+		return trimQuotes(t.Alias)
+	}
+	return trimQuotes(t.Name)
+}
+
+// relationMatches reports whether qualifier qual refers to relation t: its alias if
+// it has one, else its name.
+func relationMatches(t postgresparser.TableRef, qual string) bool {
+	return strings.EqualFold(relationName(t), trimQuotes(qual))
+}
+
+var (
+	columnRefRe     = regexp.MustCompile(`^` + sqlident.Pattern + `(?:\s*\.\s*` + sqlident.Pattern + `){0,2}$`)
+	identPartRe     = regexp.MustCompile(sqlident.Pattern)
+	starQualifierRe = regexp.MustCompile(`^(?:` + sqlident.Pattern + `\s*\.\s*)*?(` + sqlident.Pattern + `)\s*\.\s*\*$`)
+)
+
+// parseColumnRef splits a plain column reference (col, t.col, or s.t.col) into its
+// qualifier and column name.
+func parseColumnRef(expr string) (qual, name string, ok bool) {
+	expr = strings.TrimSpace(expr)
+	if !columnRefRe.MatchString(expr) {
+		return "", "", false
+	}
+	parts := identPartRe.FindAllString(expr, -1)
+	name = sqlident.Name(parts[len(parts)-1])
+	if len(parts) > 1 {
+		qual = trimQuotes(parts[len(parts)-2])
+	}
+	return qual, name, true
+}
+
+// starQualifier reports whether expr is * or qual.*, returning the qualifier.
+func starQualifier(expr string) (string, bool) {
+	expr = strings.TrimSpace(expr)
+	if expr == "*" {
+		return "", true
+	}
+	if m := starQualifierRe.FindStringSubmatch(expr); m != nil {
+		return trimQuotes(m[1]), true
+	}
+	return "", false
+}
+
+// indexFold returns the index of the first name equal to s ignoring case, or -1.
+func indexFold(names []string, s string) int {
+	return slices.IndexFunc(names, func(n string) bool { return strings.EqualFold(n, s) })
+}
+
+// hasPrefixFold reports whether s starts with prefix, ignoring case.
+func hasPrefixFold(s, prefix string) bool {
+	return len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix)
+}
@@ -0,0 +1,270 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+var resolveSchema = map[string][]ColumnSchema{
+	"users":            {{Name: "id", IsPrimaryKey: true}, {Name: "email"}, {Name: "name"}, {Name: "org_id"}},
+	"orders":           {{Name: "id", IsPrimaryKey: true}, {Name: "user_id"}, {Name: "total"}, {Name: "created_at"}},
+	"orgs":             {{Name: "id", IsPrimaryKey: true}, {Name: "name"}},
+	"payments":         {{Name: "id", IsPrimaryKey: true}, {Name: "user_id"}, {Name: "amount"}},
+	"billing.invoices": {{Name: "id", IsPrimaryKey: true}, {Name: "amount"}},
+}
+
+// resolveSummary resolves query against resolveSchema and summarizes each reference as
+// "usage type:expression" -> "status table.column" or "status [candidates]". USING
+// columns get a "/left" or "/right" suffix.
+func resolveSummary(t *testing.T, query string) map[string]string {
+	t.Helper()
+	resolved, err := ResolveColumns(query, resolveSchema)
+	require.NoError(t, err, "resolve failed")
+	out := make(map[string]string, len(resolved))
+	for _, rc := range resolved {
+		var summary string
+		switch rc.Status {
+		case ColumnResolved:
+			summary = "resolved " + rc.Table + "." + rc.Column
+			if rc.Schema != "" {
+				summary = "resolved " + rc.Schema + "." + rc.Table + "." + rc.Column
+			}
+		case ColumnDerived:
+			summary = "derived"
+		default:
+			summary = string(rc.Status) + " [" + strings.Join(rc.Candidates, " ") + "]"
+		}
+		key := string(rc.Usage.UsageType) + ":" + rc.Usage.Expression
+		if rc.Usage.Side != "" && strings.HasPrefix(rc.Usage.Context, "USING") {
+			key += "/" + rc.Usage.Side
+		}
+		out[key] = summary
+	}
+	return out
+}
+
+func TestResolveColumns(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  map[string]string
+	}{
+		{
+			name:  "unqualified columns across a join",
+			query: "SELECT email, total FROM users u JOIN orders o ON o.user_id = u.id WHERE name = 'x'",
+			want: map[string]string{
+				"projection:email": "resolved users.email",
+				"projection:total": "resolved orders.total",
+				"join:o.user_id":   "resolved orders.user_id",
+				"join:u.id":        "resolved users.id",
+				"filter:name":      "resolved users.name",
+			},
+		},
+		{
+			name:  "ambiguous column",
+			query: "SELECT id FROM users JOIN orders ON orders.user_id = users.id",
+			want: map[string]string{
+				"projection:id":       "ambiguous [users orders]",
+				"join:orders.user_id": "resolved orders.user_id",
+				"join:users.id":       "resolved users.id",
+			},
+		},
+		{
+			name:  "CTE output columns",
+			query: "WITH big AS (SELECT user_id AS uid, sum(total) AS spent FROM orders GROUP BY user_id) SELECT email, uid, spent FROM users JOIN big ON big.uid = users.id",
+			want: map[string]string{
+				"projection:email": "resolved users.email",
+				"projection:uid":   "resolved orders.user_id",
+				"projection:spent": "derived",
+				"join:big.uid":     "resolved orders.user_id",
+				"join:users.id":    "resolved users.id",
+			},
+		},
+		{
+			name:  "CTE column list",
+			query: "WITH c (who) AS (SELECT email FROM users) SELECT who FROM c",
+			want:  map[string]string{"projection:who": "resolved users.email"},
+		},
+		{
+			name:  "subquery alias with column aliases",
+			query: "SELECT s.x, name FROM (SELECT email, name FROM users) AS s (x)",
+			want: map[string]string{
+				"projection:s.x":   "resolved users.email",
+				"projection:name":  "resolved users.name",
+				"projection:email": "resolved users.email",
+			},
+		},
+		{
+			name:  "subquery star",
+			query: "SELECT s.org_id FROM (SELECT * FROM users) s",
+			want:  map[string]string{"projection:s.org_id": "resolved users.org_id"},
+		},
+		{
+			name:  "USING join column appears once",
+			query: "SELECT user_id, amount FROM orders JOIN payments USING (user_id)",
+			want: map[string]string{
+				"projection:user_id": "resolved orders.user_id",
+				"projection:amount":  "resolved payments.amount",
+				"join:user_id/left":  "resolved orders.user_id",
+				"join:user_id/right": "resolved payments.user_id",
+			},
+		},
+		{
+			name:  "NATURAL join merges common columns",
+			query: "SELECT id, name, email FROM orgs NATURAL JOIN users",
+			want: map[string]string{
+				"projection:id":    "resolved orgs.id",
+				"projection:name":  "resolved orgs.name",
+				"projection:email": "resolved users.email",
+			},
+		},
+		{
+			name:  "LATERAL subquery sees earlier FROM items",
+			query: "SELECT o.total FROM users u, LATERAL (SELECT total FROM orders WHERE orders.user_id = u.id AND email <> '') o",
+			want: map[string]string{
+				"projection:o.total":    "resolved orders.total",
+				"projection:total":      "resolved orders.total",
+				"filter:orders.user_id": "resolved orders.user_id",
+				"filter:u.id":           "resolved users.id",
+				"filter:email":          "resolved users.email",
+			},
+		},
+		{
+			name:  "derived table does not see sibling FROM items",
+			query: "SELECT o.total FROM users u, (SELECT total FROM orders WHERE email <> '') o",
+			want: map[string]string{
+				"projection:o.total": "resolved orders.total",
+				"projection:total":   "resolved orders.total",
+				"filter:email":       "unknown []",
+			},
+		},
+		{
+			name:  "correlated subquery prefers its own relations",
+			query: "SELECT name FROM orgs WHERE EXISTS (SELECT 1 FROM users WHERE users.org_id = orgs.id AND name = 'a')",
+			want: map[string]string{
+				"projection:name":     "resolved orgs.name",
+				"filter:users.org_id": "resolved users.org_id",
+				"filter:orgs.id":      "resolved orgs.id",
+				"filter:name":         "resolved users.name",
+			},
+		},
+		{
+			name:  "table missing from the schema",
+			query: "SELECT foo, email, a.user_id FROM users JOIN audit_log a ON a.user_id = users.id",
+			want: map[string]string{
+				"projection:foo":       "unknown [a]",
+				"projection:email":     "resolved users.email",
+				"projection:a.user_id": "resolved audit_log.user_id",
+				"join:a.user_id":       "resolved audit_log.user_id",
+				"join:users.id":        "resolved users.id",
+			},
+		},
+		{
+			name:  "ORDER BY output alias",
+			query: "SELECT email AS e, count(*) AS n FROM users GROUP BY email ORDER BY e, n DESC",
+			want: map[string]string{
+				"projection:email": "resolved users.email",
+				"group:email":      "resolved users.email",
+				"order:e":          "resolved users.email",
+				"order:n":          "derived",
+			},
+		},
+		{
+			name:  "schema-qualified table",
+			query: "SELECT amount FROM billing.invoices",
+			want:  map[string]string{"projection:amount": "resolved billing.invoices.amount"},
+		},
+		{
+			name:  "set operation output is derived",
+			query: "WITH ids AS (SELECT id FROM users UNION SELECT id FROM orgs) SELECT id FROM ids",
+			want:  map[string]string{"projection:id": "derived"},
+		},
+		{
+			name:  "ON CONFLICT with EXCLUDED",
+			query: "INSERT INTO users (id, email) VALUES (1, 'a') ON CONFLICT (id) DO UPDATE SET email = excluded.email WHERE users.name <> ''",
+			want: map[string]string{
+				"dml_set:email":             "resolved users.email",
+				"upsert_set:excluded.email": "resolved users.email",
+				"filter:users.name":         "resolved users.name",
+			},
+		},
+		{
+			name:  "UPDATE FROM",
+			query: "UPDATE orders SET total = amount FROM payments WHERE payments.id = orders.id",
+			want: map[string]string{
+				"dml_set:total":      "resolved orders.total",
+				"dml_set:amount":     "resolved payments.amount",
+				"filter:payments.id": "resolved payments.id",
+				"filter:orders.id":   "resolved orders.id",
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			assert.Equal(t, tc.want, resolveSummary(t, tc.query))
+		})
+	}
+}
+
+func TestResolveColumns_Structure(t *testing.T) {
+	resolved, err := ResolveColumns(`SELECT o.total, name, nope, "Email" FROM orders o JOIN users u ON u.id = o.user_id, orgs`, resolveSchema)
+	require.NoError(t, err, "resolve failed")
+	for i := range resolved {
+		resolved[i].Usage = postgresparser.ColumnUsage{}
+	}
+	assert.Equal(t, []ResolvedColumn{
+		{Status: ColumnResolved, Table: "orders", Column: "total", Relation: "o"},
+		{Status: ColumnAmbiguous, Candidates: []string{"u", "orgs"}},
+		{Status: ColumnUnknown},
+		{Status: ColumnUnknown},
+		{Status: ColumnResolved, Table: "users", Column: "id", Relation: "u"},
+		{Status: ColumnResolved, Table: "orders", Column: "user_id", Relation: "o"},
+	}, resolved)
+}
+
+func TestResolveColumnUsage_AlignedWithColumnUsage(t *testing.T) {
+	result, err := ExtractQueryAnalysis("SELECT u.id, email FROM users u WHERE email = $1")
+	require.NoError(t, err, "parse failed")
+	resolved := ResolveColumnUsage(result.ParsedQuery, resolveSchema)
+	require.Len(t, resolved, len(result.ParsedQuery.ColumnUsage), "one resolution per usage")
+	for i, rc := range resolved {
+		assert.Equal(t, result.ParsedQuery.ColumnUsage[i], rc.Usage, "usage %d", i)
+		assert.Equal(t, ColumnResolved, rc.Status, "usage %d", i)
+		assert.Equal(t, "u", rc.Relation, "usage %d", i)
+	}
+	assert.Nil(t, ResolveColumnUsage(nil, resolveSchema), "nil query")
+}
+
+func TestExtractQueryAnalysisWithSchema_ResolvesWhereTables(t *testing.T) {
+	result, err := ExtractQueryAnalysisWithSchema(
+		"SELECT u.id FROM users u JOIN orders o ON o.user_id = u.id WHERE total > 10 AND email = $1", resolveSchema)
+	require.NoError(t, err, "analysis failed")
+	tables := map[string]string{}
+	for _, cond := range result.WhereConditions {
+		tables[cond.Column] = cond.Table
+	}
+	assert.Equal(t, map[string]string{"total": "orders", "email": "users"}, tables, "WHERE condition tables")
+
+	result, err = ExtractQueryAnalysis("SELECT u.id FROM users u JOIN orders o ON o.user_id = u.id WHERE total > 10")
+	require.NoError(t, err, "analysis failed")
+	require.Len(t, result.WhereConditions, 1, "WHERE conditions")
+	assert.Empty(t, result.WhereConditions[0].Table, "no table without a schema")
+}
+
+func TestResolveReference(t *testing.T) {
+	pq, err := postgresparser.ParseSQL("SELECT lower(email) FROM users u JOIN orders o ON o.user_id = u.id")
+	require.NoError(t, err, "parse failed")
+	pos := strings.Index(pq.RawSQL, "email")
+	res := ResolveReference(pq, resolveSchema, "email", pos)
+	assert.Equal(t, ColumnResolved, res.Status, "status")
+	assert.Equal(t, "users", res.Table, "table")
+	assert.Equal(t, "u", res.Relation, "relation")
+	assert.Equal(t, "email", res.Usage.Expression, "usage")
+
+	assert.Equal(t, ColumnUnknown, ResolveReference(pq, resolveSchema, "lower(email)", pos).Status, "not a reference")
+}
@@ -0,0 +1,383 @@
+// searchpath.go fills in the schemas PostgreSQL's search_path resolves unqualified names to.
+package analysis
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+)
+
+// DefaultSearchPath is PostgreSQL's default search_path setting.
+var DefaultSearchPath = []string{"$user", "public"}
+
+// SystemSchema is the schema of PostgreSQL's built-in objects. It is searched before the
+// schemas of a search path unless the path lists it explicitly.
+const SystemSchema = "pg_catalog"
+
+// SearchPath resolves unqualified object names the way PostgreSQL does.
+type SearchPath struct {
+	Schemas []string // Schema names in search order; "$user" stands for User
+	User    string   // Session user that "$user" expands to; "$user" is skipped when empty
+
+	// Exists reports whether schema holds a table, view, sequence, index, type, or other
+	// object called name. When nil, every unqualified name resolves to the first schema
+	// of the path.
+	Exists func(schema, name string) bool
+	// HasSchema reports whether a schema exists; objects are created in the first schema
+	// of the path that does. When nil, every schema is assumed to exist.
+	HasSchema func(name string) bool
+}
+
+// schemas returns the path with "$user" expanded, missing schemas dropped, and
+// pg_catalog first unless listed.
+func (sp SearchPath) schemas() []string {
+	var out []string
+	for _, s := range sp.Schemas {
+		if s == "$user" {
+			s = sp.User
+		}
+		if s == "" || slices.Contains(out, s) || sp.HasSchema != nil && s != SystemSchema && !sp.HasSchema(s) {
+			continue
+		}
+		out = append(out, s)
+	}
+	if !slices.Contains(out, SystemSchema) {
+		out = append([]string{SystemSchema}, out...)
+	}
+	return out
+}
+
+// CreationSchema returns the schema unqualified CREATE statements create objects in: the
+// first schema listed in the path that exists, or "" when there is none.
+func (sp SearchPath) CreationSchema() string {
+	for _, s := range sp.Schemas {
+		if s == "$user" {
+			s = sp.User
+		}
+		if s != "" && (sp.HasSchema == nil || s == SystemSchema || sp.HasSchema(s)) {
+			return s
+		}
+	}
+	return ""
+}
+
+// Resolve returns the schema an unqualified object name refers to: the first schema of
+// the path holding it, or the creation schema when no schema is known to. Names starting
+// with pg_ are taken to be system catalogs and resolve to pg_catalog when it is searched
+// first.
+func (sp SearchPath) Resolve(name string) string {
+	name = sqlident.Name(name)
+	for _, s := range sp.schemas() {
+		if s == SystemSchema {
+			if strings.HasPrefix(name, "pg_") || sp.Exists != nil && sp.Exists(s, name) {
+				return s
+			}
+			continue
+		}
+		if sp.Exists == nil {
+			return s
+		}
+		if sp.Exists(s, name) {
+			return s
+		}
+	}
+	return sp.CreationSchema()
+}
+
+// QualifyQuery fills the empty Schema of every base table reference and schema-scoped
+// DDL action of pq, including those of its CTE bodies, subqueries, and nested subquery
+// IRs, with the schema sp resolves the name to. Objects created by the statement get
+// the creation schema, and indexes, triggers, policies, and rules the schema of their
+// table. Schema names that are not plain lower-case identifiers are double-quoted, as
+// if written in the query.
+func QualifyQuery(pq *postgresparser.ParsedQuery, sp SearchPath) {
+	if pq == nil {
+		return
+	}
+	q := qualifier{sp: sp, created: make(map[string]string), ctes: make(map[string]int)}
+	q.query(pq)
+}
+
+// QualifyScript qualifies the statements of a script in order, as QualifyQuery does,
+// starting from sp and following the search_path changes the script makes. RESET
+// search_path and SET search_path TO DEFAULT return to sp.
+func QualifyScript(queries []*postgresparser.ParsedQuery, sp SearchPath) {
+	current := sp
+	for _, pq := range queries {
+		if pq == nil {
+			continue
+		}
+		if schemas, ok := SearchPathSetting(pq.RawSQL); ok {
+			current.Schemas = sp.Schemas
+			if schemas != nil {
+				current.Schemas = schemas
+			}
+			continue
+		}
+		QualifyQuery(pq, current)
+	}
+}
+
+var (
+	setSearchPathRe   = regexp.MustCompile(`(?is)^\s*SET\s+(?:(?:SESSION|LOCAL)\s+)?search_path\s*(?:TO|=)\s*(.*?)\s*;?\s*$`)
+	resetSearchPathRe = regexp.MustCompile(`(?is)^\s*RESET\s+(?:search_path|ALL)\s*;?\s*$`)
+	setConfigRe       = regexp.MustCompile(`(?is)^\s*SELECT\s+(?:pg_catalog\s*\.\s*)?set_config\s*\(\s*'search_path'\s*,\s*'((?:[^']|'')*)'\s*,`)
+)
+
+// SearchPathSetting parses a statement that changes search_path: SET [SESSION | LOCAL]
+// search_path, RESET search_path or ALL, or SELECT set_config('search_path', ...) as
+// written by pg_dump. It returns the schema names the path is set to, nil for a reset
+// to the default, and false for any other statement.
+func SearchPathSetting(sql string) ([]string, bool) {
+	if resetSearchPathRe.MatchString(sql) {
+		return nil, true
+	}
+	if m := setConfigRe.FindStringSubmatch(sql); m != nil {
+		out := []string{}
+		for _, v := range splitSettingList(strings.ReplaceAll(m[1], "''", "'")) {
+			out = append(out, sqlident.Name(v))
+		}
+		return out, true
+	}
+	m := setSearchPathRe.FindStringSubmatch(sql)
+	if m == nil {
+		return nil, false
+	}
+	if strings.EqualFold(m[1], "DEFAULT") {
+		return nil, true
+	}
+	// Each value of SET is one name, whether written as an identifier or a string.
+	out := []string{}
+	for _, v := range splitSettingList(m[1]) {
+		if len(v) >= 2 && v[0] == '\'' && v[len(v)-1] == '\'' {
+			out = append(out, strings.ReplaceAll(v[1:len(v)-1], "''", "'"))
+		} else {
+			out = append(out, sqlident.Name(v))
+		}
+	}
+	return out, true
+}
+
+// splitSettingList splits a comma-separated setting value, leaving commas inside
+// quotes alone, and trims the items.
+func splitSettingList(s string) []string {
+	var out []string
+	var quote rune
+	start := 0
+	for i, r := range s {
+		switch {
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '\'' || r == '"':
+			quote = r
+		case r == ',':
+			out = append(out, strings.TrimSpace(s[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(s[start:]); last != "" || len(out) > 0 {
+		out = append(out, last)
+	}
+	return out
+}
+
+// qualifier fills the schemas of one statement.
+type qualifier struct {
+	sp      SearchPath
+	created map[string]string // Schema of each object the statement creates, by name
+	ctes    map[string]int    // Number of enclosing WITH clauses defining each CTE name
+}
+
+// schemaFor returns the schema to record for the unqualified name.
+func (q *qualifier) schemaFor(name string) string {
+	if s, ok := q.created[sqlident.Name(name)]; ok {
+		return s
+	}
+	return quoteName(q.sp.Resolve(name))
+}
+
+// table qualifies a base table reference. The IR of a nested subquery is parsed on its
+// own and marks references to the CTEs of the statement as base tables, so names of
+// enclosing CTEs are left alone.
+func (q *qualifier) table(t *postgresparser.TableRef) {
+	if t.Schema == "" && t.Name != "" && t.Type == postgresparser.TableTypeBase && q.ctes[sqlident.Name(t.Name)] == 0 {
+		t.Schema = q.schemaFor(t.Name)
+	}
+}
+
+// query qualifies the references of pq and its nested queries.
+func (q *qualifier) query(pq *postgresparser.ParsedQuery) {
+	if pq == nil {
+		return
+	}
+	for _, c := range pq.CTEs {
+		q.ctes[sqlident.Name(c.Name)]++
+	}
+	defer func() {
+		for _, c := range pq.CTEs {
+			q.ctes[sqlident.Name(c.Name)]--
+		}
+	}()
+	for _, c := range pq.CTEs {
+		q.query(c.Body)
+		q.query(c.Anchor)
+		q.query(c.RecursiveTerm)
+	}
+	for i := range pq.DDLActions {
+		q.ddl(&pq.DDLActions[i])
+	}
+	for i := range pq.Tables {
+		q.table(&pq.Tables[i])
+	}
+	for _, s := range pq.Scopes {
+		for i := range s.Relations {
+			q.table(&s.Relations[i].Table)
+		}
+	}
+	for _, op := range pq.SetOperations {
+		for i := range op.Tables {
+			q.table(&op.Tables[i])
+		}
+	}
+	for _, sub := range pq.Subqueries {
+		q.query(sub.Query)
+	}
+	for _, sub := range pq.NestedSubqueries {
+		q.query(sub.Query)
+	}
+	if pq.Target != nil {
+		q.table(pq.Target)
+	}
+	q.query(pq.Source)
+	if m := pq.Merge; m != nil {
+		q.table(&m.Target)
+		q.table(&m.Source.Table)
+		if m.Source.Subquery != nil {
+			q.query(m.Source.Subquery.Query)
+		}
+	}
+}
+
+// ddl qualifies a schema-scoped DDL action and the tables its constraints, LIKE clauses,
+// and OWNED BY option reference.
+func (q *qualifier) ddl(a *postgresparser.DDLAction) {
+	for i := range a.Constraints {
+		c := &a.Constraints[i]
+		if c.RefTable != "" && c.RefSchema == "" {
+			c.RefSchema = q.schemaFor(c.RefTable)
+		}
+	}
+	for i := range a.PublishedTables {
+		if t := &a.PublishedTables[i]; t.Schema == "" {
+			t.Schema = q.schemaFor(t.Name)
+		}
+	}
+	for i := range a.LikeTables {
+		if t := &a.LikeTables[i]; t.Schema == "" {
+			t.Schema = q.schemaFor(t.Name)
+		}
+	}
+	for i := range a.Options {
+		// OWNED BY table.column names the table like any other reference.
+		if opt := &a.Options[i]; opt.Name == "OWNED BY" {
+			if parts := identPartRe.FindAllString(opt.Value, -1); len(parts) == 2 {
+				opt.Value = q.schemaFor(parts[0]) + "." + opt.Value
+			}
+		}
+	}
+	if a.Schema != "" {
+		return
+	}
+	switch {
+	case a.Type == postgresparser.DDLCreateIndex && a.ObjectName == "":
+		// An unnamed index still lives in the schema of its table.
+		if a.Table != "" {
+			a.Schema = q.schemaFor(a.Table)
+		}
+		return
+	case a.ObjectKind == postgresparser.DDLObjectColumn:
+		// ObjectName is the table of the column.
+		if a.ObjectName != "" {
+			a.Schema = q.schemaFor(a.ObjectName)
+		}
+		return
+	case a.ObjectName == "":
+		return
+	}
+	if !schemaScoped(a) {
+		if a.Table != "" && tableScoped(a.ObjectKind) {
+			a.Schema = q.schemaFor(a.Table)
+		}
+		return
+	}
+	switch {
+	case a.Type == postgresparser.DDLCreateIndex:
+		a.Schema = q.schemaFor(a.Table)
+	case isCreate(a.Type):
+		a.Schema = quoteName(q.sp.CreationSchema())
+		q.created[sqlident.Name(a.ObjectName)] = a.Schema
+	default:
+		a.Schema = q.schemaFor(a.ObjectName)
+	}
+}
+
+// schemaScoped reports whether the object of a DDL action lives in a schema. Triggers,
+// policies, rules, and constraints belong to a table and are qualified by it.
+func schemaScoped(a *postgresparser.DDLAction) bool {
+	switch a.Type {
+	case postgresparser.DDLCreateSchema, postgresparser.DDLCreateExtension,
+		postgresparser.DDLCreateForeignDataWrapper, postgresparser.DDLCreateServer,
+		postgresparser.DDLImportForeignSchema, postgresparser.DDLCreateUserMapping,
+		postgresparser.DDLCreatePublication, postgresparser.DDLCreateSubscription:
+		return false
+	}
+	switch a.ObjectKind {
+	case postgresparser.DDLObjectSchema, postgresparser.DDLObjectExtension,
+		postgresparser.DDLObjectRole, postgresparser.DDLObjectDatabase,
+		postgresparser.DDLObjectTablespace, postgresparser.DDLObjectLanguage,
+		postgresparser.DDLObjectOwned, postgresparser.DDLObjectServer,
+		postgresparser.DDLObjectForeignDataWrapper, postgresparser.DDLObjectUserMapping,
+		postgresparser.DDLObjectPublication, postgresparser.DDLObjectSubscription,
+		postgresparser.DDLObjectColumn, postgresparser.DDLObjectConstraint,
+		postgresparser.DDLObjectTrigger, postgresparser.DDLObjectPolicy, postgresparser.DDLObjectRule:
+		return false
+	}
+	return true
+}
+
+// tableScoped reports whether objects of kind belong to a table and share its schema.
+func tableScoped(kind postgresparser.DDLObjectKind) bool {
+	switch kind {
+	case postgresparser.DDLObjectTrigger, postgresparser.DDLObjectPolicy,
+		postgresparser.DDLObjectRule, postgresparser.DDLObjectConstraint:
+		return true
+	}
+	return false
+}
+
+// isCreate reports whether a DDL action type creates a schema object.
+func isCreate(t postgresparser.DDLActionType) bool {
+	switch t {
+	case postgresparser.DDLCreateTable, postgresparser.DDLCreateType, postgresparser.DDLCreateDomain,
+		postgresparser.DDLCreateView, postgresparser.DDLCreateSequence, postgresparser.DDLCreateForeignTable,
+		postgresparser.DDLCreateFunction:
+		return true
+	}
+	return false
+}
+
+// quoteName returns a schema name as it would be written in SQL.
+func quoteName(name string) string {
+	if name == "" || plainName.MatchString(name) {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// plainName matches names that need no quoting.
+var plainName = regexp.MustCompile(`^[a-z_][a-z0-9_$]*$`)
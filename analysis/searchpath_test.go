@@ -0,0 +1,199 @@
+package analysis
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// qualifiedTables returns the base tables of pq as "schema.name".
+func qualifiedTables(pq *postgresparser.ParsedQuery) []string {
+	var out []string
+	for _, t := range pq.Tables {
+		if t.Type == postgresparser.TableTypeBase {
+			out = append(out, t.Schema+"."+t.Name)
+		}
+	}
+	return out
+}
+
+func TestQualifyQuery(t *testing.T) {
+	// tenant_a has its own users; everything else lives in public.
+	objects := map[string]bool{"tenant_a.users": true, "public.users": true, "public.plans": true}
+	sp := SearchPath{
+		Schemas: []string{"$user", "tenant_a", "public"},
+		Exists:  func(schema, name string) bool { return objects[schema+"."+name] },
+	}
+
+	pq, err := postgresparser.ParseSQL("SELECT u.id, p.name FROM users u JOIN plans p ON p.id = u.plan_id JOIN billing.invoices i ON i.user_id = u.id WHERE EXISTS (SELECT 1 FROM pg_class)")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, []string{"tenant_a.users", "public.plans", "billing.invoices"}, qualifiedTables(pq), "tables")
+	require.Len(t, pq.Scopes, 2, "scopes")
+	assert.Equal(t, "tenant_a", pq.Scopes[0].Relations[0].Table.Schema, "scope relation")
+	assert.Equal(t, "pg_catalog", pq.Scopes[1].Relations[0].Table.Schema, "system catalog in a subquery")
+
+	pq, err = postgresparser.ParseSQL("INSERT INTO audit (user_id) SELECT id FROM users")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, "tenant_a", pq.Target.Schema, "insert target falls back to the creation schema")
+	assert.Equal(t, []string{"tenant_a.users"}, qualifiedTables(pq.Source), "insert source")
+
+	pq, err = postgresparser.ParseSQL("CREATE TABLE invites (id int, plan_id int REFERENCES plans)")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	require.Len(t, pq.DDLActions, 1, "actions")
+	assert.Equal(t, "tenant_a", pq.DDLActions[0].Schema, "created in the first schema")
+
+	pq, err = postgresparser.ParseSQL("ALTER TABLE plans ADD CONSTRAINT plans_owner_fkey FOREIGN KEY (owner_id) REFERENCES users (id)")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, "public", pq.DDLActions[0].Schema, "altered table")
+	assert.Equal(t, "tenant_a", pq.DDLActions[0].Constraints[0].RefSchema, "referenced table")
+
+	pq, err = postgresparser.ParseSQL("ALTER SEQUENCE plan_seq OWNED BY users.id")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, []postgresparser.DDLOption{{Name: "OWNED BY", Value: "tenant_a.users.id"}}, pq.DDLActions[0].Options, "owning table")
+
+	pq, err = postgresparser.ParseSQL("CREATE INDEX plans_name_idx ON plans (name)")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, "public", pq.DDLActions[0].Schema, "index follows its table")
+
+	pq, err = postgresparser.ParseSQL("CREATE TRIGGER plans_audit AFTER UPDATE ON plans FOR EACH ROW EXECUTE FUNCTION audit()")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, "public", pq.DDLActions[0].Schema, "trigger follows its table")
+
+	pq, err = postgresparser.ParseSQL("DROP TRIGGER plans_audit ON plans")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, "public", pq.DDLActions[0].Schema, "dropped trigger follows its table")
+
+	pq, err = postgresparser.ParseSQL("CREATE FUNCTION plan_count() RETURNS bigint LANGUAGE sql AS 'SELECT count(*) FROM plans'")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, "tenant_a", pq.DDLActions[0].Schema, "function created in the first schema")
+
+	pq, err = postgresparser.ParseSQL("WITH users AS (SELECT 1 AS id) SELECT id FROM users")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	for _, tbl := range pq.Tables {
+		assert.Empty(t, tbl.Schema, "CTE reference %s should stay unqualified", tbl.Name)
+	}
+}
+
+func TestQualifyQuery_NestedSubqueries(t *testing.T) {
+	sp := SearchPath{Schemas: []string{"tenant"}}
+	pq, err := postgresparser.ParseSQL("SELECT * FROM users WHERE id IN (SELECT user_id FROM orders WHERE EXISTS (SELECT 1 FROM refunds))")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	require.Len(t, pq.NestedSubqueries, 2, "nested subqueries")
+	assert.Equal(t, []string{"tenant.orders"}, qualifiedTables(pq.NestedSubqueries[0].Query), "subquery tables")
+	assert.Equal(t, []string{"tenant.refunds"}, qualifiedTables(pq.NestedSubqueries[1].Query), "inner subquery tables")
+
+	pq, err = postgresparser.ParseSQL("WITH recent AS (SELECT 1 AS id) SELECT * FROM users WHERE id IN (SELECT id FROM recent)")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	require.Len(t, pq.NestedSubqueries, 1, "nested subqueries")
+	assert.Equal(t, []string{".recent"}, qualifiedTables(pq.NestedSubqueries[0].Query), "CTE reference in a subquery stays unqualified")
+}
+
+func TestQualifyQuery_CTEBodies(t *testing.T) {
+	sp := SearchPath{Schemas: []string{"tenant"}}
+	pq, err := postgresparser.ParseSQL("WITH c AS (SELECT id FROM orgs), d AS (SELECT c.id FROM c JOIN users u ON u.org_id = c.id) SELECT * FROM d")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	require.Len(t, pq.CTEs, 2, "CTEs")
+	require.NotNil(t, pq.CTEs[0].Body, "first CTE body")
+	assert.Equal(t, []string{"tenant.orgs"}, qualifiedTables(pq.CTEs[0].Body), "first CTE body tables")
+	require.NotNil(t, pq.CTEs[1].Body, "second CTE body")
+	assert.Equal(t, []string{"tenant.users"}, qualifiedTables(pq.CTEs[1].Body), "second CTE body tables")
+	for _, tbl := range pq.CTEs[1].Body.Tables {
+		if tbl.Type == postgresparser.TableTypeCTE {
+			assert.Empty(t, tbl.Schema, "reference to a sibling CTE stays unqualified")
+		}
+	}
+
+	pq, err = postgresparser.ParseSQL("WITH RECURSIVE tree AS (SELECT id, parent_id FROM nodes WHERE parent_id IS NULL UNION ALL SELECT n.id, n.parent_id FROM nodes n JOIN tree t ON n.parent_id = t.id) SELECT * FROM tree")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	require.Len(t, pq.CTEs, 1, "CTEs")
+	c := pq.CTEs[0]
+	require.NotNil(t, c.Anchor, "anchor")
+	require.NotNil(t, c.RecursiveTerm, "recursive term")
+	assert.Equal(t, []string{"tenant.nodes"}, qualifiedTables(c.Anchor), "anchor tables")
+	assert.Equal(t, []string{"tenant.nodes"}, qualifiedTables(c.RecursiveTerm), "recursive term tables")
+	for _, tbl := range c.RecursiveTerm.Tables {
+		if tbl.Name == "tree" {
+			assert.Empty(t, tbl.Schema, "self-reference stays unqualified")
+		}
+	}
+}
+
+func TestSearchPath_Resolve(t *testing.T) {
+	sp := SearchPath{Schemas: DefaultSearchPath, User: "alice"}
+	assert.Equal(t, "alice", sp.Resolve("users"), "$user comes first")
+	assert.Equal(t, "pg_catalog", sp.Resolve("pg_tables"), "system catalog")
+
+	sp.HasSchema = func(name string) bool { return name == "public" }
+	assert.Equal(t, "public", sp.Resolve("users"), "missing $user schema is skipped")
+	assert.Equal(t, "public", sp.CreationSchema(), "creation schema")
+
+	sp = SearchPath{Schemas: []string{"public", "pg_catalog"}, Exists: func(schema, name string) bool { return schema == "public" && name == "pg_stats" }}
+	assert.Equal(t, "public", sp.Resolve("pg_stats"), "explicit pg_catalog is searched in place")
+
+	sp = SearchPath{Schemas: []string{`Tenant A`}}
+	pq, err := postgresparser.ParseSQL("SELECT * FROM users")
+	require.NoError(t, err, "parse failed")
+	QualifyQuery(pq, sp)
+	assert.Equal(t, `"Tenant A"`, pq.Tables[0].Schema, "names needing quotes are quoted")
+}
+
+func TestSearchPathSetting(t *testing.T) {
+	tests := []struct {
+		sql     string
+		schemas []string
+		ok      bool
+	}{
+		{"SET search_path TO tenant_a, public", []string{"tenant_a", "public"}, true},
+		{`set search_path = "$user", "Tenant", Public;`, []string{"$user", "Tenant", "public"}, true},
+		{"SET LOCAL search_path TO 'my schema'", []string{"my schema"}, true},
+		{"SET SESSION search_path = DEFAULT", nil, true},
+		{"RESET search_path", nil, true},
+		{"RESET ALL", nil, true},
+		{"SELECT pg_catalog.set_config('search_path', '', false)", []string{}, true},
+		{`SELECT set_config('search_path', 'app, "Other"', true)`, []string{"app", "Other"}, true},
+		{"SET statement_timeout = 0", nil, false},
+		{"SELECT 1", nil, false},
+	}
+	for _, tc := range tests {
+		t.Run(tc.sql, func(t *testing.T) {
+			schemas, ok := SearchPathSetting(tc.sql)
+			assert.Equal(t, tc.ok, ok, "ok")
+			assert.Equal(t, tc.schemas, schemas, "schemas")
+		})
+	}
+}
+
+func TestQualifyScript(t *testing.T) {
+	var queries []*postgresparser.ParsedQuery
+	for _, stmt := range postgresparser.SplitStatements(`
+SELECT * FROM users;
+SET search_path TO tenant_b;
+SELECT * FROM users;
+RESET search_path;
+SELECT * FROM users;`) {
+		pq, err := postgresparser.ParseSQL(stmt.SQL)
+		require.NoError(t, err, "parse %q", stmt.SQL)
+		queries = append(queries, pq)
+	}
+	QualifyScript(queries, SearchPath{Schemas: []string{"tenant_a"}})
+	assert.Equal(t, []string{"tenant_a.users"}, qualifiedTables(queries[0]), "initial path")
+	assert.Equal(t, []string{"tenant_b.users"}, qualifiedTables(queries[2]), "after SET")
+	assert.Equal(t, []string{"tenant_a.users"}, qualifiedTables(queries[4]), "after RESET")
+}
@@ -90,11 +90,18 @@ type SQLSubquery struct {
 	Analysis *SQLAnalysis
 }
 
-// SQLCTE describes a common table expression.
+// SQLCTE describes a common table expression. Analysis is the parsed body; Anchor and
+// RecursiveTerm split the body of a recursive CTE.
 type SQLCTE struct {
-	Name         string
-	Query        string
-	Materialized string
+	Name          string
+	Query         string
+	Materialized  string
+	Columns       []string
+	Recursive     bool
+	Modifying     bool
+	Analysis      *SQLAnalysis
+	Anchor        *SQLAnalysis
+	RecursiveTerm *SQLAnalysis
 }
 
 // SQLUpsert captures ON CONFLICT metadata for INSERT statements.
@@ -170,6 +177,7 @@ type SQLColumnUsage struct {
 	Functions  []string
 	Operator   string
 	Side       string
+	Position   int
 }
 
 // SQLDDLColumn describes column-level metadata extracted from CREATE TABLE statements.
@@ -182,13 +190,61 @@ type SQLDDLColumn struct {
 
 // SQLDDLAction describes a single DDL operation in the analysis result.
 type SQLDDLAction struct {
-	Type          string
-	ObjectName    string
-	Schema        string
-	Columns       []string
-	ColumnDetails []SQLDDLColumn
-	Flags         []string
-	IndexType     string
+	Type            string
+	ObjectName      string
+	Schema          string
+	Columns         []string
+	ColumnDetails   []SQLDDLColumn
+	Flags           []string
+	IndexType       string
+	ObjectKind      string
+	Table           string
+	Owner           string
+	Comment         string
+	Server          string
+	Wrapper         string
+	RemoteSchema    string
+	Connection      string
+	Objects         []string
+	Options         []SQLDDLOption
+	PublishedTables []SQLPublicationTable
+	NewSchema       string
+	NewName         string
+	Constraints     []SQLDDLConstraint
+	EnumValues      []string
+	DataType        string
+	Tablespace      string
+	Signature       string
+	IncludeColumns  []string
+	Predicate       string
+}
+
+// SQLDDLConstraint describes a table or column constraint targeted by a DDL action.
+type SQLDDLConstraint struct {
+	Name       string
+	Type       string
+	Columns    []string
+	RefSchema  string
+	RefTable   string
+	RefColumns []string
+	OnDelete   string
+	OnUpdate   string
+	Expression string
+	NotValid   bool
+}
+
+// SQLDDLOption is a name/value entry from an OPTIONS (...) or WITH (...) list.
+type SQLDDLOption struct {
+	Name  string
+	Value string
+}
+
+// SQLPublicationTable describes a table published by CREATE PUBLICATION.
+type SQLPublicationTable struct {
+	Schema    string
+	Name      string
+	Columns   []string
+	RowFilter string
 }
 
 // SQLAnalysis is a standalone DTO representing the parsed SQL metadata.
@@ -213,6 +269,8 @@ type SQLAnalysis struct {
 	Upsert         *SQLUpsert
 	Merge          *SQLMerge
 	DDLActions     []SQLDDLAction
+	Target         *SQLTable    // Relation written by INSERT, CREATE TABLE AS, or SELECT INTO
+	Source         *SQLAnalysis // Query whose rows populate Target
 	ColumnUsage    []SQLColumnUsage
 	Correlations   []SQLJoinCorrelation
 	DerivedColumns map[string]string
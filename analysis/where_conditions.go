@@ -47,7 +47,7 @@ func ExtractWhereConditions(query string) ([]WhereCondition, error) {
 	if err != nil {
 		return nil, fmt.Errorf("failed to parse query: %w", err)
 	}
-	return extractWhereConditionsFromParsed(pq), nil
+	return extractWhereConditionsFromParsed(pq, nil), nil
 }
 
 // jsonbInfo holds extracted JSONB operator information.
@@ -0,0 +1,346 @@
+// where_tree.go extracts the AND/OR/NOT structure of a WHERE clause, with the column
+// conditions of each predicate at its leaves.
+package analysis
+
+import (
+	"fmt"
+	"reflect"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// ConditionOp is the kind of a ConditionNode.
+type ConditionOp string
+
+const (
+	ConditionAnd  ConditionOp = "AND"  // Every child holds
+	ConditionOr   ConditionOp = "OR"   // At least one child holds
+	ConditionNot  ConditionOp = "NOT"  // The only child does not hold
+	ConditionLeaf ConditionOp = "LEAF" // A predicate without a boolean operator at its top
+)
+
+// ConditionNode is a node of the condition tree of a WHERE clause. Nested ANDs and ORs
+// are flattened, so a child never has the operator of its parent.
+type ConditionNode struct {
+	Op       ConditionOp     `json:"op"`
+	Children []ConditionNode `json:"children,omitempty"` // Operands of AND, OR, and NOT
+	// Conditions of a leaf, one per column it compares. A comparison of two columns has
+	// one for each; EXISTS, a boolean column, or a function call has none, and the
+	// comparisons inside a CASE add none.
+	Conditions []WhereCondition `json:"conditions,omitempty"`
+	Expression string           `json:"expression"` // Text of the node
+}
+
+// ExtractWhereConditionTree parses a query and returns the condition tree of the WHERE
+// clause of its top-level query, or nil if it has none. Conditions inside subqueries
+// belong to the leaf that contains the subquery and are not part of its Conditions.
+// For a set operation the tree is that of the first branch.
+func ExtractWhereConditionTree(query string) (*ConditionNode, error) {
+	pq, err := postgresparser.ParseSQL(query)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return extractWhereConditionTree(pq, nil), nil
+}
+
+// extractWhereConditionTree builds the condition tree of an already-parsed query.
+// resolved is as for extractWhereConditionsFromParsed.
+func extractWhereConditionTree(pq *postgresparser.ParsedQuery, resolved []ResolvedColumn) *ConditionNode {
+	if pq == nil || len(pq.Scopes) == 0 {
+		return nil
+	}
+	cond := whereCondition(levelTokens(pq.Scopes, sqllex.Lex(pq.RawSQL), 0))
+	if len(cond) == 0 {
+		return nil
+	}
+	b := conditionTreeBuilder{pq: pq, resolved: resolved, runes: []rune(pq.RawSQL)}
+	n := b.node(cond)
+	return &n
+}
+
+// conditionTreeBuilder builds the condition tree of a statement.
+type conditionTreeBuilder struct {
+	pq       *postgresparser.ParsedQuery
+	resolved []ResolvedColumn
+	runes    []rune
+}
+
+// node builds the node of a condition. OR binds looser than AND, and AND looser than NOT.
+func (b *conditionTreeBuilder) node(toks []levelToken) ConditionNode {
+	for len(toks) > 2 && enclosed(toks) {
+		toks = toks[1 : len(toks)-1]
+	}
+	if parts := splitDisjuncts(toks); len(parts) > 1 {
+		return b.group(ConditionOr, toks, parts)
+	}
+	if parts := splitConjuncts(toks); len(parts) > 1 {
+		return b.group(ConditionAnd, toks, parts)
+	}
+	if len(toks) > 1 && toks[0].Is("NOT") {
+		return ConditionNode{Op: ConditionNot, Children: []ConditionNode{b.node(toks[1:])}, Expression: b.text(toks)}
+	}
+	return b.leaf(toks)
+}
+
+// group builds an AND or OR node of the given operands, taking in the operands of
+// children with the same operator.
+func (b *conditionTreeBuilder) group(op ConditionOp, toks []levelToken, parts [][]levelToken) ConditionNode {
+	n := ConditionNode{Op: op, Expression: b.text(toks)}
+	for _, part := range parts {
+		if c := b.node(part); c.Op == op {
+			n.Children = append(n.Children, c.Children...)
+		} else {
+			n.Children = append(n.Children, c)
+		}
+	}
+	return n
+}
+
+// leaf builds the node of a predicate from the filter column usages of the statement's
+// own query level inside it. The parser records a repeated comparison once, so a leaf
+// without usages takes those whose context is its text. Comparisons inside a CASE
+// decide its result rather than filter rows, so they are left out.
+func (b *conditionTreeBuilder) leaf(toks []levelToken) ConditionNode {
+	n := ConditionNode{Op: ConditionLeaf, Expression: b.text(toks)}
+	if len(toks) == 0 {
+		return n
+	}
+	start, end := toks[0].Pos, b.end(toks)
+	inLeaf := func(u postgresparser.ColumnUsage) bool { return u.Position >= start && u.Position < end }
+	if !slices.ContainsFunc(b.pq.ColumnUsage, inLeaf) {
+		inLeaf = func(u postgresparser.ColumnUsage) bool { return u.Context == n.Expression }
+	}
+	cases := caseSpans(toks)
+	inCase := func(u postgresparser.ColumnUsage) bool {
+		return slices.ContainsFunc(cases, func(span [2]int) bool { return u.Position > span[0] && u.Position < span[1] })
+	}
+	seen := make(map[int]bool)
+	for i, u := range b.pq.ColumnUsage {
+		if !inLeaf(u) || seen[u.Position] || innermostScope(b.pq.Scopes, u.Position) != 0 || inCase(u) {
+			continue
+		}
+		if cond, ok := whereConditionFromUsage(b.pq, i, b.resolved); ok {
+			seen[u.Position] = true
+			n.Conditions = append(n.Conditions, cond)
+		}
+	}
+	return n
+}
+
+// caseSpans returns the offsets of the CASE and END tokens of the CASE expressions in
+// toks.
+func caseSpans(toks []levelToken) [][2]int {
+	var out [][2]int
+	for k, t := range toks {
+		if !t.IsKeyword("CASE") {
+			continue
+		}
+		for _, e := range toks[k+1:] {
+			if e.depth == t.depth && e.IsKeyword("END") {
+				out = append(out, [2]int{t.Pos, e.Pos})
+				break
+			}
+		}
+	}
+	return out
+}
+
+// text returns the SQL text of a condition.
+func (b *conditionTreeBuilder) text(toks []levelToken) string {
+	if len(toks) == 0 {
+		return ""
+	}
+	return string(b.runes[toks[0].Pos:b.end(toks)])
+}
+
+// end returns the offset after a condition, including the subqueries that follow its
+// last token, which are not among the tokens of its level.
+func (b *conditionTreeBuilder) end(toks []levelToken) int {
+	end := tokenEnd(toks[len(toks)-1])
+	for extended := true; extended; {
+		extended = false
+		for _, sc := range b.pq.Scopes {
+			if sc.Start >= end && sc.End > end && strings.TrimSpace(string(b.runes[end:sc.Start])) == "" {
+				end, extended = sc.End, true
+			}
+		}
+	}
+	return end
+}
+
+// tokenEnd returns the offset after a token.
+func tokenEnd(t levelToken) int {
+	return t.Pos + len([]rune(t.Text))
+}
+
+// enclosed reports whether a condition is wholly in one pair of parentheses.
+func enclosed(toks []levelToken) bool {
+	first, last := toks[0], toks[len(toks)-1]
+	if !first.Is("(") || !last.Is(")") || last.depth != first.depth {
+		return false
+	}
+	for _, t := range toks[1 : len(toks)-1] {
+		if t.depth <= first.depth {
+			return false
+		}
+	}
+	return true
+}
+
+// splitDisjuncts splits a condition at its top-level ORs.
+func splitDisjuncts(toks []levelToken) [][]levelToken {
+	if len(toks) == 0 {
+		return nil
+	}
+	base := toks[0].depth
+	var out [][]levelToken
+	var cur []levelToken
+	for _, t := range toks {
+		if t.depth == base && t.Is("OR") {
+			out = append(out, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	return append(out, cur)
+}
+
+// DNF returns the condition in disjunctive normal form: a row satisfies it when it
+// satisfies every condition of at least one of the returned conjunctions. NOT is
+// pushed down to the leaves, whose operators are negated; a negated condition whose
+// operator has no negation, such as @>, is left out. A leaf without conditions adds no
+// constraint, so a conjunction may be empty. The number of conjunctions is the product
+// of the sizes of the ORs under an AND.
+func (n *ConditionNode) DNF() [][]WhereCondition {
+	if n == nil {
+		return nil
+	}
+	return dnf(negationNormalForm(*n, false))
+}
+
+// RequiredConditions returns the conditions that every row satisfying the condition
+// satisfies: those under ANDs only, and those common to every branch of an OR. NOT is
+// handled as in DNF.
+func (n *ConditionNode) RequiredConditions() []WhereCondition {
+	if n == nil {
+		return nil
+	}
+	return required(negationNormalForm(*n, false))
+}
+
+// dnf returns the conjunctions of a condition in negation normal form.
+func dnf(n ConditionNode) [][]WhereCondition {
+	switch n.Op {
+	case ConditionOr:
+		var out [][]WhereCondition
+		for _, c := range n.Children {
+			out = append(out, dnf(c)...)
+		}
+		return out
+	case ConditionAnd:
+		out := [][]WhereCondition{nil}
+		for _, c := range n.Children {
+			terms := dnf(c)
+			next := make([][]WhereCondition, 0, len(out)*len(terms))
+			for _, prefix := range out {
+				for _, term := range terms {
+					next = append(next, append(slices.Clip(prefix), term...))
+				}
+			}
+			out = next
+		}
+		return out
+	}
+	return [][]WhereCondition{slices.Clone(n.Conditions)}
+}
+
+// required returns the required conditions of a condition in negation normal form.
+func required(n ConditionNode) []WhereCondition {
+	switch n.Op {
+	case ConditionAnd:
+		var out []WhereCondition
+		for _, c := range n.Children {
+			for _, cond := range required(c) {
+				if !containsCondition(out, cond) {
+					out = append(out, cond)
+				}
+			}
+		}
+		return out
+	case ConditionOr:
+		if len(n.Children) == 0 {
+			return nil
+		}
+		out := required(n.Children[0])
+		for _, c := range n.Children[1:] {
+			branch := required(c)
+			out = slices.DeleteFunc(out, func(cond WhereCondition) bool { return !containsCondition(branch, cond) })
+		}
+		return out
+	}
+	return slices.Clone(n.Conditions)
+}
+
+// containsCondition reports whether conds holds a condition equal to cond.
+func containsCondition(conds []WhereCondition, cond WhereCondition) bool {
+	return slices.ContainsFunc(conds, func(c WhereCondition) bool { return reflect.DeepEqual(c, cond) })
+}
+
+// negationNormalForm returns n, or its negation, without NOT nodes, applying De Morgan's
+// laws and negating the conditions of the leaves.
+func negationNormalForm(n ConditionNode, negate bool) ConditionNode {
+	switch n.Op {
+	case ConditionNot:
+		if len(n.Children) != 1 {
+			return ConditionNode{Op: ConditionLeaf, Expression: n.Expression}
+		}
+		return negationNormalForm(n.Children[0], !negate)
+	case ConditionAnd, ConditionOr:
+		out := ConditionNode{Op: n.Op, Expression: n.Expression}
+		if negate {
+			out.Op = ConditionAnd
+			if n.Op == ConditionAnd {
+				out.Op = ConditionOr
+			}
+			out.Expression = "NOT (" + n.Expression + ")"
+		}
+		for _, c := range n.Children {
+			out.Children = append(out.Children, negationNormalForm(c, negate))
+		}
+		return out
+	}
+	if !negate {
+		return n
+	}
+	out := ConditionNode{Op: ConditionLeaf, Expression: "NOT (" + n.Expression + ")"}
+	for _, cond := range n.Conditions {
+		if op, ok := negatedOperators[cond.Operator]; ok {
+			cond.Operator = op
+			out.Conditions = append(out.Conditions, cond)
+		}
+	}
+	return out
+}
+
+// negatedOperators maps each normalized operator to its negation. The parser reports
+// IS NULL as IS, and ISNULL as IS NULL.
+var negatedOperators = func() map[string]string {
+	pairs := [][2]string{
+		{"=", "!="}, {"<", ">="}, {">", "<="},
+		{"IN", "NOT IN"}, {"BETWEEN", "NOT BETWEEN"},
+		{"LIKE", "NOT LIKE"}, {"ILIKE", "NOT ILIKE"}, {"SIMILAR TO", "NOT SIMILAR TO"},
+		{"~", "!~"}, {"~*", "!~*"},
+		{"IS", "IS NOT NULL"}, {"IS TRUE", "IS NOT TRUE"}, {"IS FALSE", "IS NOT FALSE"},
+		{"IS DISTINCT FROM", "IS NOT DISTINCT FROM"},
+	}
+	m := make(map[string]string, 2*len(pairs))
+	for _, p := range pairs {
+		m[p[0]], m[p[1]] = p[1], p[0]
+	}
+	m["IS NULL"] = "IS NOT NULL"
+	return m
+}()
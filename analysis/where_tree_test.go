@@ -0,0 +1,187 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// treeString renders a condition tree with leaves as their expressions.
+func treeString(n ConditionNode) string {
+	if n.Op == ConditionLeaf {
+		return n.Expression
+	}
+	s := string(n.Op) + "("
+	for i, c := range n.Children {
+		if i > 0 {
+			s += ", "
+		}
+		s += treeString(c)
+	}
+	return s + ")"
+}
+
+// conditionStrings renders conditions as column, operator, and value.
+func conditionStrings(conds []WhereCondition) []string {
+	out := []string{}
+	for _, c := range conds {
+		out = append(out, strings.TrimSpace(c.Column+" "+c.Operator+" "+stringValue(c.Value)))
+	}
+	return out
+}
+
+// stringValue renders a condition value.
+func stringValue(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case []string:
+		return strings.Join(v, ",")
+	}
+	return ""
+}
+
+func TestExtractWhereConditionTree_Structure(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  string
+	}{
+		{"single condition", "SELECT * FROM t WHERE a = 1", "a = 1"},
+		{"and", "SELECT * FROM t WHERE a = 1 AND b = 2", "AND(a = 1, b = 2)"},
+		{"or", "SELECT * FROM t WHERE a = 1 OR b = 2", "OR(a = 1, b = 2)"},
+		{"and binds tighter than or", "SELECT * FROM t WHERE a = 1 OR b = 2 AND c = 3", "OR(a = 1, AND(b = 2, c = 3))"},
+		{"parentheses", "SELECT * FROM t WHERE (a = 1 OR b = 2) AND c = 3", "AND(OR(a = 1, b = 2), c = 3)"},
+		{"nested groups flatten", "SELECT * FROM t WHERE a = 1 AND (b = 2 AND (c = 3))", "AND(a = 1, b = 2, c = 3)"},
+		{"not", "SELECT * FROM t WHERE NOT (a = 1 OR b = 2) AND c = 3", "AND(NOT(OR(a = 1, b = 2)), c = 3)"},
+		{"between and", "SELECT * FROM t WHERE a BETWEEN 1 AND 5 OR b NOT BETWEEN 2 AND 3", "OR(a BETWEEN 1 AND 5, b NOT BETWEEN 2 AND 3)"},
+		{"subquery stays in its leaf", "SELECT * FROM t WHERE a = 1 OR EXISTS (SELECT 1 FROM u WHERE u.x = t.a OR u.y = 2)", "OR(a = 1, EXISTS (SELECT 1 FROM u WHERE u.x = t.a OR u.y = 2))"},
+		{"clause after where", "SELECT * FROM t WHERE a = 1 OR b = 2 ORDER BY a LIMIT 5", "OR(a = 1, b = 2)"},
+		{"delete", "DELETE FROM t WHERE a = 1 OR b IS NULL", "OR(a = 1, b IS NULL)"},
+		{"or inside case", "SELECT * FROM t WHERE CASE WHEN a = 1 OR b = 2 THEN true END", "CASE WHEN a = 1 OR b = 2 THEN true END"},
+		{"and inside case", "SELECT * FROM t WHERE CASE WHEN a = 1 AND b = 2 THEN c ELSE d END = 3 AND e = 4", "AND(CASE WHEN a = 1 AND b = 2 THEN c ELSE d END = 3, e = 4)"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ExtractWhereConditionTree(tt.query)
+			require.NoError(t, err)
+			require.NotNil(t, tree)
+			assert.Equal(t, tt.want, treeString(*tree))
+		})
+	}
+
+	tree, err := ExtractWhereConditionTree("SELECT * FROM t")
+	require.NoError(t, err)
+	assert.Nil(t, tree)
+	_, err = ExtractWhereConditionTree("SELEC 1")
+	assert.Error(t, err)
+}
+
+func TestExtractWhereConditionTree_Leaves(t *testing.T) {
+	tree, err := ExtractWhereConditionTree("SELECT * FROM orders o WHERE o.status = $1 OR EXISTS (SELECT 1 FROM items i WHERE i.order_id = o.id)")
+	require.NoError(t, err)
+	require.Len(t, tree.Children, 2)
+	assert.Equal(t, []WhereCondition{{Table: "orders", Column: "status", Operator: "=", Value: "$1", IsParameter: true}}, tree.Children[0].Conditions)
+	assert.Empty(t, tree.Children[1].Conditions, "conditions of the subquery")
+}
+
+func TestExtractWhereConditionTree_Nodes(t *testing.T) {
+	tree, err := ExtractWhereConditionTree("SELECT * FROM t WHERE a = 1 OR NOT b > $1")
+	require.NoError(t, err)
+	assert.Equal(t, &ConditionNode{
+		Op: ConditionOr,
+		Children: []ConditionNode{
+			{Op: ConditionLeaf, Conditions: []WhereCondition{{Table: "t", Column: "a", Operator: "=", Value: "1"}}, Expression: "a = 1"},
+			{Op: ConditionNot, Children: []ConditionNode{
+				{Op: ConditionLeaf, Conditions: []WhereCondition{{Table: "t", Column: "b", Operator: ">", Value: "$1", IsParameter: true}}, Expression: "b > $1"},
+			}, Expression: "NOT b > $1"},
+		},
+		Expression: "a = 1 OR NOT b > $1",
+	}, tree)
+
+	tree, err = ExtractWhereConditionTree("SELECT * FROM t WHERE CASE WHEN a = 1 OR b = 2 THEN true END")
+	require.NoError(t, err)
+	assert.Equal(t, &ConditionNode{Op: ConditionLeaf, Expression: "CASE WHEN a = 1 OR b = 2 THEN true END"}, tree, "a CASE is one leaf")
+	assert.Empty(t, tree.RequiredConditions(), "the comparisons of a CASE filter no rows")
+}
+
+func TestConditionNode_DNF(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  [][]string
+	}{
+		{"and", "SELECT * FROM t WHERE a = 1 AND b = 2", [][]string{{"a = 1", "b = 2"}}},
+		{"or", "SELECT * FROM t WHERE a = 1 OR b = 2", [][]string{{"a = 1"}, {"b = 2"}}},
+		{
+			name:  "and of ors",
+			query: "SELECT * FROM t WHERE (a = 1 OR b = 2) AND (c = 3 OR d = 4)",
+			want:  [][]string{{"a = 1", "c = 3"}, {"a = 1", "d = 4"}, {"b = 2", "c = 3"}, {"b = 2", "d = 4"}},
+		},
+		{
+			name:  "not pushed to the leaves",
+			query: "SELECT * FROM t WHERE NOT (a = 1 OR b IN (1, 2)) AND c > 3",
+			want:  [][]string{{"a != 1", "b NOT IN 1,2", "c > 3"}},
+		},
+		{
+			name:  "negated and",
+			query: "SELECT * FROM t WHERE NOT (a LIKE 'x%' AND b IS NULL)",
+			want:  [][]string{{"a NOT LIKE x%"}, {"b IS NOT NULL"}},
+		},
+		{"double negation", "SELECT * FROM t WHERE NOT (NOT a < 1)", [][]string{{"a < 1"}}},
+		{"leaf without conditions", "SELECT * FROM t WHERE a = 1 OR EXISTS (SELECT 1 FROM u)", [][]string{{"a = 1"}, {}}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ExtractWhereConditionTree(tt.query)
+			require.NoError(t, err)
+			var got [][]string
+			for _, term := range tree.DNF() {
+				got = append(got, conditionStrings(term))
+			}
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestConditionNode_RequiredConditions(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{"and", "SELECT * FROM t WHERE a = 1 AND b = 2", []string{"a = 1", "b = 2"}},
+		{"or", "SELECT * FROM t WHERE a = 1 OR b = 2", []string{}},
+		{"and beside or", "SELECT * FROM t WHERE a = 1 AND (b = 2 OR c = 3)", []string{"a = 1"}},
+		{"common to every branch", "SELECT * FROM t WHERE (a = 1 AND b = 2) OR (a = 1 AND c = 3)", []string{"a = 1"}},
+		{"negated or", "SELECT * FROM t WHERE NOT (a = 1 OR b >= 2)", []string{"a != 1", "b < 2"}},
+		{"negated and", "SELECT * FROM t WHERE NOT (a = 1 AND b = 2)", []string{}},
+		{"null test", "SELECT * FROM t WHERE NOT (a IS NULL OR b ISNULL)", []string{"a IS NOT NULL", "b IS NOT NULL"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tree, err := ExtractWhereConditionTree(tt.query)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, conditionStrings(tree.RequiredConditions()))
+		})
+	}
+
+	var none *ConditionNode
+	assert.Nil(t, none.DNF())
+	assert.Nil(t, none.RequiredConditions())
+}
+
+func TestExtractQueryAnalysis_WhereConditionTree(t *testing.T) {
+	result, err := ExtractQueryAnalysisWithSchema(
+		"SELECT * FROM users u JOIN orders o ON o.user_id = u.id WHERE status = 'paid' OR u.email = $1",
+		map[string][]ColumnSchema{"orders": {{Name: "status"}}, "users": {{Name: "email"}}},
+	)
+	require.NoError(t, err)
+	require.NotNil(t, result.WhereConditionTree)
+	assert.Equal(t, ConditionOr, result.WhereConditionTree.Op)
+	require.Len(t, result.WhereConditionTree.Children, 2)
+	assert.Equal(t, "orders", result.WhereConditionTree.Children[0].Conditions[0].Table)
+	assert.Equal(t, "users", result.WhereConditionTree.Children[1].Conditions[0].Table)
+}
@@ -0,0 +1,1293 @@
+// apply.go replays parser DDL actions against the catalog.
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+)
+
+// Apply replays the DDL actions of pq in order. Statements without DDL actions are
+// ignored, as are actions on objects the catalog does not model (functions, triggers,
+// roles, ...). Apply stops at the first action that fails; earlier actions stay applied.
+func (c *Catalog) Apply(pq *postgresparser.ParsedQuery) error {
+	if pq == nil {
+		return nil
+	}
+	for i := range pq.DDLActions {
+		if err := c.applyAction(pq, &pq.DDLActions[i]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ApplySQL parses a statement and applies its DDL actions.
+func (c *Catalog) ApplySQL(sql string) error {
+	pq, err := postgresparser.ParseSQL(sql)
+	if err != nil {
+		return fmt.Errorf("failed to parse SQL: %w", err)
+	}
+	return c.Apply(pq)
+}
+
+// applyAction dispatches a single DDL action of pq.
+func (c *Catalog) applyAction(pq *postgresparser.ParsedQuery, a *postgresparser.DDLAction) error {
+	switch a.Type {
+	case postgresparser.DDLCreateSchema:
+		return c.createSchema(a)
+	case postgresparser.DDLCreateTable, postgresparser.DDLCreateForeignTable, postgresparser.DDLCreateView:
+		return c.createTable(a, pq)
+	case postgresparser.DDLCreateSequence:
+		return c.createSequence(a)
+	case postgresparser.DDLCreateIndex:
+		return c.createIndex(a)
+	case postgresparser.DDLCreateType, postgresparser.DDLCreateDomain:
+		return c.createType(a)
+	case postgresparser.DDLDropTable:
+		return c.dropTable(a)
+	case postgresparser.DDLDropIndex:
+		return c.dropIndex(a)
+	case postgresparser.DDLDropColumn:
+		return c.dropColumns(a)
+	case postgresparser.DDLAlterTable:
+		return c.alterTable(a)
+	case postgresparser.DDLComment:
+		return c.comment(a)
+	case postgresparser.DDLDrop:
+		switch a.ObjectKind {
+		case postgresparser.DDLObjectSchema:
+			return c.dropSchema(a)
+		case postgresparser.DDLObjectForeignTable, postgresparser.DDLObjectView, postgresparser.DDLObjectMaterializedView:
+			return c.dropTable(a)
+		case postgresparser.DDLObjectSequence:
+			return c.dropSequence(a)
+		case postgresparser.DDLObjectType, postgresparser.DDLObjectDomain:
+			return c.dropType(a)
+		}
+	case postgresparser.DDLAlter:
+		switch a.ObjectKind {
+		case postgresparser.DDLObjectForeignTable, postgresparser.DDLObjectView, postgresparser.DDLObjectMaterializedView:
+			return c.alterTable(a)
+		case postgresparser.DDLObjectSequence:
+			return c.alterSequence(a)
+		case postgresparser.DDLObjectIndex:
+			return c.alterIndex(a)
+		case postgresparser.DDLObjectSchema:
+			return c.alterSchema(a)
+		case postgresparser.DDLObjectType, postgresparser.DDLObjectDomain:
+			return c.alterType(a)
+		}
+	}
+	return nil
+}
+
+// hasFlag reports whether the action carries flag.
+func hasFlag(a *postgresparser.DDLAction, flag string) bool {
+	return slices.Contains(a.Flags, flag)
+}
+
+// lookupSchema resolves a possibly empty schema name.
+func (c *Catalog) lookupSchema(name string) (*Schema, error) {
+	name = normalizeIdent(schemaOrDefault(name))
+	s := c.schemas[name]
+	if s == nil {
+		return nil, fmt.Errorf("schema %s: %w", name, ErrObjectNotFound)
+	}
+	return s, nil
+}
+
+// lookupTable resolves a possibly schema-qualified table.
+func (c *Catalog) lookupTable(schema, name string) (*Schema, *Table, error) {
+	s, err := c.lookupSchema(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	t := s.Table(name)
+	if t == nil {
+		return s, nil, fmt.Errorf("table %s.%s: %w", s.Name, normalizeIdent(name), ErrObjectNotFound)
+	}
+	return s, t, nil
+}
+
+// relationExists reports whether a table, view, index, or sequence in s already uses name.
+func (s *Schema) relationExists(name string) bool {
+	return s.tables[name] != nil || s.indexes[name] != nil || s.sequences[name] != nil
+}
+
+func (c *Catalog) createSchema(a *postgresparser.DDLAction) error {
+	name := normalizeIdent(a.ObjectName)
+	if c.schemas[name] != nil {
+		if hasFlag(a, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("schema %s: %w", name, ErrObjectExists)
+	}
+	s := newSchema(name)
+	s.Owner = normalizeIdent(a.Owner)
+	c.schemas[name] = s
+	return nil
+}
+
+// createTable handles CREATE TABLE, CREATE FOREIGN TABLE, CREATE TABLE AS, and
+// CREATE [MATERIALIZED] VIEW. Columns of the query-based forms are derived from the
+// query of pq, and LIKE clauses copy the columns of their tables. A statement that
+// fails leaves no table behind.
+func (c *Catalog) createTable(a *postgresparser.DDLAction, pq *postgresparser.ParsedQuery) error {
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	kind := postgresparser.DDLObjectTable
+	switch a.Type {
+	case postgresparser.DDLCreateForeignTable:
+		kind = postgresparser.DDLObjectForeignTable
+	case postgresparser.DDLCreateView:
+		kind = a.ObjectKind
+	}
+	if existing := s.tables[name]; existing != nil && existing.Kind == postgresparser.DDLObjectView && kind == existing.Kind && hasFlag(a, "OR_REPLACE") {
+		delete(s.tables, name)
+	}
+	if s.relationExists(name) {
+		if hasFlag(a, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("relation %s.%s: %w", s.Name, name, ErrObjectExists)
+	}
+
+	t := &Table{Schema: s.Name, Name: name, Kind: kind}
+	likes := make([]*Table, len(a.LikeTables))
+	for i, like := range a.LikeTables {
+		if _, likes[i], err = c.lookupTable(like.Schema, like.Name); err != nil {
+			return err
+		}
+	}
+	// LIKE columns go where the clause appears among the column definitions.
+	addLikeColumns := func(pos int) {
+		for i, like := range a.LikeTables {
+			if like.Position == pos {
+				t.Columns = append(t.Columns, likeColumns(likes[i], like.Including)...)
+			}
+		}
+	}
+	var sequences []*Sequence
+	for i, parsed := range a.ColumnDetails {
+		addLikeColumns(i)
+		col := newColumn(parsed)
+		t.Columns = append(t.Columns, col)
+		if seq := expandSerial(s, t, col, sequences); seq != nil {
+			sequences = append(sequences, seq)
+		}
+	}
+	addLikeColumns(len(a.ColumnDetails))
+	if len(a.ColumnDetails) == 0 && len(a.LikeTables) == 0 {
+		if t.Columns, err = c.queryColumns(a.Columns, pq); err != nil {
+			return fmt.Errorf("columns of %s: %w", t.QualifiedName(), err)
+		}
+	}
+	s.tables[name] = t
+	for _, seq := range sequences {
+		s.sequences[seq.Name] = seq
+	}
+	if err := c.addTableConstraints(s, t, a, likes); err != nil {
+		// Leave the catalog as it was before the statement.
+		_ = c.removeTable(s, t, false)
+		return err
+	}
+	return nil
+}
+
+// addTableConstraints adds the constraints declared by a CREATE TABLE to its new table
+// t, followed by those its LIKE clauses copy from likes.
+func (c *Catalog) addTableConstraints(s *Schema, t *Table, a *postgresparser.DDLAction, likes []*Table) error {
+	for _, con := range a.Constraints {
+		if err := c.addConstraint(s, t, con); err != nil {
+			return err
+		}
+	}
+	for i, like := range a.LikeTables {
+		if err := c.copyLikeConstraints(s, t, likes[i], like.Including); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// likeColumns copies the columns of src for a LIKE clause. As in PostgreSQL, NOT NULL is
+// always copied, defaults and comments only with the DEFAULTS and COMMENTS options.
+func likeColumns(src *Table, including []string) []*Column {
+	out := make([]*Column, 0, len(src.Columns))
+	for _, col := range src.Columns {
+		copied := &Column{Name: col.Name, Type: col.Type, Nullable: col.Nullable}
+		if slices.Contains(including, "DEFAULTS") {
+			copied.Default = col.Default
+		}
+		if slices.Contains(including, "COMMENTS") {
+			copied.Comment = col.Comment
+		}
+		out = append(out, copied)
+	}
+	return out
+}
+
+// copyLikeConstraints copies the CHECK constraints of src to t with the CONSTRAINTS
+// option, and its primary key, unique and exclusion constraints, and other indexes with
+// the INDEXES option. Copied indexes and index-backed constraints get generated names;
+// foreign keys are never copied.
+func (c *Catalog) copyLikeConstraints(s *Schema, t *Table, src *Table, including []string) error {
+	for _, con := range src.Constraints {
+		copyIt := false
+		switch con.Type {
+		case postgresparser.DDLConstraintCheck:
+			copyIt = slices.Contains(including, "CONSTRAINTS")
+		case postgresparser.DDLConstraintForeignKey:
+		default:
+			copyIt = slices.Contains(including, "INDEXES")
+		}
+		if !copyIt {
+			continue
+		}
+		parsed := postgresparser.DDLConstraint{
+			Type:       con.Type,
+			Columns:    slices.Clone(con.Columns),
+			Expression: con.Expression,
+			NotValid:   con.NotValid,
+		}
+		if con.Type == postgresparser.DDLConstraintCheck {
+			parsed.Name = con.Name
+		}
+		if err := c.addConstraint(s, t, parsed); err != nil {
+			return err
+		}
+	}
+	if !slices.Contains(including, "INDEXES") {
+		return nil
+	}
+	srcSchema := c.schemas[src.Schema]
+	for _, idx := range srcSchema.TableIndexes(src.Name) {
+		if src.Constraint(idx.Name) != nil {
+			continue // Copied with its constraint above.
+		}
+		name := generatedName(t.Name, idx.Columns, "idx", s.relationExists)
+		s.indexes[name] = &Index{
+			Schema:    s.Name,
+			Name:      name,
+			Table:     t.Name,
+			Columns:   slices.Clone(idx.Columns),
+			Elements:  slices.Clone(idx.Elements),
+			Include:   slices.Clone(idx.Include),
+			Predicate: idx.Predicate,
+			Unique:    idx.Unique,
+			Method:    idx.Method,
+		}
+	}
+	return nil
+}
+
+// newColumn converts a parsed column definition.
+func newColumn(col postgresparser.DDLColumn) *Column {
+	return &Column{
+		Name:     normalizeIdent(col.Name),
+		Type:     col.Type,
+		Nullable: col.Nullable,
+		Default:  col.Default,
+	}
+}
+
+// serialTypes maps the serial pseudo-types to the integer type of their column and
+// sequence. An empty sequence type is bigint.
+var serialTypes = map[string][2]string{
+	"smallserial": {"smallint", "smallint"},
+	"serial2":     {"smallint", "smallint"},
+	"serial":      {"integer", "integer"},
+	"serial4":     {"integer", "integer"},
+	"bigserial":   {"bigint", ""},
+	"serial8":     {"bigint", ""},
+}
+
+// expandSerial turns a serial column of t into what PostgreSQL creates for it: a NOT NULL
+// column of the base integer type whose default calls nextval on a new sequence owned by
+// the column. It returns that sequence, named like PostgreSQL names it and not clashing
+// with the relations of s or pending, or nil when col is not serial.
+func expandSerial(s *Schema, t *Table, col *Column, pending []*Sequence) *Sequence {
+	types, ok := serialTypes[strings.TrimPrefix(strings.ToLower(col.Type), "pg_catalog.")]
+	if !ok {
+		return nil
+	}
+	taken := func(name string) bool {
+		return name == t.Name || s.relationExists(name) || slices.ContainsFunc(pending, func(seq *Sequence) bool { return seq.Name == name })
+	}
+	seq := &Sequence{
+		Schema:   s.Name,
+		Name:     generatedName(t.Name, []string{col.Name}, "seq", taken),
+		DataType: types[1],
+		OwnedBy:  t.QualifiedName() + "." + col.Name,
+	}
+	col.Type = types[0]
+	col.Nullable = false
+	col.Default = "nextval('" + strings.ReplaceAll(qualifiedName(s.Name, seq.Name), "'", "''") + "'::regclass)"
+	return seq
+}
+
+// addConstraint adds a parsed constraint to t, naming it if needed and creating the
+// index that backs PRIMARY KEY and UNIQUE constraints.
+func (c *Catalog) addConstraint(s *Schema, t *Table, parsed postgresparser.DDLConstraint) error {
+	con := &Constraint{
+		Name:       normalizeIdent(parsed.Name),
+		Type:       parsed.Type,
+		Columns:    normalizeIdents(parsed.Columns),
+		RefColumns: normalizeIdents(parsed.RefColumns),
+		OnDelete:   parsed.OnDelete,
+		OnUpdate:   parsed.OnUpdate,
+		Expression: parsed.Expression,
+		NotValid:   parsed.NotValid,
+	}
+	for _, col := range con.Columns {
+		if t.Column(col) == nil {
+			return fmt.Errorf("column %s of table %s: %w", col, t.QualifiedName(), ErrObjectNotFound)
+		}
+	}
+
+	if con.Type == postgresparser.DDLConstraintForeignKey {
+		refSchema, ref, err := c.lookupTable(parsed.RefSchema, parsed.RefTable)
+		if err != nil {
+			return err
+		}
+		con.RefSchema, con.RefTable = refSchema.Name, ref.Name
+	}
+
+	if con.Name == "" {
+		taken := func(name string) bool { return t.Constraint(name) != nil || s.relationExists(name) }
+		switch con.Type {
+		case postgresparser.DDLConstraintPrimaryKey:
+			con.Name = generatedName(t.Name, nil, "pkey", taken)
+		case postgresparser.DDLConstraintUnique:
+			con.Name = generatedName(t.Name, con.Columns, "key", taken)
+		case postgresparser.DDLConstraintForeignKey:
+			con.Name = generatedName(t.Name, con.Columns, "fkey", taken)
+		case postgresparser.DDLConstraintCheck:
+			// PostgreSQL names a CHECK after its column only when it references exactly one.
+			var cols []string
+			if refs := checkColumns(t, con.Expression); len(refs) == 1 {
+				cols = refs
+			}
+			con.Name = generatedName(t.Name, cols, "check", taken)
+		default:
+			con.Name = generatedName(t.Name, con.Columns, "excl", taken)
+		}
+	} else if t.Constraint(con.Name) != nil {
+		return fmt.Errorf("constraint %s on table %s: %w", con.Name, t.QualifiedName(), ErrObjectExists)
+	}
+
+	switch con.Type {
+	case postgresparser.DDLConstraintPrimaryKey, postgresparser.DDLConstraintUnique:
+		primary := con.Type == postgresparser.DDLConstraintPrimaryKey
+		if primary && t.PrimaryKey() != nil {
+			return fmt.Errorf("primary key of table %s: %w", t.QualifiedName(), ErrObjectExists)
+		}
+		if s.relationExists(con.Name) {
+			return fmt.Errorf("index %s.%s: %w", s.Name, con.Name, ErrObjectExists)
+		}
+		s.indexes[con.Name] = &Index{
+			Schema:  s.Name,
+			Name:    con.Name,
+			Table:   t.Name,
+			Columns: slices.Clone(con.Columns),
+			Unique:  true,
+			Primary: primary,
+		}
+		if primary {
+			for _, col := range con.Columns {
+				t.Column(col).Nullable = false
+			}
+		}
+	}
+	t.Constraints = append(t.Constraints, con)
+	return nil
+}
+
+func (c *Catalog) createIndex(a *postgresparser.DDLAction) error {
+	s, t, err := c.lookupTable(a.Schema, a.Table)
+	if err != nil {
+		return err
+	}
+	columns := make([]string, 0, len(a.Columns))
+	for _, col := range a.Columns {
+		columns = append(columns, indexColumn(col))
+	}
+	name := normalizeIdent(a.ObjectName)
+	if name == "" {
+		name = generatedName(t.Name, columns, "idx", s.relationExists)
+	} else if s.relationExists(name) {
+		if hasFlag(a, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("index %s.%s: %w", s.Name, name, ErrObjectExists)
+	}
+	s.indexes[name] = &Index{
+		Schema:    s.Name,
+		Name:      name,
+		Table:     t.Name,
+		Columns:   columns,
+		Elements:  slices.Clone(a.Columns),
+		Include:   normalizeIdents(a.IncludeColumns),
+		Predicate: a.Predicate,
+		Unique:    hasFlag(a, "UNIQUE"),
+		Method:    strings.ToLower(a.IndexType),
+	}
+	return nil
+}
+
+func (c *Catalog) createType(a *postgresparser.DDLAction) error {
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	typ := &Type{Schema: s.Name, Name: name}
+	switch {
+	case a.Type == postgresparser.DDLCreateDomain:
+		typ.Kind = TypeKindDomain
+		typ.BaseType = a.DataType
+		typ.NotNull = hasFlag(a, "NOT_NULL")
+		if len(a.ColumnDetails) > 0 {
+			typ.Default = a.ColumnDetails[0].Default
+		}
+		for _, con := range a.Constraints {
+			typ.Checks = append(typ.Checks, con.Expression)
+		}
+	case hasFlag(a, "ENUM"):
+		typ.Kind = TypeKindEnum
+		typ.Values = slices.Clone(a.EnumValues)
+	case hasFlag(a, "COMPOSITE"):
+		typ.Kind = TypeKindComposite
+		for _, col := range a.ColumnDetails {
+			typ.Attributes = append(typ.Attributes, newColumn(col))
+		}
+	case hasFlag(a, "RANGE"):
+		typ.Kind = TypeKindRange
+	case hasFlag(a, "SHELL"):
+		typ.Kind = TypeKindShell
+	default:
+		typ.Kind = TypeKindBase
+	}
+	// A shell type is a placeholder that the full CREATE TYPE replaces.
+	if existing := s.types[name]; existing != nil && (existing.Kind != TypeKindShell || typ.Kind == TypeKindShell) {
+		return fmt.Errorf("type %s.%s: %w", s.Name, name, ErrObjectExists)
+	}
+	s.types[name] = typ
+	return nil
+}
+
+func (c *Catalog) dropSchema(a *postgresparser.DDLAction) error {
+	name := normalizeIdent(a.ObjectName)
+	s := c.schemas[name]
+	if s == nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("schema %s: %w", name, ErrObjectNotFound)
+	}
+	if len(s.tables)+len(s.sequences)+len(s.types) > 0 && !hasFlag(a, "CASCADE") {
+		return fmt.Errorf("schema %s: %w", name, ErrDependentObjects)
+	}
+	for _, t := range s.Tables() {
+		if err := c.removeTable(s, t, true); err != nil {
+			return err
+		}
+	}
+	delete(c.schemas, name)
+	return nil
+}
+
+// dropTable handles DROP TABLE, DROP FOREIGN TABLE, and DROP [MATERIALIZED] VIEW. As in
+// PostgreSQL, the statement must name the kind of relation it drops.
+func (c *Catalog) dropTable(a *postgresparser.DDLAction) error {
+	s, t, err := c.lookupTable(a.Schema, a.ObjectName)
+	if err != nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+	kind := a.ObjectKind
+	if a.Type == postgresparser.DDLDropTable {
+		kind = postgresparser.DDLObjectTable
+	}
+	if t.Kind != kind && !(kind == postgresparser.DDLObjectTable && t.Kind == postgresparser.DDLObjectForeignTable) {
+		return fmt.Errorf("%s %s is a %s: %w", strings.ToLower(string(kind)), t.QualifiedName(), strings.ToLower(string(t.Kind)), ErrObjectNotFound)
+	}
+	return c.removeTable(s, t, hasFlag(a, "CASCADE"))
+}
+
+// removeTable drops t with its indexes and the sequences it owns. Foreign keys of other
+// tables that reference t are dropped with cascade and block the drop otherwise.
+func (c *Catalog) removeTable(s *Schema, t *Table, cascade bool) error {
+	deps := c.foreignKeysReferencing(t, func(fk ForeignKey) bool { return fk.Table != t })
+	if err := c.dropForeignKeys(deps, cascade, "table "+t.QualifiedName()); err != nil {
+		return err
+	}
+	for _, idx := range s.TableIndexes(t.Name) {
+		delete(s.indexes, idx.Name)
+	}
+	c.dropOwnedSequences(t.QualifiedName() + ".")
+	delete(s.tables, t.Name)
+	return nil
+}
+
+// moveOwnedSequences rewrites the owning column of sequences after a table or column
+// rename. Prefixes follow dropOwnedSequences.
+func (c *Catalog) moveOwnedSequences(oldPrefix, newPrefix string) {
+	for _, s := range c.schemas {
+		for _, seq := range s.sequences {
+			if rest, ok := strings.CutPrefix(seq.OwnedBy, oldPrefix); ok && (strings.HasSuffix(oldPrefix, ".") || rest == "") {
+				seq.OwnedBy = newPrefix + rest
+			}
+		}
+	}
+}
+
+// dropOwnedSequences drops the sequences owned by a column of a table, given as
+// "schema.table.", or by a single column, given as "schema.table.column".
+func (c *Catalog) dropOwnedSequences(prefix string) {
+	for _, s := range c.schemas {
+		for name, seq := range s.sequences {
+			if rest, ok := strings.CutPrefix(seq.OwnedBy, prefix); ok && (strings.HasSuffix(prefix, ".") || rest == "") {
+				delete(s.sequences, name)
+			}
+		}
+	}
+}
+
+func (c *Catalog) dropIndex(a *postgresparser.DDLAction) error {
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	idx := s.indexes[name]
+	if idx == nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("index %s.%s: %w", s.Name, name, ErrObjectNotFound)
+	}
+	if t := s.tables[idx.Table]; t != nil && t.Constraint(idx.Name) != nil {
+		// The index backs a PRIMARY KEY or UNIQUE constraint; drop the constraint instead.
+		return fmt.Errorf("index %s.%s is required by a constraint: %w", s.Name, name, ErrDependentObjects)
+	}
+	delete(s.indexes, name)
+	return nil
+}
+
+func (c *Catalog) dropType(a *postgresparser.DDLAction) error {
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	if s.types[name] == nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("type %s.%s: %w", s.Name, name, ErrObjectNotFound)
+	}
+	delete(s.types, name)
+	return nil
+}
+
+func (c *Catalog) dropColumns(a *postgresparser.DDLAction) error {
+	s, t, err := c.lookupTable(a.Schema, a.ObjectName)
+	if err != nil {
+		return err
+	}
+	for _, raw := range a.Columns {
+		col := t.Column(raw)
+		if col == nil {
+			if hasFlag(a, "IF_EXISTS") {
+				continue
+			}
+			return fmt.Errorf("column %s of table %s: %w", normalizeIdent(raw), t.QualifiedName(), ErrObjectNotFound)
+		}
+		if err := c.removeColumn(s, t, col, hasFlag(a, "CASCADE")); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// removeColumn drops col from t together with the table's constraints and indexes that
+// use it. Foreign keys referencing the column are dropped with cascade and block the
+// drop otherwise.
+func (c *Catalog) removeColumn(s *Schema, t *Table, col *Column, cascade bool) error {
+	pk := t.PrimaryKey()
+	deps := c.foreignKeysReferencing(t, func(fk ForeignKey) bool {
+		refCols := fk.Constraint.RefColumns
+		if len(refCols) == 0 && pk != nil {
+			refCols = pk.Columns
+		}
+		return slices.Contains(refCols, col.Name)
+	})
+	if err := c.dropForeignKeys(deps, cascade, "column "+col.Name+" of table "+t.QualifiedName()); err != nil {
+		return err
+	}
+	for _, con := range slices.Clone(t.Constraints) {
+		if slices.Contains(con.Columns, col.Name) {
+			s.removeConstraint(t, con)
+		}
+	}
+	for _, idx := range s.TableIndexes(t.Name) {
+		if slices.Contains(idx.Columns, col.Name) {
+			delete(s.indexes, idx.Name)
+		}
+	}
+	c.dropOwnedSequences(t.QualifiedName() + "." + col.Name)
+	t.Columns = slices.DeleteFunc(t.Columns, func(other *Column) bool { return other == col })
+	return nil
+}
+
+// foreignKeysReferencing returns the foreign keys that reference t and satisfy match.
+func (c *Catalog) foreignKeysReferencing(t *Table, match func(ForeignKey) bool) []ForeignKey {
+	var out []ForeignKey
+	for _, fk := range c.ForeignKeys() {
+		if fk.Constraint.RefSchema == t.Schema && fk.Constraint.RefTable == t.Name && match(fk) {
+			out = append(out, fk)
+		}
+	}
+	return out
+}
+
+// dropForeignKeys removes deps when cascading and reports ErrDependentObjects otherwise.
+func (c *Catalog) dropForeignKeys(deps []ForeignKey, cascade bool, what string) error {
+	if len(deps) == 0 {
+		return nil
+	}
+	if !cascade {
+		return fmt.Errorf("%s is referenced by constraint %s on table %s: %w",
+			what, deps[0].Constraint.Name, deps[0].Table.QualifiedName(), ErrDependentObjects)
+	}
+	for _, fk := range deps {
+		c.schemas[fk.Table.Schema].removeConstraint(fk.Table, fk.Constraint)
+	}
+	return nil
+}
+
+// removeConstraint deletes con from t along with its backing index.
+func (s *Schema) removeConstraint(t *Table, con *Constraint) {
+	t.Constraints = slices.DeleteFunc(t.Constraints, func(other *Constraint) bool { return other == con })
+	if idx := s.indexes[con.Name]; idx != nil && idx.Table == t.Name {
+		delete(s.indexes, con.Name)
+	}
+}
+
+// alterTable applies an ALTER TABLE or ALTER FOREIGN TABLE sub-command.
+func (c *Catalog) alterTable(a *postgresparser.DDLAction) error {
+	s, t, err := c.lookupTable(a.Schema, a.ObjectName)
+	if err != nil && a.Type == postgresparser.DDLAlterTable && s != nil && s.Sequence(a.ObjectName) != nil {
+		// pg_dump changes the owner of sequences with ALTER TABLE.
+		return c.alterSequence(a)
+	}
+	if err != nil {
+		// For DROP CONSTRAINT, IF_EXISTS applies to the constraint rather than the table.
+		if hasFlag(a, "IF_EXISTS") && !hasFlag(a, "DROP_CONSTRAINT") {
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case hasFlag(a, "ADD_COLUMN"):
+		return c.addColumn(a, s, t)
+	case hasFlag(a, "ALTER_COLUMN"):
+		return alterColumn(a, t)
+	case hasFlag(a, "ADD_CONSTRAINT"):
+		for _, con := range a.Constraints {
+			if err := c.addConstraint(s, t, con); err != nil {
+				return err
+			}
+		}
+	case hasFlag(a, "DROP_CONSTRAINT"):
+		return c.dropConstraint(a, s, t)
+	case hasFlag(a, "VALIDATE_CONSTRAINT"):
+		con, err := constraintOf(a, t)
+		if err != nil {
+			return err
+		}
+		con.NotValid = false
+	case hasFlag(a, "RENAME"):
+		return c.renameTable(a, s, t)
+	case hasFlag(a, "RENAME_COLUMN"):
+		return c.renameColumn(a, t)
+	case hasFlag(a, "RENAME_CONSTRAINT"):
+		return renameConstraint(a, s, t)
+	case hasFlag(a, "SET_SCHEMA"):
+		return c.setTableSchema(a, s, t)
+	case hasFlag(a, "OWNER_TO"):
+		t.Owner = normalizeIdent(a.Owner)
+	case hasFlag(a, "ENABLE_ROW_LEVEL_SECURITY"):
+		t.RowLevelSecurity = true
+	case hasFlag(a, "DISABLE_ROW_LEVEL_SECURITY"):
+		t.RowLevelSecurity = false
+	case hasFlag(a, "FORCE_ROW_LEVEL_SECURITY"):
+		t.ForceRowSecurity = true
+	case hasFlag(a, "NO_FORCE_ROW_LEVEL_SECURITY"):
+		t.ForceRowSecurity = false
+	}
+	return nil
+}
+
+func (c *Catalog) addColumn(a *postgresparser.DDLAction, s *Schema, t *Table) error {
+	for _, parsed := range a.ColumnDetails {
+		col := newColumn(parsed)
+		if t.Column(col.Name) != nil {
+			if hasFlag(a, "IF_NOT_EXISTS") {
+				return nil
+			}
+			return fmt.Errorf("column %s of table %s: %w", col.Name, t.QualifiedName(), ErrObjectExists)
+		}
+		t.Columns = append(t.Columns, col)
+		if seq := expandSerial(s, t, col, nil); seq != nil {
+			s.sequences[seq.Name] = seq
+		}
+	}
+	for _, con := range a.Constraints {
+		if err := c.addConstraint(s, t, con); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// alterColumn applies an ALTER COLUMN sub-command.
+func alterColumn(a *postgresparser.DDLAction, t *Table) error {
+	if len(a.Columns) == 0 {
+		return nil
+	}
+	col := t.Column(a.Columns[0])
+	if col == nil {
+		return fmt.Errorf("column %s of table %s: %w", normalizeIdent(a.Columns[0]), t.QualifiedName(), ErrObjectNotFound)
+	}
+	var detail postgresparser.DDLColumn
+	if len(a.ColumnDetails) > 0 {
+		detail = a.ColumnDetails[0]
+	}
+	switch {
+	case hasFlag(a, "SET_TYPE"):
+		col.Type = detail.Type
+	case hasFlag(a, "SET_DEFAULT"):
+		col.Default = detail.Default
+	case hasFlag(a, "DROP_DEFAULT"):
+		col.Default = ""
+	case hasFlag(a, "SET_NOT_NULL"), hasFlag(a, "ADD_IDENTITY"):
+		col.Nullable = false
+	case hasFlag(a, "DROP_NOT_NULL"):
+		col.Nullable = true
+	}
+	return nil
+}
+
+// constraintOf returns the existing constraint named by a.
+func constraintOf(a *postgresparser.DDLAction, t *Table) (*Constraint, error) {
+	if len(a.Constraints) == 0 {
+		return nil, fmt.Errorf("constraint on table %s: %w", t.QualifiedName(), ErrObjectNotFound)
+	}
+	name := normalizeIdent(a.Constraints[0].Name)
+	con := t.Constraint(name)
+	if con == nil {
+		return nil, fmt.Errorf("constraint %s on table %s: %w", name, t.QualifiedName(), ErrObjectNotFound)
+	}
+	return con, nil
+}
+
+func (c *Catalog) dropConstraint(a *postgresparser.DDLAction, s *Schema, t *Table) error {
+	con, err := constraintOf(a, t)
+	if err != nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+	if con.Type == postgresparser.DDLConstraintPrimaryKey || con.Type == postgresparser.DDLConstraintUnique {
+		deps := c.foreignKeysReferencing(t, func(fk ForeignKey) bool {
+			if len(fk.Constraint.RefColumns) == 0 {
+				return con.Type == postgresparser.DDLConstraintPrimaryKey
+			}
+			return sameColumns(fk.Constraint.RefColumns, con.Columns)
+		})
+		if err := c.dropForeignKeys(deps, hasFlag(a, "CASCADE"), "constraint "+con.Name+" on table "+t.QualifiedName()); err != nil {
+			return err
+		}
+	}
+	s.removeConstraint(t, con)
+	return nil
+}
+
+// sameColumns reports whether a and b hold the same columns in any order.
+func sameColumns(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for _, col := range a {
+		if !slices.Contains(b, col) {
+			return false
+		}
+	}
+	return true
+}
+
+func (c *Catalog) renameTable(a *postgresparser.DDLAction, s *Schema, t *Table) error {
+	newName := normalizeIdent(a.NewName)
+	if s.relationExists(newName) {
+		return fmt.Errorf("table %s.%s: %w", s.Name, newName, ErrObjectExists)
+	}
+	for _, fk := range c.foreignKeysReferencing(t, func(ForeignKey) bool { return true }) {
+		fk.Constraint.RefTable = newName
+	}
+	for _, idx := range s.TableIndexes(t.Name) {
+		idx.Table = newName
+	}
+	c.moveOwnedSequences(t.QualifiedName()+".", s.Name+"."+newName+".")
+	delete(s.tables, t.Name)
+	t.Name = newName
+	s.tables[newName] = t
+	return nil
+}
+
+func (c *Catalog) renameColumn(a *postgresparser.DDLAction, t *Table) error {
+	if len(a.Columns) == 0 {
+		return nil
+	}
+	col := t.Column(a.Columns[0])
+	if col == nil {
+		return fmt.Errorf("column %s of table %s: %w", normalizeIdent(a.Columns[0]), t.QualifiedName(), ErrObjectNotFound)
+	}
+	oldName, newName := col.Name, normalizeIdent(a.NewName)
+	if t.Column(newName) != nil {
+		return fmt.Errorf("column %s of table %s: %w", newName, t.QualifiedName(), ErrObjectExists)
+	}
+	rename := func(cols []string) {
+		for i := range cols {
+			if cols[i] == oldName {
+				cols[i] = newName
+			}
+		}
+	}
+	for _, con := range t.Constraints {
+		rename(con.Columns)
+	}
+	for _, idx := range c.schemas[t.Schema].TableIndexes(t.Name) {
+		for i, elem := range idx.Elements {
+			if indexColumn(elem) == oldName {
+				idx.Elements[i] = renameIndexElement(elem, newName)
+			}
+		}
+		rename(idx.Columns)
+		rename(idx.Include)
+	}
+	for _, fk := range c.foreignKeysReferencing(t, func(ForeignKey) bool { return true }) {
+		rename(fk.Constraint.RefColumns)
+	}
+	c.moveOwnedSequences(t.QualifiedName()+"."+oldName, t.QualifiedName()+"."+newName)
+	col.Name = newName
+	return nil
+}
+
+func renameConstraint(a *postgresparser.DDLAction, s *Schema, t *Table) error {
+	con, err := constraintOf(a, t)
+	if err != nil {
+		return err
+	}
+	newName := normalizeIdent(a.NewName)
+	if t.Constraint(newName) != nil {
+		return fmt.Errorf("constraint %s on table %s: %w", newName, t.QualifiedName(), ErrObjectExists)
+	}
+	if idx := s.indexes[con.Name]; idx != nil && idx.Table == t.Name {
+		if s.relationExists(newName) {
+			return fmt.Errorf("index %s.%s: %w", s.Name, newName, ErrObjectExists)
+		}
+		delete(s.indexes, idx.Name)
+		idx.Name = newName
+		s.indexes[newName] = idx
+	}
+	con.Name = newName
+	return nil
+}
+
+func (c *Catalog) setTableSchema(a *postgresparser.DDLAction, s *Schema, t *Table) error {
+	target, err := c.lookupSchema(a.NewSchema)
+	if err != nil {
+		return err
+	}
+	if target == s {
+		return nil
+	}
+	if target.relationExists(t.Name) {
+		return fmt.Errorf("table %s.%s: %w", target.Name, t.Name, ErrObjectExists)
+	}
+	indexes := s.TableIndexes(t.Name)
+	for _, idx := range indexes {
+		if target.relationExists(idx.Name) {
+			return fmt.Errorf("index %s.%s: %w", target.Name, idx.Name, ErrObjectExists)
+		}
+	}
+	var sequences []*Sequence
+	for _, seq := range s.sequences {
+		if strings.HasPrefix(seq.OwnedBy, t.QualifiedName()+".") {
+			if target.relationExists(seq.Name) {
+				return fmt.Errorf("sequence %s.%s: %w", target.Name, seq.Name, ErrObjectExists)
+			}
+			sequences = append(sequences, seq)
+		}
+	}
+	for _, fk := range c.foreignKeysReferencing(t, func(ForeignKey) bool { return true }) {
+		fk.Constraint.RefSchema = target.Name
+	}
+	// Indexes and owned sequences move with their table.
+	for _, idx := range indexes {
+		delete(s.indexes, idx.Name)
+		idx.Schema = target.Name
+		target.indexes[idx.Name] = idx
+	}
+	for _, seq := range sequences {
+		delete(s.sequences, seq.Name)
+		seq.Schema = target.Name
+		target.sequences[seq.Name] = seq
+	}
+	c.moveOwnedSequences(t.QualifiedName()+".", target.Name+"."+t.Name+".")
+	delete(s.tables, t.Name)
+	t.Schema = target.Name
+	target.tables[t.Name] = t
+	return nil
+}
+
+// alterIndex applies ALTER INDEX ... RENAME. Renaming the index of a PRIMARY KEY or
+// UNIQUE constraint renames the constraint as well.
+func (c *Catalog) alterIndex(a *postgresparser.DDLAction) error {
+	if !hasFlag(a, "RENAME") {
+		return nil
+	}
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	idx := s.indexes[name]
+	if idx == nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("index %s.%s: %w", s.Name, name, ErrObjectNotFound)
+	}
+	newName := normalizeIdent(a.NewName)
+	if s.relationExists(newName) {
+		return fmt.Errorf("index %s.%s: %w", s.Name, newName, ErrObjectExists)
+	}
+	if t := s.tables[idx.Table]; t != nil {
+		if con := t.Constraint(name); con != nil {
+			con.Name = newName
+		}
+	}
+	delete(s.indexes, name)
+	idx.Name = newName
+	s.indexes[newName] = idx
+	return nil
+}
+
+// alterSchema applies ALTER SCHEMA ... RENAME TO and OWNER TO.
+func (c *Catalog) alterSchema(a *postgresparser.DDLAction) error {
+	s, err := c.lookupSchema(a.ObjectName)
+	if err != nil {
+		return err
+	}
+	switch {
+	case hasFlag(a, "OWNER_TO"):
+		s.Owner = normalizeIdent(a.Owner)
+	case hasFlag(a, "RENAME"):
+		newName := normalizeIdent(a.NewName)
+		if c.schemas[newName] != nil {
+			return fmt.Errorf("schema %s: %w", newName, ErrObjectExists)
+		}
+		for _, fk := range c.ForeignKeys() {
+			if fk.Constraint.RefSchema == s.Name {
+				fk.Constraint.RefSchema = newName
+			}
+		}
+		for _, t := range s.tables {
+			t.Schema = newName
+		}
+		for _, idx := range s.indexes {
+			idx.Schema = newName
+		}
+		for _, seq := range s.sequences {
+			seq.Schema = newName
+		}
+		for _, typ := range s.types {
+			typ.Schema = newName
+		}
+		c.moveOwnedSequences(s.Name+".", newName+".")
+		delete(c.schemas, s.Name)
+		s.Name = newName
+		c.schemas[newName] = s
+	}
+	return nil
+}
+
+// alterType applies ALTER TYPE / ALTER DOMAIN renames, schema moves, and enum changes.
+func (c *Catalog) alterType(a *postgresparser.DDLAction) error {
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	typ := s.types[name]
+	if typ == nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("type %s.%s: %w", s.Name, name, ErrObjectNotFound)
+	}
+
+	switch {
+	case hasFlag(a, "ADD_VALUE"):
+		return addEnumValue(a, typ)
+	case hasFlag(a, "RENAME_VALUE"):
+		if len(a.EnumValues) == 0 {
+			return nil
+		}
+		i := slices.Index(typ.Values, a.EnumValues[0])
+		if i < 0 {
+			return fmt.Errorf("enum label %q of type %s.%s: %w", a.EnumValues[0], s.Name, name, ErrObjectNotFound)
+		}
+		if slices.Contains(typ.Values, a.NewName) {
+			return fmt.Errorf("enum label %q of type %s.%s: %w", a.NewName, s.Name, name, ErrObjectExists)
+		}
+		typ.Values[i] = a.NewName
+	case hasFlag(a, "RENAME"):
+		newName := normalizeIdent(a.NewName)
+		if s.types[newName] != nil {
+			return fmt.Errorf("type %s.%s: %w", s.Name, newName, ErrObjectExists)
+		}
+		delete(s.types, name)
+		typ.Name = newName
+		s.types[newName] = typ
+	case hasFlag(a, "SET_SCHEMA"):
+		target, err := c.lookupSchema(a.NewSchema)
+		if err != nil {
+			return err
+		}
+		if target == s {
+			return nil
+		}
+		if target.types[name] != nil {
+			return fmt.Errorf("type %s.%s: %w", target.Name, name, ErrObjectExists)
+		}
+		delete(s.types, name)
+		typ.Schema = target.Name
+		target.types[name] = typ
+	}
+	return nil
+}
+
+// addEnumValue inserts the label of ALTER TYPE ... ADD VALUE at its BEFORE/AFTER position.
+func addEnumValue(a *postgresparser.DDLAction, typ *Type) error {
+	if len(a.EnumValues) == 0 {
+		return nil
+	}
+	label := a.EnumValues[0]
+	if slices.Contains(typ.Values, label) {
+		if hasFlag(a, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("enum label %q of type %s.%s: %w", label, typ.Schema, typ.Name, ErrObjectExists)
+	}
+	pos := len(typ.Values)
+	if len(a.Objects) > 0 {
+		i := slices.Index(typ.Values, a.Objects[0])
+		if i < 0 {
+			return fmt.Errorf("enum label %q of type %s.%s: %w", a.Objects[0], typ.Schema, typ.Name, ErrObjectNotFound)
+		}
+		pos = i
+		if hasFlag(a, "AFTER") {
+			pos = i + 1
+		}
+	}
+	typ.Values = slices.Insert(typ.Values, pos, label)
+	return nil
+}
+
+// lookupSequence resolves a possibly schema-qualified sequence.
+func (c *Catalog) lookupSequence(schema, name string) (*Schema, *Sequence, error) {
+	s, err := c.lookupSchema(schema)
+	if err != nil {
+		return nil, nil, err
+	}
+	seq := s.Sequence(name)
+	if seq == nil {
+		return s, nil, fmt.Errorf("sequence %s.%s: %w", s.Name, normalizeIdent(name), ErrObjectNotFound)
+	}
+	return s, seq, nil
+}
+
+func (c *Catalog) createSequence(a *postgresparser.DDLAction) error {
+	s, err := c.lookupSchema(a.Schema)
+	if err != nil {
+		return err
+	}
+	name := normalizeIdent(a.ObjectName)
+	if s.relationExists(name) {
+		if hasFlag(a, "IF_NOT_EXISTS") {
+			return nil
+		}
+		return fmt.Errorf("relation %s.%s: %w", s.Name, name, ErrObjectExists)
+	}
+	seq := &Sequence{Schema: s.Name, Name: name}
+	if err := c.setSequenceOptions(seq, a.Options); err != nil {
+		return err
+	}
+	s.sequences[name] = seq
+	return nil
+}
+
+// setSequenceOptions applies the options of CREATE or ALTER SEQUENCE that the catalog tracks.
+func (c *Catalog) setSequenceOptions(seq *Sequence, opts []postgresparser.DDLOption) error {
+	for _, opt := range opts {
+		switch opt.Name {
+		case "AS":
+			seq.DataType = opt.Value
+		case "START":
+			seq.Start = opt.Value
+		case "INCREMENT":
+			seq.Increment = opt.Value
+		case "OWNED BY":
+			if strings.EqualFold(opt.Value, "NONE") {
+				seq.OwnedBy = ""
+				continue
+			}
+			parts, ok := sqlident.SplitChain(opt.Value)
+			if !ok || len(parts) < 2 {
+				return fmt.Errorf("owned by %s: %w", opt.Value, ErrObjectNotFound)
+			}
+			col := parts[len(parts)-1]
+			_, t, err := c.lookupTable(strings.Join(parts[:len(parts)-2], "."), parts[len(parts)-2])
+			if err != nil {
+				return err
+			}
+			if t.Column(col) == nil {
+				return fmt.Errorf("column %s of table %s: %w", normalizeIdent(col), t.QualifiedName(), ErrObjectNotFound)
+			}
+			seq.OwnedBy = t.QualifiedName() + "." + normalizeIdent(col)
+		}
+	}
+	return nil
+}
+
+func (c *Catalog) dropSequence(a *postgresparser.DDLAction) error {
+	s, seq, err := c.lookupSequence(a.Schema, a.ObjectName)
+	if err != nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+	delete(s.sequences, seq.Name)
+	return nil
+}
+
+// alterSequence handles ALTER SEQUENCE, and ALTER TABLE on a sequence as emitted by pg_dump.
+func (c *Catalog) alterSequence(a *postgresparser.DDLAction) error {
+	s, seq, err := c.lookupSequence(a.Schema, a.ObjectName)
+	if err != nil {
+		if hasFlag(a, "IF_EXISTS") {
+			return nil
+		}
+		return err
+	}
+
+	switch {
+	case hasFlag(a, "OWNER_TO"):
+		seq.Owner = normalizeIdent(a.Owner)
+	case hasFlag(a, "RENAME"):
+		newName := normalizeIdent(a.NewName)
+		if s.relationExists(newName) {
+			return fmt.Errorf("relation %s.%s: %w", s.Name, newName, ErrObjectExists)
+		}
+		delete(s.sequences, seq.Name)
+		seq.Name = newName
+		s.sequences[newName] = seq
+	case hasFlag(a, "SET_SCHEMA"):
+		target, err := c.lookupSchema(a.NewSchema)
+		if err != nil {
+			return err
+		}
+		if target == s {
+			return nil
+		}
+		if target.relationExists(seq.Name) {
+			return fmt.Errorf("relation %s.%s: %w", target.Name, seq.Name, ErrObjectExists)
+		}
+		delete(s.sequences, seq.Name)
+		seq.Schema = target.Name
+		target.sequences[seq.Name] = seq
+	default:
+		return c.setSequenceOptions(seq, a.Options)
+	}
+	return nil
+}
+
+// comment applies COMMENT ON SCHEMA, TABLE, FOREIGN TABLE, COLUMN, TYPE, and DOMAIN.
+func (c *Catalog) comment(a *postgresparser.DDLAction) error {
+	switch a.ObjectKind {
+	case postgresparser.DDLObjectSchema:
+		s, err := c.lookupSchema(a.ObjectName)
+		if err != nil {
+			return err
+		}
+		s.Comment = a.Comment
+	case postgresparser.DDLObjectTable, postgresparser.DDLObjectForeignTable,
+		postgresparser.DDLObjectView, postgresparser.DDLObjectMaterializedView:
+		_, t, err := c.lookupTable(a.Schema, a.ObjectName)
+		if err != nil {
+			return err
+		}
+		t.Comment = a.Comment
+	case postgresparser.DDLObjectSequence:
+		_, seq, err := c.lookupSequence(a.Schema, a.ObjectName)
+		if err != nil {
+			return err
+		}
+		seq.Comment = a.Comment
+	case postgresparser.DDLObjectColumn:
+		_, t, err := c.lookupTable(a.Schema, a.ObjectName)
+		if err != nil {
+			return err
+		}
+		for _, raw := range a.Columns {
+			col := t.Column(raw)
+			if col == nil {
+				return fmt.Errorf("column %s of table %s: %w", normalizeIdent(raw), t.QualifiedName(), ErrObjectNotFound)
+			}
+			col.Comment = a.Comment
+		}
+	case postgresparser.DDLObjectType, postgresparser.DDLObjectDomain:
+		s, err := c.lookupSchema(a.Schema)
+		if err != nil {
+			return err
+		}
+		typ := s.Type(a.ObjectName)
+		if typ == nil {
+			return fmt.Errorf("type %s.%s: %w", s.Name, normalizeIdent(a.ObjectName), ErrObjectNotFound)
+		}
+		typ.Comment = a.Comment
+	}
+	return nil
+}
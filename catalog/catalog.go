@@ -0,0 +1,397 @@
+// Package catalog maintains an in-memory model of a PostgreSQL schema built by
+// replaying the DDL actions extracted by the parser.
+//
+// A Catalog starts with an empty "public" schema. Apply (or ApplySQL) replays the
+// DDLActions of a ParsedQuery in order, creating, altering, renaming, and dropping
+// schemas, tables, views, columns, constraints, indexes, sequences, and types as
+// PostgreSQL would. ApplyScript and Load replay whole scripts such as the output of
+// pg_dump --schema-only.
+// The resulting model can be inspected directly or exported with ColumnSchemas for
+// the schema-aware functions of the analysis package. BuildDependencyGraph records
+// how the objects declared by DDL depend on each other, including the functions and
+// triggers the model leaves out.
+//
+// Identifiers follow PostgreSQL folding rules: unquoted names are lower-cased and
+// quoted names keep their case without the quotes.
+package catalog
+
+import (
+	"errors"
+	"sort"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// DefaultSchema is the schema used for unqualified object names.
+const DefaultSchema = "public"
+
+// Sentinel errors returned by Apply. They are wrapped with the name of the object involved.
+var (
+	// ErrObjectExists is returned when creating an object whose name is already taken.
+	ErrObjectExists = errors.New("object already exists")
+
+	// ErrObjectNotFound is returned when altering or dropping an object that does not exist.
+	ErrObjectNotFound = errors.New("object not found")
+
+	// ErrDependentObjects is returned when dropping an object that others depend on without CASCADE.
+	ErrDependentObjects = errors.New("other objects depend on it")
+)
+
+// Catalog is an in-memory set of schemas. The zero value is not usable; call New.
+type Catalog struct {
+	schemas map[string]*Schema
+}
+
+// Schema is a namespace holding tables, views, indexes, sequences, and types.
+type Schema struct {
+	Name    string
+	Owner   string
+	Comment string
+
+	tables    map[string]*Table
+	indexes   map[string]*Index
+	sequences map[string]*Sequence
+	types     map[string]*Type
+}
+
+// Table is a regular or foreign table, or a view. The columns of a view, or of a table
+// created with CREATE TABLE AS, are derived from the defining query; their Type is empty
+// unless the output column is a plain reference to a column the catalog knows.
+type Table struct {
+	Schema           string
+	Name             string
+	Kind             postgresparser.DDLObjectKind // TABLE, FOREIGN TABLE, VIEW, or MATERIALIZED VIEW
+	Columns          []*Column                    // In declaration order
+	Constraints      []*Constraint                // In declaration order
+	Owner            string
+	Comment          string
+	RowLevelSecurity bool // ENABLE ROW LEVEL SECURITY
+	ForceRowSecurity bool // FORCE ROW LEVEL SECURITY
+}
+
+// Column is a table column or a composite type attribute.
+type Column struct {
+	Name     string
+	Type     string // Type as written in the DDL, e.g. "varchar(255)"; serial types become their integer type
+	Nullable bool
+	Default  string
+	Comment  string
+}
+
+// Constraint is a table constraint. Unnamed constraints receive the name PostgreSQL
+// would generate, e.g. orders_pkey or orders_user_id_fkey.
+type Constraint struct {
+	Name       string
+	Type       postgresparser.DDLConstraintType
+	Columns    []string
+	RefSchema  string   // Referenced schema (FOREIGN KEY)
+	RefTable   string   // Referenced table (FOREIGN KEY)
+	RefColumns []string // Referenced columns; empty means the referenced primary key
+	OnDelete   string
+	OnUpdate   string
+	Expression string // CHECK expression, or EXCLUDE definition
+	NotValid   bool
+}
+
+// Index is an index on a table, including the implicit index of a PRIMARY KEY or
+// UNIQUE constraint.
+type Index struct {
+	Schema    string
+	Name      string
+	Table     string   // Indexed table in the same schema
+	Columns   []string // Column names or index expressions
+	Elements  []string // Elements as written in CREATE INDEX, with collation, operator class, and ordering
+	Include   []string // INCLUDE columns
+	Predicate string   // WHERE predicate of a partial index
+	Unique    bool
+	Primary   bool   // Backs the table's primary key
+	Method    string // Access method as written, e.g. gin; empty when omitted
+}
+
+// Sequence is a sequence generator.
+type Sequence struct {
+	Schema    string
+	Name      string
+	DataType  string // AS data type; empty means bigint
+	Start     string // START WITH value as written
+	Increment string // INCREMENT BY value as written
+	OwnedBy   string // Owning column as schema.table.column; empty when the sequence is not owned
+	Owner     string
+	Comment   string
+}
+
+// TypeKind classifies a user-defined type.
+type TypeKind string
+
+const (
+	TypeKindEnum      TypeKind = "ENUM"
+	TypeKindComposite TypeKind = "COMPOSITE"
+	TypeKindRange     TypeKind = "RANGE"
+	TypeKindBase      TypeKind = "BASE"
+	TypeKindShell     TypeKind = "SHELL"
+	TypeKindDomain    TypeKind = "DOMAIN"
+)
+
+// Type is a user-defined type or domain.
+type Type struct {
+	Schema     string
+	Name       string
+	Kind       TypeKind
+	Values     []string  // Enum labels in sort order
+	Attributes []*Column // Composite attributes
+	BaseType   string    // Underlying type of a domain
+	NotNull    bool      // Domain declared NOT NULL
+	Default    string    // Domain default
+	Checks     []string  // Domain CHECK expressions
+	Comment    string
+}
+
+// New returns a catalog containing the empty default schema.
+func New() *Catalog {
+	c := &Catalog{schemas: make(map[string]*Schema)}
+	c.schemas[DefaultSchema] = newSchema(DefaultSchema)
+	return c
+}
+
+func newSchema(name string) *Schema {
+	return &Schema{
+		Name:      name,
+		tables:    make(map[string]*Table),
+		indexes:   make(map[string]*Index),
+		sequences: make(map[string]*Sequence),
+		types:     make(map[string]*Type),
+	}
+}
+
+// Schema returns the named schema, or nil if it does not exist.
+func (c *Catalog) Schema(name string) *Schema {
+	return c.schemas[normalizeIdent(name)]
+}
+
+// Schemas returns all schemas sorted by name.
+func (c *Catalog) Schemas() []*Schema {
+	out := make([]*Schema, 0, len(c.schemas))
+	for _, s := range c.schemas {
+		out = append(out, s)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Table returns the named table, or nil if it does not exist. An empty schema means DefaultSchema.
+func (c *Catalog) Table(schema, name string) *Table {
+	s := c.Schema(schemaOrDefault(schema))
+	if s == nil {
+		return nil
+	}
+	return s.Table(name)
+}
+
+// Tables returns every table sorted by schema and name.
+func (c *Catalog) Tables() []*Table {
+	var out []*Table
+	for _, s := range c.Schemas() {
+		out = append(out, s.Tables()...)
+	}
+	return out
+}
+
+// Index returns the named index, or nil if it does not exist. An empty schema means DefaultSchema.
+func (c *Catalog) Index(schema, name string) *Index {
+	s := c.Schema(schemaOrDefault(schema))
+	if s == nil {
+		return nil
+	}
+	return s.Index(name)
+}
+
+// Sequence returns the named sequence, or nil if it does not exist. An empty schema means DefaultSchema.
+func (c *Catalog) Sequence(schema, name string) *Sequence {
+	s := c.Schema(schemaOrDefault(schema))
+	if s == nil {
+		return nil
+	}
+	return s.Sequence(name)
+}
+
+// Type returns the named type or domain, or nil if it does not exist. An empty schema means DefaultSchema.
+func (c *Catalog) Type(schema, name string) *Type {
+	s := c.Schema(schemaOrDefault(schema))
+	if s == nil {
+		return nil
+	}
+	return s.Type(name)
+}
+
+// SearchPath returns a search path over schemas that resolves unqualified names to the
+// schemas of the catalog holding them. With no schemas, the default search_path is used.
+func (c *Catalog) SearchPath(schemas ...string) analysis.SearchPath {
+	if len(schemas) == 0 {
+		schemas = analysis.DefaultSearchPath
+	}
+	return analysis.SearchPath{
+		Schemas: schemas,
+		Exists: func(schema, name string) bool {
+			s := c.Schema(quoteIdent(schema))
+			name = quoteIdent(name)
+			return s != nil && (s.Table(name) != nil || s.Sequence(name) != nil || s.Index(name) != nil || s.Type(name) != nil)
+		},
+		HasSchema: func(name string) bool { return c.Schema(quoteIdent(name)) != nil },
+	}
+}
+
+// ForeignKeys returns the foreign key constraints of every table, each paired with the
+// table that declares it, in the order of Tables.
+func (c *Catalog) ForeignKeys() []ForeignKey {
+	var out []ForeignKey
+	for _, t := range c.Tables() {
+		for _, con := range t.ForeignKeys() {
+			out = append(out, ForeignKey{Table: t, Constraint: con})
+		}
+	}
+	return out
+}
+
+// ForeignKey pairs a FOREIGN KEY constraint with the table that declares it.
+type ForeignKey struct {
+	Table      *Table
+	Constraint *Constraint
+}
+
+// Table returns the named table in the schema, or nil if it does not exist.
+func (s *Schema) Table(name string) *Table {
+	return s.tables[normalizeIdent(name)]
+}
+
+// Tables returns the schema's tables sorted by name.
+func (s *Schema) Tables() []*Table {
+	out := make([]*Table, 0, len(s.tables))
+	for _, t := range s.tables {
+		out = append(out, t)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Index returns the named index in the schema, or nil if it does not exist.
+func (s *Schema) Index(name string) *Index {
+	return s.indexes[normalizeIdent(name)]
+}
+
+// Indexes returns the schema's indexes sorted by name.
+func (s *Schema) Indexes() []*Index {
+	out := make([]*Index, 0, len(s.indexes))
+	for _, idx := range s.indexes {
+		out = append(out, idx)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Sequence returns the named sequence in the schema, or nil if it does not exist.
+func (s *Schema) Sequence(name string) *Sequence {
+	return s.sequences[normalizeIdent(name)]
+}
+
+// Sequences returns the schema's sequences sorted by name.
+func (s *Schema) Sequences() []*Sequence {
+	out := make([]*Sequence, 0, len(s.sequences))
+	for _, seq := range s.sequences {
+		out = append(out, seq)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// Type returns the named type or domain in the schema, or nil if it does not exist.
+func (s *Schema) Type(name string) *Type {
+	return s.types[normalizeIdent(name)]
+}
+
+// Types returns the schema's types and domains sorted by name.
+func (s *Schema) Types() []*Type {
+	out := make([]*Type, 0, len(s.types))
+	for _, typ := range s.types {
+		out = append(out, typ)
+	}
+	sort.Slice(out, func(i, j int) bool { return out[i].Name < out[j].Name })
+	return out
+}
+
+// TableIndexes returns the indexes defined on the named table, sorted by name.
+func (s *Schema) TableIndexes(table string) []*Index {
+	table = normalizeIdent(table)
+	var out []*Index
+	for _, idx := range s.Indexes() {
+		if idx.Table == table {
+			out = append(out, idx)
+		}
+	}
+	return out
+}
+
+// Column returns the named column, or nil if the table has no such column.
+func (t *Table) Column(name string) *Column {
+	name = normalizeIdent(name)
+	for _, col := range t.Columns {
+		if col.Name == name {
+			return col
+		}
+	}
+	return nil
+}
+
+// Constraint returns the named constraint, or nil if the table has no such constraint.
+func (t *Table) Constraint(name string) *Constraint {
+	name = normalizeIdent(name)
+	for _, con := range t.Constraints {
+		if con.Name == name {
+			return con
+		}
+	}
+	return nil
+}
+
+// PrimaryKey returns the table's PRIMARY KEY constraint, or nil if it has none.
+func (t *Table) PrimaryKey() *Constraint {
+	for _, con := range t.Constraints {
+		if con.Type == postgresparser.DDLConstraintPrimaryKey {
+			return con
+		}
+	}
+	return nil
+}
+
+// ForeignKeys returns the table's FOREIGN KEY constraints in declaration order.
+func (t *Table) ForeignKeys() []*Constraint {
+	var out []*Constraint
+	for _, con := range t.Constraints {
+		if con.Type == postgresparser.DDLConstraintForeignKey {
+			out = append(out, con)
+		}
+	}
+	return out
+}
+
+// QualifiedName returns schema.name.
+func (t *Table) QualifiedName() string {
+	return t.Schema + "." + t.Name
+}
+
+// IsView reports whether t is a view or materialized view.
+func (t *Table) IsView() bool {
+	return t.Kind == postgresparser.DDLObjectView || t.Kind == postgresparser.DDLObjectMaterializedView
+}
+
+// QualifiedName returns schema.name.
+func (seq *Sequence) QualifiedName() string {
+	return seq.Schema + "." + seq.Name
+}
+
+func schemaOrDefault(schema string) string {
+	if schema == "" {
+		return DefaultSchema
+	}
+	return schema
+}
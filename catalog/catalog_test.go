@@ -0,0 +1,564 @@
+package catalog
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// applyAll replays each statement against a fresh catalog and fails on the first error.
+func applyAll(t *testing.T, stmts ...string) *Catalog {
+	t.Helper()
+	c := New()
+	for _, sql := range stmts {
+		require.NoError(t, c.ApplySQL(sql), "apply %q", sql)
+	}
+	return c
+}
+
+func TestCatalog_CreateTable(t *testing.T) {
+	c := applyAll(t,
+		`CREATE TABLE users (id bigint PRIMARY KEY, email text NOT NULL UNIQUE, "DisplayName" text DEFAULT 'anon')`,
+	)
+
+	tbl := c.Table("", "USERS")
+	require.NotNil(t, tbl, "expected users table")
+	assert.Equal(t, "public", tbl.Schema, "schema mismatch")
+	assert.Equal(t, postgresparser.DDLObjectTable, tbl.Kind, "kind mismatch")
+	require.Len(t, tbl.Columns, 3, "column count mismatch")
+	assert.Equal(t, &Column{Name: "id", Type: "bigint"}, tbl.Columns[0], "id column mismatch")
+	assert.Equal(t, &Column{Name: "DisplayName", Type: "text", Nullable: true, Default: "'anon'"}, tbl.Columns[2], "quoted column mismatch")
+	assert.Nil(t, tbl.Column("displayname"), "quoted column must keep its case")
+
+	require.NotNil(t, tbl.PrimaryKey(), "expected primary key")
+	assert.Equal(t, "users_pkey", tbl.PrimaryKey().Name, "generated pkey name mismatch")
+	assert.NotNil(t, tbl.Constraint("users_email_key"), "expected generated unique constraint name")
+
+	pkIndex := c.Index("", "users_pkey")
+	require.NotNil(t, pkIndex, "expected primary key index")
+	assert.True(t, pkIndex.Primary && pkIndex.Unique, "pkey index must be primary and unique")
+	assert.NotNil(t, c.Index("", "users_email_key"), "expected unique index")
+}
+
+func TestCatalog_CreateTableFailureLeavesNoTable(t *testing.T) {
+	c := applyAll(t, "CREATE TABLE users (id bigint PRIMARY KEY)")
+	err := c.ApplySQL("CREATE TABLE orders (id serial PRIMARY KEY, user_id bigint REFERENCES missing (id))")
+	require.ErrorIs(t, err, ErrObjectNotFound, "foreign key to a missing table")
+	assert.Nil(t, c.Table("", "orders"), "failed CREATE TABLE must not leave a table")
+	assert.Nil(t, c.Index("", "orders_pkey"), "failed CREATE TABLE must not leave its indexes")
+	assert.Nil(t, c.Sequence("", "orders_id_seq"), "failed CREATE TABLE must not leave its sequences")
+
+	require.ErrorIs(t, c.ApplySQL("CREATE TABLE orders (id bigint, UNIQUE (missing))"), ErrObjectNotFound, "constraint on a missing column")
+	assert.Nil(t, c.Table("", "orders"), "failed CREATE TABLE must not leave a table")
+	require.NoError(t, c.ApplySQL("CREATE TABLE orders (id serial PRIMARY KEY)"), "the name is free again")
+}
+
+func TestCatalog_CreateTableLike(t *testing.T) {
+	c := applyAll(t,
+		"CREATE TABLE users (id bigint PRIMARY KEY, email text NOT NULL DEFAULT '' CHECK (email <> 'x'), org_id bigint)",
+		"CREATE INDEX ON users (org_id)",
+		"COMMENT ON COLUMN users.email IS 'login'",
+		"CREATE TABLE plain (note text, LIKE users)",
+		"CREATE TABLE copy (LIKE users INCLUDING ALL, note text)",
+	)
+
+	assert.Equal(t, []*Column{
+		{Name: "note", Type: "text", Nullable: true},
+		{Name: "id", Type: "bigint"},
+		{Name: "email", Type: "text"},
+		{Name: "org_id", Type: "bigint", Nullable: true},
+	}, c.Table("", "plain").Columns, "LIKE copies names, types, and NOT NULL")
+	assert.Empty(t, c.Table("", "plain").Constraints, "LIKE without options copies no constraints")
+
+	cp := c.Table("", "copy")
+	assert.Equal(t, []*Column{
+		{Name: "id", Type: "bigint"},
+		{Name: "email", Type: "text", Default: "''", Comment: "login"},
+		{Name: "org_id", Type: "bigint", Nullable: true},
+		{Name: "note", Type: "text", Nullable: true},
+	}, cp.Columns, "INCLUDING ALL copies defaults and comments")
+	var names []string
+	for _, con := range cp.Constraints {
+		names = append(names, con.Name)
+	}
+	assert.ElementsMatch(t, []string{"copy_pkey", "users_email_check"}, names, "copied constraints")
+	assert.NotNil(t, c.Index("", "copy_pkey"), "primary key index")
+	assert.NotNil(t, c.Index("", "copy_org_id_idx"), "copied index")
+
+	assert.ErrorIs(t, c.ApplySQL("CREATE TABLE broken (LIKE missing)"), ErrObjectNotFound, "LIKE of a missing table")
+}
+
+func TestCatalog_GeneratedNames(t *testing.T) {
+	long := strings.Repeat("a", 40)
+	c := applyAll(t,
+		`CREATE TABLE users (
+    email text CHECK (length(email) > 3),
+    lo int,
+    hi int CHECK (lo <= hi),
+    CHECK (users.email <> ''),
+    CHECK (lo::text <> 'x')
+)`,
+		"CREATE TABLE "+long+" ("+long+"_column int UNIQUE, CHECK ("+long+"_column > 0))",
+	)
+
+	var names []string
+	for _, con := range c.Table("", "users").Constraints {
+		names = append(names, con.Name)
+	}
+	assert.Equal(t, []string{"users_email_check", "users_check", "users_email_check1", "users_lo_check"}, names, "CHECK names come from the columns they reference")
+
+	long63 := strings.Repeat("a", 29) + "_" + strings.Repeat("a", 29) + "_key"
+	assert.Len(t, long63, 63, "test name length")
+	assert.NotNil(t, c.Table("", long).Constraint(long63), "generated names are truncated to 63 bytes")
+	assert.NotNil(t, c.Index("", long63), "index of the truncated constraint")
+	assert.NotNil(t, c.Table("", long).Constraint(strings.Repeat("a", 28)+"_"+strings.Repeat("a", 28)+"_check"), "truncation keeps the label")
+}
+
+func TestCatalog_ForeignKeys(t *testing.T) {
+	c := applyAll(t,
+		"CREATE TABLE users (id bigint PRIMARY KEY)",
+		"CREATE TABLE orders (id bigint PRIMARY KEY, user_id bigint REFERENCES users ON DELETE CASCADE)",
+	)
+
+	fks := c.ForeignKeys()
+	require.Len(t, fks, 1, "foreign key count mismatch")
+	assert.Equal(t, "orders", fks[0].Table.Name, "referencing table mismatch")
+	assert.Equal(t, &Constraint{
+		Name:      "orders_user_id_fkey",
+		Type:      postgresparser.DDLConstraintForeignKey,
+		Columns:   []string{"user_id"},
+		RefSchema: "public",
+		RefTable:  "users",
+		OnDelete:  "CASCADE",
+	}, fks[0].Constraint, "foreign key mismatch")
+
+	err := c.ApplySQL("CREATE TABLE items (order_id bigint REFERENCES missing (id))")
+	assert.ErrorIs(t, err, ErrObjectNotFound, "expected missing referenced table error")
+}
+
+func TestCatalog_AlterTable(t *testing.T) {
+	c := applyAll(t,
+		"CREATE TABLE users (id bigint, email text)",
+		"ALTER TABLE users ADD COLUMN status text NOT NULL DEFAULT 'new'",
+		"ALTER TABLE users ADD PRIMARY KEY (id)",
+		"ALTER TABLE users ALTER COLUMN email TYPE varchar(320)",
+		"ALTER TABLE users ALTER COLUMN email SET NOT NULL",
+		"ALTER TABLE users ALTER COLUMN status DROP DEFAULT",
+		"ALTER TABLE users RENAME COLUMN email TO email_address",
+		"ALTER TABLE users OWNER TO app_owner",
+		"ALTER TABLE users ENABLE ROW LEVEL SECURITY",
+		"COMMENT ON COLUMN users.status IS 'lifecycle state'",
+		"ALTER TABLE users RENAME TO accounts",
+	)
+
+	assert.Nil(t, c.Table("", "users"), "users should have been renamed")
+	tbl := c.Table("public", "accounts")
+	require.NotNil(t, tbl, "expected accounts table")
+	assert.Equal(t, &Column{Name: "email_address", Type: "varchar(320)"}, tbl.Column("email_address"), "email column mismatch")
+	assert.Equal(t, &Column{Name: "status", Type: "text", Comment: "lifecycle state"}, tbl.Column("status"), "status column mismatch")
+	assert.False(t, tbl.Column("id").Nullable, "primary key column must be NOT NULL")
+	assert.Equal(t, "app_owner", tbl.Owner, "owner mismatch")
+	assert.True(t, tbl.RowLevelSecurity, "expected row level security")
+
+	idx := c.Index("", "users_pkey")
+	require.NotNil(t, idx, "pkey index keeps its name across a table rename")
+	assert.Equal(t, "accounts", idx.Table, "index table not renamed")
+}
+
+func TestCatalog_RenameUpdatesForeignKeys(t *testing.T) {
+	c := applyAll(t,
+		"CREATE SCHEMA app",
+		"CREATE TABLE users (id bigint PRIMARY KEY)",
+		"CREATE TABLE app.orders (user_id bigint, CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES users (id))",
+		"ALTER TABLE users RENAME COLUMN id TO user_id",
+		"ALTER TABLE users RENAME TO customers",
+		"ALTER TABLE customers SET SCHEMA app",
+		"ALTER TABLE app.orders RENAME CONSTRAINT orders_user_fk TO orders_customer_fk",
+	)
+
+	orders := c.Table("app", "orders")
+	require.NotNil(t, orders, "expected app.orders")
+	fk := orders.Constraint("orders_customer_fk")
+	require.NotNil(t, fk, "expected renamed constraint")
+	assert.Equal(t, "app", fk.RefSchema, "referenced schema not updated")
+	assert.Equal(t, "customers", fk.RefTable, "referenced table not updated")
+	assert.Equal(t, []string{"user_id"}, fk.RefColumns, "referenced column not updated")
+	assert.NotNil(t, c.Index("app", "users_pkey"), "index should move with its table")
+}
+
+func TestCatalog_DropDependencies(t *testing.T) {
+	setup := []string{
+		"CREATE TABLE users (id bigint PRIMARY KEY, email text)",
+		"CREATE TABLE orders (id bigint, user_id bigint REFERENCES users)",
+		"CREATE INDEX ON orders (user_id)",
+	}
+
+	c := applyAll(t, setup...)
+	assert.NotNil(t, c.Index("", "orders_user_id_idx"), "expected generated index name")
+	assert.ErrorIs(t, c.ApplySQL("DROP TABLE users"), ErrDependentObjects, "expected dependent foreign key")
+	assert.ErrorIs(t, c.ApplySQL("ALTER TABLE users DROP CONSTRAINT users_pkey"), ErrDependentObjects, "expected dependent foreign key")
+	assert.ErrorIs(t, c.ApplySQL("DROP INDEX users_pkey"), ErrDependentObjects, "index backs a constraint")
+
+	require.NoError(t, c.ApplySQL("DROP TABLE users CASCADE"), "drop with cascade")
+	assert.Nil(t, c.Table("", "users"), "users should be dropped")
+	assert.Nil(t, c.Index("", "users_pkey"), "table indexes should be dropped")
+	assert.Empty(t, c.ForeignKeys(), "cascade should drop referencing foreign keys")
+	assert.NotNil(t, c.Table("", "orders"), "referencing table survives cascade")
+
+	c = applyAll(t, setup...)
+	require.NoError(t, c.ApplySQL("ALTER TABLE orders DROP COLUMN user_id"), "drop column")
+	assert.Empty(t, c.ForeignKeys(), "constraints on a dropped column are dropped")
+	assert.Nil(t, c.Index("", "orders_user_id_idx"), "indexes on a dropped column are dropped")
+}
+
+func TestCatalog_IfExistsAndErrors(t *testing.T) {
+	c := applyAll(t,
+		"CREATE TABLE users (id bigint)",
+		"CREATE TABLE IF NOT EXISTS users (other text)",
+		"DROP TABLE IF EXISTS missing",
+		"DROP INDEX IF EXISTS missing_idx",
+		"ALTER TABLE users DROP COLUMN IF EXISTS missing",
+		"ALTER TABLE users DROP CONSTRAINT IF EXISTS missing",
+		"ALTER TABLE IF EXISTS missing RENAME TO other",
+		"ALTER TABLE users ADD COLUMN IF NOT EXISTS id bigint",
+	)
+	require.Len(t, c.Table("", "users").Columns, 1, "IF NOT EXISTS must not modify the table")
+
+	assert.ErrorIs(t, c.ApplySQL("CREATE TABLE users (id bigint)"), ErrObjectExists)
+	assert.ErrorIs(t, c.ApplySQL("ALTER TABLE users ADD COLUMN id bigint"), ErrObjectExists)
+	assert.ErrorIs(t, c.ApplySQL("DROP TABLE missing"), ErrObjectNotFound)
+	assert.ErrorIs(t, c.ApplySQL("ALTER TABLE missing ADD COLUMN a int"), ErrObjectNotFound)
+	assert.ErrorIs(t, c.ApplySQL("CREATE TABLE nowhere.t (a int)"), ErrObjectNotFound)
+	assert.ErrorIs(t, c.ApplySQL("DROP SCHEMA public"), ErrDependentObjects)
+}
+
+func TestCatalog_SchemasAndTypes(t *testing.T) {
+	c := applyAll(t,
+		"CREATE SCHEMA app AUTHORIZATION app_owner",
+		"CREATE TYPE app.mood AS ENUM ('sad', 'happy')",
+		"ALTER TYPE app.mood ADD VALUE 'ok' BEFORE 'happy'",
+		"ALTER TYPE app.mood RENAME VALUE 'sad' TO 'blue'",
+		"CREATE TYPE app.pair AS (a int, b text)",
+		"CREATE DOMAIN app.posint AS integer NOT NULL CHECK (VALUE > 0)",
+		"CREATE TABLE app.people (id app.posint, mood app.mood)",
+		"ALTER SCHEMA app RENAME TO core",
+	)
+
+	require.Nil(t, c.Schema("app"), "app should have been renamed")
+	s := c.Schema("core")
+	require.NotNil(t, s, "expected core schema")
+	assert.Equal(t, "app_owner", s.Owner, "owner mismatch")
+
+	mood := c.Type("core", "mood")
+	require.NotNil(t, mood, "expected mood type")
+	assert.Equal(t, TypeKindEnum, mood.Kind, "kind mismatch")
+	assert.Equal(t, []string{"blue", "ok", "happy"}, mood.Values, "enum labels mismatch")
+
+	pair := c.Type("core", "pair")
+	require.NotNil(t, pair, "expected pair type")
+	assert.Equal(t, TypeKindComposite, pair.Kind, "kind mismatch")
+	assert.Len(t, pair.Attributes, 2, "attribute count mismatch")
+
+	domain := c.Type("core", "posint")
+	require.NotNil(t, domain, "expected posint domain")
+	assert.Equal(t, &Type{Schema: "core", Name: "posint", Kind: TypeKindDomain, BaseType: "integer", NotNull: true, Checks: []string{"VALUE > 0"}}, domain)
+
+	people := c.Table("core", "people")
+	require.NotNil(t, people, "expected people table")
+	assert.Equal(t, "core", people.Schema, "table schema not renamed")
+
+	require.NoError(t, c.ApplySQL("DROP SCHEMA core CASCADE"), "drop schema")
+	assert.Nil(t, c.Table("core", "people"), "tables are dropped with their schema")
+	assert.Len(t, c.Schemas(), 1, "only public should remain")
+}
+
+func TestCatalog_ColumnSchemas(t *testing.T) {
+	c := applyAll(t,
+		"CREATE SCHEMA app",
+		"CREATE TABLE customers (id bigint PRIMARY KEY, name text)",
+		"CREATE TABLE app.orders (id bigint PRIMARY KEY, customer_id bigint NOT NULL REFERENCES customers)",
+		"CREATE TABLE app.customers (code text)",
+	)
+
+	schemas := c.ColumnSchemas()
+	assert.Equal(t, []analysis.ColumnSchema{
+		{Name: "id", PGType: "bigint", IsPrimaryKey: true},
+		{Name: "name", PGType: "text", IsNullable: true},
+	}, schemas["customers"], "unqualified name resolves to public first")
+	assert.Equal(t, schemas["customers"], schemas["public.customers"], "qualified key mismatch")
+	assert.Len(t, schemas["app.customers"], 1, "app.customers mismatch")
+	assert.Len(t, schemas["orders"], 2, "unqualified key for a table outside public")
+
+	joins, err := analysis.ExtractJoinRelationshipsWithSchema(
+		"SELECT * FROM orders o JOIN customers c ON o.customer_id = c.id", schemas)
+	require.NoError(t, err, "extract joins")
+	require.Len(t, joins, 1, "join count mismatch")
+	assert.Equal(t, "orders", joins[0].ChildTable, "child table mismatch")
+	assert.Equal(t, "customers", joins[0].ParentTable, "parent table mismatch")
+}
+
+func TestCatalog_ViewsAndSequences(t *testing.T) {
+	c := applyAll(t,
+		"CREATE TABLE users (id int PRIMARY KEY, email text NOT NULL)",
+		"CREATE SEQUENCE users_id_seq AS integer START 10 OWNED BY users.id",
+		"CREATE VIEW user_emails AS SELECT u.id AS user_id, lower(email), email::varchar(64), 1 + 1 FROM users u",
+		"CREATE MATERIALIZED VIEW user_ids (uid) AS SELECT id FROM users",
+		"CREATE TABLE email_copy AS SELECT email FROM users",
+		"COMMENT ON VIEW user_emails IS 'Emails'",
+	)
+
+	view := c.Table("", "user_emails")
+	require.NotNil(t, view, "expected view")
+	assert.True(t, view.IsView(), "expected a view")
+	assert.Equal(t, "Emails", view.Comment, "view comment mismatch")
+	assert.Equal(t, []*Column{
+		{Name: "user_id", Type: "int", Nullable: true},
+		{Name: "lower", Nullable: true},
+		{Name: "email", Type: "varchar(64)", Nullable: true},
+		{Name: "?column?", Nullable: true},
+	}, view.Columns, "view columns mismatch")
+
+	matview := c.Table("", "user_ids")
+	require.NotNil(t, matview, "expected materialized view")
+	assert.Equal(t, postgresparser.DDLObjectMaterializedView, matview.Kind, "kind mismatch")
+	assert.Equal(t, []*Column{{Name: "uid", Type: "int", Nullable: true}}, matview.Columns, "column list overrides names")
+	assert.Equal(t, []*Column{{Name: "email", Type: "text", Nullable: true}}, c.Table("", "email_copy").Columns, "CREATE TABLE AS columns mismatch")
+
+	seq := c.Sequence("", "users_id_seq")
+	require.NotNil(t, seq, "expected sequence")
+	assert.Equal(t, &Sequence{Schema: "public", Name: "users_id_seq", DataType: "integer", Start: "10", OwnedBy: "public.users.id"}, seq, "sequence mismatch")
+
+	// pg_dump sets sequence owners with ALTER TABLE.
+	require.NoError(t, c.ApplySQL("ALTER TABLE users_id_seq OWNER TO app"), "alter table on sequence")
+	assert.Equal(t, "app", seq.Owner, "sequence owner mismatch")
+	require.NoError(t, c.ApplySQL("ALTER TABLE users RENAME TO members"), "rename owning table")
+	assert.Equal(t, "public.members.id", seq.OwnedBy, "owned by must follow the rename")
+	assert.ErrorIs(t, c.ApplySQL("CREATE VIEW users_id_seq AS SELECT 1"), ErrObjectExists, "views and sequences share a namespace")
+
+	// DROP must name the right kind of relation; OR REPLACE replaces a view.
+	assert.ErrorIs(t, c.ApplySQL("DROP TABLE user_emails"), ErrObjectNotFound, "DROP TABLE on a view")
+	require.NoError(t, c.ApplySQL("CREATE OR REPLACE VIEW user_emails AS SELECT id FROM members"), "replace view")
+	assert.Len(t, c.Table("", "user_emails").Columns, 1, "replaced view columns mismatch")
+	require.NoError(t, c.ApplySQL("DROP VIEW user_emails"), "drop view")
+	assert.Nil(t, c.Table("", "user_emails"), "view should be dropped")
+
+	// Dropping the owning table drops the sequence.
+	require.NoError(t, c.ApplySQL("DROP TABLE members"), "drop owning table")
+	assert.Nil(t, c.Sequence("", "users_id_seq"), "owned sequence should be dropped")
+}
+
+func TestCatalog_ViewStars(t *testing.T) {
+	c := applyAll(t,
+		"CREATE TABLE users (id bigint, email text, org_id bigint)",
+		"CREATE TABLE orgs (id bigint, name text)",
+		"CREATE VIEW v AS SELECT * FROM users",
+		"CREATE VIEW w AS SELECT o.name AS org, u.* FROM users u JOIN orgs o ON o.id = u.org_id",
+		"CREATE TABLE snapshot AS SELECT * FROM v",
+		"CREATE TABLE mirror AS TABLE users",
+	)
+	assert.Equal(t, []*Column{
+		{Name: "id", Type: "bigint", Nullable: true},
+		{Name: "email", Type: "text", Nullable: true},
+		{Name: "org_id", Type: "bigint", Nullable: true},
+	}, c.Table("", "v").Columns, "* expands to the table columns")
+	assert.Equal(t, []*Column{
+		{Name: "org", Type: "text", Nullable: true},
+		{Name: "id", Type: "bigint", Nullable: true},
+		{Name: "email", Type: "text", Nullable: true},
+		{Name: "org_id", Type: "bigint", Nullable: true},
+	}, c.Table("", "w").Columns, "alias.* expands to the aliased table columns")
+	assert.Len(t, c.Table("", "snapshot").Columns, 3, "* over a view expands to its columns")
+	assert.Equal(t, c.Table("", "v").Columns, c.Table("", "mirror").Columns, "TABLE copies the table columns")
+
+	_, err := Describe("SELECT email FROM v WHERE id = $1", c)
+	require.NoError(t, err, "describe a query over a star view")
+
+	err = c.ApplySQL("CREATE VIEW broken AS SELECT * FROM missing")
+	assert.ErrorIs(t, err, analysis.ErrUnknownColumns, "* over a relation the catalog lacks")
+	assert.Nil(t, c.Table("", "broken"), "failed view must not be created")
+}
+
+func TestCatalog_SerialColumns(t *testing.T) {
+	c := applyAll(t,
+		"CREATE SEQUENCE orders_id_seq",
+		"CREATE TABLE orders (id serial PRIMARY KEY, ref BIGSERIAL)",
+		"ALTER TABLE orders ADD COLUMN line smallserial",
+	)
+
+	assert.Equal(t, []*Column{
+		{Name: "id", Type: "integer", Default: "nextval('public.orders_id_seq1'::regclass)"},
+		{Name: "ref", Type: "bigint", Default: "nextval('public.orders_ref_seq'::regclass)"},
+		{Name: "line", Type: "smallint", Default: "nextval('public.orders_line_seq'::regclass)"},
+	}, c.Table("", "orders").Columns, "serial columns mismatch")
+	assert.Equal(t, &Sequence{Schema: "public", Name: "orders_id_seq1", DataType: "integer", OwnedBy: "public.orders.id"}, c.Sequence("", "orders_id_seq1"), "taken sequence names get a number")
+	assert.Equal(t, &Sequence{Schema: "public", Name: "orders_ref_seq", OwnedBy: "public.orders.ref"}, c.Sequence("", "orders_ref_seq"), "bigserial sequence mismatch")
+	assert.Equal(t, &Sequence{Schema: "public", Name: "orders_line_seq", DataType: "smallint", OwnedBy: "public.orders.line"}, c.Sequence("", "orders_line_seq"), "smallserial sequence mismatch")
+
+	require.NoError(t, c.ApplySQL("DROP TABLE orders"), "drop table")
+	assert.Nil(t, c.Sequence("", "orders_ref_seq"), "sequences of serial columns are dropped with their table")
+}
+
+func TestCatalog_SequencesFollowSchemaChanges(t *testing.T) {
+	c := applyAll(t,
+		"CREATE SCHEMA app",
+		"CREATE TABLE app.users (id int)",
+		"CREATE SEQUENCE app.users_id_seq OWNED BY app.users.id",
+		"ALTER SCHEMA app RENAME TO core",
+	)
+	seq := c.Sequence("core", "users_id_seq")
+	require.NotNil(t, seq, "sequence moves with its schema")
+	assert.Equal(t, &Sequence{Schema: "core", Name: "users_id_seq", OwnedBy: "core.users.id"}, seq, "sequence after schema rename")
+	require.NoError(t, c.ApplySQL("DROP TABLE core.users"), "drop owning table")
+	assert.Nil(t, c.Sequence("core", "users_id_seq"), "owned sequence dropped with its table")
+
+	c = applyAll(t,
+		"CREATE SCHEMA archive",
+		"CREATE TABLE orders (id int)",
+		"CREATE SEQUENCE orders_id_seq OWNED BY orders.id",
+		"ALTER TABLE orders SET SCHEMA archive",
+	)
+	assert.Nil(t, c.Sequence("public", "orders_id_seq"), "sequence left in the old schema")
+	assert.Equal(t, &Sequence{Schema: "archive", Name: "orders_id_seq", OwnedBy: "archive.orders.id"}, c.Sequence("archive", "orders_id_seq"), "sequence after SET SCHEMA")
+
+	c = applyAll(t,
+		"CREATE SCHEMA archive",
+		"CREATE SEQUENCE archive.orders_id_seq",
+		"CREATE TABLE orders (id int)",
+		"CREATE SEQUENCE orders_id_seq OWNED BY orders.id",
+	)
+	assert.ErrorIs(t, c.ApplySQL("ALTER TABLE orders SET SCHEMA archive"), ErrObjectExists, "owned sequence name taken in the target schema")
+	assert.NotNil(t, c.Table("public", "orders"), "failed move leaves the table")
+}
+
+func TestCatalog_LoadDump(t *testing.T) {
+	c, err := LoadFile("testdata/schema.sql")
+	require.NoError(t, err, "load pg_dump output")
+
+	billing := c.Schema("billing")
+	require.NotNil(t, billing, "expected billing schema")
+	assert.Equal(t, "app_owner", billing.Owner, "schema owner mismatch")
+
+	orders := c.Table("public", "orders")
+	require.NotNil(t, orders, "expected orders table")
+	assert.Equal(t, "app_owner", orders.Owner, "table owner mismatch")
+	assert.Equal(t, "Customer orders", orders.Comment, "table comment mismatch")
+	assert.True(t, orders.RowLevelSecurity, "expected row level security")
+	assert.Equal(t, "nextval('public.orders_id_seq'::regclass)", orders.Column("id").Default, "ALTER TABLE ONLY default mismatch")
+	assert.Equal(t, []string{"id"}, orders.PrimaryKey().Columns, "primary key mismatch")
+	assert.Equal(t, "Login address", c.Table("", "users").Column("email").Comment, "column comment mismatch")
+	assert.NotNil(t, c.Table("", "users").Column(`"displayName"`), "quoted column mismatch")
+
+	idx := c.Index("public", "orders_user_id_created_at_idx")
+	require.NotNil(t, idx, "expected index")
+	assert.Equal(t, []string{"user_id", "created_at"}, idx.Columns, "index columns mismatch")
+
+	invoice := c.Table("billing", "invoices").Constraint("invoices_order_id_fkey")
+	require.NotNil(t, invoice, "expected cross-schema foreign key")
+	assert.Equal(t, "public", invoice.RefSchema, "referenced schema mismatch")
+	assert.Equal(t, "orders", invoice.RefTable, "referenced table mismatch")
+
+	seq := c.Sequence("public", "orders_id_seq")
+	require.NotNil(t, seq, "expected sequence")
+	assert.Equal(t, "app_owner", seq.Owner, "sequence owner mismatch")
+	assert.Equal(t, "public.orders.id", seq.OwnedBy, "sequence owned by mismatch")
+	assert.Equal(t, []string{"pending", "paid", "shipped"}, c.Type("public", "order_status").Values, "enum values mismatch")
+
+	view := c.Table("public", "paid_orders")
+	require.NotNil(t, view, "expected view")
+	assert.Equal(t, "app_owner", view.Owner, "view owner mismatch")
+	require.Len(t, view.Columns, 4, "view column count mismatch")
+	assert.Equal(t, &Column{Name: "email", Type: "public.citext", Nullable: true}, view.Columns[2], "view column mismatch")
+
+	// The loaded schema drives schema-aware analysis without a database connection.
+	res, err := analysis.ExtractQueryAnalysisWithSchema(
+		"SELECT * FROM billing.invoices i JOIN orders o ON i.order_id = o.id WHERE o.status = $1", c.ColumnSchemas())
+	require.NoError(t, err, "analysis failed")
+	require.Len(t, res.JoinRelationships, 1, "join count mismatch")
+	assert.Equal(t, "orders", res.JoinRelationships[0].ParentTable, "parent table mismatch")
+}
+
+func TestCatalog_ApplyScriptErrors(t *testing.T) {
+	c := New()
+	err := c.ApplyScript("CREATE TABLE a (id int);\nCREATE TABLE b (id int;")
+	var stmtErr *postgresparser.StatementError
+	require.ErrorAs(t, err, &stmtErr, "expected statement error")
+	assert.Equal(t, 2, stmtErr.Line, "error line mismatch")
+	assert.Nil(t, c.Table("", "a"), "a syntax error must leave the catalog unchanged")
+
+	err = c.ApplyScript("SET client_min_messages = warning;\nCREATE TABLE a (id int);\n\n\nCREATE TABLE a (id int);")
+	assert.ErrorIs(t, err, ErrObjectExists, "expected duplicate table")
+	assert.ErrorContains(t, err, "line 5", "error should name the failing statement")
+	assert.NotNil(t, c.Table("", "a"), "statements before the failure stay applied")
+}
+
+func TestCatalog_ApplyScriptSearchPath(t *testing.T) {
+	c := New()
+	require.NoError(t, c.ApplyScript(`
+CREATE SCHEMA tenant_a;
+CREATE TABLE plans (id int PRIMARY KEY);
+SET search_path TO tenant_a, public;
+CREATE TABLE users (id int PRIMARY KEY, plan_id int REFERENCES plans);
+CREATE INDEX users_plan_id_idx ON users (plan_id);
+ALTER TABLE plans ADD COLUMN name text;
+RESET search_path;
+CREATE TABLE events (id int);`), "apply script")
+
+	users := c.Table("tenant_a", "users")
+	require.NotNil(t, users, "table created in the first schema of the path")
+	assert.Equal(t, "public", users.Constraint("users_plan_id_fkey").RefSchema, "reference resolved through the path")
+	assert.NotNil(t, c.Index("tenant_a", "users_plan_id_idx"), "index created in its table's schema")
+	assert.NotNil(t, c.Table("public", "plans").Column("name"), "altered table found through the path")
+	assert.NotNil(t, c.Table("public", "events"), "RESET restores the default path")
+
+	sp := c.SearchPath("tenant_a", "public")
+	assert.Equal(t, "tenant_a", sp.Resolve("users"), "resolve users")
+	assert.Equal(t, "public", sp.Resolve("plans"), "resolve plans")
+	assert.Equal(t, "pg_catalog", sp.Resolve("pg_class"), "resolve system catalog")
+}
+
+func TestCatalog_ApplyScriptSearchPathTableObjects(t *testing.T) {
+	const setup = `
+CREATE SCHEMA app;
+SET search_path = app;
+CREATE TABLE t (c int);
+`
+	c := New()
+	require.NoError(t, c.ApplyScript(setup+"CREATE INDEX ON t (c);"), "apply unnamed index")
+	assert.NotNil(t, c.Index("app", "t_c_idx"), "unnamed index created in its table's schema")
+
+	c = New()
+	require.NoError(t, c.ApplyScript(setup+"COMMENT ON COLUMN t.c IS 'x';"), "apply column comment")
+	assert.Equal(t, "x", c.Table("app", "t").Column("c").Comment, "column comment found through the path")
+}
+
+func TestCatalog_SequenceOwnedBySearchPath(t *testing.T) {
+	c := New()
+	require.NoError(t, c.ApplyScript(`
+CREATE SCHEMA app;
+SET search_path TO app;
+CREATE TABLE t (id int);
+CREATE SEQUENCE s OWNED BY t.id;`), "apply script")
+
+	seq := c.Sequence("app", "s")
+	require.NotNil(t, seq, "sequence created in the first schema of the path")
+	assert.Equal(t, "app.t.id", seq.OwnedBy, "owning table resolved through the path")
+}
+
+func TestCatalog_NormalizeIdent(t *testing.T) {
+	tests := map[string]string{
+		"Users":         "users",
+		`"Users"`:       "Users",
+		`"a""b"`:        `a"b`,
+		"lower(email)":  "lower(email)",
+		" padded ":      "padded",
+		"with$dollar_1": "with$dollar_1",
+	}
+	for in, want := range tests {
+		assert.Equal(t, want, normalizeIdent(in), "normalizeIdent(%q)", in)
+	}
+}
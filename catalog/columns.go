@@ -0,0 +1,110 @@
+// columns.go derives the columns of views and CREATE TABLE AS from their defining query.
+package catalog
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+)
+
+// queryColumns returns the columns produced by the query of pq, a CREATE TABLE AS or
+// CREATE VIEW statement. Names come from names when given, otherwise from the output
+// column alias or the name PostgreSQL would choose. Stars are expanded against the
+// catalog as analysis.ExpandResultColumns does; a star over a relation the catalog does
+// not have fails with analysis.ErrUnknownColumns.
+func (c *Catalog) queryColumns(names []string, pq *postgresparser.ParsedQuery) ([]*Column, error) {
+	var out []*Column
+	if source := pq.Source; source != nil {
+		if slices.ContainsFunc(source.Columns, func(sc postgresparser.SelectColumn) bool { return isStar(sc.Expression) }) {
+			expanded, err := analysis.ExpandResultColumns(pq, c.ColumnSchemas())
+			if err != nil {
+				return nil, err
+			}
+			for _, rc := range expanded {
+				out = append(out, c.queryColumn(rc.Name, rc.Expression, source.Tables))
+			}
+		} else {
+			for _, sc := range source.Columns {
+				out = append(out, c.queryColumn(sqlident.OutputName(sc), sc.Expression, source.Tables))
+			}
+		}
+	}
+	for i, name := range names {
+		if i < len(out) {
+			out[i].Name = normalizeIdent(name)
+		} else {
+			// A column list without a usable query, e.g. CREATE TABLE t (a, b) AS EXECUTE ...
+			out = append(out, &Column{Name: normalizeIdent(name), Nullable: true})
+		}
+	}
+	return out, nil
+}
+
+// queryColumn builds the column name of a query over tables returns for expr, typed by
+// its cast or by the catalog column it references.
+func (c *Catalog) queryColumn(name, expr string, tables []postgresparser.TableRef) *Column {
+	expr = strings.TrimSpace(expr)
+	col := &Column{Name: name, Nullable: true}
+	if _, typ, ok := sqlident.SplitCast(expr); ok {
+		col.Type = typ
+	} else if src := c.resolveColumn(expr, tables); src != nil {
+		col.Type = src.Type
+	}
+	return col
+}
+
+// resolveColumn finds the catalog column a plain column reference in a query over
+// tables points to, or nil when it is not a column reference or cannot be resolved
+// unambiguously.
+func (c *Catalog) resolveColumn(expr string, tables []postgresparser.TableRef) *Column {
+	parts, ok := sqlident.SplitChain(expr)
+	if !ok {
+		return nil
+	}
+	name := parts[len(parts)-1]
+	qualifier := parts[:len(parts)-1]
+
+	var found *Column
+	for _, ref := range tables {
+		if ref.Type != postgresparser.TableTypeBase {
+			continue
+		}
+		if !refMatches(ref, qualifier) {
+			continue
+		}
+		t := c.Table(ref.Schema, ref.Name)
+		if t == nil {
+			continue
+		}
+		if col := t.Column(name); col != nil {
+			if found != nil {
+				return nil
+			}
+			found = col
+		}
+	}
+	return found
+}
+
+// refMatches reports whether a column qualifier (empty, alias or table, or schema.table)
+// designates ref.
+func refMatches(ref postgresparser.TableRef, qualifier []string) bool {
+	switch len(qualifier) {
+	case 0:
+		return true
+	case 1:
+		q := normalizeIdent(qualifier[0])
+		if ref.Alias != "" {
+			return q == normalizeIdent(ref.Alias)
+		}
+		return q == normalizeIdent(ref.Name)
+	case 2:
+		return ref.Alias == "" &&
+			normalizeIdent(qualifier[0]) == normalizeIdent(schemaOrDefault(ref.Schema)) &&
+			normalizeIdent(qualifier[1]) == normalizeIdent(ref.Name)
+	}
+	return false
+}
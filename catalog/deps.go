@@ -0,0 +1,789 @@
+// deps.go builds a dependency graph of the schema objects declared by DDL scripts.
+package catalog
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// ErrDependencyCycle is returned by CreationOrder when objects depend on each other
+// through more than foreign keys and sequence ownership.
+var ErrDependencyCycle = errors.New("dependency cycle")
+
+// ObjectRef identifies a schema object of a DependencyGraph. Names are folded as by
+// the catalog: unquoted names are lower-cased and quoted names lose their quotes.
+type ObjectRef struct {
+	Kind   postgresparser.DDLObjectKind // TABLE, VIEW, MATERIALIZED VIEW, INDEX, SEQUENCE, TYPE, FUNCTION, TRIGGER, ...
+	Schema string
+	Name   string
+	Table  string // Table of a trigger, which is named per table
+}
+
+// String returns the kind and qualified name of the object, e.g. "VIEW public.active_users"
+// or "TRIGGER orders_audit ON public.orders".
+func (r ObjectRef) String() string {
+	if r.Table != "" {
+		return fmt.Sprintf("%s %s ON %s.%s", r.Kind, r.Name, r.Schema, r.Table)
+	}
+	return fmt.Sprintf("%s %s.%s", r.Kind, r.Schema, r.Name)
+}
+
+// DependencyKind describes why one object depends on another.
+type DependencyKind string
+
+const (
+	DependencyQuery           DependencyKind = "QUERY"            // A view's defining query or a SQL function's body reads the relation
+	DependencyForeignKey      DependencyKind = "FOREIGN_KEY"      // A foreign key of the table references the table
+	DependencyIndex           DependencyKind = "INDEX"            // The index is built on the table
+	DependencyTrigger         DependencyKind = "TRIGGER"          // The trigger fires on the table
+	DependencyTriggerFunction DependencyKind = "TRIGGER_FUNCTION" // The trigger executes the function
+	DependencyOwnedBy         DependencyKind = "OWNED_BY"         // The sequence is owned by a column of the table
+	DependencyDefault         DependencyKind = "DEFAULT"          // A column default of the table calls nextval on the sequence
+	DependencyColumnType      DependencyKind = "COLUMN_TYPE"      // A column of the table has the type or domain
+)
+
+// Dependency records that Object depends on On.
+type Dependency struct {
+	Object ObjectRef
+	On     ObjectRef
+	Kind   DependencyKind
+}
+
+// drops reports whether dropping the object depended on drops or breaks the dependent
+// object as a whole. Foreign keys, defaults, and column types only cost the dependent
+// table a constraint, default, or column.
+func (k DependencyKind) drops() bool {
+	switch k {
+	case DependencyForeignKey, DependencyDefault, DependencyColumnType:
+		return false
+	}
+	return true
+}
+
+// soft reports whether CreationOrder may ignore the dependency to break a cycle: the
+// link can be added after both objects exist, as pg_dump does with ALTER TABLE ... ADD
+// CONSTRAINT and ALTER SEQUENCE ... OWNED BY.
+func (k DependencyKind) soft() bool {
+	return k == DependencyForeignKey || k == DependencyOwnedBy
+}
+
+// DependencyGraph records the objects declared by DDL statements and the dependencies
+// between them: views on the relations their queries read, tables on the tables their
+// foreign keys reference, indexes and triggers on their tables, triggers on their
+// functions, LANGUAGE sql functions on the relations their bodies read, sequences on the
+// tables owning them, and tables on the sequences and types their columns use.
+//
+// Objects referenced but never declared, such as tables of another project, are kept
+// as external objects so that the impact of dropping them can still be computed.
+// Functions are identified by name; overloads share one object. Renames are not
+// followed. The zero value is not usable; call NewDependencyGraph.
+type DependencyGraph struct {
+	path    []string
+	objects map[string]*depObject
+	order   []string // Object keys in the order they were first seen
+	deps    []depEdge
+}
+
+// depObject is an object of the graph.
+type depObject struct {
+	ref      ObjectRef
+	declared bool
+}
+
+// depEdge is a dependency between two object keys. Optional edges, such as column
+// types, count only once their target is declared.
+type depEdge struct {
+	from, to string
+	kind     DependencyKind
+	optional bool
+}
+
+// NewDependencyGraph returns an empty graph resolving unqualified names through the
+// default search_path.
+func NewDependencyGraph() *DependencyGraph {
+	return &DependencyGraph{path: analysis.DefaultSearchPath, objects: make(map[string]*depObject)}
+}
+
+// BuildDependencyGraph parses a DDL script, such as the concatenated migrations or
+// schema files of a project, and adds its statements to a new graph in order. Statements
+// may appear in any order; unqualified names are resolved through the search_path the
+// script sets, as by ApplyScript, and otherwise to the first schema of the path.
+func BuildDependencyGraph(sql string) (*DependencyGraph, error) {
+	g := NewDependencyGraph()
+	for _, stmt := range postgresparser.SplitStatements(stripMetaCommands(sql)) {
+		pq, err := postgresparser.ParseSQL(stmt.SQL)
+		if err != nil {
+			if isSessionCommand(stmt.SQL) {
+				continue
+			}
+			return nil, fmt.Errorf("failed to parse SQL: %w", &postgresparser.StatementError{Line: stmt.Line, SQL: stmt.SQL, Err: err})
+		}
+		g.Add(pq)
+	}
+	return g, nil
+}
+
+// Add records the objects pq declares or drops and their dependencies. A statement
+// changing search_path changes how later unqualified names resolve.
+func (g *DependencyGraph) Add(pq *postgresparser.ParsedQuery) {
+	if pq == nil {
+		return
+	}
+	if schemas, ok := analysis.SearchPathSetting(pq.RawSQL); ok {
+		g.path = schemas
+		if schemas == nil {
+			g.path = analysis.DefaultSearchPath
+		}
+		return
+	}
+	analysis.QualifyQuery(pq, g.searchPath())
+	for i := range pq.DDLActions {
+		g.addAction(pq, &pq.DDLActions[i])
+	}
+}
+
+// searchPath returns the current search_path, resolving names to declared objects.
+func (g *DependencyGraph) searchPath() analysis.SearchPath {
+	return analysis.SearchPath{
+		Schemas: g.path,
+		Exists: func(schema, name string) bool {
+			for _, ns := range []string{"rel", "type", "func"} {
+				if o := g.objects[ns+":"+schema+"."+name]; o != nil && o.declared {
+					return true
+				}
+			}
+			return false
+		},
+	}
+}
+
+// addAction records a single DDL action of pq.
+func (g *DependencyGraph) addAction(pq *postgresparser.ParsedQuery, a *postgresparser.DDLAction) {
+	switch a.Type {
+	case postgresparser.DDLCreateTable, postgresparser.DDLCreateForeignTable:
+		kind := postgresparser.DDLObjectTable
+		if a.Type == postgresparser.DDLCreateForeignTable {
+			kind = postgresparser.DDLObjectForeignTable
+		}
+		key := g.declare(ObjectRef{Kind: kind, Schema: a.Schema, Name: a.ObjectName})
+		g.addColumns(key, a)
+	case postgresparser.DDLCreateView:
+		key := g.declare(ObjectRef{Kind: a.ObjectKind, Schema: a.Schema, Name: a.ObjectName})
+		// The scopes of the defining query belong to the statement, so read them there.
+		g.addReads(key, pq)
+	case postgresparser.DDLCreateIndex:
+		key := g.declare(ObjectRef{Kind: postgresparser.DDLObjectIndex, Schema: a.Schema, Name: g.indexName(a)})
+		g.depend(key, g.relation(a.Schema, a.Table), DependencyIndex, false)
+	case postgresparser.DDLCreateSequence:
+		key := g.declare(ObjectRef{Kind: postgresparser.DDLObjectSequence, Schema: a.Schema, Name: a.ObjectName})
+		g.addOwner(key, a.Options)
+	case postgresparser.DDLCreateType, postgresparser.DDLCreateDomain:
+		kind := postgresparser.DDLObjectType
+		if a.Type == postgresparser.DDLCreateDomain {
+			kind = postgresparser.DDLObjectDomain
+		}
+		g.declare(ObjectRef{Kind: kind, Schema: a.Schema, Name: a.ObjectName})
+	case postgresparser.DDLCreateFunction:
+		key := g.declare(ObjectRef{Kind: a.ObjectKind, Schema: a.Schema, Name: a.ObjectName})
+		g.addFunctionBody(key, a.Options)
+	case postgresparser.DDLCreateTrigger:
+		key := g.declare(ObjectRef{Kind: postgresparser.DDLObjectTrigger, Schema: a.Schema, Name: a.ObjectName, Table: a.Table})
+		g.depend(key, g.relation(a.Schema, a.Table), DependencyTrigger, false)
+		for _, opt := range a.Options {
+			if opt.Name == "EXECUTE FUNCTION" {
+				schema, name := splitQualified(opt.Value)
+				if schema == "" {
+					schema = g.searchPath().Resolve(name)
+				}
+				g.depend(key, g.reference(ObjectRef{Kind: postgresparser.DDLObjectFunction, Schema: schema, Name: name}), DependencyTriggerFunction, false)
+			}
+		}
+	case postgresparser.DDLAlterTable:
+		if hasFlag(a, "ADD_COLUMN") || hasFlag(a, "SET_DEFAULT") || hasFlag(a, "SET_TYPE") || hasFlag(a, "ADD_CONSTRAINT") {
+			g.addColumns(g.relation(a.Schema, a.ObjectName), a)
+		}
+	case postgresparser.DDLAlter:
+		if a.ObjectKind == postgresparser.DDLObjectSequence {
+			g.addOwner(refKey(ObjectRef{Kind: postgresparser.DDLObjectSequence, Schema: a.Schema, Name: a.ObjectName}), a.Options)
+		}
+	case postgresparser.DDLDropTable, postgresparser.DDLDropIndex:
+		g.drop(refKey(ObjectRef{Kind: postgresparser.DDLObjectTable, Schema: a.Schema, Name: a.ObjectName}), hasFlag(a, "CASCADE"))
+	case postgresparser.DDLDrop:
+		ref := ObjectRef{Kind: a.ObjectKind, Schema: a.Schema, Name: a.ObjectName}
+		if a.ObjectKind == postgresparser.DDLObjectTrigger {
+			ref.Table = a.Table
+		}
+		if key := refKey(ref); key != "" {
+			g.drop(key, hasFlag(a, "CASCADE"))
+		}
+	}
+}
+
+// indexName returns the name of a CREATE INDEX action, or the name PostgreSQL gives an
+// unnamed index when no relation of the schema declared so far has it.
+func (g *DependencyGraph) indexName(a *postgresparser.DDLAction) string {
+	if a.ObjectName != "" {
+		return a.ObjectName
+	}
+	columns := make([]string, 0, len(a.Columns))
+	for _, col := range a.Columns {
+		columns = append(columns, indexColumn(col))
+	}
+	schema := normalizeIdent(schemaOrDefault(a.Schema))
+	taken := func(name string) bool {
+		o := g.objects["rel:"+schema+"."+name]
+		return o != nil && o.declared
+	}
+	return quoteIdent(generatedName(normalizeIdent(a.Table), columns, "idx", taken))
+}
+
+// addColumns records the foreign keys, sequence defaults, and column types of a table
+// action.
+func (g *DependencyGraph) addColumns(key string, a *postgresparser.DDLAction) {
+	for _, con := range a.Constraints {
+		if con.Type != postgresparser.DDLConstraintForeignKey || con.RefTable == "" {
+			continue
+		}
+		if ref := g.relation(con.RefSchema, con.RefTable); ref != key {
+			g.depend(key, ref, DependencyForeignKey, false)
+		}
+	}
+	for _, col := range a.ColumnDetails {
+		for _, m := range nextvalRe.FindAllStringSubmatch(col.Default, -1) {
+			schema, name := splitQualified(m[1])
+			g.depend(key, g.relation(schema, name), DependencyDefault, false)
+		}
+		if typ := baseTypeName(col.Type); typ != "" {
+			schema, name := splitQualified(typ)
+			if schema == "" {
+				schema = g.searchPath().Resolve(name)
+			}
+			g.depend(key, "type:"+normalizeIdent(schema)+"."+normalizeIdent(name), DependencyColumnType, true)
+		}
+	}
+}
+
+// nextvalRe matches the sequence name of a nextval('name') call.
+var nextvalRe = regexp.MustCompile(`(?i)nextval\s*\(\s*'([^']+)'`)
+
+// baseTypeName strips array brackets and type modifiers from a column type, returning
+// "" for types written with keywords (double precision, timestamp with time zone, ...),
+// which are always built in.
+func baseTypeName(typ string) string {
+	typ = strings.TrimSpace(typ)
+	if i := strings.IndexAny(typ, "(["); i >= 0 {
+		typ = strings.TrimSpace(typ[:i])
+	}
+	if typ == "" || strings.ContainsAny(typ, " \t\n") && !strings.Contains(typ, `"`) {
+		return ""
+	}
+	return typ
+}
+
+// addOwner records the OWNED BY table.column option of a declared sequence.
+func (g *DependencyGraph) addOwner(key string, opts []postgresparser.DDLOption) {
+	if !g.objects[key].isDeclared() {
+		return
+	}
+	for _, opt := range opts {
+		if opt.Name != "OWNED BY" || strings.EqualFold(opt.Value, "NONE") {
+			continue
+		}
+		// The value is [schema.]table.column.
+		parts := splitQuotedParts(opt.Value)
+		if len(parts) < 2 {
+			continue
+		}
+		schema := strings.Join(parts[:len(parts)-2], ".")
+		g.depend(key, g.relation(schema, parts[len(parts)-2]), DependencyOwnedBy, false)
+	}
+}
+
+// addReads records a QUERY dependency on every base relation query reads.
+func (g *DependencyGraph) addReads(key string, query *postgresparser.ParsedQuery) {
+	for _, t := range baseRelations(query) {
+		if ref := g.relation(t.Schema, t.Name); ref != key {
+			g.depend(key, ref, DependencyQuery, false)
+		}
+	}
+}
+
+// addFunctionBody records the relations read by the body of a LANGUAGE sql function.
+// Bodies that do not parse are skipped.
+func (g *DependencyGraph) addFunctionBody(key string, opts []postgresparser.DDLOption) {
+	var lang, body string
+	for _, opt := range opts {
+		switch opt.Name {
+		case "LANGUAGE":
+			lang = opt.Value
+		case "AS":
+			body = opt.Value
+		}
+	}
+	if lang != "sql" || strings.TrimSpace(body) == "" {
+		return
+	}
+	stmts, err := postgresparser.ParseSQLAll(body)
+	if err != nil {
+		return
+	}
+	analysis.QualifyScript(stmts, g.searchPath())
+	for _, stmt := range stmts {
+		g.addReads(key, stmt)
+	}
+}
+
+// baseRelations returns the base tables referenced anywhere in pq, including
+// subqueries, the targets of writes, and the relations of nested queries.
+func baseRelations(pq *postgresparser.ParsedQuery) []postgresparser.TableRef {
+	if pq == nil {
+		return nil
+	}
+	var out []postgresparser.TableRef
+	add := func(t postgresparser.TableRef) {
+		if t.Type == postgresparser.TableTypeBase && t.Name != "" {
+			out = append(out, t)
+		}
+	}
+	for _, t := range pq.Tables {
+		add(t)
+	}
+	for _, s := range pq.Scopes {
+		for _, rel := range s.Relations {
+			add(rel.Table)
+		}
+	}
+	for _, op := range pq.SetOperations {
+		for _, t := range op.Tables {
+			add(t)
+		}
+	}
+	if pq.Target != nil {
+		add(*pq.Target)
+	}
+	for _, sub := range pq.Subqueries {
+		out = append(out, baseRelations(sub.Query)...)
+	}
+	out = append(out, baseRelations(pq.Source)...)
+	if m := pq.Merge; m != nil {
+		add(m.Target)
+		add(m.Source.Table)
+		if m.Source.Subquery != nil {
+			out = append(out, baseRelations(m.Source.Subquery.Query)...)
+		}
+	}
+	return out
+}
+
+// refKey returns the graph key of ref, or "" for kinds the graph does not track.
+// Tables, views, indexes, and sequences share one namespace per schema, as do types and
+// domains.
+func refKey(ref ObjectRef) string {
+	var ns string
+	switch ref.Kind {
+	case postgresparser.DDLObjectTable, postgresparser.DDLObjectView, postgresparser.DDLObjectMaterializedView,
+		postgresparser.DDLObjectForeignTable, postgresparser.DDLObjectIndex, postgresparser.DDLObjectSequence:
+		ns = "rel"
+	case postgresparser.DDLObjectType, postgresparser.DDLObjectDomain:
+		ns = "type"
+	case postgresparser.DDLObjectFunction, postgresparser.DDLObjectProcedure, postgresparser.DDLObjectRoutine:
+		ns = "func"
+	case postgresparser.DDLObjectTrigger:
+		return "trigger:" + normalizeIdent(schemaOrDefault(ref.Schema)) + "." + normalizeIdent(ref.Table) + "." + normalizeIdent(ref.Name)
+	default:
+		return ""
+	}
+	return ns + ":" + normalizeIdent(schemaOrDefault(ref.Schema)) + "." + normalizeIdent(ref.Name)
+}
+
+// normalizeRef folds the names of ref.
+func normalizeRef(ref ObjectRef) ObjectRef {
+	ref.Schema = normalizeIdent(schemaOrDefault(ref.Schema))
+	ref.Name = normalizeIdent(ref.Name)
+	ref.Table = normalizeIdent(ref.Table)
+	return ref
+}
+
+// declare records ref as declared, replacing an external object of the same name, and
+// returns its key.
+func (g *DependencyGraph) declare(ref ObjectRef) string {
+	key := refKey(ref)
+	if o := g.objects[key]; o != nil {
+		o.ref, o.declared = normalizeRef(ref), true
+		return key
+	}
+	g.objects[key] = &depObject{ref: normalizeRef(ref), declared: true}
+	g.order = append(g.order, key)
+	return key
+}
+
+// reference returns the key of ref, recording it as an external object when it is
+// not yet known.
+func (g *DependencyGraph) reference(ref ObjectRef) string {
+	key := refKey(ref)
+	if g.objects[key] == nil {
+		g.objects[key] = &depObject{ref: normalizeRef(ref)}
+		g.order = append(g.order, key)
+	}
+	return key
+}
+
+// relation returns the key of a relation, referenced as a table when not yet known.
+func (g *DependencyGraph) relation(schema, name string) string {
+	return g.reference(ObjectRef{Kind: postgresparser.DDLObjectTable, Schema: schema, Name: name})
+}
+
+// depend records a dependency, ignoring duplicates.
+func (g *DependencyGraph) depend(from, to string, kind DependencyKind, optional bool) {
+	for _, e := range g.deps {
+		if e.from == from && e.to == to && e.kind == kind {
+			return
+		}
+	}
+	g.deps = append(g.deps, depEdge{from: from, to: to, kind: kind, optional: optional})
+}
+
+// drop removes an object and its own dependencies. With cascade, the objects that
+// would be dropped along with it and the foreign keys, defaults, and column types
+// using them are removed too; otherwise an object others still depend on stays as an
+// external object.
+func (g *DependencyGraph) drop(key string, cascade bool) {
+	if g.objects[key] == nil {
+		return
+	}
+	if cascade {
+		for _, e := range g.dropImpact(key) {
+			if e.kind.drops() {
+				g.remove(e.from)
+			} else {
+				g.deps = slices.DeleteFunc(g.deps, func(d depEdge) bool { return d == e })
+			}
+		}
+	}
+	g.remove(key)
+}
+
+// remove deletes the outgoing edges of key, and the object itself unless other
+// objects still depend on it.
+func (g *DependencyGraph) remove(key string) {
+	o := g.objects[key]
+	if o == nil {
+		return
+	}
+	kept := g.deps[:0]
+	referenced := false
+	for _, e := range g.deps {
+		if e.from == key {
+			continue
+		}
+		if e.to == key && !e.optional {
+			referenced = true
+		}
+		kept = append(kept, e)
+	}
+	g.deps = kept
+	if referenced {
+		o.declared = false
+	} else {
+		g.forget(key)
+	}
+	// External objects only referenced by key go with it.
+	for _, k := range slices.Clone(g.order) {
+		if g.objects[k].declared {
+			continue
+		}
+		used := false
+		for _, e := range g.deps {
+			if e.to == k && !e.optional {
+				used = true
+				break
+			}
+		}
+		if !used {
+			g.forget(k)
+		}
+	}
+}
+
+// forget deletes the object key.
+func (g *DependencyGraph) forget(key string) {
+	delete(g.objects, key)
+	g.order = slices.DeleteFunc(g.order, func(k string) bool { return k == key })
+}
+
+// edges returns the dependencies that count: optional ones only when their target is
+// declared.
+func (g *DependencyGraph) edges() []depEdge {
+	var out []depEdge
+	for _, e := range g.deps {
+		if e.optional && !g.objects[e.to].isDeclared() {
+			continue
+		}
+		out = append(out, e)
+	}
+	return out
+}
+
+// isDeclared reports whether o exists and was declared.
+func (o *depObject) isDeclared() bool {
+	return o != nil && o.declared
+}
+
+// dependency converts an edge to its public form.
+func (g *DependencyGraph) dependency(e depEdge) Dependency {
+	return Dependency{Object: g.objects[e.from].ref, On: g.objects[e.to].ref, Kind: e.kind}
+}
+
+// Objects returns the declared objects in the order they were first seen.
+func (g *DependencyGraph) Objects() []ObjectRef {
+	var out []ObjectRef
+	for _, key := range g.order {
+		if o := g.objects[key]; o.declared {
+			out = append(out, o.ref)
+		}
+	}
+	return out
+}
+
+// External returns the objects that are referenced but never declared, in the order
+// they were first seen. Relations are reported as tables.
+func (g *DependencyGraph) External() []ObjectRef {
+	var out []ObjectRef
+	for _, key := range g.order {
+		if o := g.objects[key]; !o.declared {
+			out = append(out, o.ref)
+		}
+	}
+	return out
+}
+
+// Dependencies returns every dependency of the graph in the order it was recorded.
+func (g *DependencyGraph) Dependencies() []Dependency {
+	var out []Dependency
+	for _, e := range g.edges() {
+		out = append(out, g.dependency(e))
+	}
+	return out
+}
+
+// DependsOn returns the direct dependencies of ref.
+func (g *DependencyGraph) DependsOn(ref ObjectRef) []Dependency {
+	key := refKey(ref)
+	var out []Dependency
+	for _, e := range g.edges() {
+		if e.from == key {
+			out = append(out, g.dependency(e))
+		}
+	}
+	return out
+}
+
+// Dependents returns the direct dependencies on ref.
+func (g *DependencyGraph) Dependents(ref ObjectRef) []Dependency {
+	key := refKey(ref)
+	var out []Dependency
+	for _, e := range g.edges() {
+		if e.to == key {
+			out = append(out, g.dependency(e))
+		}
+	}
+	return out
+}
+
+// CreationOrder returns the declared objects ordered so that every object comes after
+// the objects it depends on, keeping the order in which they were first seen where
+// dependencies allow. Foreign keys and sequence ownership are ignored when they form a
+// cycle, since those links can be added once both objects exist. Any other cycle
+// returns an error wrapping ErrDependencyCycle and naming the objects left unordered.
+func (g *DependencyGraph) CreationOrder() ([]ObjectRef, error) {
+	var keys []string
+	for _, key := range g.order {
+		if g.objects[key].declared {
+			keys = append(keys, key)
+		}
+	}
+	sorted, stuck := g.topoSort(keys, g.edges(), true)
+	if len(stuck) > 0 {
+		names := make([]string, len(stuck))
+		for i, key := range stuck {
+			names[i] = g.objects[key].ref.String()
+		}
+		return nil, fmt.Errorf("%s: %w", strings.Join(names, ", "), ErrDependencyCycle)
+	}
+	out := make([]ObjectRef, len(sorted))
+	for i, key := range sorted {
+		out[i] = g.objects[key].ref
+	}
+	return out, nil
+}
+
+// topoSort orders keys so that each comes after the keys it depends on through edges,
+// picking the earliest ready key each time. When no key is ready and breakSoft is set,
+// the soft edges of the earliest blocked key are ignored. It returns the sorted keys and
+// the keys left on a cycle.
+func (g *DependencyGraph) topoSort(keys []string, edges []depEdge, breakSoft bool) ([]string, []string) {
+	in := make(map[string]bool, len(keys))
+	for _, key := range keys {
+		in[key] = true
+	}
+	var live []depEdge
+	for _, e := range edges {
+		if in[e.from] && in[e.to] && e.from != e.to {
+			live = append(live, e)
+		}
+	}
+	done := make(map[string]bool, len(keys))
+	blocked := func(key string) bool {
+		for _, e := range live {
+			if e.from == key && !done[e.to] {
+				return true
+			}
+		}
+		return false
+	}
+	var out []string
+	for len(out) < len(keys) {
+		next := ""
+		for _, key := range keys {
+			if !done[key] && !blocked(key) {
+				next = key
+				break
+			}
+		}
+		if next == "" {
+			// Ignore the soft edges of the earliest blocked key that has any, then retry.
+			broke := false
+			for _, key := range keys {
+				if done[key] || !breakSoft {
+					continue
+				}
+				n := len(live)
+				live = slices.DeleteFunc(live, func(e depEdge) bool { return e.from == key && e.kind.soft() && !done[e.to] })
+				if broke = len(live) < n; broke {
+					break
+				}
+			}
+			if broke {
+				continue
+			}
+			var stuck []string
+			for _, key := range keys {
+				if !done[key] {
+					stuck = append(stuck, key)
+				}
+			}
+			return out, stuck
+		}
+		done[next] = true
+		out = append(out, next)
+	}
+	return out, nil
+}
+
+// DropImpact returns what breaks when ref is dropped: the dependencies on ref and,
+// for every object that would be dropped along with it (views, indexes, triggers, SQL
+// functions, owned sequences), the dependencies on that object in turn. Foreign keys,
+// column defaults, and column types on a dropped object cost the dependent table only
+// that constraint, default, or column, so the table's own dependents are not followed.
+// Dependencies are ordered so that dependents come before the objects they depend on,
+// the order in which DROP ... CASCADE removes them.
+func (g *DependencyGraph) DropImpact(ref ObjectRef) []Dependency {
+	var out []Dependency
+	for _, e := range g.dropImpact(refKey(ref)) {
+		out = append(out, g.dependency(e))
+	}
+	return out
+}
+
+// dropImpact returns the edges broken by dropping key, dependents first.
+func (g *DependencyGraph) dropImpact(key string) []depEdge {
+	if g.objects[key] == nil {
+		return nil
+	}
+	edges := g.edges()
+	var broken []depEdge
+	dropped := map[string]bool{key: true}
+	queue := []string{key}
+	for len(queue) > 0 {
+		cur := queue[0]
+		queue = queue[1:]
+		for _, e := range edges {
+			if e.to != cur || e.from == key {
+				continue
+			}
+			broken = append(broken, e)
+			if e.kind.drops() && !dropped[e.from] {
+				dropped[e.from] = true
+				queue = append(queue, e.from)
+			}
+		}
+	}
+
+	// Order the dropped objects so that dependents come first, following only the
+	// dependencies that drop them; cycles keep first-seen order.
+	var keys []string
+	for _, k := range g.order {
+		if dropped[k] {
+			keys = append(keys, k)
+		}
+	}
+	var dropping []depEdge
+	for _, e := range edges {
+		if e.kind.drops() {
+			dropping = append(dropping, e)
+		}
+	}
+	sorted, stuck := g.topoSort(keys, dropping, false)
+	sorted = append(sorted, stuck...)
+	rank := make(map[string]int, len(sorted))
+	for i, k := range sorted {
+		rank[k] = len(sorted) - i
+	}
+	// A dependency breaks when its dependent is dropped or, for a table that stays,
+	// together with the object it depends on.
+	at := func(e depEdge) int {
+		if dropped[e.from] {
+			return rank[e.from]
+		}
+		return rank[e.to]
+	}
+	sort.SliceStable(broken, func(i, j int) bool { return at(broken[i]) < at(broken[j]) })
+	return broken
+}
+
+// splitQualified splits a possibly schema-qualified name, keeping the quotes of each
+// part for normalizeIdent.
+func splitQualified(name string) (string, string) {
+	parts := splitQuotedParts(name)
+	if len(parts) == 0 {
+		return "", ""
+	}
+	return strings.Join(parts[:len(parts)-1], "."), parts[len(parts)-1]
+}
+
+// splitQuotedParts splits a dotted name on the dots outside double quotes.
+func splitQuotedParts(name string) []string {
+	var parts []string
+	inQuote := false
+	start := 0
+	for i, r := range name {
+		switch {
+		case r == '"':
+			inQuote = !inQuote
+		case r == '.' && !inQuote:
+			parts = append(parts, strings.TrimSpace(name[start:i]))
+			start = i + 1
+		}
+	}
+	if last := strings.TrimSpace(name[start:]); last != "" {
+		parts = append(parts, last)
+	}
+	return parts
+}
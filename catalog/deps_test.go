@@ -0,0 +1,162 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// depsScript declares its objects out of order, as the files of a project might.
+const depsScript = `
+CREATE VIEW big_spenders AS SELECT c.id, c.email FROM customers c WHERE c.id IN (SELECT customer_id FROM orders WHERE total > 1000);
+CREATE TRIGGER orders_audit AFTER INSERT OR UPDATE ON orders FOR EACH ROW EXECUTE FUNCTION audit.log_change();
+CREATE TABLE orders (
+    id bigint PRIMARY KEY DEFAULT nextval('orders_id_seq'),
+    customer_id bigint REFERENCES customers,
+    status order_status NOT NULL,
+    total numeric(12,2) NOT NULL
+);
+CREATE SEQUENCE orders_id_seq OWNED BY orders.id;
+CREATE TYPE order_status AS ENUM ('new', 'paid');
+CREATE TABLE customers (id bigint PRIMARY KEY, email text, last_order_id bigint);
+ALTER TABLE customers ADD CONSTRAINT customers_last_order_fkey FOREIGN KEY (last_order_id) REFERENCES orders;
+CREATE INDEX orders_customer_idx ON orders (customer_id);
+CREATE FUNCTION order_count(cid bigint) RETURNS bigint LANGUAGE sql STABLE AS $$ SELECT count(*) FROM orders WHERE customer_id = cid $$;
+CREATE VIEW vip_emails AS SELECT email FROM big_spenders;
+`
+
+// refs renders objects with String for compact assertions.
+func refs(objects []ObjectRef) []string {
+	out := make([]string, len(objects))
+	for i, o := range objects {
+		out[i] = o.String()
+	}
+	return out
+}
+
+// deps renders dependencies as "object -KIND-> on".
+func deps(list []Dependency) []string {
+	out := make([]string, len(list))
+	for i, d := range list {
+		out[i] = d.Object.String() + " -" + string(d.Kind) + "-> " + d.On.String()
+	}
+	return out
+}
+
+func TestDependencyGraph(t *testing.T) {
+	g, err := BuildDependencyGraph(depsScript)
+	require.NoError(t, err, "build failed")
+
+	assert.Equal(t, []string{"FUNCTION audit.log_change"}, refs(g.External()), "external objects")
+	assert.Equal(t, []string{
+		"TABLE public.orders -FOREIGN_KEY-> TABLE public.customers",
+		"TABLE public.orders -DEFAULT-> SEQUENCE public.orders_id_seq",
+		"TABLE public.orders -COLUMN_TYPE-> TYPE public.order_status",
+	}, deps(g.DependsOn(ObjectRef{Kind: postgresparser.DDLObjectTable, Schema: "public", Name: "orders"})), "orders depends on")
+	trigger := ObjectRef{Kind: postgresparser.DDLObjectTrigger, Schema: "public", Name: "orders_audit", Table: "orders"}
+	assert.Equal(t, []Dependency{
+		{Object: trigger, On: ObjectRef{Kind: postgresparser.DDLObjectTable, Schema: "public", Name: "orders"}, Kind: DependencyTrigger},
+		{Object: trigger, On: ObjectRef{Kind: postgresparser.DDLObjectFunction, Schema: "audit", Name: "log_change"}, Kind: DependencyTriggerFunction},
+	}, g.DependsOn(trigger), "trigger depends on")
+
+	order, err := g.CreationOrder()
+	require.NoError(t, err, "creation order")
+	assert.Equal(t, []string{
+		"TYPE public.order_status",
+		"TABLE public.customers",
+		"SEQUENCE public.orders_id_seq",
+		"TABLE public.orders",
+		"VIEW public.big_spenders",
+		"TRIGGER orders_audit ON public.orders",
+		"INDEX public.orders_customer_idx",
+		"FUNCTION public.order_count",
+		"VIEW public.vip_emails",
+	}, refs(order), "creation order")
+}
+
+func TestDependencyGraph_DropImpact(t *testing.T) {
+	g, err := BuildDependencyGraph(depsScript)
+	require.NoError(t, err, "build failed")
+
+	assert.Equal(t, []string{
+		"VIEW public.vip_emails -QUERY-> VIEW public.big_spenders",
+		"FUNCTION public.order_count -QUERY-> TABLE public.orders",
+		"INDEX public.orders_customer_idx -INDEX-> TABLE public.orders",
+		"SEQUENCE public.orders_id_seq -OWNED_BY-> TABLE public.orders",
+		"TRIGGER orders_audit ON public.orders -TRIGGER-> TABLE public.orders",
+		"VIEW public.big_spenders -QUERY-> TABLE public.orders",
+		"TABLE public.customers -FOREIGN_KEY-> TABLE public.orders",
+	}, deps(g.DropImpact(ObjectRef{Kind: postgresparser.DDLObjectTable, Name: "orders"})), "dropping orders")
+
+	assert.Equal(t, []string{
+		"TABLE public.orders -COLUMN_TYPE-> TYPE public.order_status",
+	}, deps(g.DropImpact(ObjectRef{Kind: postgresparser.DDLObjectType, Name: "order_status"})), "dropping the type costs only a column")
+
+	assert.Equal(t, []string{
+		"TRIGGER orders_audit ON public.orders -TRIGGER_FUNCTION-> FUNCTION audit.log_change",
+	}, deps(g.DropImpact(ObjectRef{Kind: postgresparser.DDLObjectFunction, Schema: "audit", Name: "log_change"})), "dropping an external function")
+}
+
+func TestDependencyGraph_Drop(t *testing.T) {
+	g, err := BuildDependencyGraph(depsScript + `
+DROP VIEW big_spenders CASCADE;
+DROP INDEX orders_customer_idx;
+DROP TRIGGER orders_audit ON orders;
+`)
+	require.NoError(t, err, "build failed")
+	objects := refs(g.Objects())
+	assert.NotContains(t, objects, "VIEW public.big_spenders", "dropped view")
+	assert.NotContains(t, objects, "VIEW public.vip_emails", "view dropped by CASCADE")
+	assert.NotContains(t, objects, "INDEX public.orders_customer_idx", "dropped index")
+	assert.NotContains(t, objects, "TRIGGER orders_audit ON public.orders", "dropped trigger")
+	assert.Empty(t, g.External(), "the trigger function is no longer referenced")
+	assert.Empty(t, g.Dependents(ObjectRef{Kind: postgresparser.DDLObjectView, Name: "big_spenders"}), "no dependents left")
+}
+
+func TestDependencyGraph_SearchPath(t *testing.T) {
+	g, err := BuildDependencyGraph(`
+CREATE SCHEMA app;
+SET search_path TO app, public;
+CREATE TABLE accounts (id int PRIMARY KEY);
+CREATE VIEW active_accounts AS SELECT id FROM accounts;
+RESET search_path;
+CREATE VIEW all_accounts AS SELECT id FROM app.accounts;
+`)
+	require.NoError(t, err, "build failed")
+	accounts := ObjectRef{Kind: postgresparser.DDLObjectTable, Schema: "app", Name: "accounts"}
+	assert.Equal(t, []Dependency{
+		{Object: ObjectRef{Kind: postgresparser.DDLObjectView, Schema: "app", Name: "active_accounts"}, On: accounts, Kind: DependencyQuery},
+		{Object: ObjectRef{Kind: postgresparser.DDLObjectView, Schema: "public", Name: "all_accounts"}, On: accounts, Kind: DependencyQuery},
+	}, g.Dependencies(), "dependencies")
+	assert.Empty(t, g.External(), "every relation is declared")
+}
+
+func TestDependencyGraph_UnnamedIndexes(t *testing.T) {
+	g, err := BuildDependencyGraph(`
+CREATE INDEX ON t (a);
+CREATE INDEX ON t (a);
+CREATE INDEX ON t (lower(b));
+CREATE TABLE t (a int, b text);
+`)
+	require.NoError(t, err, "build failed")
+	table := ObjectRef{Kind: postgresparser.DDLObjectTable, Schema: "public", Name: "t"}
+	assert.Equal(t, []Dependency{
+		{Object: ObjectRef{Kind: postgresparser.DDLObjectIndex, Schema: "public", Name: "t_a_idx"}, On: table, Kind: DependencyIndex},
+		{Object: ObjectRef{Kind: postgresparser.DDLObjectIndex, Schema: "public", Name: "t_a_idx1"}, On: table, Kind: DependencyIndex},
+		{Object: ObjectRef{Kind: postgresparser.DDLObjectIndex, Schema: "public", Name: "t_expr_idx"}, On: table, Kind: DependencyIndex},
+	}, g.Dependencies(), "unnamed indexes get PostgreSQL's default names")
+}
+
+func TestDependencyGraph_Cycle(t *testing.T) {
+	g, err := BuildDependencyGraph(`
+CREATE VIEW a AS SELECT * FROM b;
+CREATE VIEW b AS SELECT * FROM a;
+`)
+	require.NoError(t, err, "build failed")
+	_, err = g.CreationOrder()
+	require.ErrorIs(t, err, ErrDependencyCycle, "views reading each other")
+	assert.Contains(t, err.Error(), "VIEW public.a, VIEW public.b", "cycle members")
+}
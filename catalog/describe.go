@@ -0,0 +1,625 @@
+// describe.go infers the result columns and parameter types of a statement from the
+// catalog, as the Describe message of PostgreSQL's extended query protocol reports them.
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// Description is the shape of a statement's result and parameters.
+type Description struct {
+	Columns    []DescribedColumn    // Result columns in order; empty for statements that return no rows
+	Parameters []DescribedParameter // One entry per distinct $n, ordered by n
+}
+
+// DescribedColumn is a result column.
+type DescribedColumn struct {
+	Name     string
+	Type     string // PostgreSQL type name, e.g. "bigint" or "character varying(255)"; empty when unknown
+	Nullable bool   // False only when the column is known never to be NULL
+	Table    string // Source table as schema.table when the column is a plain column reference
+	Column   string // Source column when Table is set
+}
+
+// DescribedParameter is a positional parameter.
+type DescribedParameter struct {
+	Position int    // n of $n
+	Type     string // Inferred PostgreSQL type name; empty when the context does not determine it
+	Name     string // Column the parameter is compared with, assigned to, or inserted into, or "limit" or "offset"; empty otherwise
+	Nullable bool   // True when the parameter is assigned to or inserted into a column that accepts NULL
+}
+
+// maxDescribeDepth bounds how many CTEs and subqueries are followed to type a column.
+const maxDescribeDepth = 32
+
+// Describe parses a statement and describes it against the catalog. See DescribeQuery.
+func Describe(sql string, c *Catalog) (*Description, error) {
+	pq, err := postgresparser.ParseSQL(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return DescribeQuery(pq, c)
+}
+
+// DescribeQuery returns the names, types, and nullability of the columns a statement
+// returns and the types of its $n parameters, without a database.
+//
+// Column references are typed from the catalog, and columns of tables on the nullable
+// side of an outer join are nullable. Constants, casts, common operators, CASE, and
+// built-in functions such as count, sum, coalesce, now, and the jsonb operators are
+// typed the way PostgreSQL types them. A column of UNION, INTERSECT, or EXCEPT has the
+// common type of its branches and is nullable when it is in any branch. A parameter takes its type from a cast, from
+// the column or expression it is compared with, assigned to, or inserted into, and from
+// LIMIT and OFFSET. Types that cannot be inferred are left empty.
+//
+// Stars are expanded as analysis.ExpandResultColumns does; a star over a table the
+// catalog does not have fails with analysis.ErrUnknownColumns.
+func DescribeQuery(pq *postgresparser.ParsedQuery, c *Catalog) (*Description, error) {
+	if pq == nil {
+		return nil, fmt.Errorf("nil query")
+	}
+	d := newDescriber(pq, c)
+	d.inferParameters()
+
+	cols, err := analysis.ExpandResultColumns(pq, d.schemaMap)
+	if err != nil {
+		return nil, err
+	}
+	out := &Description{}
+	scope := d.outputScope()
+	var exprIdx []int // Select-list entries that are not stars
+	if scope >= 0 {
+		for i, sc := range pq.Scopes[scope].Columns {
+			if !isStar(sc.Expression) {
+				exprIdx = append(exprIdx, i)
+			}
+		}
+	}
+	for _, rc := range cols {
+		var et exprType
+		if rc.Star {
+			et = d.starColumnType(scope, rc)
+		} else if len(exprIdx) > 0 {
+			et = d.branchColumnType(scope, exprIdx[0])
+			exprIdx = exprIdx[1:]
+		}
+		if scope >= 0 {
+			et = d.withSetOperations(scope, len(out.Columns), et)
+		}
+		col := DescribedColumn{Name: rc.Name, Type: et.typ, Nullable: et.nullable}
+		if col.Type == "" && et.literal {
+			col.Type = "text"
+		}
+		if rc.Status == analysis.ColumnResolved {
+			if t := d.lookupTable(rc.Schema, rc.Table); t != nil && t.Column(rc.Column) != nil {
+				col.Table, col.Column = t.QualifiedName(), rc.Column
+			}
+		}
+		out.Columns = append(out.Columns, col)
+	}
+
+	seen := make(map[int]bool)
+	for _, p := range pq.Parameters {
+		if p.Marker != "$" || seen[p.Position] {
+			continue
+		}
+		seen[p.Position] = true
+		out.Parameters = append(out.Parameters, DescribedParameter{
+			Position: p.Position,
+			Type:     d.params[p.Position],
+			Name:     d.names[p.Position],
+			Nullable: d.nullParams[p.Position],
+		})
+	}
+	sort.Slice(out.Parameters, func(i, j int) bool { return out.Parameters[i].Position < out.Parameters[j].Position })
+	return out, nil
+}
+
+// describer types the expressions of one statement.
+type describer struct {
+	c          *Catalog
+	pq         *postgresparser.ParsedQuery
+	schemaMap  map[string][]analysis.ColumnSchema
+	tokens     []sqllex.Token
+	usages     map[int]analysis.ResolvedColumn // Resolved ColumnUsage by position
+	params     map[int]string                  // Inferred type of each $n
+	names      map[int]string                  // Column each $n is compared with or assigned to
+	nullParams map[int]bool                    // $n written to a column that accepts NULL
+	assigning  bool                            // Comparisons being scanned are SET assignments
+	positions  map[int][]int                   // Offset of each output column of a scope, -1 when not found
+	depth      int
+}
+
+// newDescriber prepares the tokens and column resolutions of pq.
+func newDescriber(pq *postgresparser.ParsedQuery, c *Catalog) *describer {
+	d := &describer{
+		c:          c,
+		pq:         pq,
+		schemaMap:  c.ColumnSchemas(),
+		tokens:     sqllex.Lex(pq.RawSQL),
+		usages:     make(map[int]analysis.ResolvedColumn),
+		params:     make(map[int]string),
+		names:      make(map[int]string),
+		nullParams: make(map[int]bool),
+		positions:  make(map[int][]int),
+	}
+	for _, rc := range analysis.ResolveColumnUsage(pq, d.schemaMap) {
+		if _, dup := d.usages[rc.Usage.Position]; !dup {
+			d.usages[rc.Usage.Position] = rc
+		}
+	}
+	return d
+}
+
+// noteParam records typ as the type of e when e is a parameter of unknown type.
+func (d *describer) noteParam(e exprType, typ string) {
+	if e.param > 0 && typ != "" && d.params[e.param] == "" {
+		d.params[e.param] = typ
+	}
+}
+
+// nameParam records name as the name of e when e is a parameter without one.
+func (d *describer) nameParam(e exprType, name string) {
+	if e.param > 0 && name != "" && d.names[e.param] == "" {
+		d.names[e.param] = name
+	}
+}
+
+// unify types and names a parameter operand from the other operand of a comparison.
+// In a SET assignment to a column that accepts NULL, the parameter may be NULL too.
+func (d *describer) unify(a, b exprType) {
+	d.noteParam(a, b.typ)
+	d.noteParam(b, a.typ)
+	d.nameParam(a, b.column)
+	d.nameParam(b, a.column)
+	if d.assigning {
+		if a.param > 0 && b.column != "" && b.nullable {
+			d.nullParams[a.param] = true
+		}
+		if b.param > 0 && a.column != "" && a.nullable {
+			d.nullParams[b.param] = true
+		}
+	}
+}
+
+// describeType returns the canonical name of a type as written, resolving serial types
+// and domains to their underlying type.
+func (d *describer) describeType(typ string) string {
+	typ = canonicalType(typ)
+	arrays := ""
+	for strings.HasSuffix(typ, "[]") {
+		typ = strings.TrimSuffix(typ, "[]")
+		arrays += "[]"
+	}
+	switch typ {
+	case "serial", "serial4":
+		typ = "integer"
+	case "bigserial", "serial8":
+		typ = "bigint"
+	case "smallserial", "serial2":
+		typ = "smallint"
+	case "float":
+		typ = "double precision"
+	}
+	if d.depth < maxDescribeDepth {
+		schema, name := "", typ
+		if i := strings.LastIndex(typ, "."); i >= 0 {
+			schema, name = typ[:i], typ[i+1:]
+		}
+		if t := d.c.Type(schema, name); t != nil && t.Kind == TypeKindDomain {
+			d.depth++
+			defer func() { d.depth-- }()
+			return d.describeType(t.BaseType) + arrays
+		}
+	}
+	return typ + arrays
+}
+
+// lookupTable finds a table the way ColumnSchemas keys it: by schema when given,
+// otherwise in DefaultSchema first, then in the first schema that has it.
+func (d *describer) lookupTable(schema, name string) *Table {
+	if schema != "" {
+		return d.c.Table(schema, name)
+	}
+	if t := d.c.Table(DefaultSchema, name); t != nil {
+		return t
+	}
+	for _, s := range d.c.Schemas() {
+		if t := s.Table(name); t != nil {
+			return t
+		}
+	}
+	return nil
+}
+
+// outputScope returns the scope whose columns the statement returns, or -1.
+func (d *describer) outputScope() int {
+	if len(d.pq.Scopes) == 0 {
+		return -1
+	}
+	if d.pq.Command != postgresparser.QueryCommandDDL {
+		return 0
+	}
+	for i, s := range d.pq.Scopes {
+		if s.Kind == postgresparser.ScopeSource && s.Parent == 0 {
+			return i
+		}
+	}
+	return -1
+}
+
+// typeAt types the expression expr written at character offset pos.
+func (d *describer) typeAt(pos int, expr string) exprType {
+	if pos < 0 || d.depth >= maxDescribeDepth {
+		return unknownType
+	}
+	end := pos + len([]rune(expr))
+	var toks []sqllex.Token
+	for _, t := range d.tokens {
+		if t.Pos >= pos && t.Pos < end {
+			toks = append(toks, t)
+		}
+	}
+	d.depth++
+	defer func() { d.depth-- }()
+	p := &exprParser{d: d, toks: toks}
+	e := p.expr(0)
+	if !p.done() {
+		return unknownType
+	}
+	return e
+}
+
+// columnPositions returns the offsets of the output column expressions of scope,
+// found in order among the tokens of the scope itself; RETURNING lists are searched
+// after the RETURNING keyword.
+func (d *describer) columnPositions(scope int) []int {
+	if pos, ok := d.positions[scope]; ok {
+		return pos
+	}
+	sc := d.pq.Scopes[scope]
+	raw := []rune(d.pq.RawSQL)
+	from := sc.Start
+	if scope == 0 && d.pq.Command != postgresparser.QueryCommandSelect {
+		for _, t := range d.tokens {
+			if t.Is("RETURNING") && sqllex.ScopeAt(d.pq.Scopes, t.Pos) == 0 {
+				from = t.Pos
+			}
+		}
+	}
+	out := make([]int, len(sc.Columns))
+	k := 0
+	for i, col := range sc.Columns {
+		out[i] = -1
+		expr := []rune(col.Expression)
+		for ; k < len(d.tokens); k++ {
+			t := d.tokens[k]
+			// An expression may start with a subquery, whose scope starts with it.
+			inner := sqllex.ScopeAt(d.pq.Scopes, t.Pos)
+			if inner != scope && (d.pq.Scopes[inner].Parent != scope || d.pq.Scopes[inner].Start != t.Pos) {
+				continue
+			}
+			if t.Pos < from || t.Pos+len(expr) > len(raw) {
+				continue
+			}
+			if string(raw[t.Pos:t.Pos+len(expr)]) == col.Expression {
+				out[i] = t.Pos
+				from = t.Pos + len(expr)
+				break
+			}
+		}
+	}
+	d.positions[scope] = out
+	return out
+}
+
+// scopeColumnType types output column idx of scope, including the set operations
+// that follow it.
+func (d *describer) scopeColumnType(scope, idx int) exprType {
+	return d.withSetOperations(scope, idx, d.branchColumnType(scope, idx))
+}
+
+// branchColumnType types select-list entry idx of scope alone.
+func (d *describer) branchColumnType(scope, idx int) exprType {
+	sc := d.pq.Scopes[scope]
+	if idx >= len(sc.Columns) || isStar(sc.Columns[idx].Expression) {
+		return unknownType
+	}
+	return d.typeAt(d.columnPositions(scope)[idx], sc.Columns[idx].Expression)
+}
+
+// withSetOperations combines e, the type of output column idx of the first branch of
+// scope, with that column in the UNION, INTERSECT, and EXCEPT branches of scope: the
+// column has their common type and is nullable when it is in any branch. A branch with
+// a star counts as unknown.
+func (d *describer) withSetOperations(scope, idx int, e exprType) exprType {
+	values := []exprType{e}
+	for i, s := range d.pq.Scopes {
+		if s.Kind != postgresparser.ScopeSetOperation || s.Parent != scope {
+			continue
+		}
+		if slices.ContainsFunc(s.Columns, func(c postgresparser.SelectColumn) bool { return isStar(c.Expression) }) {
+			values = append(values, unknownType)
+			continue
+		}
+		values = append(values, d.scopeColumnType(i, idx))
+	}
+	if len(values) == 1 {
+		return e
+	}
+	out := commonType(d, values)
+	if out.typ == "" {
+		// NULL or string constants in every branch, which resolve to text.
+		out.literal = !slices.ContainsFunc(values, func(v exprType) bool { return !v.literal })
+	}
+	return out
+}
+
+// subqueryType types the first output column of the subquery whose opening
+// parenthesis is at open and whose query starts at pos.
+func (d *describer) subqueryType(open, pos int) exprType {
+	for i, s := range d.pq.Scopes {
+		if i > 0 && s.Start >= open && s.Start <= pos {
+			return d.scopeColumnType(i, 0)
+		}
+	}
+	return unknownType
+}
+
+// columnType types the column reference ref written at pos.
+func (d *describer) columnType(ref string, pos int) exprType {
+	res, ok := d.usages[pos]
+	if !ok || !strings.EqualFold(strings.Join(strings.Fields(res.Usage.Expression), ""), strings.Join(strings.Fields(ref), "")) {
+		res = analysis.ResolveReference(d.pq, d.schemaMap, ref, pos)
+	}
+	scope := sqllex.ScopeAt(d.pq.Scopes, pos)
+	switch res.Status {
+	case analysis.ColumnResolved:
+		t := d.lookupTable(res.Schema, res.Table)
+		if t == nil {
+			return unknownType
+		}
+		col := t.Column(res.Column)
+		if col == nil {
+			return unknownType
+		}
+		return exprType{typ: d.describeType(col.Type), nullable: col.Nullable || d.outerJoined(scope, res.Relation)}
+	case analysis.ColumnDerived:
+		parts, ok := sqlident.SplitChain(ref)
+		if !ok {
+			return unknownType
+		}
+		return d.derivedType(scope, res.Relation, normalizeIdent(parts[len(parts)-1]))
+	}
+	return unknownType
+}
+
+// derivedType types column col of the CTE or subquery relation rel visible from scope.
+func (d *describer) derivedType(scope int, rel, col string) exprType {
+	for s := scope; s >= 0; s = d.pq.Scopes[s].Parent {
+		for _, r := range d.pq.Scopes[s].Relations {
+			if !strings.EqualFold(relationName(r.Table), rel) || r.Scope < 0 {
+				continue
+			}
+			e := d.outputType(r.Scope, r.ColumnAliases, col)
+			e.nullable = e.nullable || d.outerJoined(s, rel)
+			return e
+		}
+	}
+	return unknownType
+}
+
+// outputType types output column col of scope, renamed by aliases if given.
+func (d *describer) outputType(scope int, aliases []string, col string) exprType {
+	if len(aliases) == 0 {
+		aliases = d.pq.Scopes[scope].ColumnAliases
+	}
+	cols := d.pq.Scopes[scope].Columns
+	idx := -1
+	for i, a := range aliases {
+		if normalizeIdent(a) == col {
+			idx = i
+			break
+		}
+	}
+	if idx < 0 {
+		for i := len(aliases); i < len(cols); i++ {
+			if sqlident.OutputName(cols[i]) == col {
+				idx = i
+				break
+			}
+		}
+	}
+	if idx < 0 || idx >= len(cols) {
+		return unknownType
+	}
+	for _, c := range cols[:idx] {
+		if isStar(c.Expression) {
+			// A star before the column shifts its position by an unknown count.
+			return unknownType
+		}
+	}
+	return d.scopeColumnType(scope, idx)
+}
+
+// starColumnType types a result column expanded from a star of scope.
+func (d *describer) starColumnType(scope int, rc analysis.ResultColumn) exprType {
+	rel, col, ok := strings.Cut(rc.Expression, ".")
+	if !ok {
+		// The merged column of a FULL join.
+		return unknownType
+	}
+	switch rc.Status {
+	case analysis.ColumnResolved:
+		t := d.lookupTable(rc.Schema, rc.Table)
+		if t == nil || t.Column(rc.Column) == nil {
+			return unknownType
+		}
+		c := t.Column(rc.Column)
+		return exprType{typ: d.describeType(c.Type), nullable: c.Nullable || d.outerJoined(scope, rel)}
+	case analysis.ColumnDerived:
+		return d.derivedType(scope, rel, col)
+	}
+	return unknownType
+}
+
+// outerJoined reports whether relation rel of scope is on the nullable side of an
+// outer join.
+func (d *describer) outerJoined(scope int, rel string) bool {
+	if rel == "" {
+		return false
+	}
+	for s := scope; s >= 0; s = d.pq.Scopes[s].Parent {
+		sc := d.pq.Scopes[s]
+		for ri, r := range sc.Relations {
+			if !strings.EqualFold(relationName(r.Table), rel) {
+				continue
+			}
+			for _, j := range sc.Joins {
+				switch {
+				case (j.Type == "LEFT" || j.Type == "FULL") && slices.Contains(j.Right, ri),
+					(j.Type == "RIGHT" || j.Type == "FULL") && slices.Contains(j.Left, ri):
+					return true
+				}
+			}
+			return false
+		}
+	}
+	return false
+}
+
+// inferParameters types parameters from the clauses of the statement: comparisons and
+// assignments in WHERE, ON, HAVING, SET, and select lists, inserted values, and LIMIT
+// and OFFSET.
+func (d *describer) inferParameters() {
+	targets := d.insertTargets()
+	for i, t := range d.tokens {
+		if t.Kind != sqllex.Ident {
+			continue
+		}
+		switch strings.ToUpper(t.Text) {
+		case "WHERE", "HAVING", "ON", "WHEN", "THEN", "ELSE", "RETURNING", "BY":
+			d.scanList(i+1, nil)
+		case "SET":
+			d.assigning = true
+			d.scanList(i+1, nil)
+			d.assigning = false
+		case "SELECT":
+			p := &exprParser{d: d, toks: d.tokens, i: i + 1}
+			p.accept("ALL")
+			if p.accept("DISTINCT") && p.accept("ON") {
+				p.skipParens()
+			}
+			d.scanList(p.i, d.sourceTargets(t.Pos, targets))
+		case "LIMIT", "OFFSET", "FIRST", "NEXT":
+			p := &exprParser{d: d, toks: d.tokens, i: i + 1}
+			e := p.expr(0)
+			d.noteParam(e, "bigint")
+			name := "limit"
+			if strings.EqualFold(t.Text, "OFFSET") {
+				name = "offset"
+			}
+			d.nameParam(e, name)
+		case "VALUES":
+			rowTargets := d.sourceTargets(t.Pos, targets)
+			p := &exprParser{d: d, toks: d.tokens, i: i + 1}
+			for p.accept("(") {
+				d.scanList(p.i, rowTargets)
+				p.i--
+				p.skipParens()
+				if !p.accept(",") {
+					break
+				}
+			}
+		}
+	}
+}
+
+// paramTarget is a column a list of values is written to.
+type paramTarget struct {
+	name     string
+	typ      string
+	nullable bool
+}
+
+// scanList types the comma-separated expressions starting at token i, assigning the
+// types and names of targets to bare parameters at the same index.
+func (d *describer) scanList(i int, targets []paramTarget) {
+	p := &exprParser{d: d, toks: d.tokens, i: i}
+	for k := 0; !p.failed; k++ {
+		if p.accept("DEFAULT") {
+			// DEFAULT in VALUES or SET.
+		} else {
+			e := p.expr(0)
+			if k < len(targets) {
+				d.noteParam(e, targets[k].typ)
+				d.nameParam(e, targets[k].name)
+				if e.param > 0 && targets[k].nullable {
+					d.nullParams[e.param] = true
+				}
+			}
+		}
+		if !p.accept(",") {
+			return
+		}
+	}
+}
+
+// insertTargets returns the columns an INSERT writes, in order.
+func (d *describer) insertTargets() []paramTarget {
+	if d.pq.Command != postgresparser.QueryCommandInsert || d.pq.Target == nil {
+		return nil
+	}
+	t := d.lookupTable(normalizeIdent(d.pq.Target.Schema), normalizeIdent(d.pq.Target.Name))
+	if t == nil {
+		return nil
+	}
+	var out []paramTarget
+	if len(d.pq.InsertColumns) == 0 {
+		for _, col := range t.Columns {
+			out = append(out, paramTarget{name: col.Name, typ: d.describeType(col.Type), nullable: col.Nullable})
+		}
+		return out
+	}
+	for _, name := range d.pq.InsertColumns {
+		target := paramTarget{name: normalizeIdent(name)}
+		if col := t.Column(name); col != nil {
+			target.typ, target.nullable = d.describeType(col.Type), col.Nullable
+		}
+		out = append(out, target)
+	}
+	return out
+}
+
+// sourceTargets returns targets when the SELECT or VALUES at pos starts the source
+// query of an INSERT.
+func (d *describer) sourceTargets(pos int, targets []paramTarget) []paramTarget {
+	for _, s := range d.pq.Scopes {
+		if s.Kind == postgresparser.ScopeSource && s.Parent == 0 && s.Start == pos {
+			return targets
+		}
+	}
+	return nil
+}
+
+// relationName returns the alias of a relation, or its name.
+func relationName(t postgresparser.TableRef) string {
+	if t.Alias != "" {
+		return normalizeIdent(t.Alias)
+	}
+	return normalizeIdent(t.Name)
+}
+
+// isStar reports whether a select-list expression is * or alias.*.
+func isStar(expr string) bool {
+	expr = strings.TrimSpace(expr)
+	return expr == "*" || strings.HasSuffix(expr, ".*")
+}
@@ -0,0 +1,301 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// describeCatalog is the schema the Describe tests run against.
+func describeCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	return applyAll(t,
+		"CREATE DOMAIN email AS varchar(255) NOT NULL",
+		"CREATE TABLE users (id bigserial PRIMARY KEY, email email NOT NULL, name text, tags text[], created_at timestamptz NOT NULL DEFAULT now())",
+		"CREATE TABLE orders (id bigserial PRIMARY KEY, user_id bigint NOT NULL REFERENCES users, total numeric(10,2) NOT NULL, qty int NOT NULL, meta jsonb, placed_on date)",
+	)
+}
+
+func TestDescribe(t *testing.T) {
+	c := describeCatalog(t)
+	tests := []struct {
+		name   string
+		sql    string
+		cols   []DescribedColumn
+		params []DescribedParameter
+	}{
+		{
+			name: "columns and a compared parameter",
+			sql:  "SELECT id, email, name FROM users WHERE email = $1",
+			cols: []DescribedColumn{
+				{Name: "id", Type: "bigint", Table: "public.users", Column: "id"},
+				{Name: "email", Type: "character varying(255)", Table: "public.users", Column: "email"},
+				{Name: "name", Type: "text", Nullable: true, Table: "public.users", Column: "name"},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "character varying(255)", Name: "email"},
+			},
+		},
+		{
+			name: "star over a left join",
+			sql:  "SELECT * FROM users u LEFT JOIN orders o ON o.user_id = u.id WHERE o.total > $1 LIMIT $2",
+			cols: []DescribedColumn{
+				{Name: "id", Type: "bigint", Table: "public.users", Column: "id"},
+				{Name: "email", Type: "character varying(255)", Table: "public.users", Column: "email"},
+				{Name: "name", Type: "text", Nullable: true, Table: "public.users", Column: "name"},
+				{Name: "tags", Type: "text[]", Nullable: true, Table: "public.users", Column: "tags"},
+				{Name: "created_at", Type: "timestamp with time zone", Table: "public.users", Column: "created_at"},
+				{Name: "id", Type: "bigint", Nullable: true, Table: "public.orders", Column: "id"},
+				{Name: "user_id", Type: "bigint", Nullable: true, Table: "public.orders", Column: "user_id"},
+				{Name: "total", Type: "numeric(10,2)", Nullable: true, Table: "public.orders", Column: "total"},
+				{Name: "qty", Type: "integer", Nullable: true, Table: "public.orders", Column: "qty"},
+				{Name: "meta", Type: "jsonb", Nullable: true, Table: "public.orders", Column: "meta"},
+				{Name: "placed_on", Type: "date", Nullable: true, Table: "public.orders", Column: "placed_on"},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "numeric(10,2)", Name: "total"},
+				{Position: 2, Type: "bigint", Name: "limit"},
+			},
+		},
+		{
+			name: "aggregates and functions",
+			sql:  "SELECT u.id, count(*) AS n, sum(o.qty), sum(o.total) AS spent, avg(o.qty), max(o.placed_on), coalesce(u.name, 'anon') AS display, now() FROM users u JOIN orders o ON o.user_id = u.id GROUP BY u.id",
+			cols: []DescribedColumn{
+				{Name: "id", Type: "bigint", Table: "public.users", Column: "id"},
+				{Name: "n", Type: "bigint"},
+				{Name: "sum", Type: "bigint", Nullable: true},
+				{Name: "spent", Type: "numeric", Nullable: true},
+				{Name: "avg", Type: "numeric", Nullable: true},
+				{Name: "max", Type: "date", Nullable: true},
+				{Name: "display", Type: "text"},
+				{Name: "now", Type: "timestamp with time zone"},
+			},
+		},
+		{
+			name: "literals, casts, and operators",
+			sql:  "SELECT 1 AS one, 2.5 AS half, 'x' AS s, true AS b, NULL AS nothing, $1::int AS p, CAST(total AS float8) AS f, qty * 2 AS double, total * qty AS amount, name || '!' AS shout, placed_on + 7 AS due, created_at - interval '1 day' AS yesterday FROM orders JOIN users ON users.id = orders.user_id",
+			cols: []DescribedColumn{
+				{Name: "one", Type: "integer"},
+				{Name: "half", Type: "numeric"},
+				{Name: "s", Type: "text"},
+				{Name: "b", Type: "boolean"},
+				{Name: "nothing", Type: "text", Nullable: true},
+				{Name: "p", Type: "integer", Nullable: true},
+				{Name: "f", Type: "double precision"},
+				{Name: "double", Type: "integer"},
+				{Name: "amount", Type: "numeric"},
+				{Name: "shout", Type: "text", Nullable: true},
+				{Name: "due", Type: "date", Nullable: true},
+				{Name: "yesterday", Type: "timestamp with time zone"},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "integer"},
+			},
+		},
+		{
+			name: "jsonb operators",
+			sql:  "SELECT meta -> 'a' AS a, meta ->> $1 AS b, meta @> $2 AS c FROM orders",
+			cols: []DescribedColumn{
+				{Name: "a", Type: "jsonb", Nullable: true},
+				{Name: "b", Type: "text", Nullable: true},
+				{Name: "c", Type: "boolean", Nullable: true},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "text"},
+				{Position: 2, Type: "jsonb", Name: "meta"},
+			},
+		},
+		{
+			name: "CASE, EXISTS, and a scalar subquery",
+			sql:  "SELECT CASE WHEN qty > $1 THEN 'big' ELSE 'small' END AS size, EXISTS (SELECT 1 FROM users) AS any, (SELECT max(id) FROM users) AS top FROM orders",
+			cols: []DescribedColumn{
+				{Name: "size", Type: "text"},
+				{Name: "any", Type: "boolean"},
+				{Name: "top", Type: "bigint", Nullable: true},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "integer", Name: "qty"},
+			},
+		},
+		{
+			name: "CTE output types",
+			sql:  "WITH spend AS (SELECT user_id, sum(total) AS spent FROM orders GROUP BY user_id) SELECT u.email, s.spent, s.user_id FROM users u JOIN spend s ON s.user_id = u.id",
+			cols: []DescribedColumn{
+				{Name: "email", Type: "character varying(255)", Table: "public.users", Column: "email"},
+				{Name: "spent", Type: "numeric", Nullable: true},
+				{Name: "user_id", Type: "bigint", Table: "public.orders", Column: "user_id"},
+			},
+		},
+		{
+			name: "IN list, ANY, BETWEEN, and LIKE",
+			sql:  "SELECT id FROM orders WHERE user_id IN ($1, $2) AND id = ANY($3) AND placed_on BETWEEN $4 AND $5 AND meta::text LIKE $6",
+			cols: []DescribedColumn{
+				{Name: "id", Type: "bigint", Table: "public.orders", Column: "id"},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "bigint", Name: "user_id"},
+				{Position: 2, Type: "bigint", Name: "user_id"},
+				{Position: 3, Type: "bigint[]", Name: "id"},
+				{Position: 4, Type: "date", Name: "placed_on"},
+				{Position: 5, Type: "date", Name: "placed_on"},
+				{Position: 6, Type: "text"},
+			},
+		},
+		{
+			name: "INSERT VALUES with RETURNING",
+			sql:  "INSERT INTO orders (user_id, total, qty) VALUES ($1, $2, $3) RETURNING id, total * qty AS amount",
+			cols: []DescribedColumn{
+				{Name: "id", Type: "bigint", Table: "public.orders", Column: "id"},
+				{Name: "amount", Type: "numeric"},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "bigint", Name: "user_id"},
+				{Position: 2, Type: "numeric(10,2)", Name: "total"},
+				{Position: 3, Type: "integer", Name: "qty"},
+			},
+		},
+		{
+			name: "INSERT SELECT",
+			sql:  "INSERT INTO users (email, name) SELECT $1, name FROM users WHERE id = $2",
+			params: []DescribedParameter{
+				{Position: 1, Type: "character varying(255)", Name: "email"},
+				{Position: 2, Type: "bigint", Name: "id"},
+			},
+		},
+		{
+			name: "UPDATE SET",
+			sql:  "UPDATE orders SET qty = $1, meta = meta || $2 WHERE id = $3 RETURNING *",
+			cols: []DescribedColumn{
+				{Name: "id", Type: "bigint", Table: "public.orders", Column: "id"},
+				{Name: "user_id", Type: "bigint", Table: "public.orders", Column: "user_id"},
+				{Name: "total", Type: "numeric(10,2)", Table: "public.orders", Column: "total"},
+				{Name: "qty", Type: "integer", Table: "public.orders", Column: "qty"},
+				{Name: "meta", Type: "jsonb", Nullable: true, Table: "public.orders", Column: "meta"},
+				{Name: "placed_on", Type: "date", Nullable: true, Table: "public.orders", Column: "placed_on"},
+			},
+			params: []DescribedParameter{
+				{Position: 1, Type: "integer", Name: "qty"},
+				{Position: 2, Type: "jsonb", Name: "meta", Nullable: true},
+				{Position: 3, Type: "bigint", Name: "id"},
+			},
+		},
+		{
+			name: "DELETE without RETURNING",
+			sql:  "DELETE FROM orders WHERE placed_on < now() - $1::interval",
+			params: []DescribedParameter{
+				{Position: 1, Type: "interval"},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			desc, err := Describe(tc.sql, c)
+			require.NoError(t, err, "describe %q", tc.sql)
+			assert.Equal(t, tc.cols, desc.Columns, "columns")
+			assert.Equal(t, tc.params, desc.Parameters, "parameters")
+		})
+	}
+}
+
+func TestDescribe_SourceColumns(t *testing.T) {
+	desc, err := Describe("SELECT u.email AS who, length(name) FROM users u", describeCatalog(t))
+	require.NoError(t, err, "describe failed")
+	require.Len(t, desc.Columns, 2, "columns")
+	assert.Equal(t, DescribedColumn{Name: "who", Type: "character varying(255)", Table: "public.users", Column: "email"}, desc.Columns[0])
+	assert.Equal(t, DescribedColumn{Name: "length", Type: "integer", Nullable: true}, desc.Columns[1])
+}
+
+func TestDescribe_SetOperations(t *testing.T) {
+	c := describeCatalog(t)
+	tests := []struct {
+		sql  string
+		want []DescribedColumn
+	}{
+		{"SELECT id FROM users UNION SELECT NULL", []DescribedColumn{{Name: "id", Type: "bigint", Nullable: true}}},
+		{"SELECT qty FROM orders UNION ALL SELECT user_id FROM orders", []DescribedColumn{{Name: "qty", Type: "bigint"}}},
+		{"SELECT name FROM users EXCEPT SELECT email FROM users", []DescribedColumn{{Name: "name", Type: "text", Nullable: true}}},
+		{
+			"SELECT email, placed_on FROM users, orders INTERSECT SELECT email, created_at FROM users",
+			[]DescribedColumn{{Name: "email", Type: "character varying(255)"}, {Name: "placed_on", Type: "timestamp with time zone", Nullable: true}},
+		},
+		{"SELECT 'a' UNION SELECT NULL", []DescribedColumn{{Name: "?column?", Type: "text", Nullable: true}}},
+	}
+	for _, tc := range tests {
+		desc, err := Describe(tc.sql, c)
+		require.NoError(t, err, "describe %q", tc.sql)
+		assert.Equal(t, tc.want, desc.Columns, "columns of %q", tc.sql)
+	}
+
+	desc, err := Describe("SELECT s.id FROM (SELECT id FROM users UNION SELECT NULL) s", c)
+	require.NoError(t, err, "describe derived table")
+	assert.Equal(t, []DescribedColumn{{Name: "id", Type: "bigint", Nullable: true}}, desc.Columns, "union in a derived table")
+}
+
+func TestDescribe_ConstantsAndCommonTypes(t *testing.T) {
+	desc, err := Describe("SELECT true, NULL, 1::int, 'x'::varchar(3), coalesce(email, name), coalesce(qty, user_id) FROM users JOIN orders ON orders.user_id = users.id", describeCatalog(t))
+	require.NoError(t, err, "describe failed")
+	assert.Equal(t, []DescribedColumn{
+		{Name: "bool", Type: "boolean"},
+		{Name: "?column?", Type: "text", Nullable: true},
+		{Name: "int4", Type: "integer"},
+		{Name: "varchar", Type: "character varying(3)"},
+		{Name: "coalesce", Type: "text"},
+		{Name: "coalesce", Type: "bigint"},
+	}, desc.Columns)
+}
+
+func TestDescribe_CastOperandNames(t *testing.T) {
+	desc, err := Describe("SELECT total::numeric * 2, id::int + qty, meta::jsonb -> 'a', total::numeric FROM orders", describeCatalog(t))
+	require.NoError(t, err, "describe failed")
+	var names []string
+	for _, c := range desc.Columns {
+		names = append(names, c.Name)
+	}
+	assert.Equal(t, []string{"?column?", "?column?", "?column?", "total"}, names, "a cast followed by an operator does not name the column")
+}
+
+func TestDescribe_ParameterNames(t *testing.T) {
+	c := describeCatalog(t)
+	tests := map[string][]string{
+		"SELECT id FROM orders WHERE user_id = $1 AND $2 < total AND id = ANY($3) LIMIT $4 OFFSET $5": {"user_id", "total", "id", "limit", "offset"},
+		"INSERT INTO orders (user_id, total, qty) VALUES ($1, $2, $3 + 1)":                            {"user_id", "total", ""},
+		"UPDATE orders SET qty = $1 WHERE id = $2":                                                    {"qty", "id"},
+		"SELECT $1::int AS n": {""},
+	}
+	for sql, want := range tests {
+		desc, err := Describe(sql, c)
+		require.NoError(t, err, "describe %q", sql)
+		var names []string
+		for _, p := range desc.Parameters {
+			names = append(names, p.Name)
+		}
+		assert.Equal(t, want, names, "parameter names of %q", sql)
+	}
+}
+
+func TestDescribe_NullableParameters(t *testing.T) {
+	c := describeCatalog(t)
+	desc, err := Describe("INSERT INTO orders (user_id, total, qty, meta) VALUES ($1, $2, $3, $4)", c)
+	require.NoError(t, err, "describe insert")
+	require.Len(t, desc.Parameters, 4, "insert parameters")
+	assert.False(t, desc.Parameters[0].Nullable, "user_id is NOT NULL")
+	assert.Equal(t, DescribedParameter{Position: 4, Type: "jsonb", Name: "meta", Nullable: true}, desc.Parameters[3])
+
+	desc, err = Describe("UPDATE users SET name = $1 WHERE id = $2 AND name <> $3", c)
+	require.NoError(t, err, "describe update")
+	require.Len(t, desc.Parameters, 3, "update parameters")
+	assert.Equal(t, DescribedParameter{Position: 1, Type: "text", Name: "name", Nullable: true}, desc.Parameters[0])
+	assert.False(t, desc.Parameters[1].Nullable, "compared with a NOT NULL column")
+	assert.False(t, desc.Parameters[2].Nullable, "compared, not assigned")
+}
+
+func TestDescribe_Errors(t *testing.T) {
+	_, err := Describe("SELECT * FROM missing", describeCatalog(t))
+	require.ErrorIs(t, err, analysis.ErrUnknownColumns, "star over an unknown table")
+
+	_, err = Describe("SELEC 1", describeCatalog(t))
+	assert.Error(t, err, "parse error")
+}
@@ -0,0 +1,362 @@
+// diff.go compares two catalogs and generates the DDL that migrates one into the other.
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// ChangeKind classifies a difference between two catalogs.
+type ChangeKind string
+
+const (
+	ChangeAdded    ChangeKind = "ADDED"
+	ChangeRemoved  ChangeKind = "REMOVED"
+	ChangeModified ChangeKind = "MODIFIED"
+)
+
+// SchemaDiff lists the differences between two catalogs. Only schemas, regular tables,
+// their columns and constraints, indexes, and sequences owned by a column (such as those
+// of serial columns) are compared; views, other sequences, types, owners, and comments
+// are not. A renamed object shows up as removed and added.
+type SchemaDiff struct {
+	AddedSchemas   []string       // Sorted by name
+	RemovedSchemas []string       // Sorted by name
+	Tables         []TableDiff    // Sorted by schema and name
+	Indexes        []IndexDiff    // Standalone indexes, sorted by schema and name
+	Sequences      []SequenceDiff // Sequences owned by a column, sorted by schema and name
+}
+
+// TableDiff describes an added, removed, or modified table.
+type TableDiff struct {
+	Schema      string
+	Name        string
+	Change      ChangeKind
+	From        *Table           // Nil for ADDED
+	To          *Table           // Nil for REMOVED
+	Columns     []ColumnDiff     // Column changes of a MODIFIED table: removed columns, then the rest in column order
+	Constraints []ConstraintDiff // Constraint changes of a MODIFIED table, sorted by name
+}
+
+// ColumnDiff describes an added, removed, or modified column. For MODIFIED columns the
+// flags tell which properties changed.
+type ColumnDiff struct {
+	Name            string
+	Change          ChangeKind
+	From            *Column // Nil for ADDED
+	To              *Column // Nil for REMOVED
+	TypeChanged     bool
+	NullableChanged bool
+	DefaultChanged  bool
+}
+
+// ConstraintDiff describes an added, removed, or modified constraint. A modified
+// constraint is dropped and re-added, unless only its NOT VALID mark was cleared.
+type ConstraintDiff struct {
+	Name   string
+	Change ChangeKind
+	From   *Constraint // Nil for ADDED
+	To     *Constraint // Nil for REMOVED
+}
+
+// IndexDiff describes an added, removed, or modified index. Indexes backing PRIMARY
+// KEY and UNIQUE constraints are reported through their constraints instead.
+type IndexDiff struct {
+	Schema string
+	Name   string
+	Change ChangeKind
+	From   *Index // Nil for ADDED
+	To     *Index // Nil for REMOVED
+}
+
+// SequenceDiff describes an added or removed sequence owned by a column. Owned
+// sequences are compared by name only.
+type SequenceDiff struct {
+	Schema string
+	Name   string
+	Change ChangeKind
+	From   *Sequence // Nil for ADDED
+	To     *Sequence // Nil for REMOVED
+}
+
+// Diff compares two catalogs and reports what changes from turns into to.
+//
+// Column types are compared after resolving common aliases (int and int4 are integer,
+// varchar is character varying, timestamptz is timestamp with time zone, ...); defaults,
+// CHECK expressions, and index predicates are compared with whitespace normalized.
+func Diff(from, to *Catalog) *SchemaDiff {
+	d := &SchemaDiff{}
+	for _, s := range to.Schemas() {
+		if from.schemas[s.Name] == nil {
+			d.AddedSchemas = append(d.AddedSchemas, s.Name)
+		}
+	}
+	for _, s := range from.Schemas() {
+		if to.schemas[s.Name] == nil {
+			d.RemovedSchemas = append(d.RemovedSchemas, s.Name)
+		}
+	}
+
+	fromTables, toTables := regularTables(from), regularTables(to)
+	for _, key := range unionKeys(fromTables, toTables) {
+		old, cur := fromTables[key], toTables[key]
+		switch {
+		case old == nil:
+			d.Tables = append(d.Tables, TableDiff{Schema: cur.Schema, Name: cur.Name, Change: ChangeAdded, To: cur})
+		case cur == nil:
+			d.Tables = append(d.Tables, TableDiff{Schema: old.Schema, Name: old.Name, Change: ChangeRemoved, From: old})
+		default:
+			td := TableDiff{Schema: cur.Schema, Name: cur.Name, Change: ChangeModified, From: old, To: cur}
+			td.Columns = diffColumns(old, cur)
+			td.Constraints = diffConstraints(old, cur)
+			if len(td.Columns) > 0 || len(td.Constraints) > 0 {
+				d.Tables = append(d.Tables, td)
+			}
+		}
+	}
+
+	fromIndexes, toIndexes := standaloneIndexes(from), standaloneIndexes(to)
+	for _, key := range unionKeys(fromIndexes, toIndexes) {
+		old, cur := fromIndexes[key], toIndexes[key]
+		switch {
+		case old == nil:
+			d.Indexes = append(d.Indexes, IndexDiff{Schema: cur.Schema, Name: cur.Name, Change: ChangeAdded, To: cur})
+		case cur == nil:
+			d.Indexes = append(d.Indexes, IndexDiff{Schema: old.Schema, Name: old.Name, Change: ChangeRemoved, From: old})
+		case !sameIndex(old, cur):
+			d.Indexes = append(d.Indexes, IndexDiff{Schema: cur.Schema, Name: cur.Name, Change: ChangeModified, From: old, To: cur})
+		}
+	}
+
+	fromSequences, toSequences := ownedSequences(from), ownedSequences(to)
+	for _, key := range unionKeys(fromSequences, toSequences) {
+		old, cur := fromSequences[key], toSequences[key]
+		switch {
+		case old == nil:
+			d.Sequences = append(d.Sequences, SequenceDiff{Schema: cur.Schema, Name: cur.Name, Change: ChangeAdded, To: cur})
+		case cur == nil:
+			d.Sequences = append(d.Sequences, SequenceDiff{Schema: old.Schema, Name: old.Name, Change: ChangeRemoved, From: old})
+		}
+	}
+	return d
+}
+
+// DiffScripts loads two schema scripts, such as an old and a new schema.sql, into
+// fresh catalogs with ApplyScript and compares them.
+func DiffScripts(fromSQL, toSQL string) (*SchemaDiff, error) {
+	from, to := New(), New()
+	if err := from.ApplyScript(fromSQL); err != nil {
+		return nil, fmt.Errorf("old schema: %w", err)
+	}
+	if err := to.ApplyScript(toSQL); err != nil {
+		return nil, fmt.Errorf("new schema: %w", err)
+	}
+	return Diff(from, to), nil
+}
+
+// Empty reports whether the catalogs compared equal.
+func (d *SchemaDiff) Empty() bool {
+	return len(d.AddedSchemas) == 0 && len(d.RemovedSchemas) == 0 && len(d.Tables) == 0 && len(d.Indexes) == 0 &&
+		len(d.Sequences) == 0
+}
+
+// regularTables returns the regular tables of c keyed by qualified name.
+func regularTables(c *Catalog) map[string]*Table {
+	out := make(map[string]*Table)
+	for _, t := range c.Tables() {
+		if t.Kind == postgresparser.DDLObjectTable {
+			out[t.QualifiedName()] = t
+		}
+	}
+	return out
+}
+
+// standaloneIndexes returns the indexes of regular tables that do not back a
+// constraint, keyed by qualified name.
+func standaloneIndexes(c *Catalog) map[string]*Index {
+	out := make(map[string]*Index)
+	for _, s := range c.Schemas() {
+		for _, idx := range s.Indexes() {
+			t := s.tables[idx.Table]
+			if t == nil || t.Kind != postgresparser.DDLObjectTable || t.Constraint(idx.Name) != nil {
+				continue
+			}
+			out[s.Name+"."+idx.Name] = idx
+		}
+	}
+	return out
+}
+
+// ownedSequences returns the sequences of c owned by a column, keyed by qualified name.
+func ownedSequences(c *Catalog) map[string]*Sequence {
+	out := make(map[string]*Sequence)
+	for _, s := range c.Schemas() {
+		for _, seq := range s.Sequences() {
+			if seq.OwnedBy != "" {
+				out[s.Name+"."+seq.Name] = seq
+			}
+		}
+	}
+	return out
+}
+
+// unionKeys returns the keys of both maps, sorted.
+func unionKeys[V any](a, b map[string]V) []string {
+	keys := make([]string, 0, len(a)+len(b))
+	for k := range a {
+		keys = append(keys, k)
+	}
+	for k := range b {
+		if _, ok := a[k]; !ok {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// diffColumns compares the columns of two versions of a table. Removed columns come
+// first in their old order, then kept and added columns in their new order.
+func diffColumns(old, cur *Table) []ColumnDiff {
+	var out []ColumnDiff
+	for _, col := range old.Columns {
+		if cur.Column(quoteIdent(col.Name)) == nil {
+			out = append(out, ColumnDiff{Name: col.Name, Change: ChangeRemoved, From: col})
+		}
+	}
+	for _, col := range cur.Columns {
+		prev := old.Column(quoteIdent(col.Name))
+		if prev == nil {
+			out = append(out, ColumnDiff{Name: col.Name, Change: ChangeAdded, To: col})
+			continue
+		}
+		cd := ColumnDiff{
+			Name:            col.Name,
+			Change:          ChangeModified,
+			From:            prev,
+			To:              col,
+			TypeChanged:     canonicalType(prev.Type) != canonicalType(col.Type),
+			NullableChanged: prev.Nullable != col.Nullable,
+			DefaultChanged:  normalizeExpr(prev.Default) != normalizeExpr(col.Default),
+		}
+		if cd.TypeChanged || cd.NullableChanged || cd.DefaultChanged {
+			out = append(out, cd)
+		}
+	}
+	return out
+}
+
+// diffConstraints compares the constraints of two versions of a table by name.
+func diffConstraints(old, cur *Table) []ConstraintDiff {
+	byName := func(t *Table) map[string]*Constraint {
+		out := make(map[string]*Constraint, len(t.Constraints))
+		for _, con := range t.Constraints {
+			out[con.Name] = con
+		}
+		return out
+	}
+	oldCons, curCons := byName(old), byName(cur)
+	var out []ConstraintDiff
+	for _, name := range unionKeys(oldCons, curCons) {
+		prev, con := oldCons[name], curCons[name]
+		switch {
+		case prev == nil:
+			out = append(out, ConstraintDiff{Name: name, Change: ChangeAdded, To: con})
+		case con == nil:
+			out = append(out, ConstraintDiff{Name: name, Change: ChangeRemoved, From: prev})
+		case !sameConstraint(prev, con) || prev.NotValid != con.NotValid:
+			out = append(out, ConstraintDiff{Name: name, Change: ChangeModified, From: prev, To: con})
+		}
+	}
+	return out
+}
+
+// sameConstraint reports whether two constraints have the same definition, ignoring NOT VALID.
+func sameConstraint(a, b *Constraint) bool {
+	return a.Type == b.Type &&
+		slices.Equal(a.Columns, b.Columns) &&
+		a.RefSchema == b.RefSchema && a.RefTable == b.RefTable &&
+		slices.Equal(a.RefColumns, b.RefColumns) &&
+		a.OnDelete == b.OnDelete && a.OnUpdate == b.OnUpdate &&
+		normalizeExpr(a.Expression) == normalizeExpr(b.Expression)
+}
+
+// sameIndex reports whether two indexes have the same definition.
+func sameIndex(a, b *Index) bool {
+	norm := func(elems []string) []string {
+		out := make([]string, len(elems))
+		for i, e := range elems {
+			out[i] = normalizeExpr(e)
+		}
+		return out
+	}
+	method := func(m string) string {
+		if m == "" {
+			return "btree"
+		}
+		return m
+	}
+	return a.Table == b.Table && a.Unique == b.Unique &&
+		method(a.Method) == method(b.Method) &&
+		slices.Equal(norm(a.Elements), norm(b.Elements)) &&
+		slices.Equal(a.Include, b.Include) &&
+		normalizeExpr(a.Predicate) == normalizeExpr(b.Predicate)
+}
+
+// normalizeExpr collapses whitespace so formatting differences do not count as changes.
+func normalizeExpr(expr string) string {
+	return strings.Join(strings.Fields(expr), " ")
+}
+
+// typeAliases maps alternative spellings of built-in types to the names PostgreSQL
+// reports in pg_dump output.
+var typeAliases = map[string]string{
+	"int":         "integer",
+	"int4":        "integer",
+	"int8":        "bigint",
+	"int2":        "smallint",
+	"bool":        "boolean",
+	"varchar":     "character varying",
+	"char":        "character",
+	"bpchar":      "character",
+	"float8":      "double precision",
+	"float4":      "real",
+	"decimal":     "numeric",
+	"timestamptz": "timestamp with time zone",
+	"timestamp":   "timestamp without time zone",
+	"timetz":      "time with time zone",
+	"time":        "time without time zone",
+	"varbit":      "bit varying",
+}
+
+// canonicalType normalizes a type name for comparison: lower case, single spaces, no
+// pg_catalog qualifier, and aliases resolved. Type modifiers and array brackets are kept,
+// e.g. "TIMESTAMPTZ(3)[]" becomes "timestamp(3) with time zone[]".
+func canonicalType(typ string) string {
+	typ = strings.TrimPrefix(strings.ToLower(normalizeExpr(typ)), "pg_catalog.")
+
+	arrays := ""
+	for strings.HasSuffix(typ, "[]") {
+		typ = strings.TrimSpace(strings.TrimSuffix(typ, "[]"))
+		arrays += "[]"
+	}
+	modifier := ""
+	if open := strings.Index(typ, "("); open >= 0 {
+		if end := strings.Index(typ[open:], ")"); end >= 0 {
+			modifier = strings.ReplaceAll(typ[open:open+end+1], " ", "")
+			typ = normalizeExpr(typ[:open] + " " + typ[open+end+1:])
+		}
+	}
+	if alias, ok := typeAliases[typ]; ok {
+		typ = alias
+	}
+	if head, zone, ok := strings.Cut(typ, " "); ok && (head == "timestamp" || head == "time") {
+		// The modifier goes before the zone clause: timestamp(3) with time zone.
+		return head + modifier + " " + zone + arrays
+	}
+	return typ + modifier + arrays
+}
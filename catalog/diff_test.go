@@ -0,0 +1,228 @@
+package catalog
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const diffOldSchema = `
+CREATE TABLE users (id int PRIMARY KEY, email varchar(100) NOT NULL, name text, legacy int);
+CREATE TABLE posts (id int PRIMARY KEY, user_id int REFERENCES users, body text);
+CREATE TABLE tags (id int PRIMARY KEY, post_id int REFERENCES posts);
+CREATE INDEX posts_user_idx ON posts (user_id);
+CREATE INDEX users_name_idx ON users (name);
+CREATE TABLE orders (id int PRIMARY KEY, user_id int, total numeric(12, 2),
+    CONSTRAINT orders_total_check CHECK (total >= 0) NOT VALID);
+`
+
+const diffNewSchema = `
+CREATE SCHEMA audit;
+CREATE TABLE users (id integer PRIMARY KEY, email character varying(255), name text DEFAULT 'anon' NOT NULL, "user" int UNIQUE);
+CREATE TABLE audit.log (id bigint PRIMARY KEY, user_id int REFERENCES public.users ON DELETE CASCADE, at timestamptz);
+CREATE INDEX users_name_idx ON users (lower(name) DESC) INCLUDE (email) WHERE name <> '';
+CREATE TABLE orders (id int PRIMARY KEY, user_id int REFERENCES users, total numeric(12,2),
+    CONSTRAINT orders_total_check CHECK (total >= 0));
+`
+
+func TestDiff_Report(t *testing.T) {
+	d, err := DiffScripts(diffOldSchema, diffNewSchema)
+	require.NoError(t, err, "diff failed")
+	assert.False(t, d.Empty(), "expected changes")
+	assert.Equal(t, []string{"audit"}, d.AddedSchemas, "added schemas mismatch")
+	assert.Empty(t, d.RemovedSchemas, "removed schemas mismatch")
+
+	changes := map[string]ChangeKind{}
+	for _, td := range d.Tables {
+		changes[td.Schema+"."+td.Name] = td.Change
+	}
+	assert.Equal(t, map[string]ChangeKind{
+		"audit.log":     ChangeAdded,
+		"public.orders": ChangeModified,
+		"public.posts":  ChangeRemoved,
+		"public.tags":   ChangeRemoved,
+		"public.users":  ChangeModified,
+	}, changes, "table changes mismatch")
+
+	var users TableDiff
+	for _, td := range d.Tables {
+		if td.Name == "users" {
+			users = td
+		}
+	}
+	require.Len(t, users.Columns, 4, "users column changes mismatch")
+	assert.Equal(t, ColumnDiff{Name: "legacy", Change: ChangeRemoved, From: users.From.Column("legacy")}, users.Columns[0], "removed column mismatch")
+	email := users.Columns[1]
+	assert.Equal(t, "email", email.Name, "column order mismatch")
+	assert.True(t, email.TypeChanged && email.NullableChanged && !email.DefaultChanged, "email changes mismatch: %+v", email)
+	name := users.Columns[2]
+	assert.True(t, !name.TypeChanged && name.NullableChanged && name.DefaultChanged, "name changes mismatch: %+v", name)
+	assert.Equal(t, ChangeAdded, users.Columns[3].Change, "user column mismatch")
+	require.Len(t, users.Constraints, 1, "users constraint changes mismatch")
+	assert.Equal(t, "users_user_key", users.Constraints[0].Name, "added constraint mismatch")
+
+	require.Len(t, d.Indexes, 2, "index changes mismatch")
+	assert.Equal(t, IndexDiff{Schema: "public", Name: "posts_user_idx", Change: ChangeRemoved, From: d.Indexes[0].From}, d.Indexes[0], "removed index mismatch")
+	assert.Equal(t, ChangeModified, d.Indexes[1].Change, "modified index mismatch")
+}
+
+func TestDiff_Statements(t *testing.T) {
+	d, err := DiffScripts(diffOldSchema, diffNewSchema)
+	require.NoError(t, err, "diff failed")
+	assert.Equal(t, []string{
+		"CREATE SCHEMA audit",
+		"DROP TABLE public.tags",
+		"DROP TABLE public.posts",
+		"DROP INDEX public.users_name_idx",
+		"CREATE TABLE audit.log (\n    id bigint NOT NULL,\n    user_id int,\n    at timestamptz,\n    CONSTRAINT log_pkey PRIMARY KEY (id)\n)",
+		"ALTER TABLE public.users DROP COLUMN legacy",
+		"ALTER TABLE public.users ALTER COLUMN email TYPE character varying(255)",
+		"ALTER TABLE public.users ALTER COLUMN email DROP NOT NULL",
+		"ALTER TABLE public.users ALTER COLUMN name SET DEFAULT 'anon'",
+		"ALTER TABLE public.users ALTER COLUMN name SET NOT NULL",
+		`ALTER TABLE public.users ADD COLUMN "user" int`,
+		"ALTER TABLE public.orders VALIDATE CONSTRAINT orders_total_check",
+		`ALTER TABLE public.users ADD CONSTRAINT users_user_key UNIQUE ("user")`,
+		"CREATE INDEX users_name_idx ON public.users (lower(name) DESC) INCLUDE (email) WHERE name <> ''",
+		"ALTER TABLE audit.log ADD CONSTRAINT log_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users ON DELETE CASCADE",
+		"ALTER TABLE public.orders ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users",
+	}, d.Statements(), "migration mismatch")
+}
+
+// TestDiff_RoundTrip applies the generated migration to the old schema and expects no
+// remaining differences.
+func TestDiff_RoundTrip(t *testing.T) {
+	for _, tc := range []struct{ name, from, to string }{
+		{"forward", diffOldSchema, diffNewSchema},
+		{"backward", diffNewSchema, diffOldSchema},
+		{"from empty", "", diffNewSchema},
+		{"to empty", diffNewSchema, ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to := New(), New()
+			require.NoError(t, from.ApplyScript(tc.from), "load old schema")
+			require.NoError(t, to.ApplyScript(tc.to), "load new schema")
+
+			migration := Diff(from, to).SQL()
+			require.NoError(t, from.ApplyScript(migration), "apply migration:\n%s", migration)
+			remaining := Diff(from, to)
+			assert.True(t, remaining.Empty(), "remaining changes:\n%s", remaining.SQL())
+		})
+	}
+}
+
+func TestDiff_Equivalent(t *testing.T) {
+	d, err := DiffScripts(
+		"CREATE TABLE t (a int4, b VARCHAR(10), c timestamptz(3), d bool[], e numeric(10,2));\nCREATE INDEX t_a ON t (a)",
+		"CREATE TABLE t (a integer, b character varying(10), c timestamp(3) with time zone, d boolean[], e numeric(10, 2));\nCREATE INDEX t_a ON t USING btree (a)",
+	)
+	require.NoError(t, err, "diff failed")
+	assert.True(t, d.Empty(), "aliases and formatting must not count as changes:\n%s", d.SQL())
+
+	dump, err := os.ReadFile("testdata/schema.sql")
+	require.NoError(t, err, "read dump")
+	d, err = DiffScripts(string(dump), string(dump))
+	require.NoError(t, err, "diff failed")
+	assert.True(t, d.Empty(), "a dump must equal itself:\n%s", d.SQL())
+}
+
+func TestDiff_DropOrder(t *testing.T) {
+	d, err := DiffScripts(`
+CREATE TABLE a (id int PRIMARY KEY);
+CREATE TABLE c (id int PRIMARY KEY, b_id int);
+CREATE TABLE b (id int PRIMARY KEY, a_id int REFERENCES a);
+ALTER TABLE c ADD FOREIGN KEY (b_id) REFERENCES b;
+`, "")
+	require.NoError(t, err, "diff failed")
+	assert.Equal(t, []string{"DROP TABLE public.c", "DROP TABLE public.b", "DROP TABLE public.a"}, d.Statements(), "referencing tables must be dropped first")
+}
+
+// TestDiff_DependentObjects drops a table whose foreign key references a unique
+// constraint that is dropped too, and a schema that still holds a sequence and a type.
+func TestDiff_DependentObjects(t *testing.T) {
+	from, to := New(), New()
+	require.NoError(t, from.ApplyScript(`
+CREATE TABLE users (id int PRIMARY KEY, email text CONSTRAINT users_email_key UNIQUE);
+CREATE TABLE sessions (id int PRIMARY KEY, email text REFERENCES users (email));
+CREATE SCHEMA app;
+CREATE TYPE app.mood AS ENUM ('ok');
+CREATE SEQUENCE app.seq;
+CREATE TABLE app.t (id int DEFAULT nextval('app.seq'), m app.mood);
+`), "load old schema")
+	require.NoError(t, to.ApplyScript("CREATE TABLE users (id int PRIMARY KEY, email text);"), "load new schema")
+
+	d := Diff(from, to)
+	assert.Equal(t, []string{
+		"DROP TABLE app.t",
+		"DROP TABLE public.sessions",
+		"ALTER TABLE public.users DROP CONSTRAINT users_email_key",
+		"DROP SCHEMA app CASCADE",
+	}, d.Statements(), "migration mismatch")
+	migration := d.SQL()
+	require.NoError(t, from.ApplyScript(migration), "apply migration:\n%s", migration)
+	assert.True(t, Diff(from, to).Empty(), "remaining changes")
+	assert.Nil(t, from.Schema("app"), "schema app remains")
+}
+
+func TestDiff_Serial(t *testing.T) {
+	d, err := DiffScripts("CREATE TABLE a (id integer);", "CREATE TABLE a (id serial);")
+	require.NoError(t, err, "diff failed")
+	assert.Equal(t, []string{
+		"CREATE SEQUENCE public.a_id_seq AS integer",
+		"ALTER TABLE public.a ALTER COLUMN id SET DEFAULT nextval('public.a_id_seq'::regclass)",
+		"ALTER TABLE public.a ALTER COLUMN id SET NOT NULL",
+		"ALTER SEQUENCE public.a_id_seq OWNED BY public.a.id",
+	}, d.Statements(), "integer to serial")
+
+	d, err = DiffScripts("CREATE TABLE a (id serial);", "CREATE TABLE a (id integer);")
+	require.NoError(t, err, "diff failed")
+	assert.Equal(t, []string{
+		"ALTER TABLE public.a ALTER COLUMN id DROP DEFAULT",
+		"ALTER TABLE public.a ALTER COLUMN id DROP NOT NULL",
+		"DROP SEQUENCE public.a_id_seq",
+	}, d.Statements(), "serial to integer")
+
+	d, err = DiffScripts("CREATE TABLE a (id bigserial PRIMARY KEY, n int);", `
+CREATE TABLE public.a (id bigint NOT NULL, n integer);
+CREATE SEQUENCE public.a_id_seq START WITH 1 INCREMENT BY 1 NO MINVALUE NO MAXVALUE CACHE 1;
+ALTER SEQUENCE public.a_id_seq OWNED BY public.a.id;
+ALTER TABLE ONLY public.a ALTER COLUMN id SET DEFAULT nextval('public.a_id_seq'::regclass);
+ALTER TABLE ONLY public.a ADD CONSTRAINT a_pkey PRIMARY KEY (id);`)
+	require.NoError(t, err, "diff failed")
+	assert.True(t, d.Empty(), "a serial column must equal its pg_dump form:\n%s", d.SQL())
+
+	for _, tc := range []struct{ name, from, to string }{
+		{"add serial table", "", "CREATE TABLE a (id serial PRIMARY KEY)"},
+		{"add serial column", "CREATE TABLE a (n int)", "CREATE TABLE a (n int, id bigserial)"},
+		{"drop serial column", "CREATE TABLE a (n int, id bigserial)", "CREATE TABLE a (n int)"},
+		{"drop serial table", "CREATE TABLE a (id serial PRIMARY KEY)", ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			from, to := New(), New()
+			require.NoError(t, from.ApplyScript(tc.from), "load old schema")
+			require.NoError(t, to.ApplyScript(tc.to), "load new schema")
+
+			migration := Diff(from, to).SQL()
+			require.NoError(t, from.ApplyScript(migration), "apply migration:\n%s", migration)
+			remaining := Diff(from, to)
+			assert.True(t, remaining.Empty(), "remaining changes:\n%s", remaining.SQL())
+		})
+	}
+}
+
+func TestCanonicalType(t *testing.T) {
+	for in, want := range map[string]string{
+		"INT":                            "integer",
+		"pg_catalog.int8":                "bigint",
+		"varchar(255)":                   "character varying(255)",
+		"Character Varying ( 255 )":      "character varying(255)",
+		"timestamptz(3)[]":               "timestamp(3) with time zone[]",
+		"timestamp(6) without time zone": "timestamp(6) without time zone",
+		"timestamp":                      "timestamp without time zone",
+		"public.citext":                  "public.citext",
+	} {
+		assert.Equal(t, want, canonicalType(in), "canonicalType(%q)", in)
+	}
+}
@@ -0,0 +1,59 @@
+// export.go converts the catalog into the metadata types of the analysis package.
+package catalog
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// ColumnSchemas returns the tables in the shape accepted by the schema-aware analysis
+// functions, such as analysis.ExtractJoinRelationshipsWithSchema.
+//
+// Every table is keyed by its lower-cased "schema.table" name. Unqualified names are
+// keyed too: they resolve to DefaultSchema first, then to the first schema in name
+// order that has a table of that name.
+func (c *Catalog) ColumnSchemas() map[string][]analysis.ColumnSchema {
+	out := make(map[string][]analysis.ColumnSchema)
+	tables := c.Tables()
+	// Tables is sorted by schema; move DefaultSchema to the front so it wins unqualified keys.
+	slices.SortStableFunc(tables, func(a, b *Table) int {
+		switch {
+		case a.Schema == b.Schema:
+			return 0
+		case a.Schema == DefaultSchema:
+			return -1
+		case b.Schema == DefaultSchema:
+			return 1
+		}
+		return 0
+	})
+	for _, t := range tables {
+		cols := t.ColumnSchemas()
+		out[strings.ToLower(t.QualifiedName())] = cols
+		if key := strings.ToLower(t.Name); out[key] == nil {
+			out[key] = cols
+		}
+	}
+	return out
+}
+
+// ColumnSchemas returns the table's columns in declaration order with primary key
+// membership taken from the PRIMARY KEY constraint.
+func (t *Table) ColumnSchemas() []analysis.ColumnSchema {
+	var pkCols []string
+	if pk := t.PrimaryKey(); pk != nil {
+		pkCols = pk.Columns
+	}
+	out := make([]analysis.ColumnSchema, 0, len(t.Columns))
+	for _, col := range t.Columns {
+		out = append(out, analysis.ColumnSchema{
+			Name:         col.Name,
+			PGType:       col.Type,
+			IsPrimaryKey: slices.Contains(pkCols, col.Name),
+			IsNullable:   col.Nullable,
+		})
+	}
+	return out
+}
@@ -0,0 +1,1030 @@
+// exprtype.go infers the types of SQL expressions for Describe.
+package catalog
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// exprType is the inferred type of an expression.
+type exprType struct {
+	typ      string // Canonical type name; empty when unknown
+	nullable bool
+	param    int    // n when the expression is the bare parameter $n
+	literal  bool   // A string constant or NULL, whose type PostgreSQL takes from context
+	column   string // Column name when the expression is a plain column reference
+}
+
+// unknownType is the type of an expression that cannot be typed.
+var unknownType = exprType{nullable: true}
+
+// Operator precedences, lowest first, following the PostgreSQL grammar.
+const (
+	precOr = iota + 1
+	precAnd
+	precNot
+	precIs
+	precCompare
+	precLike // BETWEEN, IN, LIKE, ILIKE, SIMILAR TO
+	precOp   // Other operators: ||, ->, @>, ...
+	precAdd
+	precMul
+	precExp
+	precUnary
+	precCast // ::, subscripts, COLLATE, AT TIME ZONE
+)
+
+// reservedWords end an expression; they are never column references.
+var reservedWords = map[string]bool{
+	"ALL": true, "AND": true, "AS": true, "ASC": true, "DESC": true, "DO": true, "ELSE": true,
+	"END": true, "EXCEPT": true, "FETCH": true, "FOR": true, "FROM": true, "GROUP": true,
+	"HAVING": true, "INTERSECT": true, "INTO": true, "JOIN": true, "LIMIT": true, "OFFSET": true,
+	"ON": true, "OR": true, "ORDER": true, "RETURNING": true, "SELECT": true, "SET": true,
+	"THEN": true, "UNION": true, "USING": true, "VALUES": true, "WHEN": true, "WHERE": true,
+	"WINDOW": true, "WITH": true,
+}
+
+// exprParser types one expression over tokens of the statement. Column references,
+// subqueries, and parameters are looked up through the describer.
+type exprParser struct {
+	d      *describer
+	toks   []sqllex.Token
+	i      int
+	failed bool
+}
+
+// peek returns the token k positions ahead, or a blank token past the end.
+func (p *exprParser) peek(k int) sqllex.Token {
+	if p.i+k < len(p.toks) {
+		return p.toks[p.i+k]
+	}
+	return sqllex.Token{Kind: sqllex.Punct}
+}
+
+// at reports whether the next tokens are the keywords or punctuation kws.
+func (p *exprParser) at(kws ...string) bool {
+	for k, kw := range kws {
+		if !p.peek(k).Is(kw) {
+			return false
+		}
+	}
+	return true
+}
+
+// accept consumes the next tokens if they are kws.
+func (p *exprParser) accept(kws ...string) bool {
+	if !p.at(kws...) {
+		return false
+	}
+	p.i += len(kws)
+	return true
+}
+
+// expect consumes kw or marks the parse as failed.
+func (p *exprParser) expect(kw string) {
+	if !p.accept(kw) {
+		p.failed = true
+	}
+}
+
+// done reports whether every token was consumed by a successful parse.
+func (p *exprParser) done() bool {
+	return !p.failed && p.i >= len(p.toks)
+}
+
+// skipParens skips a parenthesized token group starting at the current "(".
+func (p *exprParser) skipParens() {
+	depth := 0
+	for p.i < len(p.toks) {
+		t := p.toks[p.i]
+		p.i++
+		switch {
+		case t.Is("(") || t.Is("["):
+			depth++
+		case t.Is(")") || t.Is("]"):
+			depth--
+			if depth == 0 {
+				return
+			}
+		}
+	}
+	p.failed = true
+}
+
+// list parses a comma-separated list of expressions.
+func (p *exprParser) list() []exprType {
+	out := []exprType{p.expr(0)}
+	for !p.failed && p.accept(",") {
+		out = append(out, p.expr(0))
+	}
+	return out
+}
+
+// expr parses an expression whose operators bind at least as tightly as minPrec.
+func (p *exprParser) expr(minPrec int) exprType {
+	left := p.prefix()
+	for !p.failed {
+		prec := p.infixPrec()
+		if prec == 0 || prec < minPrec {
+			return left
+		}
+		left = p.infix(left, prec)
+	}
+	return left
+}
+
+// infixPrec returns the precedence of the operator at the current token, or 0.
+func (p *exprParser) infixPrec() int {
+	t := p.peek(0)
+	switch t.Kind {
+	case sqllex.Op:
+		switch t.Text {
+		case "::":
+			return precCast
+		case "=", "<>", "!=", "<", ">", "<=", ">=":
+			return precCompare
+		case "+", "-":
+			return precAdd
+		case "*", "/", "%":
+			return precMul
+		case "^":
+			return precExp
+		}
+		return precOp
+	case sqllex.Punct:
+		if t.Is("[") {
+			return precCast
+		}
+	case sqllex.Ident:
+		switch strings.ToUpper(t.Text) {
+		case "OR":
+			return precOr
+		case "AND":
+			return precAnd
+		case "IS", "ISNULL", "NOTNULL":
+			return precIs
+		case "BETWEEN", "IN", "LIKE", "ILIKE", "SIMILAR":
+			return precLike
+		case "NOT":
+			if p.peek(1).Is("BETWEEN") || p.peek(1).Is("IN") || p.peek(1).Is("LIKE") ||
+				p.peek(1).Is("ILIKE") || p.peek(1).Is("SIMILAR") {
+				return precLike
+			}
+		case "COLLATE":
+			return precCast
+		case "AT":
+			if p.peek(1).Is("TIME") {
+				return precCast
+			}
+		}
+	}
+	return 0
+}
+
+// infix parses the operator at the current token and its right operand.
+func (p *exprParser) infix(left exprType, prec int) exprType {
+	t := p.peek(0)
+	p.i++
+	boolean := exprType{typ: "boolean", nullable: left.nullable}
+	if t.Kind == sqllex.Ident {
+		switch strings.ToUpper(t.Text) {
+		case "OR", "AND":
+			right := p.expr(prec + 1)
+			p.d.noteParam(left, "boolean")
+			p.d.noteParam(right, "boolean")
+			return exprType{typ: "boolean", nullable: left.nullable || right.nullable}
+		case "ISNULL", "NOTNULL":
+			return exprType{typ: "boolean"}
+		case "IS":
+			p.accept("NOT")
+			switch {
+			case p.accept("NULL"), p.accept("TRUE"), p.accept("FALSE"), p.accept("UNKNOWN"):
+			case p.accept("DISTINCT", "FROM"):
+				right := p.expr(precIs + 1)
+				p.d.unify(left, right)
+			default:
+				p.failed = true
+			}
+			return exprType{typ: "boolean"}
+		case "COLLATE":
+			p.qualifiedName()
+			return left
+		case "AT":
+			p.i += 2 // TIME ZONE
+			p.expr(precCast + 1)
+			switch left.typ {
+			case "timestamp with time zone":
+				return exprType{typ: "timestamp without time zone", nullable: left.nullable}
+			case "timestamp without time zone":
+				return exprType{typ: "timestamp with time zone", nullable: left.nullable}
+			}
+			return exprType{typ: left.typ, nullable: left.nullable}
+		case "NOT":
+			return p.infix(left, prec)
+		case "BETWEEN":
+			p.accept("SYMMETRIC")
+			low := p.expr(precLike + 1)
+			p.expect("AND")
+			high := p.expr(precLike + 1)
+			p.d.unify(left, low)
+			p.d.unify(left, high)
+			boolean.nullable = left.nullable || low.nullable || high.nullable
+			return boolean
+		case "IN":
+			p.expect("(")
+			if p.atSubquery() {
+				p.i--
+				p.skipParens()
+				boolean.nullable = true
+				return boolean
+			}
+			for _, item := range p.list() {
+				p.d.unify(left, item)
+				boolean.nullable = boolean.nullable || item.nullable
+			}
+			p.expect(")")
+			return boolean
+		case "LIKE", "ILIKE", "SIMILAR":
+			p.accept("TO")
+			pattern := p.expr(precLike + 1)
+			if p.accept("ESCAPE") {
+				p.expr(precLike + 1)
+			}
+			p.d.noteParam(left, "text")
+			p.d.noteParam(pattern, "text")
+			boolean.nullable = left.nullable || pattern.nullable
+			return boolean
+		}
+	}
+	if t.Is("[") {
+		// Array subscript or slice, or jsonb subscript.
+		slice := false
+		if !p.at(":") {
+			p.d.noteParam(p.expr(0), "integer")
+		}
+		if p.accept(":") {
+			slice = true
+			if !p.at("]") {
+				p.d.noteParam(p.expr(0), "integer")
+			}
+		}
+		p.expect("]")
+		switch {
+		case strings.HasSuffix(left.typ, "[]") && !slice:
+			return exprType{typ: strings.TrimSuffix(left.typ, "[]"), nullable: true}
+		case strings.HasSuffix(left.typ, "[]"), left.typ == "jsonb":
+			return exprType{typ: left.typ, nullable: true}
+		}
+		return unknownType
+	}
+	if t.Text == "::" {
+		typ := p.typeName()
+		p.d.noteParam(left, typ)
+		return exprType{typ: typ, nullable: left.nullable}
+	}
+
+	// A comparison may apply to ANY, SOME, or ALL of an array or subquery.
+	if prec == precCompare && (p.at("ANY", "(") || p.at("SOME", "(") || p.at("ALL", "(")) {
+		p.i++
+		p.expect("(")
+		if p.atSubquery() {
+			p.i--
+			p.skipParens()
+			return exprType{typ: "boolean", nullable: true}
+		}
+		arr := p.expr(0)
+		p.expect(")")
+		if left.typ != "" && !left.literal {
+			p.d.noteParam(arr, left.typ+"[]")
+		}
+		p.d.nameParam(arr, left.column)
+		if strings.HasSuffix(arr.typ, "[]") {
+			p.d.noteParam(left, strings.TrimSuffix(arr.typ, "[]"))
+		}
+		return exprType{typ: "boolean", nullable: left.nullable || arr.nullable}
+	}
+
+	right := p.expr(prec + 1)
+	return binaryType(p.d, t.Text, left, right)
+}
+
+// binaryType returns the type of left op right and types parameter operands from the
+// other side.
+func binaryType(d *describer, op string, left, right exprType) exprType {
+	nullable := left.nullable || right.nullable
+	switch op {
+	case "=", "<>", "!=", "<", ">", "<=", ">=", "@>", "<@", "&&":
+		d.unify(left, right)
+		return exprType{typ: "boolean", nullable: nullable}
+	case "~", "~*", "!~", "!~*", "~~", "~~*", "!~~", "!~~*":
+		d.noteParam(left, "text")
+		d.noteParam(right, "text")
+		return exprType{typ: "boolean", nullable: nullable}
+	case "?":
+		d.noteParam(right, "text")
+		return exprType{typ: "boolean", nullable: nullable}
+	case "?|", "?&":
+		d.noteParam(right, "text[]")
+		return exprType{typ: "boolean", nullable: nullable}
+	case "->", "->>", "#>", "#>>":
+		if strings.HasPrefix(op, "#") {
+			d.noteParam(right, "text[]")
+		} else {
+			d.noteParam(right, "text")
+		}
+		if strings.HasSuffix(op, ">>") {
+			return exprType{typ: "text", nullable: true}
+		}
+		if left.typ == "json" {
+			return exprType{typ: "json", nullable: true}
+		}
+		return exprType{typ: "jsonb", nullable: true}
+	case "||":
+		for _, side := range []exprType{left, right} {
+			if side.typ == "jsonb" || strings.HasSuffix(side.typ, "[]") {
+				d.unify(left, right)
+				return exprType{typ: side.typ, nullable: nullable}
+			}
+		}
+		d.noteParam(left, "text")
+		d.noteParam(right, "text")
+		return exprType{typ: "text", nullable: nullable}
+	case "+", "-", "*", "/", "%", "^":
+		return arithmeticType(d, op, left, right)
+	}
+	return exprType{nullable: true}
+}
+
+// numericRank orders the numeric types by PostgreSQL's implicit promotion.
+var numericRank = map[string]int{
+	"smallint":         1,
+	"integer":          2,
+	"bigint":           3,
+	"numeric":          4,
+	"real":             5,
+	"double precision": 6,
+}
+
+// arithmeticType returns the type of left op right for an arithmetic operator.
+func arithmeticType(d *describer, op string, left, right exprType) exprType {
+	nullable := left.nullable || right.nullable
+	lt, rt := baseType(left.typ), baseType(right.typ)
+	if isTemporal(lt) || isTemporal(rt) {
+		return exprType{typ: temporalArithmetic(op, lt, rt, left.literal, right.literal), nullable: nullable}
+	}
+	d.unify(left, right)
+	if op == "^" {
+		if lt == "numeric" || rt == "numeric" {
+			return exprType{typ: "numeric", nullable: nullable}
+		}
+		return exprType{typ: "double precision", nullable: nullable}
+	}
+	switch {
+	case left.param > 0 && left.typ == "":
+		lt = rt
+	case right.param > 0 && right.typ == "":
+		rt = lt
+	}
+	if numericRank[lt] == 0 || numericRank[rt] == 0 {
+		return exprType{nullable: true}
+	}
+	if numericRank[rt] > numericRank[lt] {
+		lt = rt
+	}
+	return exprType{typ: lt, nullable: nullable}
+}
+
+// isTemporal reports whether typ is a date, time, or interval type.
+func isTemporal(typ string) bool {
+	switch typ {
+	case "date", "interval", "timestamp with time zone", "timestamp without time zone",
+		"time with time zone", "time without time zone":
+		return true
+	}
+	return false
+}
+
+// temporalArithmetic returns the result type of arithmetic on dates and times. A string
+// constant operand is taken to be an interval, or a timestamp when subtracted from one.
+func temporalArithmetic(op, lt, rt string, lLiteral, rLiteral bool) string {
+	if rLiteral {
+		rt = "interval"
+	}
+	if lLiteral {
+		lt = "interval"
+	}
+	switch {
+	case lt == "date" && numericRank[rt] > 0 && numericRank[rt] <= 2 && (op == "+" || op == "-"):
+		return "date"
+	case lt == "date" && rt == "date" && op == "-":
+		return "integer"
+	case lt == "date" && rt == "interval", lt == "interval" && rt == "date":
+		return "timestamp without time zone"
+	case strings.HasPrefix(lt, "timestamp") && rt == lt && op == "-":
+		return "interval"
+	case (strings.HasPrefix(lt, "timestamp") || strings.HasPrefix(lt, "time ")) && rt == "interval":
+		return lt
+	case lt == "interval" && (strings.HasPrefix(rt, "timestamp") || strings.HasPrefix(rt, "time ")) && op == "+":
+		return rt
+	case lt == "interval" && rt == "interval", lt == "interval" && numericRank[rt] > 0, numericRank[lt] > 0 && rt == "interval":
+		return "interval"
+	}
+	return ""
+}
+
+// baseType strips type modifiers: numeric(10,2) becomes numeric.
+func baseType(typ string) string {
+	if open := strings.Index(typ, "("); open >= 0 {
+		if end := strings.Index(typ[open:], ")"); end >= 0 {
+			return strings.TrimSpace(typ[:open] + typ[open+end+1:])
+		}
+	}
+	return typ
+}
+
+// atSubquery reports whether the current token starts a query, after "(".
+func (p *exprParser) atSubquery() bool {
+	return p.at("SELECT") || p.at("WITH") || p.at("VALUES") || p.at("TABLE")
+}
+
+// prefix parses an operand or a prefix operator.
+func (p *exprParser) prefix() exprType {
+	t := p.peek(0)
+	if p.i >= len(p.toks) {
+		p.failed = true
+		return unknownType
+	}
+	p.i++
+	switch t.Kind {
+	case sqllex.Number:
+		return numberType(t.Text)
+	case sqllex.String:
+		return exprType{typ: "text", literal: true}
+	case sqllex.Param:
+		n, _ := strconv.Atoi(t.Text[1:])
+		return exprType{typ: p.d.params[n], nullable: true, param: n}
+	case sqllex.Op:
+		switch t.Text {
+		case "-", "+":
+			operand := p.expr(precUnary)
+			return exprType{typ: operand.typ, nullable: operand.nullable}
+		case "*":
+			return unknownType
+		}
+	case sqllex.Punct:
+		if t.Is("(") {
+			if p.atSubquery() {
+				pos := p.peek(0).Pos
+				p.i--
+				p.skipParens()
+				return exprType{typ: p.d.subqueryType(t.Pos, pos).typ, nullable: true}
+			}
+			inner := p.list()
+			p.expect(")")
+			if len(inner) > 1 {
+				return exprType{typ: "record", nullable: false}
+			}
+			if p.at(".") {
+				// Field selection from a composite value.
+				p.i++
+				p.i++
+				return unknownType
+			}
+			return inner[0]
+		}
+	case sqllex.Ident, sqllex.Quoted:
+		return p.identifier(t)
+	}
+	p.failed = true
+	return unknownType
+}
+
+// numberType returns the type of a numeric constant.
+func numberType(text string) exprType {
+	text = strings.ReplaceAll(text, "_", "")
+	if strings.ContainsAny(text, ".eE") {
+		return exprType{typ: "numeric"}
+	}
+	if _, err := strconv.ParseInt(text, 10, 32); err == nil {
+		return exprType{typ: "integer"}
+	}
+	if _, err := strconv.ParseInt(text, 10, 64); err == nil {
+		return exprType{typ: "bigint"}
+	}
+	return exprType{typ: "numeric"}
+}
+
+// sqlValueFunctions are the functions called without parentheses.
+var sqlValueFunctions = map[string]string{
+	"CURRENT_DATE":      "date",
+	"CURRENT_TIME":      "time with time zone",
+	"CURRENT_TIMESTAMP": "timestamp with time zone",
+	"LOCALTIME":         "time without time zone",
+	"LOCALTIMESTAMP":    "timestamp without time zone",
+	"CURRENT_USER":      "name",
+	"CURRENT_ROLE":      "name",
+	"SESSION_USER":      "name",
+	"USER":              "name",
+	"CURRENT_CATALOG":   "name",
+	"CURRENT_SCHEMA":    "name",
+}
+
+// identifier parses an expression starting with a name: a keyword expression, a typed
+// constant, a function call, or a column reference.
+func (p *exprParser) identifier(t sqllex.Token) exprType {
+	word := strings.ToUpper(t.Text)
+	if t.Kind == sqllex.Ident {
+		if reservedWords[word] {
+			p.failed = true
+			return unknownType
+		}
+		switch word {
+		case "NULL":
+			return exprType{nullable: true, literal: true}
+		case "TRUE", "FALSE":
+			return exprType{typ: "boolean"}
+		case "NOT":
+			operand := p.expr(precNot)
+			p.d.noteParam(operand, "boolean")
+			return exprType{typ: "boolean", nullable: operand.nullable}
+		case "CASE":
+			return p.caseExpr()
+		case "CAST":
+			p.expect("(")
+			operand := p.expr(0)
+			p.expect("AS")
+			typ := p.typeName()
+			p.expect(")")
+			p.d.noteParam(operand, typ)
+			return exprType{typ: typ, nullable: operand.nullable}
+		case "EXISTS":
+			p.skipParens()
+			return exprType{typ: "boolean"}
+		case "ARRAY":
+			return p.arrayExpr()
+		case "ROW":
+			p.skipParens()
+			return exprType{typ: "record"}
+		case "INTERVAL":
+			if p.peek(0).Kind == sqllex.String {
+				p.i++
+				for p.peek(0).Kind == sqllex.Ident && isIntervalField(p.peek(0).Text) {
+					p.i++
+				}
+				return exprType{typ: "interval"}
+			}
+		}
+		if typ, ok := sqlValueFunctions[word]; ok && !p.at("(") {
+			return exprType{typ: typ}
+		}
+	}
+
+	// A type name followed by a string is a typed constant: date '2024-01-01'.
+	start := p.i - 1
+	p.i = start
+	if name := p.typeName(); name != "" && p.peek(0).Kind == sqllex.String && !p.failed {
+		p.i++
+		return exprType{typ: name}
+	}
+	p.i = start
+	p.failed = false
+
+	parts := p.qualifiedName()
+	if p.at("(") {
+		return p.functionCall(parts)
+	}
+	if p.accept(".", "*") {
+		return unknownType
+	}
+	ref := make([]string, len(parts))
+	for i, tok := range parts {
+		ref[i] = tok.Text
+	}
+	et := p.d.columnType(strings.Join(ref, "."), t.Pos)
+	et.column = normalizeIdent(ref[len(ref)-1])
+	return et
+}
+
+// isIntervalField reports whether word is an interval field qualifier, as in
+// INTERVAL '1' DAY TO SECOND.
+func isIntervalField(word string) bool {
+	switch strings.ToUpper(word) {
+	case "YEAR", "MONTH", "DAY", "HOUR", "MINUTE", "SECOND", "TO":
+		return true
+	}
+	return false
+}
+
+// qualifiedName consumes a dotted name and returns its parts.
+func (p *exprParser) qualifiedName() []sqllex.Token {
+	var parts []sqllex.Token
+	for {
+		t := p.peek(0)
+		if t.Kind != sqllex.Ident && t.Kind != sqllex.Quoted {
+			if len(parts) == 0 {
+				p.failed = true
+			}
+			return parts
+		}
+		parts = append(parts, t)
+		p.i++
+		if !p.at(".") || (p.peek(1).Kind != sqllex.Ident && p.peek(1).Kind != sqllex.Quoted) {
+			return parts
+		}
+		p.i++
+	}
+}
+
+// typeWords are the words that continue a multi-word type name.
+var typeWords = map[string][]string{
+	"double":    {"precision"},
+	"character": {"varying"},
+	"char":      {"varying"},
+	"bit":       {"varying"},
+	"national":  {"character", "char", "varying"},
+}
+
+// typeName parses a type name with modifiers and array brackets and returns its
+// canonical form.
+func (p *exprParser) typeName() string {
+	parts := p.qualifiedName()
+	if len(parts) == 0 {
+		return ""
+	}
+	words := make([]string, len(parts))
+	for i, t := range parts {
+		words[i] = t.Text
+	}
+	name := strings.Join(words, ".")
+	last := strings.ToLower(parts[len(parts)-1].Text)
+	for next := typeWords[last]; len(next) > 0; {
+		word := strings.ToLower(p.peek(0).Text)
+		if p.peek(0).Kind != sqllex.Ident || !slices.Contains(next, word) {
+			break
+		}
+		p.i++
+		name += " " + word
+		next = typeWords[word]
+	}
+	if p.at("(") {
+		start := p.i
+		p.skipParens()
+		var mods []string
+		for _, t := range p.toks[start:p.i] {
+			mods = append(mods, t.Text)
+		}
+		name += strings.Join(mods, "")
+	}
+	if last == "timestamp" || last == "time" {
+		if p.at("WITH", "TIME", "ZONE") || p.at("WITHOUT", "TIME", "ZONE") {
+			name += " " + strings.ToLower(p.peek(0).Text) + " time zone"
+			p.i += 3
+		}
+	}
+	for p.at("[") {
+		p.i++
+		if p.peek(0).Kind == sqllex.Number {
+			p.i++
+		}
+		p.expect("]")
+		name += "[]"
+	}
+	if p.accept("ARRAY") {
+		name += "[]"
+	}
+	return p.d.describeType(name)
+}
+
+// caseExpr parses the rest of a CASE expression. Its type is that of the first branch
+// with a known type; it is not null only when every branch, including ELSE, is not.
+func (p *exprParser) caseExpr() exprType {
+	var subject *exprType
+	if !p.at("WHEN") {
+		s := p.expr(0)
+		subject = &s
+	}
+	var branches []exprType
+	hasElse := false
+	for !p.failed && p.accept("WHEN") {
+		cond := p.expr(0)
+		if subject != nil {
+			p.d.unify(*subject, cond)
+		} else {
+			p.d.noteParam(cond, "boolean")
+		}
+		p.expect("THEN")
+		branches = append(branches, p.expr(0))
+	}
+	if p.accept("ELSE") {
+		branches = append(branches, p.expr(0))
+		hasElse = true
+	}
+	p.expect("END")
+	out := commonType(p.d, branches)
+	out.nullable = out.nullable || !hasElse
+	return out
+}
+
+// commonType returns the type shared by a list of values, as in CASE branches, COALESCE
+// arguments, or the columns of UNION branches: the common type of the known types as
+// resolveCommonType picks it, or that of the first string constant, also assigned to
+// parameters among them. It is nullable when any value is.
+func commonType(d *describer, values []exprType) exprType {
+	out := exprType{}
+	for _, v := range values {
+		switch {
+		case v.typ == "":
+		case out.typ == "" || out.literal && !v.literal:
+			out.typ, out.literal = v.typ, v.literal
+		case !v.literal:
+			out.typ = resolveCommonType(out.typ, v.typ)
+		}
+		out.nullable = out.nullable || v.nullable
+	}
+	if out.typ != "" && !out.literal {
+		for _, v := range values {
+			d.noteParam(v, out.typ)
+		}
+	}
+	out.literal = false
+	if out.typ == "" {
+		out.nullable = true
+	}
+	return out
+}
+
+// stringTypes are the types of the string category, in which text is preferred.
+var stringTypes = map[string]bool{"text": true, "character varying": true, "character": true, "name": true}
+
+// dateTimeRank orders the date and timestamp types by implicit promotion.
+var dateTimeRank = map[string]int{
+	"date":                        1,
+	"timestamp without time zone": 2,
+	"timestamp with time zone":    3,
+}
+
+// resolveCommonType returns the type PostgreSQL resolves two types to: the type itself
+// when they are equal, the type without modifiers when only those differ, text among
+// string types, and the type the other promotes to among numbers and among dates and
+// timestamps. Types of unrelated categories resolve to the first.
+func resolveCommonType(a, b string) string {
+	if a == b {
+		return a
+	}
+	ba, bb := baseType(a), baseType(b)
+	switch {
+	case ba == bb:
+		return ba
+	case stringTypes[ba] && stringTypes[bb]:
+		if ba == "text" || bb == "text" {
+			return "text"
+		}
+		return ba
+	case numericRank[ba] > 0 && numericRank[bb] > 0:
+		if numericRank[bb] > numericRank[ba] {
+			return bb
+		}
+		return ba
+	case dateTimeRank[ba] > 0 && dateTimeRank[bb] > 0:
+		if dateTimeRank[bb] > dateTimeRank[ba] {
+			return bb
+		}
+		return ba
+	}
+	return a
+}
+
+// arrayExpr parses the rest of ARRAY[...] or ARRAY(subquery).
+func (p *exprParser) arrayExpr() exprType {
+	if p.at("(") {
+		open, pos := p.peek(0).Pos, p.peek(1).Pos
+		p.skipParens()
+		if elem := p.d.subqueryType(open, pos).typ; elem != "" {
+			return exprType{typ: elem + "[]"}
+		}
+		return exprType{}
+	}
+	p.expect("[")
+	var elems []exprType
+	if !p.at("]") {
+		elems = p.list()
+	}
+	p.expect("]")
+	if elem := commonType(p.d, elems); elem.typ != "" {
+		return exprType{typ: elem.typ + "[]"}
+	}
+	return exprType{}
+}
+
+// functionCall parses the argument list and trailing clauses of a call to name.
+func (p *exprParser) functionCall(name []sqllex.Token) exprType {
+	fn := strings.ToLower(normalizeIdent(name[len(name)-1].Text))
+	p.expect("(")
+	var args []exprType
+	star := false
+	switch {
+	case fn == "extract" || fn == "position" || fn == "substring" || fn == "trim" || fn == "overlay":
+		// Keyword argument syntax, e.g. EXTRACT(field FROM source).
+		p.i--
+		p.skipParens()
+	case p.accept(")"):
+	case p.accept("*", ")"):
+		star = true
+	default:
+		p.accept("DISTINCT")
+		p.accept("ALL")
+		for {
+			if p.peek(1).Is("=>") {
+				p.i += 2
+			}
+			args = append(args, p.expr(0))
+			if !p.accept(",") {
+				break
+			}
+		}
+		if p.at("ORDER", "BY") {
+			// Ordered-set arguments of an aggregate do not affect its type.
+			for !p.failed && !p.at(")") {
+				if p.at("(") {
+					p.skipParens()
+				} else {
+					p.i++
+				}
+			}
+		}
+		p.expect(")")
+	}
+	if p.at("WITHIN", "GROUP") {
+		p.i += 2
+		p.skipParens()
+	}
+	if p.accept("FILTER") {
+		p.skipParens()
+	}
+	if p.accept("OVER") {
+		if p.at("(") {
+			p.skipParens()
+		} else {
+			p.i++
+		}
+	}
+	if p.failed {
+		return unknownType
+	}
+	return functionType(p.d, fn, args, star)
+}
+
+// functionResults maps built-in functions to a fixed result type.
+var functionResults = map[string]string{
+	"count": "bigint", "row_number": "bigint", "rank": "bigint", "dense_rank": "bigint",
+	"ntile": "integer", "percent_rank": "double precision", "cume_dist": "double precision",
+
+	"now": "timestamp with time zone", "clock_timestamp": "timestamp with time zone",
+	"statement_timestamp": "timestamp with time zone", "transaction_timestamp": "timestamp with time zone",
+	"to_timestamp": "timestamp with time zone", "to_date": "date", "make_date": "date",
+	"age": "interval", "make_interval": "interval", "date_part": "double precision",
+	"extract": "numeric", "to_number": "numeric",
+
+	"length": "integer", "char_length": "integer", "character_length": "integer",
+	"octet_length": "integer", "bit_length": "integer", "strpos": "integer", "position": "integer",
+	"array_length": "integer", "cardinality": "integer", "array_position": "integer",
+	"jsonb_array_length": "integer", "json_array_length": "integer",
+
+	"lower": "text", "upper": "text", "initcap": "text", "btrim": "text", "ltrim": "text",
+	"rtrim": "text", "trim": "text", "concat": "text", "concat_ws": "text", "substr": "text",
+	"substring": "text", "overlay": "text", "replace": "text", "left": "text", "right": "text",
+	"lpad": "text", "rpad": "text", "repeat": "text", "reverse": "text", "md5": "text",
+	"format": "text", "to_char": "text", "string_agg": "text", "translate": "text",
+	"split_part": "text", "regexp_replace": "text", "quote_ident": "text", "quote_literal": "text",
+	"encode": "text", "array_to_string": "text", "jsonb_typeof": "text", "json_typeof": "text",
+	"jsonb_pretty": "text", "jsonb_extract_path_text": "text", "json_extract_path_text": "text",
+	"version": "text", "string_to_array": "text[]", "regexp_split_to_array": "text[]",
+	"regexp_matches": "text[]",
+
+	"to_json": "json", "row_to_json": "json", "array_to_json": "json", "json_build_object": "json",
+	"json_build_array": "json", "json_agg": "json", "json_object_agg": "json",
+	"to_jsonb": "jsonb", "jsonb_build_object": "jsonb", "jsonb_build_array": "jsonb",
+	"jsonb_agg": "jsonb", "jsonb_object_agg": "jsonb", "jsonb_set": "jsonb", "jsonb_insert": "jsonb",
+	"jsonb_strip_nulls": "jsonb", "jsonb_extract_path": "jsonb", "jsonb_path_query_first": "jsonb",
+
+	"gen_random_uuid": "uuid", "uuid_generate_v4": "uuid",
+	"bool_and": "boolean", "bool_or": "boolean", "every": "boolean", "starts_with": "boolean",
+	"nextval": "bigint", "currval": "bigint", "setval": "bigint", "lastval": "bigint",
+	"txid_current": "bigint", "random": "double precision", "pi": "double precision",
+	"current_database": "name", "current_schema": "name", "pg_typeof": "regtype",
+}
+
+// aggregateFunctions return NULL for an empty group or frame, whatever their arguments.
+var aggregateFunctions = map[string]bool{
+	"sum": true, "avg": true, "min": true, "max": true, "array_agg": true, "string_agg": true,
+	"json_agg": true, "jsonb_agg": true, "json_object_agg": true, "jsonb_object_agg": true,
+	"bool_and": true, "bool_or": true, "every": true, "stddev": true, "variance": true,
+	"lag": true, "lead": true, "first_value": true, "last_value": true, "nth_value": true,
+}
+
+// notNullFunctions never return NULL.
+var notNullFunctions = map[string]bool{
+	"count": true, "row_number": true, "rank": true, "dense_rank": true, "ntile": true,
+	"percent_rank": true, "cume_dist": true, "now": true, "clock_timestamp": true,
+	"statement_timestamp": true, "transaction_timestamp": true, "concat": true,
+	"concat_ws": true, "gen_random_uuid": true, "uuid_generate_v4": true, "random": true,
+	"pi": true, "nextval": true, "version": true, "current_database": true,
+}
+
+// textArgumentFunctions take text as their first argument.
+var textArgumentFunctions = map[string]bool{
+	"lower": true, "upper": true, "initcap": true, "btrim": true, "ltrim": true, "rtrim": true,
+	"length": true, "char_length": true, "md5": true, "replace": true, "split_part": true,
+	"strpos": true, "left": true, "right": true, "lpad": true, "rpad": true, "reverse": true,
+	"starts_with": true, "substr": true, "to_date": true, "to_timestamp": true, "to_number": true,
+}
+
+// functionType returns the result type of a call to fn and types parameter arguments
+// where the function determines them.
+func functionType(d *describer, fn string, args []exprType, star bool) exprType {
+	nullable := false
+	for _, a := range args {
+		nullable = nullable || a.nullable
+	}
+	if textArgumentFunctions[fn] && len(args) > 0 {
+		d.noteParam(args[0], "text")
+	}
+	var typ string
+	switch fn {
+	case "coalesce", "greatest", "least", "nullif":
+		common := commonType(d, args)
+		switch fn {
+		case "coalesce":
+			common.nullable = true
+			for _, a := range args {
+				common.nullable = common.nullable && a.nullable
+			}
+		case "nullif":
+			common = exprType{typ: args[0].typ, nullable: true}
+		}
+		return common
+	case "min", "max", "abs", "ceil", "ceiling", "floor", "lag", "lead", "first_value",
+		"last_value", "nth_value", "generate_series", "mode":
+		if len(args) > 0 {
+			typ = args[0].typ
+		}
+	case "sum":
+		if len(args) > 0 {
+			switch t := baseType(args[0].typ); t {
+			case "smallint", "integer":
+				typ = "bigint"
+			case "bigint", "numeric":
+				typ = "numeric"
+			case "real", "double precision", "interval", "money":
+				typ = t
+			}
+		}
+	case "avg", "stddev", "variance":
+		if len(args) > 0 {
+			switch t := baseType(args[0].typ); t {
+			case "smallint", "integer", "bigint", "numeric":
+				typ = "numeric"
+			case "real", "double precision":
+				typ = "double precision"
+			case "interval":
+				typ = t
+			}
+		}
+	case "round", "trunc":
+		typ = "numeric"
+		if len(args) == 1 && args[0].typ == "double precision" {
+			typ = args[0].typ
+		}
+	case "date_trunc":
+		typ = "timestamp with time zone"
+		if len(args) > 1 && args[1].typ != "" && !args[1].literal {
+			typ = args[1].typ
+		}
+	case "array_agg":
+		if len(args) > 0 && args[0].typ != "" {
+			typ = args[0].typ + "[]"
+		}
+	case "unnest":
+		if len(args) == 1 && strings.HasSuffix(args[0].typ, "[]") {
+			typ = strings.TrimSuffix(args[0].typ, "[]")
+		}
+	default:
+		var known bool
+		if typ, known = functionResults[fn]; !known {
+			return unknownType
+		}
+	}
+	switch {
+	case star || notNullFunctions[fn]:
+		nullable = false
+	case aggregateFunctions[fn]:
+		nullable = true
+	}
+	if typ == "" {
+		nullable = true
+	}
+	return exprType{typ: typ, nullable: nullable}
+}
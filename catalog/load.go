@@ -0,0 +1,98 @@
+// load.go builds a catalog from multi-statement scripts such as pg_dump output.
+package catalog
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+)
+
+// Load reads a schema script, typically the output of pg_dump --schema-only, into a new
+// catalog. See ApplyScript for how the script is processed.
+func Load(r io.Reader) (*Catalog, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema: %w", err)
+	}
+	c := New()
+	if err := c.ApplyScript(string(data)); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// LoadFile reads the schema script at path into a new catalog.
+func LoadFile(path string) (*Catalog, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open schema: %w", err)
+	}
+	defer f.Close()
+	return Load(f)
+}
+
+// ApplyScript applies the DDL actions of every statement of a script in order.
+// Lines starting with a backslash are psql meta-commands, such as the \restrict lines
+// written by recent pg_dump versions, and are skipped. SET, SELECT, GRANT, and other
+// statements without catalog effects are ignored; SET and RESET statements are
+// skipped even when the parser rejects their value.
+//
+// Unqualified names are resolved against the search_path, which starts as the default
+// and follows the SET search_path and set_config('search_path', ...) statements of the
+// script.
+//
+// The whole script is parsed before anything is applied, so a syntax error leaves the
+// catalog unchanged. An error while applying names the line of the failing statement;
+// statements before it stay applied.
+func (c *Catalog) ApplyScript(sql string) error {
+	var parsed []*postgresparser.ParsedQuery
+	var lines []int
+	for _, stmt := range postgresparser.SplitStatements(stripMetaCommands(sql)) {
+		pq, err := postgresparser.ParseSQL(stmt.SQL)
+		if err != nil {
+			if isSessionCommand(stmt.SQL) {
+				continue
+			}
+			return fmt.Errorf("failed to parse SQL: %w", &postgresparser.StatementError{Line: stmt.Line, SQL: stmt.SQL, Err: err})
+		}
+		parsed = append(parsed, pq)
+		lines = append(lines, stmt.Line)
+	}
+	path := analysis.DefaultSearchPath
+	for i, pq := range parsed {
+		if schemas, ok := analysis.SearchPathSetting(pq.RawSQL); ok {
+			path = schemas
+			if schemas == nil {
+				path = analysis.DefaultSearchPath
+			}
+			continue
+		}
+		analysis.QualifyQuery(pq, c.SearchPath(path...))
+		if err := c.Apply(pq); err != nil {
+			return fmt.Errorf("statement at line %d: %w", lines[i], err)
+		}
+	}
+	return nil
+}
+
+// stripMetaCommands blanks out psql meta-command lines. Lines are kept, empty, so that
+// positions in parse errors still match the input.
+func stripMetaCommands(sql string) string {
+	lines := strings.Split(sql, "\n")
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), `\`) {
+			lines[i] = ""
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// isSessionCommand reports whether stmt is a SET or RESET statement.
+func isSessionCommand(stmt string) bool {
+	fields := strings.Fields(stmt)
+	return len(fields) > 0 && (strings.EqualFold(fields[0], "SET") || strings.EqualFold(fields[0], "RESET"))
+}
@@ -0,0 +1,371 @@
+// migrate.go renders a SchemaDiff as an ordered list of DDL statements.
+package catalog
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// Statements returns the DDL that migrates the old catalog of the diff into the new
+// one, without trailing semicolons. Statements are ordered so each one only depends on
+// objects that exist at that point:
+//
+//  1. CREATE SCHEMA for added schemas.
+//  2. CREATE SEQUENCE for added owned sequences, which column defaults may call.
+//  3. DROP CONSTRAINT for removed and modified foreign keys of kept tables.
+//  4. DROP TABLE for removed tables, referencing tables before the tables they reference.
+//  5. DROP CONSTRAINT for the other removed and modified constraints of kept tables,
+//     which the foreign keys of removed tables may have depended on.
+//  6. DROP INDEX for removed and modified indexes of kept tables.
+//  7. CREATE TABLE for added tables, without their foreign keys.
+//  8. ALTER TABLE ... ADD, ALTER, and DROP COLUMN for modified tables.
+//  9. DROP SEQUENCE for removed owned sequences whose column is kept, and ALTER
+//     SEQUENCE ... OWNED BY for added ones.
+//  10. ADD CONSTRAINT for added and modified constraints other than foreign keys, and
+//     VALIDATE CONSTRAINT for constraints that are no longer NOT VALID.
+//  11. CREATE INDEX for added and modified indexes.
+//  12. ADD CONSTRAINT for the foreign keys of added tables and modified tables.
+//  13. DROP SCHEMA ... CASCADE for removed schemas, which also drops the sequences,
+//     types, and views left in them, as the diff does not compare those.
+//
+// Column type changes are emitted without a USING clause, and data is not migrated:
+// review the output before running it against a populated database.
+func (d *SchemaDiff) Statements() []string {
+	var out []string
+	emit := func(format string, args ...any) {
+		out = append(out, fmt.Sprintf(format, args...))
+	}
+
+	for _, name := range d.AddedSchemas {
+		emit("CREATE SCHEMA %s", quoteIdent(name))
+	}
+	for _, sd := range d.Sequences {
+		if sd.Change == ChangeAdded {
+			out = append(out, createSequenceStatement(sd.To))
+		}
+	}
+
+	// Drop the foreign keys of kept tables, then the removed tables, and only then the
+	// other constraints of kept tables: the unique constraints a foreign key references
+	// cannot go while it exists.
+	dropConstraints := func(foreignKeys bool) {
+		for _, td := range d.Tables {
+			for _, cd := range td.Constraints {
+				if cd.Change == ChangeAdded || isForeignKey(cd.From) != foreignKeys || onlyValidated(cd) {
+					continue
+				}
+				emit("ALTER TABLE %s DROP CONSTRAINT %s", qualifiedName(td.Schema, td.Name), quoteIdent(cd.Name))
+			}
+		}
+	}
+	dropConstraints(true)
+	droppedTables := make(map[string]bool)
+	for _, t := range d.dropOrder() {
+		droppedTables[t.QualifiedName()] = true
+		emit("DROP TABLE %s", qualifiedName(t.Schema, t.Name))
+	}
+	dropConstraints(false)
+	for _, id := range d.Indexes {
+		// Indexes of dropped tables go with their table.
+		if id.Change != ChangeAdded && !droppedTables[id.From.Schema+"."+id.From.Table] {
+			emit("DROP INDEX %s", qualifiedName(id.Schema, id.Name))
+		}
+	}
+
+	for _, td := range d.Tables {
+		if td.Change == ChangeAdded {
+			out = append(out, createTableStatement(td.To))
+		}
+	}
+	for _, td := range d.Tables {
+		if td.Change != ChangeModified {
+			continue
+		}
+		table := qualifiedName(td.Schema, td.Name)
+		for _, cd := range td.Columns {
+			col := quoteIdent(cd.Name)
+			switch cd.Change {
+			case ChangeAdded:
+				emit("ALTER TABLE %s ADD COLUMN %s", table, columnDefinition(cd.To))
+			case ChangeRemoved:
+				emit("ALTER TABLE %s DROP COLUMN %s", table, col)
+			default:
+				if cd.TypeChanged {
+					emit("ALTER TABLE %s ALTER COLUMN %s TYPE %s", table, col, cd.To.Type)
+				}
+				if cd.DefaultChanged {
+					if cd.To.Default == "" {
+						emit("ALTER TABLE %s ALTER COLUMN %s DROP DEFAULT", table, col)
+					} else {
+						emit("ALTER TABLE %s ALTER COLUMN %s SET DEFAULT %s", table, col, cd.To.Default)
+					}
+				}
+				if cd.NullableChanged {
+					if cd.To.Nullable {
+						emit("ALTER TABLE %s ALTER COLUMN %s DROP NOT NULL", table, col)
+					} else {
+						emit("ALTER TABLE %s ALTER COLUMN %s SET NOT NULL", table, col)
+					}
+				}
+			}
+		}
+	}
+
+	// Dropping a table or column drops the sequences it owns.
+	removedColumns := make(map[string]bool)
+	for _, td := range d.Tables {
+		for _, cd := range td.Columns {
+			if cd.Change == ChangeRemoved {
+				removedColumns[td.Schema+"."+td.Name+"."+cd.Name] = true
+			}
+		}
+	}
+	for _, sd := range d.Sequences {
+		switch {
+		case sd.Change == ChangeAdded:
+			schema, table, column := splitOwnedBy(sd.To.OwnedBy)
+			emit("ALTER SEQUENCE %s OWNED BY %s.%s", qualifiedName(sd.Schema, sd.Name), qualifiedName(schema, table), quoteIdent(column))
+		case slices.Contains(d.RemovedSchemas, sd.Schema) || removedColumns[sd.From.OwnedBy]:
+		default:
+			if schema, table, _ := splitOwnedBy(sd.From.OwnedBy); !droppedTables[schema+"."+table] {
+				emit("DROP SEQUENCE %s", qualifiedName(sd.Schema, sd.Name))
+			}
+		}
+	}
+
+	for _, td := range d.Tables {
+		for _, cd := range td.Constraints {
+			switch {
+			case cd.Change == ChangeRemoved || isForeignKey(cd.To):
+			case onlyValidated(cd):
+				emit("ALTER TABLE %s VALIDATE CONSTRAINT %s", qualifiedName(td.Schema, td.Name), quoteIdent(cd.Name))
+			default:
+				emit("ALTER TABLE %s ADD %s", qualifiedName(td.Schema, td.Name), constraintDefinition(cd.To))
+			}
+		}
+	}
+	for _, id := range d.Indexes {
+		if id.Change != ChangeRemoved {
+			out = append(out, createIndexStatement(id.To))
+		}
+	}
+	for _, td := range d.Tables {
+		switch td.Change {
+		case ChangeAdded:
+			for _, con := range td.To.ForeignKeys() {
+				emit("ALTER TABLE %s ADD %s", qualifiedName(td.Schema, td.Name), constraintDefinition(con))
+			}
+		case ChangeModified:
+			for _, cd := range td.Constraints {
+				if cd.Change != ChangeRemoved && isForeignKey(cd.To) && !onlyValidated(cd) {
+					emit("ALTER TABLE %s ADD %s", qualifiedName(td.Schema, td.Name), constraintDefinition(cd.To))
+				}
+			}
+		}
+	}
+
+	for _, name := range d.RemovedSchemas {
+		emit("DROP SCHEMA %s CASCADE", quoteIdent(name))
+	}
+	return out
+}
+
+// SQL returns Statements as a script, one statement per line.
+func (d *SchemaDiff) SQL() string {
+	var b strings.Builder
+	for _, stmt := range d.Statements() {
+		b.WriteString(stmt)
+		b.WriteString(";\n")
+	}
+	return b.String()
+}
+
+// dropOrder returns the removed tables so that a table is dropped before any removed
+// table its foreign keys reference. Foreign key cycles are broken in name order.
+func (d *SchemaDiff) dropOrder() []*Table {
+	removed := make(map[string]*Table)
+	var names []string
+	for _, td := range d.Tables {
+		if td.Change == ChangeRemoved {
+			removed[td.From.QualifiedName()] = td.From
+			names = append(names, td.From.QualifiedName())
+		}
+	}
+	// referencedBy counts the removed tables still referencing each removed table.
+	referencedBy := make(map[string]int)
+	for _, name := range names {
+		for _, ref := range referencedTables(removed[name]) {
+			if removed[ref] != nil && ref != name {
+				referencedBy[ref]++
+			}
+		}
+	}
+
+	var out []*Table
+	done := make(map[string]bool)
+	for len(out) < len(names) {
+		next := ""
+		for _, name := range names {
+			if !done[name] && referencedBy[name] == 0 {
+				next = name
+				break
+			}
+		}
+		if next == "" {
+			for _, name := range names {
+				if !done[name] {
+					next = name
+					break
+				}
+			}
+		}
+		done[next] = true
+		out = append(out, removed[next])
+		for _, ref := range referencedTables(removed[next]) {
+			if removed[ref] != nil && ref != next {
+				referencedBy[ref]--
+			}
+		}
+	}
+	return out
+}
+
+// referencedTables returns the qualified names of the tables t's foreign keys reference.
+func referencedTables(t *Table) []string {
+	var out []string
+	for _, con := range t.ForeignKeys() {
+		out = append(out, con.RefSchema+"."+con.RefTable)
+	}
+	return out
+}
+
+// isForeignKey reports whether con is a FOREIGN KEY constraint.
+func isForeignKey(con *Constraint) bool {
+	return con != nil && con.Type == postgresparser.DDLConstraintForeignKey
+}
+
+// onlyValidated reports whether a modified constraint only lost its NOT VALID mark,
+// which VALIDATE CONSTRAINT handles without dropping it.
+func onlyValidated(cd ConstraintDiff) bool {
+	return cd.Change == ChangeModified && cd.From.NotValid && !cd.To.NotValid && sameConstraint(cd.From, cd.To)
+}
+
+// createTableStatement renders CREATE TABLE with columns and every constraint except
+// foreign keys, which Statements adds once all tables exist.
+func createTableStatement(t *Table) string {
+	var elems []string
+	for _, col := range t.Columns {
+		elems = append(elems, columnDefinition(col))
+	}
+	for _, con := range t.Constraints {
+		if !isForeignKey(con) {
+			elems = append(elems, constraintDefinition(con))
+		}
+	}
+	return fmt.Sprintf("CREATE TABLE %s (\n    %s\n)", qualifiedName(t.Schema, t.Name), strings.Join(elems, ",\n    "))
+}
+
+// columnDefinition renders a column as in CREATE TABLE or ADD COLUMN.
+func columnDefinition(col *Column) string {
+	def := quoteIdent(col.Name) + " " + col.Type
+	if col.Default != "" {
+		def += " DEFAULT " + col.Default
+	}
+	if !col.Nullable {
+		def += " NOT NULL"
+	}
+	return def
+}
+
+// constraintDefinition renders a named table constraint as in CREATE TABLE or ADD CONSTRAINT.
+func constraintDefinition(con *Constraint) string {
+	var body string
+	switch con.Type {
+	case postgresparser.DDLConstraintPrimaryKey:
+		body = "PRIMARY KEY (" + quoteIdents(con.Columns) + ")"
+	case postgresparser.DDLConstraintUnique:
+		body = "UNIQUE (" + quoteIdents(con.Columns) + ")"
+	case postgresparser.DDLConstraintForeignKey:
+		body = "FOREIGN KEY (" + quoteIdents(con.Columns) + ") REFERENCES " + qualifiedName(con.RefSchema, con.RefTable)
+		if len(con.RefColumns) > 0 {
+			body += " (" + quoteIdents(con.RefColumns) + ")"
+		}
+		if con.OnDelete != "" {
+			body += " ON DELETE " + con.OnDelete
+		}
+		if con.OnUpdate != "" {
+			body += " ON UPDATE " + con.OnUpdate
+		}
+	case postgresparser.DDLConstraintCheck:
+		body = "CHECK (" + con.Expression + ")"
+	default:
+		body = "EXCLUDE " + con.Expression
+	}
+	if con.NotValid {
+		body += " NOT VALID"
+	}
+	return "CONSTRAINT " + quoteIdent(con.Name) + " " + body
+}
+
+// createIndexStatement renders CREATE INDEX for a standalone index.
+func createIndexStatement(idx *Index) string {
+	var b strings.Builder
+	b.WriteString("CREATE ")
+	if idx.Unique {
+		b.WriteString("UNIQUE ")
+	}
+	fmt.Fprintf(&b, "INDEX %s ON %s", quoteIdent(idx.Name), qualifiedName(idx.Schema, idx.Table))
+	if idx.Method != "" {
+		b.WriteString(" USING " + idx.Method)
+	}
+	b.WriteString(" (" + strings.Join(idx.Elements, ", ") + ")")
+	if len(idx.Include) > 0 {
+		b.WriteString(" INCLUDE (" + quoteIdents(idx.Include) + ")")
+	}
+	if idx.Predicate != "" {
+		b.WriteString(" WHERE " + idx.Predicate)
+	}
+	return b.String()
+}
+
+// createSequenceStatement renders CREATE SEQUENCE for a sequence, without its owner.
+func createSequenceStatement(seq *Sequence) string {
+	var b strings.Builder
+	b.WriteString("CREATE SEQUENCE " + qualifiedName(seq.Schema, seq.Name))
+	if seq.DataType != "" {
+		b.WriteString(" AS " + seq.DataType)
+	}
+	if seq.Start != "" {
+		b.WriteString(" START " + seq.Start)
+	}
+	if seq.Increment != "" {
+		b.WriteString(" INCREMENT " + seq.Increment)
+	}
+	return b.String()
+}
+
+// splitOwnedBy splits the schema.table.column owner of a sequence.
+func splitOwnedBy(ownedBy string) (schema, table, column string) {
+	schema, rest, _ := strings.Cut(ownedBy, ".")
+	if i := strings.LastIndexByte(rest, '.'); i >= 0 {
+		return schema, rest[:i], rest[i+1:]
+	}
+	return schema, rest, ""
+}
+
+// qualifiedName renders schema.name with quoting as needed.
+func qualifiedName(schema, name string) string {
+	return quoteIdent(schema) + "." + quoteIdent(name)
+}
+
+// quoteIdents quotes and comma-separates names.
+func quoteIdents(names []string) string {
+	quoted := make([]string, len(names))
+	for i, name := range names {
+		quoted[i] = quoteIdent(name)
+	}
+	return strings.Join(quoted, ", ")
+}
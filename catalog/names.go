@@ -0,0 +1,176 @@
+// names.go implements PostgreSQL identifier folding and generated constraint, index, and
+// sequence names.
+package catalog
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// normalizeIdent folds an identifier the way PostgreSQL does: quoted identifiers lose
+// their quotes and keep their case, unquoted ones are lower-cased. Text that is not a
+// plain identifier (an index expression, for example) is returned trimmed but unchanged.
+func normalizeIdent(name string) string {
+	name = strings.TrimSpace(name)
+	if len(name) >= 2 && name[0] == '"' && name[len(name)-1] == '"' {
+		return strings.ReplaceAll(name[1:len(name)-1], `""`, `"`)
+	}
+	if !isPlainIdent(name) {
+		return name
+	}
+	return strings.ToLower(name)
+}
+
+// isPlainIdent reports whether name is an unquoted identifier.
+func isPlainIdent(name string) bool {
+	if name == "" {
+		return false
+	}
+	for i, r := range name {
+		switch {
+		case r == '_' || r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r > 0x7f:
+		case i > 0 && (r >= '0' && r <= '9' || r == '$'):
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// normalizeIdents applies normalizeIdent to each name.
+func normalizeIdents(names []string) []string {
+	if len(names) == 0 {
+		return nil
+	}
+	out := make([]string, len(names))
+	for i, name := range names {
+		out[i] = normalizeIdent(name)
+	}
+	return out
+}
+
+// maxIdentLen is the longest identifier PostgreSQL keeps, in bytes (NAMEDATALEN - 1).
+const maxIdentLen = 63
+
+// generatedName builds a name the way PostgreSQL's ChooseRelationName does: the table,
+// the columns, and a suffix joined by underscores, with a number appended to the suffix
+// until the name is not taken. Index expressions contribute "expr".
+func generatedName(table string, columns []string, suffix string, taken func(string) bool) string {
+	parts := make([]string, len(columns))
+	for i, col := range columns {
+		if strings.Contains(col, "(") {
+			col = "expr"
+		}
+		parts[i] = col
+	}
+	name2 := strings.Join(parts, "_")
+	name := makeObjectName(table, name2, suffix)
+	for i := 1; taken(name); i++ {
+		name = makeObjectName(table, name2, suffix+strconv.Itoa(i))
+	}
+	return name
+}
+
+// makeObjectName joins name1, name2, and label with underscores like PostgreSQL's
+// makeObjectName, shortening the longer of name1 and name2 until the result fits in
+// maxIdentLen bytes. The label is never shortened.
+func makeObjectName(name1, name2, label string) string {
+	avail := maxIdentLen - len(label) - 1
+	if name2 != "" {
+		avail--
+	}
+	n1, n2 := len(name1), len(name2)
+	for n1+n2 > avail && n1+n2 > 0 {
+		if n1 > n2 {
+			n1--
+		} else {
+			n2--
+		}
+	}
+	name := clipUTF8(name1, n1)
+	if name2 != "" {
+		name += "_" + clipUTF8(name2, n2)
+	}
+	return name + "_" + label
+}
+
+// clipUTF8 returns the longest prefix of s of at most n bytes that does not split a
+// character.
+func clipUTF8(s string, n int) string {
+	if len(s) <= n {
+		return s
+	}
+	for n > 0 && !utf8.RuneStart(s[n]) {
+		n--
+	}
+	return s[:n]
+}
+
+// checkColumns returns the columns of t a CHECK expression references, each once, in
+// order of first reference.
+func checkColumns(t *Table, expr string) []string {
+	var out []string
+	toks := sqllex.Lex(expr)
+	for i, tok := range toks {
+		if tok.Kind != sqllex.Ident && tok.Kind != sqllex.Quoted {
+			continue
+		}
+		// Skip type names, function names, and the qualifiers of column references.
+		if i > 0 && toks[i-1].Is("::") || i+1 < len(toks) && (toks[i+1].Is("(") || toks[i+1].Is(".")) {
+			continue
+		}
+		if col := t.Column(tok.Text); col != nil && !slices.Contains(out, col.Name) {
+			out = append(out, col.Name)
+		}
+	}
+	return out
+}
+
+// renameIndexElement replaces the column of a plain index element, keeping its options.
+func renameIndexElement(elem, column string) string {
+	fields := strings.Fields(elem)
+	fields[0] = quoteIdent(column)
+	return strings.Join(fields, " ")
+}
+
+// quoteIdent quotes name when PostgreSQL would not read it back unchanged: when it is
+// not a lower-case plain identifier or is a reserved keyword.
+func quoteIdent(name string) string {
+	if isPlainIdent(name) && name == strings.ToLower(name) && !reservedKeywords[name] {
+		return name
+	}
+	return `"` + strings.ReplaceAll(name, `"`, `""`) + `"`
+}
+
+// reservedKeywords are PostgreSQL's reserved key words, which must be quoted as identifiers.
+var reservedKeywords = map[string]bool{
+	"all": true, "analyse": true, "analyze": true, "and": true, "any": true, "array": true,
+	"as": true, "asc": true, "asymmetric": true, "both": true, "case": true, "cast": true,
+	"check": true, "collate": true, "column": true, "constraint": true, "create": true,
+	"current_catalog": true, "current_date": true, "current_role": true, "current_time": true,
+	"current_timestamp": true, "current_user": true, "default": true, "deferrable": true,
+	"desc": true, "distinct": true, "do": true, "else": true, "end": true, "except": true,
+	"false": true, "fetch": true, "for": true, "foreign": true, "from": true, "grant": true,
+	"group": true, "having": true, "in": true, "initially": true, "intersect": true,
+	"into": true, "lateral": true, "leading": true, "limit": true, "localtime": true,
+	"localtimestamp": true, "not": true, "null": true, "offset": true, "on": true,
+	"only": true, "or": true, "order": true, "placing": true, "primary": true,
+	"references": true, "returning": true, "select": true, "session_user": true,
+	"some": true, "symmetric": true, "system_user": true, "table": true, "then": true,
+	"to": true, "trailing": true, "true": true, "union": true, "unique": true, "user": true,
+	"using": true, "variadic": true, "when": true, "where": true, "window": true, "with": true,
+}
+
+// indexColumn returns the column name or expression of an index element, without
+// its collation, operator class, and ordering options.
+func indexColumn(elem string) string {
+	elem = strings.TrimSpace(elem)
+	if elem == "" || elem[0] == '(' || elem[0] == '"' || strings.Contains(elem, "(") {
+		return normalizeIdent(elem)
+	}
+	return normalizeIdent(strings.Fields(elem)[0])
+}
@@ -0,0 +1,314 @@
+--
+-- PostgreSQL database dump
+--
+
+\restrict 4ljZ0Bq8ahKJbNfZCg0qO6fTzJq3c1g4Qd8xmZQ5bV0XlTQfQO6Zs2mD9S1kWbR
+
+-- Dumped from database version 16.4
+-- Dumped by pg_dump version 16.4
+
+SET statement_timeout = 0;
+SET lock_timeout = 0;
+SET idle_in_transaction_session_timeout = 0;
+SET client_encoding = 'UTF8';
+SET standard_conforming_strings = on;
+SELECT pg_catalog.set_config('search_path', '', false);
+SET check_function_bodies = false;
+SET xmloption = content;
+SET client_min_messages = warning;
+SET row_security = off;
+
+--
+-- Name: billing; Type: SCHEMA; Schema: -; Owner: app_owner
+--
+
+CREATE SCHEMA billing;
+
+
+ALTER SCHEMA billing OWNER TO app_owner;
+
+--
+-- Name: citext; Type: EXTENSION; Schema: -; Owner: -
+--
+
+CREATE EXTENSION IF NOT EXISTS citext WITH SCHEMA public;
+
+
+--
+-- Name: EXTENSION citext; Type: COMMENT; Schema: -; Owner:
+--
+
+COMMENT ON EXTENSION citext IS 'data type for case-insensitive character strings';
+
+
+--
+-- Name: order_status; Type: TYPE; Schema: public; Owner: app_owner
+--
+
+CREATE TYPE public.order_status AS ENUM (
+    'pending',
+    'paid',
+    'shipped'
+);
+
+
+ALTER TYPE public.order_status OWNER TO app_owner;
+
+--
+-- Name: touch_updated_at(); Type: FUNCTION; Schema: public; Owner: app_owner
+--
+
+CREATE FUNCTION public.touch_updated_at() RETURNS trigger
+    LANGUAGE plpgsql
+    AS $$
+BEGIN
+    NEW.updated_at := now();
+    RETURN NEW;
+END;
+$$;
+
+
+ALTER FUNCTION public.touch_updated_at() OWNER TO app_owner;
+
+SET default_tablespace = '';
+
+SET default_table_access_method = heap;
+
+--
+-- Name: invoices; Type: TABLE; Schema: billing; Owner: app_owner
+--
+
+CREATE TABLE billing.invoices (
+    id bigint NOT NULL,
+    order_id bigint NOT NULL,
+    amount_cents integer NOT NULL,
+    issued_at timestamp with time zone DEFAULT now() NOT NULL,
+    CONSTRAINT invoices_amount_cents_check CHECK ((amount_cents >= 0))
+);
+
+
+ALTER TABLE billing.invoices OWNER TO app_owner;
+
+--
+-- Name: invoices_id_seq; Type: SEQUENCE; Schema: billing; Owner: app_owner
+--
+
+ALTER TABLE billing.invoices ALTER COLUMN id ADD GENERATED ALWAYS AS IDENTITY (
+    SEQUENCE NAME billing.invoices_id_seq
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1
+);
+
+
+--
+-- Name: orders; Type: TABLE; Schema: public; Owner: app_owner
+--
+
+CREATE TABLE public.orders (
+    id integer NOT NULL,
+    user_id integer NOT NULL,
+    status public.order_status DEFAULT 'pending'::public.order_status NOT NULL,
+    total numeric(12,2),
+    created_at timestamp with time zone DEFAULT now() NOT NULL,
+    updated_at timestamp with time zone
+);
+
+
+ALTER TABLE public.orders OWNER TO app_owner;
+
+--
+-- Name: TABLE orders; Type: COMMENT; Schema: public; Owner: app_owner
+--
+
+COMMENT ON TABLE public.orders IS 'Customer orders';
+
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE; Schema: public; Owner: app_owner
+--
+
+CREATE SEQUENCE public.orders_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE public.orders_id_seq OWNER TO app_owner;
+
+--
+-- Name: orders_id_seq; Type: SEQUENCE OWNED BY; Schema: public; Owner: app_owner
+--
+
+ALTER SEQUENCE public.orders_id_seq OWNED BY public.orders.id;
+
+
+--
+-- Name: users; Type: TABLE; Schema: public; Owner: app_owner
+--
+
+CREATE TABLE public.users (
+    id integer NOT NULL,
+    email public.citext NOT NULL,
+    "displayName" text,
+    created_at timestamp with time zone DEFAULT now() NOT NULL
+);
+
+
+ALTER TABLE public.users OWNER TO app_owner;
+
+--
+-- Name: COLUMN users.email; Type: COMMENT; Schema: public; Owner: app_owner
+--
+
+COMMENT ON COLUMN public.users.email IS 'Login address';
+
+
+--
+-- Name: users_id_seq; Type: SEQUENCE; Schema: public; Owner: app_owner
+--
+
+CREATE SEQUENCE public.users_id_seq
+    AS integer
+    START WITH 1
+    INCREMENT BY 1
+    NO MINVALUE
+    NO MAXVALUE
+    CACHE 1;
+
+
+ALTER TABLE public.users_id_seq OWNER TO app_owner;
+
+ALTER SEQUENCE public.users_id_seq OWNED BY public.users.id;
+
+
+--
+-- Name: paid_orders; Type: VIEW; Schema: public; Owner: app_owner
+--
+
+CREATE VIEW public.paid_orders AS
+ SELECT o.id,
+    o.user_id,
+    u.email,
+    o.total
+   FROM (public.orders o
+     JOIN public.users u ON ((u.id = o.user_id)))
+  WHERE (o.status = 'paid'::public.order_status);
+
+
+ALTER VIEW public.paid_orders OWNER TO app_owner;
+
+--
+-- Name: orders id; Type: DEFAULT; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE ONLY public.orders ALTER COLUMN id SET DEFAULT nextval('public.orders_id_seq'::regclass);
+
+
+--
+-- Name: users id; Type: DEFAULT; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE ONLY public.users ALTER COLUMN id SET DEFAULT nextval('public.users_id_seq'::regclass);
+
+
+--
+-- Name: invoices invoices_pkey; Type: CONSTRAINT; Schema: billing; Owner: app_owner
+--
+
+ALTER TABLE ONLY billing.invoices
+    ADD CONSTRAINT invoices_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders orders_pkey; Type: CONSTRAINT; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: users users_email_key; Type: CONSTRAINT; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_email_key UNIQUE (email);
+
+
+--
+-- Name: users users_pkey; Type: CONSTRAINT; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE ONLY public.users
+    ADD CONSTRAINT users_pkey PRIMARY KEY (id);
+
+
+--
+-- Name: orders_user_id_created_at_idx; Type: INDEX; Schema: public; Owner: app_owner
+--
+
+CREATE INDEX orders_user_id_created_at_idx ON public.orders USING btree (user_id, created_at DESC);
+
+
+--
+-- Name: orders orders_touch_updated_at; Type: TRIGGER; Schema: public; Owner: app_owner
+--
+
+CREATE TRIGGER orders_touch_updated_at BEFORE UPDATE ON public.orders FOR EACH ROW EXECUTE FUNCTION public.touch_updated_at();
+
+
+--
+-- Name: invoices invoices_order_id_fkey; Type: FK CONSTRAINT; Schema: billing; Owner: app_owner
+--
+
+ALTER TABLE ONLY billing.invoices
+    ADD CONSTRAINT invoices_order_id_fkey FOREIGN KEY (order_id) REFERENCES public.orders(id) ON DELETE RESTRICT;
+
+
+--
+-- Name: orders orders_user_id_fkey; Type: FK CONSTRAINT; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE ONLY public.orders
+    ADD CONSTRAINT orders_user_id_fkey FOREIGN KEY (user_id) REFERENCES public.users(id) ON DELETE CASCADE;
+
+
+--
+-- Name: orders; Type: ROW SECURITY; Schema: public; Owner: app_owner
+--
+
+ALTER TABLE public.orders ENABLE ROW LEVEL SECURITY;
+
+--
+-- Name: orders orders_owner; Type: POLICY; Schema: public; Owner: app_owner
+--
+
+CREATE POLICY orders_owner ON public.orders USING ((user_id = (current_setting('app.user_id'::text))::integer));
+
+
+--
+-- Name: SCHEMA public; Type: ACL; Schema: -; Owner: pg_database_owner
+--
+
+GRANT USAGE ON SCHEMA public TO app_reader;
+
+
+--
+-- Name: TABLE orders; Type: ACL; Schema: public; Owner: app_owner
+--
+
+GRANT SELECT ON TABLE public.orders TO app_reader;
+
+
+--
+-- PostgreSQL database dump complete
+--
+
+\unrestrict 4ljZ0Bq8ahKJbNfZCg0qO6fTzJq3c1g4Qd8xmZQ5bV0XlTQfQO6Zs2mD9S1kWbR
+
@@ -0,0 +1,402 @@
+// validate.go checks a statement against the catalog for errors PostgreSQL would raise
+// while planning it.
+package catalog
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/analysis"
+	"github.com/valkdb/postgresparser/internal/sqlident"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// IssueKind classifies a problem found by Validate.
+type IssueKind string
+
+const (
+	IssueUnknownRelation IssueKind = "UNKNOWN_RELATION"
+	IssueUnknownColumn   IssueKind = "UNKNOWN_COLUMN"
+	IssueAmbiguousColumn IssueKind = "AMBIGUOUS_COLUMN"
+	IssueInsertArity     IssueKind = "INSERT_ARITY"    // VALUES row or SELECT list does not match the INSERT columns
+	IssueConflictTarget  IssueKind = "CONFLICT_TARGET" // ON CONFLICT target matches no unique index or constraint
+	IssueFunctionArity   IssueKind = "FUNCTION_ARITY"  // Built-in function called with the wrong number of arguments
+)
+
+// Issue is a problem in a statement.
+type Issue struct {
+	Kind     IssueKind
+	Message  string // Worded like the PostgreSQL error, e.g. relation "userz" does not exist
+	Position int    // Character offset in RawSQL of the offending name or clause
+}
+
+// ValidateSQL parses a statement and validates it against the catalog. See Validate.
+func ValidateSQL(sql string, c *Catalog) ([]Issue, error) {
+	pq, err := postgresparser.ParseSQL(sql)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse query: %w", err)
+	}
+	return Validate(pq, c), nil
+}
+
+// Validate reports the problems PostgreSQL would reject a parsed statement for, given
+// the schema in the catalog: relations and columns that do not exist, ambiguous column
+// references, INSERT statements whose VALUES rows or SELECT list do not match the
+// target columns, ON CONFLICT targets without a matching unique index or constraint,
+// and built-in functions called with the wrong number of arguments. Issues are sorted
+// by position.
+//
+// Functions are not modeled by the catalog, so calls to functions other than the common
+// built-ins are not checked. Relations in pg_catalog and information_schema are assumed
+// to exist.
+func Validate(pq *postgresparser.ParsedQuery, c *Catalog) []Issue {
+	if pq == nil {
+		return nil
+	}
+	v := &validator{describer: newDescriber(pq, c), used: make(map[int]bool)}
+	v.relations()
+	v.columns()
+	v.insert()
+	v.conflict()
+	v.functions()
+	sort.SliceStable(v.issues, func(i, j int) bool { return v.issues[i].Position < v.issues[j].Position })
+	return v.issues
+}
+
+// validator collects the issues of one statement.
+type validator struct {
+	*describer
+	issues    []Issue
+	used      map[int]bool // Token positions already attributed to a relation
+	targetPos int          // Offset of the name of the table written by a DML statement
+}
+
+// report adds an issue.
+func (v *validator) report(kind IssueKind, pos int, format string, args ...any) {
+	v.issues = append(v.issues, Issue{Kind: kind, Message: fmt.Sprintf(format, args...), Position: pos})
+}
+
+// relations reports base tables of each scope that are missing from the catalog.
+func (v *validator) relations() {
+	for si, s := range v.pq.Scopes {
+		for ri, r := range s.Relations {
+			if r.Scope >= 0 || r.Table.Type != postgresparser.TableTypeBase {
+				continue
+			}
+			pos := v.relationPos(si, r.Table)
+			if si == 0 && ri == 0 {
+				v.targetPos = pos
+			}
+			schema, name := normalizeIdent(r.Table.Schema), normalizeIdent(r.Table.Name)
+			if schema == "pg_catalog" || schema == "information_schema" || schema == "" && strings.HasPrefix(name, "pg_") {
+				continue
+			}
+			if v.lookupTable(schema, name) == nil {
+				if schema != "" {
+					name = schema + "." + name
+				}
+				v.report(IssueUnknownRelation, pos, "relation %q does not exist", name)
+			}
+		}
+	}
+}
+
+// relationPos returns the offset of the name of relation t in scope, or the start of
+// the scope when it cannot be found.
+func (v *validator) relationPos(scope int, t postgresparser.TableRef) int {
+	name := normalizeIdent(t.Name)
+	for i, tok := range v.tokens {
+		if v.used[tok.Pos] || (tok.Kind != sqllex.Ident && tok.Kind != sqllex.Quoted) || normalizeIdent(tok.Text) != name {
+			continue
+		}
+		// Skip qualifiers of column references; a qualified relation follows its schema.
+		dotAfter := i+1 < len(v.tokens) && v.tokens[i+1].Is(".")
+		dotBefore := i > 0 && v.tokens[i-1].Is(".")
+		if dotAfter || dotBefore != (t.Schema != "") {
+			continue
+		}
+		inner := sqllex.ScopeAt(v.pq.Scopes, tok.Pos)
+		if inner != scope && v.pq.Scopes[inner].Start != tok.Pos {
+			continue
+		}
+		v.used[tok.Pos] = true
+		return tok.Pos
+	}
+	return v.pq.Scopes[scope].Start
+}
+
+// columns reports column references that no relation in scope has, or that several do.
+// References that a relation missing from the catalog might supply are not reported.
+func (v *validator) columns() {
+	seen := make(map[int]bool)
+	for _, rc := range analysis.ResolveColumnUsage(v.pq, v.schemaMap) {
+		u := rc.Usage
+		if u.Column == "" || u.Column == "*" || seen[u.Position] {
+			continue
+		}
+		switch {
+		case rc.Status == analysis.ColumnAmbiguous:
+			v.report(IssueAmbiguousColumn, u.Position, "column reference %q is ambiguous", referenceName(u.Expression))
+		case rc.Status == analysis.ColumnUnknown && len(rc.Candidates) == 0:
+			v.report(IssueUnknownColumn, u.Position, "column %q does not exist", referenceName(u.Expression))
+		default:
+			continue
+		}
+		seen[u.Position] = true
+	}
+}
+
+// referenceName returns a column reference with the quotes of its parts removed, as
+// PostgreSQL names it in errors.
+func referenceName(expr string) string {
+	parts, ok := sqlident.SplitChain(expr)
+	if !ok {
+		return expr
+	}
+	for i, part := range parts {
+		parts[i] = sqlident.Name(part)
+	}
+	return strings.Join(parts, ".")
+}
+
+// target returns the table written by a DML statement and the offset of its name, or
+// nil when it is not in the catalog.
+func (v *validator) target() (*Table, int) {
+	if len(v.pq.Scopes) == 0 || len(v.pq.Scopes[0].Relations) == 0 {
+		return nil, 0
+	}
+	ref := v.pq.Scopes[0].Relations[0].Table
+	return v.lookupTable(normalizeIdent(ref.Schema), normalizeIdent(ref.Name)), v.targetPos
+}
+
+// insert reports INSERT columns missing from the target table and VALUES rows or
+// SELECT lists with more expressions than target columns, or fewer than the columns
+// listed.
+func (v *validator) insert() {
+	if v.pq.Command != postgresparser.QueryCommandInsert {
+		return
+	}
+	t, pos := v.target()
+	if t == nil {
+		return
+	}
+	for _, name := range v.pq.InsertColumns {
+		if t.Column(name) != nil {
+			continue
+		}
+		at := pos
+		for _, tok := range v.tokens {
+			if tok.Pos > pos && normalizeIdent(tok.Text) == normalizeIdent(name) {
+				at = tok.Pos
+				break
+			}
+		}
+		v.report(IssueUnknownColumn, at, "column %q of relation %q does not exist", normalizeIdent(name), t.Name)
+	}
+
+	want := len(v.pq.InsertColumns)
+	if want == 0 {
+		want = len(t.Columns)
+	}
+	check := func(got, at int) {
+		switch {
+		case got > want:
+			v.report(IssueInsertArity, at, "INSERT has more expressions than target columns")
+		case got < want && len(v.pq.InsertColumns) > 0:
+			v.report(IssueInsertArity, at, "INSERT has more target columns than expressions")
+		}
+	}
+	for _, s := range v.pq.Scopes {
+		if s.Kind != postgresparser.ScopeSource || s.Parent != 0 {
+			continue
+		}
+		p := &exprParser{d: v.describer, toks: v.tokens}
+		for p.i < len(p.toks) && p.toks[p.i].Pos < s.Start {
+			p.i++
+		}
+		if !p.accept("VALUES") {
+			for _, col := range s.Columns {
+				if isStar(col.Expression) {
+					return
+				}
+			}
+			check(len(s.Columns), s.Start)
+			return
+		}
+		for p.at("(") {
+			at := p.peek(0).Pos
+			check(countArguments(p.toks, p.i), at)
+			p.skipParens()
+			if !p.accept(",") {
+				break
+			}
+		}
+		return
+	}
+}
+
+// countArguments counts the comma-separated items of the parenthesized list starting at
+// toks[open], stopping at an ORDER BY of an aggregate. A lone * counts as one.
+func countArguments(toks []sqllex.Token, open int) int {
+	depth, n := 0, 0
+	for i := open; i < len(toks); i++ {
+		t := toks[i]
+		switch {
+		case t.Is("(") || t.Is("["):
+			depth++
+			if depth == 1 && i+1 < len(toks) && !toks[i+1].Is(")") {
+				n = 1
+			}
+		case t.Is(")") || t.Is("]"):
+			depth--
+			if depth == 0 {
+				return n
+			}
+		case depth == 1 && t.Is(","):
+			n++
+		case depth == 1 && t.Is("ORDER") && i+1 < len(toks) && toks[i+1].Is("BY"):
+			return n
+		}
+	}
+	return n
+}
+
+// conflict reports an ON CONFLICT target that names a missing constraint or matches no
+// unique index of the target table.
+func (v *validator) conflict() {
+	up := v.pq.Upsert
+	if up == nil || up.Constraint == "" && len(up.TargetColumns) == 0 {
+		return
+	}
+	t, pos := v.target()
+	if t == nil {
+		return
+	}
+	for i, tok := range v.tokens {
+		if tok.Is("ON") && i+1 < len(v.tokens) && v.tokens[i+1].Is("CONFLICT") {
+			pos = tok.Pos
+		}
+	}
+	if up.Constraint != "" {
+		con := t.Constraint(up.Constraint)
+		if con == nil {
+			v.report(IssueConflictTarget, pos, "constraint %q for table %q does not exist", normalizeIdent(up.Constraint), t.Name)
+		}
+		return
+	}
+
+	var target []string
+	for _, col := range up.TargetColumns {
+		if isPlainIdent(col) || strings.HasPrefix(col, `"`) {
+			if t.Column(col) == nil {
+				v.report(IssueUnknownColumn, pos, "column %q does not exist", normalizeIdent(col))
+				return
+			}
+			col = normalizeIdent(col)
+		}
+		target = append(target, conflictKey(col))
+	}
+	sort.Strings(target)
+	for _, idx := range v.c.Schema(t.Schema).TableIndexes(t.Name) {
+		if !idx.Unique || idx.Predicate != "" && up.TargetWhere == "" {
+			continue
+		}
+		var cols []string
+		for _, col := range idx.Columns {
+			cols = append(cols, conflictKey(col))
+		}
+		sort.Strings(cols)
+		if strings.Join(cols, ",") == strings.Join(target, ",") {
+			return
+		}
+	}
+	v.report(IssueConflictTarget, pos, "there is no unique or exclusion constraint matching the ON CONFLICT specification")
+}
+
+// conflictKey normalizes a conflict target or index column for comparison.
+func conflictKey(col string) string {
+	return strings.ToLower(strings.Join(strings.Fields(col), ""))
+}
+
+// functionArity is the minimum and maximum argument count of common built-in
+// functions; a maximum of -1 means any number.
+var functionArity = map[string][2]int{
+	"count": {1, 1}, "sum": {1, 1}, "avg": {1, 1}, "min": {1, 1}, "max": {1, 1},
+	"array_agg": {1, 1}, "string_agg": {2, 2}, "json_agg": {1, 1}, "jsonb_agg": {1, 1},
+	"bool_and": {1, 1}, "bool_or": {1, 1}, "every": {1, 1},
+	"row_number": {0, 0}, "rank": {0, 0}, "dense_rank": {0, 0}, "ntile": {1, 1},
+	"lag": {1, 3}, "lead": {1, 3}, "first_value": {1, 1}, "last_value": {1, 1}, "nth_value": {2, 2},
+
+	"coalesce": {1, -1}, "nullif": {2, 2}, "greatest": {1, -1}, "least": {1, -1},
+	"now": {0, 0}, "clock_timestamp": {0, 0}, "statement_timestamp": {0, 0},
+	"transaction_timestamp": {0, 0}, "date_trunc": {2, 3}, "date_part": {2, 2}, "age": {1, 2},
+	"to_char": {2, 2}, "to_date": {2, 2}, "to_timestamp": {1, 2}, "to_number": {2, 2},
+	"make_date": {3, 3},
+
+	"lower": {1, 1}, "upper": {1, 1}, "initcap": {1, 1}, "length": {1, 2}, "char_length": {1, 1},
+	"octet_length": {1, 1}, "md5": {1, 1}, "btrim": {1, 2}, "ltrim": {1, 2}, "rtrim": {1, 2},
+	"substr": {2, 3}, "replace": {3, 3}, "split_part": {3, 3}, "strpos": {2, 2},
+	"left": {2, 2}, "right": {2, 2}, "lpad": {2, 3}, "rpad": {2, 3}, "repeat": {2, 2},
+	"reverse": {1, 1}, "starts_with": {2, 2}, "concat": {1, -1}, "concat_ws": {1, -1},
+	"format": {1, -1}, "regexp_replace": {3, 6}, "translate": {3, 3},
+	"string_to_array": {2, 3}, "array_to_string": {2, 3},
+
+	"abs": {1, 1}, "ceil": {1, 1}, "ceiling": {1, 1}, "floor": {1, 1}, "round": {1, 2},
+	"trunc": {1, 2}, "random": {0, 0}, "sqrt": {1, 1}, "power": {2, 2}, "mod": {2, 2},
+
+	"array_length": {2, 2}, "cardinality": {1, 1}, "array_position": {2, 3}, "unnest": {1, -1},
+	"generate_series": {2, 3}, "jsonb_set": {3, 4}, "jsonb_array_length": {1, 1},
+	"jsonb_typeof": {1, 1}, "to_json": {1, 1}, "to_jsonb": {1, 1}, "row_to_json": {1, 2},
+	"gen_random_uuid": {0, 0}, "nextval": {1, 1}, "currval": {1, 1}, "setval": {2, 3},
+}
+
+// functions reports calls to the built-ins of functionArity with the wrong number of
+// arguments.
+func (v *validator) functions() {
+	for i, tok := range v.tokens {
+		if tok.Kind != sqllex.Ident || i+1 >= len(v.tokens) || !v.tokens[i+1].Is("(") {
+			continue
+		}
+		arity, ok := functionArity[strings.ToLower(tok.Text)]
+		if !ok {
+			continue
+		}
+		if i > 0 {
+			prev := v.tokens[i-1]
+			switch {
+			case prev.Is("."):
+				// Only pg_catalog.fn is the built-in.
+				if i < 2 || !v.tokens[i-2].Is("pg_catalog") {
+					continue
+				}
+			case prev.Is("INTO"), prev.Is("TABLE"), prev.Is("UPDATE"), prev.Is("WITH"), prev.Is("REFERENCES"):
+				// A relation or CTE that happens to share the name.
+				continue
+			}
+		}
+		n := countArguments(v.tokens, i+1)
+		if n >= arity[0] && (arity[1] < 0 || n <= arity[1]) {
+			continue
+		}
+		v.report(IssueFunctionArity, tok.Pos, "function %s() takes %s, got %d", strings.ToLower(tok.Text), arityText(arity), n)
+	}
+}
+
+// arityText describes an argument count range.
+func arityText(arity [2]int) string {
+	plural := func(n int) string {
+		if n == 1 {
+			return "1 argument"
+		}
+		return fmt.Sprintf("%d arguments", n)
+	}
+	switch {
+	case arity[1] < 0:
+		return "at least " + plural(arity[0])
+	case arity[0] == arity[1]:
+		return plural(arity[0])
+	}
+	return fmt.Sprintf("%d to %d arguments", arity[0], arity[1])
+}
@@ -0,0 +1,158 @@
+package catalog
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// validateCatalog is the schema the Validate tests run against.
+func validateCatalog(t *testing.T) *Catalog {
+	t.Helper()
+	return applyAll(t,
+		"CREATE TABLE users (id bigserial PRIMARY KEY, email text NOT NULL, name text, CONSTRAINT users_email_key UNIQUE (email))",
+		"CREATE TABLE orders (id bigserial PRIMARY KEY, user_id bigint NOT NULL REFERENCES users, total numeric NOT NULL, code text)",
+		"CREATE UNIQUE INDEX orders_code_idx ON orders (lower(code)) WHERE code IS NOT NULL",
+	)
+}
+
+func TestValidate(t *testing.T) {
+	c := validateCatalog(t)
+	tests := []struct {
+		name   string
+		sql    string
+		issues []Issue
+	}{
+		{
+			name: "valid query",
+			sql:  "SELECT u.id, lower(u.email), count(*) FROM users u JOIN orders o ON o.user_id = u.id WHERE o.total > $1 GROUP BY u.id",
+		},
+		{
+			name: "unknown relation",
+			sql:  "SELECT nme FROM userz",
+			issues: []Issue{
+				{Kind: IssueUnknownRelation, Message: `relation "userz" does not exist`, Position: 16},
+			},
+		},
+		{
+			name: "unknown relation after a qualifier of the same name",
+			sql:  "SELECT users.id FROM public.users JOIN audit.users a ON a.id = users.id",
+			issues: []Issue{
+				{Kind: IssueUnknownRelation, Message: `relation "audit.users" does not exist`, Position: 45},
+			},
+		},
+		{
+			name: "unknown column",
+			sql:  "SELECT nme FROM users",
+			issues: []Issue{
+				{Kind: IssueUnknownColumn, Message: `column "nme" does not exist`, Position: 7},
+			},
+		},
+		{
+			name: "unquoted column names fold to lower case",
+			sql:  "SELECT NAME, Users.Email FROM users WHERE ID = 1",
+		},
+		{
+			name: "quoted column names keep their case",
+			sql:  `SELECT "Name", "NAME", "name" FROM users`,
+			issues: []Issue{
+				{Kind: IssueUnknownColumn, Message: `column "Name" does not exist`, Position: 7},
+				{Kind: IssueUnknownColumn, Message: `column "NAME" does not exist`, Position: 15},
+			},
+		},
+		{
+			name: "ambiguous column",
+			sql:  "SELECT id FROM users JOIN orders ON orders.user_id = users.id",
+			issues: []Issue{
+				{Kind: IssueAmbiguousColumn, Message: `column reference "id" is ambiguous`, Position: 7},
+			},
+		},
+		{
+			name: "INSERT with more values than columns",
+			sql:  "INSERT INTO users (email) VALUES ('a', 'b')",
+			issues: []Issue{
+				{Kind: IssueInsertArity, Message: "INSERT has more expressions than target columns", Position: 33},
+			},
+		},
+		{
+			name: "INSERT with fewer values than columns",
+			sql:  "INSERT INTO users (email, name) VALUES ('a'), ('b', 'c')",
+			issues: []Issue{
+				{Kind: IssueInsertArity, Message: "INSERT has more target columns than expressions", Position: 39},
+			},
+		},
+		{
+			name: "INSERT SELECT with too many columns",
+			sql:  "INSERT INTO users (email) SELECT email, name FROM users",
+			issues: []Issue{
+				{Kind: IssueInsertArity, Message: "INSERT has more expressions than target columns", Position: 26},
+			},
+		},
+		{
+			name: "INSERT of an unknown column",
+			sql:  "INSERT INTO users (email, nick) VALUES ('a', 'b')",
+			issues: []Issue{
+				{Kind: IssueUnknownColumn, Message: `column "nick" of relation "users" does not exist`, Position: 26},
+			},
+		},
+		{
+			name: "ON CONFLICT on a unique constraint",
+			sql:  "INSERT INTO users (email) VALUES ($1) ON CONFLICT (email) DO NOTHING",
+		},
+		{
+			name: "ON CONFLICT on a partial expression index",
+			sql:  "INSERT INTO orders (user_id, total, code) VALUES (1, 2, 'x') ON CONFLICT (lower(code)) WHERE code IS NOT NULL DO NOTHING",
+		},
+		{
+			name: "ON CONFLICT without a matching unique index",
+			sql:  "INSERT INTO users (email, name) VALUES ($1, $2) ON CONFLICT (name) DO NOTHING",
+			issues: []Issue{
+				{Kind: IssueConflictTarget, Message: "there is no unique or exclusion constraint matching the ON CONFLICT specification", Position: 48},
+			},
+		},
+		{
+			name: "ON CONFLICT ON CONSTRAINT that does not exist",
+			sql:  "INSERT INTO users (email) VALUES ($1) ON CONFLICT ON CONSTRAINT users_name_key DO NOTHING",
+			issues: []Issue{
+				{Kind: IssueConflictTarget, Message: `constraint "users_name_key" for table "users" does not exist`, Position: 38},
+			},
+		},
+		{
+			name: "function arity",
+			sql:  "SELECT lower(email, name), concat(), now() FROM users",
+			issues: []Issue{
+				{Kind: IssueFunctionArity, Message: "function lower() takes 1 argument, got 2", Position: 7},
+				{Kind: IssueFunctionArity, Message: "function concat() takes at least 1 argument, got 0", Position: 27},
+			},
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			issues, err := ValidateSQL(tc.sql, c)
+			require.NoError(t, err, "validate %q", tc.sql)
+			assert.Equal(t, tc.issues, issues)
+		})
+	}
+}
+
+func TestValidate_Errors(t *testing.T) {
+	_, err := ValidateSQL("SELEC 1", validateCatalog(t))
+	assert.Error(t, err, "parse error")
+	assert.Nil(t, Validate(nil, validateCatalog(t)), "nil query")
+}
+
+func TestValidate_QuotedColumns(t *testing.T) {
+	c := applyAll(t, `CREATE TABLE accounts (id int, "Email" text)`)
+
+	issues, err := ValidateSQL(`SELECT a."Email" FROM accounts a WHERE "Email" IS NOT NULL`, c)
+	require.NoError(t, err)
+	assert.Empty(t, issues, "quoted references match exactly")
+
+	issues, err = ValidateSQL("SELECT Email, a.email FROM accounts a", c)
+	require.NoError(t, err)
+	assert.Equal(t, []Issue{
+		{Kind: IssueUnknownColumn, Message: `column "email" does not exist`, Position: 7},
+		{Kind: IssueUnknownColumn, Message: `column "a.email" does not exist`, Position: 14},
+	}, issues, "unquoted references fold to lower case")
+}
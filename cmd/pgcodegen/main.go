@@ -0,0 +1,75 @@
+// Command pgcodegen generates typed Go functions for database/sql from annotated SQL
+// query files, typed against a schema loaded from DDL files such as pg_dump output.
+//
+// Usage:
+//
+//	pgcodegen -schema schema.sql [-schema more.sql] [-package db] [-out queries.go] queries.sql...
+//
+// See package codegen for the query file format.
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/valkdb/postgresparser/catalog"
+	"github.com/valkdb/postgresparser/codegen"
+)
+
+// listFlag collects the values of a repeated flag.
+type listFlag []string
+
+func (l *listFlag) String() string { return strings.Join(*l, ",") }
+
+func (l *listFlag) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+func main() {
+	var schemas listFlag
+	flag.Var(&schemas, "schema", "DDL file to build the schema from; may be repeated")
+	pkg := flag.String("package", "db", "package name of the generated file")
+	out := flag.String("out", "", "output file; standard output when empty")
+	flag.Usage = func() {
+		fmt.Fprintln(flag.CommandLine.Output(), "usage: pgcodegen -schema schema.sql [flags] queries.sql...")
+		flag.PrintDefaults()
+	}
+	flag.Parse()
+	if len(schemas) == 0 || flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+	if err := run(schemas, flag.Args(), *pkg, *out); err != nil {
+		fmt.Fprintln(os.Stderr, "pgcodegen:", err)
+		os.Exit(1)
+	}
+}
+
+func run(schemas, queryFiles []string, pkg, out string) error {
+	cat := catalog.New()
+	for _, path := range schemas {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("failed to read schema: %w", err)
+		}
+		if err := cat.ApplyScript(string(data)); err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+	}
+	queries, err := codegen.LoadQueries(queryFiles...)
+	if err != nil {
+		return err
+	}
+	src, err := codegen.Generate(queries, cat, codegen.Config{Package: pkg})
+	if err != nil {
+		return err
+	}
+	if out == "" {
+		_, err = os.Stdout.Write(src)
+		return err
+	}
+	return os.WriteFile(out, src, 0o644)
+}
@@ -0,0 +1,129 @@
+package codegen
+
+import (
+	"go/ast"
+	"go/importer"
+	"go/parser"
+	"go/token"
+	"go/types"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser/catalog"
+)
+
+func TestGenerate(t *testing.T) {
+	tests := []struct {
+		name, schema, queries, golden string
+	}{
+		{"users and orders", "schema.sql", "queries.sql", "queries.go.golden"},
+		{"nullable columns", "events_schema.sql", "events.sql", "events.go.golden"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cat, err := catalog.LoadFile("testdata/" + tt.schema)
+			require.NoError(t, err, "load schema")
+			queries, err := LoadQueries("testdata/" + tt.queries)
+			require.NoError(t, err, "load queries")
+
+			src, err := Generate(queries, cat, Config{})
+			require.NoError(t, err, "generate")
+			want, err := os.ReadFile("testdata/" + tt.golden)
+			require.NoError(t, err, "read golden file")
+			assert.Equal(t, string(want), string(src), "generated code differs from testdata/%s", tt.golden)
+
+			// The generated code must compile.
+			fset := token.NewFileSet()
+			file, err := parser.ParseFile(fset, "queries.go", src, 0)
+			require.NoError(t, err, "parse generated code")
+			conf := types.Config{Importer: importer.ForCompiler(fset, "source", nil)}
+			_, err = conf.Check("db", fset, []*ast.File{file}, nil)
+			require.NoError(t, err, "type-check generated code")
+		})
+	}
+}
+
+func TestGenerate_Config(t *testing.T) {
+	cat, err := catalog.LoadFile("testdata/schema.sql")
+	require.NoError(t, err, "load schema")
+	queries, err := ParseQueries("q.sql", "-- name: GetTotal :one\nSELECT total FROM orders WHERE id = $1 AND user_id = $2")
+	require.NoError(t, err, "parse queries")
+
+	src, err := Generate(queries, cat, Config{
+		Package: "store",
+		Types:   map[string]GoType{"numeric": {Type: "decimal.Decimal", NullType: "decimal.NullDecimal", Import: "github.com/shopspring/decimal"}},
+		MaxArgs: 1,
+	})
+	require.NoError(t, err, "generate")
+	assert.Contains(t, string(src), "package store", "package name")
+	assert.Contains(t, string(src), `"github.com/shopspring/decimal"`, "override import")
+	assert.Contains(t, string(src), "func (q *Queries) GetTotal(ctx context.Context, arg GetTotalParams) (decimal.Decimal, error)", "signature")
+}
+
+func TestGenerate_Errors(t *testing.T) {
+	cat, err := catalog.LoadFile("testdata/schema.sql")
+	require.NoError(t, err, "load schema")
+	tests := map[string]string{
+		"no columns":      "-- name: Touch :one\nUPDATE users SET name = 'x'",
+		"skipped param":   "-- name: Skip :exec\nDELETE FROM users WHERE id = $2",
+		"duplicate name":  "-- name: A :exec\nDELETE FROM users;\n-- name: A :exec\nDELETE FROM orders",
+		"reserved name":   "-- name: Queries :exec\nDELETE FROM users",
+		"unknown star":    "-- name: All :many\nSELECT * FROM missing",
+		"unparsable text": "-- name: Bad :exec\nSELEC 1",
+	}
+	for name, src := range tests {
+		t.Run(name, func(t *testing.T) {
+			queries, err := ParseQueries("q.sql", src)
+			require.NoError(t, err, "parse queries")
+			_, err = Generate(queries, cat, Config{})
+			assert.ErrorContains(t, err, "q.sql:", "error should name the file")
+		})
+	}
+}
+
+func TestParseQueries(t *testing.T) {
+	queries, err := ParseQueries("q.sql", `-- header
+-- name: GetUser :one
+-- GetUser fetches a user.
+--   Second line.
+SELECT id
+FROM users WHERE id = $1;
+
+-- name: ListUsers :many
+SELECT id FROM users
+`)
+	require.NoError(t, err, "parse queries")
+	require.Len(t, queries, 2, "queries")
+	assert.Equal(t, Query{
+		Name: "GetUser", Command: CommandOne, SQL: "SELECT id\nFROM users WHERE id = $1",
+		Doc: []string{"GetUser fetches a user.", "Second line."}, File: "q.sql", Line: 2,
+	}, queries[0])
+	assert.Equal(t, "ListUsers", queries[1].Name, "second query")
+	assert.Equal(t, CommandMany, queries[1].Command, "second command")
+
+	for _, src := range []string{
+		"-- name: getUser :one\nSELECT 1",
+		"-- name: GetUser :first\nSELECT 1",
+		"-- name: GetUser\nSELECT 1",
+		"-- name: Two :exec\nSELECT 1; SELECT 2",
+		"-- name: Empty :exec\n",
+	} {
+		_, err := ParseQueries("q.sql", src)
+		assert.ErrorIs(t, err, ErrInvalidAnnotation, "%q", src)
+	}
+}
+
+func TestNames(t *testing.T) {
+	exported := map[string]string{"user_id": "UserID", "created_at": "CreatedAt", "?column?": "Column", "2fa": "C2fa", "url_path": "URLPath"}
+	for in, want := range exported {
+		assert.Equal(t, want, exportedName(in), "exportedName(%q)", in)
+	}
+	unexported := map[string]string{"user_id": "userID", "id": "id", "type": "type_", "url_path": "urlPath", "GetUser": "getUser"}
+	for in, want := range unexported {
+		assert.Equal(t, want, unexportedName(in), "unexportedName(%q)", in)
+	}
+	assert.Equal(t, []string{"id", "id2", "err2"}, uniqueNames([]string{"id", "id", "err"}, map[string]bool{"err": true}))
+}
@@ -0,0 +1,375 @@
+// Package codegen generates typed Go functions for database/sql from SQL files whose
+// queries are annotated with names, in the style of:
+//
+//	-- name: GetUser :one
+//	SELECT id, email FROM users WHERE id = $1;
+//
+// Each query is parsed with postgresparser and described with catalog.DescribeQuery,
+// so parameter and result column types come from a schema model built from DDL, with
+// no database connection. Generate emits one Go file holding a Queries type with a
+// method per query, a Row struct for queries returning several columns, and a Params
+// struct for queries taking many parameters.
+package codegen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"go/format"
+	"path"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/catalog"
+)
+
+// GoType is the Go representation of a PostgreSQL type.
+type GoType struct {
+	Type     string // Go type of non-null values, e.g. "int64"
+	NullType string // Go type that can also hold NULL, e.g. "sql.NullInt64"
+	Import   string // Import path of the package the types refer to, e.g. "time"; empty for none
+}
+
+// Config controls the generated code.
+type Config struct {
+	Package string            // Package name of the generated file; "db" when empty
+	Types   map[string]GoType // Overrides of the default mapping, by PostgreSQL type name such as "uuid" or "numeric(10,2)"
+
+	// MaxArgs is the largest number of parameters passed as separate function arguments;
+	// queries with more take a Params struct. Zero means 3.
+	MaxArgs int
+}
+
+// ErrUnsupportedQuery is returned for queries whose function cannot be generated, such
+// as a :one query that returns no columns or a query skipping a parameter number.
+var ErrUnsupportedQuery = errors.New("unsupported query")
+
+// defaultTypes maps PostgreSQL types, without modifiers, to Go types. Other types,
+// including arrays, map to any.
+var defaultTypes = map[string]GoType{
+	"bigint":           {Type: "int64", NullType: "sql.NullInt64"},
+	"integer":          {Type: "int32", NullType: "sql.NullInt32"},
+	"smallint":         {Type: "int16", NullType: "sql.NullInt16"},
+	"boolean":          {Type: "bool", NullType: "sql.NullBool"},
+	"real":             {Type: "float32", NullType: "sql.Null[float32]"},
+	"double precision": {Type: "float64", NullType: "sql.NullFloat64"},
+	"numeric":          {Type: "string", NullType: "sql.NullString"},
+	"money":            {Type: "string", NullType: "sql.NullString"},
+
+	"text":              {Type: "string", NullType: "sql.NullString"},
+	"character varying": {Type: "string", NullType: "sql.NullString"},
+	"character":         {Type: "string", NullType: "sql.NullString"},
+	"citext":            {Type: "string", NullType: "sql.NullString"},
+	"name":              {Type: "string", NullType: "sql.NullString"},
+	"uuid":              {Type: "string", NullType: "sql.NullString"},
+	"inet":              {Type: "string", NullType: "sql.NullString"},
+	"cidr":              {Type: "string", NullType: "sql.NullString"},
+	"macaddr":           {Type: "string", NullType: "sql.NullString"},
+	"interval":          {Type: "string", NullType: "sql.NullString"},
+	"xml":               {Type: "string", NullType: "sql.NullString"},
+
+	"timestamp with time zone":    {Type: "time.Time", NullType: "sql.NullTime", Import: "time"},
+	"timestamp without time zone": {Type: "time.Time", NullType: "sql.NullTime", Import: "time"},
+	"date":                        {Type: "time.Time", NullType: "sql.NullTime", Import: "time"},
+	"time with time zone":         {Type: "string", NullType: "sql.NullString"},
+	"time without time zone":      {Type: "string", NullType: "sql.NullString"},
+
+	"json":  {Type: "json.RawMessage", NullType: "json.RawMessage", Import: "encoding/json"},
+	"jsonb": {Type: "json.RawMessage", NullType: "json.RawMessage", Import: "encoding/json"},
+	"bytea": {Type: "[]byte", NullType: "[]byte"},
+}
+
+// anyType is used for types without a mapping.
+var anyType = GoType{Type: "any", NullType: "any"}
+
+// reservedNames are the identifiers every generated file declares.
+var reservedNames = []string{"DBTX", "New", "Queries"}
+
+// Generate returns the formatted Go source of the functions for queries, typed against
+// the catalog. Query names must be unique. Errors name the file and line of the
+// offending query.
+func Generate(queries []Query, c *catalog.Catalog, cfg Config) ([]byte, error) {
+	g := &generator{cfg: cfg, catalog: c, imports: map[string]bool{"context": true, "database/sql": true}}
+	if g.cfg.Package == "" {
+		g.cfg.Package = "db"
+	}
+	if g.cfg.MaxArgs == 0 {
+		g.cfg.MaxArgs = 3
+	}
+	declared := make(map[string]bool)
+	for _, q := range queries {
+		if slices.Contains(reservedNames, q.Name) || declared[q.Name] {
+			return nil, fmt.Errorf("%s:%d: query name %s is already declared: %w", q.File, q.Line, q.Name, ErrUnsupportedQuery)
+		}
+		declared[q.Name] = true
+		if err := g.query(q); err != nil {
+			return nil, fmt.Errorf("%s:%d: query %s: %w", q.File, q.Line, q.Name, err)
+		}
+	}
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by postgresparser/codegen. DO NOT EDIT.\n\npackage %s\n\nimport (\n", g.cfg.Package)
+	imports := make([]string, 0, len(g.imports))
+	for path := range g.imports {
+		imports = append(imports, path)
+	}
+	sort.Strings(imports)
+	for _, path := range imports {
+		fmt.Fprintf(&out, "\t%q\n", path)
+	}
+	out.WriteString(`)
+
+// DBTX is the part of *sql.DB, *sql.Conn, and *sql.Tx the queries use.
+type DBTX interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+// Queries runs the generated queries.
+type Queries struct {
+	db DBTX
+}
+
+// New returns Queries that run on db.
+func New(db DBTX) *Queries {
+	return &Queries{db: db}
+}
+`)
+	out.Write(g.body.Bytes())
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return nil, fmt.Errorf("failed to format generated code: %w", err)
+	}
+	return src, nil
+}
+
+// generator accumulates the declarations of the generated file.
+type generator struct {
+	cfg     Config
+	catalog *catalog.Catalog
+	imports map[string]bool
+	body    bytes.Buffer
+}
+
+// field is a struct field or function argument and its Go type.
+type field struct {
+	name string
+	typ  string
+}
+
+// localNames are the variables of generated function bodies, which parameters must not
+// shadow.
+var localNames = map[string]bool{
+	"ctx": true, "q": true, "row": true, "rows": true, "i": true, "items": true,
+	"err": true, "result": true, "v": true, "arg": true,
+}
+
+// query emits the SQL constant, structs, and method of q.
+func (g *generator) query(q Query) error {
+	pq, err := postgresparser.ParseSQL(q.SQL)
+	if err != nil {
+		return fmt.Errorf("failed to parse query: %w", err)
+	}
+	desc, err := catalog.DescribeQuery(pq, g.catalog)
+	if err != nil {
+		return err
+	}
+
+	var params []field
+	var paramNames []string
+	for i, p := range desc.Parameters {
+		if p.Position != i+1 {
+			return fmt.Errorf("parameter $%d is not used: %w", i+1, ErrUnsupportedQuery)
+		}
+		name := p.Name
+		if name == "" {
+			name = "arg" + strconv.Itoa(p.Position)
+		}
+		paramNames = append(paramNames, name)
+		params = append(params, field{typ: g.goType(p.Type, p.Nullable)})
+	}
+	constName := unexportedName(q.Name)
+	inStruct := len(params) > g.cfg.MaxArgs
+	if inStruct {
+		// Fields of the Params struct cannot clash with the function's variables.
+		for i, name := range paramNames {
+			paramNames[i] = exportedName(name)
+		}
+		for i, name := range uniqueNames(paramNames, nil) {
+			params[i].name = name
+		}
+	} else {
+		taken := map[string]bool{constName: true}
+		for name := range localNames {
+			taken[name] = true
+		}
+		for i, name := range paramNames {
+			paramNames[i] = unexportedName(name)
+		}
+		for i, name := range uniqueNames(paramNames, taken) {
+			params[i].name = name
+		}
+	}
+
+	var cols []field
+	var colNames []string
+	for _, col := range desc.Columns {
+		colNames = append(colNames, exportedName(col.Name))
+		cols = append(cols, field{typ: g.goType(col.Type, col.Nullable)})
+	}
+	for i, name := range uniqueNames(colNames, nil) {
+		cols[i].name = name
+	}
+	returnsRows := q.Command == CommandOne || q.Command == CommandMany
+	if returnsRows && len(cols) == 0 {
+		return fmt.Errorf(":%s query returns no columns: %w", q.Command, ErrUnsupportedQuery)
+	}
+
+	b := &g.body
+	fmt.Fprintf(b, "\nconst %s = %s\n", constName, goString(q.SQL))
+
+	// Result type: a Row struct, or the column type for a single column.
+	rowType := ""
+	if returnsRows {
+		rowType = cols[0].typ
+		if len(cols) > 1 {
+			rowType = q.Name + "Row"
+			fmt.Fprintf(b, "\n// %s is a row returned by %s.\ntype %s struct {\n", rowType, q.Name, rowType)
+			for _, c := range cols {
+				fmt.Fprintf(b, "\t%s %s\n", c.name, c.typ)
+			}
+			b.WriteString("}\n")
+		}
+	}
+
+	// Arguments: separate parameters, or a Params struct.
+	signature := []string{"ctx context.Context"}
+	args := []string{"ctx", constName}
+	if inStruct {
+		paramsType := q.Name + "Params"
+		fmt.Fprintf(b, "\n// %s holds the parameters of %s.\ntype %s struct {\n", paramsType, q.Name, paramsType)
+		for _, p := range params {
+			fmt.Fprintf(b, "\t%s %s\n", p.name, p.typ)
+		}
+		b.WriteString("}\n")
+		signature = append(signature, "arg "+paramsType)
+		for _, p := range params {
+			args = append(args, "arg."+p.name)
+		}
+	} else {
+		for _, p := range params {
+			signature = append(signature, p.name+" "+p.typ)
+			args = append(args, p.name)
+		}
+	}
+
+	var scan []string
+	if len(cols) == 1 {
+		scan = []string{"&v"}
+	} else {
+		for _, c := range cols {
+			scan = append(scan, "&i."+c.name)
+		}
+	}
+
+	b.WriteString("\n")
+	for _, line := range q.Doc {
+		fmt.Fprintf(b, "// %s\n", line)
+	}
+	if len(q.Doc) == 0 {
+		fmt.Fprintf(b, "// %s runs the %s query.\n", q.Name, constName)
+	}
+	call := strings.Join(args, ", ")
+	head := fmt.Sprintf("func (q *Queries) %s(%s)", q.Name, strings.Join(signature, ", "))
+	switch q.Command {
+	case CommandOne:
+		fmt.Fprintf(b, "%s (%s, error) {\n\trow := q.db.QueryRowContext(%s)\n", head, rowType, call)
+		if len(cols) == 1 {
+			fmt.Fprintf(b, "\tvar v %s\n\terr := row.Scan(&v)\n\treturn v, err\n}\n", rowType)
+		} else {
+			fmt.Fprintf(b, "\tvar i %s\n\terr := row.Scan(%s)\n\treturn i, err\n}\n", rowType, strings.Join(scan, ", "))
+		}
+	case CommandMany:
+		fmt.Fprintf(b, "%s ([]%s, error) {\n\trows, err := q.db.QueryContext(%s)\n", head, rowType, call)
+		b.WriteString("\tif err != nil {\n\t\treturn nil, err\n\t}\n\tdefer rows.Close()\n")
+		fmt.Fprintf(b, "\tvar items []%s\n\tfor rows.Next() {\n", rowType)
+		item := "i"
+		if len(cols) == 1 {
+			item = "v"
+		}
+		fmt.Fprintf(b, "\t\tvar %s %s\n\t\tif err := rows.Scan(%s); err != nil {\n\t\t\treturn nil, err\n\t\t}\n", item, rowType, strings.Join(scan, ", "))
+		fmt.Fprintf(b, "\t\titems = append(items, %s)\n\t}\n", item)
+		b.WriteString("\tif err := rows.Err(); err != nil {\n\t\treturn nil, err\n\t}\n\treturn items, nil\n}\n")
+	case CommandExec:
+		fmt.Fprintf(b, "%s error {\n\t_, err := q.db.ExecContext(%s)\n\treturn err\n}\n", head, call)
+	case CommandExecRows:
+		fmt.Fprintf(b, "%s (int64, error) {\n\tresult, err := q.db.ExecContext(%s)\n", head, call)
+		b.WriteString("\tif err != nil {\n\t\treturn 0, err\n\t}\n\treturn result.RowsAffected()\n}\n")
+	case CommandExecResult:
+		fmt.Fprintf(b, "%s (sql.Result, error) {\n\treturn q.db.ExecContext(%s)\n}\n", head, call)
+	}
+	return nil
+}
+
+// typeModifierRe matches the modifier of a type name, as in numeric(10,2).
+var typeModifierRe = regexp.MustCompile(`\([^)]*\)`)
+
+// goType returns the Go type for a PostgreSQL type, recording the import it needs. Enum
+// types map to string.
+func (g *generator) goType(pgType string, nullable bool) string {
+	base := strings.Join(strings.Fields(typeModifierRe.ReplaceAllString(pgType, "")), " ")
+	t, ok := g.cfg.Types[pgType]
+	if !ok {
+		t, ok = g.cfg.Types[base]
+	}
+	if !ok {
+		t, ok = defaultTypes[base]
+	}
+	if !ok {
+		t = anyType
+		schema, name := "", base
+		if i := strings.LastIndex(base, "."); i >= 0 {
+			schema, name = base[:i], base[i+1:]
+		}
+		if typ := g.catalog.Type(schema, name); typ != nil && typ.Kind == catalog.TypeKindEnum {
+			t = defaultTypes["text"]
+		}
+	}
+	typ := t.Type
+	if nullable && t.NullType != "" {
+		typ = t.NullType
+	}
+	if t.Import != "" && usesImport(typ, t.Import) {
+		g.imports[t.Import] = true
+	}
+	return typ
+}
+
+// qualifierRe matches the package qualifiers in a Go type, as in map[string]json.RawMessage.
+var qualifierRe = regexp.MustCompile(`([A-Za-z_][A-Za-z0-9_]*)\.`)
+
+// usesImport reports whether a Go type refers to the package of an import path. Any
+// qualifier but sql is taken to name it, so that paths whose last element differs from
+// the package name work; the NullType of a time column, sql.NullTime, does not need
+// the time import.
+func usesImport(typ, importPath string) bool {
+	for _, m := range qualifierRe.FindAllStringSubmatch(typ, -1) {
+		if m[1] != "sql" || path.Base(importPath) == "sql" {
+			return true
+		}
+	}
+	return false
+}
+
+// goString returns s as a Go string literal, raw when possible.
+func goString(s string) string {
+	if strings.Contains(s, "`") {
+		return strconv.Quote(s)
+	}
+	return "`" + s + "`"
+}
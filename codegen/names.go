@@ -0,0 +1,75 @@
+// names.go turns SQL names into Go identifiers.
+package codegen
+
+import (
+	"go/token"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// initialisms are the words written in upper case in Go names.
+var initialisms = map[string]bool{
+	"api": true, "db": true, "html": true, "http": true, "id": true, "ip": true, "json": true,
+	"sql": true, "uri": true, "url": true, "utc": true, "uuid": true, "xml": true,
+}
+
+// exportedName converts a SQL name such as user_id to an exported Go name such as UserID.
+// Names without letters or digits become Column.
+func exportedName(name string) string {
+	var b strings.Builder
+	for _, word := range strings.FieldsFunc(name, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	}) {
+		if initialisms[strings.ToLower(word)] {
+			b.WriteString(strings.ToUpper(word))
+			continue
+		}
+		r := []rune(word)
+		b.WriteString(strings.ToUpper(string(r[0])) + string(r[1:]))
+	}
+	out := b.String()
+	if out == "" {
+		return "Column"
+	}
+	if unicode.IsDigit([]rune(out)[0]) {
+		out = "C" + out
+	}
+	return out
+}
+
+// unexportedName converts a SQL name such as user_id to an unexported Go name such as
+// userID, avoiding Go keywords.
+func unexportedName(name string) string {
+	exported := exportedName(name)
+	r := []rune(exported)
+	// Lower the leading initialism or letter: IDNumber -> idNumber, UserID -> userID.
+	n := 1
+	for n < len(r) && unicode.IsUpper(r[n]) && (n+1 == len(r) || unicode.IsUpper(r[n+1])) {
+		n++
+	}
+	out := strings.ToLower(string(r[:n])) + string(r[n:])
+	if token.IsKeyword(out) {
+		out += "_"
+	}
+	return out
+}
+
+// uniqueNames makes names unique by numbering repeats, skipping taken names: id, id, id
+// becomes id, id2, id3.
+func uniqueNames(names []string, taken map[string]bool) []string {
+	seen := make(map[string]bool, len(names))
+	for k := range taken {
+		seen[k] = true
+	}
+	out := make([]string, len(names))
+	for i, name := range names {
+		candidate := name
+		for n := 2; seen[candidate]; n++ {
+			candidate = name + strconv.Itoa(n)
+		}
+		seen[candidate] = true
+		out[i] = candidate
+	}
+	return out
+}
@@ -0,0 +1,126 @@
+// queries.go reads the named queries of annotated SQL files.
+package codegen
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"regexp"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// Command is the kind of function generated for a query, named by the annotation
+// suffix.
+type Command string
+
+const (
+	CommandOne        Command = "one"        // Returns the single row of the result
+	CommandMany       Command = "many"       // Returns every row of the result
+	CommandExec       Command = "exec"       // Returns only an error
+	CommandExecRows   Command = "execrows"   // Returns the number of affected rows
+	CommandExecResult Command = "execresult" // Returns the sql.Result
+)
+
+// ErrInvalidAnnotation is returned for a malformed "-- name:" line or a query block
+// without exactly one statement.
+var ErrInvalidAnnotation = errors.New("invalid query annotation")
+
+// Query is a named statement of a query file.
+type Query struct {
+	Name    string   // Go name of the generated function, e.g. GetUser
+	Command Command  // Kind of function to generate
+	SQL     string   // Statement text without the terminating semicolon
+	Doc     []string // Comment lines between the annotation and the statement
+	File    string   // File the query was read from, if any
+	Line    int      // 1-based line of the annotation
+}
+
+// annotationRe matches a query annotation: -- name: GetUser :one
+var annotationRe = regexp.MustCompile(`^--\s*name:\s*(\S+)\s+:(\S+)\s*$`)
+
+// goNameRe matches exported Go identifiers.
+var goNameRe = regexp.MustCompile(`^[A-Z][A-Za-z0-9_]*$`)
+
+// ParseQueries reads the queries of an annotated SQL file. Each query starts with a
+// "-- name: <Name> :<command>" line, optionally followed by comment lines that become
+// the doc comment of the generated function, and holds exactly one statement. Text
+// before the first annotation is ignored. file is used in error messages and recorded
+// in each Query.
+func ParseQueries(file, src string) ([]Query, error) {
+	var (
+		out   []Query
+		cur   *Query
+		body  []string
+		inDoc bool
+	)
+	flush := func() error {
+		if cur == nil {
+			return nil
+		}
+		stmts := postgresparser.SplitStatements(strings.Join(body, "\n"))
+		if len(stmts) != 1 {
+			return fmt.Errorf("%s:%d: query %s has %d statements: %w", file, cur.Line, cur.Name, len(stmts), ErrInvalidAnnotation)
+		}
+		cur.SQL = stmts[0].SQL
+		out = append(out, *cur)
+		return nil
+	}
+	for i, line := range strings.Split(src, "\n") {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "--") && strings.Contains(trimmed, "name:") {
+			if err := flush(); err != nil {
+				return nil, err
+			}
+			m := annotationRe.FindStringSubmatch(trimmed)
+			if m == nil || !goNameRe.MatchString(m[1]) || !validCommand(Command(m[2])) {
+				return nil, fmt.Errorf("%s:%d: %q: %w", file, i+1, trimmed, ErrInvalidAnnotation)
+			}
+			cur = &Query{Name: m[1], Command: Command(m[2]), File: file, Line: i + 1}
+			body, inDoc = nil, true
+			continue
+		}
+		if cur == nil {
+			continue
+		}
+		if inDoc && strings.HasPrefix(trimmed, "--") {
+			cur.Doc = append(cur.Doc, strings.TrimSpace(strings.TrimPrefix(trimmed, "--")))
+			continue
+		}
+		if trimmed != "" {
+			inDoc = false
+		}
+		body = append(body, line)
+	}
+	if err := flush(); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// LoadQueries reads the queries of the annotated SQL files at paths, in order.
+func LoadQueries(paths ...string) ([]Query, error) {
+	var out []Query
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read queries: %w", err)
+		}
+		queries, err := ParseQueries(path, string(data))
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, queries...)
+	}
+	return out, nil
+}
+
+// validCommand reports whether c is a known command.
+func validCommand(c Command) bool {
+	switch c {
+	case CommandOne, CommandMany, CommandExec, CommandExecRows, CommandExecResult:
+		return true
+	}
+	return false
+}
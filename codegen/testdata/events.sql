@@ -0,0 +1,7 @@
+-- Queries whose nullable timestamps need no time import.
+
+-- name: ListEv :many
+SELECT id, at FROM ev;
+
+-- name: UpdateEv :exec
+UPDATE ev SET at = $1, q = $2, err = $3, ctx = $4 WHERE id = $5;
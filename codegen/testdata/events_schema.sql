@@ -0,0 +1,7 @@
+CREATE TABLE ev (
+    id int PRIMARY KEY,
+    at timestamptz,
+    q text,
+    err text,
+    ctx text NOT NULL
+);
@@ -0,0 +1,25 @@
+-- Queries for the users and orders tables.
+
+-- name: GetUser :one
+-- GetUser returns the user with the given id.
+SELECT id, email, name, created_at FROM users WHERE id = $1;
+
+-- name: CountUsers :one
+SELECT count(*) FROM users;
+
+-- name: ListOrders :many
+SELECT o.id, o.status, o.total, o.meta, u.email
+FROM orders o
+JOIN users u ON u.id = o.user_id
+WHERE o.status = $1 AND o.total > $2
+ORDER BY o.id
+LIMIT $3 OFFSET $4;
+
+-- name: CreateUser :one
+INSERT INTO users (email, name) VALUES ($1, $2) RETURNING id;
+
+-- name: UpdateOrderStatus :execrows
+UPDATE orders SET status = $1 WHERE id = $2;
+
+-- name: DeleteUser :exec
+DELETE FROM users WHERE id = $1;
@@ -0,0 +1,16 @@
+CREATE TYPE order_status AS ENUM ('pending', 'paid', 'shipped');
+
+CREATE TABLE users (
+    id bigserial PRIMARY KEY,
+    email text NOT NULL UNIQUE,
+    name text,
+    created_at timestamptz NOT NULL DEFAULT now()
+);
+
+CREATE TABLE orders (
+    id bigserial PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users,
+    status order_status NOT NULL DEFAULT 'pending',
+    total numeric(10,2) NOT NULL,
+    meta jsonb
+);
@@ -0,0 +1,125 @@
+// cte.go parses the bodies of WITH queries and splits recursive ones into their
+// anchor and recursive branches.
+package postgresparser
+
+import (
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// cteStream is the token stream of a statement with the CTE bodies parsed for it and
+// for the statements around it. A body is reached more than once: from the WITH of
+// the statement, when the tables of an enclosing CTE are collected, and from the
+// branches of a recursive CTE. Parsing it each time would double the work with every
+// level of nested WITH.
+type cteStream struct {
+	antlr.TokenStream
+	bodies map[cteBodyKey]*ParsedQuery
+}
+
+// cteBodyKey identifies a parsed CTE body by its text and the CTE names visible to it.
+type cteBodyKey struct {
+	query, visible string
+}
+
+// parseCTEBodies sets the parsed body, and for a recursive CTE its branches, of each
+// CTE of a WITH clause. bodies holds the statement of each CTE.
+func parseCTEBodies(ctes []CTE, bodies []gen.IPreparablestmtContext, tokens antlr.TokenStream) {
+	for i := range ctes {
+		cte := &ctes[i]
+		// In WITH RECURSIVE every CTE sees every CTE; otherwise only the ones before it.
+		visible := make(map[string]struct{})
+		for j, other := range ctes {
+			if j < i || cte.Recursive {
+				visible[strings.ToLower(trimIdentQuotes(other.Name))] = struct{}{}
+			}
+		}
+		cte.Modifying = bodies[i] != nil && bodies[i].Selectstmt() == nil
+		cte.Body = parseCTEQuery(cte.Query, visible, tokens)
+		if cte.Recursive && bodies[i] != nil {
+			cte.Anchor, cte.RecursiveTerm = recursiveBranches(cte.Name, bodies[i].Selectstmt(), tokens, visible)
+		}
+	}
+}
+
+// recursiveBranches returns the branches before the last UNION of a CTE body and the
+// branch after it, or nils unless that branch refers to the CTE name.
+func recursiveBranches(name string, sel gen.ISelectstmtContext, tokens antlr.TokenStream, visible map[string]struct{}) (*ParsedQuery, *ParsedQuery) {
+	if sel == nil || sel.Select_no_parens() == nil || sel.Select_no_parens().Select_clause() == nil {
+		return nil, nil
+	}
+	clause := sel.Select_no_parens().Select_clause()
+	branches := clause.AllSimple_select_intersect()
+	unions := clause.AllUNION()
+	if len(branches) < 2 || len(unions) == 0 {
+		return nil, nil
+	}
+	last, prev := branches[len(branches)-1], branches[len(branches)-2]
+	if unions[len(unions)-1].GetSymbol().GetTokenIndex() < prev.GetStop().GetTokenIndex() {
+		return nil, nil // The last operator is EXCEPT
+	}
+	term := parseCTEQuery(ruleText(last, tokens), visible, tokens)
+	if term == nil || !refersToCTE(term, name) {
+		return nil, nil
+	}
+	anchorText := tokens.GetTextFromInterval(antlr.Interval{
+		Start: branches[0].GetStart().GetTokenIndex(),
+		Stop:  prev.GetStop().GetTokenIndex(),
+	})
+	return parseCTEQuery(strings.TrimSpace(anchorText), visible, tokens), term
+}
+
+// parseCTEQuery parses the text of a CTE body or branch and marks the references to
+// the visible CTEs, or returns nil if it does not parse. A body already parsed for the
+// statement of tokens is reused.
+func parseCTEQuery(query string, visible map[string]struct{}, tokens antlr.TokenStream) *ParsedQuery {
+	if query == "" {
+		return nil
+	}
+	stream, _ := tokens.(*cteStream)
+	if stream == nil {
+		stream = &cteStream{bodies: make(map[cteBodyKey]*ParsedQuery)}
+	}
+	key := cteBodyKey{query: query, visible: strings.Join(slices.Sorted(maps.Keys(visible)), ",")}
+	if pq, ok := stream.bodies[key]; ok {
+		return pq
+	}
+	pq, err := parseSQL(query, stream.bodies)
+	if err != nil {
+		stream.bodies[key] = nil
+		return nil
+	}
+	stream.bodies[key] = pq
+	isCTE := func(t TableRef) bool {
+		_, ok := visible[strings.ToLower(trimIdentQuotes(t.Name))]
+		return ok && t.Schema == "" && t.Type == TableTypeBase
+	}
+	for i := range pq.Tables {
+		if isCTE(pq.Tables[i]) {
+			pq.Tables[i].Type = TableTypeCTE
+		}
+	}
+	for i := range pq.Scopes {
+		for j := range pq.Scopes[i].Relations {
+			if isCTE(pq.Scopes[i].Relations[j].Table) {
+				pq.Scopes[i].Relations[j].Table.Type = TableTypeCTE
+			}
+		}
+	}
+	return pq
+}
+
+// refersToCTE reports whether pq reads the CTE name.
+func refersToCTE(pq *ParsedQuery, name string) bool {
+	for _, t := range pq.Tables {
+		if t.Type == TableTypeCTE && strings.EqualFold(trimIdentQuotes(t.Name), trimIdentQuotes(name)) {
+			return true
+		}
+	}
+	return false
+}
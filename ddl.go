@@ -1,4 +1,4 @@
-// ddl.go implements DDL population logic for CREATE TABLE, DROP, ALTER TABLE, CREATE INDEX, and TRUNCATE.
+// ddl.go implements DDL population logic for CREATE TABLE [AS], DROP, ALTER TABLE, CREATE INDEX, and TRUNCATE.
 package postgresparser
 
 import (
@@ -48,22 +48,81 @@ func populateCreateTable(result *ParsedQuery, ctx gen.ICreatestmtContext, tokens
 		Flags:      flags,
 	}
 
-	if opts := ctx.Opttableelementlist(); opts != nil && opts.Tableelementlist() != nil {
-		for _, tableElem := range opts.Tableelementlist().AllTableelement() {
-			if tableElem == nil || tableElem.ColumnDef() == nil {
-				continue
-			}
-			col := extractCreateTableColumn(tableElem.ColumnDef(), tokens)
-			if col.Name == "" {
+	appendTableElementColumns(result, &action, ctx.Opttableelementlist(), tokens)
+	appendTableElementConstraints(&action, ctx.Opttableelementlist(), tokens)
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// appendTableElementColumns adds the column definitions and LIKE clauses of a table
+// element list to action, recording the tables copied by LIKE in result.Tables.
+func appendTableElementColumns(result *ParsedQuery, action *DDLAction, opts gen.IOpttableelementlistContext, tokens antlr.TokenStream) {
+	if opts == nil || opts.Tableelementlist() == nil {
+		return
+	}
+	for _, tableElem := range opts.Tableelementlist().AllTableelement() {
+		if tableElem == nil {
+			continue
+		}
+		if like := tableElem.Tablelikeclause(); like != nil {
+			ref := tableRefFromQualifiedName(like.Qualified_name(), tokens)
+			if ref.Name == "" {
 				continue
 			}
-			action.Columns = append(action.Columns, col.Name)
-			action.ColumnDetails = append(action.ColumnDetails, col)
+			action.LikeTables = append(action.LikeTables, TableLike{
+				Schema:    ref.Schema,
+				Name:      ref.Name,
+				Position:  len(action.ColumnDetails),
+				Including: tableLikeOptions(like.Tablelikeoptionlist()),
+			})
+			result.Tables = append(result.Tables, ref)
+			continue
 		}
+		if tableElem.ColumnDef() == nil {
+			continue
+		}
+		col := extractCreateTableColumn(tableElem.ColumnDef(), tokens)
+		if col.Name == "" {
+			continue
+		}
+		action.Columns = append(action.Columns, col.Name)
+		action.ColumnDetails = append(action.ColumnDetails, col)
 	}
+}
 
-	result.DDLActions = append(result.DDLActions, action)
-	return nil
+// tableLikeOptionNames lists the options of a LIKE clause in grammar order.
+var tableLikeOptionNames = []string{"COMMENTS", "CONSTRAINTS", "DEFAULTS", "IDENTITY", "GENERATED", "INDEXES", "STATISTICS", "STORAGE"}
+
+// tableLikeOptions returns the LIKE options that list leaves included, in the order of
+// tableLikeOptionNames. Later clauses override earlier ones, as in PostgreSQL.
+func tableLikeOptions(list gen.ITablelikeoptionlistContext) []string {
+	if list == nil {
+		return nil
+	}
+	included := make(map[string]bool)
+	including := false
+	for _, child := range list.GetChildren() {
+		switch node := child.(type) {
+		case antlr.TerminalNode:
+			including = node.GetSymbol().GetTokenType() == gen.PostgreSQLParserINCLUDING
+		case gen.ITablelikeoptionContext:
+			if option := strings.ToUpper(node.GetText()); option == "ALL" {
+				for _, name := range tableLikeOptionNames {
+					included[name] = including
+				}
+			} else {
+				included[option] = including
+			}
+		}
+	}
+	var out []string
+	for _, name := range tableLikeOptionNames {
+		if included[name] {
+			out = append(out, name)
+		}
+	}
+	return out
 }
 
 // extractCreateTableColumn extracts metadata for a single CREATE TABLE column definition.
@@ -102,125 +161,267 @@ func extractCreateTableColumn(colDef gen.IColumnDefContext, tokens antlr.TokenSt
 					col.Default = strings.TrimSpace(ctxText(tokens, prc))
 				}
 			}
+			if elem.GENERATED() != nil {
+				switch {
+				case elem.IDENTITY_P() != nil:
+					col.Nullable = false // Identity columns are implicitly NOT NULL.
+					col.Identity = "ALWAYS"
+					if when := elem.Generated_when(); when != nil && when.BY() != nil {
+						col.Identity = "BY DEFAULT"
+					}
+				case elem.STORED() != nil:
+					col.Generated = ruleText(elem.A_expr(), tokens)
+				}
+			}
 		}
 	}
 	return col
 }
 
-// populateDropStmt handles DROP TABLE, DROP INDEX, and DROP INDEX CONCURRENTLY.
-func populateDropStmt(result *ParsedQuery, ctx gen.IDropstmtContext, tokens antlr.TokenStream) error {
+// populateCreateTableAs handles CREATE [TEMP|UNLOGGED] TABLE ... AS SELECT, recording
+// the created table as Target and the defining query as Source.
+func populateCreateTableAs(result *ParsedQuery, ctx gen.ICreateasstmtContext, tokens antlr.TokenStream) error {
 	if ctx == nil {
-		return fmt.Errorf("drop statement: %w", ErrNilContext)
+		return fmt.Errorf("create table as statement: %w", ErrNilContext)
 	}
+	target := ctx.Create_as_target()
+	if target == nil {
+		return nil
+	}
+
+	tbl := tableRefFromQualifiedName(target.Qualified_name(), tokens)
+	result.Target = &tbl
+	result.Tables = append(result.Tables, tbl)
 
-	// Determine shared flags.
 	var flags []string
-	ifExists := ctx.IF_P() != nil && ctx.EXISTS() != nil
-	if ifExists {
-		flags = append(flags, "IF_EXISTS")
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
 	}
-	if db := ctx.Drop_behavior_(); db != nil {
-		if db.CASCADE() != nil {
-			flags = append(flags, "CASCADE")
-		} else if db.RESTRICT() != nil {
-			flags = append(flags, "RESTRICT")
+	flags = append(flags, "AS_SELECT")
+	if temp := ctx.Opttemp(); temp != nil {
+		if temp.UNLOGGED() != nil {
+			flags = append(flags, "UNLOGGED")
+		} else {
+			flags = append(flags, "TEMPORARY")
 		}
 	}
+	if wd := ctx.With_data_(); wd != nil && wd.NO() != nil {
+		flags = append(flags, "WITH_NO_DATA")
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateTable,
+		ObjectName: tbl.Name,
+		Schema:     tbl.Schema,
+		Flags:      flags,
+	}
+	if cols := target.Column_list_(); cols != nil && cols.Columnlist() != nil {
+		for _, elem := range cols.Columnlist().AllColumnElem() {
+			if name := ruleText(elem, tokens); name != "" {
+				action.Columns = append(action.Columns, name)
+			}
+		}
+	}
+	result.DDLActions = append(result.DDLActions, action)
+
+	if sel := ctx.Selectstmt(); sel != nil {
+		source, err := buildSelectQuery(sel, tokens)
+		if err != nil {
+			return err
+		}
+		result.Source = source
+		appendSetOpTables(result, nil, source.Tables)
+	}
+	return nil
+}
+
+// populateDropStmt handles the generic DROP statement: tables, indexes (including
+// CONCURRENTLY), views, sequences, schemas, extensions, types, domains, and objects
+// declared ON a table (triggers, policies, rules).
+func populateDropStmt(result *ParsedQuery, ctx gen.IDropstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop statement: %w", ErrNilContext)
+	}
+
+	flags := dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, ctx.Drop_behavior_())
 
 	// DROP INDEX CONCURRENTLY (special grammar alternatives).
 	if ctx.CONCURRENTLY() != nil {
 		flags = append(flags, "CONCURRENTLY")
-		if nameList := ctx.Any_name_list_(); nameList != nil {
-			for _, anyName := range nameList.AllAny_name() {
-				prc, ok := anyName.(antlr.ParserRuleContext)
-				if !ok {
-					continue
-				}
-				name := strings.TrimSpace(ctxText(tokens, prc))
-				schema, objectName := splitQualifiedName(name)
-				result.DDLActions = append(result.DDLActions, DDLAction{
-					Type:       DDLDropIndex,
-					ObjectName: objectName,
-					Schema:     schema,
-					Flags:      copyFlags(flags),
-				})
-			}
+		for _, name := range anyNameListTexts(ctx.Any_name_list_(), tokens) {
+			appendDropAction(result, DDLObjectIndex, name, flags)
 		}
 		return nil
 	}
 
-	// DROP object_type_any_name ... (TABLE, INDEX, VIEW, etc.)
-	if objType := ctx.Object_type_any_name(); objType != nil {
-		if nameList := ctx.Any_name_list_(); nameList != nil {
-			switch {
-			case objType.TABLE() != nil:
-				for _, anyName := range nameList.AllAny_name() {
-					prc, ok := anyName.(antlr.ParserRuleContext)
-					if !ok {
-						continue
-					}
-					nameText := strings.TrimSpace(ctxText(tokens, prc))
-					schema, tableName := splitQualifiedName(nameText)
-					result.DDLActions = append(result.DDLActions, DDLAction{
-						Type:       DDLDropTable,
-						ObjectName: tableName,
-						Schema:     schema,
-						Flags:      copyFlags(flags),
-					})
-					result.Tables = append(result.Tables, TableRef{
-						Schema: schema,
-						Name:   tableName,
-						Type:   TableTypeBase,
-						Raw:    nameText,
-					})
-				}
-			case objType.INDEX() != nil:
-				for _, anyName := range nameList.AllAny_name() {
-					prc, ok := anyName.(antlr.ParserRuleContext)
-					if !ok {
-						continue
-					}
-					name := strings.TrimSpace(ctxText(tokens, prc))
-					schema, objectName := splitQualifiedName(name)
-					result.DDLActions = append(result.DDLActions, DDLAction{
-						Type:       DDLDropIndex,
-						ObjectName: objectName,
-						Schema:     schema,
-						Flags:      copyFlags(flags),
-					})
-				}
+	switch {
+	case ctx.Object_type_any_name() != nil:
+		// DROP TABLE | INDEX | VIEW | MATERIALIZED VIEW | SEQUENCE | FOREIGN TABLE | ...
+		kind := objectKindFromText(ruleText(ctx.Object_type_any_name(), tokens))
+		for _, name := range anyNameListTexts(ctx.Any_name_list_(), tokens) {
+			appendDropAction(result, kind, name, flags)
+		}
+
+	case ctx.Drop_type_name() != nil:
+		// DROP SCHEMA | EXTENSION | SERVER | PUBLICATION | [PROCEDURAL] LANGUAGE | ...
+		kind := objectKindFromText(ruleText(ctx.Drop_type_name(), tokens))
+		if ctx.Drop_type_name().Procedural_() != nil {
+			kind = DDLObjectLanguage
+		}
+		if nameList := ctx.Name_list(); nameList != nil {
+			for _, name := range nameList.AllName() {
+				appendDropAction(result, kind, ruleText(name, tokens), flags)
 			}
 		}
+
+	case ctx.Object_type_name_on_any_name() != nil:
+		// DROP TRIGGER | POLICY | RULE name ON table
+		schema, table := splitQualifiedName(ruleText(ctx.Any_name(), tokens))
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:       DDLDrop,
+			ObjectName: ruleText(ctx.Name(), tokens),
+			Schema:     schema,
+			ObjectKind: objectKindFromText(ruleText(ctx.Object_type_name_on_any_name(), tokens)),
+			Table:      table,
+			Flags:      copyFlags(flags),
+		})
+
+	case ctx.Type_name_list() != nil:
+		// DROP TYPE | DOMAIN name [, ...]
+		kind := DDLObjectType
+		if ctx.DOMAIN_P() != nil {
+			kind = DDLObjectDomain
+		}
+		for _, typ := range ctx.Type_name_list().AllTypename() {
+			appendDropAction(result, kind, ruleText(typ, tokens), flags)
+		}
 	}
 	return nil
 }
 
-// populateAlterTable handles ALTER TABLE with ADD/DROP/ALTER column sub-commands.
+// appendDropAction records a DROP of a single named object. Tables and indexes keep
+// their dedicated DROP_TABLE / DROP_INDEX types; every other kind uses DROP.
+func appendDropAction(result *ParsedQuery, kind DDLObjectKind, nameText string, flags []string) {
+	if nameText == "" {
+		return
+	}
+	schema, name := splitQualifiedName(nameText)
+	actionType := DDLDrop
+	switch kind {
+	case DDLObjectTable:
+		actionType = DDLDropTable
+		result.Tables = append(result.Tables, TableRef{
+			Schema: schema,
+			Name:   name,
+			Type:   TableTypeBase,
+			Raw:    nameText,
+		})
+	case DDLObjectIndex:
+		actionType = DDLDropIndex
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       actionType,
+		ObjectName: name,
+		Schema:     schema,
+		ObjectKind: kind,
+		Flags:      copyFlags(flags),
+	})
+}
+
+// anyNameListTexts returns the text of each name in an any_name list.
+func anyNameListTexts(nameList gen.IAny_name_list_Context, tokens antlr.TokenStream) []string {
+	if nameList == nil {
+		return nil
+	}
+	var out []string
+	for _, anyName := range nameList.AllAny_name() {
+		if name := ruleText(anyName, tokens); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// dropFlags builds the IF_EXISTS and CASCADE/RESTRICT flags shared by DROP statements.
+func dropFlags(ifExists bool, behavior gen.IDrop_behavior_Context) []string {
+	var flags []string
+	if ifExists {
+		flags = append(flags, "IF_EXISTS")
+	}
+	if behavior != nil {
+		if behavior.CASCADE() != nil {
+			flags = append(flags, "CASCADE")
+		} else if behavior.RESTRICT() != nil {
+			flags = append(flags, "RESTRICT")
+		}
+	}
+	return flags
+}
+
+// populateAlterTable handles ALTER TABLE | INDEX | SEQUENCE | VIEW | MATERIALIZED VIEW |
+// FOREIGN TABLE name <subcommand> [, ...], plus ATTACH/DETACH PARTITION.
 func populateAlterTable(result *ParsedQuery, ctx gen.IAltertablestmtContext, tokens antlr.TokenStream) error {
 	if ctx == nil {
 		return fmt.Errorf("alter table statement: %w", ErrNilContext)
 	}
-	// Only handle ALTER TABLE (not ALTER INDEX/VIEW/SEQUENCE).
-	if ctx.TABLE() == nil {
+	// ALTER ... ALL IN TABLESPACE moves every matching relation and names no single object.
+	if ctx.ALL() != nil {
 		return nil
 	}
 
-	tableRaw := ""
-	tableName := ""
-	tableSchema := ""
+	base := DDLAction{Type: DDLAlter}
+	switch {
+	case ctx.FOREIGN() != nil:
+		base.ObjectKind = DDLObjectForeignTable
+	case ctx.TABLE() != nil:
+		base.Type = DDLAlterTable
+		base.ObjectKind = DDLObjectTable
+	case ctx.INDEX() != nil:
+		base.ObjectKind = DDLObjectIndex
+	case ctx.SEQUENCE() != nil:
+		base.ObjectKind = DDLObjectSequence
+	case ctx.MATERIALIZED() != nil:
+		base.ObjectKind = DDLObjectMaterializedView
+	case ctx.VIEW() != nil:
+		base.ObjectKind = DDLObjectView
+	}
+
+	var nameCtx, rawCtx antlr.RuleContext
 	if rel := ctx.Relation_expr(); rel != nil {
-		if prc, ok := rel.(antlr.ParserRuleContext); ok {
-			tableRaw = strings.TrimSpace(ctxText(tokens, prc))
+		// ALTER TABLE ONLY name: the name is the qualified_name inside relation_expr.
+		nameCtx, rawCtx = rel.Qualified_name(), rel
+	} else if qn := ctx.Qualified_name(); qn != nil {
+		nameCtx, rawCtx = qn, qn
+	}
+	if nameCtx != nil {
+		base.Schema, base.ObjectName = splitQualifiedName(ruleText(nameCtx, tokens))
+		if base.ObjectKind == DDLObjectTable || base.ObjectKind == DDLObjectForeignTable {
+			result.Tables = append(result.Tables, TableRef{
+				Schema: base.Schema,
+				Name:   base.ObjectName,
+				Type:   TableTypeBase,
+				Raw:    ruleText(rawCtx, tokens),
+			})
 		}
-		schema, name := splitQualifiedName(tableRaw)
-		tableName = name
-		tableSchema = schema
-		result.Tables = append(result.Tables, TableRef{
-			Schema: schema,
-			Name:   name,
-			Type:   TableTypeBase,
-			Raw:    tableRaw,
-		})
+	}
+
+	if pc := ctx.Partition_cmd(); pc != nil {
+		action := base
+		if pc.DETACH() != nil {
+			action.Flags = []string{"DETACH_PARTITION"}
+		} else {
+			action.Flags = []string{"ATTACH_PARTITION"}
+		}
+		action.Objects = []string{ruleText(pc.Qualified_name(), tokens)}
+		result.DDLActions = append(result.DDLActions, action)
+		return nil
+	}
+	if pc := ctx.Index_partition_cmd(); pc != nil {
+		action := base
+		action.Flags = []string{"ATTACH_PARTITION"}
+		action.Objects = []string{ruleText(pc.Qualified_name(), tokens)}
+		result.DDLActions = append(result.DDLActions, action)
+		return nil
 	}
 
 	cmds := ctx.Alter_table_cmds()
@@ -228,33 +429,35 @@ func populateAlterTable(result *ParsedQuery, ctx gen.IAltertablestmtContext, tok
 		return nil
 	}
 	for _, cmd := range cmds.AllAlter_table_cmd() {
-		populateAlterTableCmd(result, cmd, tokens, tableName, tableSchema)
+		populateAlterTableCmd(result, cmd, tokens, base)
 	}
 	return nil
 }
 
-// populateAlterTableCmd processes a single ALTER TABLE sub-command.
-func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext, tokens antlr.TokenStream, tableName, tableSchema string) {
+// populateAlterTableCmd processes a single ALTER TABLE sub-command. base carries the
+// action type and the identity of the altered object.
+func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext, tokens antlr.TokenStream, base DDLAction) {
 	if cmd == nil {
 		return
 	}
 
-	var flags []string
-	if db := cmd.Drop_behavior_(); db != nil {
-		if db.CASCADE() != nil {
-			flags = append(flags, "CASCADE")
-		} else if db.RESTRICT() != nil {
-			flags = append(flags, "RESTRICT")
-		}
-	}
+	flags := dropFlags(false, cmd.Drop_behavior_())
 
 	switch {
-	case cmd.DROP() != nil:
-		// DROP COLUMN vs DROP CONSTRAINT
-		if cmd.CONSTRAINT() != nil {
-			// Skip constraint drops — not column-level DDL.
+	case cmd.ALTER() != nil && cmd.CONSTRAINT() == nil:
+		// ALTER [COLUMN] col ... (checked before DROP/ADD, which also appear in
+		// forms such as ALTER COLUMN col DROP NOT NULL).
+		colName := extractAlterCmdColumnName(cmd, tokens)
+		if colName == "" {
 			return
 		}
+		action := base
+		action.Columns = []string{colName}
+		action.Flags = append(copyFlags(flags), "ALTER_COLUMN")
+		describeAlterColumnCmd(&action, cmd, colName, tokens)
+		result.DDLActions = append(result.DDLActions, action)
+
+	case cmd.DROP() != nil && cmd.CONSTRAINT() == nil:
 		colName := extractAlterCmdColumnName(cmd, tokens)
 		if colName == "" {
 			return
@@ -262,26 +465,16 @@ func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext,
 		if cmd.IF_P() != nil && cmd.EXISTS() != nil {
 			flags = append(flags, "IF_EXISTS")
 		}
-		result.DDLActions = append(result.DDLActions, DDLAction{
-			Type:       DDLDropColumn,
-			ObjectName: tableName,
-			Schema:     tableSchema,
-			Columns:    []string{colName},
-			Flags:      flags,
-		})
+		action := base
+		action.Type = DDLDropColumn
+		action.Columns = []string{colName}
+		action.Flags = flags
+		result.DDLActions = append(result.DDLActions, action)
 
-	case cmd.ADD_P() != nil:
-		if cmd.CONSTRAINT() != nil || cmd.Tableconstraint() != nil {
-			// Skip ADD CONSTRAINT.
-			return
-		}
+	case cmd.ADD_P() != nil && cmd.ColumnDef() != nil:
 		colName := ""
-		if colDef := cmd.ColumnDef(); colDef != nil {
-			if colDef.Colid() != nil {
-				if prc, ok := colDef.Colid().(antlr.ParserRuleContext); ok {
-					colName = strings.TrimSpace(ctxText(tokens, prc))
-				}
-			}
+		if colDef := cmd.ColumnDef(); colDef.Colid() != nil {
+			colName = ruleText(colDef.Colid(), tokens)
 		}
 		if colName == "" {
 			return
@@ -291,37 +484,26 @@ func populateAlterTableCmd(result *ParsedQuery, cmd gen.IAlter_table_cmdContext,
 		if cmd.IF_P() != nil && cmd.NOT() != nil && cmd.EXISTS() != nil {
 			addFlags = append(addFlags, "IF_NOT_EXISTS")
 		}
-		result.DDLActions = append(result.DDLActions, DDLAction{
-			Type:       DDLAlterTable,
-			ObjectName: tableName,
-			Schema:     tableSchema,
-			Columns:    []string{colName},
-			Flags:      addFlags,
-		})
-
-	case cmd.ALTER() != nil:
-		colName := extractAlterCmdColumnName(cmd, tokens)
-		if colName == "" {
-			return
-		}
-		alterFlags := copyFlags(flags)
-		alterFlags = append(alterFlags, "ALTER_COLUMN")
-		result.DDLActions = append(result.DDLActions, DDLAction{
-			Type:       DDLAlterTable,
-			ObjectName: tableName,
-			Schema:     tableSchema,
-			Columns:    []string{colName},
-			Flags:      alterFlags,
-		})
+		action := base
+		action.Columns = []string{colName}
+		action.ColumnDetails = []DDLColumn{extractCreateTableColumn(cmd.ColumnDef(), tokens)}
+		action.Constraints = extractColumnConstraints(cmd.ColumnDef(), colName, tokens)
+		action.Flags = addFlags
+		result.DDLActions = append(result.DDLActions, action)
+
+	case cmd.CONSTRAINT() != nil || cmd.Tableconstraint() != nil:
+		action := base
+		action.Flags = flags
+		describeConstraintCmd(&action, cmd, tokens)
+		result.DDLActions = append(result.DDLActions, action)
 
 	default:
-		// Other sub-commands (OWNER TO, SET, etc.) — generic ALTER_TABLE action.
-		result.DDLActions = append(result.DDLActions, DDLAction{
-			Type:       DDLAlterTable,
-			ObjectName: tableName,
-			Schema:     tableSchema,
-			Flags:      flags,
-		})
+		// OWNER TO, SET TABLESPACE, SET (...), ENABLE/DISABLE TRIGGER, row level
+		// security, SET LOGGED, and the other relation-level sub-commands.
+		action := base
+		action.Flags = flags
+		describeAlterTableCmd(&action, cmd, tokens)
+		result.DDLActions = append(result.DDLActions, action)
 	}
 }
 
@@ -357,19 +539,19 @@ func populateCreateIndex(result *ParsedQuery, ctx gen.IIndexstmtContext, tokens
 		}
 	}
 
-	tableName := ""
+	tableBase := ""
 	tableSchema := ""
 	if rel := ctx.Relation_expr(); rel != nil {
+		tableRaw := ""
 		if prc, ok := rel.(antlr.ParserRuleContext); ok {
-			tableName = strings.TrimSpace(ctxText(tokens, prc))
+			tableRaw = strings.TrimSpace(ctxText(tokens, prc))
 		}
-		schema, name := splitQualifiedName(tableName)
-		tableSchema = schema
+		tableSchema, tableBase = splitQualifiedName(ruleText(rel.Qualified_name(), tokens))
 		result.Tables = append(result.Tables, TableRef{
-			Schema: schema,
-			Name:   name,
+			Schema: tableSchema,
+			Name:   tableBase,
 			Type:   TableTypeBase,
-			Raw:    tableName,
+			Raw:    tableRaw,
 		})
 	}
 
@@ -419,6 +601,16 @@ func populateCreateIndex(result *ParsedQuery, ctx gen.IIndexstmtContext, tokens
 		Columns:    columns,
 		Flags:      flags,
 		IndexType:  indexType,
+		ObjectKind: DDLObjectIndex,
+		Table:      tableBase,
+	}
+	if inc := ctx.Include_(); inc != nil && inc.Index_including_params() != nil {
+		for _, elem := range inc.Index_including_params().AllIndex_elem() {
+			action.IncludeColumns = append(action.IncludeColumns, ruleText(elem, tokens))
+		}
+	}
+	if where := ctx.Where_clause(); where != nil && where.A_expr() != nil {
+		action.Predicate = normalizeSpace(ruleText(where.A_expr(), tokens))
 	}
 	result.DDLActions = append(result.DDLActions, action)
 	return nil
@@ -453,7 +645,7 @@ func populateTruncate(result *ParsedQuery, ctx gen.ITruncatestmtContext, tokens
 				continue
 			}
 			nameText := strings.TrimSpace(ctxText(tokens, prc))
-			schema, name := splitQualifiedName(nameText)
+			schema, name := splitQualifiedName(ruleText(rel.Qualified_name(), tokens))
 			result.DDLActions = append(result.DDLActions, DDLAction{
 				Type:       DDLTruncate,
 				ObjectName: name,
@@ -0,0 +1,443 @@
+// ddl_alter.go implements DDL population logic for relation-level ALTER TABLE sub-commands,
+// ALTER ... OWNER TO, ALTER ... SET SCHEMA, and ALTER SEQUENCE options.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// describeAlterTableCmd identifies a relation-level ALTER TABLE sub-command and records it
+// as a flag, with its argument in Owner, Tablespace, Options, or Objects.
+func describeAlterTableCmd(action *DDLAction, cmd gen.IAlter_table_cmdContext, tokens antlr.TokenStream) {
+	addFlag := func(flag string) { action.Flags = append(action.Flags, flag) }
+
+	switch {
+	case cmd.OWNER() != nil:
+		addFlag("OWNER_TO")
+		action.Owner = ruleText(cmd.Rolespec(), tokens)
+
+	case cmd.ROW() != nil && cmd.SECURITY() != nil:
+		switch {
+		case cmd.NO() != nil:
+			addFlag("NO_FORCE_ROW_LEVEL_SECURITY")
+		case cmd.FORCE() != nil:
+			addFlag("FORCE_ROW_LEVEL_SECURITY")
+		case cmd.ENABLE_P() != nil:
+			addFlag("ENABLE_ROW_LEVEL_SECURITY")
+		default:
+			addFlag("DISABLE_ROW_LEVEL_SECURITY")
+		}
+
+	case cmd.TRIGGER() != nil || cmd.RULE() != nil:
+		// ENABLE [ALWAYS | REPLICA] | DISABLE TRIGGER | RULE name | ALL | USER
+		flag := "DISABLE"
+		if cmd.ENABLE_P() != nil {
+			flag = "ENABLE"
+			if cmd.ALWAYS() != nil {
+				flag += "_ALWAYS"
+			} else if cmd.REPLICA() != nil {
+				flag += "_REPLICA"
+			}
+		}
+		if cmd.TRIGGER() != nil {
+			flag += "_TRIGGER"
+		} else {
+			flag += "_RULE"
+		}
+		addFlag(flag)
+		switch {
+		case cmd.Name() != nil:
+			action.Objects = []string{ruleText(cmd.Name(), tokens)}
+		case cmd.ALL() != nil:
+			action.Objects = []string{"ALL"}
+		case cmd.USER() != nil:
+			action.Objects = []string{"USER"}
+		}
+
+	case cmd.TABLESPACE() != nil:
+		addFlag("SET_TABLESPACE")
+		action.Tablespace = ruleText(cmd.Name(), tokens)
+
+	case cmd.UNLOGGED() != nil:
+		addFlag("SET_UNLOGGED")
+
+	case cmd.LOGGED() != nil:
+		addFlag("SET_LOGGED")
+
+	case cmd.WITHOUT() != nil && cmd.OIDS() != nil:
+		addFlag("SET_WITHOUT_OIDS")
+
+	case cmd.WITHOUT() != nil && cmd.CLUSTER() != nil:
+		addFlag("SET_WITHOUT_CLUSTER")
+
+	case cmd.CLUSTER() != nil:
+		addFlag("CLUSTER_ON")
+		action.Objects = []string{ruleText(cmd.Name(), tokens)}
+
+	case cmd.Reloptions() != nil:
+		// SET (storage_parameter = value, ...) | RESET (storage_parameter, ...)
+		if cmd.RESET() != nil {
+			addFlag("RESET_OPTIONS")
+		} else {
+			addFlag("SET_OPTIONS")
+		}
+		action.Options = extractReloptions(cmd.Reloptions(), tokens)
+
+	case cmd.REPLICA() != nil && cmd.IDENTITY_P() != nil:
+		addFlag("REPLICA_IDENTITY")
+		if ri := cmd.Replica_identity(); ri != nil {
+			if ri.Name() != nil {
+				action.Objects = []string{ruleText(ri.Name(), tokens)}
+			} else {
+				action.Objects = []string{strings.ToUpper(ruleText(ri, tokens))}
+			}
+		}
+
+	case cmd.INHERIT() != nil:
+		if cmd.NO() != nil {
+			addFlag("NO_INHERIT")
+		} else {
+			addFlag("INHERIT")
+		}
+		action.Objects = []string{ruleText(cmd.Qualified_name(), tokens)}
+
+	case cmd.OF() != nil:
+		if cmd.NOT() != nil {
+			addFlag("NOT_OF")
+		} else {
+			addFlag("OF")
+			action.Objects = []string{ruleText(cmd.Any_name(), tokens)}
+		}
+
+	case cmd.Alter_generic_options() != nil:
+		addFlag("OPTIONS")
+		action.Options = extractAlterGenericOptions(cmd.Alter_generic_options(), tokens)
+	}
+}
+
+// extractReloptions converts a (name [= value], ...) storage parameter list into DDLOptions.
+func extractReloptions(opts gen.IReloptionsContext, tokens antlr.TokenStream) []DDLOption {
+	if opts == nil || opts.Reloption_list() == nil {
+		return nil
+	}
+	var out []DDLOption
+	for _, elem := range opts.Reloption_list().AllReloption_elem() {
+		labels := make([]string, 0, 2)
+		for _, label := range elem.AllColLabel() {
+			labels = append(labels, ruleText(label, tokens))
+		}
+		value := unquoteStringLiteral(ruleText(elem.Def_arg(), tokens))
+		out = append(out, newDDLOption(strings.Join(labels, "."), value))
+	}
+	return out
+}
+
+// extractAlterGenericOptions converts an OPTIONS ([ADD | SET | DROP] name 'value', ...) clause
+// into DDLOptions. Dropped options carry an empty value.
+func extractAlterGenericOptions(opts gen.IAlter_generic_optionsContext, tokens antlr.TokenStream) []DDLOption {
+	if opts == nil || opts.Alter_generic_option_list() == nil {
+		return nil
+	}
+	var out []DDLOption
+	for _, elem := range opts.Alter_generic_option_list().AllAlter_generic_option_elem() {
+		if ge := elem.Generic_option_elem(); ge != nil {
+			name := ruleText(ge.Generic_option_name(), tokens)
+			value := unquoteStringLiteral(ruleText(ge.Generic_option_arg(), tokens))
+			out = append(out, newDDLOption(name, value))
+		} else if gn := elem.Generic_option_name(); gn != nil {
+			out = append(out, DDLOption{Name: ruleText(gn, tokens)})
+		}
+	}
+	return out
+}
+
+// populateAlterOwner handles ALTER <object> name OWNER TO role for non-relation objects
+// (schemas, functions, types, databases, servers, publications, ...).
+func populateAlterOwner(result *ParsedQuery, ctx gen.IAlterownerstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter owner statement: %w", ErrNilContext)
+	}
+	prc, ok := ctx.(antlr.ParserRuleContext)
+	if !ok {
+		return nil
+	}
+	action, _ := alterObjectTarget(result, prc, tokens)
+	action.Owner = ruleText(ctx.Rolespec(), tokens)
+	action.Flags = append(action.Flags, "OWNER_TO")
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateAlterObjectSchema handles ALTER <object> [IF EXISTS] name SET SCHEMA new_schema.
+func populateAlterObjectSchema(result *ParsedQuery, ctx gen.IAlterobjectschemastmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter set schema statement: %w", ErrNilContext)
+	}
+	prc, ok := ctx.(antlr.ParserRuleContext)
+	if !ok {
+		return nil
+	}
+	action, _ := alterObjectTarget(result, prc, tokens)
+	if ctx.IF_P() != nil && ctx.EXISTS() != nil {
+		action.Flags = append(action.Flags, "IF_EXISTS")
+	}
+	action.Flags = append(action.Flags, "SET_SCHEMA")
+	// The new schema is always the last name in the statement.
+	if names := ctx.AllName(); len(names) > 0 {
+		action.NewSchema = ruleText(names[len(names)-1], tokens)
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateAlterSequence handles ALTER SEQUENCE [IF EXISTS] name <sequence options>.
+// Each option becomes a DDLOption named by its keywords, e.g. INCREMENT, RESTART, OWNED BY.
+func populateAlterSequence(result *ParsedQuery, ctx gen.IAlterseqstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter sequence statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLAlter,
+		ObjectKind: DDLObjectSequence,
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Qualified_name(), tokens))
+	if ctx.IF_P() != nil && ctx.EXISTS() != nil {
+		action.Flags = append(action.Flags, "IF_EXISTS")
+	}
+	if list := ctx.Seqoptlist(); list != nil {
+		for _, elem := range list.AllSeqoptelem() {
+			if prc, ok := elem.(antlr.ParserRuleContext); ok {
+				action.Options = append(action.Options, sequenceOption(prc, tokens))
+			}
+		}
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// sequenceOption converts a seqoptelem into a DDLOption: keywords form the name and the
+// first operand (number, type, or name) forms the value. Noise words BY and WITH are dropped.
+func sequenceOption(elem antlr.ParserRuleContext, tokens antlr.TokenStream) DDLOption {
+	var words []string
+	value := ""
+	for _, child := range elem.GetChildren() {
+		switch c := child.(type) {
+		case antlr.TerminalNode:
+			words = append(words, strings.ToUpper(c.GetText()))
+		case gen.IBy_Context, gen.IWith_Context:
+			continue
+		case antlr.RuleContext:
+			if value == "" {
+				value = normalizeSpace(ruleText(c, tokens))
+			}
+		}
+	}
+	return DDLOption{Name: strings.Join(words, " "), Value: value}
+}
+
+// alterObjectTarget builds the base action for an ALTER <object> statement by reading the
+// object keywords after ALTER and the first name-like child that follows them, which it
+// also returns. Tables keep the ALTER_TABLE type; every other kind uses ALTER.
+func alterObjectTarget(result *ParsedQuery, ctx antlr.ParserRuleContext, tokens antlr.TokenStream) (DDLAction, antlr.ParserRuleContext) {
+	action := DDLAction{Type: DDLAlter}
+	var words []string
+	var target antlr.ParserRuleContext
+	raw := ""
+children:
+	for _, child := range ctx.GetChildren() {
+		switch c := child.(type) {
+		case antlr.TerminalNode:
+			switch c.GetSymbol().GetTokenType() {
+			case gen.PostgreSQLLexerALTER, gen.PostgreSQLLexerIF_P, gen.PostgreSQLLexerEXISTS:
+				continue
+			}
+			words = append(words, c.GetText())
+			continue
+		case gen.IProcedural_Context:
+			continue
+		}
+		if prc, ok := child.(antlr.ParserRuleContext); ok {
+			target = prc
+		}
+		switch c := child.(type) {
+		case gen.IFunction_with_argtypesContext:
+			action.Schema, action.ObjectName = functionNameFromArgtypes(c, tokens)
+			action.Signature = funcArgsSignature(c.Func_args(), tokens)
+		case gen.IAggregate_with_argtypesContext:
+			action.Schema, action.ObjectName = splitQualifiedName(ruleText(c.Func_name(), tokens))
+			action.Signature = aggrArgsSignature(c.Aggr_args(), tokens)
+		case gen.IOperator_with_argtypesContext:
+			action.Schema, action.ObjectName = splitOperatorName(ruleText(c.Any_operator(), tokens))
+			action.Signature = operArgsSignature(c.Oper_argtypes(), tokens)
+		case antlr.RuleContext:
+			raw = ruleText(c, tokens)
+			action.Schema, action.ObjectName = splitQualifiedName(raw)
+		}
+		break children
+	}
+	action.ObjectKind = objectKindFromText(strings.Join(words, " "))
+	switch action.ObjectKind {
+	case "USER", "GROUP":
+		action.ObjectKind = DDLObjectRole
+	case DDLObjectTable:
+		action.Type = DDLAlterTable
+		fallthrough
+	case DDLObjectForeignTable:
+		result.Tables = append(result.Tables, TableRef{
+			Schema: action.Schema,
+			Name:   action.ObjectName,
+			Type:   TableTypeBase,
+			Raw:    raw,
+		})
+	}
+	return action, target
+}
+
+// describeAlterColumnCmd identifies an ALTER COLUMN sub-command. The changed type or
+// default is recorded in a single ColumnDetails entry; its Nullable field is not meaningful.
+func describeAlterColumnCmd(action *DDLAction, cmd gen.IAlter_table_cmdContext, colName string, tokens antlr.TokenStream) {
+	addFlag := func(flag string) { action.Flags = append(action.Flags, flag) }
+
+	switch {
+	case cmd.TYPE_P() != nil:
+		addFlag("SET_TYPE")
+		action.ColumnDetails = []DDLColumn{{Name: colName, Type: normalizeSpace(ruleText(cmd.Typename(), tokens))}}
+		if cmd.Alter_using() != nil {
+			addFlag("USING")
+		}
+	case cmd.Alter_column_default() != nil:
+		def := cmd.Alter_column_default()
+		if def.DROP() != nil {
+			addFlag("DROP_DEFAULT")
+		} else {
+			addFlag("SET_DEFAULT")
+			action.ColumnDetails = []DDLColumn{{Name: colName, Default: ruleText(def.A_expr(), tokens)}}
+		}
+	case cmd.NOT() != nil && cmd.NULL_P() != nil:
+		if cmd.SET() != nil {
+			addFlag("SET_NOT_NULL")
+		} else {
+			addFlag("DROP_NOT_NULL")
+		}
+	case cmd.EXPRESSION() != nil:
+		addFlag("DROP_EXPRESSION")
+	case cmd.GENERATED() != nil:
+		addFlag("ADD_IDENTITY")
+	case cmd.IDENTITY_P() != nil:
+		addFlag("DROP_IDENTITY")
+	case cmd.Alter_identity_column_option_list() != nil:
+		addFlag("SET_IDENTITY")
+	case cmd.STATISTICS() != nil:
+		addFlag("SET_STATISTICS")
+	case cmd.STORAGE() != nil:
+		addFlag("SET_STORAGE")
+	case cmd.Reloptions() != nil:
+		if cmd.RESET() != nil {
+			addFlag("RESET_OPTIONS")
+		} else {
+			addFlag("SET_OPTIONS")
+		}
+		action.Options = extractReloptions(cmd.Reloptions(), tokens)
+	case cmd.Alter_generic_options() != nil:
+		addFlag("OPTIONS")
+		action.Options = extractAlterGenericOptions(cmd.Alter_generic_options(), tokens)
+	}
+}
+
+// describeConstraintCmd identifies ADD, DROP, VALIDATE, and ALTER CONSTRAINT sub-commands.
+// Added constraints are recorded in full; the others record only the constraint name.
+func describeConstraintCmd(action *DDLAction, cmd gen.IAlter_table_cmdContext, tokens antlr.TokenStream) {
+	switch {
+	case cmd.ADD_P() != nil:
+		action.Flags = append(action.Flags, "ADD_CONSTRAINT")
+		if con, ok := extractTableConstraint(cmd.Tableconstraint(), tokens); ok {
+			action.Constraints = []DDLConstraint{con}
+			action.Columns = append([]string(nil), con.Columns...)
+		}
+		return
+	case cmd.DROP() != nil:
+		if cmd.IF_P() != nil && cmd.EXISTS() != nil {
+			action.Flags = append(action.Flags, "IF_EXISTS")
+		}
+		action.Flags = append(action.Flags, "DROP_CONSTRAINT")
+	case cmd.VALIDATE() != nil:
+		action.Flags = append(action.Flags, "VALIDATE_CONSTRAINT")
+	default:
+		action.Flags = append(action.Flags, "ALTER_CONSTRAINT")
+	}
+	action.Constraints = []DDLConstraint{{Name: ruleText(cmd.Name(), tokens)}}
+}
+
+// populateRename handles ALTER <object> ... RENAME [COLUMN | CONSTRAINT | ATTRIBUTE] ... TO new_name.
+// Object renames set NewName; column and attribute renames put the old name in Columns, and
+// constraint renames put it in Constraints.
+func populateRename(result *ParsedQuery, ctx gen.IRenamestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("rename statement: %w", ErrNilContext)
+	}
+	prc, ok := ctx.(antlr.ParserRuleContext)
+	if !ok {
+		return nil
+	}
+	action, target := alterObjectTarget(result, prc, tokens)
+	if ctx.IF_P() != nil && ctx.EXISTS() != nil {
+		action.Flags = append(action.Flags, "IF_EXISTS")
+	}
+
+	// Role renames use roleid rather than name.
+	if roles := ctx.AllRoleid(); len(roles) == 2 {
+		action.ObjectName = ruleText(roles[0], tokens)
+		action.NewName = ruleText(roles[1], tokens)
+		action.Flags = append(action.Flags, "RENAME")
+		result.DDLActions = append(result.DDLActions, action)
+		return nil
+	}
+
+	// Objects declared ON a table: ALTER TRIGGER | POLICY | RULE name ON table RENAME TO new.
+	if ctx.ON() != nil && ctx.Qualified_name() != nil {
+		action.Schema, action.Table = splitQualifiedName(ruleText(ctx.Qualified_name(), tokens))
+	}
+
+	// Names after the renamed object: [old] new.
+	var rest []string
+	for _, name := range ctx.AllName() {
+		if target == nil || name.GetStart().GetTokenIndex() > target.GetStop().GetTokenIndex() {
+			rest = append(rest, ruleText(name, tokens))
+		}
+	}
+	if len(rest) == 0 {
+		return nil
+	}
+	action.NewName = rest[len(rest)-1]
+
+	switch {
+	case ctx.CONSTRAINT() != nil && len(rest) == 2:
+		action.Flags = append(action.Flags, "RENAME_CONSTRAINT")
+		action.Constraints = []DDLConstraint{{Name: rest[0]}}
+	case ctx.ATTRIBUTE() != nil && len(rest) == 2:
+		action.Flags = append(action.Flags, "RENAME_ATTRIBUTE")
+		action.Columns = []string{rest[0]}
+	case len(rest) == 2 && isRelationKind(action.ObjectKind):
+		action.Flags = append(action.Flags, "RENAME_COLUMN")
+		action.Columns = []string{rest[0]}
+	default:
+		action.Flags = append(action.Flags, "RENAME")
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// isRelationKind reports whether kind names a relation that has columns.
+func isRelationKind(kind DDLObjectKind) bool {
+	switch kind {
+	case DDLObjectTable, DDLObjectView, DDLObjectMaterializedView, DDLObjectForeignTable:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,150 @@
+// ddl_comment.go implements DDL population logic for COMMENT ON.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateComment handles COMMENT ON <object> IS 'text' | NULL.
+func populateComment(result *ParsedQuery, ctx gen.ICommentstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("comment statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{Type: DDLComment}
+	if text := ctx.Comment_text(); text != nil {
+		if text.NULL_P() != nil {
+			action.Flags = append(action.Flags, "NULL_COMMENT")
+		} else if sc := text.Sconst(); sc != nil {
+			if prc, ok := sc.(antlr.ParserRuleContext); ok {
+				action.Comment = unquoteStringLiteral(strings.TrimSpace(ctxText(tokens, prc)))
+			}
+		}
+	}
+
+	switch {
+	case ctx.COLUMN() != nil:
+		// COMMENT ON COLUMN [schema.]table.column
+		action.ObjectKind = DDLObjectColumn
+		parts := splitQuotedDot(ruleText(ctx.Any_name(), tokens))
+		if len(parts) < 2 {
+			return nil
+		}
+		tableRaw := strings.Join(parts[:len(parts)-1], ".")
+		schema, table := splitQualifiedName(tableRaw)
+		action.ObjectName = table
+		action.Schema = schema
+		action.Columns = []string{strings.TrimSpace(parts[len(parts)-1])}
+		result.Tables = append(result.Tables, TableRef{
+			Schema: schema,
+			Name:   table,
+			Type:   TableTypeBase,
+			Raw:    tableRaw,
+		})
+
+	case ctx.Object_type_any_name() != nil:
+		action.ObjectKind = objectKindFromText(ruleText(ctx.Object_type_any_name(), tokens))
+		raw := ruleText(ctx.Any_name(), tokens)
+		action.Schema, action.ObjectName = splitQualifiedName(raw)
+		if action.ObjectKind == DDLObjectTable {
+			result.Tables = append(result.Tables, TableRef{
+				Schema: action.Schema,
+				Name:   action.ObjectName,
+				Type:   TableTypeBase,
+				Raw:    raw,
+			})
+		}
+
+	case ctx.Object_type_name() != nil:
+		action.ObjectKind = objectKindFromText(ruleText(ctx.Object_type_name(), tokens))
+		action.ObjectName = ruleText(ctx.Name(), tokens)
+
+	case ctx.CONSTRAINT() != nil:
+		// COMMENT ON CONSTRAINT name ON [DOMAIN] table
+		action.ObjectKind = DDLObjectConstraint
+		action.ObjectName = ruleText(ctx.Name(), tokens)
+		action.Schema, action.Table = splitQualifiedName(ruleText(ctx.Any_name(), tokens))
+
+	case ctx.Object_type_name_on_any_name() != nil:
+		// COMMENT ON TRIGGER|POLICY|RULE name ON table
+		action.ObjectKind = objectKindFromText(ruleText(ctx.Object_type_name_on_any_name(), tokens))
+		action.ObjectName = ruleText(ctx.Name(), tokens)
+		action.Schema, action.Table = splitQualifiedName(ruleText(ctx.Any_name(), tokens))
+
+	case ctx.TYPE_P() != nil && ctx.TRANSFORM() == nil:
+		action.ObjectKind = DDLObjectType
+		action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Typename(0), tokens))
+
+	case ctx.DOMAIN_P() != nil:
+		action.ObjectKind = DDLObjectDomain
+		action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Typename(0), tokens))
+
+	case ctx.Function_with_argtypes() != nil:
+		switch {
+		case ctx.PROCEDURE() != nil:
+			action.ObjectKind = DDLObjectProcedure
+		case ctx.ROUTINE() != nil:
+			action.ObjectKind = DDLObjectRoutine
+		default:
+			action.ObjectKind = DDLObjectFunction
+		}
+		action.Schema, action.ObjectName = functionNameFromArgtypes(ctx.Function_with_argtypes(), tokens)
+
+	case ctx.Aggregate_with_argtypes() != nil:
+		action.ObjectKind = DDLObjectAggregate
+		if fn := ctx.Aggregate_with_argtypes().Func_name(); fn != nil {
+			action.Schema, action.ObjectName = splitQualifiedName(ruleText(fn, tokens))
+		}
+
+	default:
+		// OPERATOR, CAST, TRANSFORM, LARGE OBJECT: record the kind only.
+		action.ObjectKind = commentFallbackKind(ctx)
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// commentFallbackKind derives the object kind for the less common COMMENT ON forms.
+func commentFallbackKind(ctx gen.ICommentstmtContext) DDLObjectKind {
+	switch {
+	case ctx.OPERATOR() != nil && ctx.CLASS() != nil:
+		return DDLObjectKind("OPERATOR CLASS")
+	case ctx.OPERATOR() != nil && ctx.FAMILY() != nil:
+		return DDLObjectKind("OPERATOR FAMILY")
+	case ctx.OPERATOR() != nil:
+		return DDLObjectOperator
+	case ctx.CAST() != nil:
+		return DDLObjectKind("CAST")
+	case ctx.TRANSFORM() != nil:
+		return DDLObjectKind("TRANSFORM")
+	case ctx.LARGE_P() != nil:
+		return DDLObjectKind("LARGE OBJECT")
+	}
+	return ""
+}
+
+// functionNameFromArgtypes returns the schema and name of a function_with_argtypes, without arguments.
+func functionNameFromArgtypes(fn gen.IFunction_with_argtypesContext, tokens antlr.TokenStream) (string, string) {
+	if fn == nil {
+		return "", ""
+	}
+	if fn.Func_name() != nil {
+		return splitQualifiedName(ruleText(fn.Func_name(), tokens))
+	}
+	raw := ruleText(fn, tokens)
+	if idx := strings.Index(raw, "("); idx >= 0 {
+		raw = strings.TrimSpace(raw[:idx])
+	}
+	return splitQualifiedName(raw)
+}
+
+// objectKindFromText normalizes an object type keyword sequence into a DDLObjectKind.
+func objectKindFromText(text string) DDLObjectKind {
+	return DDLObjectKind(strings.ToUpper(normalizeSpace(text)))
+}
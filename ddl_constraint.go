@@ -0,0 +1,179 @@
+// ddl_constraint.go extracts column and table constraints from CREATE TABLE, ADD COLUMN,
+// and ALTER TABLE ... ADD CONSTRAINT.
+package postgresparser
+
+import (
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// extractColumnConstraints returns the PRIMARY KEY, UNIQUE, REFERENCES, and CHECK
+// constraints declared inline on a column definition.
+func extractColumnConstraints(colDef gen.IColumnDefContext, colName string, tokens antlr.TokenStream) []DDLConstraint {
+	if colDef == nil || colDef.Colquallist() == nil {
+		return nil
+	}
+	var out []DDLConstraint
+	for _, cc := range colDef.Colquallist().AllColconstraint() {
+		elem := cc.Colconstraintelem()
+		if elem == nil {
+			// A trailing DEFERRABLE / NOT DEFERRABLE / INITIALLY attribute.
+			continue
+		}
+		con := DDLConstraint{Name: ruleText(cc.Name(), tokens)}
+		switch {
+		case elem.PRIMARY() != nil:
+			con.Type = DDLConstraintPrimaryKey
+			con.Columns = []string{colName}
+		case elem.UNIQUE() != nil:
+			con.Type = DDLConstraintUnique
+			con.Columns = []string{colName}
+		case elem.REFERENCES() != nil:
+			con.Type = DDLConstraintForeignKey
+			con.Columns = []string{colName}
+			con.RefSchema, con.RefTable = splitQualifiedName(ruleText(elem.Qualified_name(), tokens))
+			con.RefColumns = columnListNames(elem.Column_list_(), tokens)
+			con.OnDelete, con.OnUpdate = foreignKeyActions(elem.Key_actions(), tokens)
+		case elem.CHECK() != nil:
+			con.Type = DDLConstraintCheck
+			con.Columns = []string{colName}
+			con.Expression = ruleText(elem.A_expr(), tokens)
+		default:
+			// NOT NULL, NULL, DEFAULT, and GENERATED are column properties, not constraints.
+			continue
+		}
+		out = append(out, con)
+	}
+	return out
+}
+
+// extractTableConstraint converts a table-level [CONSTRAINT name] constraint into a DDLConstraint.
+func extractTableConstraint(tc gen.ITableconstraintContext, tokens antlr.TokenStream) (DDLConstraint, bool) {
+	if tc == nil || tc.Constraintelem() == nil {
+		return DDLConstraint{}, false
+	}
+	elem := tc.Constraintelem()
+	con := DDLConstraint{Name: ruleText(tc.Name(), tokens)}
+	switch {
+	case elem.PRIMARY() != nil:
+		con.Type = DDLConstraintPrimaryKey
+		con.Columns = columnlistNames(elem.Columnlist(), tokens)
+		con.Index = existingIndexName(elem.Existingindex(), tokens)
+	case elem.UNIQUE() != nil:
+		con.Type = DDLConstraintUnique
+		con.Columns = columnlistNames(elem.Columnlist(), tokens)
+		con.Index = existingIndexName(elem.Existingindex(), tokens)
+	case elem.FOREIGN() != nil:
+		con.Type = DDLConstraintForeignKey
+		con.Columns = columnlistNames(elem.Columnlist(), tokens)
+		con.RefSchema, con.RefTable = splitQualifiedName(ruleText(elem.Qualified_name(), tokens))
+		con.RefColumns = columnListNames(elem.Column_list_(), tokens)
+		con.OnDelete, con.OnUpdate = foreignKeyActions(elem.Key_actions(), tokens)
+	case elem.CHECK() != nil:
+		con.Type = DDLConstraintCheck
+		con.Expression = ruleText(elem.A_expr(), tokens)
+	case elem.EXCLUDE() != nil:
+		con.Type = DDLConstraintExclude
+		con.Expression = exclusionDefinition(elem, tokens)
+	default:
+		return DDLConstraint{}, false
+	}
+	con.NotValid = constraintNotValid(elem.Constraintattributespec())
+	return con, true
+}
+
+// existingIndexName returns the index of a USING INDEX clause, or "".
+func existingIndexName(ctx gen.IExistingindexContext, tokens antlr.TokenStream) string {
+	if ctx == nil {
+		return ""
+	}
+	return ruleText(ctx.Name(), tokens)
+}
+
+// exclusionDefinition returns the text of an EXCLUDE constraint after the keyword, up to
+// its constraint attributes, e.g. "USING gist (room WITH =, during WITH &&)".
+func exclusionDefinition(elem gen.IConstraintelemContext, tokens antlr.TokenStream) string {
+	start := elem.EXCLUDE().GetSymbol().GetTokenIndex() + 1
+	stop := elem.GetStop().GetTokenIndex()
+	if spec := elem.Constraintattributespec(); spec != nil && spec.GetStop() != nil &&
+		spec.GetStop().GetTokenIndex() >= spec.GetStart().GetTokenIndex() {
+		stop = spec.GetStart().GetTokenIndex() - 1
+	}
+	if stop < start {
+		return ""
+	}
+	return normalizeSpace(tokens.GetTextFromInterval(antlr.Interval{Start: start, Stop: stop}))
+}
+
+// appendTableElementConstraints adds the inline and table-level constraints of a table
+// element list to action.
+func appendTableElementConstraints(action *DDLAction, opts gen.IOpttableelementlistContext, tokens antlr.TokenStream) {
+	if opts == nil || opts.Tableelementlist() == nil {
+		return
+	}
+	for _, tableElem := range opts.Tableelementlist().AllTableelement() {
+		if tableElem == nil {
+			continue
+		}
+		if colDef := tableElem.ColumnDef(); colDef != nil {
+			name := ruleText(colDef.Colid(), tokens)
+			action.Constraints = append(action.Constraints, extractColumnConstraints(colDef, name, tokens)...)
+			continue
+		}
+		if con, ok := extractTableConstraint(tableElem.Tableconstraint(), tokens); ok {
+			action.Constraints = append(action.Constraints, con)
+		}
+	}
+}
+
+// columnlistNames returns the column names of a columnlist.
+func columnlistNames(list gen.IColumnlistContext, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	var out []string
+	for _, elem := range list.AllColumnElem() {
+		if name := ruleText(elem, tokens); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// columnListNames returns the column names of an optional parenthesised column list.
+func columnListNames(list gen.IColumn_list_Context, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	return columnlistNames(list.Columnlist(), tokens)
+}
+
+// foreignKeyActions returns the normalized ON DELETE and ON UPDATE actions.
+func foreignKeyActions(actions gen.IKey_actionsContext, tokens antlr.TokenStream) (onDelete, onUpdate string) {
+	if actions == nil {
+		return "", ""
+	}
+	if del := actions.Key_delete(); del != nil {
+		onDelete = strings.ToUpper(normalizeSpace(ruleText(del.Key_action(), tokens)))
+	}
+	if upd := actions.Key_update(); upd != nil {
+		onUpdate = strings.ToUpper(normalizeSpace(ruleText(upd.Key_action(), tokens)))
+	}
+	return onDelete, onUpdate
+}
+
+// constraintNotValid reports whether a constraint attribute list contains NOT VALID.
+func constraintNotValid(spec gen.IConstraintattributespecContext) bool {
+	if spec == nil {
+		return false
+	}
+	for _, elem := range spec.AllConstraintattributeElem() {
+		if elem.NOT() != nil && elem.VALID() != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,319 @@
+// ddl_drop.go implements DDL population logic for the DROP statements that have their
+// own grammar rules: functions, aggregates, operators, roles, databases, subscriptions,
+// tablespaces, user mappings, and DROP OWNED.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateDropFunction handles DROP FUNCTION | PROCEDURE | ROUTINE [IF EXISTS] name [(args)] [, ...].
+func populateDropFunction(result *ParsedQuery, ctx gen.IRemovefuncstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop function statement: %w", ErrNilContext)
+	}
+
+	kind := DDLObjectFunction
+	switch {
+	case ctx.PROCEDURE() != nil:
+		kind = DDLObjectProcedure
+	case ctx.ROUTINE() != nil:
+		kind = DDLObjectRoutine
+	}
+	flags := dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, ctx.Drop_behavior_())
+
+	if list := ctx.Function_with_argtypes_list(); list != nil {
+		for _, fn := range list.AllFunction_with_argtypes() {
+			schema, name := functionNameFromArgtypes(fn, tokens)
+			if name == "" {
+				continue
+			}
+			result.DDLActions = append(result.DDLActions, DDLAction{
+				Type:       DDLDrop,
+				ObjectName: name,
+				Schema:     schema,
+				ObjectKind: kind,
+				Signature:  funcArgsSignature(fn.Func_args(), tokens),
+				Flags:      copyFlags(flags),
+			})
+		}
+	}
+	return nil
+}
+
+// populateDropAggregate handles DROP AGGREGATE [IF EXISTS] name (args) [, ...].
+func populateDropAggregate(result *ParsedQuery, ctx gen.IRemoveaggrstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop aggregate statement: %w", ErrNilContext)
+	}
+
+	flags := dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, ctx.Drop_behavior_())
+	if list := ctx.Aggregate_with_argtypes_list(); list != nil {
+		for _, agg := range list.AllAggregate_with_argtypes() {
+			schema, name := splitQualifiedName(ruleText(agg.Func_name(), tokens))
+			if name == "" {
+				continue
+			}
+			result.DDLActions = append(result.DDLActions, DDLAction{
+				Type:       DDLDrop,
+				ObjectName: name,
+				Schema:     schema,
+				ObjectKind: DDLObjectAggregate,
+				Signature:  aggrArgsSignature(agg.Aggr_args(), tokens),
+				Flags:      copyFlags(flags),
+			})
+		}
+	}
+	return nil
+}
+
+// populateDropOperator handles DROP OPERATOR [IF EXISTS] op (left, right) [, ...].
+func populateDropOperator(result *ParsedQuery, ctx gen.IRemoveoperstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop operator statement: %w", ErrNilContext)
+	}
+
+	flags := dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, ctx.Drop_behavior_())
+	if list := ctx.Operator_with_argtypes_list(); list != nil {
+		for _, op := range list.AllOperator_with_argtypes() {
+			schema, name := splitOperatorName(ruleText(op.Any_operator(), tokens))
+			if name == "" {
+				continue
+			}
+			result.DDLActions = append(result.DDLActions, DDLAction{
+				Type:       DDLDrop,
+				ObjectName: name,
+				Schema:     schema,
+				ObjectKind: DDLObjectOperator,
+				Signature:  operArgsSignature(op.Oper_argtypes(), tokens),
+				Flags:      copyFlags(flags),
+			})
+		}
+	}
+	return nil
+}
+
+// populateDropRole handles DROP ROLE | USER | GROUP [IF EXISTS] name [, ...].
+func populateDropRole(result *ParsedQuery, ctx gen.IDroprolestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop role statement: %w", ErrNilContext)
+	}
+
+	flags := dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, nil)
+	for _, role := range roleListTexts(ctx.Role_list(), tokens) {
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:       DDLDrop,
+			ObjectName: role,
+			ObjectKind: DDLObjectRole,
+			Flags:      copyFlags(flags),
+		})
+	}
+	return nil
+}
+
+// populateDropDatabase handles DROP DATABASE [IF EXISTS] name [[WITH] (FORCE)].
+func populateDropDatabase(result *ParsedQuery, ctx gen.IDropdbstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop database statement: %w", ErrNilContext)
+	}
+
+	flags := dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, nil)
+	if opts := ctx.Drop_option_list(); opts != nil {
+		for _, opt := range opts.AllDrop_option() {
+			if opt.FORCE() != nil {
+				flags = append(flags, "FORCE")
+				break
+			}
+		}
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLDrop,
+		ObjectName: ruleText(ctx.Name(), tokens),
+		ObjectKind: DDLObjectDatabase,
+		Flags:      flags,
+	})
+	return nil
+}
+
+// populateDropSubscription handles DROP SUBSCRIPTION [IF EXISTS] name [CASCADE | RESTRICT].
+func populateDropSubscription(result *ParsedQuery, ctx gen.IDropsubscriptionstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop subscription statement: %w", ErrNilContext)
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLDrop,
+		ObjectName: ruleText(ctx.Name(), tokens),
+		ObjectKind: DDLObjectSubscription,
+		Flags:      dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, ctx.Drop_behavior_()),
+	})
+	return nil
+}
+
+// populateDropTablespace handles DROP TABLESPACE [IF EXISTS] name.
+func populateDropTablespace(result *ParsedQuery, ctx gen.IDroptablespacestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop tablespace statement: %w", ErrNilContext)
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLDrop,
+		ObjectName: ruleText(ctx.Name(), tokens),
+		ObjectKind: DDLObjectTablespace,
+		Flags:      dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, nil),
+	})
+	return nil
+}
+
+// populateDropUserMapping handles DROP USER MAPPING [IF EXISTS] FOR role SERVER name.
+func populateDropUserMapping(result *ParsedQuery, ctx gen.IDropusermappingstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop user mapping statement: %w", ErrNilContext)
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLDrop,
+		ObjectName: ruleText(ctx.Auth_ident(), tokens),
+		ObjectKind: DDLObjectUserMapping,
+		Server:     ruleText(ctx.Name(), tokens),
+		Flags:      dropFlags(ctx.IF_P() != nil && ctx.EXISTS() != nil, nil),
+	})
+	return nil
+}
+
+// populateDropOwned handles DROP OWNED BY role [, ...] [CASCADE | RESTRICT].
+// Each role becomes one action whose ObjectName is the role.
+func populateDropOwned(result *ParsedQuery, ctx gen.IDropownedstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("drop owned statement: %w", ErrNilContext)
+	}
+
+	flags := dropFlags(false, ctx.Drop_behavior_())
+	for _, role := range roleListTexts(ctx.Role_list(), tokens) {
+		result.DDLActions = append(result.DDLActions, DDLAction{
+			Type:       DDLDrop,
+			ObjectName: role,
+			ObjectKind: DDLObjectOwned,
+			Flags:      copyFlags(flags),
+		})
+	}
+	return nil
+}
+
+// splitOperatorName splits a possibly schema-qualified operator such as "myschema.===".
+// The operator symbol itself may contain dots, so only the last one separates the schema.
+func splitOperatorName(raw string) (string, string) {
+	raw = strings.ReplaceAll(raw, " ", "")
+	if idx := strings.LastIndex(raw, "."); idx > 0 && idx < len(raw)-1 {
+		return raw[:idx], raw[idx+1:]
+	}
+	return "", raw
+}
+
+// roleListTexts returns the text of each role in a role_list.
+func roleListTexts(roles gen.IRole_listContext, tokens antlr.TokenStream) []string {
+	if roles == nil {
+		return nil
+	}
+	var out []string
+	for _, role := range roles.AllRolespec() {
+		if name := ruleText(role, tokens); name != "" {
+			out = append(out, name)
+		}
+	}
+	return out
+}
+
+// funcArgsSignature renders the argument types of a function reference as "(type, ...)".
+// Argument names and modes are dropped; OUT arguments do not take part in the signature.
+// It returns "" when the reference has no argument list.
+func funcArgsSignature(args gen.IFunc_argsContext, tokens antlr.TokenStream) string {
+	if args == nil {
+		return ""
+	}
+	var types []string
+	if list := args.Func_args_list(); list != nil {
+		for _, arg := range list.AllFunc_arg() {
+			if typ, ok := funcArgType(arg, tokens); ok {
+				types = append(types, typ)
+			}
+		}
+	}
+	return "(" + strings.Join(types, ", ") + ")"
+}
+
+// funcArgType returns the normalized type of a single func_arg and whether it is part
+// of the call signature.
+func funcArgType(arg gen.IFunc_argContext, tokens antlr.TokenStream) (string, bool) {
+	if arg == nil {
+		return "", false
+	}
+	if class := arg.Arg_class(); class != nil && class.OUT_P() != nil && class.IN_P() == nil {
+		return "", false
+	}
+	return normalizeSpace(ruleText(arg.Func_type(), tokens)), true
+}
+
+// aggrArgsSignature renders aggregate arguments as "(type, ...)", "(*)", or, for ordered-set
+// aggregates, "(type ORDER BY type)".
+func aggrArgsSignature(args gen.IAggr_argsContext, tokens antlr.TokenStream) string {
+	if args == nil {
+		return ""
+	}
+	if args.STAR() != nil {
+		return "(*)"
+	}
+	var parts []string
+	for _, list := range args.AllAggr_args_list() {
+		var types []string
+		for _, arg := range list.AllAggr_arg() {
+			if typ, ok := funcArgType(arg.Func_arg(), tokens); ok {
+				types = append(types, typ)
+			}
+		}
+		parts = append(parts, strings.Join(types, ", "))
+	}
+	if args.ORDER() != nil {
+		if len(parts) == 1 {
+			// ORDER BY without direct arguments: "(ORDER BY type)".
+			return "(ORDER BY " + parts[0] + ")"
+		}
+		return "(" + strings.Join(parts, " ORDER BY ") + ")"
+	}
+	return "(" + strings.Join(parts, ", ") + ")"
+}
+
+// operArgsSignature renders operator operand types as "(left, right)", using NONE for a
+// missing operand.
+func operArgsSignature(args gen.IOper_argtypesContext, tokens antlr.TokenStream) string {
+	if args == nil {
+		return ""
+	}
+	types := args.AllTypename()
+	switch {
+	case len(types) == 2:
+		return "(" + normalizeSpace(ruleText(types[0], tokens)) + ", " + normalizeSpace(ruleText(types[1], tokens)) + ")"
+	case len(types) == 1 && args.NONE() != nil:
+		// NONE appears before the type for prefix operators and after it otherwise.
+		typ := normalizeSpace(ruleText(types[0], tokens))
+		if nonePrecedes(args) {
+			return "(NONE, " + typ + ")"
+		}
+		return "(" + typ + ", NONE)"
+	case len(types) == 1:
+		return "(" + normalizeSpace(ruleText(types[0], tokens)) + ")"
+	}
+	return ""
+}
+
+// nonePrecedes reports whether the NONE keyword precedes the operand type in oper_argtypes.
+func nonePrecedes(args gen.IOper_argtypesContext) bool {
+	none := args.NONE()
+	typ := args.Typename(0)
+	if none == nil || typ == nil {
+		return false
+	}
+	return none.GetSymbol().GetTokenIndex() < typ.GetStart().GetTokenIndex()
+}
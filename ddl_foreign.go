@@ -0,0 +1,140 @@
+// ddl_foreign.go implements DDL population logic for foreign data wrappers, servers,
+// foreign tables, IMPORT FOREIGN SCHEMA, and user mappings.
+package postgresparser
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateFdw handles CREATE FOREIGN DATA WRAPPER name [HANDLER ...] [OPTIONS (...)].
+func populateCreateFdw(result *ParsedQuery, ctx gen.ICreatefdwstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create foreign data wrapper statement: %w", ErrNilContext)
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLCreateForeignDataWrapper,
+		ObjectName: ruleText(ctx.Name(), tokens),
+		ObjectKind: DDLObjectForeignDataWrapper,
+		Options:    extractGenericOptions(ctx.Create_generic_options(), tokens),
+	})
+	return nil
+}
+
+// populateCreateServer handles CREATE SERVER [IF NOT EXISTS] name ... FOREIGN DATA WRAPPER fdw [OPTIONS (...)].
+func populateCreateServer(result *ParsedQuery, ctx gen.ICreateforeignserverstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create server statement: %w", ErrNilContext)
+	}
+
+	var flags []string
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLCreateServer,
+		ObjectName: ruleText(ctx.Name(0), tokens),
+		ObjectKind: DDLObjectServer,
+		Wrapper:    ruleText(ctx.Name(1), tokens),
+		Options:    extractGenericOptions(ctx.Create_generic_options(), tokens),
+		Flags:      flags,
+	})
+	return nil
+}
+
+// populateCreateForeignTable handles CREATE FOREIGN TABLE ... SERVER name [OPTIONS (...)],
+// including the PARTITION OF form.
+func populateCreateForeignTable(result *ParsedQuery, ctx gen.ICreateforeigntablestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create foreign table statement: %w", ErrNilContext)
+	}
+
+	tbl := tableRefFromQualifiedName(ctx.Qualified_name(0), tokens)
+	result.Tables = append(result.Tables, tbl)
+
+	var flags []string
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateForeignTable,
+		ObjectName: tbl.Name,
+		Schema:     tbl.Schema,
+		ObjectKind: DDLObjectForeignTable,
+		Server:     ruleText(ctx.Name(), tokens),
+		Options:    extractGenericOptions(ctx.Create_generic_options(), tokens),
+	}
+	if ctx.PARTITION() != nil {
+		flags = append(flags, "PARTITION_OF")
+		parent := tableRefFromQualifiedName(ctx.Qualified_name(1), tokens)
+		action.Table = parent.Raw
+		result.Tables = append(result.Tables, parent)
+	}
+	action.Flags = flags
+	appendTableElementColumns(result, &action, ctx.Opttableelementlist(), tokens)
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateImportForeignSchema handles IMPORT FOREIGN SCHEMA remote [LIMIT TO | EXCEPT (...)]
+// FROM SERVER name INTO local [OPTIONS (...)].
+func populateImportForeignSchema(result *ParsedQuery, ctx gen.IImportforeignschemastmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("import foreign schema statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:         DDLImportForeignSchema,
+		ObjectName:   ruleText(ctx.Name(2), tokens),
+		ObjectKind:   DDLObjectSchema,
+		RemoteSchema: ruleText(ctx.Name(0), tokens),
+		Server:       ruleText(ctx.Name(1), tokens),
+		Options:      extractGenericOptions(ctx.Create_generic_options(), tokens),
+	}
+	if qual := ctx.Import_qualification(); qual != nil {
+		if qt := qual.Import_qualification_type(); qt != nil {
+			if qt.EXCEPT() != nil {
+				action.Flags = append(action.Flags, "EXCEPT")
+			} else {
+				action.Flags = append(action.Flags, "LIMIT_TO")
+			}
+		}
+		if relList := qual.Relation_expr_list(); relList != nil {
+			for _, rel := range relList.AllRelation_expr() {
+				if name := ruleText(rel, tokens); name != "" {
+					action.Objects = append(action.Objects, name)
+				}
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateCreateUserMapping handles CREATE USER MAPPING [IF NOT EXISTS] FOR role SERVER name [OPTIONS (...)].
+// Password options are redacted.
+func populateCreateUserMapping(result *ParsedQuery, ctx gen.ICreateusermappingstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create user mapping statement: %w", ErrNilContext)
+	}
+
+	var flags []string
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLCreateUserMapping,
+		ObjectName: ruleText(ctx.Auth_ident(), tokens),
+		ObjectKind: DDLObjectUserMapping,
+		Server:     ruleText(ctx.Name(), tokens),
+		Options:    extractGenericOptions(ctx.Create_generic_options(), tokens),
+		Flags:      flags,
+	})
+	return nil
+}
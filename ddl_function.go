@@ -0,0 +1,152 @@
+// ddl_function.go implements DDL population logic for CREATE FUNCTION, CREATE PROCEDURE,
+// and CREATE TRIGGER.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateFunction handles CREATE [OR REPLACE] {FUNCTION | PROCEDURE} name (args)
+// [RETURNS type | RETURNS TABLE (...)] options. The argument types form Signature and
+// the return type DataType. LANGUAGE and the AS body are recorded as options; volatility,
+// STRICT, and SECURITY DEFINER become flags.
+func populateCreateFunction(result *ParsedQuery, ctx gen.ICreatefunctionstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create function statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateFunction,
+		ObjectKind: DDLObjectFunction,
+	}
+	if ctx.PROCEDURE() != nil {
+		action.ObjectKind = DDLObjectProcedure
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Func_name(), tokens))
+	if ctx.Or_replace_() != nil {
+		action.Flags = append(action.Flags, "OR_REPLACE")
+	}
+
+	var types []string
+	if args := ctx.Func_args_with_defaults(); args != nil && args.Func_args_with_defaults_list() != nil {
+		for _, arg := range args.Func_args_with_defaults_list().AllFunc_arg_with_default() {
+			if typ, ok := funcArgType(arg.Func_arg(), tokens); ok {
+				types = append(types, typ)
+			}
+		}
+	}
+	action.Signature = "(" + strings.Join(types, ", ") + ")"
+
+	switch {
+	case ctx.Func_return() != nil:
+		action.DataType = normalizeSpace(ruleText(ctx.Func_return(), tokens))
+	case ctx.TABLE() != nil:
+		action.DataType = "TABLE (" + normalizeSpace(ruleText(ctx.Table_func_column_list(), tokens)) + ")"
+	}
+
+	if opts := ctx.Createfunc_opt_list(); opts != nil {
+		for _, item := range opts.AllCreatefunc_opt_item() {
+			switch {
+			case item.LANGUAGE() != nil:
+				lang := ruleText(item.Nonreservedword_or_sconst(), tokens)
+				action.Options = append(action.Options, DDLOption{Name: "LANGUAGE", Value: strings.ToLower(unquoteStringLiteral(lang))})
+			case item.AS() != nil && item.Func_as() != nil:
+				// AS 'obj_file', 'link_symbol' keeps both parts, comma-separated.
+				var parts []string
+				for _, s := range item.Func_as().AllSconst() {
+					parts = append(parts, unquoteStringLiteral(ruleText(s, tokens)))
+				}
+				action.Options = append(action.Options, DDLOption{Name: "AS", Value: strings.Join(parts, ", ")})
+			case item.WINDOW() != nil:
+				action.Flags = append(action.Flags, "WINDOW")
+			case item.Common_func_opt_item() != nil:
+				if flag := functionFlag(item.Common_func_opt_item()); flag != "" {
+					action.Flags = append(action.Flags, flag)
+				}
+			}
+		}
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// functionFlag returns the flag recorded for a function option, or "" for options that
+// are not recorded (COST, ROWS, SET, and the like).
+func functionFlag(item gen.ICommon_func_opt_itemContext) string {
+	switch {
+	case item.IMMUTABLE() != nil:
+		return "IMMUTABLE"
+	case item.STABLE() != nil:
+		return "STABLE"
+	case item.VOLATILE() != nil:
+		return "VOLATILE"
+	case item.STRICT_P() != nil, item.RETURNS() != nil:
+		return "STRICT"
+	case item.SECURITY() != nil && item.DEFINER() != nil:
+		return "SECURITY_DEFINER"
+	case item.LEAKPROOF() != nil && item.NOT() == nil:
+		return "LEAKPROOF"
+	}
+	return ""
+}
+
+// populateCreateTrigger handles CREATE [CONSTRAINT] TRIGGER name {BEFORE | AFTER | INSTEAD OF}
+// events ON table ... EXECUTE {FUNCTION | PROCEDURE} func(args). The timing, events, and
+// FOR EACH ROW become flags, UPDATE OF columns become Columns, and the trigger function is
+// recorded as the EXECUTE FUNCTION option. The table is added to Tables.
+func populateCreateTrigger(result *ParsedQuery, ctx gen.ICreatetrigstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create trigger statement: %w", ErrNilContext)
+	}
+
+	tbl := tableRefFromQualifiedName(ctx.Qualified_name(), tokens)
+	action := DDLAction{
+		Type:       DDLCreateTrigger,
+		ObjectName: ruleText(ctx.Name(), tokens),
+		Schema:     tbl.Schema,
+		Table:      tbl.Name,
+		ObjectKind: DDLObjectTrigger,
+	}
+	if ctx.CONSTRAINT() != nil {
+		action.Flags = append(action.Flags, "CONSTRAINT")
+	}
+	switch timing := ctx.Triggeractiontime(); {
+	case timing == nil:
+		action.Flags = append(action.Flags, "AFTER")
+	case timing.BEFORE() != nil:
+		action.Flags = append(action.Flags, "BEFORE")
+	case timing.AFTER() != nil:
+		action.Flags = append(action.Flags, "AFTER")
+	default:
+		action.Flags = append(action.Flags, "INSTEAD_OF")
+	}
+	if events := ctx.Triggerevents(); events != nil {
+		for _, ev := range events.AllTriggeroneevent() {
+			switch {
+			case ev.INSERT() != nil:
+				action.Flags = append(action.Flags, "INSERT")
+			case ev.DELETE_P() != nil:
+				action.Flags = append(action.Flags, "DELETE")
+			case ev.UPDATE() != nil:
+				action.Flags = append(action.Flags, "UPDATE")
+				action.Columns = append(action.Columns, columnlistNames(ev.Columnlist(), tokens)...)
+			case ev.TRUNCATE() != nil:
+				action.Flags = append(action.Flags, "TRUNCATE")
+			}
+		}
+	}
+	if spec := ctx.Triggerforspec(); ctx.ROW() != nil || spec != nil && spec.Triggerfortype() != nil && spec.Triggerfortype().ROW() != nil {
+		action.Flags = append(action.Flags, "FOR_EACH_ROW")
+	}
+	if fn := ruleText(ctx.Func_name(), tokens); fn != "" {
+		action.Options = append(action.Options, DDLOption{Name: "EXECUTE FUNCTION", Value: fn})
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	result.Tables = append(result.Tables, tbl)
+	return nil
+}
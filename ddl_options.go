@@ -0,0 +1,256 @@
+// ddl_options.go extracts OPTIONS (...) and WITH (...) lists and redacts secrets in them
+// and in the statement text.
+package postgresparser
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// extractGenericOptions converts an OPTIONS (name 'value', ...) clause into DDLOptions.
+func extractGenericOptions(opts gen.ICreate_generic_optionsContext, tokens antlr.TokenStream) []DDLOption {
+	if opts == nil || opts.Generic_option_list() == nil {
+		return nil
+	}
+	var out []DDLOption
+	for _, elem := range opts.Generic_option_list().AllGeneric_option_elem() {
+		if elem == nil {
+			continue
+		}
+		name := ruleText(elem.Generic_option_name(), tokens)
+		value := unquoteStringLiteral(ruleText(elem.Generic_option_arg(), tokens))
+		out = append(out, newDDLOption(name, value))
+	}
+	return out
+}
+
+// extractDefinitionOptions converts a WITH (name [= value], ...) definition into DDLOptions.
+func extractDefinitionOptions(def gen.IDefinition_Context, tokens antlr.TokenStream) []DDLOption {
+	if def == nil || def.Definition() == nil || def.Definition().Def_list() == nil {
+		return nil
+	}
+	var out []DDLOption
+	for _, elem := range def.Definition().Def_list().AllDef_elem() {
+		if elem == nil {
+			continue
+		}
+		name := ruleText(elem.ColLabel(), tokens)
+		value := unquoteStringLiteral(ruleText(elem.Def_arg(), tokens))
+		out = append(out, newDDLOption(name, value))
+	}
+	return out
+}
+
+// newDDLOption builds a DDLOption, redacting the value of password-like options.
+func newDDLOption(name, value string) DDLOption {
+	if isSecretOptionName(name) {
+		value = RedactedValue
+	}
+	return DDLOption{Name: name, Value: value}
+}
+
+// isSecretOptionName reports whether an option or conninfo key carries a password.
+// Flags such as password_required are not secrets and are left alone.
+func isSecretOptionName(name string) bool {
+	return strings.HasSuffix(strings.ToLower(trimIdentQuotes(name)), "password")
+}
+
+// secretCollector walks a statement and records the string constants that hold
+// passwords: password-like OPTIONS values and subscription connection strings.
+type secretCollector struct {
+	*gen.BasePostgreSQLParserListener
+	tokens       antlr.TokenStream
+	replacements []secretReplacement
+}
+
+// secretReplacement replaces the characters start through stop of the statement.
+type secretReplacement struct {
+	start, stop int
+	text        string
+}
+
+func (c *secretCollector) EnterGeneric_option_elem(ctx *gen.Generic_option_elemContext) {
+	arg := ctx.Generic_option_arg()
+	if arg == nil || !isSecretOptionName(ruleText(ctx.Generic_option_name(), c.tokens)) {
+		return
+	}
+	c.add(arg, RedactedValue)
+}
+
+func (c *secretCollector) EnterCreatesubscriptionstmt(ctx *gen.CreatesubscriptionstmtContext) {
+	c.addConninfo(ctx.Sconst())
+}
+
+func (c *secretCollector) EnterAltersubscriptionstmt(ctx *gen.AltersubscriptionstmtContext) {
+	if ctx.CONNECTION() != nil {
+		c.addConninfo(ctx.Sconst())
+	}
+}
+
+// addConninfo records the redacted form of a connection string constant when it
+// carries a password.
+func (c *secretCollector) addConninfo(ctx gen.ISconstContext) {
+	if ctx == nil {
+		return
+	}
+	conninfo := unquoteStringLiteral(ruleText(ctx, c.tokens))
+	if redacted := redactConninfo(conninfo); redacted != conninfo {
+		c.add(ctx, redacted)
+	}
+}
+
+// add records that the string constant ctx is to be replaced by value, quoted.
+func (c *secretCollector) add(ctx antlr.ParserRuleContext, value string) {
+	c.replacements = append(c.replacements, secretReplacement{
+		start: ctx.GetStart().GetStart(),
+		stop:  ctx.GetStop().GetStop(),
+		text:  "'" + strings.ReplaceAll(value, "'", "''") + "'",
+	})
+}
+
+// redactStatementSecrets returns sql, the text stmt was parsed from, with the string
+// constants holding passwords replaced by their redacted values, so that RawSQL does not
+// leak what the structured fields hide.
+func redactStatementSecrets(sql string, stmt antlr.ParseTree, tokens antlr.TokenStream) string {
+	collector := &secretCollector{BasePostgreSQLParserListener: &gen.BasePostgreSQLParserListener{}, tokens: tokens}
+	antlr.ParseTreeWalkerDefault.Walk(collector, stmt)
+	if len(collector.replacements) == 0 {
+		return sql
+	}
+
+	runes := []rune(sql)
+	var b strings.Builder
+	pos := 0
+	for _, r := range collector.replacements {
+		if r.start < pos || r.stop >= len(runes) {
+			continue
+		}
+		b.WriteString(string(runes[pos:r.start]))
+		b.WriteString(r.text)
+		pos = r.stop + 1
+	}
+	b.WriteString(string(runes[pos:]))
+	return b.String()
+}
+
+// redactConninfo masks password values in a libpq connection string, in either
+// key=value form ("host=db password=secret") or URI form (postgresql://user:secret@db/app).
+func redactConninfo(conninfo string) string {
+	trimmed := strings.TrimSpace(conninfo)
+	if strings.HasPrefix(trimmed, "postgres://") || strings.HasPrefix(trimmed, "postgresql://") {
+		return redactConninfoURI(trimmed)
+	}
+
+	var b strings.Builder
+	i := 0
+	for i < len(conninfo) {
+		// Copy whitespace between pairs.
+		if conninfo[i] == ' ' || conninfo[i] == '\t' || conninfo[i] == '\n' {
+			b.WriteByte(conninfo[i])
+			i++
+			continue
+		}
+		eq := strings.IndexByte(conninfo[i:], '=')
+		if eq < 0 {
+			b.WriteString(conninfo[i:])
+			break
+		}
+		key := strings.TrimSpace(conninfo[i : i+eq])
+		b.WriteString(conninfo[i : i+eq+1])
+		i += eq + 1
+		for i < len(conninfo) && conninfo[i] == ' ' {
+			b.WriteByte(' ')
+			i++
+		}
+		start := i
+		if i < len(conninfo) && conninfo[i] == '\'' {
+			i++
+			for i < len(conninfo) && conninfo[i] != '\'' {
+				if conninfo[i] == '\\' {
+					i++
+				}
+				i++
+			}
+			if i < len(conninfo) {
+				i++
+			}
+		} else {
+			for i < len(conninfo) && conninfo[i] != ' ' && conninfo[i] != '\t' && conninfo[i] != '\n' {
+				i++
+			}
+		}
+		if i > len(conninfo) {
+			i = len(conninfo)
+		}
+		if isSecretOptionName(key) {
+			b.WriteString(RedactedValue)
+		} else {
+			b.WriteString(conninfo[start:i])
+		}
+	}
+	return b.String()
+}
+
+// redactConninfoURI masks the password in the userinfo and query of a connection URI.
+func redactConninfoURI(conninfo string) string {
+	u, err := url.Parse(conninfo)
+	if err != nil {
+		return redactMalformedConninfoURI(conninfo)
+	}
+	q, err := url.ParseQuery(u.RawQuery)
+	if err != nil {
+		return redactMalformedConninfoURI(conninfo)
+	}
+	if u.User != nil {
+		if _, ok := u.User.Password(); ok {
+			u.User = url.UserPassword(u.User.Username(), RedactedValue)
+		}
+	}
+	if u.RawQuery != "" {
+		for key := range q {
+			if isSecretOptionName(key) {
+				q.Set(key, RedactedValue)
+			}
+		}
+		u.RawQuery = q.Encode()
+	}
+	out := u.String()
+	// url.URL escapes the placeholder; keep it readable.
+	return strings.ReplaceAll(out, url.QueryEscape(RedactedValue), RedactedValue)
+}
+
+// redactMalformedConninfoURI masks the password in the userinfo and query of a connection
+// URI that net/url rejects, e.g. because of a bad percent escape in the password.
+func redactMalformedConninfoURI(conninfo string) string {
+	start := strings.Index(conninfo, "://") + len("://")
+	rest := conninfo[start:]
+	authEnd := strings.IndexAny(rest, "/?#")
+	if authEnd < 0 {
+		authEnd = len(rest)
+	}
+	auth, tail := rest[:authEnd], rest[authEnd:]
+	if at := strings.LastIndexByte(auth, '@'); at >= 0 {
+		if colon := strings.IndexByte(auth[:at], ':'); colon >= 0 {
+			auth = auth[:colon+1] + RedactedValue + auth[at:]
+		}
+	}
+	if q := strings.IndexByte(tail, '?'); q >= 0 {
+		query, fragment := tail[q+1:], ""
+		if hash := strings.IndexByte(query, '#'); hash >= 0 {
+			query, fragment = query[:hash], query[hash:]
+		}
+		params := strings.Split(query, "&")
+		for i, param := range params {
+			key, _, _ := strings.Cut(param, "=")
+			if name, err := url.QueryUnescape(key); err != nil || isSecretOptionName(name) {
+				params[i] = key + "=" + RedactedValue
+			}
+		}
+		tail = tail[:q+1] + strings.Join(params, "&") + fragment
+	}
+	return conninfo[:start] + auth + tail
+}
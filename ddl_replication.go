@@ -0,0 +1,310 @@
+// ddl_replication.go implements DDL population logic for CREATE PUBLICATION and CREATE SUBSCRIPTION.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreatePublication handles CREATE PUBLICATION name [FOR ...] [WITH (...)].
+// The statement is read from its tokens so that PostgreSQL 15 column lists, row
+// filters, and TABLES IN SCHEMA entries are captured alongside the older forms.
+func populateCreatePublication(result *ParsedQuery, ctx gen.ICreatepublicationstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create publication statement: %w", ErrNilContext)
+	}
+	start, stop := ctx.GetStart(), ctx.GetStop()
+	if start == nil || stop == nil {
+		return nil
+	}
+	r := &publicationReader{
+		toks:   defaultChannelTokens(tokens, start.GetTokenIndex(), stop.GetTokenIndex()),
+		tokens: tokens,
+	}
+	r.populate(result)
+	return nil
+}
+
+// parsePublicationFallback parses a CREATE PUBLICATION the grammar rejected (PostgreSQL 15
+// column lists, row filters, TABLES IN SCHEMA). It reports false unless the whole first
+// statement is understood, in which case the caller keeps the original parse errors.
+func parsePublicationFallback(sql string, stream *antlr.CommonTokenStream) (*ParsedQuery, bool) {
+	stream.Fill()
+	toks := defaultChannelTokens(stream, 0, stream.Size()-1)
+	for i, tok := range toks {
+		if tok.GetTokenType() == gen.PostgreSQLLexerSEMI {
+			toks = toks[:i]
+			break
+		}
+	}
+	r := &publicationReader{toks: toks, tokens: stream}
+	if !r.acceptWords("CREATE", "PUBLICATION") {
+		return nil, false
+	}
+	r.pos = 0
+
+	res := &ParsedQuery{
+		Command:        QueryCommandDDL,
+		RawSQL:         strings.TrimSpace(sql),
+		DerivedColumns: make(map[string]string),
+	}
+	r.populate(res)
+	if r.pos != len(r.toks) {
+		return nil, false
+	}
+	res.Parameters = extractParameters(sql)
+	return res, true
+}
+
+// publicationReader walks the default-channel tokens of a CREATE PUBLICATION statement.
+type publicationReader struct {
+	toks   []antlr.Token
+	pos    int
+	tokens antlr.TokenStream
+}
+
+// populate appends the CREATE_PUBLICATION action and published tables to result.
+func (r *publicationReader) populate(result *ParsedQuery) {
+	if !r.acceptWords("CREATE", "PUBLICATION") {
+		return
+	}
+	action := DDLAction{
+		Type:       DDLCreatePublication,
+		ObjectName: r.name(),
+		ObjectKind: DDLObjectPublication,
+	}
+
+	if r.acceptWords("FOR") {
+		if r.acceptWords("ALL", "TABLES") {
+			action.Flags = append(action.Flags, "ALL_TABLES")
+		} else {
+			r.readPublicationObjects(result, &action)
+		}
+	}
+	if r.acceptWords("WITH") {
+		for _, item := range r.parenItems() {
+			action.Options = append(action.Options, r.definitionOption(item))
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+}
+
+// readPublicationObjects consumes a comma-separated list of TABLE and TABLES IN SCHEMA entries.
+// Entries without a leading keyword inherit the kind of the previous entry.
+func (r *publicationReader) readPublicationObjects(result *ParsedQuery, action *DDLAction) {
+	inSchema := false
+	for {
+		switch {
+		case r.acceptWords("TABLES", "IN", "SCHEMA"):
+			inSchema = true
+		case r.acceptWords("TABLE"):
+			inSchema = false
+		}
+
+		if inSchema {
+			if name := r.name(); name != "" {
+				if len(action.Objects) == 0 {
+					action.Flags = append(action.Flags, "TABLES_IN_SCHEMA")
+				}
+				action.Objects = append(action.Objects, name)
+			}
+		} else {
+			r.acceptWords("ONLY")
+			raw := r.name()
+			r.acceptType(gen.PostgreSQLLexerSTAR)
+			schema, name := splitQualifiedName(raw)
+			pt := PublicationTable{Schema: schema, Name: name}
+			if r.peekType(gen.PostgreSQLLexerOPEN_PAREN) {
+				for _, item := range r.parenItems() {
+					pt.Columns = append(pt.Columns, r.text(item))
+				}
+			}
+			if r.acceptWords("WHERE") {
+				if items := r.parenSpan(); len(items) > 0 {
+					pt.RowFilter = r.text(items)
+				}
+			}
+			if raw != "" {
+				action.PublishedTables = append(action.PublishedTables, pt)
+				result.Tables = append(result.Tables, TableRef{
+					Schema: schema,
+					Name:   name,
+					Type:   TableTypeBase,
+					Raw:    raw,
+				})
+			}
+		}
+
+		if !r.acceptType(gen.PostgreSQLLexerCOMMA) {
+			return
+		}
+	}
+}
+
+// definitionOption converts "name [= value]" tokens into a DDLOption.
+func (r *publicationReader) definitionOption(item []antlr.Token) DDLOption {
+	if len(item) == 0 {
+		return DDLOption{}
+	}
+	name := item[0].GetText()
+	value := ""
+	if len(item) > 2 && item[1].GetTokenType() == gen.PostgreSQLLexerEQUAL {
+		value = unquoteStringLiteral(r.text(item[2:]))
+	}
+	return newDDLOption(name, value)
+}
+
+// name consumes a possibly qualified identifier and returns its text.
+func (r *publicationReader) name() string {
+	if r.pos >= len(r.toks) || isPunctuationToken(r.toks[r.pos]) {
+		return ""
+	}
+	start := r.pos
+	r.pos++
+	for r.pos+1 < len(r.toks) && r.toks[r.pos].GetTokenType() == gen.PostgreSQLLexerDOT && !isPunctuationToken(r.toks[r.pos+1]) {
+		r.pos += 2
+	}
+	return r.text(r.toks[start:r.pos])
+}
+
+// parenSpan consumes a parenthesised group and returns the tokens inside it.
+func (r *publicationReader) parenSpan() []antlr.Token {
+	if !r.peekType(gen.PostgreSQLLexerOPEN_PAREN) {
+		return nil
+	}
+	depth := 0
+	for i := r.pos; i < len(r.toks); i++ {
+		switch r.toks[i].GetTokenType() {
+		case gen.PostgreSQLLexerOPEN_PAREN:
+			depth++
+		case gen.PostgreSQLLexerCLOSE_PAREN:
+			depth--
+			if depth == 0 {
+				inner := r.toks[r.pos+1 : i]
+				r.pos = i + 1
+				return inner
+			}
+		}
+	}
+	// Unbalanced parentheses: leave the reader where it is so the caller sees unconsumed input.
+	return nil
+}
+
+// parenItems consumes a parenthesised group and splits it on top-level commas.
+func (r *publicationReader) parenItems() [][]antlr.Token {
+	inner := r.parenSpan()
+	if len(inner) == 0 {
+		return nil
+	}
+	var items [][]antlr.Token
+	depth, start := 0, 0
+	for i, tok := range inner {
+		switch tok.GetTokenType() {
+		case gen.PostgreSQLLexerOPEN_PAREN:
+			depth++
+		case gen.PostgreSQLLexerCLOSE_PAREN:
+			depth--
+		case gen.PostgreSQLLexerCOMMA:
+			if depth == 0 {
+				items = append(items, inner[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(items, inner[start:])
+}
+
+// acceptWords consumes the given keyword sequence (case-insensitive) if it is next.
+func (r *publicationReader) acceptWords(words ...string) bool {
+	if r.pos+len(words) > len(r.toks) {
+		return false
+	}
+	for i, w := range words {
+		if !strings.EqualFold(r.toks[r.pos+i].GetText(), w) {
+			return false
+		}
+	}
+	r.pos += len(words)
+	return true
+}
+
+// acceptType consumes the next token if it has the given type.
+func (r *publicationReader) acceptType(tokenType int) bool {
+	if !r.peekType(tokenType) {
+		return false
+	}
+	r.pos++
+	return true
+}
+
+// peekType reports whether the next token has the given type.
+func (r *publicationReader) peekType(tokenType int) bool {
+	return r.pos < len(r.toks) && r.toks[r.pos].GetTokenType() == tokenType
+}
+
+// text returns the original input text spanning toks, including hidden whitespace.
+func (r *publicationReader) text(toks []antlr.Token) string {
+	if len(toks) == 0 {
+		return ""
+	}
+	return strings.TrimSpace(r.tokens.GetTextFromInterval(antlr.Interval{
+		Start: toks[0].GetTokenIndex(),
+		Stop:  toks[len(toks)-1].GetTokenIndex(),
+	}))
+}
+
+// isPunctuationToken reports whether tok is a separator that cannot start an identifier.
+func isPunctuationToken(tok antlr.Token) bool {
+	switch tok.GetTokenType() {
+	case gen.PostgreSQLLexerOPEN_PAREN, gen.PostgreSQLLexerCLOSE_PAREN, gen.PostgreSQLLexerCOMMA,
+		gen.PostgreSQLLexerDOT, gen.PostgreSQLLexerSEMI, gen.PostgreSQLLexerEQUAL, gen.PostgreSQLLexerSTAR:
+		return true
+	}
+	return false
+}
+
+// defaultChannelTokens returns the default-channel tokens between start and stop (inclusive).
+func defaultChannelTokens(tokens antlr.TokenStream, start, stop int) []antlr.Token {
+	var out []antlr.Token
+	for i := start; i <= stop && i < tokens.Size(); i++ {
+		tok := tokens.Get(i)
+		if tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		if tok.GetChannel() == antlr.TokenDefaultChannel {
+			out = append(out, tok)
+		}
+	}
+	return out
+}
+
+// populateCreateSubscription handles CREATE SUBSCRIPTION name CONNECTION 'conninfo'
+// PUBLICATION pub [, ...] [WITH (...)]. Passwords in the connection string are redacted.
+func populateCreateSubscription(result *ParsedQuery, ctx gen.ICreatesubscriptionstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create subscription statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateSubscription,
+		ObjectName: ruleText(ctx.Name(), tokens),
+		ObjectKind: DDLObjectSubscription,
+		Connection: redactConninfo(unquoteStringLiteral(ruleText(ctx.Sconst(), tokens))),
+		Options:    extractDefinitionOptions(ctx.Definition_(), tokens),
+	}
+	if pubs := ctx.Publication_name_list(); pubs != nil {
+		for _, item := range pubs.AllPublication_name_item() {
+			if name := ruleText(item, tokens); name != "" {
+				action.Objects = append(action.Objects, name)
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
@@ -0,0 +1,133 @@
+// ddl_schema.go implements DDL population logic for CREATE SCHEMA and CREATE EXTENSION.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateSchema handles CREATE SCHEMA [IF NOT EXISTS] name [AUTHORIZATION role] [elements].
+func populateCreateSchema(result *ParsedQuery, ctx gen.ICreateschemastmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create schema statement: %w", ErrNilContext)
+	}
+
+	schemaName := ""
+	if name := ctx.Optschemaname(); name != nil {
+		if prc, ok := name.(antlr.ParserRuleContext); ok {
+			schemaName = strings.TrimSpace(ctxText(tokens, prc))
+		}
+	}
+	if schemaName == "" && ctx.Colid() != nil {
+		if prc, ok := ctx.Colid().(antlr.ParserRuleContext); ok {
+			schemaName = strings.TrimSpace(ctxText(tokens, prc))
+		}
+	}
+
+	owner := ""
+	if role := ctx.Rolespec(); role != nil {
+		if prc, ok := role.(antlr.ParserRuleContext); ok {
+			owner = strings.TrimSpace(ctxText(tokens, prc))
+		}
+	}
+	// CREATE SCHEMA AUTHORIZATION role names the schema after the role.
+	if schemaName == "" {
+		schemaName = owner
+	}
+
+	var flags []string
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLCreateSchema,
+		ObjectName: schemaName,
+		ObjectKind: DDLObjectSchema,
+		Owner:      owner,
+		Flags:      flags,
+	})
+
+	elems := ctx.Optschemaeltlist()
+	if elems == nil {
+		return nil
+	}
+	for _, elem := range elems.AllSchema_stmt() {
+		if elem == nil {
+			continue
+		}
+		start := len(result.DDLActions)
+		tableStart := len(result.Tables)
+		switch {
+		case elem.Createstmt() != nil:
+			if err := populateCreateTable(result, elem.Createstmt(), tokens); err != nil {
+				return err
+			}
+		case elem.Indexstmt() != nil:
+			if err := populateCreateIndex(result, elem.Indexstmt(), tokens); err != nil {
+				return err
+			}
+		}
+		// Unqualified objects declared inside CREATE SCHEMA belong to that schema.
+		for i := start; i < len(result.DDLActions); i++ {
+			if result.DDLActions[i].Schema == "" {
+				result.DDLActions[i].Schema = schemaName
+			}
+		}
+		for i := tableStart; i < len(result.Tables); i++ {
+			if result.Tables[i].Schema == "" {
+				result.Tables[i].Schema = schemaName
+			}
+		}
+	}
+	return nil
+}
+
+// populateCreateExtension handles CREATE EXTENSION [IF NOT EXISTS] name [WITH] [SCHEMA s] [VERSION v] [CASCADE].
+func populateCreateExtension(result *ParsedQuery, ctx gen.ICreateextensionstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create extension statement: %w", ErrNilContext)
+	}
+
+	extName := ""
+	if name := ctx.Name(); name != nil {
+		if prc, ok := name.(antlr.ParserRuleContext); ok {
+			extName = strings.TrimSpace(ctxText(tokens, prc))
+		}
+	}
+
+	var flags []string
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		flags = append(flags, "IF_NOT_EXISTS")
+	}
+
+	schema := ""
+	if opts := ctx.Create_extension_opt_list(); opts != nil {
+		for _, item := range opts.AllCreate_extension_opt_item() {
+			if item == nil {
+				continue
+			}
+			switch {
+			case item.SCHEMA() != nil && item.Name() != nil:
+				if prc, ok := item.Name().(antlr.ParserRuleContext); ok {
+					schema = strings.TrimSpace(ctxText(tokens, prc))
+				}
+			case item.CASCADE() != nil:
+				flags = append(flags, "CASCADE")
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLCreateExtension,
+		ObjectName: extName,
+		Schema:     schema,
+		ObjectKind: DDLObjectExtension,
+		Flags:      flags,
+	})
+	return nil
+}
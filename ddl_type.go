@@ -0,0 +1,163 @@
+// ddl_type.go implements DDL population logic for CREATE TYPE, CREATE DOMAIN, and
+// ALTER TYPE ... ADD VALUE / RENAME VALUE.
+package postgresparser
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateType handles CREATE TYPE name [AS ENUM (...) | AS (...) | AS RANGE (...) | (...)].
+// Other CREATE forms sharing the definestmt rule (aggregates, operators, collations, text
+// search objects) are ignored.
+func populateCreateType(result *ParsedQuery, ctx gen.IDefinestmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create type statement: %w", ErrNilContext)
+	}
+	if ctx.TYPE_P() == nil {
+		return nil
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateType,
+		ObjectKind: DDLObjectType,
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Any_name(0), tokens))
+
+	switch {
+	case ctx.ENUM_P() != nil:
+		action.Flags = append(action.Flags, "ENUM")
+		if vals := ctx.Enum_val_list_(); vals != nil && vals.Enum_val_list() != nil {
+			for _, sc := range vals.Enum_val_list().AllSconst() {
+				action.EnumValues = append(action.EnumValues, unquoteStringLiteral(ruleText(sc, tokens)))
+			}
+		}
+	case ctx.RANGE() != nil:
+		action.Flags = append(action.Flags, "RANGE")
+		action.Options = extractDefinitionList(ctx.Definition(), tokens)
+	case ctx.AS() != nil:
+		action.Flags = append(action.Flags, "COMPOSITE")
+		if elems := ctx.Opttablefuncelementlist(); elems != nil && elems.Tablefuncelementlist() != nil {
+			for _, elem := range elems.Tablefuncelementlist().AllTablefuncelement() {
+				col := DDLColumn{
+					Name:     ruleText(elem.Colid(), tokens),
+					Type:     normalizeSpace(ruleText(elem.Typename(), tokens)),
+					Nullable: true,
+				}
+				action.Columns = append(action.Columns, col.Name)
+				action.ColumnDetails = append(action.ColumnDetails, col)
+			}
+		}
+	case ctx.Definition() != nil:
+		action.Flags = append(action.Flags, "BASE")
+		action.Options = extractDefinitionList(ctx.Definition(), tokens)
+	default:
+		action.Flags = append(action.Flags, "SHELL")
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// extractDefinitionList converts a (name = value, ...) definition into DDLOptions.
+func extractDefinitionList(def gen.IDefinitionContext, tokens antlr.TokenStream) []DDLOption {
+	if def == nil || def.Def_list() == nil {
+		return nil
+	}
+	var out []DDLOption
+	for _, elem := range def.Def_list().AllDef_elem() {
+		name := ruleText(elem.ColLabel(), tokens)
+		value := unquoteStringLiteral(ruleText(elem.Def_arg(), tokens))
+		out = append(out, newDDLOption(name, value))
+	}
+	return out
+}
+
+// populateCreateDomain handles CREATE DOMAIN name [AS] type [DEFAULT ...] [NOT NULL] [CHECK (...)].
+func populateCreateDomain(result *ParsedQuery, ctx gen.ICreatedomainstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create domain statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateDomain,
+		ObjectKind: DDLObjectDomain,
+		DataType:   normalizeSpace(ruleText(ctx.Typename(), tokens)),
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Any_name(), tokens))
+
+	if quals := ctx.Colquallist(); quals != nil {
+		for _, cc := range quals.AllColconstraint() {
+			elem := cc.Colconstraintelem()
+			if elem == nil {
+				continue
+			}
+			switch {
+			case elem.NOT() != nil && elem.NULL_P() != nil:
+				action.Flags = append(action.Flags, "NOT_NULL")
+			case elem.DEFAULT() != nil:
+				action.ColumnDetails = []DDLColumn{{Default: ruleText(elem.B_expr(), tokens), Type: action.DataType}}
+			case elem.CHECK() != nil:
+				action.Constraints = append(action.Constraints, DDLConstraint{
+					Name:       ruleText(cc.Name(), tokens),
+					Type:       DDLConstraintCheck,
+					Expression: ruleText(elem.A_expr(), tokens),
+				})
+			}
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// populateAlterEnum handles ALTER TYPE name ADD VALUE [IF NOT EXISTS] 'label' [BEFORE | AFTER 'label']
+// and ALTER TYPE name RENAME VALUE 'old' TO 'new'.
+func populateAlterEnum(result *ParsedQuery, ctx gen.IAlterenumstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("alter type statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLAlter,
+		ObjectKind: DDLObjectType,
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Any_name(), tokens))
+
+	var labels []string
+	for _, sc := range ctx.AllSconst() {
+		labels = append(labels, unquoteStringLiteral(ruleText(sc, tokens)))
+	}
+	if len(labels) == 0 {
+		return nil
+	}
+
+	if ctx.RENAME() != nil {
+		action.Flags = append(action.Flags, "RENAME_VALUE")
+		action.EnumValues = labels[:1]
+		if len(labels) > 1 {
+			action.NewName = labels[1]
+		}
+	} else {
+		action.Flags = append(action.Flags, "ADD_VALUE")
+		if ctx.If_not_exists_() != nil {
+			action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+		}
+		action.EnumValues = labels[:1]
+		if len(labels) > 1 {
+			// The neighbouring label goes in Objects, positioned by the BEFORE / AFTER flag.
+			if ctx.BEFORE() != nil {
+				action.Flags = append(action.Flags, "BEFORE")
+			} else {
+				action.Flags = append(action.Flags, "AFTER")
+			}
+			action.Objects = labels[1:2]
+		}
+	}
+
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
@@ -0,0 +1,139 @@
+// ddl_view.go implements DDL population logic for CREATE VIEW, CREATE MATERIALIZED VIEW,
+// and CREATE SEQUENCE.
+package postgresparser
+
+import (
+	"fmt"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// populateCreateView handles CREATE [OR REPLACE] [TEMP] [RECURSIVE] VIEW name [(columns)]
+// [WITH (options)] AS query [WITH CHECK OPTION]. The defining query becomes Source.
+func populateCreateView(result *ParsedQuery, ctx gen.IViewstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create view statement: %w", ErrNilContext)
+	}
+
+	tbl := tableRefFromQualifiedName(ctx.Qualified_name(), tokens)
+	action := DDLAction{
+		Type:       DDLCreateView,
+		ObjectName: tbl.Name,
+		Schema:     tbl.Schema,
+		ObjectKind: DDLObjectView,
+		Options:    withReloptions(ctx.Reloptions_(), tokens),
+	}
+	if ctx.OR() != nil && ctx.REPLACE() != nil {
+		action.Flags = append(action.Flags, "OR_REPLACE")
+	}
+	if ctx.Opttemp() != nil {
+		action.Flags = append(action.Flags, "TEMPORARY")
+	}
+	if ctx.RECURSIVE() != nil {
+		action.Flags = append(action.Flags, "RECURSIVE")
+		action.Columns = columnlistNames(ctx.Columnlist(), tokens)
+	} else {
+		action.Columns = columnListNames(ctx.Column_list_(), tokens)
+	}
+	if ctx.Check_option_() != nil {
+		action.Flags = append(action.Flags, "CHECK_OPTION")
+	}
+	result.DDLActions = append(result.DDLActions, action)
+
+	return populateViewSource(result, ctx.Selectstmt(), tokens)
+}
+
+// populateCreateMaterializedView handles CREATE [UNLOGGED] MATERIALIZED VIEW [IF NOT EXISTS]
+// name [(columns)] AS query [WITH [NO] DATA]. The view is the Target and the defining
+// query its Source.
+func populateCreateMaterializedView(result *ParsedQuery, ctx gen.ICreatematviewstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create materialized view statement: %w", ErrNilContext)
+	}
+	target := ctx.Create_mv_target()
+	if target == nil {
+		return nil
+	}
+
+	tbl := tableRefFromQualifiedName(target.Qualified_name(), tokens)
+	result.Target = &tbl
+	result.Tables = append(result.Tables, tbl)
+	action := DDLAction{
+		Type:       DDLCreateView,
+		ObjectName: tbl.Name,
+		Schema:     tbl.Schema,
+		ObjectKind: DDLObjectMaterializedView,
+		Columns:    columnListNames(target.Column_list_(), tokens),
+		Options:    withReloptions(target.Reloptions_(), tokens),
+	}
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+	}
+	if ctx.Optnolog() != nil {
+		action.Flags = append(action.Flags, "UNLOGGED")
+	}
+	if wd := ctx.With_data_(); wd != nil && wd.NO() != nil {
+		action.Flags = append(action.Flags, "WITH_NO_DATA")
+	}
+	result.DDLActions = append(result.DDLActions, action)
+
+	return populateViewSource(result, ctx.Selectstmt(), tokens)
+}
+
+// populateViewSource records the defining query of a view as Source and adds the
+// relations it reads to Tables.
+func populateViewSource(result *ParsedQuery, sel gen.ISelectstmtContext, tokens antlr.TokenStream) error {
+	if sel == nil {
+		return nil
+	}
+	source, err := buildSelectQuery(sel, tokens)
+	if err != nil {
+		return err
+	}
+	result.Source = source
+	appendSetOpTables(result, nil, source.Tables)
+	return nil
+}
+
+// populateCreateSequence handles CREATE [TEMP | UNLOGGED] SEQUENCE [IF NOT EXISTS] name [options].
+// Options are named by their keywords, as for ALTER SEQUENCE.
+func populateCreateSequence(result *ParsedQuery, ctx gen.ICreateseqstmtContext, tokens antlr.TokenStream) error {
+	if ctx == nil {
+		return fmt.Errorf("create sequence statement: %w", ErrNilContext)
+	}
+
+	action := DDLAction{
+		Type:       DDLCreateSequence,
+		ObjectKind: DDLObjectSequence,
+	}
+	action.Schema, action.ObjectName = splitQualifiedName(ruleText(ctx.Qualified_name(), tokens))
+	if ctx.IF_P() != nil && ctx.NOT() != nil && ctx.EXISTS() != nil {
+		action.Flags = append(action.Flags, "IF_NOT_EXISTS")
+	}
+	if temp := ctx.Opttemp(); temp != nil {
+		if temp.UNLOGGED() != nil {
+			action.Flags = append(action.Flags, "UNLOGGED")
+		} else {
+			action.Flags = append(action.Flags, "TEMPORARY")
+		}
+	}
+	if opts := ctx.Optseqoptlist(); opts != nil && opts.Seqoptlist() != nil {
+		for _, elem := range opts.Seqoptlist().AllSeqoptelem() {
+			if prc, ok := elem.(antlr.ParserRuleContext); ok {
+				action.Options = append(action.Options, sequenceOption(prc, tokens))
+			}
+		}
+	}
+	result.DDLActions = append(result.DDLActions, action)
+	return nil
+}
+
+// withReloptions returns the storage parameters of an optional WITH (...) clause.
+func withReloptions(opts gen.IReloptions_Context, tokens antlr.TokenStream) []DDLOption {
+	if opts == nil {
+		return nil
+	}
+	return extractReloptions(opts.Reloptions(), tokens)
+}
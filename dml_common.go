@@ -89,6 +89,7 @@ func recordSetTargetUsage(result *ParsedQuery, list gen.ISet_clause_listContext,
 			Expression: expression,
 			UsageType:  role,
 			Context:    strings.TrimSpace(ctxText(tokens, setCtx)),
+			Position:   target.GetStart().GetStart(),
 		})
 	}
 }
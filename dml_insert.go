@@ -45,6 +45,7 @@ func populateInsert(result *ParsedQuery, ctx gen.IInsertstmtContext, tokens antl
 				Raw:    nameText,
 			}
 			result.Tables = append(result.Tables, tbl)
+			result.Target = &tbl
 		}
 	}
 
@@ -64,6 +65,9 @@ func populateInsert(result *ParsedQuery, ctx gen.IInsertstmtContext, tokens antl
 			if err := populateSelect(result, rest.Selectstmt(), tokens); err != nil {
 				return err
 			}
+			if err := populateInsertSource(result, rest.Selectstmt(), tokens); err != nil {
+				return err
+			}
 		}
 	}
 
@@ -71,6 +75,21 @@ func populateInsert(result *ParsedQuery, ctx gen.IInsertstmtContext, tokens antl
 	return nil
 }
 
+// populateInsertSource records the SELECT feeding an INSERT as its own nested query.
+// VALUES lists carry no relations and are left without a Source.
+func populateInsertSource(result *ParsedQuery, selectCtx gen.ISelectstmtContext, tokens antlr.TokenStream) error {
+	_, simple, _, err := resolveSelect(selectCtx)
+	if err != nil || simple == nil || simple.Values_clause() != nil {
+		return nil
+	}
+	source, err := buildSelectQuery(selectCtx, tokens)
+	if err != nil {
+		return err
+	}
+	result.Source = source
+	return nil
+}
+
 // buildUpsertClause structures ON CONFLICT metadata including target and action.
 func buildUpsertClause(result *ParsedQuery, conflict gen.IOn_conflict_Context, tokens antlr.TokenStream) *UpsertClause {
 	if conflict == nil {
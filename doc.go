@@ -24,8 +24,18 @@
 //
 //   - Column usage analysis (which columns are used for filtering, joining, ordering)
 //   - WHERE condition extraction with operator and value details
+//   - WHERE condition trees with AND/OR/NOT structure, DNF, and the conditions every row satisfies
 //   - JOIN relationship inference (parent-child table detection)
 //   - Schema-aware FK detection using primary key metadata
+//   - Resolution of unqualified column references to their owning tables
+//   - Expansion of * and alias.* into ordered result columns
+//   - Schema qualification of unqualified names through a search_path
+//   - Column lineage from result and target columns to the base-table columns they derive from
+//   - Tables read and written by a statement, including data-modifying CTEs and sequence calls
+//   - A read-only check for untrusted SQL, with a configurable deny list of side-effecting functions
+//   - Migration safety: lock levels, table rewrites and scans, and warnings for risky DDL
+//   - Index recommendations for a workload, with unused and redundant existing indexes
+//   - Join graphs per query level, flagging cartesian products and outer joins undone by WHERE
 //
 // Example:
 //
@@ -36,6 +46,37 @@
 //	fmt.Println(result.Tables)       // tables referenced
 //	fmt.Println(result.ColumnUsage)  // columns with usage types
 //
+// # Catalog Subpackage
+//
+// The catalog subpackage replays DDL actions into an in-memory schema model
+// (schemas, tables, views, columns, constraints, indexes, sequences, types) that
+// can be exported as the ColumnSchema metadata used by the analysis functions.
+// catalog.Load builds the model from a pg_dump --schema-only file and
+// catalog.Diff compares two models and generates the DDL that migrates one
+// into the other. catalog.Describe infers the result column and parameter types
+// of a query from the model, without a database, and catalog.Validate reports
+// unknown relations and columns and other errors PostgreSQL would raise.
+// catalog.BuildDependencyGraph links the objects declared by a project's DDL
+// (views, tables, indexes, sequences, types, functions, triggers) for creation
+// ordering and drop impact analysis.
+//
+// # Code Generation
+//
+// The codegen subpackage and the cmd/pgcodegen command generate typed Go functions
+// for database/sql from SQL files of named queries, typed with catalog.Describe.
+//
+// # Linting
+//
+// The lint subpackage checks statements against rules such as DELETE without WHERE,
+// SELECT *, and comparisons to NULL with =, with per-rule severities, custom rules,
+// and lint:ignore comments to suppress findings.
+//
+// # Scripts
+//
+// ParseSQL analyzes only the first statement of its input. ParseSQLAll returns one
+// ParsedQuery per statement of a script, and SplitStatements splits a script into
+// its statements without parsing them.
+//
 // # Supported SQL Features
 //
 //   - SELECT with projections, WHERE, GROUP BY, HAVING, ORDER BY, LIMIT/OFFSET
@@ -43,10 +84,19 @@
 //   - UPDATE with FROM clause, RETURNING
 //   - DELETE with USING clause, RETURNING
 //   - MERGE with MATCHED/NOT MATCHED actions
-//   - CREATE TABLE with column metadata (name, type, nullability, default)
+//   - CREATE TABLE with column metadata (name, type, nullability, default) and constraints
+//   - CREATE TABLE AS, SELECT INTO, and INSERT ... SELECT with write target and nested source query
 //   - CREATE/DROP INDEX, DROP TABLE, ALTER TABLE, TRUNCATE
-//   - Common Table Expressions (WITH ... AS)
-//   - Subqueries in SELECT, FROM, WHERE, and HAVING
+//   - ALTER TABLE/INDEX/SEQUENCE/VIEW sub-commands, ALTER ... OWNER TO, SET SCHEMA, and RENAME
+//   - CREATE TYPE, CREATE DOMAIN, ALTER TYPE ... ADD VALUE / RENAME VALUE
+//   - CREATE [MATERIALIZED] VIEW with the defining query as source, CREATE SEQUENCE
+//   - CREATE FUNCTION / PROCEDURE (signature, return type, language, body) and CREATE TRIGGER
+//   - DROP of any object kind, with function signatures and IF EXISTS/CASCADE/RESTRICT flags
+//   - CREATE SCHEMA, CREATE EXTENSION, COMMENT ON (object and column comments)
+//   - Foreign data wrappers, servers, foreign tables, IMPORT FOREIGN SCHEMA, user mappings
+//   - CREATE PUBLICATION (tables, column lists, row filters) and CREATE SUBSCRIPTION (passwords redacted)
+//   - Common Table Expressions (WITH ... AS), with parsed bodies and recursive anchor/term split
+//   - Subqueries in SELECT, FROM, WHERE, and HAVING, each with its kind, IR, and correlations
 //   - All JOIN types (INNER, LEFT, RIGHT, FULL, CROSS, NATURAL, LATERAL)
 //   - Set operations (UNION, INTERSECT, EXCEPT with ALL/DISTINCT)
 //   - JSONB operators (->>, ->, @>, ?, ?|, ?&)
@@ -12,11 +12,17 @@ import (
 // ParseSQL parses a PostgreSQL query using the ANTLR-generated parser and returns
 // a structured representation with projections, relations, and auxiliary clauses.
 func ParseSQL(sql string) (*ParsedQuery, error) {
+	return parseSQL(sql, make(map[cteBodyKey]*ParsedQuery))
+}
+
+// parseSQL implements ParseSQL, sharing the CTE bodies parsed so far with the parses
+// of the CTE bodies of the statement.
+func parseSQL(sql string, cteBodies map[cteBodyKey]*ParsedQuery) (*ParsedQuery, error) {
 	cleanSQL := preprocessSQLInput(sql)
 	input := antlr.NewInputStream(cleanSQL)
 	lexer := gen.NewPostgreSQLLexer(input)
-	stream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
-	parser := gen.NewPostgreSQLParser(stream)
+	tokenStream := antlr.NewCommonTokenStream(lexer, antlr.TokenDefaultChannel)
+	parser := gen.NewPostgreSQLParser(tokenStream)
 	parser.BuildParseTrees = true
 
 	errListener := &parseErrorListener{}
@@ -25,6 +31,9 @@ func ParseSQL(sql string) (*ParsedQuery, error) {
 
 	root := parser.Root()
 	if len(errListener.errs) > 0 {
+		if res, ok := parsePublicationFallback(cleanSQL, tokenStream); ok {
+			return res, nil
+		}
 		return nil, &ParseErrors{SQL: cleanSQL, Errors: errListener.errs}
 	}
 	if root == nil || root.Stmtblock() == nil {
@@ -46,13 +55,18 @@ func ParseSQL(sql string) (*ParsedQuery, error) {
 		DerivedColumns: make(map[string]string),
 	}
 
+	stream := &cteStream{TokenStream: tokenStream, bodies: cteBodies}
 	mainStmt := stmts[0]
+	res.RawSQL = redactStatementSecrets(res.RawSQL, mainStmt, stream)
 	switch {
 	case mainStmt.Selectstmt() != nil:
 		res.Command = QueryCommandSelect
 		if err := populateSelect(res, mainStmt.Selectstmt(), stream); err != nil {
 			return nil, err
 		}
+		if err := populateSelectInto(res, mainStmt.Selectstmt(), stream); err != nil {
+			return nil, err
+		}
 	case mainStmt.Insertstmt() != nil:
 		res.Command = QueryCommandInsert
 		if err := populateInsert(res, mainStmt.Insertstmt(), stream); err != nil {
@@ -78,16 +92,126 @@ func ParseSQL(sql string) (*ParsedQuery, error) {
 		if err := populateCreateTable(res, mainStmt.Createstmt(), stream); err != nil {
 			return nil, err
 		}
+	case mainStmt.Createasstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateTableAs(res, mainStmt.Createasstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Viewstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateView(res, mainStmt.Viewstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Creatematviewstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateMaterializedView(res, mainStmt.Creatematviewstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createseqstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateSequence(res, mainStmt.Createseqstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createfunctionstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateFunction(res, mainStmt.Createfunctionstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createtrigstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateTrigger(res, mainStmt.Createtrigstmt(), stream); err != nil {
+			return nil, err
+		}
 	case mainStmt.Dropstmt() != nil:
 		res.Command = QueryCommandDDL
 		if err := populateDropStmt(res, mainStmt.Dropstmt(), stream); err != nil {
 			return nil, err
 		}
+	case mainStmt.Removefuncstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropFunction(res, mainStmt.Removefuncstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Removeaggrstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropAggregate(res, mainStmt.Removeaggrstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Removeoperstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropOperator(res, mainStmt.Removeoperstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Droprolestmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropRole(res, mainStmt.Droprolestmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Dropdbstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropDatabase(res, mainStmt.Dropdbstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Dropsubscriptionstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropSubscription(res, mainStmt.Dropsubscriptionstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Droptablespacestmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropTablespace(res, mainStmt.Droptablespacestmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Dropusermappingstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropUserMapping(res, mainStmt.Dropusermappingstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Dropownedstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateDropOwned(res, mainStmt.Dropownedstmt(), stream); err != nil {
+			return nil, err
+		}
 	case mainStmt.Altertablestmt() != nil:
 		res.Command = QueryCommandDDL
 		if err := populateAlterTable(res, mainStmt.Altertablestmt(), stream); err != nil {
 			return nil, err
 		}
+	case mainStmt.Renamestmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateRename(res, mainStmt.Renamestmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Definestmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateType(res, mainStmt.Definestmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createdomainstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateDomain(res, mainStmt.Createdomainstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Alterenumstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterEnum(res, mainStmt.Alterenumstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Alterownerstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterOwner(res, mainStmt.Alterownerstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Alterobjectschemastmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterObjectSchema(res, mainStmt.Alterobjectschemastmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Alterseqstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateAlterSequence(res, mainStmt.Alterseqstmt(), stream); err != nil {
+			return nil, err
+		}
 	case mainStmt.Indexstmt() != nil:
 		res.Command = QueryCommandDDL
 		if err := populateCreateIndex(res, mainStmt.Indexstmt(), stream); err != nil {
@@ -98,10 +222,64 @@ func ParseSQL(sql string) (*ParsedQuery, error) {
 		if err := populateTruncate(res, mainStmt.Truncatestmt(), stream); err != nil {
 			return nil, err
 		}
+	case mainStmt.Createschemastmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateSchema(res, mainStmt.Createschemastmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createextensionstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateExtension(res, mainStmt.Createextensionstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Commentstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateComment(res, mainStmt.Commentstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createfdwstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateFdw(res, mainStmt.Createfdwstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createforeignserverstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateServer(res, mainStmt.Createforeignserverstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createforeigntablestmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateForeignTable(res, mainStmt.Createforeigntablestmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Importforeignschemastmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateImportForeignSchema(res, mainStmt.Importforeignschemastmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createusermappingstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateUserMapping(res, mainStmt.Createusermappingstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createpublicationstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreatePublication(res, mainStmt.Createpublicationstmt(), stream); err != nil {
+			return nil, err
+		}
+	case mainStmt.Createsubscriptionstmt() != nil:
+		res.Command = QueryCommandDDL
+		if err := populateCreateSubscription(res, mainStmt.Createsubscriptionstmt(), stream); err != nil {
+			return nil, err
+		}
 	default:
 		return res, nil
 	}
 
+	res.Scopes, res.NestedSubqueries = buildScopes(mainStmt, stream)
+	linkSubqueryCorrelations(res)
+	res.Functions = collectFunctionCalls(mainStmt, stream)
+	res.Locking = collectLockingClauses(mainStmt, stream)
 	res.Parameters = extractParameters(cleanSQL)
 	return res, nil
 }
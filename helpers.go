@@ -15,6 +15,7 @@ type columnRef struct {
 	TableAlias string
 	Name       string
 	Expr       string
+	Position   int
 }
 
 // --- Column Usage Analysis Helpers ---
@@ -71,6 +72,7 @@ func findAndRecordUsage(result *ParsedQuery, ctx antlr.RuleContext, role ColumnU
 			Expression: colCtx.GetText(),
 			UsageType:  role,
 			Context:    ctx.GetText(), // Context is the text of the rule context that triggered the find
+			Position:   ref.Position,
 		}
 
 		// Assign operator only to the first column to avoid duplicates
@@ -143,6 +145,7 @@ func parseColRefFromContext(ctx *gen.ColumnrefContext) columnRef {
 		ref.Name = strings.TrimSpace(ctx.GetText())
 	}
 	ref.Expr = ctx.GetText()
+	ref.Position = ctx.GetStart().GetStart()
 	return ref
 }
 
@@ -176,7 +179,8 @@ func tableRefAliasOrName(tr TableRef) string {
 }
 
 // recordUsingJoinFromString parses a textual USING clause and records join usages for the two most recent base tables.
-func recordUsingJoinFromString(result *ParsedQuery, clause string) {
+// position is the offset of the clause in the statement.
+func recordUsingJoinFromString(result *ParsedQuery, clause string, position int) {
 	if result == nil {
 		return
 	}
@@ -198,11 +202,11 @@ func recordUsingJoinFromString(result *ParsedQuery, clause string) {
 			cols = append(cols, col)
 		}
 	}
-	recordUsingJoinFromParts(result, cols, clause)
+	recordUsingJoinFromParts(result, cols, clause, position)
 }
 
 // recordUsingJoinFromParts associates the supplied column names with the left/right base tables of the current join context.
-func recordUsingJoinFromParts(result *ParsedQuery, cols []string, context string) {
+func recordUsingJoinFromParts(result *ParsedQuery, cols []string, context string, position int) {
 	if result == nil || len(cols) == 0 {
 		return
 	}
@@ -233,6 +237,7 @@ func recordUsingJoinFromParts(result *ParsedQuery, cols []string, context string
 			UsageType:  ColumnUsageTypeJoin,
 			Context:    context,
 			Side:       "left",
+			Position:   position,
 		})
 		result.ColumnUsage = append(result.ColumnUsage, ColumnUsage{
 			TableAlias: rightAlias,
@@ -241,6 +246,7 @@ func recordUsingJoinFromParts(result *ParsedQuery, cols []string, context string
 			UsageType:  ColumnUsageTypeJoin,
 			Context:    context,
 			Side:       "right",
+			Position:   position,
 		})
 	}
 }
@@ -297,6 +303,60 @@ func ctxText(tokens antlr.TokenStream, ctx antlr.RuleContext) string {
 	return tokens.GetTextFromInterval(interval)
 }
 
+// ruleText returns the trimmed input text covered by ctx, or "" when ctx is nil.
+func ruleText(ctx antlr.RuleContext, tokens antlr.TokenStream) string {
+	return strings.TrimSpace(ctxText(tokens, ctx))
+}
+
+// unquoteStringLiteral returns the value of a PostgreSQL string constant
+// ('...', E'...', U&'...', $$...$$ or $tag$...$tag$). Unrecognized input is returned unchanged.
+func unquoteStringLiteral(s string) string {
+	s = strings.TrimSpace(s)
+	if strings.HasPrefix(s, "$") {
+		if end := strings.Index(s[1:], "$"); end >= 0 {
+			tag := s[:end+2]
+			if len(s) >= 2*len(tag) && strings.HasSuffix(s, tag) {
+				return s[len(tag) : len(s)-len(tag)]
+			}
+		}
+		return s
+	}
+	escapes := false
+	switch {
+	case len(s) > 1 && (s[0] == 'E' || s[0] == 'e') && s[1] == '\'':
+		escapes = true
+		s = s[1:]
+	case len(s) > 1 && (s[0] == 'U' || s[0] == 'u') && s[1] == '&':
+		s = s[2:]
+	}
+	if len(s) < 2 || s[0] != '\'' || s[len(s)-1] != '\'' {
+		return s
+	}
+	body := strings.ReplaceAll(s[1:len(s)-1], "''", "'")
+	if !escapes {
+		return body
+	}
+	var b strings.Builder
+	for i := 0; i < len(body); i++ {
+		if body[i] != '\\' || i+1 == len(body) {
+			b.WriteByte(body[i])
+			continue
+		}
+		i++
+		switch body[i] {
+		case 'n':
+			b.WriteByte('\n')
+		case 't':
+			b.WriteByte('\t')
+		case 'r':
+			b.WriteByte('\r')
+		default:
+			b.WriteByte(body[i])
+		}
+	}
+	return b.String()
+}
+
 // splitQualifiedName splits identifiers of the form schema.name into structured parts.
 // It is quote-aware: dots inside double-quoted identifiers (e.g., "my.schema"."my.table")
 // are not treated as separators.
@@ -414,17 +474,89 @@ func extractOperatorFromExpression(exprCtx antlr.RuleContext, tokens antlr.Token
 	return ""
 }
 
-// functionCallCollector walks the subtree and records function calls.
+// functionCallCollector walks the subtree and records function calls. With tokens set,
+// it also records each call with its arguments.
 type functionCallCollector struct {
 	*gen.BasePostgreSQLParserListener
 	functions []string
+	tokens    antlr.TokenStream
+	calls     []FunctionCall
 }
 
 func (f *functionCallCollector) EnterFunc_application(ctx *gen.Func_applicationContext) {
-	if ctx.Func_name() != nil {
-		funcName := strings.ToUpper(ctx.Func_name().GetText())
-		f.functions = append(f.functions, funcName)
+	if ctx.Func_name() == nil {
+		return
+	}
+	funcName := strings.ToUpper(ctx.Func_name().GetText())
+	f.functions = append(f.functions, funcName)
+	if f.tokens == nil {
+		return
 	}
+	call := FunctionCall{Position: ctx.GetStart().GetStart()}
+	call.Schema, call.Name = splitQualifiedName(ruleText(ctx.Func_name(), f.tokens))
+	switch {
+	case ctx.STAR() != nil:
+		call.Args = []string{"*"}
+	case ctx.Func_arg_list() != nil:
+		for _, arg := range ctx.Func_arg_list().AllFunc_arg_expr() {
+			call.Args = append(call.Args, ruleText(arg, f.tokens))
+		}
+	case ctx.Func_arg_expr() != nil:
+		call.Args = []string{ruleText(ctx.Func_arg_expr(), f.tokens)}
+	}
+	f.calls = append(f.calls, call)
+}
+
+// collectFunctionCalls returns the named function calls in a statement, in order.
+func collectFunctionCalls(stmt antlr.ParseTree, tokens antlr.TokenStream) []FunctionCall {
+	collector := &functionCallCollector{BasePostgreSQLParserListener: &gen.BasePostgreSQLParserListener{}, tokens: tokens}
+	antlr.ParseTreeWalkerDefault.Walk(collector, stmt)
+	return collector.calls
+}
+
+// lockingClauseCollector walks the subtree and records row-locking clauses.
+type lockingClauseCollector struct {
+	*gen.BasePostgreSQLParserListener
+	tokens  antlr.TokenStream
+	clauses []LockingClause
+}
+
+func (l *lockingClauseCollector) EnterFor_locking_item(ctx *gen.For_locking_itemContext) {
+	strength := ctx.For_locking_strength()
+	if strength == nil {
+		return
+	}
+	clause := LockingClause{Position: ctx.GetStart().GetStart()}
+	switch {
+	case strength.NO() != nil:
+		clause.Strength = "NO KEY UPDATE"
+	case strength.KEY() != nil:
+		clause.Strength = "KEY SHARE"
+	case strength.SHARE() != nil:
+		clause.Strength = "SHARE"
+	default:
+		clause.Strength = "UPDATE"
+	}
+	if rels := ctx.Locked_rels_list(); rels != nil && rels.Qualified_name_list() != nil {
+		for _, name := range rels.Qualified_name_list().AllQualified_name() {
+			clause.Tables = append(clause.Tables, ruleText(name, l.tokens))
+		}
+	}
+	if wait := ctx.Nowait_or_skip_(); wait != nil {
+		if wait.NOWAIT() != nil {
+			clause.Wait = "NOWAIT"
+		} else {
+			clause.Wait = "SKIP LOCKED"
+		}
+	}
+	l.clauses = append(l.clauses, clause)
+}
+
+// collectLockingClauses returns the FOR UPDATE/SHARE clauses in a statement, in order.
+func collectLockingClauses(stmt antlr.ParseTree, tokens antlr.TokenStream) []LockingClause {
+	collector := &lockingClauseCollector{BasePostgreSQLParserListener: &gen.BasePostgreSQLParserListener{}, tokens: tokens}
+	antlr.ParseTreeWalkerDefault.Walk(collector, stmt)
+	return collector.clauses
 }
 
 // extractFunctionsFromContext extracts function names that wrap the column reference.
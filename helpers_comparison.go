@@ -17,6 +17,7 @@ type comparisonInfo struct {
 	expression string
 	operator   string
 	context    string
+	position   int
 }
 
 // comprehensiveComparisonCollector implements a full listener for all A_expr node types
@@ -58,6 +59,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_compare(ctx *gen.A_expr_c
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -100,6 +102,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_like(ctx *gen.A_expr_like
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -142,6 +145,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_in(ctx *gen.A_expr_inCont
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -184,6 +188,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_between(ctx *gen.A_expr_b
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -254,6 +259,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_isnull(ctx *gen.A_expr_is
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -307,6 +313,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_is_not(ctx *gen.A_expr_is
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -335,6 +342,7 @@ func (c *comprehensiveComparisonCollector) EnterA_expr_qual_op(ctx *gen.A_expr_q
 				expression: col.Expr,
 				operator:   operator,
 				context:    exprText,
+				position:   col.Position,
 			})
 		}
 	}
@@ -403,6 +411,7 @@ func findAndRecordComparisons(result *ParsedQuery, ctx antlr.RuleContext, role C
 					UsageType:  role,
 					Context:    comp.context,
 					Operator:   comp.operator,
+					Position:   comp.position,
 				})
 			}
 		}
@@ -0,0 +1,132 @@
+// Package sqlident recognizes identifiers and column references in SQL text and derives
+// the names PostgreSQL gives output columns. It is shared by the analysis and catalog
+// packages.
+package sqlident
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// Pattern matches one identifier, quoted or plain.
+const Pattern = `(?:"(?:[^"]|"")+"|[A-Za-z_][A-Za-z0-9_$]*)`
+
+var (
+	chainRe        = regexp.MustCompile(`^` + Pattern + `(?:\s*\.\s*` + Pattern + `)*$`)
+	partRe         = regexp.MustCompile(Pattern)
+	functionCallRe = regexp.MustCompile(`^(?:` + Pattern + `\s*\.\s*)?(` + Pattern + `)\s*\(`)
+	// typeRe matches a type name as written after ::, such as numeric(10,2),
+	// double precision, timestamp(3) with time zone, or public.mood[].
+	typeRe = regexp.MustCompile(`^` + Pattern + `(?:\s*\.\s*` + Pattern + `)*(?:\s*\([^()]*\))?` +
+		`(?:\s+(?i:precision|varying|with|without|time|zone|to|year|month|day|hour|minute|second)(?:\s*\([^()]*\))?)*` +
+		`(?:\s*\[\s*\d*\s*\])*$`)
+)
+
+// Name returns the name an identifier denotes: quoted identifiers keep their case,
+// unquoted ones fold to lower case.
+func Name(ident string) string {
+	ident = strings.TrimSpace(ident)
+	if len(ident) >= 2 && ident[0] == '"' && ident[len(ident)-1] == '"' {
+		return strings.ReplaceAll(ident[1:len(ident)-1], `""`, `"`)
+	}
+	return strings.ToLower(ident)
+}
+
+// SplitChain splits a possibly qualified name such as o."userId" into its parts as
+// written, or reports false when expr is not one.
+func SplitChain(expr string) ([]string, bool) {
+	expr = strings.TrimSpace(expr)
+	if !chainRe.MatchString(expr) {
+		return nil, false
+	}
+	return partRe.FindAllString(expr, -1), true
+}
+
+// SplitCast splits ref::type into the column reference ref and type. Only casts of a
+// plain column reference that are not operands of an operator are recognized.
+func SplitCast(expr string) (ref, typ string, ok bool) {
+	i := strings.Index(expr, "::")
+	if i < 0 {
+		return "", "", false
+	}
+	ref, typ = strings.TrimSpace(expr[:i]), strings.TrimSpace(expr[i+2:])
+	if !chainRe.MatchString(ref) || !typeRe.MatchString(typ) {
+		return "", "", false
+	}
+	return ref, typ, true
+}
+
+// OutputName returns the name PostgreSQL gives an output column: its alias, the column
+// name of a column reference or cast of one, the function name of a call, case for
+// CASE, the type name of another cast or of a boolean constant, or ?column?.
+func OutputName(c postgresparser.SelectColumn) string {
+	if c.Alias != "" {
+		return Name(c.Alias)
+	}
+	expr := strings.TrimSpace(c.Expression)
+	castType := ""
+	for !isKeywordConstant(expr) {
+		if parts, ok := SplitChain(expr); ok {
+			return Name(parts[len(parts)-1])
+		}
+		// Only a cast whose type runs to the end of the expression applies to all of
+		// it; in total::numeric * 2 the cast is an operand.
+		i := strings.LastIndex(expr, "::")
+		if i <= 0 || strings.Count(expr[:i], "(") != strings.Count(expr[:i], ")") ||
+			!typeRe.MatchString(strings.TrimSpace(expr[i+2:])) {
+			break
+		}
+		if castType == "" {
+			castType = strings.TrimSpace(expr[i+2:])
+		}
+		expr = strings.TrimSpace(expr[:i])
+	}
+	switch {
+	case functionCallRe.MatchString(expr):
+		return Name(functionCallRe.FindStringSubmatch(expr)[1])
+	case castType != "":
+		return typeColumnName(castType)
+	case len(expr) >= 4 && strings.EqualFold(expr[:4], "CASE"):
+		return "case"
+	case strings.EqualFold(expr, "true"), strings.EqualFold(expr, "false"):
+		return "bool"
+	}
+	return "?column?"
+}
+
+// isKeywordConstant reports whether expr is TRUE, FALSE, or NULL, which are constants
+// rather than column references.
+func isKeywordConstant(expr string) bool {
+	return strings.EqualFold(expr, "true") || strings.EqualFold(expr, "false") || strings.EqualFold(expr, "null")
+}
+
+// internalTypeNames maps type names as written to the internal names PostgreSQL uses
+// for the output columns of casts.
+var internalTypeNames = map[string]string{
+	"int": "int4", "integer": "int4", "smallint": "int2", "bigint": "int8",
+	"boolean": "bool", "real": "float4", "float": "float8", "double precision": "float8",
+	"decimal": "numeric", "character varying": "varchar", "character": "bpchar", "char": "bpchar",
+	"timestamp with time zone": "timestamptz", "timestamp without time zone": "timestamp",
+	"time with time zone": "timetz", "time without time zone": "time",
+}
+
+// typeColumnName returns the output column name of a cast to typ, e.g. int4 for
+// ::integer and varchar for ::varchar(10)[].
+func typeColumnName(typ string) string {
+	typ = strings.TrimSpace(strings.TrimRight(typ, "[] "))
+	if open := strings.Index(typ, "("); open >= 0 {
+		if end := strings.Index(typ[open:], ")"); end >= 0 {
+			typ = typ[:open] + typ[open+end+1:]
+		}
+	}
+	if name, ok := internalTypeNames[strings.Join(strings.Fields(strings.ToLower(typ)), " ")]; ok {
+		return name
+	}
+	parts := partRe.FindAllString(typ, -1)
+	if len(parts) == 0 {
+		return "?column?"
+	}
+	return Name(parts[len(parts)-1])
+}
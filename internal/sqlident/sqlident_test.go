@@ -0,0 +1,59 @@
+package sqlident
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/valkdb/postgresparser"
+)
+
+func TestSplitChain(t *testing.T) {
+	parts, ok := SplitChain(`public . orders."UserId"`)
+	assert.True(t, ok, "qualified reference")
+	assert.Equal(t, []string{"public", "orders", `"UserId"`}, parts)
+
+	_, ok = SplitChain("lower(email)")
+	assert.False(t, ok, "function call")
+	assert.Equal(t, `a"b`, Name(`"a""b"`), "escaped quote")
+	assert.Equal(t, "users", Name("Users"), "folded name")
+}
+
+func TestSplitCast(t *testing.T) {
+	ref, typ, ok := SplitCast("o.total::numeric(10,2)")
+	assert.True(t, ok, "cast of a column")
+	assert.Equal(t, "o.total", ref)
+	assert.Equal(t, "numeric(10,2)", typ)
+
+	_, _, ok = SplitCast("(a + b)::int")
+	assert.False(t, ok, "cast of an expression")
+
+	_, _, ok = SplitCast("total::numeric * 2")
+	assert.False(t, ok, "cast followed by an operator")
+}
+
+func TestOutputName(t *testing.T) {
+	tests := map[string]string{
+		"u.email":                        "email",
+		`"Name"::text`:                   "Name",
+		"pg_catalog.lower(x)":            "lower",
+		"count(*)::int":                  "count",
+		"1::bigint":                      "int8",
+		"'a'::varchar(3)[]":              "varchar",
+		"true":                           "bool",
+		"NULL":                           "?column?",
+		"CASE WHEN a THEN 1 END":         "case",
+		"a + b":                          "?column?",
+		"total::numeric * 2":             "?column?",
+		"'{}'::jsonb -> 'a'":             "?column?",
+		"id::int + org_id":               "?column?",
+		"n::numeric(10,2) / 3":           "?column?",
+		"a::bool AND b":                  "?column?",
+		"x::double precision":            "x",
+		"1::timestamp(3) with time zone": "timestamptz",
+	}
+	for expr, want := range tests {
+		assert.Equal(t, want, OutputName(postgresparser.SelectColumn{Expression: expr}), "name of %s", expr)
+	}
+	assert.Equal(t, "Total", OutputName(postgresparser.SelectColumn{Expression: "sum(x)", Alias: `"Total"`}), "alias")
+}
@@ -0,0 +1,55 @@
+package sqllex
+
+import (
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// RelationQualifier returns the name columns of a relation are qualified with: its
+// alias, or its name when it has none.
+func RelationQualifier(t postgresparser.TableRef) string {
+	if t.Alias != "" {
+		return strings.Trim(t.Alias, `"`)
+	}
+	return strings.Trim(t.Name, `"`)
+}
+
+// ScopeAt returns the innermost of scopes containing the character offset pos, or the
+// statement scope 0 when none does.
+func ScopeAt(scopes []postgresparser.QueryScope, pos int) int {
+	best := 0
+	for i, s := range scopes {
+		// Scopes are recorded parents first, so the last match is the innermost.
+		if pos >= s.Start && pos < s.End {
+			best = i
+		}
+	}
+	return best
+}
+
+// UnionFind groups the elements 0..n-1 into disjoint sets.
+type UnionFind []int
+
+// NewUnionFind returns n singleton groups.
+func NewUnionFind(n int) UnionFind {
+	u := make(UnionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+	return u
+}
+
+// Find returns the representative of the group of i.
+func (u UnionFind) Find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+	return i
+}
+
+// Union merges the groups of a and b.
+func (u UnionFind) Union(a, b int) {
+	u[u.Find(a)] = u.Find(b)
+}
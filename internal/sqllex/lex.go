@@ -0,0 +1,234 @@
+// Package sqllex splits SQL text into tokens for the token-level passes of the catalog,
+// lint, and analysis packages, and holds the small helpers those passes share.
+package sqllex
+
+import (
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Kind classifies a Token.
+type Kind int
+
+const (
+	Ident   Kind = iota // Keyword or unquoted identifier
+	Quoted              // Quoted identifier
+	Number              // Numeric constant
+	String              // String constant, including E'', B'', X'', and dollar-quoted forms
+	Param               // Positional parameter $n
+	Op                  // Operator, including :: and *
+	Punct               // ( ) [ ] , ; .
+	Comment             // -- or /* */ comment, kept only by Comments
+)
+
+// Token is a token of SQL text. Pos is a character (rune) offset, like the offsets
+// recorded by the parser.
+type Token struct {
+	Kind Kind
+	Text string
+	Pos  int
+}
+
+// Is reports whether the token is the keyword or punctuation kw, ignoring case.
+func (t Token) Is(kw string) bool {
+	return (t.Kind == Ident || t.Kind == Punct || t.Kind == Op) && strings.EqualFold(t.Text, kw)
+}
+
+// IsKeyword reports whether the token is one of the keywords kws.
+func (t Token) IsKeyword(kws ...string) bool {
+	return t.Kind == Ident && slices.ContainsFunc(kws, t.Is)
+}
+
+// opChars are the characters PostgreSQL operators are made of.
+const opChars = "+-*/<>=~!@#%^&|`?"
+
+// Lex tokenizes sql, skipping whitespace and comments. Unterminated constructs run to
+// the end of the text.
+func Lex(sql string) []Token {
+	return lex(sql, false)
+}
+
+// Comments returns the comments of sql as Comment tokens.
+func Comments(sql string) []Token {
+	var out []Token
+	for _, t := range lex(sql, true) {
+		if t.Kind == Comment {
+			out = append(out, t)
+		}
+	}
+	return out
+}
+
+// lex tokenizes sql, keeping comments when comments is set.
+func lex(sql string, comments bool) []Token {
+	src := []rune(sql)
+	var out []Token
+	i := 0
+	for i < len(src) {
+		r := src[i]
+		start := i
+		next := func(k int) rune {
+			if i+k < len(src) {
+				return src[i+k]
+			}
+			return 0
+		}
+		switch {
+		case unicode.IsSpace(r):
+			i++
+			continue
+		case r == '-' && next(1) == '-':
+			for i < len(src) && src[i] != '\n' {
+				i++
+			}
+			if comments {
+				out = append(out, Token{Kind: Comment, Text: string(src[start:i]), Pos: start})
+			}
+			continue
+		case r == '/' && next(1) == '*':
+			depth := 0
+			for i < len(src) {
+				if src[i] == '/' && next(1) == '*' {
+					depth++
+					i += 2
+				} else if src[i] == '*' && next(1) == '/' {
+					depth--
+					i += 2
+					if depth == 0 {
+						break
+					}
+				} else {
+					i++
+				}
+			}
+			if comments {
+				out = append(out, Token{Kind: Comment, Text: string(src[start:i]), Pos: start})
+			}
+			continue
+		case r == '\'':
+			i = skipQuoted(src, i+1, '\'', false)
+			out = append(out, Token{Kind: String, Text: string(src[start:i]), Pos: start})
+		case strings.ContainsRune("eEbBxXnN", r) && next(1) == '\'':
+			i = skipQuoted(src, i+2, '\'', r == 'e' || r == 'E')
+			out = append(out, Token{Kind: String, Text: string(src[start:i]), Pos: start})
+		case r == '"':
+			i = skipQuoted(src, i+1, '"', false)
+			out = append(out, Token{Kind: Quoted, Text: string(src[start:i]), Pos: start})
+		case r == '$' && unicode.IsDigit(next(1)):
+			i++
+			for i < len(src) && unicode.IsDigit(src[i]) {
+				i++
+			}
+			out = append(out, Token{Kind: Param, Text: string(src[start:i]), Pos: start})
+		case r == '$':
+			// Dollar quoting: $tag$ ... $tag$.
+			j := i + 1
+			for j < len(src) && (src[j] == '_' || unicode.IsLetter(src[j]) || unicode.IsDigit(src[j])) {
+				j++
+			}
+			if j >= len(src) || src[j] != '$' {
+				i++
+				out = append(out, Token{Kind: Op, Text: "$", Pos: start})
+				continue
+			}
+			tag := src[i : j+1]
+			i = len(src)
+			for k := j + 1; k+len(tag) <= len(src); k++ {
+				if string(src[k:k+len(tag)]) == string(tag) {
+					i = k + len(tag)
+					break
+				}
+			}
+			out = append(out, Token{Kind: String, Text: string(src[start:i]), Pos: start})
+		case unicode.IsDigit(r) || r == '.' && unicode.IsDigit(next(1)):
+			i = skipNumber(src, i)
+			out = append(out, Token{Kind: Number, Text: string(src[start:i]), Pos: start})
+		case r == '_' || unicode.IsLetter(r):
+			for i < len(src) && (src[i] == '_' || src[i] == '$' || unicode.IsLetter(src[i]) || unicode.IsDigit(src[i])) {
+				i++
+			}
+			out = append(out, Token{Kind: Ident, Text: string(src[start:i]), Pos: start})
+		case r == ':' && next(1) == ':':
+			i += 2
+			out = append(out, Token{Kind: Op, Text: "::", Pos: start})
+		case strings.ContainsRune("()[],;.:", r):
+			i++
+			out = append(out, Token{Kind: Punct, Text: string(r), Pos: start})
+		case strings.ContainsRune(opChars, r):
+			i = skipOperator(src, i)
+			out = append(out, Token{Kind: Op, Text: string(src[start:i]), Pos: start})
+		default:
+			i++
+			out = append(out, Token{Kind: Op, Text: string(r), Pos: start})
+		}
+	}
+	return out
+}
+
+// skipQuoted returns the offset after the closing quote of a constant or identifier
+// whose body starts at i. A doubled quote stands for itself; with backslash set, a
+// backslash escapes the next character.
+func skipQuoted(src []rune, i int, quote rune, backslash bool) int {
+	for i < len(src) {
+		switch {
+		case backslash && src[i] == '\\':
+			i += 2
+		case src[i] == quote && i+1 < len(src) && src[i+1] == quote:
+			i += 2
+		case src[i] == quote:
+			return i + 1
+		default:
+			i++
+		}
+	}
+	return len(src)
+}
+
+// skipNumber returns the offset after the numeric constant starting at i.
+func skipNumber(src []rune, i int) int {
+	for i < len(src) && (unicode.IsDigit(src[i]) || src[i] == '_') {
+		i++
+	}
+	if i+1 < len(src) && src[i] == '.' && src[i+1] != '.' {
+		i++
+		for i < len(src) && unicode.IsDigit(src[i]) {
+			i++
+		}
+	}
+	if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+		j := i + 1
+		if j < len(src) && (src[j] == '+' || src[j] == '-') {
+			j++
+		}
+		if j < len(src) && unicode.IsDigit(src[j]) {
+			i = j
+			for i < len(src) && unicode.IsDigit(src[i]) {
+				i++
+			}
+		}
+	}
+	return i
+}
+
+// skipOperator returns the offset after the operator starting at i, following the
+// lexer rules of PostgreSQL: an operator stops before a comment, and a multi-character
+// operator cannot end in + or - unless it contains one of ~!@#%^&|`?.
+func skipOperator(src []rune, i int) int {
+	start := i
+	for i < len(src) && strings.ContainsRune(opChars, src[i]) {
+		if i > start && (src[i] == '-' && src[i-1] == '-' || src[i] == '*' && src[i-1] == '/') {
+			i--
+			break
+		}
+		i++
+	}
+	op := string(src[start:i])
+	if len(op) > 1 && !strings.ContainsAny(op, "~!@#%^&|`?") {
+		for len(op) > 1 && strings.ContainsAny(op[len(op)-1:], "+-") {
+			op = op[:len(op)-1]
+			i--
+		}
+	}
+	return i
+}
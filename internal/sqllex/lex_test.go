@@ -0,0 +1,56 @@
+package sqllex
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/valkdb/postgresparser"
+)
+
+func TestLex(t *testing.T) {
+	toks := Lex(`SELECT "a""b", E'x\'y', $1::int -- note
+FROM t WHERE x <> 1.5e3`)
+	assert.Equal(t, []Token{
+		{Kind: Ident, Text: "SELECT", Pos: 0},
+		{Kind: Quoted, Text: `"a""b"`, Pos: 7},
+		{Kind: Punct, Text: ",", Pos: 13},
+		{Kind: String, Text: `E'x\'y'`, Pos: 15},
+		{Kind: Punct, Text: ",", Pos: 22},
+		{Kind: Param, Text: "$1", Pos: 24},
+		{Kind: Op, Text: "::", Pos: 26},
+		{Kind: Ident, Text: "int", Pos: 28},
+		{Kind: Ident, Text: "FROM", Pos: 40},
+		{Kind: Ident, Text: "t", Pos: 45},
+		{Kind: Ident, Text: "WHERE", Pos: 47},
+		{Kind: Ident, Text: "x", Pos: 53},
+		{Kind: Op, Text: "<>", Pos: 55},
+		{Kind: Number, Text: "1.5e3", Pos: 58},
+	}, toks)
+	assert.True(t, toks[0].Is("select"), "keyword ignoring case")
+	assert.True(t, toks[0].IsKeyword("FROM", "SELECT"), "one of the keywords")
+	assert.False(t, toks[1].IsKeyword(`"a""b"`), "quoted identifier is not a keyword")
+}
+
+func TestComments(t *testing.T) {
+	assert.Equal(t, []Token{
+		{Kind: Comment, Text: "/* a /* nested */ */", Pos: 7},
+		{Kind: Comment, Text: "-- b", Pos: 30},
+	}, Comments("SELECT /* a /* nested */ */ 1 -- b"))
+}
+
+func TestUnionFind(t *testing.T) {
+	u := NewUnionFind(4)
+	u.Union(0, 1)
+	u.Union(2, 1)
+	assert.Equal(t, u.Find(0), u.Find(2), "joined through 1")
+	assert.NotEqual(t, u.Find(0), u.Find(3), "separate group")
+}
+
+func TestScopeAt(t *testing.T) {
+	scopes := []postgresparser.QueryScope{{Start: 0, End: 40}, {Start: 10, End: 30, Parent: 0}, {Start: 15, End: 20, Parent: 1}}
+	assert.Equal(t, 0, ScopeAt(scopes, 5), "statement scope")
+	assert.Equal(t, 1, ScopeAt(scopes, 12), "child scope")
+	assert.Equal(t, 2, ScopeAt(scopes, 15), "innermost scope")
+	assert.Equal(t, 0, ScopeAt(scopes, 50), "outside every scope")
+}
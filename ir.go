@@ -109,25 +109,169 @@ const (
 	DDLCreateIndex DDLActionType = "CREATE_INDEX"
 	DDLDropIndex   DDLActionType = "DROP_INDEX"
 	DDLTruncate    DDLActionType = "TRUNCATE"
+	// DDLDrop is used for DROP of any object kind other than tables and indexes;
+	// ObjectKind identifies what is dropped.
+	DDLDrop DDLActionType = "DROP"
+	// DDLAlter is used for ALTER of any object kind other than tables;
+	// ObjectKind identifies what is altered and Flags the sub-command.
+	DDLAlter DDLActionType = "ALTER"
+	// DDLCreateType is used for CREATE TYPE (enum, composite, range, or shell types).
+	DDLCreateType DDLActionType = "CREATE_TYPE"
+	// DDLCreateDomain is used for CREATE DOMAIN.
+	DDLCreateDomain DDLActionType = "CREATE_DOMAIN"
+	// DDLCreateView is used for CREATE VIEW and CREATE MATERIALIZED VIEW; ObjectKind tells them apart.
+	DDLCreateView DDLActionType = "CREATE_VIEW"
+	// DDLCreateSequence is used for CREATE SEQUENCE.
+	DDLCreateSequence DDLActionType = "CREATE_SEQUENCE"
+	// DDLCreateFunction is used for CREATE FUNCTION and CREATE PROCEDURE; ObjectKind tells them apart.
+	DDLCreateFunction DDLActionType = "CREATE_FUNCTION"
+	// DDLCreateTrigger is used for CREATE TRIGGER and CREATE CONSTRAINT TRIGGER.
+	DDLCreateTrigger DDLActionType = "CREATE_TRIGGER"
+
+	DDLCreateSchema    DDLActionType = "CREATE_SCHEMA"
+	DDLCreateExtension DDLActionType = "CREATE_EXTENSION"
+	DDLComment         DDLActionType = "COMMENT"
+
+	DDLCreateForeignDataWrapper DDLActionType = "CREATE_FOREIGN_DATA_WRAPPER"
+	DDLCreateServer             DDLActionType = "CREATE_SERVER"
+	DDLCreateForeignTable       DDLActionType = "CREATE_FOREIGN_TABLE"
+	DDLImportForeignSchema      DDLActionType = "IMPORT_FOREIGN_SCHEMA"
+	DDLCreateUserMapping        DDLActionType = "CREATE_USER_MAPPING"
+	DDLCreatePublication        DDLActionType = "CREATE_PUBLICATION"
+	DDLCreateSubscription       DDLActionType = "CREATE_SUBSCRIPTION"
+)
+
+// DDLObjectKind identifies the kind of catalog object a DDL action refers to.
+// Values are the normalized SQL keywords (e.g. "MATERIALIZED VIEW").
+type DDLObjectKind string
+
+const (
+	DDLObjectTable              DDLObjectKind = "TABLE"
+	DDLObjectColumn             DDLObjectKind = "COLUMN"
+	DDLObjectView               DDLObjectKind = "VIEW"
+	DDLObjectMaterializedView   DDLObjectKind = "MATERIALIZED VIEW"
+	DDLObjectForeignTable       DDLObjectKind = "FOREIGN TABLE"
+	DDLObjectIndex              DDLObjectKind = "INDEX"
+	DDLObjectSequence           DDLObjectKind = "SEQUENCE"
+	DDLObjectSchema             DDLObjectKind = "SCHEMA"
+	DDLObjectExtension          DDLObjectKind = "EXTENSION"
+	DDLObjectType               DDLObjectKind = "TYPE"
+	DDLObjectDomain             DDLObjectKind = "DOMAIN"
+	DDLObjectFunction           DDLObjectKind = "FUNCTION"
+	DDLObjectProcedure          DDLObjectKind = "PROCEDURE"
+	DDLObjectAggregate          DDLObjectKind = "AGGREGATE"
+	DDLObjectConstraint         DDLObjectKind = "CONSTRAINT"
+	DDLObjectTrigger            DDLObjectKind = "TRIGGER"
+	DDLObjectPolicy             DDLObjectKind = "POLICY"
+	DDLObjectRule               DDLObjectKind = "RULE"
+	DDLObjectRole               DDLObjectKind = "ROLE"
+	DDLObjectDatabase           DDLObjectKind = "DATABASE"
+	DDLObjectTablespace         DDLObjectKind = "TABLESPACE"
+	DDLObjectRoutine            DDLObjectKind = "ROUTINE"
+	DDLObjectOperator           DDLObjectKind = "OPERATOR"
+	DDLObjectLanguage           DDLObjectKind = "LANGUAGE"
+	DDLObjectOwned              DDLObjectKind = "OWNED" // DROP OWNED BY role
+	DDLObjectServer             DDLObjectKind = "SERVER"
+	DDLObjectForeignDataWrapper DDLObjectKind = "FOREIGN DATA WRAPPER"
+	DDLObjectUserMapping        DDLObjectKind = "USER MAPPING"
+	DDLObjectPublication        DDLObjectKind = "PUBLICATION"
+	DDLObjectSubscription       DDLObjectKind = "SUBSCRIPTION"
 )
 
 // DDLColumn describes column-level metadata extracted from CREATE TABLE statements.
 type DDLColumn struct {
+	Name      string
+	Type      string
+	Nullable  bool
+	Default   string
+	Generated string // Expression of GENERATED ALWAYS AS (...) STORED
+	Identity  string // "ALWAYS" or "BY DEFAULT" for GENERATED ... AS IDENTITY
+}
+
+// DDLConstraintType identifies the kind of a table or column constraint.
+type DDLConstraintType string
+
+const (
+	DDLConstraintPrimaryKey DDLConstraintType = "PRIMARY KEY"
+	DDLConstraintUnique     DDLConstraintType = "UNIQUE"
+	DDLConstraintForeignKey DDLConstraintType = "FOREIGN KEY"
+	DDLConstraintCheck      DDLConstraintType = "CHECK"
+	DDLConstraintExclude    DDLConstraintType = "EXCLUDE"
+)
+
+// DDLConstraint describes a constraint declared in CREATE TABLE, ADD COLUMN, or ADD CONSTRAINT.
+// For DROP/VALIDATE/RENAME CONSTRAINT only Name is set.
+type DDLConstraint struct {
+	Name       string // Explicit constraint name; empty when PostgreSQL generates one
+	Type       DDLConstraintType
+	Columns    []string // Constrained columns (empty for table-level CHECK and EXCLUDE)
+	RefSchema  string   // Referenced table schema (FOREIGN KEY)
+	RefTable   string   // Referenced table (FOREIGN KEY)
+	RefColumns []string // Referenced columns; empty means the referenced primary key
+	OnDelete   string   // ON DELETE action, e.g. CASCADE, SET NULL (FOREIGN KEY)
+	OnUpdate   string   // ON UPDATE action (FOREIGN KEY)
+	Expression string   // CHECK expression without the surrounding parentheses, or the EXCLUDE definition after the keyword
+	NotValid   bool     // Declared NOT VALID
+	Index      string   // Existing index of ADD CONSTRAINT ... PRIMARY KEY | UNIQUE USING INDEX
+}
+
+// RedactedValue replaces secret values (passwords) in DDL options and connection strings.
+const RedactedValue = "********"
+
+// DDLOption is a single name/value entry from an OPTIONS (...) or WITH (...) list.
+type DDLOption struct {
+	Name  string
+	Value string // Unquoted value; RedactedValue for password options
+}
+
+// PublicationTable describes a table listed in CREATE PUBLICATION ... FOR TABLE.
+type PublicationTable struct {
+	Schema    string
+	Name      string
+	Columns   []string // Published column list; empty means all columns
+	RowFilter string   // WHERE row filter expression, without the surrounding parentheses
+}
+
+// TableLike describes a LIKE clause of CREATE TABLE.
+type TableLike struct {
+	Schema   string
 	Name     string
-	Type     string
-	Nullable bool
-	Default  string
+	Position int // Number of ColumnDetails entries declared before the clause
+	// Including lists the options in effect after the INCLUDING and EXCLUDING clauses,
+	// e.g. DEFAULTS or INDEXES, in grammar order. INCLUDING ALL is expanded.
+	Including []string
 }
 
 // DDLAction describes a single DDL operation extracted from a statement.
 type DDLAction struct {
-	Type          DDLActionType
-	ObjectName    string      // Unqualified table/index/object name
-	Schema        string      // Optional schema qualifier
-	Columns       []string    // Affected columns
-	ColumnDetails []DDLColumn // Column metadata (CREATE TABLE)
-	Flags         []string    // IF_EXISTS, CONCURRENTLY, CASCADE, etc.
-	IndexType     string      // btree, gin, gist, hash (CREATE INDEX only)
+	Type            DDLActionType
+	ObjectName      string      // Unqualified table/index/object name
+	Schema          string      // Optional schema qualifier
+	Columns         []string    // Affected columns
+	ColumnDetails   []DDLColumn // Column metadata (CREATE TABLE, ADD COLUMN, ALTER COLUMN)
+	Flags           []string    // IF_EXISTS, CONCURRENTLY, CASCADE, etc.
+	IndexType       string      // btree, gin, gist, hash (CREATE INDEX only)
+	ObjectKind      DDLObjectKind
+	Table           string             // Owning table for objects declared ON a table (index, constraint, trigger, policy, rule)
+	Owner           string             // AUTHORIZATION role (CREATE SCHEMA) or new owner (OWNER TO)
+	Comment         string             // Comment text (COMMENT ON); empty with NULL_COMMENT flag for IS NULL
+	Server          string             // Foreign server (CREATE FOREIGN TABLE, IMPORT FOREIGN SCHEMA, CREATE USER MAPPING)
+	Wrapper         string             // Foreign data wrapper (CREATE SERVER)
+	RemoteSchema    string             // Remote schema (IMPORT FOREIGN SCHEMA)
+	Connection      string             // Connection string with passwords redacted (CREATE SUBSCRIPTION)
+	Objects         []string           // Imported tables, subscribed publications, or published schemas
+	Options         []DDLOption        // OPTIONS (...) / WITH (...) entries
+	PublishedTables []PublicationTable // FOR TABLE entries (CREATE PUBLICATION)
+	LikeTables      []TableLike        // LIKE clauses (CREATE TABLE)
+	NewSchema       string             // Target schema of ALTER ... SET SCHEMA
+	NewName         string             // New name of a RENAME
+	Constraints     []DDLConstraint    // Constraints declared or targeted by the action
+	EnumValues      []string           // Labels of CREATE TYPE ... AS ENUM or ALTER TYPE ... ADD VALUE
+	DataType        string             // Underlying type of CREATE DOMAIN or return type of CREATE FUNCTION
+	Tablespace      string             // Target tablespace of ALTER ... SET TABLESPACE
+	Signature       string             // Argument types of a function, procedure, aggregate, or operator, e.g. "(integer, text)"
+	IncludeColumns  []string           // INCLUDE columns of CREATE INDEX
+	Predicate       string             // WHERE predicate of a partial CREATE INDEX
 }
 
 // SubqueryRef records metadata for subqueries discovered in FROM or set operations.
@@ -136,6 +280,43 @@ type SubqueryRef struct {
 	Query *ParsedQuery
 }
 
+// SubqueryKind identifies where a subquery appears.
+type SubqueryKind string
+
+const (
+	// SubqueryDerived is a subquery in FROM, or the source of MERGE ... USING.
+	SubqueryDerived SubqueryKind = "derived"
+	// SubqueryLateral is a LATERAL subquery in FROM.
+	SubqueryLateral SubqueryKind = "lateral"
+	// SubqueryIn is the list of x [NOT] IN (SELECT ...).
+	SubqueryIn SubqueryKind = "in"
+	// SubqueryExists is the operand of EXISTS (SELECT ...).
+	SubqueryExists SubqueryKind = "exists"
+	// SubqueryQuantified is the operand of x op ANY, SOME, or ALL (SELECT ...).
+	SubqueryQuantified SubqueryKind = "quantified"
+	// SubqueryArray is the operand of ARRAY(SELECT ...).
+	SubqueryArray SubqueryKind = "array"
+	// SubqueryScalar is a subquery used as a value, as in a SELECT list or comparison.
+	SubqueryScalar SubqueryKind = "scalar"
+)
+
+// NestedSubquery is a subquery anywhere in a statement, in FROM or in an expression.
+type NestedSubquery struct {
+	Kind  SubqueryKind
+	Alias string // Alias of a derived or LATERAL subquery
+	// Query is the subquery's own IR, or nil if it could not be parsed. Like a statement's
+	// IR, its Tables leave out the tables of the expression subqueries inside it. It has
+	// no Scopes, NestedSubqueries, or Correlations: the subqueries nested in it are
+	// entries of the statement's NestedSubqueries whose Parent is this one.
+	Query    *ParsedQuery
+	Scope    int // Index of the subquery's scope in Scopes
+	Parent   int // Index in NestedSubqueries of the innermost subquery containing this one, or -1
+	Position int // Character offset in RawSQL of the opening parenthesis
+	// Correlations indexes the Correlations entries of column references inside the
+	// subquery to relations outside it.
+	Correlations []int
+}
+
 // OrderExpression describes ORDER BY items.
 type OrderExpression struct {
 	Expression string
@@ -157,11 +338,39 @@ type Parameter struct {
 	Position int    // Parsed index for $n, or sequential order for '?'
 }
 
+// FunctionCall is a call to a named function, such as lower(email) or pg_catalog.nextval('s').
+type FunctionCall struct {
+	Schema   string   // Schema qualifier as written, or ""
+	Name     string   // Function name as written
+	Args     []string // Argument expressions as written; "*" for count(*)
+	Position int      // Character offset of the call in RawSQL
+}
+
+// LockingClause is a FOR UPDATE, FOR NO KEY UPDATE, FOR SHARE, or FOR KEY SHARE clause.
+type LockingClause struct {
+	Strength string   // "UPDATE", "NO KEY UPDATE", "SHARE", or "KEY SHARE"
+	Tables   []string // OF relations as written; empty when every FROM relation is locked
+	Wait     string   // "NOWAIT", "SKIP LOCKED", or "" to wait
+	Position int      // Character offset of the clause in RawSQL
+}
+
 // CTE describes a common table expression defined in a WITH clause.
 type CTE struct {
 	Name         string
 	Query        string
-	Materialized string // "", "MATERIALIZED", or "NOT MATERIALIZED"
+	Materialized string   // "", "MATERIALIZED", or "NOT MATERIALIZED"
+	Columns      []string // Column names of WITH name (a, b) AS (...)
+	Recursive    bool     // Declared in WITH RECURSIVE
+	Modifying    bool     // The body is an INSERT, UPDATE, or DELETE
+	// Body is Query parsed on its own, so its offsets are relative to Query. References
+	// to the CTEs of the same WITH clause that the body can see have Type TableTypeCTE.
+	// Nil if the body could not be parsed.
+	Body *ParsedQuery
+	// Anchor and RecursiveTerm split the body of a recursive CTE, a UNION whose last
+	// branch refers to the CTE itself: RecursiveTerm is that branch and Anchor the
+	// branches before it. Both are parsed like Body, and nil for other CTEs.
+	Anchor        *ParsedQuery
+	RecursiveTerm *ParsedQuery
 }
 
 // ColumnUsageType defines the context where a column is referenced.
@@ -206,6 +415,67 @@ type ColumnUsage struct {
 	Operator   string
 	Side       string
 	Functions  []string
+	Position   int // Character offset of the reference in the RawSQL of the parsed statement
+}
+
+// ScopeKind identifies the construct that introduces a QueryScope.
+type ScopeKind string
+
+const (
+	// ScopeStatement is the statement itself: the outermost SELECT, or an INSERT,
+	// UPDATE, DELETE, or MERGE whose target table is the first relation. CREATE TABLE
+	// AS and CREATE VIEW have a statement scope without relations.
+	ScopeStatement ScopeKind = "statement"
+	// ScopeCTE is the body of a WITH query.
+	ScopeCTE ScopeKind = "cte"
+	// ScopeDerived is a subquery in FROM.
+	ScopeDerived ScopeKind = "derived"
+	// ScopeLateral is a LATERAL subquery in FROM; it sees the relations listed before it.
+	ScopeLateral ScopeKind = "lateral"
+	// ScopeSubquery is a subquery inside an expression (IN, EXISTS, ARRAY, or scalar);
+	// it sees every relation of the enclosing scopes.
+	ScopeSubquery ScopeKind = "subquery"
+	// ScopeSetOperation is a UNION, INTERSECT, or EXCEPT branch other than the first,
+	// which shares the scope of its query.
+	ScopeSetOperation ScopeKind = "set_operation"
+	// ScopeSource is the query feeding INSERT ... SELECT, CREATE TABLE AS, or a view.
+	ScopeSource ScopeKind = "source"
+)
+
+// QueryScope is one query level of a statement and the relations its column references
+// resolve against. A ColumnUsage belongs to the innermost scope whose Start-End range
+// contains its Position. Only the derived, CTE, set-operation, and source scopes hide
+// the relations of their parent; references still reach the scopes above it.
+type QueryScope struct {
+	Kind          ScopeKind
+	Parent        int             // Index of the enclosing scope, or -1 for the statement scope
+	Start         int             // Character offset in RawSQL where the scope starts
+	End           int             // Character offset in RawSQL just past the scope
+	Name          string          // CTE name or derived-table alias
+	ColumnAliases []string        // Column names of WITH name (a, b) AS (...) or of a derived table's alias
+	Relations     []ScopeRelation // FROM-clause relations, in FROM order
+	Joins         []ScopeJoin     // Joins between Relations, in the order they are evaluated
+	Columns       []SelectColumn  // Output columns: the SELECT list, RETURNING of a DML statement, or column1, column2, ... of VALUES
+}
+
+// ScopeRelation is a relation visible to the column references of a scope.
+type ScopeRelation struct {
+	Table         TableRef
+	Scope         int      // Scope of the CTE body or subquery that produces the relation, or -1
+	ColumnAliases []string // Column names given with the alias, as in AS t (a, b)
+}
+
+// ScopeJoin is a JOIN between relations of a scope. Left and Right index the scope's
+// Relations; Left holds every relation joined before the right-hand side.
+type ScopeJoin struct {
+	// Type is a plain string by design, matching SetOperation.Type. Values: "INNER",
+	// "LEFT", "RIGHT", "FULL", or "CROSS".
+	Type     string
+	Natural  bool
+	Using    []string // Column names of a USING (...) join
+	Left     []int
+	Right    []int
+	Position int // Character offset in RawSQL of the ON or USING qualifier; 0 for CROSS and NATURAL joins
 }
 
 // ParsedQuery is the intermediate representation returned by ParseSQL.
@@ -225,12 +495,21 @@ type ParsedQuery struct {
 	Limit          *LimitClause
 	JoinConditions []string
 	Parameters     []Parameter
+	Functions      []FunctionCall  // Named function calls anywhere in the statement, in order; set on the ParsedQuery returned by ParseSQL only
+	Locking        []LockingClause // Row-locking clauses anywhere in the statement, in order; set on the ParsedQuery returned by ParseSQL only
 	InsertColumns  []string
 	SetClauses     []string
 	Returning      []string
 	Upsert         *UpsertClause
 	Merge          *MergeClause
 	DDLActions     []DDLAction
+	Target         *TableRef         // Relation written by INSERT, CREATE TABLE AS, SELECT INTO, or CREATE MATERIALIZED VIEW
+	Source         *ParsedQuery      // Query whose rows populate Target (INSERT ... SELECT, CREATE TABLE AS, SELECT INTO), or the defining query of a view
 	Correlations   []JoinCorrelation // Join correlations for LATERAL and correlated subqueries
 	DerivedColumns map[string]string // Alias -> expression mappings (e.g., "order_count" -> "COUNT(*)")
+	Scopes         []QueryScope      // Query levels of the statement; set on the ParsedQuery returned by ParseSQL only
+
+	// NestedSubqueries lists every subquery of the statement at any depth, in order
+	// of position; set on the ParsedQuery returned by ParseSQL only.
+	NestedSubqueries []NestedSubquery
 }
@@ -0,0 +1,214 @@
+// Package lint checks SQL statements against a set of rules, such as DELETE without
+// WHERE or comparisons to NULL with =, and reports the findings with their positions.
+//
+// Rules operate on the ParsedQuery of each statement. DefaultRules returns the
+// built-in rules; a Linter can run any other Rule, and change the severity of a rule
+// or turn it off. A comment containing lint:ignore suppresses findings:
+//
+//	SELECT * FROM audit_log; -- lint:ignore select-star, missing-limit
+//
+//	-- lint:ignore
+//	DELETE FROM sessions;
+//
+// A comment suppresses the rules it names, or every rule when it names none, on its
+// own line, or on the next line with code when the comment is on a line of its own.
+package lint
+
+import (
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// Severity is how serious a finding is.
+type Severity string
+
+const (
+	SeverityError   Severity = "error"
+	SeverityWarning Severity = "warning"
+	SeverityInfo    Severity = "info"
+	// SeverityOff turns a rule off in Linter.Severity.
+	SeverityOff Severity = "off"
+)
+
+// Finding is a rule violation in a statement.
+type Finding struct {
+	Rule      string // Name of the rule
+	Severity  Severity
+	Message   string
+	Statement int // Index of the statement in the linted script; 0 for LintQuery
+	Line      int // 1-based line of Position in the linted text
+	Position  int // Character offset in the statement's RawSQL
+}
+
+// Rule checks a statement.
+type Rule interface {
+	// Name identifies the rule in Linter.Severity and lint:ignore comments, e.g. "select-star".
+	Name() string
+	// Description says what the rule reports and why.
+	Description() string
+	// DefaultSeverity is the severity of the rule's findings unless the Linter overrides it.
+	DefaultSeverity() Severity
+	// Check returns the violations in a statement, with Message and Position set; the
+	// Linter fills in the other fields.
+	Check(pq *postgresparser.ParsedQuery) []Finding
+}
+
+// NewRule returns a Rule that runs check.
+func NewRule(name, description string, severity Severity, check func(pq *postgresparser.ParsedQuery) []Finding) Rule {
+	return funcRule{name: name, description: description, severity: severity, check: check}
+}
+
+// funcRule is a Rule made by NewRule.
+type funcRule struct {
+	name        string
+	description string
+	severity    Severity
+	check       func(pq *postgresparser.ParsedQuery) []Finding
+}
+
+func (r funcRule) Name() string                                   { return r.name }
+func (r funcRule) Description() string                            { return r.description }
+func (r funcRule) DefaultSeverity() Severity                      { return r.severity }
+func (r funcRule) Check(pq *postgresparser.ParsedQuery) []Finding { return r.check(pq) }
+
+// Linter runs rules over SQL.
+type Linter struct {
+	Rules    []Rule              // Rules to run; nil means DefaultRules()
+	Severity map[string]Severity // Severity overrides by rule name; SeverityOff turns a rule off
+}
+
+// Lint checks every statement of sql with the default rules. See Linter.Lint.
+func Lint(sql string) ([]Finding, error) {
+	return Linter{}.Lint(sql)
+}
+
+// Lint parses every statement of sql and returns the findings of the rules, ordered by
+// statement and position. A statement that fails to parse is reported as a
+// *postgresparser.StatementError.
+func (l Linter) Lint(sql string) ([]Finding, error) {
+	stmts := postgresparser.SplitStatements(sql)
+	if len(stmts) == 0 {
+		return nil, postgresparser.ErrNoStatements
+	}
+	ignores := parseIgnores(sql)
+	var out []Finding
+	for i, stmt := range stmts {
+		pq, err := postgresparser.ParseSQL(stmt.SQL)
+		if err != nil {
+			return nil, &postgresparser.StatementError{Line: stmt.Line, SQL: stmt.SQL, Err: err}
+		}
+		for _, f := range l.run(pq) {
+			f.Statement = i
+			f.Line = stmt.Line + lineOf(pq.RawSQL, f.Position) - 1
+			if !ignores.suppressed(f) {
+				out = append(out, f)
+			}
+		}
+	}
+	return out, nil
+}
+
+// LintQuery returns the findings of the rules in a parsed statement, ordered by
+// position. Lines are counted from the start of its RawSQL, whose lint:ignore comments
+// apply.
+func (l Linter) LintQuery(pq *postgresparser.ParsedQuery) []Finding {
+	if pq == nil {
+		return nil
+	}
+	ignores := parseIgnores(pq.RawSQL)
+	var out []Finding
+	for _, f := range l.run(pq) {
+		f.Line = lineOf(pq.RawSQL, f.Position)
+		if !ignores.suppressed(f) {
+			out = append(out, f)
+		}
+	}
+	return out
+}
+
+// run applies the enabled rules to pq.
+func (l Linter) run(pq *postgresparser.ParsedQuery) []Finding {
+	rules := l.Rules
+	if rules == nil {
+		rules = DefaultRules()
+	}
+	var out []Finding
+	for _, r := range rules {
+		severity := r.DefaultSeverity()
+		if s, ok := l.Severity[r.Name()]; ok {
+			severity = s
+		}
+		if severity == SeverityOff {
+			continue
+		}
+		for _, f := range r.Check(pq) {
+			f.Rule = r.Name()
+			f.Severity = severity
+			out = append(out, f)
+		}
+	}
+	sort.SliceStable(out, func(i, j int) bool { return out[i].Position < out[j].Position })
+	return out
+}
+
+// lineOf returns the 1-based line of the character offset pos in text.
+func lineOf(text string, pos int) int {
+	runes := []rune(text)
+	pos = min(max(pos, 0), len(runes))
+	return 1 + strings.Count(string(runes[:pos]), "\n")
+}
+
+// ignoreDirective marks lint:ignore comments.
+const ignoreDirective = "lint:ignore"
+
+// ignores maps a line to the rules suppressed on it; "*" stands for every rule.
+type ignores map[int][]string
+
+// parseIgnores finds the lint:ignore comments of text and the lines they apply to.
+func parseIgnores(text string) ignores {
+	comments := sqllex.Comments(text)
+	if len(comments) == 0 {
+		return nil
+	}
+	codeLines := make(map[int]bool)
+	var lines []int
+	for _, t := range sqllex.Lex(text) {
+		if line := lineOf(text, t.Pos); !codeLines[line] {
+			codeLines[line] = true
+			lines = append(lines, line)
+		}
+	}
+	out := make(ignores)
+	for _, c := range comments {
+		idx := strings.Index(c.Text, ignoreDirective)
+		if idx < 0 {
+			continue
+		}
+		body := strings.TrimSuffix(strings.TrimSpace(c.Text[idx+len(ignoreDirective):]), "*/")
+		rules := strings.FieldsFunc(body, func(r rune) bool { return r == ',' || r == ' ' || r == '\t' || r == '\n' })
+		line := lineOf(text, c.Pos)
+		if !codeLines[line] {
+			// A comment on its own lines applies to the next line with code.
+			end := line + strings.Count(c.Text, "\n")
+			next := slices.IndexFunc(lines, func(l int) bool { return l > end })
+			if next < 0 {
+				continue
+			}
+			line = lines[next]
+		}
+		if len(rules) == 0 {
+			rules = []string{"*"}
+		}
+		out[line] = append(out[line], rules...)
+	}
+	return out
+}
+
+// suppressed reports whether a lint:ignore comment covers f.
+func (ig ignores) suppressed(f Finding) bool {
+	return slices.Contains(ig[f.Line], "*") || slices.Contains(ig[f.Line], f.Rule)
+}
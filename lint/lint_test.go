@@ -0,0 +1,185 @@
+package lint
+
+import (
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/valkdb/postgresparser"
+)
+
+// findings renders findings as "rule@position".
+func findings(list []Finding) []string {
+	var out []string
+	for _, f := range list {
+		out = append(out, f.Rule+"@"+strconv.Itoa(f.Position))
+	}
+	return out
+}
+
+func TestDefaultRules(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want []string
+	}{
+		{name: "clean query", sql: "SELECT id, email FROM users WHERE id = $1"},
+		{name: "DELETE without WHERE", sql: "DELETE FROM orders", want: []string{"delete-without-where@0"}},
+		{name: "DELETE with WHERE", sql: "DELETE FROM orders WHERE id IN (SELECT id FROM stale)"},
+		{name: "UPDATE without WHERE", sql: "UPDATE users SET active = false", want: []string{"update-without-where@0"}},
+		{
+			name: "data-modifying CTEs",
+			sql:  "WITH d AS (DELETE FROM orders RETURNING id), u AS (UPDATE users SET n = 1 WHERE id = 2 RETURNING id) SELECT id FROM d WHERE id > 0",
+			want: []string{"delete-without-where@11"},
+		},
+		{
+			name: "comparisons to NULL, not assignments",
+			sql:  "UPDATE users SET deleted_at = NULL, note = NULL WHERE deleted_at = NULL OR NULL <> note",
+			want: []string{"null-comparison@65", "null-comparison@80"},
+		},
+		{
+			name: "SELECT star",
+			sql:  "SELECT *, u.* FROM users u WHERE EXISTS (SELECT * FROM bans b WHERE b.user_id = u.id) AND u.id = 1",
+			want: []string{"select-star@7", "select-star@12"},
+		},
+		{name: "count star", sql: "SELECT count(*) FROM users"},
+		{
+			name: "implicit cross join",
+			sql:  "SELECT a.id FROM accounts a, plans p, regions r WHERE a.plan_id = p.id AND r.code = 'eu'",
+			want: []string{"implicit-cross-join@36"},
+		},
+		{name: "comma join with condition", sql: "SELECT a.id FROM accounts a, plans p WHERE p.id = a.plan_id AND a.id = 1"},
+		{name: "comma join with unqualified columns", sql: "SELECT 1 FROM accounts, plans WHERE plan_id = plans_id"},
+		{name: "explicit cross join", sql: "SELECT 1 FROM accounts CROSS JOIN plans WHERE true"},
+		{name: "function in FROM", sql: "SELECT t.id, x FROM tags t, unnest(t.labels) x WHERE t.id = 1"},
+		{
+			name: "NOT IN subquery",
+			sql:  "SELECT id FROM users WHERE id NOT IN (SELECT user_id FROM bans) AND id NOT IN (1, 2)",
+			want: []string{"not-in-subquery@30"},
+		},
+		{
+			name: "ORDER BY random",
+			sql:  "SELECT id FROM users WHERE active ORDER BY random() LIMIT 5",
+			want: []string{"order-by-random@43"},
+		},
+		{
+			name: "LIKE with a leading wildcard",
+			sql:  "SELECT id FROM users WHERE email ILIKE '%@example.com' OR name LIKE 'Jo%' OR code LIKE E'_x'",
+			want: []string{"like-leading-wildcard@33", "like-leading-wildcard@82"},
+		},
+		{
+			name: "OFFSET pagination",
+			sql:  "SELECT id FROM users WHERE active ORDER BY id LIMIT 20 OFFSET $1",
+			want: []string{"offset-pagination@55"},
+		},
+		{name: "OFFSET 0", sql: "SELECT id FROM users WHERE active LIMIT 20 OFFSET 0"},
+		{name: "missing LIMIT", sql: "SELECT id, email FROM users", want: []string{"missing-limit@0"}},
+		{name: "aggregate needs no LIMIT", sql: "SELECT count(*), max(id) FROM users"},
+		{name: "window is not an aggregate", sql: "SELECT count(*) OVER () FROM users", want: []string{"missing-limit@0"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lint(tt.sql)
+			require.NoError(t, err, "lint failed")
+			assert.Equal(t, tt.want, findings(got))
+		})
+	}
+}
+
+func TestDefaultRules_Findings(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want Finding
+	}{
+		{
+			name: "comparison to NULL",
+			sql:  "UPDATE users SET deleted_at = NULL WHERE deleted_at = NULL",
+			want: Finding{Rule: "null-comparison", Severity: SeverityError, Message: "comparison with = NULL is never true; use IS NULL", Line: 1, Position: 52},
+		},
+		{
+			name: "implicit cross join",
+			sql:  "SELECT a.id FROM accounts a, plans p, regions r WHERE a.plan_id = p.id AND r.code = 'eu'",
+			want: Finding{Rule: "implicit-cross-join", Severity: SeverityWarning, Message: "regions is cross joined: no WHERE condition joins it to accounts; add a join condition or write CROSS JOIN", Line: 1, Position: 36},
+		},
+		{
+			name: "NOT IN subquery",
+			sql:  "SELECT id FROM users WHERE id NOT IN (SELECT user_id FROM bans) LIMIT 1",
+			want: Finding{Rule: "not-in-subquery", Severity: SeverityWarning, Message: "NOT IN (SELECT ...) matches no row if the subquery returns a NULL; use NOT EXISTS", Line: 1, Position: 30},
+		},
+		{
+			name: "LIKE with a leading wildcard",
+			sql:  "SELECT id FROM users WHERE email ILIKE '%@example.com' LIMIT 5",
+			want: Finding{Rule: "like-leading-wildcard", Severity: SeverityWarning, Message: "ILIKE pattern '%@example.com' starts with a wildcard and cannot use a B-tree index", Line: 1, Position: 33},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Lint(tt.sql)
+			require.NoError(t, err, "lint failed")
+			assert.Equal(t, []Finding{tt.want}, got)
+		})
+	}
+}
+
+func TestLint_Script(t *testing.T) {
+	got, err := Lint("SELECT id FROM users LIMIT 1;\n\nDELETE FROM sessions;\nUPDATE users\n   SET email = NULL\n WHERE email = NULL;")
+	require.NoError(t, err, "lint failed")
+	require.Len(t, got, 2, "findings")
+	assert.Equal(t, Finding{Rule: "delete-without-where", Severity: SeverityError, Message: "DELETE without WHERE removes every row of sessions", Statement: 1, Line: 3, Position: 0}, got[0])
+	assert.Equal(t, "null-comparison", got[1].Rule, "rule")
+	assert.Equal(t, 2, got[1].Statement, "statement")
+	assert.Equal(t, 6, got[1].Line, "line")
+
+	_, err = Lint("SELECT 1;\nSELEC 2;")
+	var stmtErr *postgresparser.StatementError
+	require.ErrorAs(t, err, &stmtErr, "parse error")
+	assert.Equal(t, 2, stmtErr.Line, "line of the failing statement")
+}
+
+func TestLint_Ignore(t *testing.T) {
+	got, err := Lint(`-- lint:ignore missing-limit
+SELECT * FROM audit_log;
+SELECT * FROM events; -- lint:ignore select-star, missing-limit
+/* lint:ignore */
+DELETE FROM sessions;
+SELECT id
+  FROM a, b -- lint:ignore missing-limit
+ LIMIT 1;`)
+	require.NoError(t, err, "lint failed")
+	var rules []string
+	for _, f := range got {
+		rules = append(rules, f.Rule+"@"+strconv.Itoa(f.Line))
+	}
+	assert.Equal(t, []string{"select-star@2", "implicit-cross-join@7"}, rules, "unsuppressed findings")
+}
+
+func TestLinter(t *testing.T) {
+	noFloats := NewRule("no-float", "Columns should not be float.", SeverityWarning, func(pq *postgresparser.ParsedQuery) []Finding {
+		var out []Finding
+		for _, a := range pq.DDLActions {
+			for _, c := range a.ColumnDetails {
+				if c.Type == "float" {
+					out = append(out, Finding{Message: "column " + c.Name + " is float"})
+				}
+			}
+		}
+		return out
+	})
+	l := Linter{
+		Rules:    append(DefaultRules(), noFloats),
+		Severity: map[string]Severity{"missing-limit": SeverityOff, "select-star": SeverityError},
+	}
+
+	pq, err := postgresparser.ParseSQL("SELECT * FROM t")
+	require.NoError(t, err, "parse failed")
+	got := l.LintQuery(pq)
+	require.Len(t, got, 1, "missing-limit is off")
+	assert.Equal(t, SeverityError, got[0].Severity, "overridden severity")
+
+	got, err = l.Lint("CREATE TABLE m (id int, v float)")
+	require.NoError(t, err, "lint failed")
+	assert.Equal(t, []Finding{{Rule: "no-float", Severity: SeverityWarning, Message: "column v is float", Line: 1}}, got, "custom rule")
+}
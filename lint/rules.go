@@ -0,0 +1,548 @@
+// rules.go holds the built-in rules.
+package lint
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser"
+	"github.com/valkdb/postgresparser/internal/sqllex"
+)
+
+// DefaultRules returns the built-in rules:
+//
+//   - delete-without-where (error): DELETE that removes every row
+//   - update-without-where (error): UPDATE that changes every row
+//   - null-comparison (error): = NULL or <> NULL, which is never true
+//   - select-star (warning): SELECT * or alias.* outside EXISTS and other expression subqueries
+//   - implicit-cross-join (warning): comma-separated FROM items no WHERE condition joins
+//   - not-in-subquery (warning): NOT IN (SELECT ...), which matches nothing once the subquery returns a NULL
+//   - order-by-random (warning): ORDER BY random(), which sorts every row
+//   - like-leading-wildcard (warning): LIKE or ILIKE patterns starting with % or _, which cannot use a B-tree index
+//   - offset-pagination (info): OFFSET other than 0, which reads and discards the skipped rows
+//   - missing-limit (info): SELECT from a table without WHERE, GROUP BY, LIMIT, or aggregates
+func DefaultRules() []Rule {
+	return []Rule{
+		NewRule("delete-without-where", "DELETE without WHERE removes every row of its table.", SeverityError, checkDeleteWithoutWhere),
+		NewRule("update-without-where", "UPDATE without WHERE changes every row of its table.", SeverityError, checkUpdateWithoutWhere),
+		NewRule("null-comparison", "Comparing to NULL with = or <> yields NULL, never true; use IS NULL or IS NOT NULL.", SeverityError, checkNullComparison),
+		NewRule("select-star", "SELECT * returns columns the query may not need and changes shape when the table does.", SeverityWarning, checkSelectStar),
+		NewRule("implicit-cross-join", "Comma-separated FROM items without a joining WHERE condition form a cross join.", SeverityWarning, checkImplicitCrossJoin),
+		NewRule("not-in-subquery", "NOT IN (SELECT ...) matches no row once the subquery returns a NULL; use NOT EXISTS.", SeverityWarning, checkNotInSubquery),
+		NewRule("order-by-random", "ORDER BY random() reads and sorts every row to return a sample.", SeverityWarning, checkOrderByRandom),
+		NewRule("like-leading-wildcard", "A LIKE pattern starting with a wildcard cannot use a B-tree index.", SeverityWarning, checkLikeLeadingWildcard),
+		NewRule("offset-pagination", "OFFSET reads and discards every skipped row; paginate with a keyset condition instead.", SeverityInfo, checkOffsetPagination),
+		NewRule("missing-limit", "A SELECT without WHERE, GROUP BY, or LIMIT returns every row of its tables.", SeverityInfo, checkMissingLimit),
+	}
+}
+
+// checkDeleteWithoutWhere reports DELETE statements and DELETE CTEs without WHERE.
+func checkDeleteWithoutWhere(pq *postgresparser.ParsedQuery) []Finding {
+	return writesWithoutWhere(pq, postgresparser.QueryCommandDelete, "removes every row of")
+}
+
+// checkUpdateWithoutWhere reports UPDATE statements and UPDATE CTEs without WHERE.
+func checkUpdateWithoutWhere(pq *postgresparser.ParsedQuery) []Finding {
+	return writesWithoutWhere(pq, postgresparser.QueryCommandUpdate, "changes every row of")
+}
+
+// writesWithoutWhere reports the statement or CTE bodies of the given command that
+// have no WHERE clause.
+func writesWithoutWhere(pq *postgresparser.ParsedQuery, cmd postgresparser.QueryCommand, effect string) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i, sc := range pq.Scopes {
+		if sc.Kind != postgresparser.ScopeCTE && (sc.Kind != postgresparser.ScopeStatement || pq.Command != cmd) {
+			continue
+		}
+		level := scopeTokens(toks, pq.Scopes, i)
+		start := -1
+		for k, t := range level {
+			if t.depth == 0 && t.Is(string(cmd)) {
+				start = k
+				break
+			}
+			if sc.Kind == postgresparser.ScopeCTE {
+				break // A CTE body must start with the command
+			}
+		}
+		if start < 0 || indexKeyword(level, start, "WHERE") >= 0 {
+			continue
+		}
+		target := "its table"
+		if len(sc.Relations) > 0 {
+			target = relationName(sc.Relations[0].Table)
+		}
+		out = append(out, Finding{
+			Message:  fmt.Sprintf("%s without WHERE %s %s", cmd, effect, target),
+			Position: level[start].Pos,
+		})
+	}
+	return out
+}
+
+// checkNullComparison reports = NULL, <> NULL, and != NULL outside SET assignments.
+func checkNullComparison(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	depth, setDepth := 0, -1
+	for i, t := range toks {
+		switch {
+		case t.Is("("):
+			depth++
+		case t.Is(")"):
+			depth--
+			if depth < setDepth {
+				setDepth = -1
+			}
+		case t.Is(";"):
+			setDepth = -1
+		case t.Is("SET"):
+			setDepth = depth
+		case depth == setDepth && (t.Is("FROM") || t.Is("WHERE") || t.Is("RETURNING")):
+			setDepth = -1
+		case t.Is("=") || t.Is("<>") || t.Is("!="):
+			if depth == setDepth {
+				continue // An assignment
+			}
+			nullBefore := i > 0 && toks[i-1].Is("NULL")
+			nullAfter := i+1 < len(toks) && toks[i+1].Is("NULL")
+			if !nullBefore && !nullAfter {
+				continue
+			}
+			fix := "IS NULL"
+			if !t.Is("=") {
+				fix = "IS NOT NULL"
+			}
+			out = append(out, Finding{
+				Message:  fmt.Sprintf("comparison with %s NULL is never true; use %s", t.Text, fix),
+				Position: t.Pos,
+			})
+		}
+	}
+	return out
+}
+
+// selectListEnd are the keywords that end a select list.
+var selectListEnd = []string{"FROM", "INTO", "WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT"}
+
+// checkSelectStar reports * and alias.* in the select lists of queries, except in
+// expression subqueries such as EXISTS (SELECT * ...).
+func checkSelectStar(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i, sc := range pq.Scopes {
+		if sc.Kind == postgresparser.ScopeSubquery {
+			continue
+		}
+		level := scopeTokens(toks, pq.Scopes, i)
+		start := indexKeyword(level, 0, "SELECT")
+		if start < 0 {
+			continue
+		}
+		for k := start + 1; k < len(level); k++ {
+			t := level[k]
+			if t.depth != 0 {
+				continue
+			}
+			if t.IsKeyword(selectListEnd...) {
+				break
+			}
+			prev := level[k-1]
+			if t.Is("*") && (prev.Is("SELECT") || prev.Is("DISTINCT") || prev.Is("ALL") || prev.Is(",") || prev.Is(".") || prev.Is(")")) {
+				msg := "SELECT * returns every column; list the columns the query needs"
+				if prev.Is(".") && k >= 2 {
+					msg = fmt.Sprintf("%s.* returns every column of %s; list the columns the query needs", level[k-2].Text, level[k-2].Text)
+				}
+				out = append(out, Finding{Message: msg, Position: t.Pos})
+			}
+		}
+	}
+	return out
+}
+
+// fromClauseEnd are the keywords that end a FROM clause.
+var fromClauseEnd = []string{"WHERE", "GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "RETURNING"}
+
+// whereClauseEnd are the keywords that end a WHERE clause.
+var whereClauseEnd = []string{"GROUP", "HAVING", "WINDOW", "ORDER", "LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "RETURNING"}
+
+// checkImplicitCrossJoin reports comma-separated FROM items of a query that no WHERE
+// condition joins to the first item. FROM items containing a LATERAL subquery or a
+// function, which may refer to the items before them, count as joined.
+func checkImplicitCrossJoin(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i, sc := range pq.Scopes {
+		if len(sc.Relations) < 2 {
+			continue
+		}
+		level := scopeTokens(toks, pq.Scopes, i)
+		from := indexKeyword(level, 0, "SELECT")
+		if from >= 0 {
+			from = indexKeyword(level, from, "FROM")
+		}
+		if from < 0 {
+			continue
+		}
+
+		// The FROM items are the join trees of the relations, in FROM order.
+		items := sqllex.NewUnionFind(len(sc.Relations))
+		for _, j := range sc.Joins {
+			for _, l := range j.Left {
+				for _, r := range j.Right {
+					items.Union(l, r)
+				}
+			}
+		}
+		var roots []int // Root of each FROM item, in order
+		for r := range sc.Relations {
+			if root := items.Find(r); !slices.Contains(roots, root) {
+				roots = append(roots, root)
+			}
+		}
+		if len(roots) < 2 {
+			continue
+		}
+
+		// Commas separate the FROM items; WHERE conditions join them.
+		var commas []int
+		var where []levelToken
+		for k := from + 1; k < len(level); k++ {
+			t := level[k]
+			if t.depth == 0 && t.Is("WHERE") {
+				for k++; k < len(level) && (level[k].depth > 0 || !level[k].IsKeyword(whereClauseEnd...)); k++ {
+					where = append(where, level[k])
+				}
+				break
+			}
+			if t.depth == 0 && t.IsKeyword(fromClauseEnd...) {
+				break
+			}
+			if t.depth == 0 && t.Is(",") {
+				commas = append(commas, t.Pos)
+			}
+		}
+		for r, rel := range sc.Relations {
+			if rel.Table.Type == postgresparser.TableTypeFunction || rel.Scope >= 0 && pq.Scopes[rel.Scope].Kind == postgresparser.ScopeLateral {
+				items.Union(r, roots[0])
+			}
+		}
+		for _, cond := range splitConjuncts(where) {
+			refs, unqualified := conditionRelations(cond, sc.Relations)
+			if unqualified > 0 && len(refs)+unqualified >= 2 {
+				// Unqualified columns could belong to any relation; assume they join.
+				refs = refs[:0]
+				for r := range sc.Relations {
+					refs = append(refs, r)
+				}
+			}
+			for _, r := range refs[min(1, len(refs)):] {
+				items.Union(refs[0], r)
+			}
+		}
+		for n, root := range roots[1:] {
+			if items.Find(root) == items.Find(roots[0]) || n >= len(commas) {
+				continue
+			}
+			out = append(out, Finding{
+				Message:  fmt.Sprintf("%s is cross joined: no WHERE condition joins it to %s; add a join condition or write CROSS JOIN", relationName(sc.Relations[root].Table), relationName(sc.Relations[roots[0]].Table)),
+				Position: commas[n],
+			})
+		}
+	}
+	return out
+}
+
+// conditionKeywords are the words of a condition that are not column references.
+var conditionKeywords = map[string]bool{
+	"and": true, "or": true, "not": true, "is": true, "null": true, "true": true, "false": true, "unknown": true,
+	"in": true, "like": true, "ilike": true, "similar": true, "to": true, "escape": true, "between": true, "symmetric": true,
+	"exists": true, "any": true, "all": true, "some": true, "case": true, "when": true, "then": true, "else": true, "end": true,
+	"as": true, "cast": true, "distinct": true, "from": true, "interval": true, "collate": true, "at": true, "time": true, "zone": true,
+	"array": true, "row": true, "overlaps": true, "isnull": true, "notnull": true, "current_date": true, "current_time": true,
+	"current_timestamp": true, "localtime": true, "localtimestamp": true, "current_user": true, "session_user": true, "user": true,
+}
+
+// conditionRelations returns the relations a condition refers to by qualifier, and
+// the number of its unqualified column references.
+func conditionRelations(cond []levelToken, rels []postgresparser.ScopeRelation) ([]int, int) {
+	var refs []int
+	unqualified := 0
+	for k, t := range cond {
+		if t.Kind != sqllex.Ident && t.Kind != sqllex.Quoted {
+			continue
+		}
+		if k > 0 && (cond[k-1].Is(".") || cond[k-1].Is("::")) {
+			continue
+		}
+		next := func(n int) levelToken {
+			if k+n < len(cond) {
+				return cond[k+n]
+			}
+			return levelToken{}
+		}
+		switch {
+		case next(1).Is("("), next(1).Kind == sqllex.String:
+			continue // A function call or a typed literal
+		case next(1).Is(".") && next(3).Is("."):
+			continue // A schema; the relation follows it
+		case next(1).Is("."):
+			name := unquote(t)
+			for r, rel := range rels {
+				if strings.EqualFold(name, sqllex.RelationQualifier(rel.Table)) && !slices.Contains(refs, r) {
+					refs = append(refs, r)
+				}
+			}
+		case t.Kind == sqllex.Quoted || !conditionKeywords[strings.ToLower(t.Text)]:
+			unqualified++
+		}
+	}
+	return refs, unqualified
+}
+
+// splitConjuncts splits condition tokens on the ANDs outside parentheses.
+func splitConjuncts(toks []levelToken) [][]levelToken {
+	if len(toks) == 0 {
+		return nil
+	}
+	base := toks[0].depth
+	var out [][]levelToken
+	var cur []levelToken
+	for _, t := range toks {
+		if t.depth == base && t.Is("AND") {
+			out = append(out, cur)
+			cur = nil
+			continue
+		}
+		cur = append(cur, t)
+	}
+	return append(out, cur)
+}
+
+// checkNotInSubquery reports NOT IN (SELECT ...).
+func checkNotInSubquery(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i := 0; i+3 < len(toks); i++ {
+		if toks[i].Is("NOT") && toks[i+1].Is("IN") && toks[i+2].Is("(") && (toks[i+3].Is("SELECT") || toks[i+3].Is("WITH")) {
+			out = append(out, Finding{
+				Message:  "NOT IN (SELECT ...) matches no row if the subquery returns a NULL; use NOT EXISTS",
+				Position: toks[i].Pos,
+			})
+		}
+	}
+	return out
+}
+
+// orderByEnd are the keywords that end an ORDER BY list.
+var orderByEnd = []string{"LIMIT", "OFFSET", "FETCH", "FOR", "UNION", "INTERSECT", "EXCEPT", "ROWS", "RANGE", "GROUPS"}
+
+// checkOrderByRandom reports random() in ORDER BY lists.
+func checkOrderByRandom(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i := 0; i+1 < len(toks); i++ {
+		if !toks[i].Is("ORDER") || !toks[i+1].Is("BY") {
+			continue
+		}
+		depth := 0
+		for k := i + 2; k < len(toks) && depth >= 0; k++ {
+			t := toks[k]
+			switch {
+			case t.Is("("):
+				depth++
+			case t.Is(")"):
+				depth--
+			case depth == 0 && (t.Is(";") || t.IsKeyword(orderByEnd...)):
+				depth = -1
+			case t.Is("random") && k+2 < len(toks) && toks[k+1].Is("(") && toks[k+2].Is(")"):
+				out = append(out, Finding{
+					Message:  "ORDER BY random() reads and sorts every row; sample with TABLESAMPLE or a random key instead",
+					Position: t.Pos,
+				})
+			}
+		}
+	}
+	return out
+}
+
+// checkLikeLeadingWildcard reports LIKE and ILIKE patterns starting with % or _.
+func checkLikeLeadingWildcard(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i := 0; i+1 < len(toks); i++ {
+		t := toks[i]
+		if !t.Is("LIKE") && !t.Is("ILIKE") && !t.Is("~~") && !t.Is("~~*") {
+			continue
+		}
+		if toks[i+1].Kind != sqllex.String {
+			continue
+		}
+		if pattern := stringValue(toks[i+1].Text); strings.HasPrefix(pattern, "%") || strings.HasPrefix(pattern, "_") {
+			out = append(out, Finding{
+				Message:  fmt.Sprintf("%s pattern %s starts with a wildcard and cannot use a B-tree index", strings.ToUpper(t.Text), toks[i+1].Text),
+				Position: t.Pos,
+			})
+		}
+	}
+	return out
+}
+
+// checkOffsetPagination reports OFFSET clauses other than OFFSET 0.
+func checkOffsetPagination(pq *postgresparser.ParsedQuery) []Finding {
+	toks := sqllex.Lex(pq.RawSQL)
+	var out []Finding
+	for i := 0; i+1 < len(toks); i++ {
+		if toks[i].Is("OFFSET") && !(toks[i+1].Kind == sqllex.Number && strings.Trim(toks[i+1].Text, "0") == "") {
+			out = append(out, Finding{
+				Message:  "OFFSET reads and discards every skipped row; paginate with a keyset condition such as WHERE id > $1",
+				Position: toks[i].Pos,
+			})
+		}
+	}
+	return out
+}
+
+// aggregateCallRe matches a call to a common aggregate function.
+var aggregateCallRe = regexp.MustCompile(`(?i)\b(count|sum|avg|min|max|bool_and|bool_or|every|array_agg|string_agg|json_agg|jsonb_agg|json_object_agg|jsonb_object_agg|percentile_cont|percentile_disc|mode|stddev|variance)\s*\(`)
+
+// checkMissingLimit reports a top-level SELECT that reads a table without WHERE,
+// GROUP BY, LIMIT, or FETCH and whose columns are not all aggregates.
+func checkMissingLimit(pq *postgresparser.ParsedQuery) []Finding {
+	if pq.Limit != nil && pq.Limit.Limit != "" && !strings.EqualFold(pq.Limit.Limit, "ALL") {
+		return nil
+	}
+	if pq.Command != postgresparser.QueryCommandSelect || pq.Target != nil || len(pq.Where) > 0 || len(pq.GroupBy) > 0 || len(pq.SetOperations) > 0 || len(pq.Scopes) == 0 {
+		return nil
+	}
+	var table string
+	for _, rel := range pq.Scopes[0].Relations {
+		if rel.Table.Type == postgresparser.TableTypeBase {
+			table = relationName(rel.Table)
+			break
+		}
+	}
+	if table == "" {
+		return nil
+	}
+	aggregate := len(pq.Columns) > 0
+	for _, c := range pq.Columns {
+		if !aggregateCallRe.MatchString(c.Expression) || strings.Contains(strings.ToUpper(c.Expression), "OVER") {
+			aggregate = false
+		}
+	}
+	if aggregate {
+		return nil
+	}
+	return []Finding{{Message: fmt.Sprintf("SELECT without WHERE or LIMIT returns every row of %s", table)}}
+}
+
+// levelToken is a token of a scope with its parenthesis depth within the scope.
+type levelToken struct {
+	sqllex.Token
+	depth int
+}
+
+// scopeTokens returns the tokens of scope i without those of its nested scopes and the
+// parentheses around it. Parentheses have the depth of the tokens around them.
+func scopeTokens(toks []sqllex.Token, scopes []postgresparser.QueryScope, i int) []levelToken {
+	sc := scopes[i]
+	var in []sqllex.Token
+	for _, t := range toks {
+		if t.Pos < sc.Start || t.Pos >= sc.End || inChildScope(scopes, i, t.Pos) {
+			continue
+		}
+		in = append(in, t)
+	}
+	for len(in) >= 2 && in[0].Is("(") && matchingParen(in) == len(in)-1 {
+		in = in[1 : len(in)-1]
+	}
+	out := make([]levelToken, 0, len(in))
+	depth := 0
+	for _, t := range in {
+		if t.Is(")") {
+			depth--
+		}
+		out = append(out, levelToken{Token: t, depth: depth})
+		if t.Is("(") {
+			depth++
+		}
+	}
+	return out
+}
+
+// inChildScope reports whether pos is inside a scope nested directly in scope i.
+func inChildScope(scopes []postgresparser.QueryScope, i, pos int) bool {
+	for _, sc := range scopes {
+		if sc.Parent == i && pos >= sc.Start && pos < sc.End {
+			return true
+		}
+	}
+	return false
+}
+
+// matchingParen returns the index of the parenthesis closing toks[0], or -1.
+func matchingParen(toks []sqllex.Token) int {
+	depth := 0
+	for k, t := range toks {
+		if t.Is("(") {
+			depth++
+		} else if t.Is(")") {
+			if depth--; depth == 0 {
+				return k
+			}
+		}
+	}
+	return -1
+}
+
+// indexKeyword returns the index of the first kw outside parentheses in toks[from:], or -1.
+func indexKeyword(toks []levelToken, from int, kw string) int {
+	for k := from; k < len(toks); k++ {
+		if toks[k].depth == 0 && toks[k].Is(kw) {
+			return k
+		}
+	}
+	return -1
+}
+
+// relationName returns the qualified name of a table reference, or its alias when it
+// has no name.
+func relationName(t postgresparser.TableRef) string {
+	switch {
+	case t.Type != postgresparser.TableTypeBase && t.Type != postgresparser.TableTypeCTE:
+		if t.Alias != "" {
+			return t.Alias
+		}
+		return t.Raw
+	case t.Schema != "":
+		return t.Schema + "." + t.Name
+	default:
+		return t.Name
+	}
+}
+
+// unquote returns an identifier token without quotes.
+func unquote(t levelToken) string {
+	if t.Kind == sqllex.Quoted {
+		return strings.ReplaceAll(strings.Trim(t.Text, `"`), `""`, `"`)
+	}
+	return t.Text
+}
+
+// stringValue returns the text of a string constant token without its quotes.
+func stringValue(text string) string {
+	if strings.HasPrefix(text, "$") {
+		if end := strings.Index(text[1:], "$"); end >= 0 {
+			tag := text[:end+2]
+			return strings.TrimSuffix(strings.TrimPrefix(text, tag), tag)
+		}
+	}
+	if i := strings.IndexByte(text, '\''); i >= 0 {
+		text = text[i+1:]
+	}
+	return strings.TrimSuffix(text, "'")
+}
@@ -1,7 +1,9 @@
 package postgresparser
 
 import (
+	"fmt"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
@@ -96,3 +98,104 @@ SELECT * FROM users_cte JOIN orders_cte ON users_cte.id = orders_cte.user_id`
 		assert.True(t, found, "Expected to find '%s' as a base table from inside CTEs", name)
 	}
 }
+
+// TestDataModifyingCTETables tests that the targets of INSERT, UPDATE, and DELETE CTEs are extracted
+func TestDataModifyingCTETables(t *testing.T) {
+	sql := `WITH moved AS (DELETE FROM orders WHERE created_at < NOW() RETURNING *),
+	touched AS (UPDATE app.customers SET archived = true WHERE id IN (SELECT customer_id FROM moved) RETURNING id)
+INSERT INTO orders_archive SELECT * FROM moved`
+
+	ir := parseAssertNoError(t, sql)
+
+	var base []string
+	for _, table := range ir.Tables {
+		if table.Type == TableTypeBase {
+			base = append(base, table.Schema+"."+table.Name)
+		}
+	}
+	assert.Contains(t, base, ".orders", "DELETE target")
+	assert.Contains(t, base, "app.customers", "UPDATE target")
+	assert.Contains(t, base, ".orders_archive", "INSERT target")
+}
+
+// cteTableNames renders the tables of a parsed CTE body as "name:type".
+func cteTableNames(pq *ParsedQuery) []string {
+	var out []string
+	for _, tbl := range pq.Tables {
+		out = append(out, tbl.Name+":"+string(tbl.Type))
+	}
+	return out
+}
+
+// TestCTEBodies checks the parsed body, column list, and flags of each CTE.
+func TestCTEBodies(t *testing.T) {
+	sql := `WITH active (uid, uname) AS MATERIALIZED (SELECT id, name FROM users WHERE active),
+moved AS (DELETE FROM sessions s USING active a WHERE s.user_id = a.uid RETURNING s.*)
+SELECT * FROM moved`
+	ir := parseAssertNoError(t, sql)
+	require.Len(t, ir.CTEs, 2)
+
+	active := ir.CTEs[0]
+	assert.Equal(t, []string{"uid", "uname"}, active.Columns, "column aliases")
+	assert.False(t, active.Recursive, "not recursive")
+	assert.False(t, active.Modifying, "SELECT body")
+	require.NotNil(t, active.Body, "parsed body")
+	assert.Equal(t, QueryCommandSelect, active.Body.Command)
+	assert.Equal(t, active.Query, active.Body.RawSQL, "body offsets are relative to Query")
+	assert.Equal(t, []string{"users:base"}, cteTableNames(active.Body))
+	assert.Nil(t, active.Anchor)
+	assert.Nil(t, active.RecursiveTerm)
+
+	moved := ir.CTEs[1]
+	assert.True(t, moved.Modifying, "DELETE body")
+	require.NotNil(t, moved.Body)
+	assert.Equal(t, QueryCommandDelete, moved.Body.Command)
+	assert.Equal(t, []string{"sessions:base", "active:cte"}, cteTableNames(moved.Body), "an earlier CTE is a CTE reference")
+}
+
+// TestRecursiveCTEBranches checks the anchor and recursive branches of a recursive CTE.
+func TestRecursiveCTEBranches(t *testing.T) {
+	sql := `WITH RECURSIVE tree (id, depth) AS (
+    SELECT id, 0 FROM nodes WHERE parent_id IS NULL
+    UNION ALL
+    SELECT id, 0 FROM roots
+    UNION ALL
+    SELECT n.id, t.depth + 1 FROM nodes n JOIN tree t ON n.parent_id = t.id
+), totals AS (SELECT count(*) FROM nodes UNION SELECT 0)
+SELECT * FROM tree, totals`
+	ir := parseAssertNoError(t, sql)
+	require.Len(t, ir.CTEs, 2)
+
+	tree := ir.CTEs[0]
+	assert.True(t, tree.Recursive)
+	require.NotNil(t, tree.Anchor, "anchor")
+	require.NotNil(t, tree.RecursiveTerm, "recursive term")
+	assert.Equal(t, "SELECT id, 0 FROM nodes WHERE parent_id IS NULL\n    UNION ALL\n    SELECT id, 0 FROM roots", tree.Anchor.RawSQL)
+	assert.Len(t, tree.Anchor.SetOperations, 1, "anchor keeps its own UNION")
+	assert.Equal(t, "SELECT n.id, t.depth + 1 FROM nodes n JOIN tree t ON n.parent_id = t.id", tree.RecursiveTerm.RawSQL)
+	assert.Equal(t, []string{"nodes:base", "tree:cte"}, cteTableNames(tree.RecursiveTerm))
+
+	totals := ir.CTEs[1]
+	assert.True(t, totals.Recursive, "declared in WITH RECURSIVE")
+	assert.Nil(t, totals.Anchor, "a UNION that does not refer to the CTE is not split")
+	assert.Nil(t, totals.RecursiveTerm)
+}
+
+// TestNestedCTEBodiesParseOnce guards against parsing each CTE body again for every
+// enclosing WITH, which made parse time double with each level of nesting.
+func TestNestedCTEBodiesParseOnce(t *testing.T) {
+	sql := "SELECT 1"
+	for i := 0; i < 16; i++ {
+		sql = fmt.Sprintf("WITH c%d AS (%s) SELECT * FROM c%d", i, sql, i)
+	}
+	start := time.Now()
+	ir := parseAssertNoError(t, sql)
+	assert.Less(t, time.Since(start), 2*time.Second, "parse time of 16 nested WITHs")
+
+	depth := 0
+	for body := ir; len(body.CTEs) == 1; body = body.CTEs[0].Body {
+		require.NotNil(t, body.CTEs[0].Body, "body at depth %d", depth)
+		depth++
+	}
+	assert.Equal(t, 16, depth)
+}
@@ -138,6 +138,217 @@ func TestIR_DDL_DropIndex(t *testing.T) {
 	}
 }
 
+// TestIR_DDL_DropObjects covers DROP for object kinds other than tables and indexes.
+func TestIR_DDL_DropObjects(t *testing.T) {
+	type wantAction struct {
+		kind      DDLObjectKind
+		schema    string
+		name      string
+		table     string
+		signature string
+	}
+	tests := []struct {
+		name      string
+		sql       string
+		want      []wantAction
+		wantFlags []string
+	}{
+		{
+			name: "views with schema",
+			sql:  "DROP VIEW IF EXISTS reporting.daily, weekly CASCADE",
+			want: []wantAction{
+				{kind: DDLObjectView, schema: "reporting", name: "daily"},
+				{kind: DDLObjectView, name: "weekly"},
+			},
+			wantFlags: []string{"IF_EXISTS", "CASCADE"},
+		},
+		{
+			name:      "materialized view",
+			sql:       "DROP MATERIALIZED VIEW mv_sales RESTRICT",
+			want:      []wantAction{{kind: DDLObjectMaterializedView, name: "mv_sales"}},
+			wantFlags: []string{"RESTRICT"},
+		},
+		{
+			name: "sequence",
+			sql:  "DROP SEQUENCE public.order_seq",
+			want: []wantAction{{kind: DDLObjectSequence, schema: "public", name: "order_seq"}},
+		},
+		{
+			name: "schemas",
+			sql:  "DROP SCHEMA app, staging CASCADE",
+			want: []wantAction{
+				{kind: DDLObjectSchema, name: "app"},
+				{kind: DDLObjectSchema, name: "staging"},
+			},
+			wantFlags: []string{"CASCADE"},
+		},
+		{
+			name:      "extension",
+			sql:       "DROP EXTENSION IF EXISTS pgcrypto",
+			want:      []wantAction{{kind: DDLObjectExtension, name: "pgcrypto"}},
+			wantFlags: []string{"IF_EXISTS"},
+		},
+		{
+			name: "procedural language",
+			sql:  "DROP PROCEDURAL LANGUAGE plsample",
+			want: []wantAction{{kind: DDLObjectLanguage, name: "plsample"}},
+		},
+		{
+			name: "types",
+			sql:  "DROP TYPE app.mood, status CASCADE",
+			want: []wantAction{
+				{kind: DDLObjectType, schema: "app", name: "mood"},
+				{kind: DDLObjectType, name: "status"},
+			},
+			wantFlags: []string{"CASCADE"},
+		},
+		{
+			name: "domain",
+			sql:  "DROP DOMAIN email_address",
+			want: []wantAction{{kind: DDLObjectDomain, name: "email_address"}},
+		},
+		{
+			name:      "trigger on table",
+			sql:       "DROP TRIGGER IF EXISTS audit_trg ON public.orders",
+			want:      []wantAction{{kind: DDLObjectTrigger, schema: "public", name: "audit_trg", table: "orders"}},
+			wantFlags: []string{"IF_EXISTS"},
+		},
+		{
+			name: "policy on table",
+			sql:  "DROP POLICY tenant_isolation ON accounts",
+			want: []wantAction{{kind: DDLObjectPolicy, name: "tenant_isolation", table: "accounts"}},
+		},
+		{
+			name: "functions with signatures",
+			sql:  "DROP FUNCTION IF EXISTS util.slugify(text), util.add(IN a integer, b  integer), now_utc CASCADE",
+			want: []wantAction{
+				{kind: DDLObjectFunction, schema: "util", name: "slugify", signature: "(text)"},
+				{kind: DDLObjectFunction, schema: "util", name: "add", signature: "(integer, integer)"},
+				{kind: DDLObjectFunction, name: "now_utc"},
+			},
+			wantFlags: []string{"IF_EXISTS", "CASCADE"},
+		},
+		{
+			name: "function ignores OUT arguments",
+			sql:  "DROP FUNCTION split(text, OUT head text)",
+			want: []wantAction{{kind: DDLObjectFunction, name: "split", signature: "(text)"}},
+		},
+		{
+			name: "procedure",
+			sql:  "DROP PROCEDURE archive_orders(date)",
+			want: []wantAction{{kind: DDLObjectProcedure, name: "archive_orders", signature: "(date)"}},
+		},
+		{
+			name: "routine",
+			sql:  "DROP ROUTINE cleanup()",
+			want: []wantAction{{kind: DDLObjectRoutine, name: "cleanup", signature: "()"}},
+		},
+		{
+			name: "aggregates",
+			sql:  "DROP AGGREGATE myavg(integer), cnt(*), pct(float8 ORDER BY float8)",
+			want: []wantAction{
+				{kind: DDLObjectAggregate, name: "myavg", signature: "(integer)"},
+				{kind: DDLObjectAggregate, name: "cnt", signature: "(*)"},
+				{kind: DDLObjectAggregate, name: "pct", signature: "(float8 ORDER BY float8)"},
+			},
+		},
+		{
+			name: "operators",
+			sql:  "DROP OPERATOR IF EXISTS ^ (integer, integer), ~ (NONE, bit)",
+			want: []wantAction{
+				{kind: DDLObjectOperator, name: "^", signature: "(integer, integer)"},
+				{kind: DDLObjectOperator, name: "~", signature: "(NONE, bit)"},
+			},
+			wantFlags: []string{"IF_EXISTS"},
+		},
+		{
+			name: "roles",
+			sql:  "DROP ROLE IF EXISTS reporting, etl",
+			want: []wantAction{
+				{kind: DDLObjectRole, name: "reporting"},
+				{kind: DDLObjectRole, name: "etl"},
+			},
+			wantFlags: []string{"IF_EXISTS"},
+		},
+		{
+			name:      "database with force",
+			sql:       "DROP DATABASE IF EXISTS analytics WITH (FORCE)",
+			want:      []wantAction{{kind: DDLObjectDatabase, name: "analytics"}},
+			wantFlags: []string{"IF_EXISTS", "FORCE"},
+		},
+		{
+			name:      "subscription",
+			sql:       "DROP SUBSCRIPTION IF EXISTS orders_sub CASCADE",
+			want:      []wantAction{{kind: DDLObjectSubscription, name: "orders_sub"}},
+			wantFlags: []string{"IF_EXISTS", "CASCADE"},
+		},
+		{
+			name: "server",
+			sql:  "DROP SERVER billing_srv",
+			want: []wantAction{{kind: DDLObjectServer, name: "billing_srv"}},
+		},
+		{
+			name: "tablespace",
+			sql:  "DROP TABLESPACE fastdisk",
+			want: []wantAction{{kind: DDLObjectTablespace, name: "fastdisk"}},
+		},
+		{
+			name:      "owned by",
+			sql:       "DROP OWNED BY etl CASCADE",
+			want:      []wantAction{{kind: DDLObjectOwned, name: "etl"}},
+			wantFlags: []string{"CASCADE"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, len(tc.want), "action count mismatch")
+			assert.Empty(t, ir.Tables, "non-table drops should not add tables")
+
+			for i, want := range tc.want {
+				act := ir.DDLActions[i]
+				assert.Equal(t, DDLDrop, act.Type, "expected DROP")
+				assert.Equal(t, want.kind, act.ObjectKind, "object kind mismatch")
+				assert.Equal(t, want.schema, act.Schema, "schema mismatch")
+				assert.Equal(t, want.name, act.ObjectName, "object name mismatch")
+				assert.Equal(t, want.table, act.Table, "owning table mismatch")
+				assert.Equal(t, want.signature, act.Signature, "signature mismatch")
+				assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			}
+		})
+	}
+}
+
+// TestIR_DDL_DropUserMapping verifies the role and server of DROP USER MAPPING.
+func TestIR_DDL_DropUserMapping(t *testing.T) {
+	ir := parseAssertNoError(t, "DROP USER MAPPING IF EXISTS FOR app SERVER billing")
+	require.Len(t, ir.DDLActions, 1)
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLDrop, act.Type)
+	assert.Equal(t, DDLObjectUserMapping, act.ObjectKind)
+	assert.Equal(t, "app", act.ObjectName)
+	assert.Equal(t, "billing", act.Server)
+	assert.Equal(t, []string{"IF_EXISTS"}, act.Flags)
+}
+
+// TestIR_DDL_DropTableAndIndexKind verifies the legacy DROP_TABLE/DROP_INDEX types also carry ObjectKind.
+func TestIR_DDL_DropTableAndIndexKind(t *testing.T) {
+	ir := parseAssertNoError(t, "DROP TABLE public.users")
+	require.Len(t, ir.DDLActions, 1)
+	assert.Equal(t, DDLDropTable, ir.DDLActions[0].Type)
+	assert.Equal(t, DDLObjectTable, ir.DDLActions[0].ObjectKind)
+
+	ir = parseAssertNoError(t, "DROP INDEX CONCURRENTLY IF EXISTS idx_a, idx_b")
+	require.Len(t, ir.DDLActions, 2)
+	for _, act := range ir.DDLActions {
+		assert.Equal(t, DDLDropIndex, act.Type)
+		assert.Equal(t, DDLObjectIndex, act.ObjectKind)
+		assert.ElementsMatch(t, []string{"IF_EXISTS", "CONCURRENTLY"}, act.Flags)
+	}
+}
+
 func TestIR_DDL_CreateIndex(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -278,6 +489,24 @@ func TestIR_DDL_CreateTableIfNotExists(t *testing.T) {
 	assert.Equal(t, DDLColumn{Name: "id", Type: "integer", Nullable: true}, act.ColumnDetails[0], "column mismatch")
 }
 
+func TestIR_DDL_CreateTableLike(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE TABLE archive (archived_at timestamptz, LIKE app.users INCLUDING ALL EXCLUDING INDEXES, note text, LIKE orgs)")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, []string{"archived_at", "note"}, act.Columns, "column names mismatch")
+	assert.Equal(t, []TableLike{
+		{Schema: "app", Name: "users", Position: 1, Including: []string{"COMMENTS", "CONSTRAINTS", "DEFAULTS", "IDENTITY", "GENERATED", "STATISTICS", "STORAGE"}},
+		{Name: "orgs", Position: 2},
+	}, act.LikeTables, "LIKE clauses mismatch")
+
+	var tables []string
+	for _, tbl := range ir.Tables {
+		tables = append(tables, tbl.Raw)
+	}
+	assert.Equal(t, []string{"archive", "app.users", "orgs"}, tables, "tables mismatch")
+}
+
 func TestIR_DDL_CreateTableTypeCoverage(t *testing.T) {
 	sql := `CREATE TABLE public.type_matrix (
     c_smallint smallint,
@@ -437,6 +666,416 @@ func TestIR_DDL_AlterTableAddColumn(t *testing.T) {
 	assert.Contains(t, act.Flags, "ADD_COLUMN", "expected flag ADD_COLUMN")
 }
 
+// TestIR_DDL_AlterColumnDropNotNull verifies ALTER COLUMN ... DROP NOT NULL is not mistaken for DROP COLUMN.
+func TestIR_DDL_AlterColumnDropNotNull(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TABLE users ALTER COLUMN email DROP NOT NULL")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLAlterTable, act.Type, "expected ALTER_TABLE")
+	assert.Equal(t, []string{"email"}, act.Columns, "column mismatch")
+	assert.Contains(t, act.Flags, "ALTER_COLUMN", "expected flag ALTER_COLUMN")
+}
+
+// TestIR_DDL_AlterTableSubcommands covers relation-level ALTER TABLE sub-commands.
+func TestIR_DDL_AlterTableSubcommands(t *testing.T) {
+	tests := []struct {
+		name           string
+		sql            string
+		wantFlag       string
+		wantOwner      string
+		wantTablespace string
+		wantObjects    []string
+		wantOptions    []DDLOption
+	}{
+		{
+			name:      "owner to",
+			sql:       "ALTER TABLE public.accounts OWNER TO app_owner",
+			wantFlag:  "OWNER_TO",
+			wantOwner: "app_owner",
+		},
+		{
+			name:     "enable row level security",
+			sql:      "ALTER TABLE public.accounts ENABLE ROW LEVEL SECURITY",
+			wantFlag: "ENABLE_ROW_LEVEL_SECURITY",
+		},
+		{
+			name:     "force row level security",
+			sql:      "ALTER TABLE public.accounts FORCE ROW LEVEL SECURITY",
+			wantFlag: "FORCE_ROW_LEVEL_SECURITY",
+		},
+		{
+			name:     "no force row level security",
+			sql:      "ALTER TABLE public.accounts NO FORCE ROW LEVEL SECURITY",
+			wantFlag: "NO_FORCE_ROW_LEVEL_SECURITY",
+		},
+		{
+			name:     "disable row level security",
+			sql:      "ALTER TABLE public.accounts DISABLE ROW LEVEL SECURITY",
+			wantFlag: "DISABLE_ROW_LEVEL_SECURITY",
+		},
+		{
+			name:     "storage parameters",
+			sql:      "ALTER TABLE public.accounts SET (fillfactor = 70, toast.autovacuum_enabled = false)",
+			wantFlag: "SET_OPTIONS",
+			wantOptions: []DDLOption{
+				{Name: "fillfactor", Value: "70"},
+				{Name: "toast.autovacuum_enabled", Value: "false"},
+			},
+		},
+		{
+			name:        "reset storage parameters",
+			sql:         "ALTER TABLE public.accounts RESET (fillfactor)",
+			wantFlag:    "RESET_OPTIONS",
+			wantOptions: []DDLOption{{Name: "fillfactor"}},
+		},
+		{
+			name:           "set tablespace",
+			sql:            "ALTER TABLE public.accounts SET TABLESPACE fastdisk",
+			wantFlag:       "SET_TABLESPACE",
+			wantTablespace: "fastdisk",
+		},
+		{
+			name:     "set logged",
+			sql:      "ALTER TABLE public.accounts SET LOGGED",
+			wantFlag: "SET_LOGGED",
+		},
+		{
+			name:     "set unlogged",
+			sql:      "ALTER TABLE public.accounts SET UNLOGGED",
+			wantFlag: "SET_UNLOGGED",
+		},
+		{
+			name:        "disable trigger",
+			sql:         "ALTER TABLE public.accounts DISABLE TRIGGER audit_trg",
+			wantFlag:    "DISABLE_TRIGGER",
+			wantObjects: []string{"audit_trg"},
+		},
+		{
+			name:        "enable replica trigger",
+			sql:         "ALTER TABLE public.accounts ENABLE REPLICA TRIGGER sync_trg",
+			wantFlag:    "ENABLE_REPLICA_TRIGGER",
+			wantObjects: []string{"sync_trg"},
+		},
+		{
+			name:        "enable all triggers",
+			sql:         "ALTER TABLE public.accounts ENABLE TRIGGER ALL",
+			wantFlag:    "ENABLE_TRIGGER",
+			wantObjects: []string{"ALL"},
+		},
+		{
+			name:        "disable rule",
+			sql:         "ALTER TABLE public.accounts DISABLE RULE protect",
+			wantFlag:    "DISABLE_RULE",
+			wantObjects: []string{"protect"},
+		},
+		{
+			name:        "cluster on",
+			sql:         "ALTER TABLE public.accounts CLUSTER ON accounts_pkey",
+			wantFlag:    "CLUSTER_ON",
+			wantObjects: []string{"accounts_pkey"},
+		},
+		{
+			name:        "replica identity index",
+			sql:         "ALTER TABLE public.accounts REPLICA IDENTITY USING INDEX accounts_uq",
+			wantFlag:    "REPLICA_IDENTITY",
+			wantObjects: []string{"accounts_uq"},
+		},
+		{
+			name:        "replica identity full",
+			sql:         "ALTER TABLE public.accounts REPLICA IDENTITY FULL",
+			wantFlag:    "REPLICA_IDENTITY",
+			wantObjects: []string{"FULL"},
+		},
+		{
+			name:        "inherit",
+			sql:         "ALTER TABLE public.accounts INHERIT public.base",
+			wantFlag:    "INHERIT",
+			wantObjects: []string{"public.base"},
+		},
+		{
+			name:        "attach partition",
+			sql:         "ALTER TABLE public.accounts ATTACH PARTITION public.accounts_2024 FOR VALUES FROM ('2024-01-01') TO ('2025-01-01')",
+			wantFlag:    "ATTACH_PARTITION",
+			wantObjects: []string{"public.accounts_2024"},
+		},
+		{
+			name:        "detach partition",
+			sql:         "ALTER TABLE public.accounts DETACH PARTITION public.accounts_2023",
+			wantFlag:    "DETACH_PARTITION",
+			wantObjects: []string{"public.accounts_2023"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+			require.Len(t, ir.Tables, 1, "tables count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLAlterTable, act.Type, "expected ALTER_TABLE")
+			assert.Equal(t, DDLObjectTable, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, "public", act.Schema, "schema mismatch")
+			assert.Equal(t, "accounts", act.ObjectName, "object name mismatch")
+			assert.Equal(t, []string{tc.wantFlag}, act.Flags, "flags mismatch")
+			assert.Equal(t, tc.wantOwner, act.Owner, "owner mismatch")
+			assert.Equal(t, tc.wantTablespace, act.Tablespace, "tablespace mismatch")
+			assert.Equal(t, tc.wantObjects, act.Objects, "objects mismatch")
+			assert.Equal(t, tc.wantOptions, act.Options, "options mismatch")
+			assert.Empty(t, act.Columns, "relation-level sub-commands have no columns")
+		})
+	}
+}
+
+// TestIR_DDL_AlterTableMixedSubcommands verifies each sub-command of one ALTER TABLE becomes its own action.
+func TestIR_DDL_AlterTableMixedSubcommands(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TABLE accounts OWNER TO app_owner, ENABLE ROW LEVEL SECURITY, ADD COLUMN tenant_id int")
+	require.Len(t, ir.DDLActions, 3, "action count mismatch")
+	assert.Equal(t, []string{"OWNER_TO"}, ir.DDLActions[0].Flags)
+	assert.Equal(t, "app_owner", ir.DDLActions[0].Owner)
+	assert.Equal(t, []string{"ENABLE_ROW_LEVEL_SECURITY"}, ir.DDLActions[1].Flags)
+	assert.Equal(t, []string{"ADD_COLUMN"}, ir.DDLActions[2].Flags)
+	assert.Equal(t, []string{"tenant_id"}, ir.DDLActions[2].Columns)
+}
+
+// TestIR_DDL_AlterOtherRelations covers ALTER INDEX, SEQUENCE, VIEW, MATERIALIZED VIEW, and FOREIGN TABLE.
+func TestIR_DDL_AlterOtherRelations(t *testing.T) {
+	tests := []struct {
+		name           string
+		sql            string
+		wantKind       DDLObjectKind
+		wantSchema     string
+		wantObject     string
+		wantFlags      []string
+		wantOwner      string
+		wantTablespace string
+		wantObjects    []string
+		wantOptions    []DDLOption
+		wantTables     int
+	}{
+		{
+			name:           "index set tablespace",
+			sql:            "ALTER INDEX app.accounts_pkey SET TABLESPACE fastdisk",
+			wantKind:       DDLObjectIndex,
+			wantSchema:     "app",
+			wantObject:     "accounts_pkey",
+			wantFlags:      []string{"SET_TABLESPACE"},
+			wantTablespace: "fastdisk",
+		},
+		{
+			name:        "index attach partition",
+			sql:         "ALTER INDEX parent_idx ATTACH PARTITION child_idx",
+			wantKind:    DDLObjectIndex,
+			wantObject:  "parent_idx",
+			wantFlags:   []string{"ATTACH_PARTITION"},
+			wantObjects: []string{"child_idx"},
+		},
+		{
+			name:       "sequence owner",
+			sql:        "ALTER SEQUENCE app.order_seq OWNER TO app_owner",
+			wantKind:   DDLObjectSequence,
+			wantSchema: "app",
+			wantObject: "order_seq",
+			wantFlags:  []string{"OWNER_TO"},
+			wantOwner:  "app_owner",
+		},
+		{
+			name:       "sequence options",
+			sql:        "ALTER SEQUENCE IF EXISTS order_seq RESTART WITH 100 INCREMENT BY 5 NO MAXVALUE OWNED BY orders.id",
+			wantKind:   DDLObjectSequence,
+			wantObject: "order_seq",
+			wantFlags:  []string{"IF_EXISTS"},
+			wantOptions: []DDLOption{
+				{Name: "RESTART", Value: "100"},
+				{Name: "INCREMENT", Value: "5"},
+				{Name: "NO MAXVALUE"},
+				{Name: "OWNED BY", Value: "orders.id"},
+			},
+		},
+		{
+			name:       "view owner",
+			sql:        "ALTER VIEW reporting.daily OWNER TO analyst",
+			wantKind:   DDLObjectView,
+			wantSchema: "reporting",
+			wantObject: "daily",
+			wantFlags:  []string{"OWNER_TO"},
+			wantOwner:  "analyst",
+		},
+		{
+			name:        "view options",
+			sql:         "ALTER VIEW daily SET (security_barrier = true)",
+			wantKind:    DDLObjectView,
+			wantObject:  "daily",
+			wantFlags:   []string{"SET_OPTIONS"},
+			wantOptions: []DDLOption{{Name: "security_barrier", Value: "true"}},
+		},
+		{
+			name:           "materialized view tablespace",
+			sql:            "ALTER MATERIALIZED VIEW mv_sales SET TABLESPACE archive",
+			wantKind:       DDLObjectMaterializedView,
+			wantObject:     "mv_sales",
+			wantFlags:      []string{"SET_TABLESPACE"},
+			wantTablespace: "archive",
+		},
+		{
+			name:       "foreign table options",
+			sql:        "ALTER FOREIGN TABLE remote_orders OPTIONS (ADD schema_name 'sales', DROP table_name)",
+			wantKind:   DDLObjectForeignTable,
+			wantObject: "remote_orders",
+			wantFlags:  []string{"OPTIONS"},
+			wantOptions: []DDLOption{
+				{Name: "schema_name", Value: "sales"},
+				{Name: "table_name"},
+			},
+			wantTables: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLAlter, act.Type, "expected ALTER")
+			assert.Equal(t, tc.wantKind, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantSchema, act.Schema, "schema mismatch")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			assert.Equal(t, tc.wantOwner, act.Owner, "owner mismatch")
+			assert.Equal(t, tc.wantTablespace, act.Tablespace, "tablespace mismatch")
+			assert.Equal(t, tc.wantObjects, act.Objects, "objects mismatch")
+			assert.Equal(t, tc.wantOptions, act.Options, "options mismatch")
+			assert.Len(t, ir.Tables, tc.wantTables, "tables count mismatch")
+		})
+	}
+}
+
+// TestIR_DDL_AlterOwnerAndSetSchema covers ALTER <object> OWNER TO and SET SCHEMA for non-relation objects.
+func TestIR_DDL_AlterOwnerAndSetSchema(t *testing.T) {
+	tests := []struct {
+		name          string
+		sql           string
+		wantType      DDLActionType
+		wantKind      DDLObjectKind
+		wantSchema    string
+		wantObject    string
+		wantSignature string
+		wantFlags     []string
+		wantOwner     string
+		wantNewSchema string
+		wantTables    int
+	}{
+		{
+			name:       "schema owner",
+			sql:        "ALTER SCHEMA app OWNER TO app_owner",
+			wantType:   DDLAlter,
+			wantKind:   DDLObjectSchema,
+			wantObject: "app",
+			wantFlags:  []string{"OWNER_TO"},
+			wantOwner:  "app_owner",
+		},
+		{
+			name:          "function owner",
+			sql:           "ALTER FUNCTION util.add(integer, integer) OWNER TO app_owner",
+			wantType:      DDLAlter,
+			wantKind:      DDLObjectFunction,
+			wantSchema:    "util",
+			wantObject:    "add",
+			wantSignature: "(integer, integer)",
+			wantFlags:     []string{"OWNER_TO"},
+			wantOwner:     "app_owner",
+		},
+		{
+			name:       "database owner",
+			sql:        "ALTER DATABASE analytics OWNER TO dba",
+			wantType:   DDLAlter,
+			wantKind:   DDLObjectDatabase,
+			wantObject: "analytics",
+			wantFlags:  []string{"OWNER_TO"},
+			wantOwner:  "dba",
+		},
+		{
+			name:       "foreign data wrapper owner",
+			sql:        "ALTER FOREIGN DATA WRAPPER postgres_fdw OWNER TO dba",
+			wantType:   DDLAlter,
+			wantKind:   DDLObjectForeignDataWrapper,
+			wantObject: "postgres_fdw",
+			wantFlags:  []string{"OWNER_TO"},
+			wantOwner:  "dba",
+		},
+		{
+			name:       "procedural language owner",
+			sql:        "ALTER PROCEDURAL LANGUAGE plsample OWNER TO dba",
+			wantType:   DDLAlter,
+			wantKind:   DDLObjectLanguage,
+			wantObject: "plsample",
+			wantFlags:  []string{"OWNER_TO"},
+			wantOwner:  "dba",
+		},
+		{
+			name:          "table set schema",
+			sql:           "ALTER TABLE IF EXISTS staging.accounts SET SCHEMA app",
+			wantType:      DDLAlterTable,
+			wantKind:      DDLObjectTable,
+			wantSchema:    "staging",
+			wantObject:    "accounts",
+			wantFlags:     []string{"IF_EXISTS", "SET_SCHEMA"},
+			wantNewSchema: "app",
+			wantTables:    1,
+		},
+		{
+			name:          "type set schema",
+			sql:           "ALTER TYPE mood SET SCHEMA app",
+			wantType:      DDLAlter,
+			wantKind:      DDLObjectType,
+			wantObject:    "mood",
+			wantFlags:     []string{"SET_SCHEMA"},
+			wantNewSchema: "app",
+		},
+		{
+			name:          "sequence set schema",
+			sql:           "ALTER SEQUENCE order_seq SET SCHEMA app",
+			wantType:      DDLAlter,
+			wantKind:      DDLObjectSequence,
+			wantObject:    "order_seq",
+			wantFlags:     []string{"SET_SCHEMA"},
+			wantNewSchema: "app",
+		},
+		{
+			name:          "extension set schema",
+			sql:           "ALTER EXTENSION pgcrypto SET SCHEMA crypto",
+			wantType:      DDLAlter,
+			wantKind:      DDLObjectExtension,
+			wantObject:    "pgcrypto",
+			wantFlags:     []string{"SET_SCHEMA"},
+			wantNewSchema: "crypto",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, tc.wantType, act.Type, "action type mismatch")
+			assert.Equal(t, tc.wantKind, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantSchema, act.Schema, "schema mismatch")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, tc.wantSignature, act.Signature, "signature mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			assert.Equal(t, tc.wantOwner, act.Owner, "owner mismatch")
+			assert.Equal(t, tc.wantNewSchema, act.NewSchema, "new schema mismatch")
+			assert.Len(t, ir.Tables, tc.wantTables, "tables count mismatch")
+		})
+	}
+}
+
 func TestIR_DDL_AlterTableSchemaQualified(t *testing.T) {
 	ir := parseAssertNoError(t, "ALTER TABLE public.users ADD COLUMN status text")
 	assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
@@ -522,3 +1161,994 @@ func TestIR_DDL_Truncate(t *testing.T) {
 		})
 	}
 }
+
+func TestIR_DDL_CreateSchema(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantObject string
+		wantOwner  string
+		wantFlags  []string
+	}{
+		{
+			name:       "simple",
+			sql:        "CREATE SCHEMA app",
+			wantObject: "app",
+		},
+		{
+			name:       "IF NOT EXISTS with AUTHORIZATION",
+			sql:        "CREATE SCHEMA IF NOT EXISTS app AUTHORIZATION bob",
+			wantObject: "app",
+			wantOwner:  "bob",
+			wantFlags:  []string{"IF_NOT_EXISTS"},
+		},
+		{
+			name:       "AUTHORIZATION only names schema after role",
+			sql:        "CREATE SCHEMA AUTHORIZATION bob",
+			wantObject: "bob",
+			wantOwner:  "bob",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreateSchema, act.Type, "expected CREATE_SCHEMA")
+			assert.Equal(t, DDLObjectSchema, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, tc.wantOwner, act.Owner, "owner mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+		})
+	}
+}
+
+func TestIR_DDL_CreateSchemaElements(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE SCHEMA app CREATE TABLE items (id int) CREATE INDEX items_id_idx ON items (id)")
+	require.Len(t, ir.DDLActions, 3, "action count mismatch")
+
+	assert.Equal(t, DDLCreateSchema, ir.DDLActions[0].Type, "expected CREATE_SCHEMA first")
+	assert.Equal(t, DDLCreateTable, ir.DDLActions[1].Type, "expected nested CREATE_TABLE")
+	assert.Equal(t, "items", ir.DDLActions[1].ObjectName, "table name mismatch")
+	assert.Equal(t, "app", ir.DDLActions[1].Schema, "nested table should inherit schema")
+	assert.Equal(t, DDLCreateIndex, ir.DDLActions[2].Type, "expected nested CREATE_INDEX")
+	assert.Equal(t, "app", ir.DDLActions[2].Schema, "nested index should inherit schema")
+	for _, tbl := range ir.Tables {
+		assert.Equal(t, "app", tbl.Schema, "table ref should inherit schema")
+	}
+}
+
+func TestIR_DDL_CreateExtension(t *testing.T) {
+	tests := []struct {
+		name       string
+		sql        string
+		wantObject string
+		wantSchema string
+		wantFlags  []string
+	}{
+		{
+			name:       "simple",
+			sql:        "CREATE EXTENSION pgcrypto",
+			wantObject: "pgcrypto",
+		},
+		{
+			name:       "quoted name IF NOT EXISTS",
+			sql:        `CREATE EXTENSION IF NOT EXISTS "uuid-ossp"`,
+			wantObject: `"uuid-ossp"`,
+			wantFlags:  []string{"IF_NOT_EXISTS"},
+		},
+		{
+			name:       "WITH SCHEMA VERSION CASCADE",
+			sql:        "CREATE EXTENSION postgis WITH SCHEMA ext VERSION '3.4' CASCADE",
+			wantObject: "postgis",
+			wantSchema: "ext",
+			wantFlags:  []string{"CASCADE"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreateExtension, act.Type, "expected CREATE_EXTENSION")
+			assert.Equal(t, DDLObjectExtension, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, tc.wantSchema, act.Schema, "schema mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			assert.Empty(t, ir.Tables, "extensions should not produce table refs")
+		})
+	}
+}
+
+func TestIR_DDL_Comment(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantKind    DDLObjectKind
+		wantObject  string
+		wantSchema  string
+		wantTable   string
+		wantColumns []string
+		wantComment string
+		wantFlags   []string
+		wantTables  int
+	}{
+		{
+			name:        "table",
+			sql:         "COMMENT ON TABLE public.users IS 'Registered users'",
+			wantKind:    DDLObjectTable,
+			wantObject:  "users",
+			wantSchema:  "public",
+			wantComment: "Registered users",
+			wantTables:  1,
+		},
+		{
+			name:        "column with escaped quote",
+			sql:         "COMMENT ON COLUMN public.users.email IS 'User''s primary email'",
+			wantKind:    DDLObjectColumn,
+			wantObject:  "users",
+			wantSchema:  "public",
+			wantColumns: []string{"email"},
+			wantComment: "User's primary email",
+			wantTables:  1,
+		},
+		{
+			name:        "unqualified column",
+			sql:         "COMMENT ON COLUMN users.email IS 'Email'",
+			wantKind:    DDLObjectColumn,
+			wantObject:  "users",
+			wantColumns: []string{"email"},
+			wantComment: "Email",
+			wantTables:  1,
+		},
+		{
+			name:        "materialized view",
+			sql:         "COMMENT ON MATERIALIZED VIEW reporting.daily IS 'Daily rollup'",
+			wantKind:    DDLObjectMaterializedView,
+			wantObject:  "daily",
+			wantSchema:  "reporting",
+			wantComment: "Daily rollup",
+		},
+		{
+			name:        "schema",
+			sql:         "COMMENT ON SCHEMA app IS 'Application objects'",
+			wantKind:    DDLObjectSchema,
+			wantObject:  "app",
+			wantComment: "Application objects",
+		},
+		{
+			name:        "function with dollar quoting",
+			sql:         "COMMENT ON FUNCTION util.slugify(text) IS $$Turns text into a slug$$",
+			wantKind:    DDLObjectFunction,
+			wantObject:  "slugify",
+			wantSchema:  "util",
+			wantComment: "Turns text into a slug",
+		},
+		{
+			name:        "trigger on table",
+			sql:         "COMMENT ON TRIGGER audit_trg ON public.orders IS 'Audit trail'",
+			wantKind:    DDLObjectTrigger,
+			wantObject:  "audit_trg",
+			wantSchema:  "public",
+			wantTable:   "orders",
+			wantComment: "Audit trail",
+		},
+		{
+			name:        "constraint on table",
+			sql:         "COMMENT ON CONSTRAINT orders_pkey ON orders IS 'PK'",
+			wantKind:    DDLObjectConstraint,
+			wantObject:  "orders_pkey",
+			wantTable:   "orders",
+			wantComment: "PK",
+		},
+		{
+			name:       "IS NULL removes comment",
+			sql:        "COMMENT ON TABLE users IS NULL",
+			wantKind:   DDLObjectTable,
+			wantObject: "users",
+			wantFlags:  []string{"NULL_COMMENT"},
+			wantTables: 1,
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLComment, act.Type, "expected COMMENT")
+			assert.Equal(t, tc.wantKind, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, tc.wantSchema, act.Schema, "schema mismatch")
+			assert.Equal(t, tc.wantTable, act.Table, "owning table mismatch")
+			assert.Equal(t, tc.wantColumns, act.Columns, "columns mismatch")
+			assert.Equal(t, tc.wantComment, act.Comment, "comment mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			assert.Len(t, ir.Tables, tc.wantTables, "tables count mismatch")
+		})
+	}
+}
+
+func TestIR_DDL_CreateTableAs(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantSchema  string
+		wantObject  string
+		wantColumns []string
+		wantFlags   []string
+		wantSources []string
+	}{
+		{
+			name:        "simple",
+			sql:         "CREATE TABLE active_users AS SELECT id, email FROM users WHERE active",
+			wantObject:  "active_users",
+			wantFlags:   []string{"AS_SELECT"},
+			wantSources: []string{"users"},
+		},
+		{
+			name:        "column list and join",
+			sql:         "CREATE TABLE rpt.totals (customer, amount) AS SELECT c.name, SUM(o.total) FROM customers c JOIN orders o ON o.customer_id = c.id GROUP BY c.name",
+			wantSchema:  "rpt",
+			wantObject:  "totals",
+			wantColumns: []string{"customer", "amount"},
+			wantFlags:   []string{"AS_SELECT"},
+			wantSources: []string{"customers", "orders"},
+		},
+		{
+			name:        "temp if not exists with no data",
+			sql:         "CREATE TEMP TABLE IF NOT EXISTS scratch AS SELECT * FROM events WITH NO DATA",
+			wantObject:  "scratch",
+			wantFlags:   []string{"IF_NOT_EXISTS", "AS_SELECT", "TEMPORARY", "WITH_NO_DATA"},
+			wantSources: []string{"events"},
+		},
+		{
+			name:        "TABLE form",
+			sql:         "CREATE TABLE users_copy AS TABLE users",
+			wantObject:  "users_copy",
+			wantFlags:   []string{"AS_SELECT"},
+			wantSources: []string{"users"},
+		},
+		{
+			name:        "parenthesized query",
+			sql:         "CREATE TABLE recent AS (SELECT id FROM events WHERE created_at > now() - interval '1 day')",
+			wantObject:  "recent",
+			wantFlags:   []string{"AS_SELECT"},
+			wantSources: []string{"events"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreateTable, act.Type, "expected CREATE_TABLE")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, tc.wantSchema, act.Schema, "schema mismatch")
+			assert.Equal(t, tc.wantColumns, act.Columns, "columns mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+
+			require.NotNil(t, ir.Target, "expected write target")
+			assert.Equal(t, tc.wantObject, ir.Target.Name, "target name mismatch")
+			assert.Equal(t, tc.wantSchema, ir.Target.Schema, "target schema mismatch")
+
+			require.NotNil(t, ir.Source, "expected nested source query")
+			var sources []string
+			for _, tbl := range ir.Source.Tables {
+				sources = append(sources, tbl.Name)
+			}
+			assert.Equal(t, tc.wantSources, sources, "source tables mismatch")
+			for _, name := range tc.wantSources {
+				assert.True(t, containsTable(ir.Tables, name), "expected %s in Tables", name)
+			}
+		})
+	}
+}
+
+func TestIR_DDL_SelectInto(t *testing.T) {
+	ir := parseAssertNoError(t, "SELECT id, email INTO TEMP recent_users FROM users WHERE created_at > now() - interval '1 day'")
+	assert.Equal(t, QueryCommandSelect, ir.Command, "SELECT INTO keeps the SELECT command")
+
+	require.NotNil(t, ir.Target, "expected write target")
+	assert.Equal(t, "recent_users", ir.Target.Name, "target name mismatch")
+	assert.True(t, containsTable(ir.Tables, "recent_users"), "expected created table in Tables")
+
+	require.Len(t, ir.DDLActions, 1, "expected a CREATE_TABLE action for SELECT INTO")
+	assert.Equal(t, DDLCreateTable, ir.DDLActions[0].Type, "expected CREATE_TABLE")
+	assert.Equal(t, "recent_users", ir.DDLActions[0].ObjectName, "object name mismatch")
+	assert.Equal(t, []string{"SELECT_INTO", "TEMPORARY"}, ir.DDLActions[0].Flags, "flags mismatch")
+
+	require.NotNil(t, ir.Source, "expected nested source query")
+	assert.Equal(t, "SELECT id, email FROM users WHERE created_at > now() - interval '1 day'", ir.Source.RawSQL, "source query should leave out the INTO clause")
+	require.Len(t, ir.Source.Tables, 1, "source tables mismatch")
+	assert.Equal(t, "users", ir.Source.Tables[0].Name, "source table mismatch")
+	assert.Nil(t, ir.Source.Target, "source query should not carry the target")
+}
+
+func TestIR_DDL_PlainSelectHasNoTarget(t *testing.T) {
+	ir := parseAssertNoError(t, "SELECT id FROM users")
+	assert.Nil(t, ir.Target, "plain SELECT should not have a write target")
+	assert.Nil(t, ir.Source, "plain SELECT should not have a source query")
+	assert.Empty(t, ir.DDLActions, "plain SELECT should not produce DDL actions")
+}
+
+func TestIR_DDL_ForeignDataWrapper(t *testing.T) {
+	t.Run("server", func(t *testing.T) {
+		ir := parseAssertNoError(t, "CREATE SERVER IF NOT EXISTS billing FOREIGN DATA WRAPPER postgres_fdw OPTIONS (host 'billing-db', dbname 'billing')")
+		assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+		require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+		act := ir.DDLActions[0]
+		assert.Equal(t, DDLCreateServer, act.Type, "expected CREATE_SERVER")
+		assert.Equal(t, "billing", act.ObjectName, "server name mismatch")
+		assert.Equal(t, "postgres_fdw", act.Wrapper, "wrapper mismatch")
+		assert.Equal(t, []string{"IF_NOT_EXISTS"}, act.Flags, "flags mismatch")
+		assert.Equal(t, []DDLOption{{Name: "host", Value: "billing-db"}, {Name: "dbname", Value: "billing"}}, act.Options, "options mismatch")
+	})
+
+	t.Run("foreign table", func(t *testing.T) {
+		ir := parseAssertNoError(t, "CREATE FOREIGN TABLE remote.invoices (id bigint NOT NULL, total numeric) SERVER billing OPTIONS (schema_name 'public', table_name 'invoices')")
+		require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+		act := ir.DDLActions[0]
+		assert.Equal(t, DDLCreateForeignTable, act.Type, "expected CREATE_FOREIGN_TABLE")
+		assert.Equal(t, DDLObjectForeignTable, act.ObjectKind, "object kind mismatch")
+		assert.Equal(t, "remote", act.Schema, "schema mismatch")
+		assert.Equal(t, "invoices", act.ObjectName, "table name mismatch")
+		assert.Equal(t, "billing", act.Server, "server mismatch")
+		assert.Equal(t, []string{"id", "total"}, act.Columns, "columns mismatch")
+		require.Len(t, act.ColumnDetails, 2, "column details mismatch")
+		assert.False(t, act.ColumnDetails[0].Nullable, "id should be NOT NULL")
+		assert.Equal(t, []DDLOption{{Name: "schema_name", Value: "public"}, {Name: "table_name", Value: "invoices"}}, act.Options, "options mismatch")
+		assert.True(t, containsTable(ir.Tables, "invoices"), "expected foreign table in Tables")
+	})
+
+	t.Run("import foreign schema", func(t *testing.T) {
+		ir := parseAssertNoError(t, "IMPORT FOREIGN SCHEMA public LIMIT TO (invoices, payments) FROM SERVER billing INTO billing_remote")
+		require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+		act := ir.DDLActions[0]
+		assert.Equal(t, DDLImportForeignSchema, act.Type, "expected IMPORT_FOREIGN_SCHEMA")
+		assert.Equal(t, "billing_remote", act.ObjectName, "local schema mismatch")
+		assert.Equal(t, "public", act.RemoteSchema, "remote schema mismatch")
+		assert.Equal(t, "billing", act.Server, "server mismatch")
+		assert.Equal(t, []string{"LIMIT_TO"}, act.Flags, "flags mismatch")
+		assert.Equal(t, []string{"invoices", "payments"}, act.Objects, "imported tables mismatch")
+	})
+
+	t.Run("user mapping redacts password", func(t *testing.T) {
+		ir := parseAssertNoError(t, "CREATE USER MAPPING FOR app_user SERVER billing OPTIONS (user 'reporter', password 'hunter2', password_required 'false')")
+		require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+		act := ir.DDLActions[0]
+		assert.Equal(t, DDLCreateUserMapping, act.Type, "expected CREATE_USER_MAPPING")
+		assert.Equal(t, "app_user", act.ObjectName, "role mismatch")
+		assert.Equal(t, "billing", act.Server, "server mismatch")
+		assert.Equal(t, []DDLOption{
+			{Name: "user", Value: "reporter"},
+			{Name: "password", Value: RedactedValue},
+			{Name: "password_required", Value: "false"},
+		}, act.Options, "options mismatch")
+	})
+}
+
+func TestIR_DDL_CreatePublication(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantFlags   []string
+		wantTables  []PublicationTable
+		wantSchemas []string
+		wantOptions []DDLOption
+	}{
+		{
+			name: "table list with options",
+			sql:  "CREATE PUBLICATION orders_pub FOR TABLE orders, sales.refunds WITH (publish = 'insert, update')",
+			wantTables: []PublicationTable{
+				{Name: "orders"},
+				{Schema: "sales", Name: "refunds"},
+			},
+			wantOptions: []DDLOption{{Name: "publish", Value: "insert, update"}},
+		},
+		{
+			name:      "all tables",
+			sql:       "CREATE PUBLICATION everything FOR ALL TABLES",
+			wantFlags: []string{"ALL_TABLES"},
+		},
+		{
+			name: "column list and row filter",
+			sql:  "CREATE PUBLICATION orders_pub FOR TABLE orders (id, status, total) WHERE (status <> 'draft'), TABLE customers",
+			wantTables: []PublicationTable{
+				{Name: "orders", Columns: []string{"id", "status", "total"}, RowFilter: "status <> 'draft'"},
+				{Name: "customers"},
+			},
+		},
+		{
+			name:        "tables in schema",
+			sql:         "CREATE PUBLICATION sales_pub FOR TABLES IN SCHEMA sales, archive, TABLE public.orders",
+			wantFlags:   []string{"TABLES_IN_SCHEMA"},
+			wantTables:  []PublicationTable{{Schema: "public", Name: "orders"}},
+			wantSchemas: []string{"sales", "archive"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLCreatePublication, act.Type, "expected CREATE_PUBLICATION")
+			assert.Equal(t, DDLObjectPublication, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			assert.Equal(t, tc.wantTables, act.PublishedTables, "published tables mismatch")
+			assert.Equal(t, tc.wantSchemas, act.Objects, "published schemas mismatch")
+			assert.Equal(t, tc.wantOptions, act.Options, "options mismatch")
+			assert.Len(t, ir.Tables, len(tc.wantTables), "tables count mismatch")
+		})
+	}
+}
+
+func TestIR_DDL_CreatePublicationMalformedStillErrors(t *testing.T) {
+	_, err := ParseSQL("CREATE PUBLICATION p FOR TABLE orders (id WHERE")
+	require.Error(t, err, "unbalanced publication column list should fail to parse")
+}
+
+func TestIR_DDL_CreateSubscription(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE SUBSCRIPTION orders_sub CONNECTION 'host=primary port=5432 user=repl password=s3cret dbname=shop' PUBLICATION orders_pub, customers_pub WITH (copy_data = false)")
+	assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateSubscription, act.Type, "expected CREATE_SUBSCRIPTION")
+	assert.Equal(t, "orders_sub", act.ObjectName, "subscription name mismatch")
+	assert.Equal(t, "host=primary port=5432 user=repl password="+RedactedValue+" dbname=shop", act.Connection, "connection mismatch")
+	assert.NotContains(t, act.Connection, "s3cret", "password must be redacted")
+	assert.Equal(t, []string{"orders_pub", "customers_pub"}, act.Objects, "publications mismatch")
+	assert.Equal(t, []DDLOption{{Name: "copy_data", Value: "false"}}, act.Options, "options mismatch")
+}
+
+func TestIR_DDL_RedactedRawSQL(t *testing.T) {
+	tests := []struct {
+		name string
+		sql  string
+		want string
+	}{
+		{
+			name: "create subscription",
+			sql:  `CREATE SUBSCRIPTION orders_sub CONNECTION 'host=primary password=''it\''s'' dbname=shop' PUBLICATION orders_pub`,
+			want: "CREATE SUBSCRIPTION orders_sub CONNECTION 'host=primary password=" + RedactedValue + " dbname=shop' PUBLICATION orders_pub",
+		},
+		{
+			name: "create subscription without password",
+			sql:  "CREATE SUBSCRIPTION orders_sub CONNECTION 'host=primary dbname=shop' PUBLICATION orders_pub",
+			want: "CREATE SUBSCRIPTION orders_sub CONNECTION 'host=primary dbname=shop' PUBLICATION orders_pub",
+		},
+		{
+			name: "create subscription with malformed URI",
+			sql:  "CREATE SUBSCRIPTION orders_sub CONNECTION 'postgresql://rep:hun%zzter2@h1/db' PUBLICATION orders_pub",
+			want: "CREATE SUBSCRIPTION orders_sub CONNECTION 'postgresql://rep:" + RedactedValue + "@h1/db' PUBLICATION orders_pub",
+		},
+		{
+			name: "alter subscription connection",
+			sql:  "ALTER SUBSCRIPTION orders_sub CONNECTION 'host=replica password=s3cret'",
+			want: "ALTER SUBSCRIPTION orders_sub CONNECTION 'host=replica password=" + RedactedValue + "'",
+		},
+		{
+			name: "create server",
+			sql:  "CREATE SERVER billing FOREIGN DATA WRAPPER postgres_fdw OPTIONS (host 'billing-db', sslpassword 'k3y')",
+			want: "CREATE SERVER billing FOREIGN DATA WRAPPER postgres_fdw OPTIONS (host 'billing-db', sslpassword '" + RedactedValue + "')",
+		},
+		{
+			name: "alter server",
+			sql:  "ALTER SERVER billing OPTIONS (SET sslpassword 'n3w', ADD port '5433')",
+			want: "ALTER SERVER billing OPTIONS (SET sslpassword '" + RedactedValue + "', ADD port '5433')",
+		},
+		{
+			name: "create user mapping",
+			sql:  "CREATE USER MAPPING FOR app_user SERVER billing OPTIONS (user 'reporter', password 'hunter2', password_required 'false')",
+			want: "CREATE USER MAPPING FOR app_user SERVER billing OPTIONS (user 'reporter', password '" + RedactedValue + "', password_required 'false')",
+		},
+		{
+			name: "alter user mapping",
+			sql:  "ALTER USER MAPPING FOR app_user SERVER billing OPTIONS (SET password 'hunter3')",
+			want: "ALTER USER MAPPING FOR app_user SERVER billing OPTIONS (SET password '" + RedactedValue + "')",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			assert.Equal(t, tc.want, ir.RawSQL, "RawSQL mismatch")
+		})
+	}
+}
+
+func TestRedactConninfo(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{"host=db dbname=app", "host=db dbname=app"},
+		{"host=db password=secret", "host=db password=" + RedactedValue},
+		{"host=db password = 'with space' user=x", "host=db password = " + RedactedValue + " user=x"},
+		{"sslpassword=abc sslmode=require", "sslpassword=" + RedactedValue + " sslmode=require"},
+		{"postgresql://repl:secret@db:5432/app?sslmode=require", "postgresql://repl:" + RedactedValue + "@db:5432/app?sslmode=require"},
+		{"postgres://db/app?password=secret", "postgres://db/app?password=" + RedactedValue},
+		{"postgresql://rep:hun%zzter2@h1/db", "postgresql://rep:" + RedactedValue + "@h1/db"},
+		{"postgresql://h1/db?sslmode=require&password=hun%zz#x", "postgresql://h1/db?sslmode=require&password=" + RedactedValue + "#x"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, redactConninfo(tt.input), "input %q", tt.input)
+	}
+}
+
+// TestIR_DDL_CreateTableConstraints verifies inline and table-level constraints are extracted.
+func TestIR_DDL_CreateTableConstraints(t *testing.T) {
+	sql := `CREATE TABLE orders (
+    id bigint PRIMARY KEY,
+    user_id bigint NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    total numeric CHECK (total >= 0),
+    CONSTRAINT orders_ref_key UNIQUE (user_id, total)
+)`
+	ir := parseAssertNoError(t, sql)
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	want := []DDLConstraint{
+		{Type: DDLConstraintPrimaryKey, Columns: []string{"id"}},
+		{Type: DDLConstraintForeignKey, Columns: []string{"user_id"}, RefTable: "users", RefColumns: []string{"id"}, OnDelete: "CASCADE"},
+		{Type: DDLConstraintCheck, Columns: []string{"total"}, Expression: "total >= 0"},
+		{Name: "orders_ref_key", Type: DDLConstraintUnique, Columns: []string{"user_id", "total"}},
+	}
+	assert.Equal(t, want, ir.DDLActions[0].Constraints, "constraints mismatch")
+}
+
+// TestIR_DDL_AlterTableConstraints covers ADD, DROP, VALIDATE, and RENAME CONSTRAINT.
+func TestIR_DDL_AlterTableConstraints(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantFlags   []string
+		wantColumns []string
+		wantNewName string
+		want        DDLConstraint
+	}{
+		{
+			name:        "add foreign key not valid",
+			sql:         "ALTER TABLE orders ADD CONSTRAINT orders_user_fk FOREIGN KEY (user_id) REFERENCES public.users (id) NOT VALID",
+			wantFlags:   []string{"ADD_CONSTRAINT"},
+			wantColumns: []string{"user_id"},
+			want: DDLConstraint{
+				Name: "orders_user_fk", Type: DDLConstraintForeignKey, Columns: []string{"user_id"},
+				RefSchema: "public", RefTable: "users", RefColumns: []string{"id"}, NotValid: true,
+			},
+		},
+		{
+			name:        "add primary key",
+			sql:         "ALTER TABLE orders ADD PRIMARY KEY (id)",
+			wantFlags:   []string{"ADD_CONSTRAINT"},
+			wantColumns: []string{"id"},
+			want:        DDLConstraint{Type: DDLConstraintPrimaryKey, Columns: []string{"id"}},
+		},
+		{
+			name:      "drop constraint",
+			sql:       "ALTER TABLE orders DROP CONSTRAINT IF EXISTS orders_user_fk CASCADE",
+			wantFlags: []string{"DROP_CONSTRAINT", "IF_EXISTS", "CASCADE"},
+			want:      DDLConstraint{Name: "orders_user_fk"},
+		},
+		{
+			name:      "validate constraint",
+			sql:       "ALTER TABLE orders VALIDATE CONSTRAINT orders_user_fk",
+			wantFlags: []string{"VALIDATE_CONSTRAINT"},
+			want:      DDLConstraint{Name: "orders_user_fk"},
+		},
+		{
+			name:        "rename constraint",
+			sql:         "ALTER TABLE orders RENAME CONSTRAINT orders_user_fk TO orders_buyer_fk",
+			wantFlags:   []string{"RENAME_CONSTRAINT"},
+			wantNewName: "orders_buyer_fk",
+			want:        DDLConstraint{Name: "orders_user_fk"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, DDLAlterTable, act.Type, "expected ALTER_TABLE")
+			assert.Equal(t, "orders", act.ObjectName, "object name mismatch")
+			for _, flag := range tc.wantFlags {
+				assert.Contains(t, act.Flags, flag, "expected flag %s", flag)
+			}
+			assert.Equal(t, tc.wantColumns, act.Columns, "columns mismatch")
+			assert.Equal(t, tc.wantNewName, act.NewName, "new name mismatch")
+			assert.Equal(t, []DDLConstraint{tc.want}, act.Constraints, "constraint mismatch")
+		})
+	}
+}
+
+// TestIR_DDL_AlterColumnSubcommands verifies ALTER COLUMN sub-commands and their arguments.
+func TestIR_DDL_AlterColumnSubcommands(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantFlags   []string
+		wantDetails []DDLColumn
+	}{
+		{
+			name:        "set type using",
+			sql:         "ALTER TABLE orders ALTER COLUMN total TYPE numeric(12,2) USING total::numeric(12,2)",
+			wantFlags:   []string{"ALTER_COLUMN", "SET_TYPE", "USING"},
+			wantDetails: []DDLColumn{{Name: "total", Type: "numeric(12,2)"}},
+		},
+		{
+			name:        "set default",
+			sql:         "ALTER TABLE orders ALTER COLUMN total SET DEFAULT 0",
+			wantFlags:   []string{"ALTER_COLUMN", "SET_DEFAULT"},
+			wantDetails: []DDLColumn{{Name: "total", Default: "0"}},
+		},
+		{
+			name:      "drop default",
+			sql:       "ALTER TABLE orders ALTER COLUMN total DROP DEFAULT",
+			wantFlags: []string{"ALTER_COLUMN", "DROP_DEFAULT"},
+		},
+		{
+			name:      "set not null",
+			sql:       "ALTER TABLE orders ALTER COLUMN total SET NOT NULL",
+			wantFlags: []string{"ALTER_COLUMN", "SET_NOT_NULL"},
+		},
+		{
+			name:      "drop not null",
+			sql:       "ALTER TABLE orders ALTER COLUMN total DROP NOT NULL",
+			wantFlags: []string{"ALTER_COLUMN", "DROP_NOT_NULL"},
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, []string{"total"}, act.Columns, "column mismatch")
+			assert.Equal(t, tc.wantFlags, act.Flags, "flags mismatch")
+			assert.Equal(t, tc.wantDetails, act.ColumnDetails, "column details mismatch")
+		})
+	}
+}
+
+// TestIR_DDL_AddColumnDetails verifies ADD COLUMN reports the column definition and its constraints.
+func TestIR_DDL_AddColumnDetails(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TABLE orders ADD COLUMN coupon_id int NOT NULL DEFAULT 0 REFERENCES coupons")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, []DDLColumn{{Name: "coupon_id", Type: "int", Default: "0"}}, act.ColumnDetails, "column details mismatch")
+	require.Len(t, act.Constraints, 1, "constraint count mismatch")
+	assert.Equal(t, DDLConstraintForeignKey, act.Constraints[0].Type, "constraint type mismatch")
+	assert.Equal(t, "coupons", act.Constraints[0].RefTable, "referenced table mismatch")
+	assert.Empty(t, act.Constraints[0].RefColumns, "expected implicit primary key reference")
+}
+
+// TestIR_DDL_GeneratedAndIdentityColumns verifies generated and identity columns report their generation.
+func TestIR_DDL_GeneratedAndIdentityColumns(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE TABLE items (
+    id bigint GENERATED BY DEFAULT AS IDENTITY,
+    seq int GENERATED ALWAYS AS IDENTITY,
+    total numeric GENERATED ALWAYS AS (price * qty) STORED
+)`)
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	want := []DDLColumn{
+		{Name: "id", Type: "bigint", Identity: "BY DEFAULT"},
+		{Name: "seq", Type: "int", Identity: "ALWAYS"},
+		{Name: "total", Type: "numeric", Nullable: true, Generated: "price * qty"},
+	}
+	assert.Equal(t, want, ir.DDLActions[0].ColumnDetails, "column details mismatch")
+}
+
+// TestIR_DDL_ConstraintUsingIndex verifies ADD CONSTRAINT ... USING INDEX reports the index.
+func TestIR_DDL_ConstraintUsingIndex(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TABLE users ADD CONSTRAINT users_email_key UNIQUE USING INDEX users_email_idx")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	require.Len(t, ir.DDLActions[0].Constraints, 1, "constraint count mismatch")
+
+	con := ir.DDLActions[0].Constraints[0]
+	assert.Equal(t, DDLConstraintUnique, con.Type, "constraint type mismatch")
+	assert.Equal(t, "users_email_key", con.Name, "constraint name mismatch")
+	assert.Equal(t, "users_email_idx", con.Index, "index mismatch")
+}
+
+// TestIR_DDL_Rename covers RENAME for tables, columns, indexes, and schemas.
+func TestIR_DDL_Rename(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantType    DDLActionType
+		wantKind    DDLObjectKind
+		wantSchema  string
+		wantObject  string
+		wantFlag    string
+		wantColumns []string
+		wantNewName string
+	}{
+		{
+			name:        "table",
+			sql:         "ALTER TABLE public.orders RENAME TO purchases",
+			wantType:    DDLAlterTable,
+			wantKind:    DDLObjectTable,
+			wantSchema:  "public",
+			wantObject:  "orders",
+			wantFlag:    "RENAME",
+			wantNewName: "purchases",
+		},
+		{
+			name:        "column",
+			sql:         "ALTER TABLE orders RENAME COLUMN total TO amount",
+			wantType:    DDLAlterTable,
+			wantKind:    DDLObjectTable,
+			wantObject:  "orders",
+			wantFlag:    "RENAME_COLUMN",
+			wantColumns: []string{"total"},
+			wantNewName: "amount",
+		},
+		{
+			name:        "index",
+			sql:         "ALTER INDEX orders_idx RENAME TO purchases_idx",
+			wantType:    DDLAlter,
+			wantKind:    DDLObjectIndex,
+			wantObject:  "orders_idx",
+			wantFlag:    "RENAME",
+			wantNewName: "purchases_idx",
+		},
+		{
+			name:        "schema",
+			sql:         "ALTER SCHEMA app RENAME TO core",
+			wantType:    DDLAlter,
+			wantKind:    DDLObjectSchema,
+			wantObject:  "app",
+			wantFlag:    "RENAME",
+			wantNewName: "core",
+		},
+	}
+
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+			act := ir.DDLActions[0]
+			assert.Equal(t, tc.wantType, act.Type, "type mismatch")
+			assert.Equal(t, tc.wantKind, act.ObjectKind, "object kind mismatch")
+			assert.Equal(t, tc.wantSchema, act.Schema, "schema mismatch")
+			assert.Equal(t, tc.wantObject, act.ObjectName, "object name mismatch")
+			assert.Equal(t, []string{tc.wantFlag}, act.Flags, "flags mismatch")
+			assert.Equal(t, tc.wantColumns, act.Columns, "columns mismatch")
+			assert.Equal(t, tc.wantNewName, act.NewName, "new name mismatch")
+		})
+	}
+}
+
+// TestIR_DDL_CreateTypeAndDomain covers CREATE TYPE and CREATE DOMAIN.
+func TestIR_DDL_CreateTypeAndDomain(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE TYPE public.mood AS ENUM ('sad', 'ok', 'happy')")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateType, act.Type, "expected CREATE_TYPE")
+	assert.Equal(t, "public", act.Schema, "schema mismatch")
+	assert.Equal(t, "mood", act.ObjectName, "object name mismatch")
+	assert.Equal(t, []string{"ENUM"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []string{"sad", "ok", "happy"}, act.EnumValues, "enum values mismatch")
+
+	ir = parseAssertNoError(t, "CREATE TYPE pair AS (a int, b text)")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act = ir.DDLActions[0]
+	assert.Equal(t, []string{"COMPOSITE"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []DDLColumn{{Name: "a", Type: "int", Nullable: true}, {Name: "b", Type: "text", Nullable: true}}, act.ColumnDetails, "attributes mismatch")
+
+	ir = parseAssertNoError(t, "CREATE DOMAIN posint AS integer NOT NULL DEFAULT 1 CHECK (VALUE > 0)")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act = ir.DDLActions[0]
+	assert.Equal(t, DDLCreateDomain, act.Type, "expected CREATE_DOMAIN")
+	assert.Equal(t, DDLObjectDomain, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, "integer", act.DataType, "data type mismatch")
+	assert.Contains(t, act.Flags, "NOT_NULL", "expected flag NOT_NULL")
+	require.Len(t, act.Constraints, 1, "constraint count mismatch")
+	assert.Equal(t, "VALUE > 0", act.Constraints[0].Expression, "check expression mismatch")
+}
+
+// TestIR_DDL_AlterEnum covers ALTER TYPE ... ADD VALUE and RENAME VALUE.
+func TestIR_DDL_AlterEnum(t *testing.T) {
+	ir := parseAssertNoError(t, "ALTER TYPE mood ADD VALUE IF NOT EXISTS 'meh' BEFORE 'ok'")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLAlter, act.Type, "expected ALTER")
+	assert.Equal(t, DDLObjectType, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, []string{"ADD_VALUE", "IF_NOT_EXISTS", "BEFORE"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []string{"meh"}, act.EnumValues, "enum value mismatch")
+	assert.Equal(t, []string{"ok"}, act.Objects, "neighbour mismatch")
+
+	ir = parseAssertNoError(t, "ALTER TYPE mood RENAME VALUE 'sad' TO 'blue'")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act = ir.DDLActions[0]
+	assert.Equal(t, []string{"RENAME_VALUE"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []string{"sad"}, act.EnumValues, "enum value mismatch")
+	assert.Equal(t, "blue", act.NewName, "new name mismatch")
+}
+
+// TestIR_DDL_CreateIndexTable verifies CREATE INDEX records the indexed table.
+func TestIR_DDL_CreateIndexTable(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE INDEX ON app.orders (user_id)")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLObjectIndex, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, "app", act.Schema, "schema mismatch")
+	assert.Equal(t, "orders", act.Table, "table mismatch")
+	assert.Empty(t, act.ObjectName, "expected unnamed index")
+}
+
+// TestIR_DDL_CreateIndexIncludeAndPredicate verifies INCLUDE columns and partial index predicates.
+func TestIR_DDL_CreateIndexIncludeAndPredicate(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE INDEX orders_open_idx ON orders (lower(ref) DESC) INCLUDE (total, \"Status\") WHERE  status <> 'closed'")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+
+	act := ir.DDLActions[0]
+	assert.Equal(t, []string{"lower(ref) DESC"}, act.Columns, "columns mismatch")
+	assert.Equal(t, []string{"total", "\"Status\""}, act.IncludeColumns, "include columns mismatch")
+	assert.Equal(t, "status <> 'closed'", act.Predicate, "predicate mismatch")
+}
+
+// TestIR_DDL_ExcludeConstraint verifies EXCLUDE constraints keep their definition.
+func TestIR_DDL_ExcludeConstraint(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE TABLE bookings (room int, during tsrange, CONSTRAINT no_overlap EXCLUDE USING gist (room WITH =, during WITH &&) DEFERRABLE)")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	require.Len(t, ir.DDLActions[0].Constraints, 1, "constraint count mismatch")
+
+	con := ir.DDLActions[0].Constraints[0]
+	assert.Equal(t, DDLConstraintExclude, con.Type, "constraint type mismatch")
+	assert.Equal(t, "USING gist (room WITH =, during WITH &&)", con.Expression, "expression mismatch")
+}
+
+// TestIR_DDL_OnlyKeyword verifies ONLY is not mistaken for part of the schema name.
+func TestIR_DDL_OnlyKeyword(t *testing.T) {
+	for _, sql := range []string{
+		"ALTER TABLE ONLY public.orders ADD CONSTRAINT orders_pkey PRIMARY KEY (id)",
+		"CREATE INDEX orders_idx ON ONLY public.orders (id)",
+		"TRUNCATE ONLY public.orders",
+	} {
+		t.Run(sql, func(t *testing.T) {
+			ir := parseAssertNoError(t, sql)
+			require.Len(t, ir.DDLActions, 1, "action count mismatch")
+			assert.Equal(t, "public", ir.DDLActions[0].Schema, "schema mismatch")
+			require.Len(t, ir.Tables, 1, "table count mismatch")
+			assert.Equal(t, "public", ir.Tables[0].Schema, "table schema mismatch")
+			assert.Equal(t, "orders", ir.Tables[0].Name, "table name mismatch")
+		})
+	}
+}
+
+// TestIR_DDL_CreateView verifies CREATE VIEW records the view and its defining query.
+func TestIR_DDL_CreateView(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE OR REPLACE VIEW app.active_users (id, mail) WITH (security_barrier = true) AS
+		SELECT u.id, u.email FROM users u WHERE u.active WITH CHECK OPTION`)
+	assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateView, act.Type, "expected CREATE_VIEW")
+	assert.Equal(t, DDLObjectView, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, "app", act.Schema, "schema mismatch")
+	assert.Equal(t, "active_users", act.ObjectName, "object name mismatch")
+	assert.Equal(t, []string{"id", "mail"}, act.Columns, "column list mismatch")
+	assert.Equal(t, []string{"OR_REPLACE", "CHECK_OPTION"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []DDLOption{{Name: "security_barrier", Value: "true"}}, act.Options, "options mismatch")
+
+	require.NotNil(t, ir.Source, "expected defining query")
+	require.Len(t, ir.Source.Columns, 2, "source column count mismatch")
+	assert.Equal(t, "u.email", ir.Source.Columns[1].Expression, "source column mismatch")
+	require.Len(t, ir.Tables, 1, "table count mismatch")
+	assert.Equal(t, "users", ir.Tables[0].Name, "read table mismatch")
+}
+
+// TestIR_DDL_CreateMaterializedView verifies the materialized view is the Target.
+func TestIR_DDL_CreateMaterializedView(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE MATERIALIZED VIEW IF NOT EXISTS sales_by_day AS SELECT day, sum(total) FROM orders GROUP BY day WITH NO DATA")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateView, act.Type, "expected CREATE_VIEW")
+	assert.Equal(t, DDLObjectMaterializedView, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, []string{"IF_NOT_EXISTS", "WITH_NO_DATA"}, act.Flags, "flags mismatch")
+
+	require.NotNil(t, ir.Target, "expected target")
+	assert.Equal(t, "sales_by_day", ir.Target.Name, "target mismatch")
+	require.NotNil(t, ir.Source, "expected defining query")
+	require.Len(t, ir.Tables, 2, "table count mismatch")
+	assert.Equal(t, "orders", ir.Tables[1].Name, "read table mismatch")
+}
+
+// TestIR_DDL_CreateSequence verifies CREATE SEQUENCE options use the ALTER SEQUENCE names.
+func TestIR_DDL_CreateSequence(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE UNLOGGED SEQUENCE IF NOT EXISTS public.orders_id_seq
+		AS integer START WITH 1 INCREMENT BY 1 NO MINVALUE CACHE 1 OWNED BY public.orders.id`)
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateSequence, act.Type, "expected CREATE_SEQUENCE")
+	assert.Equal(t, DDLObjectSequence, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, "public", act.Schema, "schema mismatch")
+	assert.Equal(t, "orders_id_seq", act.ObjectName, "object name mismatch")
+	assert.Equal(t, []string{"IF_NOT_EXISTS", "UNLOGGED"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []DDLOption{
+		{Name: "AS", Value: "integer"},
+		{Name: "START", Value: "1"},
+		{Name: "INCREMENT", Value: "1"},
+		{Name: "NO MINVALUE"},
+		{Name: "CACHE", Value: "1"},
+		{Name: "OWNED BY", Value: "public.orders.id"},
+	}, act.Options, "options mismatch")
+}
+
+// TestIR_DDL_CreateFunction verifies the signature, return type, language, and body of CREATE FUNCTION.
+func TestIR_DDL_CreateFunction(t *testing.T) {
+	ir := parseAssertNoError(t, `CREATE OR REPLACE FUNCTION app.order_total(order_id bigint, OUT total numeric, tax numeric DEFAULT 0)
+		RETURNS numeric LANGUAGE SQL STABLE STRICT AS $$ SELECT sum(amount) FROM order_items WHERE order_items.order_id = order_total.order_id $$`)
+	assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateFunction, act.Type, "expected CREATE_FUNCTION")
+	assert.Equal(t, DDLObjectFunction, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, "app", act.Schema, "schema mismatch")
+	assert.Equal(t, "order_total", act.ObjectName, "object name mismatch")
+	assert.Equal(t, "(bigint, numeric)", act.Signature, "OUT arguments are not part of the signature")
+	assert.Equal(t, "numeric", act.DataType, "return type mismatch")
+	assert.Equal(t, []string{"OR_REPLACE", "STABLE", "STRICT"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []DDLOption{
+		{Name: "LANGUAGE", Value: "sql"},
+		{Name: "AS", Value: " SELECT sum(amount) FROM order_items WHERE order_items.order_id = order_total.order_id "},
+	}, act.Options, "options mismatch")
+
+	ir = parseAssertNoError(t, "CREATE PROCEDURE archive(days int) LANGUAGE plpgsql AS 'BEGIN DELETE FROM logs; END'")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act = ir.DDLActions[0]
+	assert.Equal(t, DDLObjectProcedure, act.ObjectKind, "object kind mismatch")
+	assert.Empty(t, act.DataType, "procedures return nothing")
+	assert.Equal(t, []DDLOption{{Name: "LANGUAGE", Value: "plpgsql"}, {Name: "AS", Value: "BEGIN DELETE FROM logs; END"}}, act.Options, "options mismatch")
+
+	ir = parseAssertNoError(t, "CREATE FUNCTION recent(n int) RETURNS TABLE (id bigint, at timestamptz) LANGUAGE sql AS 'SELECT id, at FROM events LIMIT n'")
+	assert.Equal(t, "TABLE (id bigint, at timestamptz)", ir.DDLActions[0].DataType, "table return type mismatch")
+}
+
+// TestIR_DDL_CreateTrigger verifies the table, timing, events, and function of CREATE TRIGGER.
+func TestIR_DDL_CreateTrigger(t *testing.T) {
+	ir := parseAssertNoError(t, "CREATE TRIGGER orders_audit BEFORE INSERT OR UPDATE OF status, total ON app.orders FOR EACH ROW EXECUTE FUNCTION audit.log_change('orders')")
+	assert.Equal(t, QueryCommandDDL, ir.Command, "expected DDL command")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	act := ir.DDLActions[0]
+	assert.Equal(t, DDLCreateTrigger, act.Type, "expected CREATE_TRIGGER")
+	assert.Equal(t, DDLObjectTrigger, act.ObjectKind, "object kind mismatch")
+	assert.Equal(t, "orders_audit", act.ObjectName, "object name mismatch")
+	assert.Equal(t, "app", act.Schema, "schema mismatch")
+	assert.Equal(t, "orders", act.Table, "table mismatch")
+	assert.Equal(t, []string{"status", "total"}, act.Columns, "UPDATE OF columns mismatch")
+	assert.Equal(t, []string{"BEFORE", "INSERT", "UPDATE", "FOR_EACH_ROW"}, act.Flags, "flags mismatch")
+	assert.Equal(t, []DDLOption{{Name: "EXECUTE FUNCTION", Value: "audit.log_change"}}, act.Options, "options mismatch")
+	require.Len(t, ir.Tables, 1, "table count mismatch")
+	assert.Equal(t, "orders", ir.Tables[0].Name, "table mismatch")
+
+	ir = parseAssertNoError(t, "CREATE CONSTRAINT TRIGGER check_stock AFTER DELETE ON items DEFERRABLE INITIALLY DEFERRED FOR EACH ROW EXECUTE PROCEDURE check_stock()")
+	require.Len(t, ir.DDLActions, 1, "action count mismatch")
+	assert.Equal(t, []string{"CONSTRAINT", "AFTER", "DELETE", "FOR_EACH_ROW"}, ir.DDLActions[0].Flags, "flags mismatch")
+	assert.Equal(t, []DDLOption{{Name: "EXECUTE FUNCTION", Value: "check_stock"}}, ir.DDLActions[0].Options, "options mismatch")
+}
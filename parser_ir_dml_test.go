@@ -284,3 +284,66 @@ ON CONFLICT (id) WHERE is_active = TRUE DO NOTHING`
 	assert.Contains(t, ir.Upsert.TargetWhere, "is_active", "expected target WHERE clause to capture predicate")
 	assert.Empty(t, ir.Upsert.SetClauses, "expected no set clauses for DO NOTHING")
 }
+
+// TestIR_InsertSelectSource verifies INSERT ... SELECT exposes the target and a nested source query.
+func TestIR_InsertSelectSource(t *testing.T) {
+	sql := `
+INSERT INTO archive.orders (id, total)
+SELECT o.id, o.total
+FROM orders o
+JOIN customers c ON c.id = o.customer_id
+WHERE o.created_at < $1`
+
+	ir := parseAssertNoError(t, sql)
+
+	assert.Equal(t, QueryCommandInsert, ir.Command, "expected INSERT command")
+	require.NotNil(t, ir.Target, "expected write target")
+	assert.Equal(t, "archive", ir.Target.Schema, "target schema mismatch")
+	assert.Equal(t, "orders", ir.Target.Name, "target name mismatch")
+
+	require.NotNil(t, ir.Source, "expected nested source query")
+	assert.Equal(t, QueryCommandSelect, ir.Source.Command, "source should be a SELECT")
+	require.Len(t, ir.Source.Tables, 2, "source tables mismatch")
+	assert.Equal(t, "orders", ir.Source.Tables[0].Name, "unexpected first source table")
+	assert.Empty(t, ir.Source.Tables[0].Schema, "source orders should not be the archive target")
+	assert.Equal(t, "customers", ir.Source.Tables[1].Name, "unexpected second source table")
+	require.Len(t, ir.Source.Columns, 2, "source projection mismatch")
+	assert.Equal(t, []string{"o.created_at < $1"}, ir.Source.Where, "source WHERE mismatch")
+}
+
+// TestIR_InsertParenthesizedAndTableSource verifies a parenthesized SELECT and the TABLE
+// form are unwrapped into the source query.
+func TestIR_InsertParenthesizedAndTableSource(t *testing.T) {
+	tests := []struct {
+		name        string
+		sql         string
+		wantColumns []string
+	}{
+		{name: "parenthesized", sql: "INSERT INTO archive (SELECT * FROM orders WHERE closed)", wantColumns: []string{"*"}},
+		{name: "doubly parenthesized", sql: "INSERT INTO archive ((SELECT id FROM orders))", wantColumns: []string{"id"}},
+		{name: "TABLE form", sql: "INSERT INTO archive TABLE orders", wantColumns: []string{"*"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ir := parseAssertNoError(t, tc.sql)
+			require.NotNil(t, ir.Source, "expected nested source query")
+			require.Len(t, ir.Source.Tables, 1, "source tables mismatch")
+			assert.Equal(t, "orders", ir.Source.Tables[0].Name, "source table mismatch")
+			var cols []string
+			for _, col := range ir.Source.Columns {
+				cols = append(cols, col.Expression)
+			}
+			assert.Equal(t, tc.wantColumns, cols, "source projection mismatch")
+			assert.True(t, containsTable(ir.Tables, "orders"), "expected orders in Tables")
+		})
+	}
+}
+
+// TestIR_InsertValuesHasNoSource verifies VALUES inserts record a target but no source query.
+func TestIR_InsertValuesHasNoSource(t *testing.T) {
+	ir := parseAssertNoError(t, "INSERT INTO users (id) VALUES ($1)")
+
+	require.NotNil(t, ir.Target, "expected write target")
+	assert.Equal(t, "users", ir.Target.Name, "target name mismatch")
+	assert.Nil(t, ir.Source, "VALUES should not produce a source query")
+}
@@ -51,6 +51,26 @@ func TestSplitQualifiedName(t *testing.T) {
 	}
 }
 
+// TestUnquoteStringLiteral verifies string constant unquoting for each literal form.
+func TestUnquoteStringLiteral(t *testing.T) {
+	tests := []struct {
+		input string
+		want  string
+	}{
+		{`'plain'`, "plain"},
+		{`'it''s'`, "it's"},
+		{`E'line\nnext'`, "line\nnext"},
+		{`E'it\'s'`, "it's"},
+		{`$$body$$`, "body"},
+		{`$tag$a $$ b$tag$`, "a $$ b"},
+		{`U&'d\0061ta'`, `d\0061ta`},
+		{`unquoted`, "unquoted"},
+	}
+	for _, tt := range tests {
+		assert.Equal(t, tt.want, unquoteStringLiteral(tt.input), "input %q", tt.input)
+	}
+}
+
 // normalise collapses whitespace and lowercases strings for comparison convenience.
 func normalise(s string) string {
 	compact := strings.ReplaceAll(strings.ReplaceAll(strings.ReplaceAll(strings.TrimSpace(s), " ", ""), "\n", ""), "\t", "")
@@ -0,0 +1,82 @@
+// parser_ir_scope_test.go covers query scopes and column reference positions.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestIR_ScopesSelect checks the scope tree of a query using a CTE, a join with USING,
+// a LATERAL subquery, an EXISTS subquery, and a UNION branch.
+func TestIR_ScopesSelect(t *testing.T) {
+	sql := `WITH recent AS (SELECT user_id, max(created_at) AS last FROM orders GROUP BY user_id)
+SELECT u.id, r.last, s.total FROM users u JOIN recent r USING (user_id)
+LEFT JOIN LATERAL (SELECT sum(amount) AS total FROM payments p WHERE p.user_id = u.id) s ON true
+WHERE EXISTS (SELECT 1 FROM bans WHERE bans.uid = u.id)
+UNION SELECT 1, now(), 0`
+	ir := parseAssertNoError(t, sql)
+
+	require.Len(t, ir.Scopes, 5, "expected statement, CTE, lateral, subquery and set-operation scopes")
+	kinds := make([]ScopeKind, len(ir.Scopes))
+	for i, s := range ir.Scopes {
+		kinds[i] = s.Kind
+	}
+	assert.Equal(t, []ScopeKind{ScopeStatement, ScopeCTE, ScopeLateral, ScopeSubquery, ScopeSetOperation}, kinds, "scope kinds")
+
+	stmt := ir.Scopes[0]
+	assert.Equal(t, -1, stmt.Parent, "statement has no parent")
+	assert.Equal(t, 0, stmt.Start, "statement start")
+	assert.Equal(t, len(ir.RawSQL), stmt.End, "statement end")
+	require.Len(t, stmt.Relations, 3, "statement relations")
+	assert.Equal(t, ScopeRelation{Table: TableRef{Name: "users", Alias: "u", Type: TableTypeBase, Raw: "users"}, Scope: -1}, stmt.Relations[0])
+	assert.Equal(t, TableTypeCTE, stmt.Relations[1].Table.Type, "recent is a CTE")
+	assert.Equal(t, 1, stmt.Relations[1].Scope, "recent points at its body")
+	assert.Equal(t, 2, stmt.Relations[2].Scope, "s points at the lateral subquery")
+	require.Len(t, stmt.Joins, 2, "statement joins")
+	assert.Equal(t, ScopeJoin{Type: "INNER", Using: []string{"user_id"}, Left: []int{0}, Right: []int{1}, Position: stmt.Joins[0].Position}, stmt.Joins[0])
+	assert.Equal(t, "USING (user_id)", ir.RawSQL[stmt.Joins[0].Position:stmt.Joins[0].Position+15], "USING position")
+	assert.Equal(t, "LEFT", stmt.Joins[1].Type, "lateral join type")
+	assert.Equal(t, []int{0, 1}, stmt.Joins[1].Left, "lateral join left side")
+	assert.Len(t, stmt.Columns, 3, "statement output columns")
+
+	cte := ir.Scopes[1]
+	assert.Equal(t, "recent", cte.Name, "CTE name")
+	assert.Equal(t, "SELECT user_id", ir.RawSQL[cte.Start:cte.Start+14], "CTE body start")
+	assert.Equal(t, []SelectColumn{{Expression: "user_id"}, {Expression: "max(created_at)", Alias: "last"}}, cte.Columns, "CTE output columns")
+
+	assert.Equal(t, "s", ir.Scopes[2].Name, "lateral alias")
+	assert.Equal(t, "bans", ir.Scopes[3].Relations[0].Table.Name, "EXISTS relation")
+	assert.Equal(t, 0, ir.Scopes[4].Parent, "set-operation branch parent")
+}
+
+// TestIR_ScopesDML checks that the target table is the first relation of a DML statement.
+func TestIR_ScopesDML(t *testing.T) {
+	ir := parseAssertNoError(t, `UPDATE orders o SET total = p.amount FROM payments p WHERE p.order_id = o.id RETURNING o.id`)
+	require.Len(t, ir.Scopes, 1, "update scopes")
+	require.Len(t, ir.Scopes[0].Relations, 2, "update relations")
+	assert.Equal(t, "orders", ir.Scopes[0].Relations[0].Table.Name, "target first")
+	assert.Equal(t, "o", ir.Scopes[0].Relations[0].Table.Alias, "target alias")
+	assert.Equal(t, "payments", ir.Scopes[0].Relations[1].Table.Name, "FROM relation")
+	assert.Equal(t, []SelectColumn{{Expression: "o.id"}}, ir.Scopes[0].Columns, "RETURNING columns")
+
+	ir = parseAssertNoError(t, `INSERT INTO archive SELECT * FROM orders WHERE total > 0`)
+	require.Len(t, ir.Scopes, 2, "insert scopes")
+	assert.Equal(t, ScopeSource, ir.Scopes[1].Kind, "INSERT source scope")
+	assert.Equal(t, "orders", ir.Scopes[1].Relations[0].Table.Name, "source relation")
+
+	ir = parseAssertNoError(t, `CREATE TABLE t (id int)`)
+	assert.Nil(t, ir.Scopes, "DDL has no scopes")
+}
+
+// TestIR_ColumnUsagePosition checks that each usage records the offset of its reference.
+func TestIR_ColumnUsagePosition(t *testing.T) {
+	ir := parseAssertNoError(t, `SELECT u.id, name FROM users u WHERE u.email = $1 ORDER BY name`)
+	require.NotEmpty(t, ir.ColumnUsage, "expected column usage")
+	for _, cu := range ir.ColumnUsage {
+		end := cu.Position + len(cu.Expression)
+		require.LessOrEqual(t, end, len(ir.RawSQL), "position of %s out of range", cu.Expression)
+		assert.Equal(t, cu.Expression, ir.RawSQL[cu.Position:end], "text at position of %s usage", cu.UsageType)
+	}
+}
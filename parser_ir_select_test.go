@@ -392,3 +392,25 @@ func TestContainsWordDot(t *testing.T) {
 		assert.Equal(t, tt.want, got, "containsWordDot(%q, %q)", tt.text, tt.word)
 	}
 }
+
+func TestIR_FunctionCalls(t *testing.T) {
+	sql := "SELECT count(*), app.f(u.id, 'x'), now() FROM users u WHERE u.id = (SELECT max(id) FROM users)"
+	ir := parseAssertNoError(t, sql)
+
+	require.Len(t, ir.Functions, 4, "function calls")
+	assert.Equal(t, FunctionCall{Name: "count", Args: []string{"*"}, Position: 7}, ir.Functions[0], "count(*)")
+	assert.Equal(t, FunctionCall{Schema: "app", Name: "f", Args: []string{"u.id", "'x'"}, Position: 17}, ir.Functions[1], "qualified call")
+	assert.Equal(t, "now", ir.Functions[2].Name, "call without arguments")
+	assert.Empty(t, ir.Functions[2].Args, "call without arguments")
+	assert.Equal(t, "max", ir.Functions[3].Name, "call in a subquery")
+}
+
+func TestIR_LockingClauses(t *testing.T) {
+	sql := "SELECT * FROM jobs j JOIN queues q ON q.id = j.queue_id WHERE j.id IN (SELECT id FROM runs FOR KEY SHARE) FOR NO KEY UPDATE OF j, app.q SKIP LOCKED FOR SHARE OF q NOWAIT"
+	ir := parseAssertNoError(t, sql)
+
+	require.Len(t, ir.Locking, 3, "locking clauses")
+	assert.Equal(t, LockingClause{Strength: "KEY SHARE", Position: 91}, ir.Locking[0], "subquery clause")
+	assert.Equal(t, LockingClause{Strength: "NO KEY UPDATE", Tables: []string{"j", "app.q"}, Wait: "SKIP LOCKED", Position: 106}, ir.Locking[1], "OF list")
+	assert.Equal(t, LockingClause{Strength: "SHARE", Tables: []string{"q"}, Wait: "NOWAIT", Position: 148}, ir.Locking[2], "NOWAIT")
+}
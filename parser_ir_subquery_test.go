@@ -0,0 +1,101 @@
+// parser_ir_subquery_test.go covers nested subqueries and their correlations.
+package postgresparser
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// subqueryTables returns the table names of each subquery's own IR.
+func subqueryTables(t *testing.T, subs []NestedSubquery) [][]string {
+	t.Helper()
+	out := make([][]string, len(subs))
+	for i, sub := range subs {
+		require.NotNil(t, sub.Query, "subquery %d IR", i)
+		for _, tbl := range sub.Query.Tables {
+			out[i] = append(out[i], tbl.Name)
+		}
+	}
+	return out
+}
+
+// TestIR_NestedSubqueriesKinds checks the kind, nesting, and tables of subqueries in
+// the SELECT list, FROM, and WHERE.
+func TestIR_NestedSubqueriesKinds(t *testing.T) {
+	sql := `SELECT u.id, (SELECT count(*) FROM orders o WHERE o.user_id = u.id) AS n, ARRAY(SELECT name FROM tags) AS tags
+FROM users u, LATERAL (SELECT * FROM logins l WHERE l.uid = u.id LIMIT 1) ll, (SELECT * FROM plans) p
+WHERE EXISTS (SELECT 1 FROM perms WHERE perms.kind IN (SELECT k.kind FROM kinds k))
+AND u.id NOT IN (SELECT user_id FROM bans) AND u.score > ALL (SELECT score FROM limits)`
+	ir := parseAssertNoError(t, sql)
+
+	subs := ir.NestedSubqueries
+	require.Len(t, subs, 8, "every subquery is recorded")
+	kinds := make([]SubqueryKind, len(subs))
+	parents := make([]int, len(subs))
+	for i, sub := range subs {
+		kinds[i] = sub.Kind
+		parents[i] = sub.Parent
+		assert.Equal(t, sub.Position, ir.Scopes[sub.Scope].Start, "subquery %d scope", i)
+		assert.Equal(t, byte('('), ir.RawSQL[sub.Position], "subquery %d position", i)
+	}
+	assert.Equal(t, []SubqueryKind{
+		SubqueryScalar, SubqueryArray, SubqueryLateral, SubqueryDerived,
+		SubqueryExists, SubqueryIn, SubqueryIn, SubqueryQuantified,
+	}, kinds, "kinds in order of position")
+	assert.Equal(t, []int{-1, -1, -1, -1, -1, 4, -1, -1}, parents, "only the kinds list is nested")
+	assert.Equal(t, [][]string{{"orders"}, {"tags"}, {"logins"}, {"plans"}, {"perms"}, {"kinds"}, {"bans"}, {"limits"}}, subqueryTables(t, subs))
+	assert.Equal(t, "ll", subs[2].Alias, "lateral alias")
+	assert.Equal(t, "p", subs[3].Alias, "derived alias")
+}
+
+// TestIR_NestedSubqueriesCorrelations checks that references to outer relations are
+// recorded as correlations and linked from each subquery they reach out of.
+func TestIR_NestedSubqueriesCorrelations(t *testing.T) {
+	ir := parseAssertNoError(t, `SELECT * FROM users u, LATERAL (SELECT * FROM logins l WHERE l.uid = u.id) ll
+WHERE EXISTS (SELECT 1 FROM perms p WHERE p.uid = u.id AND p.kind IN (SELECT k.kind FROM kinds k WHERE k.owner = u.id))`)
+
+	assert.Equal(t, []JoinCorrelation{
+		{OuterAlias: "u", InnerAlias: "l", Expression: "l.uid = u.id", Type: "LATERAL"},
+		{OuterAlias: "u", InnerAlias: "p", Expression: "p.uid = u.id", Type: "CORRELATED"},
+		{OuterAlias: "u", InnerAlias: "k", Expression: "k.owner = u.id", Type: "CORRELATED"},
+	}, ir.Correlations)
+	require.Len(t, ir.NestedSubqueries, 3)
+	assert.Equal(t, []int{0}, ir.NestedSubqueries[0].Correlations, "lateral")
+	assert.Equal(t, []int{1, 2}, ir.NestedSubqueries[1].Correlations, "EXISTS sees the reference of its nested subquery")
+	assert.Equal(t, []int{2}, ir.NestedSubqueries[2].Correlations, "IN")
+}
+
+// TestIR_NestedSubqueriesQuery checks that a subquery's own IR leaves its nested
+// subqueries to their own entries.
+func TestIR_NestedSubqueriesQuery(t *testing.T) {
+	ir := parseAssertNoError(t, "SELECT * FROM a WHERE a.id IN (SELECT c.a_id FROM c WHERE EXISTS (SELECT 1 FROM d WHERE d.c_id = c.id))")
+
+	require.Len(t, ir.NestedSubqueries, 2)
+	in, exists := ir.NestedSubqueries[0], ir.NestedSubqueries[1]
+	require.NotNil(t, in.Query)
+	assert.Equal(t, []TableRef{{Name: "c", Type: TableTypeBase, Raw: "c"}}, in.Query.Tables, "tables of the IN subquery only")
+	assert.Nil(t, in.Query.NestedSubqueries, "nested subqueries")
+	assert.Nil(t, in.Query.Scopes, "scopes")
+	assert.Equal(t, SubqueryExists, exists.Kind)
+	assert.Equal(t, 0, exists.Parent, "EXISTS is nested in the IN subquery")
+	require.NotNil(t, exists.Query)
+	assert.Equal(t, []TableRef{{Name: "d", Type: TableTypeBase, Raw: "d"}}, exists.Query.Tables)
+}
+
+// TestIR_NestedSubqueriesDML checks subqueries of an UPDATE.
+func TestIR_NestedSubqueriesDML(t *testing.T) {
+	ir := parseAssertNoError(t, `UPDATE orders o SET total = (SELECT sum(i.price) FROM items i WHERE i.order_id = o.id)
+WHERE o.user_id IN (SELECT id FROM users WHERE banned)`)
+
+	require.Len(t, ir.NestedSubqueries, 2)
+	assert.Equal(t, SubqueryScalar, ir.NestedSubqueries[0].Kind)
+	assert.Equal(t, SubqueryIn, ir.NestedSubqueries[1].Kind)
+	assert.Equal(t, [][]string{{"items"}, {"users"}}, subqueryTables(t, ir.NestedSubqueries))
+	assert.Equal(t, []JoinCorrelation{{OuterAlias: "o", InnerAlias: "i", Expression: "i.order_id = o.id", Type: "CORRELATED"}}, ir.Correlations)
+	assert.Empty(t, ir.NestedSubqueries[1].Correlations, "uncorrelated IN list")
+
+	ir = parseAssertNoError(t, "SELECT 1")
+	assert.Empty(t, ir.NestedSubqueries)
+}
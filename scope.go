@@ -0,0 +1,530 @@
+// scope.go records the query levels of a statement and the relations visible in each,
+// so column references can be resolved against the FROM clause they belong to.
+package postgresparser
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// buildScopes returns the scopes of a statement that reads or writes rows and its
+// subqueries, or nil for other statements. Scope 0 is the statement itself.
+func buildScopes(stmt gen.IStmtContext, tokens antlr.TokenStream) ([]QueryScope, []NestedSubquery) {
+	if stmt == nil {
+		return nil, nil
+	}
+	b := &scopeBuilder{tokens: tokens, hidden: make(map[int]bool), subqueryOf: make(map[int]int)}
+	switch {
+	case stmt.Selectstmt() != nil:
+		b.selectStmt(stmt.Selectstmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Insertstmt() != nil:
+		b.insertStmt(stmt.Insertstmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Updatestmt() != nil:
+		b.updateStmt(stmt.Updatestmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Deletestmt() != nil:
+		b.deleteStmt(stmt.Deletestmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Mergestmt() != nil:
+		b.mergeStmt(stmt.Mergestmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Createasstmt() != nil:
+		b.sourceStmt(stmt.Createasstmt().Selectstmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Viewstmt() != nil:
+		b.sourceStmt(stmt.Viewstmt().Selectstmt(), b.newScope(ScopeStatement, -1, stmt))
+	case stmt.Creatematviewstmt() != nil:
+		b.sourceStmt(stmt.Creatematviewstmt().Selectstmt(), b.newScope(ScopeStatement, -1, stmt))
+	default:
+		return nil, nil
+	}
+	return b.scopes, b.sortedSubqueries()
+}
+
+// scopeBuilder accumulates scopes while walking a statement.
+type scopeBuilder struct {
+	tokens antlr.TokenStream
+	scopes []QueryScope
+	hidden map[int]bool // CTE scopes whose own body is being built and that are not yet visible
+
+	subqueries []NestedSubquery
+	subqueryOf map[int]int // Index in subqueries of the subquery whose scope is the key
+}
+
+// newScope appends a scope covering ctx and returns its index.
+func (b *scopeBuilder) newScope(kind ScopeKind, parent int, ctx antlr.ParserRuleContext) int {
+	scope := QueryScope{Kind: kind, Parent: parent}
+	if start := ctx.GetStart(); start != nil {
+		scope.Start = start.GetStart()
+	}
+	if stop := ctx.GetStop(); stop != nil {
+		scope.End = stop.GetStop() + 1
+	}
+	b.scopes = append(b.scopes, scope)
+	return len(b.scopes) - 1
+}
+
+// addRelation appends a relation to scope idx and returns its index in Relations.
+func (b *scopeBuilder) addRelation(idx int, rel ScopeRelation) int {
+	b.scopes[idx].Relations = append(b.scopes[idx].Relations, rel)
+	return len(b.scopes[idx].Relations) - 1
+}
+
+// sourceStmt records the query of CREATE TABLE AS or CREATE [MATERIALIZED] VIEW as a
+// source scope of the statement.
+func (b *scopeBuilder) sourceStmt(sel gen.ISelectstmtContext, idx int) {
+	if sel == nil {
+		return
+	}
+	b.selectStmt(sel, b.newScope(ScopeSource, idx, sel))
+}
+
+// preparable walks the body of a CTE into scope idx.
+func (b *scopeBuilder) preparable(stmt gen.IPreparablestmtContext, idx int) {
+	if stmt == nil {
+		return
+	}
+	switch {
+	case stmt.Selectstmt() != nil:
+		b.selectStmt(stmt.Selectstmt(), idx)
+	case stmt.Insertstmt() != nil:
+		b.insertStmt(stmt.Insertstmt(), idx)
+	case stmt.Updatestmt() != nil:
+		b.updateStmt(stmt.Updatestmt(), idx)
+	case stmt.Deletestmt() != nil:
+		b.deleteStmt(stmt.Deletestmt(), idx)
+	}
+}
+
+// selectStmt walks a SELECT into scope idx.
+func (b *scopeBuilder) selectStmt(sel gen.ISelectstmtContext, idx int) {
+	if sel == nil {
+		return
+	}
+	if snp := sel.Select_no_parens(); snp != nil {
+		b.selectNoParens(snp, idx)
+	} else {
+		b.selectWithParens(sel.Select_with_parens(), idx)
+	}
+}
+
+// selectWithParens unwraps parentheses and walks the SELECT into scope idx.
+func (b *scopeBuilder) selectWithParens(swp gen.ISelect_with_parensContext, idx int) {
+	for swp != nil && swp.Select_with_parens() != nil {
+		swp = swp.Select_with_parens()
+	}
+	if swp != nil && swp.Select_no_parens() != nil {
+		b.selectNoParens(swp.Select_no_parens(), idx)
+	}
+}
+
+// selectNoParens walks the WITH clause, the set operation branches, and ORDER BY and
+// LIMIT of a SELECT. The first branch shares scope idx, later branches get their own.
+func (b *scopeBuilder) selectNoParens(snp gen.ISelect_no_parensContext, idx int) {
+	if w := snp.With_clause(); w != nil {
+		b.withClause(w, idx)
+	}
+	if sc := snp.Select_clause(); sc != nil {
+		first := true
+		for _, inter := range sc.AllSimple_select_intersect() {
+			for _, prim := range inter.AllSimple_select_pramary() {
+				target := idx
+				if !first {
+					target = b.newScope(ScopeSetOperation, idx, prim)
+				}
+				b.primary(prim, target)
+				first = false
+			}
+		}
+	}
+	b.expressions(snp.Sort_clause_(), idx)
+	b.expressions(snp.Select_limit(), idx)
+	b.expressions(snp.Select_limit_(), idx)
+}
+
+// withClause creates a scope per CTE. In WITH RECURSIVE every CTE is visible to every
+// body; otherwise a body only sees the CTEs declared before it.
+func (b *scopeBuilder) withClause(w gen.IWith_clauseContext, idx int) {
+	if w.Cte_list() == nil {
+		return
+	}
+	ctes := w.Cte_list().AllCommon_table_expr()
+	recursive := w.RECURSIVE() != nil
+	ids := make([]int, len(ctes))
+	newCTE := func(i int) {
+		cte := ctes[i]
+		ids[i] = b.newScope(ScopeCTE, idx, cte)
+		if body := cte.Preparablestmt(); body != nil {
+			b.scopes[ids[i]].Start = body.GetStart().GetStart()
+			b.scopes[ids[i]].End = body.GetStop().GetStop() + 1
+		}
+		b.scopes[ids[i]].Name = trimIdentQuotes(ruleText(cte.Name(), b.tokens))
+		if names := cte.Name_list_(); names != nil {
+			b.scopes[ids[i]].ColumnAliases = nameListNames(names.Name_list(), b.tokens)
+		}
+	}
+	if recursive {
+		for i := range ctes {
+			newCTE(i)
+		}
+	}
+	for i, cte := range ctes {
+		if !recursive {
+			newCTE(i)
+			b.hidden[ids[i]] = true
+		}
+		b.preparable(cte.Preparablestmt(), ids[i])
+		delete(b.hidden, ids[i])
+	}
+}
+
+// primary walks one SELECT, VALUES, or TABLE branch into scope idx.
+func (b *scopeBuilder) primary(prim gen.ISimple_select_pramaryContext, idx int) {
+	switch {
+	case prim.Select_with_parens() != nil:
+		b.selectWithParens(prim.Select_with_parens(), idx)
+	case prim.Values_clause() != nil:
+		// VALUES names its columns column1, column2, ...
+		if rows := prim.Values_clause().AllExpr_list(); len(rows) > 0 {
+			for i, expr := range rows[0].AllA_expr() {
+				b.scopes[idx].Columns = append(b.scopes[idx].Columns, SelectColumn{Expression: ruleText(expr, b.tokens), Alias: fmt.Sprintf("column%d", i+1)})
+			}
+		}
+		b.expressions(prim.Values_clause(), idx)
+	case prim.TABLE() != nil:
+		b.relation(prim.Relation_expr(), nil, idx)
+		b.scopes[idx].Columns = []SelectColumn{{Expression: "*"}}
+	default:
+		if from := prim.From_clause(); from != nil {
+			b.fromList(from.From_list(), idx)
+		}
+		targetList := prim.Target_list()
+		if targetList == nil && prim.Target_list_() != nil {
+			targetList = prim.Target_list_().Target_list()
+		}
+		b.scopes[idx].Columns = targetListColumns(targetList, b.tokens)
+		b.expressions(targetList, idx)
+		b.expressions(prim.Distinct_clause(), idx)
+		b.expressions(prim.Where_clause(), idx)
+		b.expressions(prim.Group_clause(), idx)
+		b.expressions(prim.Having_clause(), idx)
+		b.expressions(prim.Window_clause(), idx)
+	}
+}
+
+// fromList adds the relations and joins of a FROM list to scope idx.
+func (b *scopeBuilder) fromList(list gen.IFrom_listContext, idx int) {
+	if list == nil {
+		return
+	}
+	for _, ref := range list.AllTable_ref() {
+		b.tableRef(ref, idx)
+	}
+}
+
+// tableRef adds the relations of a FROM item to scope idx, recording its joins, and
+// returns the indexes of the relations it added.
+func (b *scopeBuilder) tableRef(ref gen.ITable_refContext, idx int) []int {
+	var left []int
+	switch {
+	case ref.Relation_expr() != nil:
+		left = append(left, b.relation(ref.Relation_expr(), ref.Alias_clause(), idx))
+		b.expressions(ref.Tablesample_clause(), idx)
+	case ref.Func_table() != nil:
+		name := ruleText(ref.Func_table(), b.tokens)
+		rel := ScopeRelation{
+			Table: TableRef{Name: name, Alias: aliasFromFuncAlias(ref.Func_alias_clause(), b.tokens), Type: TableTypeFunction, Raw: name},
+			Scope: -1,
+		}
+		if fa := ref.Func_alias_clause(); fa != nil {
+			if fa.Alias_clause() != nil {
+				rel.ColumnAliases = nameListNames(fa.Alias_clause().Name_list(), b.tokens)
+			} else if elems := fa.Tablefuncelementlist(); elems != nil {
+				for _, elem := range elems.AllTablefuncelement() {
+					rel.ColumnAliases = append(rel.ColumnAliases, trimIdentQuotes(ruleText(elem.Colid(), b.tokens)))
+				}
+			}
+		}
+		left = append(left, b.addRelation(idx, rel))
+		b.expressions(ref.Func_table(), idx)
+	case ref.Xmltable() != nil:
+		name := ruleText(ref.Xmltable(), b.tokens)
+		rel := ScopeRelation{
+			Table: TableRef{Name: name, Alias: aliasFromAliasClause(ref.Alias_clause(), b.tokens), Type: TableTypeFunction, Raw: name},
+			Scope: -1,
+		}
+		left = append(left, b.addRelation(idx, rel))
+		b.expressions(ref.Xmltable(), idx)
+	case ref.Select_with_parens() != nil:
+		swp := ref.Select_with_parens()
+		kind := ScopeDerived
+		if ref.LATERAL_P() != nil {
+			kind = ScopeLateral
+		}
+		alias := aliasFromAliasClause(ref.Alias_clause(), b.tokens)
+		sub := b.newScope(kind, idx, swp)
+		b.scopes[sub].Name = trimIdentQuotes(alias)
+		if kind == ScopeLateral {
+			b.addSubquery(SubqueryLateral, alias, swp, sub)
+		} else {
+			b.addSubquery(SubqueryDerived, alias, swp, sub)
+		}
+		if ac := ref.Alias_clause(); ac != nil {
+			b.scopes[sub].ColumnAliases = nameListNames(ac.Name_list(), b.tokens)
+		}
+		b.selectWithParens(swp, sub)
+		left = append(left, b.addRelation(idx, ScopeRelation{
+			Table:         TableRef{Name: alias, Alias: alias, Type: TableTypeSubquery, Raw: ruleText(swp, b.tokens)},
+			Scope:         sub,
+			ColumnAliases: b.scopes[sub].ColumnAliases,
+		}))
+	}
+
+	// Joins and parenthesized join trees follow as children in source order.
+	var pending *ScopeJoin
+	finish := func() {
+		b.scopes[idx].Joins = append(b.scopes[idx].Joins, *pending)
+		pending = nil
+	}
+	for _, child := range ref.GetChildren() {
+		switch c := child.(type) {
+		case antlr.TerminalNode:
+			switch c.GetSymbol().GetTokenType() {
+			case gen.PostgreSQLParserCROSS:
+				pending = &ScopeJoin{Type: "CROSS"}
+			case gen.PostgreSQLParserNATURAL:
+				pending = &ScopeJoin{Type: "INNER", Natural: true}
+			case gen.PostgreSQLParserJOIN:
+				if pending == nil {
+					pending = &ScopeJoin{Type: "INNER"}
+				}
+			}
+		case *gen.Join_typeContext:
+			if pending == nil {
+				pending = &ScopeJoin{}
+			}
+			pending.Type = joinTypeName(c)
+		case *gen.Table_refContext:
+			right := b.tableRef(c, idx)
+			if pending != nil {
+				pending.Left = slices.Clone(left)
+				pending.Right = right
+				if pending.Type == "CROSS" || pending.Natural {
+					finish()
+				}
+			}
+			left = append(left, right...)
+		case *gen.Join_qualContext:
+			if pending == nil {
+				continue
+			}
+			pending.Position = c.GetStart().GetStart()
+			if c.USING() != nil {
+				pending.Using = nameListNames(c.Name_list(), b.tokens)
+			} else {
+				b.expressions(c.A_expr(), idx)
+			}
+			finish()
+		}
+	}
+	return left
+}
+
+// relation adds a table reference to scope idx and returns its index. A name that
+// matches a visible CTE refers to that CTE's scope.
+func (b *scopeBuilder) relation(rel gen.IRelation_exprContext, alias gen.IAlias_clauseContext, idx int) int {
+	schema, name := splitQualifiedName(ruleText(rel.Qualified_name(), b.tokens))
+	out := ScopeRelation{
+		Table: TableRef{Schema: schema, Name: name, Alias: aliasFromAliasClause(alias, b.tokens), Type: TableTypeBase, Raw: ruleText(rel, b.tokens)},
+		Scope: -1,
+	}
+	if alias != nil {
+		out.ColumnAliases = nameListNames(alias.Name_list(), b.tokens)
+	}
+	if schema == "" {
+		if cte := b.visibleCTE(name, idx); cte >= 0 {
+			out.Table.Type = TableTypeCTE
+			out.Scope = cte
+		}
+	}
+	return b.addRelation(idx, out)
+}
+
+// visibleCTE returns the scope of the CTE called name that is visible from scope idx,
+// or -1.
+func (b *scopeBuilder) visibleCTE(name string, idx int) int {
+	name = trimIdentQuotes(name)
+	for s := idx; s >= 0; s = b.scopes[s].Parent {
+		for i, scope := range b.scopes {
+			if scope.Kind == ScopeCTE && scope.Parent == s && !b.hidden[i] && strings.EqualFold(scope.Name, name) {
+				return i
+			}
+		}
+	}
+	return -1
+}
+
+// expressions walks an expression tree and gives every parenthesized subquery in it a
+// subquery scope under idx.
+func (b *scopeBuilder) expressions(node antlr.Tree, idx int) {
+	if node == nil {
+		return
+	}
+	if swp, ok := node.(*gen.Select_with_parensContext); ok {
+		sub := b.newScope(ScopeSubquery, idx, swp)
+		b.addSubquery(expressionSubqueryKind(swp), "", swp, sub)
+		b.selectWithParens(swp, sub)
+		return
+	}
+	for _, child := range node.GetChildren() {
+		b.expressions(child, idx)
+	}
+}
+
+// optionalWith walks the optional WITH clause of a data-modifying statement.
+func (b *scopeBuilder) optionalWith(w gen.IWith_clause_Context, idx int) {
+	if w != nil && w.With_clause() != nil {
+		b.withClause(w.With_clause(), idx)
+	}
+}
+
+// returning sets the output columns of a data-modifying statement from RETURNING.
+func (b *scopeBuilder) returning(ret gen.IReturning_clauseContext, idx int) {
+	if ret == nil {
+		return
+	}
+	b.scopes[idx].Columns = targetListColumns(ret.Target_list(), b.tokens)
+	b.expressions(ret, idx)
+}
+
+// targetRelation adds the target table of UPDATE or DELETE to scope idx.
+func (b *scopeBuilder) targetRelation(target gen.IRelation_expr_opt_aliasContext, idx int) {
+	if target == nil || target.Relation_expr() == nil {
+		return
+	}
+	schema, name := splitQualifiedName(ruleText(target.Relation_expr().Qualified_name(), b.tokens))
+	b.addRelation(idx, ScopeRelation{
+		Table: TableRef{Schema: schema, Name: name, Alias: ruleText(target.Colid(), b.tokens), Type: TableTypeBase, Raw: ruleText(target.Relation_expr(), b.tokens)},
+		Scope: -1,
+	})
+}
+
+// insertStmt walks an INSERT into scope idx. The target is relation 0 and the inserted
+// query gets a source scope.
+func (b *scopeBuilder) insertStmt(ins gen.IInsertstmtContext, idx int) {
+	b.optionalWith(ins.With_clause_(), idx)
+	if target := ins.Insert_target(); target != nil {
+		schema, name := splitQualifiedName(ruleText(target.Qualified_name(), b.tokens))
+		b.addRelation(idx, ScopeRelation{
+			Table: TableRef{Schema: schema, Name: name, Alias: ruleText(target.Colid(), b.tokens), Type: TableTypeBase, Raw: ruleText(target.Qualified_name(), b.tokens)},
+			Scope: -1,
+		})
+	}
+	if rest := ins.Insert_rest(); rest != nil && rest.Selectstmt() != nil {
+		b.selectStmt(rest.Selectstmt(), b.newScope(ScopeSource, idx, rest.Selectstmt()))
+	}
+	b.expressions(ins.On_conflict_(), idx)
+	b.returning(ins.Returning_clause(), idx)
+}
+
+// updateStmt walks an UPDATE into scope idx. The target is relation 0, followed by
+// the FROM relations.
+func (b *scopeBuilder) updateStmt(up gen.IUpdatestmtContext, idx int) {
+	b.optionalWith(up.With_clause_(), idx)
+	b.targetRelation(up.Relation_expr_opt_alias(), idx)
+	if from := up.From_clause(); from != nil {
+		b.fromList(from.From_list(), idx)
+	}
+	b.expressions(up.Set_clause_list(), idx)
+	b.expressions(up.Where_or_current_clause(), idx)
+	b.returning(up.Returning_clause(), idx)
+}
+
+// deleteStmt walks a DELETE into scope idx. The target is relation 0, followed by the
+// USING relations.
+func (b *scopeBuilder) deleteStmt(del gen.IDeletestmtContext, idx int) {
+	b.optionalWith(del.With_clause_(), idx)
+	b.targetRelation(del.Relation_expr_opt_alias(), idx)
+	if using := del.Using_clause(); using != nil {
+		b.fromList(using.From_list(), idx)
+	}
+	b.expressions(del.Where_or_current_clause(), idx)
+	b.returning(del.Returning_clause(), idx)
+}
+
+// mergeStmt walks a MERGE into scope idx. The target is relation 0 and the source
+// relation 1.
+func (b *scopeBuilder) mergeStmt(m gen.IMergestmtContext, idx int) {
+	names := m.AllQualified_name()
+	if len(names) == 0 {
+		return
+	}
+	// An alias clause before USING belongs to the target, one after it to the source.
+	var targetAlias, sourceAlias gen.IAlias_clauseContext
+	usingAt := -1
+	if m.USING() != nil {
+		usingAt = m.USING().GetSymbol().GetTokenIndex()
+	}
+	for _, ac := range m.AllAlias_clause() {
+		if ac.GetStart().GetTokenIndex() < usingAt {
+			targetAlias = ac
+		} else {
+			sourceAlias = ac
+		}
+	}
+	schema, name := splitQualifiedName(ruleText(names[0], b.tokens))
+	b.addRelation(idx, ScopeRelation{
+		Table: TableRef{Schema: schema, Name: name, Alias: aliasFromAliasClause(targetAlias, b.tokens), Type: TableTypeBase, Raw: ruleText(names[0], b.tokens)},
+		Scope: -1,
+	})
+	switch {
+	case m.Select_with_parens() != nil:
+		swp := m.Select_with_parens()
+		alias := aliasFromAliasClause(sourceAlias, b.tokens)
+		sub := b.newScope(ScopeDerived, idx, swp)
+		b.scopes[sub].Name = trimIdentQuotes(alias)
+		b.addSubquery(SubqueryDerived, alias, swp, sub)
+		b.selectWithParens(swp, sub)
+		b.addRelation(idx, ScopeRelation{
+			Table: TableRef{Name: alias, Alias: alias, Type: TableTypeSubquery, Raw: ruleText(swp, b.tokens)},
+			Scope: sub,
+		})
+	case len(names) > 1:
+		schema, name := splitQualifiedName(ruleText(names[1], b.tokens))
+		b.addRelation(idx, ScopeRelation{
+			Table: TableRef{Schema: schema, Name: name, Alias: aliasFromAliasClause(sourceAlias, b.tokens), Type: TableTypeBase, Raw: ruleText(names[1], b.tokens)},
+			Scope: -1,
+		})
+	}
+	b.expressions(m.A_expr(), idx)
+	b.expressions(m.Merge_insert_clause(), idx)
+	b.expressions(m.Merge_update_clause(), idx)
+	b.expressions(m.Merge_delete_clause(), idx)
+}
+
+// joinTypeName returns INNER, LEFT, RIGHT, or FULL for a join_type.
+func joinTypeName(jt gen.IJoin_typeContext) string {
+	switch {
+	case jt.LEFT() != nil:
+		return "LEFT"
+	case jt.RIGHT() != nil:
+		return "RIGHT"
+	case jt.FULL() != nil:
+		return "FULL"
+	default:
+		return "INNER"
+	}
+}
+
+// nameListNames returns the unquoted names of an optional name_list.
+func nameListNames(list gen.IName_listContext, tokens antlr.TokenStream) []string {
+	if list == nil {
+		return nil
+	}
+	var out []string
+	for _, name := range list.AllName() {
+		out = append(out, trimIdentQuotes(ruleText(name, tokens)))
+	}
+	return out
+}
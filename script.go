@@ -0,0 +1,127 @@
+// script.go splits multi-statement scripts and parses their statements one by one.
+package postgresparser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/antlr4-go/antlr/v4"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// ScriptStatement is one statement of a multi-statement script.
+type ScriptStatement struct {
+	SQL  string // Statement text without the terminating semicolon
+	Line int    // 1-based script line on which the statement starts
+}
+
+// StatementError reports a statement of a script that failed to parse.
+type StatementError struct {
+	Line int    // 1-based script line on which the statement starts
+	SQL  string // Statement text
+	Err  error  // Error returned by ParseSQL
+}
+
+// Error prefixes the parse error with the statement's position in the script.
+func (e *StatementError) Error() string {
+	return fmt.Sprintf("statement at line %d: %v", e.Line, e.Err)
+}
+
+// Unwrap returns the underlying parse error.
+func (e *StatementError) Unwrap() error {
+	return e.Err
+}
+
+// SplitStatements splits a script into its semicolon-separated statements using the
+// SQL lexer, so semicolons inside string literals, dollar-quoted bodies, comments,
+// parentheses, and BEGIN ATOMIC ... END function bodies do not end a statement.
+// Empty statements are dropped.
+func SplitStatements(sql string) []ScriptStatement {
+	input := antlr.NewInputStream(sql)
+	lexer := gen.NewPostgreSQLLexer(input)
+	lexer.RemoveErrorListeners()
+
+	var (
+		out          []ScriptStatement
+		first, last  antlr.Token
+		prev         antlr.Token
+		parenDepth   int
+		atomicDepth  int
+		flushPending = func() {
+			if first != nil {
+				out = append(out, ScriptStatement{
+					SQL:  strings.TrimSpace(input.GetText(first.GetStart(), last.GetStop())),
+					Line: first.GetLine(),
+				})
+			}
+			first, last = nil, nil
+		}
+	)
+	for {
+		tok := lexer.NextToken()
+		if tok == nil || tok.GetTokenType() == antlr.TokenEOF {
+			break
+		}
+		if tok.GetChannel() != antlr.TokenDefaultChannel {
+			continue
+		}
+
+		switch tok.GetTokenType() {
+		case gen.PostgreSQLLexerOPEN_PAREN:
+			parenDepth++
+		case gen.PostgreSQLLexerCLOSE_PAREN:
+			if parenDepth > 0 {
+				parenDepth--
+			}
+		case gen.PostgreSQLLexerATOMIC:
+			if prev != nil && prev.GetTokenType() == gen.PostgreSQLLexerBEGIN_P {
+				atomicDepth++
+			}
+		case gen.PostgreSQLLexerCASE:
+			if atomicDepth > 0 {
+				atomicDepth++
+			}
+		case gen.PostgreSQLLexerEND_P:
+			if atomicDepth > 0 {
+				atomicDepth--
+			}
+		case gen.PostgreSQLLexerSEMI:
+			if parenDepth == 0 && atomicDepth == 0 {
+				flushPending()
+				prev = tok
+				continue
+			}
+		}
+
+		if first == nil {
+			first = tok
+		}
+		last = tok
+		prev = tok
+	}
+	flushPending()
+	return out
+}
+
+// ParseSQLAll parses every statement of a script, such as a migration or a pg_dump
+// file, and returns one ParsedQuery per statement in script order. Each statement is
+// parsed as by ParseSQL, so RawSQL and Parameters cover only that statement.
+// Statements the parser does not model (SET, GRANT, DO, ...) are returned
+// with Command UNKNOWN. The first statement that fails to parse stops the parse with a
+// *StatementError.
+func ParseSQLAll(sql string) ([]*ParsedQuery, error) {
+	stmts := SplitStatements(sql)
+	if len(stmts) == 0 {
+		return nil, ErrNoStatements
+	}
+	out := make([]*ParsedQuery, 0, len(stmts))
+	for _, stmt := range stmts {
+		res, err := ParseSQL(stmt.SQL)
+		if err != nil {
+			return nil, &StatementError{Line: stmt.Line, SQL: stmt.SQL, Err: err}
+		}
+		out = append(out, res)
+	}
+	return out, nil
+}
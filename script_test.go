@@ -0,0 +1,62 @@
+package postgresparser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSplitStatements(t *testing.T) {
+	sql := `-- leading comment; not a statement
+CREATE TABLE t (id int);
+
+CREATE FUNCTION f() RETURNS int LANGUAGE plpgsql AS $$
+BEGIN
+    RETURN 1;
+END;
+$$;
+CREATE FUNCTION g() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT CASE WHEN true THEN 1 END; END;
+INSERT INTO t VALUES (';'); ;
+SELECT 1`
+
+	stmts := SplitStatements(sql)
+	require.Len(t, stmts, 5, "statement count mismatch")
+	assert.Equal(t, ScriptStatement{SQL: "CREATE TABLE t (id int)", Line: 2}, stmts[0], "first statement mismatch")
+	assert.Equal(t, 4, stmts[1].Line, "function line mismatch")
+	assert.Contains(t, stmts[1].SQL, "RETURN 1;", "dollar-quoted body split")
+	assert.Equal(t, "CREATE FUNCTION g() RETURNS int LANGUAGE sql BEGIN ATOMIC SELECT CASE WHEN true THEN 1 END; END", stmts[2].SQL, "atomic body split")
+	assert.Equal(t, "INSERT INTO t VALUES (';')", stmts[3].SQL, "string literal split")
+	assert.Equal(t, ScriptStatement{SQL: "SELECT 1", Line: 11}, stmts[4], "last statement mismatch")
+}
+
+func TestParseSQLAll(t *testing.T) {
+	results, err := ParseSQLAll(`CREATE TABLE users (id int PRIMARY KEY);
+SET search_path = app;
+UPDATE users SET id = $2 WHERE id = $1;
+SELECT * FROM users WHERE id = ?;`)
+	require.NoError(t, err, "parse failed")
+	require.Len(t, results, 4, "result count mismatch")
+
+	assert.Equal(t, QueryCommandDDL, results[0].Command, "expected DDL")
+	assert.Equal(t, "CREATE TABLE users (id int PRIMARY KEY)", results[0].RawSQL, "raw SQL mismatch")
+	assert.Equal(t, QueryCommandUnknown, results[1].Command, "expected unmodelled SET")
+	assert.Equal(t, QueryCommandUpdate, results[2].Command, "expected UPDATE")
+	require.Len(t, results[2].Parameters, 2, "UPDATE parameter count mismatch")
+	require.Len(t, results[3].Parameters, 1, "SELECT parameter count mismatch")
+	assert.Equal(t, 1, results[3].Parameters[0].Position, "anonymous parameters are numbered per statement")
+}
+
+func TestParseSQLAllErrors(t *testing.T) {
+	_, err := ParseSQLAll("  \n-- only a comment\n")
+	assert.ErrorIs(t, err, ErrNoStatements, "expected ErrNoStatements")
+
+	_, err = ParseSQLAll("SELECT 1;\n\nSELEC 2;\nSELECT 3;")
+	var stmtErr *StatementError
+	require.True(t, errors.As(err, &stmtErr), "expected *StatementError, got %v", err)
+	assert.Equal(t, 3, stmtErr.Line, "error line mismatch")
+	assert.Equal(t, "SELEC 2", stmtErr.SQL, "failing statement mismatch")
+	var parseErr *ParseErrors
+	assert.True(t, errors.As(err, &parseErr), "expected wrapped *ParseErrors")
+}
@@ -56,6 +56,10 @@ func populateSelectFromResolvedNested(result *ParsedQuery, withClause gen.IWith_
 
 	extractProjection(result, simple, tokens)
 	extractFromClause(result, simple.From_clause(), tokens, cteNames)
+	if rel := simple.Relation_expr(); rel != nil {
+		// TABLE name is shorthand for SELECT * FROM name.
+		result.Tables = append(result.Tables, relationTableRef(rel, "", tokens, cteNames))
+	}
 	extractWhereClause(result, simple.Where_clause(), tokens)
 	extractHavingClause(result, simple.Having_clause(), tokens)
 	extractGroupClause(result, simple.Group_clause(), tokens)
@@ -74,6 +78,85 @@ func populateSelectFromResolvedNested(result *ParsedQuery, withClause gen.IWith_
 	return nil
 }
 
+// buildSelectQuery parses a SELECT statement into a standalone ParsedQuery, used for
+// the Source of INSERT ... SELECT, CREATE TABLE AS, and SELECT INTO.
+func buildSelectQuery(selectCtx gen.ISelectstmtContext, tokens antlr.TokenStream) (*ParsedQuery, error) {
+	if selectCtx == nil {
+		return nil, fmt.Errorf("select statement: %w", ErrNilContext)
+	}
+	parsed := &ParsedQuery{
+		Command:        QueryCommandSelect,
+		RawSQL:         ruleText(selectCtx, tokens),
+		DerivedColumns: make(map[string]string),
+	}
+	if err := populateSelect(parsed, selectCtx, tokens); err != nil {
+		return nil, err
+	}
+	return parsed, nil
+}
+
+// populateSelectInto records the table created by SELECT ... INTO as Target, with the
+// query's own relations and projection available as Source.
+func populateSelectInto(result *ParsedQuery, selectCtx gen.ISelectstmtContext, tokens antlr.TokenStream) error {
+	_, simple, _, err := resolveSelect(selectCtx)
+	if err != nil || simple == nil {
+		return err
+	}
+	into := simple.Into_clause()
+	if into == nil || into.OpttempTableName() == nil {
+		return nil
+	}
+	nameCtx := into.OpttempTableName()
+	tbl := tableRefFromQualifiedName(nameCtx.Qualified_name(), tokens)
+	result.Target = &tbl
+	result.Tables = append(result.Tables, tbl)
+
+	flags := []string{"SELECT_INTO"}
+	switch {
+	case nameCtx.UNLOGGED() != nil:
+		flags = append(flags, "UNLOGGED")
+	case nameCtx.TEMP() != nil || nameCtx.TEMPORARY() != nil:
+		flags = append(flags, "TEMPORARY")
+	}
+	result.DDLActions = append(result.DDLActions, DDLAction{
+		Type:       DDLCreateTable,
+		ObjectName: tbl.Name,
+		Schema:     tbl.Schema,
+		Flags:      flags,
+	})
+
+	source, err := buildSelectQuery(selectCtx, tokens)
+	if err != nil {
+		return err
+	}
+	source.RawSQL = withoutIntoClause(source.RawSQL, selectCtx.GetStart().GetStart(), into)
+	result.Source = source
+	return nil
+}
+
+// withoutIntoClause removes the INTO clause from raw, the text of a SELECT starting at
+// character offset start, leaving the query that produces the rows.
+func withoutIntoClause(raw string, start int, into gen.IInto_clauseContext) string {
+	runes := []rune(raw)
+	from, to := into.GetStart().GetStart()-start, into.GetStop().GetStop()+1-start
+	if from < 0 || to > len(runes) || from >= to {
+		return raw
+	}
+	return strings.TrimRightFunc(string(runes[:from]), unicode.IsSpace) + string(runes[to:])
+}
+
+// tableRefFromQualifiedName builds a base TableRef from a qualified_name context.
+func tableRefFromQualifiedName(qn gen.IQualified_nameContext, tokens antlr.TokenStream) TableRef {
+	raw := ruleText(qn, tokens)
+	schema, name := splitQualifiedName(raw)
+	return TableRef{
+		Schema: schema,
+		Name:   name,
+		Type:   TableTypeBase,
+		Raw:    raw,
+	}
+}
+
 // resolveSelect unwraps nested structures to expose the primary SELECT clauses.
 func resolveSelect(selectCtx gen.ISelectstmtContext) (gen.IWith_clauseContext, gen.ISimple_select_pramaryContext, gen.ISelect_no_parensContext, error) {
 	if selectCtx == nil {
@@ -81,19 +164,7 @@ func resolveSelect(selectCtx gen.ISelectstmtContext) (gen.IWith_clauseContext, g
 	}
 
 	if snp := selectCtx.Select_no_parens(); snp != nil {
-		selectClause := snp.Select_clause()
-		if selectClause == nil {
-			return snp.With_clause(), nil, snp, fmt.Errorf("missing select clause")
-		}
-		simpleIntersect := selectClause.Simple_select_intersect(0)
-		if simpleIntersect == nil {
-			return snp.With_clause(), nil, snp, fmt.Errorf("missing simple select")
-		}
-		simple := simpleIntersect.Simple_select_pramary(0)
-		if simple == nil {
-			return snp.With_clause(), nil, snp, fmt.Errorf("missing simple select primary")
-		}
-		return snp.With_clause(), simple, snp, nil
+		return resolveSelectNoParens(snp)
 	}
 
 	if swp := selectCtx.Select_with_parens(); swp != nil {
@@ -103,24 +174,45 @@ func resolveSelect(selectCtx gen.ISelectstmtContext) (gen.IWith_clauseContext, g
 	return nil, nil, nil, fmt.Errorf("unable to resolve select statement")
 }
 
+// resolveSelectNoParens exposes the first primary of snp. ANTLR parses a parenthesized
+// SELECT such as the source of INSERT INTO t (SELECT ...) as a select_no_parens whose
+// only primary is the parenthesized query; that form resolves to the inner query.
+func resolveSelectNoParens(snp gen.ISelect_no_parensContext) (gen.IWith_clauseContext, gen.ISimple_select_pramaryContext, gen.ISelect_no_parensContext, error) {
+	selectClause := snp.Select_clause()
+	if selectClause == nil {
+		return snp.With_clause(), nil, snp, fmt.Errorf("missing select clause")
+	}
+	simpleIntersect := selectClause.Simple_select_intersect(0)
+	if simpleIntersect == nil {
+		return snp.With_clause(), nil, snp, fmt.Errorf("missing simple select")
+	}
+	simple := simpleIntersect.Simple_select_pramary(0)
+	if simple == nil {
+		return snp.With_clause(), nil, snp, fmt.Errorf("missing simple select primary")
+	}
+	if swp := simple.Select_with_parens(); swp != nil && onlyPrimary(snp) {
+		return resolveSelectFromParens(swp)
+	}
+	return snp.With_clause(), simple, snp, nil
+}
+
+// onlyPrimary reports whether snp is a single primary without WITH, set operations,
+// ORDER BY, LIMIT, or locking clauses.
+func onlyPrimary(snp gen.ISelect_no_parensContext) bool {
+	if snp.With_clause() != nil || snp.Sort_clause_() != nil || snp.Select_limit() != nil ||
+		snp.Select_limit_() != nil || snp.For_locking_clause() != nil || snp.For_locking_clause_() != nil {
+		return false
+	}
+	intersects := snp.Select_clause().AllSimple_select_intersect()
+	return len(intersects) == 1 && len(intersects[0].AllSimple_select_pramary()) == 1
+}
+
 // resolveSelectFromParens collapses parenthesised selects until a base form is reached.
 func resolveSelectFromParens(swp gen.ISelect_with_parensContext) (gen.IWith_clauseContext, gen.ISimple_select_pramaryContext, gen.ISelect_no_parensContext, error) {
 	current := swp
 	for current != nil {
 		if inner := current.Select_no_parens(); inner != nil {
-			selectClause := inner.Select_clause()
-			if selectClause == nil {
-				return nil, nil, nil, fmt.Errorf("missing select clause")
-			}
-			simpleIntersect := selectClause.Simple_select_intersect(0)
-			if simpleIntersect == nil {
-				return nil, nil, nil, fmt.Errorf("missing simple select")
-			}
-			simple := simpleIntersect.Simple_select_pramary(0)
-			if simple == nil {
-				return nil, nil, nil, fmt.Errorf("missing simple select primary")
-			}
-			return nil, simple, inner, nil
+			return resolveSelectNoParens(inner)
 		}
 		current = current.Select_with_parens()
 	}
@@ -139,6 +231,7 @@ func extractCTEs(withCtx gen.IWith_clauseContext, tokens antlr.TokenStream) ([]C
 	}
 	commonExprs := listCtx.AllCommon_table_expr()
 	ctes := make([]CTE, 0, len(commonExprs))
+	bodies := make([]gen.IPreparablestmtContext, 0, len(commonExprs))
 	var allTables []TableRef
 
 	for _, cteCtx := range commonExprs {
@@ -174,17 +267,26 @@ func extractCTEs(withCtx gen.IWith_clauseContext, tokens antlr.TokenStream) ([]C
 		if name == "" {
 			name = fmt.Sprintf("cte_%d", len(ctes)+1)
 		}
+		var columns []string
+		if names := cteCtx.Name_list_(); names != nil {
+			columns = nameListNames(names.Name_list(), tokens)
+		}
 		ctes = append(ctes, CTE{
 			Name:         name,
 			Query:        query,
 			Materialized: materialized,
+			Columns:      columns,
+			Recursive:    withCtx.RECURSIVE() != nil,
 		})
+		bodies = append(bodies, cteCtx.Preparablestmt())
 	}
+	parseCTEBodies(ctes, bodies, tokens)
 
 	return ctes, allTables
 }
 
-// extractTablesFromPreparableStmt extracts table references from a preparable statement (used in CTEs)
+// extractTablesFromPreparableStmt extracts table references from a preparable statement (used in CTEs),
+// including the targets of INSERT, UPDATE, and DELETE.
 func extractTablesFromPreparableStmt(stmt gen.IPreparablestmtContext, tokens antlr.TokenStream) []TableRef {
 	if stmt == nil {
 		return nil
@@ -251,7 +353,20 @@ func extractTablesFromPreparableStmt(stmt gen.IPreparablestmtContext, tokens ant
 			}
 		}
 	}
-	// Other statement types (INSERT, UPDATE, DELETE) could be handled here if needed
+	// Data-modifying statements read and write their own tables.
+	dml := &ParsedQuery{}
+	var err error
+	switch {
+	case stmt.Insertstmt() != nil:
+		err = populateInsert(dml, stmt.Insertstmt(), tokens)
+	case stmt.Updatestmt() != nil:
+		err = populateUpdate(dml, stmt.Updatestmt(), tokens)
+	case stmt.Deletestmt() != nil:
+		err = populateDelete(dml, stmt.Deletestmt(), tokens)
+	}
+	if err == nil {
+		tables = append(tables, dml.Tables...)
+	}
 
 	return tables
 }
@@ -265,6 +380,10 @@ func extractProjection(result *ParsedQuery, simple gen.ISimple_select_pramaryCon
 	if targetList == nil && simple.Target_list_() != nil {
 		targetList = simple.Target_list_().Target_list()
 	}
+	if simple.TABLE() != nil {
+		result.Columns = append(result.Columns, SelectColumn{Expression: "*"})
+		return
+	}
 	if targetList == nil && simple.Select_with_parens() != nil {
 		_, nestedSimple, _, err := resolveSelectFromParens(simple.Select_with_parens())
 		if err == nil {
@@ -276,6 +395,29 @@ func extractProjection(result *ParsedQuery, simple gen.ISimple_select_pramaryCon
 		return
 	}
 
+	for _, item := range targetList.AllTarget_el() {
+		if col, ok := item.(*gen.Target_labelContext); ok && col.A_expr() != nil {
+			findAndRecordUsage(result, col.A_expr(), ColumnUsageTypeProjection, tokens)
+		}
+	}
+	for _, col := range targetListColumns(targetList, tokens) {
+		result.Columns = append(result.Columns, col)
+		// Track derived columns (alias -> expression mapping)
+		if col.Alias != "" && col.Expression != "" && col.Alias != col.Expression {
+			result.DerivedColumns[col.Alias] = col.Expression
+		}
+	}
+
+	// Extract window functions from the projection
+	extractWindowFunctions(result, targetList, tokens)
+}
+
+// targetListColumns returns the output columns of a target list.
+func targetListColumns(targetList gen.ITarget_listContext, tokens antlr.TokenStream) []SelectColumn {
+	if targetList == nil {
+		return nil
+	}
+	var cols []SelectColumn
 	for _, item := range targetList.AllTarget_el() {
 		switch col := item.(type) {
 		case *gen.Target_labelContext:
@@ -284,7 +426,6 @@ func extractProjection(result *ParsedQuery, simple gen.ISimple_select_pramaryCon
 				if prc, ok := col.A_expr().(antlr.ParserRuleContext); ok {
 					expr = strings.TrimSpace(ctxText(tokens, prc))
 				}
-				findAndRecordUsage(result, col.A_expr(), ColumnUsageTypeProjection, tokens)
 			}
 			alias := ""
 			switch {
@@ -297,29 +438,16 @@ func extractProjection(result *ParsedQuery, simple gen.ISimple_select_pramaryCon
 					alias = strings.TrimSpace(ctxText(tokens, prc))
 				}
 			}
-			result.Columns = append(result.Columns, SelectColumn{
-				Expression: expr,
-				Alias:      alias,
-			})
-			// Track derived columns (alias -> expression mapping)
-			if alias != "" && expr != "" && alias != expr {
-				result.DerivedColumns[alias] = expr
-			}
+			cols = append(cols, SelectColumn{Expression: expr, Alias: alias})
 		case *gen.Target_starContext:
-			result.Columns = append(result.Columns, SelectColumn{
-				Expression: strings.TrimSpace(ctxText(tokens, col)),
-			})
+			cols = append(cols, SelectColumn{Expression: strings.TrimSpace(ctxText(tokens, col))})
 		default:
 			if prc, ok := col.(antlr.ParserRuleContext); ok {
-				result.Columns = append(result.Columns, SelectColumn{
-					Expression: strings.TrimSpace(ctxText(tokens, prc)),
-				})
+				cols = append(cols, SelectColumn{Expression: strings.TrimSpace(ctxText(tokens, prc))})
 			}
 		}
 	}
-
-	// Extract window functions from the projection
-	extractWindowFunctions(result, targetList, tokens)
+	return cols
 }
 
 // extractFromClause walks a FROM clause to collect table references.
@@ -340,6 +468,34 @@ func extractFromList(result *ParsedQuery, listCtx gen.IFrom_listContext, tokens
 	}
 }
 
+// relationTableRef builds the reference to a relation named in FROM or TABLE, typed as
+// a CTE when cteNames has its name.
+func relationTableRef(rel gen.IRelation_exprContext, alias string, tokens antlr.TokenStream, cteNames map[string]struct{}) TableRef {
+	name := ""
+	if rel.Qualified_name() != nil {
+		if prc, ok := rel.Qualified_name().(antlr.ParserRuleContext); ok {
+			name = strings.TrimSpace(ctxText(tokens, prc))
+		}
+	}
+	schema, relation := splitQualifiedName(name)
+	tableType := TableTypeBase
+	if _, ok := cteNames[strings.ToLower(relation)]; ok {
+		tableType = TableTypeCTE
+	}
+
+	rawText := ""
+	if prc, ok := rel.(antlr.ParserRuleContext); ok {
+		rawText = strings.TrimSpace(ctxText(tokens, prc))
+	}
+	return TableRef{
+		Schema: schema,
+		Name:   relation,
+		Alias:  alias,
+		Type:   tableType,
+		Raw:    rawText,
+	}
+}
+
 // collectTableRefs registers table, function, or subquery references within a join tree.
 func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens antlr.TokenStream, cteNames map[string]struct{}) {
 	if ref == nil {
@@ -347,30 +503,8 @@ func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens ant
 	}
 
 	if rel := ref.Relation_expr(); rel != nil {
-		name := ""
-		if rel.Qualified_name() != nil {
-			if prc, ok := rel.Qualified_name().(antlr.ParserRuleContext); ok {
-				name = strings.TrimSpace(ctxText(tokens, prc))
-			}
-		}
-		schema, relation := splitQualifiedName(name)
 		alias := aliasFromAliasClause(ref.Alias_clause(), tokens)
-		tableType := TableTypeBase
-		if _, ok := cteNames[strings.ToLower(relation)]; ok {
-			tableType = TableTypeCTE
-		}
-
-		rawText := ""
-		if prc, ok := rel.(antlr.ParserRuleContext); ok {
-			rawText = strings.TrimSpace(ctxText(tokens, prc))
-		}
-		result.Tables = append(result.Tables, TableRef{
-			Schema: schema,
-			Name:   relation,
-			Alias:  alias,
-			Type:   tableType,
-			Raw:    rawText,
-		})
+		result.Tables = append(result.Tables, relationTableRef(rel, alias, tokens, cteNames))
 	} else if fn := ref.Func_table(); fn != nil {
 		tableName := ""
 		if prc, ok := fn.(antlr.ParserRuleContext); ok {
@@ -422,7 +556,7 @@ func collectTableRefs(result *ParsedQuery, ref gen.ITable_refContext, tokens ant
 			result.JoinConditions = append(result.JoinConditions, clauseText)
 		}
 		if join.USING() != nil {
-			recordUsingJoinFromString(result, clauseText)
+			recordUsingJoinFromString(result, clauseText, joinCtx.GetStart().GetStart())
 		} else {
 			findAndRecordUsage(result, joinCtx, ColumnUsageTypeJoin, tokens)
 		}
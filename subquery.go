@@ -0,0 +1,165 @@
+// subquery.go records the subqueries of a statement, parses each into its own IR, and
+// links the column references that correlate them with the enclosing query.
+package postgresparser
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/valkdb/postgresparser/gen"
+)
+
+// addSubquery records swp, whose scope is sub, as a subquery of the given kind.
+func (b *scopeBuilder) addSubquery(kind SubqueryKind, alias string, swp gen.ISelect_with_parensContext, sub int) {
+	nested := NestedSubquery{Kind: kind, Alias: alias, Scope: sub, Parent: -1, Position: b.scopes[sub].Start}
+	for p := b.scopes[sub].Parent; p >= 0; p = b.scopes[p].Parent {
+		if i, ok := b.subqueryOf[p]; ok {
+			nested.Parent = i
+			break
+		}
+	}
+	if ref, err := buildSubqueryRefWithResult(alias, swp, b.tokens, nil); err == nil && ref != nil {
+		nested.Query = ref.Query
+	}
+	b.subqueryOf[sub] = len(b.subqueries)
+	b.subqueries = append(b.subqueries, nested)
+}
+
+// sortedSubqueries returns the recorded subqueries in order of position. The builder
+// walks FROM before the SELECT list, so they are recorded out of order.
+func (b *scopeBuilder) sortedSubqueries() []NestedSubquery {
+	order := make([]int, len(b.subqueries))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(x, y int) int { return b.subqueries[x].Position - b.subqueries[y].Position })
+	newIndex := make([]int, len(order))
+	for to, from := range order {
+		newIndex[from] = to
+	}
+	out := make([]NestedSubquery, len(order))
+	for to, from := range order {
+		out[to] = b.subqueries[from]
+		if out[to].Parent >= 0 {
+			out[to].Parent = newIndex[out[to].Parent]
+		}
+	}
+	return out
+}
+
+// expressionSubqueryKind classifies a subquery inside an expression by the construct
+// around it.
+func expressionSubqueryKind(swp *gen.Select_with_parensContext) SubqueryKind {
+	switch parent := swp.GetParent().(type) {
+	case *gen.In_expr_selectContext:
+		return SubqueryIn
+	case *gen.C_expr_existsContext:
+		return SubqueryExists
+	case *gen.A_expr_compareContext:
+		return SubqueryQuantified
+	case *gen.C_expr_exprContext:
+		if parent.ARRAY() != nil {
+			return SubqueryArray
+		}
+	}
+	return SubqueryScalar
+}
+
+// linkSubqueryCorrelations appends a correlation for each qualified column reference
+// inside a subquery to a relation outside it, and links it from every subquery the
+// reference reaches out of. References to the relations before a LATERAL subquery are
+// LATERAL correlations; the others are CORRELATED.
+func linkSubqueryCorrelations(res *ParsedQuery) {
+	if len(res.NestedSubqueries) == 0 {
+		return
+	}
+	subqueryOf := make(map[int]int, len(res.NestedSubqueries))
+	for i, sub := range res.NestedSubqueries {
+		subqueryOf[sub.Scope] = i
+	}
+	seen := make(map[int]bool)
+	for _, use := range res.ColumnUsage {
+		if use.TableAlias == "" || seen[use.Position] {
+			continue
+		}
+		seen[use.Position] = true
+		inner := innermostScopeAt(res.Scopes, use.Position)
+		owner := relationOwner(res.Scopes, inner, use.TableAlias)
+		if owner < 0 {
+			continue
+		}
+		var crossed []int
+		for s := inner; s != owner; s = res.Scopes[s].Parent {
+			if i, ok := subqueryOf[s]; ok {
+				crossed = append(crossed, i)
+			}
+		}
+		if len(crossed) == 0 {
+			continue
+		}
+		corr := JoinCorrelation{OuterAlias: use.TableAlias, Type: "CORRELATED"}
+		if res.NestedSubqueries[crossed[len(crossed)-1]].Kind == SubqueryLateral {
+			corr.Type = "LATERAL"
+		}
+		// The innermost subquery's own usage holds the comparison the reference is in.
+		innermost := res.NestedSubqueries[crossed[0]]
+		corr.Expression = use.Expression
+		if innermost.Query != nil {
+			for _, nu := range innermost.Query.ColumnUsage {
+				if nu.Position == use.Position && nu.Context != "" {
+					corr.Expression = nu.Context
+				}
+			}
+			for _, nu := range innermost.Query.ColumnUsage {
+				if nu.Context == corr.Expression && nu.TableAlias != "" && nu.Position != use.Position &&
+					scopeWithin(res.Scopes, relationOwner(res.Scopes, innermostScopeAt(res.Scopes, nu.Position), nu.TableAlias), innermost.Scope) {
+					corr.InnerAlias = nu.TableAlias
+					break
+				}
+			}
+		}
+		for _, i := range crossed {
+			res.NestedSubqueries[i].Correlations = append(res.NestedSubqueries[i].Correlations, len(res.Correlations))
+		}
+		res.Correlations = append(res.Correlations, corr)
+	}
+}
+
+// innermostScopeAt returns the innermost scope containing character offset pos, or -1.
+func innermostScopeAt(scopes []QueryScope, pos int) int {
+	best := -1
+	for i, sc := range scopes {
+		if pos >= sc.Start && pos < sc.End && (best < 0 || sc.End-sc.Start <= scopes[best].End-scopes[best].Start) {
+			best = i
+		}
+	}
+	return best
+}
+
+// scopeWithin reports whether scope idx is ancestor or one of the scopes nested in it.
+func scopeWithin(scopes []QueryScope, idx, ancestor int) bool {
+	for s := idx; s >= 0; s = scopes[s].Parent {
+		if s == ancestor {
+			return true
+		}
+	}
+	return false
+}
+
+// relationOwner returns the scope, from idx outward, whose relations include one that
+// qualifier names, or -1.
+func relationOwner(scopes []QueryScope, idx int, qualifier string) int {
+	qualifier = trimIdentQuotes(qualifier)
+	for s := idx; s >= 0; s = scopes[s].Parent {
+		for _, rel := range scopes[s].Relations {
+			name := rel.Table.Alias
+			if name == "" {
+				name = rel.Table.Name
+			}
+			if strings.EqualFold(trimIdentQuotes(name), qualifier) {
+				return s
+			}
+		}
+	}
+	return -1
+}